@@ -2,16 +2,23 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/stats"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+const (
+	defaultAdminAlertRulesLimit = 100
+	maxAdminAlertRulesLimit     = 1000
+)
+
 // swagger:route GET /admin/settings admin adminGetSettings
 //
 // Fetch settings.
@@ -55,6 +62,95 @@ func (hs *HTTPServer) AdminGetStats(c *contextmodel.ReqContext) response.Respons
 	return response.JSON(http.StatusOK, statsQuery.Result)
 }
 
+// swagger:route GET /admin/ngalert/stats admin adminGetAlertingStats
+//
+// Fetch the stats of Grafana Alerting.
+//
+// Returns the state of the scheduler, the statistics of the rule evaluations and notifications since Grafana started,
+// and the number of alert instances in every state. If Grafana Alerting is disabled, only `enabled: false` and a message
+// are returned. Only Grafana admins can fetch them.
+//
+// Responses:
+// 200: adminGetAlertingStatsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminGetAlertingStats(c *contextmodel.ReqContext) response.Response {
+	if hs.AlertNG == nil || hs.AlertNG.IsDisabled() {
+		return response.JSON(http.StatusOK, apimodels.AlertingStats{
+			Enabled: false,
+			Message: "Grafana Alerting is disabled",
+		})
+	}
+	result, err := hs.AlertNG.GetStats(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get alerting stats", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// swagger:route GET /admin/ngalert/rules admin adminGetAlertRules
+//
+// Fetch the alert rules of all organizations.
+//
+// Returns a page of the alert rules of all organizations with the names of their organizations, ordered by organization.
+// If `datasourceUid` is set, only the rules whose queries read the data source are returned. Only Grafana admins can
+// fetch them.
+//
+// Responses:
+// 200: adminGetAlertRulesResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) AdminGetAlertRules(c *contextmodel.ReqContext) response.Response {
+	if hs.AlertNG == nil || hs.AlertNG.IsDisabled() {
+		return response.Error(http.StatusNotFound, "Grafana Alerting is disabled", nil)
+	}
+	page := c.QueryInt64("page")
+	if page == 0 {
+		page = 1
+	}
+	limit := c.QueryInt64("limit")
+	if limit == 0 {
+		limit = defaultAdminAlertRulesLimit
+	}
+	if page < 0 || limit < 0 || limit > maxAdminAlertRulesLimit {
+		return response.Error(http.StatusBadRequest, fmt.Sprintf("page must be positive and limit must be between 1 and %d", maxAdminAlertRulesLimit), nil)
+	}
+	result, err := hs.AlertNG.ListRulesAcrossOrgs(c.Req.Context(), c.Query("datasourceUid"), page, limit)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to get alert rules", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// swagger:route POST /admin/ngalert/settings/reload admin adminReloadAlertingSettings
+//
+// Reload the settings of alerting.
+//
+// Reads the configuration again and applies the settings of alerting that can be changed without a restart. The
+// scheduler applies its settings at its next tick. Returns the changed settings that were applied and those that require
+// a restart. Only Grafana admins can reload the settings. Sending SIGHUP to the Grafana process reloads them too.
+//
+// Responses:
+// 200: adminReloadAlertingSettingsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) AdminReloadAlertingSettings(c *contextmodel.ReqContext) response.Response {
+	if hs.AlertNG == nil || hs.AlertNG.IsDisabled() {
+		return response.Error(http.StatusNotFound, "Grafana Alerting is disabled", nil)
+	}
+	result, err := hs.AlertNG.ReloadSettings(c.Req.Context())
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to reload alerting settings", err)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 func (hs *HTTPServer) getAuthorizedSettings(ctx context.Context, user *user.SignedInUser, bag setting.SettingsBag) (setting.SettingsBag, error) {
 	if hs.AccessControl.IsDisabled() {
 		return bag, nil
@@ -111,3 +207,36 @@ type GetStatsResponse struct {
 	// in:body
 	Body stats.AdminStats `json:"body"`
 }
+
+// swagger:parameters adminGetAlertRules
+type AdminGetAlertRulesParams struct {
+	// in:query
+	// required:false
+	DatasourceUID string `json:"datasourceUid"`
+	// in:query
+	// required:false
+	// default:1
+	Page int64 `json:"page"`
+	// in:query
+	// required:false
+	// default:100
+	Limit int64 `json:"limit"`
+}
+
+// swagger:response adminGetAlertRulesResponse
+type GetAlertRulesResponse struct {
+	// in:body
+	Body apimodels.AdminAlertRules `json:"body"`
+}
+
+// swagger:response adminReloadAlertingSettingsResponse
+type ReloadAlertingSettingsResponse struct {
+	// in:body
+	Body apimodels.AlertingSettingsReload `json:"body"`
+}
+
+// swagger:response adminGetAlertingStatsResponse
+type GetAlertingStatsResponse struct {
+	// in:body
+	Body apimodels.AlertingStats `json:"body"`
+}
@@ -10,8 +10,12 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/db/dbtest"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/ngalert"
+	"github.com/grafana/grafana/pkg/services/org"
 	"github.com/grafana/grafana/pkg/services/stats/statstest"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web/webtest"
 )
 
@@ -164,3 +168,124 @@ func TestAdmin_AccessControl(t *testing.T) {
 		})
 	}
 }
+
+func TestAdmin_GetAlertingStats(t *testing.T) {
+	tests := []struct {
+		desc         string
+		admin        bool
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			desc:         "should return that alerting is disabled to Grafana admins",
+			admin:        true,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"enabled":false,"message":"Grafana Alerting is disabled"}`,
+		},
+		{
+			desc:         "should return 403 to users that are not Grafana admins",
+			admin:        false,
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.Cfg.UnifiedAlerting.Enabled = util.Pointer(false)
+				hs.AlertNG = &ngalert.AlertNG{Cfg: hs.Cfg}
+			})
+
+			signedInUser := &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: org.RoleAdmin, IsGrafanaAdmin: tt.admin}
+			res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/admin/ngalert/stats"), signedInUser))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			if tt.expectedBody != "" {
+				body, err := io.ReadAll(res.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, tt.expectedBody, string(body))
+			}
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+func TestAdmin_GetAlertRules(t *testing.T) {
+	tests := []struct {
+		desc         string
+		admin        bool
+		orgRole      org.RoleType
+		expectedCode int
+	}{
+		{
+			desc:         "should return 404 to Grafana admins if alerting is disabled",
+			admin:        true,
+			orgRole:      org.RoleViewer,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			desc:         "should return 403 to org admins that are not Grafana admins",
+			orgRole:      org.RoleAdmin,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "should return 403 to editors",
+			orgRole:      org.RoleEditor,
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.Cfg.UnifiedAlerting.Enabled = util.Pointer(false)
+				hs.AlertNG = &ngalert.AlertNG{Cfg: hs.Cfg}
+			})
+
+			signedInUser := &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: tt.orgRole, IsGrafanaAdmin: tt.admin}
+			res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/admin/ngalert/rules?datasourceUid=prometheus"), signedInUser))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
+
+func TestAdmin_ReloadAlertingSettings(t *testing.T) {
+	tests := []struct {
+		desc         string
+		admin        bool
+		orgRole      org.RoleType
+		expectedCode int
+	}{
+		{
+			desc:         "should return 404 to Grafana admins if alerting is disabled",
+			admin:        true,
+			orgRole:      org.RoleViewer,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			desc:         "should return 403 to org admins that are not Grafana admins",
+			orgRole:      org.RoleAdmin,
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.Cfg = setting.NewCfg()
+				hs.Cfg.UnifiedAlerting.Enabled = util.Pointer(false)
+				hs.AlertNG = &ngalert.AlertNG{Cfg: hs.Cfg}
+			})
+
+			signedInUser := &user.SignedInUser{UserID: 1, OrgID: 1, OrgRole: tt.orgRole, IsGrafanaAdmin: tt.admin}
+			res, err := server.Send(webtest.RequestWithSignedInUser(server.NewPostRequest("/api/admin/ngalert/settings/reload", nil), signedInUser))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+}
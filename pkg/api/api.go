@@ -607,6 +607,9 @@ func (hs *HTTPServer) registerRoutes() {
 		adminRoute.Get("/settings", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionSettingsRead)), routing.Wrap(hs.AdminGetSettings))
 		adminRoute.Get("/stats", authorize(reqGrafanaAdmin, ac.EvalPermission(ac.ActionServerStatsRead)), routing.Wrap(hs.AdminGetStats))
 		adminRoute.Post("/pause-all-alerts", reqGrafanaAdmin, routing.Wrap(hs.PauseAllAlerts(setting.AlertingEnabled)))
+		adminRoute.Get("/ngalert/stats", reqGrafanaAdmin, routing.Wrap(hs.AdminGetAlertingStats))
+		adminRoute.Get("/ngalert/rules", reqGrafanaAdmin, routing.Wrap(hs.AdminGetAlertRules))
+		adminRoute.Post("/ngalert/settings/reload", reqGrafanaAdmin, routing.Wrap(hs.AdminReloadAlertingSettings))
 
 		adminRoute.Post("/encryption/rotate-data-keys", reqGrafanaAdmin, routing.Wrap(hs.AdminRotateDataEncryptionKeys))
 		adminRoute.Post("/encryption/reencrypt-data-keys", reqGrafanaAdmin, routing.Wrap(hs.AdminReEncryptEncryptionKeys))
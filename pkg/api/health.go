@@ -2,9 +2,14 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
 	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 func (hs *HTTPServer) databaseHealthy(ctx context.Context) bool {
@@ -23,3 +28,45 @@ func (hs *HTTPServer) databaseHealthy(ctx context.Context) bool {
 	hs.CacheService.Set(cacheKey, healthy, time.Second*5)
 	return healthy
 }
+
+// alertingHealthHandler returns the health of Grafana Alerting. It returns http status code 503 if the scheduler does
+// not run or the alerting tables cannot be read, and 200 otherwise, with warnings if the scheduler is degraded. If
+// Grafana Alerting is disabled, the status is disabled. Callers that are not signed in get only the status, because the
+// details describe the internals of the instance.
+func (hs *HTTPServer) alertingHealthHandler(ctx *web.Context) {
+	notHeadOrGet := ctx.Req.Method != http.MethodGet && ctx.Req.Method != http.MethodHead
+	if notHeadOrGet || ctx.Req.URL.Path != "/api/health/alerting" {
+		return
+	}
+
+	health := apimodels.AlertingHealth{
+		Status:  apimodels.AlertingHealthDisabled,
+		Message: "Grafana Alerting is disabled",
+	}
+	if hs.AlertNG != nil && !hs.AlertNG.IsDisabled() {
+		health = hs.AlertNG.Health(ctx.Req.Context())
+	}
+
+	var data interface{} = health
+	if c := contexthandler.FromContext(ctx.Req.Context()); c == nil || !c.IsSignedIn {
+		data = struct {
+			Status string `json:"status"`
+		}{Status: health.Status}
+	}
+
+	dataBytes, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		hs.log.Error("Failed to encode data", "err", err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if health.Status == apimodels.AlertingHealthFailing {
+		ctx.Resp.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		ctx.Resp.WriteHeader(http.StatusOK)
+	}
+	if _, err := ctx.Resp.Write(dataBytes); err != nil {
+		hs.log.Error("Failed to write to response", "err", err)
+	}
+}
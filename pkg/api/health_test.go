@@ -11,7 +11,11 @@ import (
 
 	"github.com/grafana/grafana/pkg/infra/db/dbtest"
 	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/ngalert"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -147,6 +151,47 @@ func TestHealthAPI_DatabaseHealthCached(t *testing.T) {
 	require.True(t, healthy.(bool))
 }
 
+func TestHealthAPI_AlertingDisabled(t *testing.T) {
+	m, hs := setupHealthAPITestEnvironment(t, func(cfg *setting.Cfg) {
+		cfg.UnifiedAlerting.Enabled = util.Pointer(false)
+	})
+	hs.AlertNG = &ngalert.AlertNG{Cfg: hs.Cfg}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/health/alerting", nil)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	require.Equal(t, 200, rec.Code)
+	expectedBody := `
+		{
+			"status": "disabled"
+		}
+	`
+	require.JSONEq(t, expectedBody, rec.Body.String())
+}
+
+func TestHealthAPI_AlertingSignedIn(t *testing.T) {
+	m, hs := setupHealthAPITestEnvironment(t, func(cfg *setting.Cfg) {
+		cfg.UnifiedAlerting.Enabled = util.Pointer(false)
+	})
+	hs.AlertNG = &ngalert.AlertNG{Cfg: hs.Cfg}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/health/alerting", nil)
+	req = req.WithContext(ctxkey.Set(req.Context(), &contextmodel.ReqContext{IsSignedIn: true}))
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	require.Equal(t, 200, rec.Code)
+	expectedBody := `
+		{
+			"status": "disabled",
+			"message": "Grafana Alerting is disabled",
+			"notificationBacklog": 0
+		}
+	`
+	require.JSONEq(t, expectedBody, rec.Body.String())
+}
+
 func setupHealthAPITestEnvironment(t *testing.T, cbs ...func(*setting.Cfg)) (*web.Mux, *HTTPServer) {
 	t.Helper()
 
@@ -162,5 +207,6 @@ func setupHealthAPITestEnvironment(t *testing.T, cbs ...func(*setting.Cfg)) (*we
 	}
 
 	m.Get("/api/health", hs.apiHealthHandler)
+	m.Get("/api/health/alerting", hs.alertingHealthHandler)
 	return m, hs
 }
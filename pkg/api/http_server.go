@@ -617,6 +617,8 @@ func (hs *HTTPServer) addMiddlewaresAndStaticRoutes() {
 	m.Use(hs.frontendLogEndpoints())
 
 	m.UseMiddleware(hs.ContextHandler.Middleware)
+	// the health of alerting needs the signed in user to decide how much it reports, but must not be redirected or rejected
+	m.Use(hs.alertingHealthHandler)
 	m.Use(middleware.OrgRedirect(hs.Cfg, hs.userService))
 	if !hs.Features.IsEnabled(featuremgmt.FlagAuthnService) {
 		m.Use(accesscontrol.LoadPermissionsMiddleware(hs.accesscontrolService))
@@ -8,6 +8,7 @@ import (
 
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/datamigrations"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/ngalertdata"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/commands/secretsmigrations"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
 	"github.com/grafana/grafana/pkg/cmd/grafana-cli/services"
@@ -244,6 +245,134 @@ grafana-cli user-manager conflicts ingest-file <filepath>
 	},
 }
 
+var ngalertCommands = []*cli.Command{
+	{
+		Name:   "dump",
+		Usage:  "dump <file>. Writes the alert rules of one or all organizations to a JSON file.",
+		Action: runRunnerCommand(ngalertdata.Dump),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: "The file to write the dump to",
+			},
+			&cli.IntFlag{
+				Name:  "org-id",
+				Usage: "The organization to dump. All organizations are dumped if it is not set.",
+			},
+			&cli.BoolFlag{
+				Name:  "with-instances",
+				Usage: "Dump the state of the alert instances as well",
+				Value: false,
+			},
+		},
+	},
+	{
+		Name:   "restore",
+		Usage:  "restore <file>. Restores the alert rules of a dump. Each organization is restored in a single transaction.",
+		Action: runRunnerCommand(ngalertdata.Restore),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "file",
+				Usage: "The dump to restore",
+			},
+			&cli.IntFlag{
+				Name:  "org-id",
+				Usage: "The organization of the dump to restore. All organizations of the dump are restored if it is not set.",
+			},
+			&cli.IntFlag{
+				Name:  "target-org-id",
+				Usage: "The organization to restore the dumped organization into, to clone it. It defaults to the dumped organization.",
+			},
+			&cli.StringFlag{
+				Name:  "on-conflict",
+				Usage: "What to do with a dumped alert rule whose UID already exists: skip, overwrite or error",
+				Value: "error",
+			},
+		},
+	},
+	{
+		Name:   "seed",
+		Usage:  "Creates synthetic alert rules to test Grafana Alerting at scale. It only runs if app_mode is development.",
+		Action: runRunnerCommand(ngalertdata.Seed),
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "rules",
+				Usage: "The number of alert rules to create",
+				Value: 100,
+			},
+			&cli.IntFlag{
+				Name:  "orgs",
+				Usage: "The number of organizations to spread the alert rules across, from the one with the lowest ID. Every organization must have a folder.",
+				Value: 1,
+			},
+			&cli.IntFlag{
+				Name:  "group-size",
+				Usage: "The number of alert rules of every rule group",
+				Value: 10,
+			},
+			&cli.StringFlag{
+				Name:  "intervals",
+				Usage: "The evaluation intervals of the rule groups, with their weights, e.g. 10s:5,1m:3,5m:1",
+				Value: "1m",
+			},
+			&cli.IntFlag{
+				Name:  "min-queries",
+				Usage: "The minimum number of queries and expressions of every alert rule",
+				Value: 1,
+			},
+			&cli.IntFlag{
+				Name:  "max-queries",
+				Usage: "The maximum number of queries and expressions of every alert rule",
+				Value: 3,
+			},
+			&cli.IntFlag{
+				Name:  "labels",
+				Usage: "The number of labels of every alert rule",
+				Value: 2,
+			},
+			&cli.IntFlag{
+				Name:  "label-values",
+				Usage: "The number of distinct values of every label",
+				Value: 10,
+			},
+			&cli.StringFlag{
+				Name:  "paused",
+				Usage: "The proportion of the alert rules that are paused, between 0 and 1",
+				Value: "0",
+			},
+			&cli.IntFlag{
+				Name:  "instances",
+				Usage: "The number of alert instances to create for every alert rule",
+				Value: 0,
+			},
+			&cli.StringFlag{
+				Name:  "datasource-uid",
+				Usage: "The Prometheus data source that the queries read. The alert rules only have math expressions if it is not set.",
+			},
+			&cli.IntFlag{
+				Name:  "seed",
+				Usage: "The seed of the random generator, to create the same alert rules again. It is random if it is not set.",
+			},
+		},
+	},
+	{
+		Name:   "validate",
+		Usage:  "validate <path>. Checks the alert rules of a provisioning file, or of the files of a directory, without a database.",
+		Action: runPluginCommand(ngalertdata.Validate),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "base-interval",
+				Usage: "The base interval of the scheduler that the evaluation intervals must be a multiple of",
+				Value: "10s",
+			},
+			&cli.StringFlag{
+				Name:  "datasource-uids",
+				Usage: "A comma-separated list of the data sources that the queries can use. Any data source is accepted if it is not set.",
+			},
+		},
+	},
+}
+
 var Commands = []*cli.Command{
 	{
 		Name:        "plugins",
@@ -255,4 +384,9 @@ var Commands = []*cli.Command{
 		Usage:       "Grafana admin commands",
 		Subcommands: adminCommands,
 	},
+	{
+		Name:        "ngalert",
+		Usage:       "Dump, restore, seed and validate the alert rules of Grafana Alerting",
+		Subcommands: ngalertCommands,
+	},
 }
@@ -0,0 +1,268 @@
+package ngalertdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// dumpVersion is the version of the format of the dump files.
+const dumpVersion = 1
+
+// alertingDump is the content of a dump file.
+type alertingDump struct {
+	Version int       `json:"version"`
+	Orgs    []orgDump `json:"orgs"`
+}
+
+// orgDump holds the alert rules of an organization, and the folders and data sources that they reference so that the
+// references can be resolved when the dump is restored in another Grafana.
+type orgDump struct {
+	OrgID int64 `json:"orgId"`
+	// Folders are the titles of the folders of the rules by UID.
+	Folders map[string]string `json:"folders"`
+	// Datasources are the data sources that the queries of the rules use by UID.
+	Datasources map[string]datasourceRef `json:"datasources"`
+	Rules       []ruleDump               `json:"rules"`
+	Instances   []instanceDump           `json:"instances,omitempty"`
+}
+
+type datasourceRef struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type ruleDump struct {
+	models.AlertRule
+	Provenance models.Provenance `json:"Provenance,omitempty"`
+}
+
+// instanceDump is an alert instance. The values are nullable because JSON cannot represent NaN.
+type instanceDump struct {
+	models.AlertInstance
+	Values map[string]*float64
+}
+
+func newStore(runner server.Runner) store.DBstore {
+	return newDBStore(runner.SQLStore, runner.Cfg.UnifiedAlerting)
+}
+
+func newDBStore(sqlStore db.DB, cfg setting.UnifiedAlertingSettings) store.DBstore {
+	return store.DBstore{
+		SQLStore: sqlStore,
+		Cfg:      cfg,
+		// no feature is enabled, so that the store does not hide any alert instance
+		FeatureToggles: featuremgmt.WithFeatures(),
+		Logger:         log.New("ngalert.cli"),
+	}
+}
+
+// Dump writes the alert rules of one or all organizations, and optionally the state of their alert instances, to a
+// JSON file.
+func Dump(c utils.CommandLine, runner server.Runner) error {
+	path := c.String("file")
+	if path == "" {
+		path = c.Args().First()
+	}
+	if path == "" {
+		return errors.New("the file to dump the alert rules to is required")
+	}
+
+	st := newStore(runner)
+	d, err := dump(context.Background(), st, int64(c.Int("org-id")), c.Bool("with-instances"))
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the dump: %w", err)
+	}
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		return fmt.Errorf("failed to write the dump: %w", err)
+	}
+
+	rules := 0
+	for _, o := range d.Orgs {
+		rules += len(o.Rules)
+	}
+	logger.Infof("%s Dumped %d alert rules of %d organizations to %s\n", color.GreenString("✔"), rules, len(d.Orgs), path)
+	return nil
+}
+
+// dump reads the alert rules of the organization, or of all organizations if orgID is 0.
+func dump(ctx context.Context, st store.DBstore, orgID int64, withInstances bool) (*alertingDump, error) {
+	orgIDs := []int64{orgID}
+	if orgID == 0 {
+		var err error
+		if orgIDs, err = listOrgs(ctx, st.SQLStore); err != nil {
+			return nil, err
+		}
+	} else if err := checkOrgExists(ctx, st.SQLStore, orgID); err != nil {
+		return nil, err
+	}
+
+	result := &alertingDump{Version: dumpVersion, Orgs: make([]orgDump, 0, len(orgIDs))}
+	for _, id := range orgIDs {
+		o, err := dumpOrg(ctx, st, id, withInstances)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dump the alert rules of organization %d: %w", id, err)
+		}
+		result.Orgs = append(result.Orgs, o)
+	}
+	return result, nil
+}
+
+func dumpOrg(ctx context.Context, st store.DBstore, orgID int64, withInstances bool) (orgDump, error) {
+	rules, err := st.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+	if err != nil {
+		return orgDump{}, err
+	}
+	provenances, err := st.GetProvenances(ctx, orgID, (&models.AlertRule{}).ResourceType())
+	if err != nil {
+		return orgDump{}, err
+	}
+	folders, err := listFolders(ctx, st.SQLStore, orgID)
+	if err != nil {
+		return orgDump{}, err
+	}
+	datasources, err := listDatasources(ctx, st.SQLStore, orgID)
+	if err != nil {
+		return orgDump{}, err
+	}
+
+	result := orgDump{
+		OrgID:       orgID,
+		Folders:     map[string]string{},
+		Datasources: map[string]datasourceRef{},
+		Rules:       make([]ruleDump, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		if title, ok := folders[rule.NamespaceUID]; ok {
+			result.Folders[rule.NamespaceUID] = title
+		}
+		for _, q := range rule.Data {
+			if ds, ok := datasources[q.DatasourceUID]; ok {
+				result.Datasources[q.DatasourceUID] = ds
+			}
+		}
+		r := *rule
+		// the IDs are specific to the database
+		r.ID = 0
+		result.Rules = append(result.Rules, ruleDump{AlertRule: r, Provenance: provenances[rule.UID]})
+	}
+
+	if withInstances {
+		instances, err := st.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: orgID})
+		if err != nil {
+			return orgDump{}, err
+		}
+		result.Instances = make([]instanceDump, 0, len(instances))
+		for _, instance := range instances {
+			i := instanceDump{AlertInstance: *instance, Values: instance.Values.Nullable()}
+			i.AlertInstance.Values = nil
+			result.Instances = append(result.Instances, i)
+		}
+	}
+	return result, nil
+}
+
+func listOrgs(ctx context.Context, sqlStore db.DB) ([]int64, error) {
+	var ids []int64
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("org").Cols("id").OrderBy("id").Find(&ids)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the organizations: %w", err)
+	}
+	return ids, nil
+}
+
+func checkOrgExists(ctx context.Context, sqlStore db.DB, orgID int64) error {
+	var exists bool
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		exists, err = sess.Table("org").Where("id = ?", orgID).Exist()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get organization %d: %w", orgID, err)
+	}
+	if !exists {
+		return fmt.Errorf("organization %d does not exist", orgID)
+	}
+	return nil
+}
+
+// listFolders returns the titles of the folders of the organization by UID.
+func listFolders(ctx context.Context, sqlStore db.DB, orgID int64) (map[string]string, error) {
+	var rows []struct {
+		UID   string `xorm:"uid"`
+		Title string
+	}
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("dashboard").Cols("uid", "title").
+			Where("org_id = ? AND is_folder = "+sqlStore.GetDialect().BooleanStr(true), orgID).
+			Find(&rows)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the folders: %w", err)
+	}
+	result := make(map[string]string, len(rows))
+	for _, row := range rows {
+		result[row.UID] = row.Title
+	}
+	return result, nil
+}
+
+// listDatasources returns the data sources of the organization by UID.
+func listDatasources(ctx context.Context, sqlStore db.DB, orgID int64) (map[string]datasourceRef, error) {
+	var rows []struct {
+		UID  string `xorm:"uid"`
+		Name string
+		Type string
+	}
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table("data_source").Cols("uid", "name", "type").Where("org_id = ?", orgID).Find(&rows)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the data sources: %w", err)
+	}
+	result := make(map[string]datasourceRef, len(rows))
+	for _, row := range rows {
+		result[row.UID] = datasourceRef{Name: row.Name, Type: row.Type}
+	}
+	return result, nil
+}
+
+// toAlertInstance converts the dumped instance back, restoring as NaN the values that JSON cannot represent.
+func (i instanceDump) toAlertInstance(orgID int64) models.AlertInstance {
+	result := i.AlertInstance
+	result.RuleOrgID = orgID
+	result.Values = nil
+	if i.Values != nil {
+		result.Values = make(models.InstanceValues, len(i.Values))
+		for k, v := range i.Values {
+			if v == nil {
+				result.Values[k] = math.NaN()
+				continue
+			}
+			result.Values[k] = *v
+		}
+	}
+	return result
+}
@@ -0,0 +1,231 @@
+package ngalertdata
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestDumpAndRestore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	setup := func(t *testing.T) store.DBstore {
+		t.Helper()
+		sqlStore := db.InitTestDB(t)
+		err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Insert(
+				&org.Org{ID: 1, Name: "main", Created: now, Updated: now},
+				&org.Org{ID: 2, Name: "clone", Created: now, Updated: now},
+				&org.Org{ID: 3, Name: "empty", Created: now, Updated: now},
+				&dashboards.Dashboard{OrgID: 1, UID: "folder-1", Title: "Infra", Slug: "infra", IsFolder: true, Created: now, Updated: now},
+				&dashboards.Dashboard{OrgID: 2, UID: "folder-2", Title: "Infra", Slug: "infra", IsFolder: true, Created: now, Updated: now},
+				&datasources.DataSource{OrgID: 1, UID: "prom-1", Name: "Prometheus", Type: "prometheus", Created: now, Updated: now},
+				&datasources.DataSource{OrgID: 2, UID: "prom-2", Name: "Prometheus", Type: "prometheus", Created: now, Updated: now},
+			)
+			return err
+		})
+		require.NoError(t, err)
+		return newDBStore(sqlStore, setting.UnifiedAlertingSettings{BaseInterval: 10 * time.Second})
+	}
+	rule := func(uid, title string, data ...models.AlertQuery) models.AlertRule {
+		return models.AlertRule{
+			OrgID:           1,
+			UID:             uid,
+			Title:           title,
+			Condition:       data[len(data)-1].RefID,
+			Data:            data,
+			IntervalSeconds: 60,
+			NamespaceUID:    "folder-1",
+			RuleGroup:       "infra",
+			NoDataState:     models.NoData,
+			ExecErrState:    models.AlertingErrState,
+			For:             5 * time.Minute,
+			Labels:          map[string]string{"team": "infra"},
+			Annotations:     map[string]string{"summary": title},
+		}
+	}
+	query := func(refID, datasourceUID, model string) models.AlertQuery {
+		return models.AlertQuery{
+			RefID:             refID,
+			DatasourceUID:     datasourceUID,
+			RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(10 * time.Minute)},
+			Model:             json.RawMessage(model),
+		}
+	}
+	createRules := func(t *testing.T, st store.DBstore) {
+		t.Helper()
+		_, err := st.InsertAlertRules(ctx, []models.AlertRule{
+			rule("high-cpu", "High CPU",
+				query("A", "prom-1", `{"expr":"cpu"}`),
+				query("B", "__expr__", `{"type":"math","expression":"$A > 80"}`)),
+			rule("always", "Always firing",
+				query("A", "__expr__", `{"type":"math","expression":"1 > 0"}`)),
+		})
+		require.NoError(t, err)
+		require.NoError(t, st.SetProvenance(ctx, &models.AlertRule{UID: "always"}, 1, models.ProvenanceFile))
+		require.NoError(t, st.SaveAlertInstances(ctx, models.AlertInstance{
+			AlertInstanceKey:  models.AlertInstanceKey{RuleOrgID: 1, RuleUID: "high-cpu", LabelsHash: "hash"},
+			Labels:            models.InstanceLabels{"instance": "server-1"},
+			CurrentState:      models.InstanceStateFiring,
+			CurrentStateSince: now.Add(-time.Hour),
+			LastEvalTime:      now,
+			Values:            models.InstanceValues{"A": 95, "B": math.NaN()},
+		}))
+	}
+	// encode returns the dump without the fields that change when the rules are restored.
+	encode := func(t *testing.T, d *alertingDump) string {
+		t.Helper()
+		b, err := json.Marshal(d)
+		require.NoError(t, err)
+		var normalized alertingDump
+		require.NoError(t, json.Unmarshal(b, &normalized))
+		for _, o := range normalized.Orgs {
+			for i := range o.Rules {
+				o.Rules[i].Version = 0
+				o.Rules[i].Updated = time.Time{}
+			}
+		}
+		b, err = json.Marshal(normalized)
+		require.NoError(t, err)
+		return string(b)
+	}
+	// roundTrip encodes and decodes the dump like it is written to a file and read back.
+	roundTrip := func(t *testing.T, d *alertingDump) *alertingDump {
+		t.Helper()
+		b, err := json.Marshal(d)
+		require.NoError(t, err)
+		var result alertingDump
+		require.NoError(t, json.Unmarshal(b, &result))
+		return &result
+	}
+	ruleTitles := func(t *testing.T, st store.DBstore, orgID int64) map[string]string {
+		t.Helper()
+		rules, err := st.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		result := make(map[string]string, len(rules))
+		for _, r := range rules {
+			result[r.UID] = r.Title
+		}
+		return result
+	}
+
+	t.Run("restores the dumped alert rules", func(t *testing.T) {
+		st := setup(t)
+		createRules(t, st)
+		before, err := dump(ctx, st, 0, true)
+		require.NoError(t, err)
+		require.Len(t, before.Orgs, 3)
+		require.Equal(t, map[string]string{"folder-1": "Infra"}, before.Orgs[0].Folders)
+		require.Equal(t, map[string]datasourceRef{"prom-1": {Name: "Prometheus", Type: "prometheus"}}, before.Orgs[0].Datasources)
+		require.Len(t, before.Orgs[0].Rules, 2)
+		require.Len(t, before.Orgs[0].Instances, 1)
+
+		require.NoError(t, st.DeleteAlertRulesByUID(ctx, 1, "high-cpu", "always"))
+		require.NoError(t, st.DeleteProvenance(ctx, &models.AlertRule{UID: "always"}, 1))
+		empty, err := dump(ctx, st, 1, true)
+		require.NoError(t, err)
+		require.Empty(t, empty.Orgs[0].Rules)
+		require.Empty(t, empty.Orgs[0].Instances)
+
+		result, err := restore(ctx, st, roundTrip(t, before), restoreOptions{onConflict: conflictError})
+		require.NoError(t, err)
+		require.Equal(t, restoreResult{created: 2}, result)
+		after, err := dump(ctx, st, 0, true)
+		require.NoError(t, err)
+		require.Equal(t, encode(t, before), encode(t, after))
+		require.Nil(t, after.Orgs[0].Instances[0].Values["B"])
+	})
+
+	t.Run("applies the conflict policy to the rules that exist", func(t *testing.T) {
+		st := setup(t)
+		createRules(t, st)
+		d, err := dump(ctx, st, 1, false)
+		require.NoError(t, err)
+		d.Orgs[0].Rules[0].Title = "High CPU usage"
+
+		_, err = restore(ctx, st, roundTrip(t, d), restoreOptions{onConflict: conflictError})
+		require.ErrorContains(t, err, "high-cpu, always")
+		require.Equal(t, map[string]string{"high-cpu": "High CPU", "always": "Always firing"}, ruleTitles(t, st, 1))
+
+		result, err := restore(ctx, st, roundTrip(t, d), restoreOptions{onConflict: conflictSkip})
+		require.NoError(t, err)
+		require.Equal(t, restoreResult{skipped: 2}, result)
+		require.Equal(t, map[string]string{"high-cpu": "High CPU", "always": "Always firing"}, ruleTitles(t, st, 1))
+
+		result, err = restore(ctx, st, roundTrip(t, d), restoreOptions{onConflict: conflictOverwrite})
+		require.NoError(t, err)
+		require.Equal(t, restoreResult{updated: 2}, result)
+		require.Equal(t, map[string]string{"high-cpu": "High CPU usage", "always": "Always firing"}, ruleTitles(t, st, 1))
+	})
+
+	t.Run("remaps the folders and data sources of another organization", func(t *testing.T) {
+		st := setup(t)
+		createRules(t, st)
+		d, err := dump(ctx, st, 1, true)
+		require.NoError(t, err)
+
+		result, err := restore(ctx, st, roundTrip(t, d), restoreOptions{targetOrgID: 2, onConflict: conflictError})
+		require.NoError(t, err)
+		require.Equal(t, restoreResult{created: 2}, result)
+		rules, err := st.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: 2})
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		for _, r := range rules {
+			require.Equal(t, "folder-2", r.NamespaceUID)
+		}
+		require.Equal(t, "prom-2", rules[0].Data[0].DatasourceUID)
+		require.Equal(t, "__expr__", rules[0].Data[1].DatasourceUID)
+		provenances, err := st.GetProvenances(ctx, 2, (&models.AlertRule{}).ResourceType())
+		require.NoError(t, err)
+		require.Equal(t, models.ProvenanceFile, provenances["always"])
+		instances, err := st.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: 2})
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+	})
+
+	t.Run("fails with the references that cannot be resolved", func(t *testing.T) {
+		st := setup(t)
+		createRules(t, st)
+		d, err := dump(ctx, st, 1, false)
+		require.NoError(t, err)
+
+		_, err = restore(ctx, st, roundTrip(t, d), restoreOptions{targetOrgID: 3, onConflict: conflictError})
+		require.ErrorContains(t, err, `data source "prom-1" (name "Prometheus", type "prometheus") used by "High CPU"`)
+		require.ErrorContains(t, err, `folder "folder-1" (title "Infra") used by "High CPU", "Always firing"`)
+		require.Empty(t, ruleTitles(t, st, 3))
+	})
+
+	t.Run("restores nothing of an organization if a rule is invalid", func(t *testing.T) {
+		st := setup(t)
+		createRules(t, st)
+		d, err := dump(ctx, st, 1, false)
+		require.NoError(t, err)
+		d.Orgs[0].Rules[1].IntervalSeconds = 15
+
+		_, err = restore(ctx, st, roundTrip(t, d), restoreOptions{targetOrgID: 2, onConflict: conflictError})
+		require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+		require.ErrorContains(t, err, "always")
+		require.Empty(t, ruleTitles(t, st, 2))
+	})
+
+	t.Run("fails if the conflict policy is invalid", func(t *testing.T) {
+		_, err := parseConflictPolicy("replace")
+		require.Error(t, err)
+		p, err := parseConflictPolicy("")
+		require.NoError(t, err)
+		require.Equal(t, conflictError, p)
+	})
+}
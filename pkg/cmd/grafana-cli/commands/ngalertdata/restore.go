@@ -0,0 +1,333 @@
+package ngalertdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// conflictPolicy tells what to do with a dumped alert rule whose UID is already used by a rule of the organization.
+type conflictPolicy string
+
+const (
+	// conflictSkip keeps the existing rule.
+	conflictSkip conflictPolicy = "skip"
+	// conflictOverwrite replaces the existing rule with the dumped one.
+	conflictOverwrite conflictPolicy = "overwrite"
+	// conflictError fails the restore of the organization.
+	conflictError conflictPolicy = "error"
+)
+
+func parseConflictPolicy(s string) (conflictPolicy, error) {
+	switch p := conflictPolicy(s); p {
+	case conflictSkip, conflictOverwrite, conflictError:
+		return p, nil
+	case "":
+		return conflictError, nil
+	default:
+		return "", fmt.Errorf("invalid conflict policy %q, must be one of %s, %s or %s", s, conflictSkip, conflictOverwrite, conflictError)
+	}
+}
+
+type restoreOptions struct {
+	// orgID is the organization of the dump to restore. Zero restores all organizations of the dump.
+	orgID int64
+	// targetOrgID is the organization to restore the dumped organization into. Zero restores each dumped organization
+	// into the organization with the same ID.
+	targetOrgID int64
+	onConflict  conflictPolicy
+}
+
+type restoreResult struct {
+	created int
+	updated int
+	skipped int
+}
+
+// Restore restores the alert rules of a dump file written by Dump. Each organization is restored in its own
+// transaction, so that it is restored completely or not at all.
+func Restore(c utils.CommandLine, runner server.Runner) error {
+	path := c.String("file")
+	if path == "" {
+		path = c.Args().First()
+	}
+	if path == "" {
+		return errors.New("the file to restore the alert rules from is required")
+	}
+	onConflict, err := parseConflictPolicy(c.String("on-conflict"))
+	if err != nil {
+		return err
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read the dump: %w", err)
+	}
+	var d alertingDump
+	if err := json.Unmarshal(b, &d); err != nil {
+		return fmt.Errorf("failed to decode the dump: %w", err)
+	}
+
+	st := newStore(runner)
+	result, err := restore(context.Background(), st, &d, restoreOptions{
+		orgID:       int64(c.Int("org-id")),
+		targetOrgID: int64(c.Int("target-org-id")),
+		onConflict:  onConflict,
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("%s Restored alert rules from %s: %d created, %d overwritten, %d skipped\n", color.GreenString("✔"), path, result.created, result.updated, result.skipped)
+	return nil
+}
+
+func restore(ctx context.Context, st store.DBstore, d *alertingDump, opts restoreOptions) (restoreResult, error) {
+	if d.Version != dumpVersion {
+		return restoreResult{}, fmt.Errorf("unsupported dump version %d, expected %d", d.Version, dumpVersion)
+	}
+	orgs := d.Orgs
+	if opts.orgID != 0 {
+		orgs = nil
+		for _, o := range d.Orgs {
+			if o.OrgID == opts.orgID {
+				orgs = append(orgs, o)
+			}
+		}
+		if len(orgs) == 0 {
+			return restoreResult{}, fmt.Errorf("the dump does not contain organization %d", opts.orgID)
+		}
+	}
+	if opts.targetOrgID != 0 && len(orgs) != 1 {
+		return restoreResult{}, errors.New("a target organization requires the dump to contain a single organization, or the organization to restore")
+	}
+
+	var total restoreResult
+	for _, o := range orgs {
+		orgID := o.OrgID
+		if opts.targetOrgID != 0 {
+			orgID = opts.targetOrgID
+		}
+		var result restoreResult
+		err := st.SQLStore.InTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			result, err = restoreOrg(ctx, st, o, orgID, opts.onConflict)
+			return err
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to restore the alert rules of organization %d: %w", orgID, err)
+		}
+		total.created += result.created
+		total.updated += result.updated
+		total.skipped += result.skipped
+	}
+	return total, nil
+}
+
+func restoreOrg(ctx context.Context, st store.DBstore, o orgDump, orgID int64, onConflict conflictPolicy) (restoreResult, error) {
+	if err := checkOrgExists(ctx, st.SQLStore, orgID); err != nil {
+		return restoreResult{}, err
+	}
+	rules, err := remapReferences(ctx, st, o, orgID)
+	if err != nil {
+		return restoreResult{}, err
+	}
+
+	existing, err := st.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+	if err != nil {
+		return restoreResult{}, err
+	}
+	existingByUID := make(map[string]*models.AlertRule, len(existing))
+	for _, rule := range existing {
+		existingByUID[rule.UID] = rule
+	}
+	if onConflict == conflictError {
+		var conflicts []string
+		for _, rule := range rules {
+			if _, ok := existingByUID[rule.UID]; ok {
+				conflicts = append(conflicts, rule.UID)
+			}
+		}
+		if len(conflicts) > 0 {
+			return restoreResult{}, fmt.Errorf("alert rules with the UIDs %s already exist", strings.Join(conflicts, ", "))
+		}
+	}
+
+	var result restoreResult
+	restored := make(map[string]struct{}, len(rules))
+	for _, rule := range rules {
+		current, ok := existingByUID[rule.UID]
+		switch {
+		case !ok:
+			if _, err := st.InsertAlertRules(ctx, []models.AlertRule{rule.AlertRule}); err != nil {
+				return restoreResult{}, fmt.Errorf("failed to create alert rule %s (%s): %w", rule.UID, rule.Title, err)
+			}
+			result.created++
+		case onConflict == conflictOverwrite:
+			if err := st.UpdateAlertRules(ctx, []models.UpdateRule{{Existing: current, New: rule.AlertRule}}); err != nil {
+				return restoreResult{}, fmt.Errorf("failed to overwrite alert rule %s (%s): %w", rule.UID, rule.Title, err)
+			}
+			result.updated++
+		default:
+			result.skipped++
+			continue
+		}
+		restored[rule.UID] = struct{}{}
+
+		if rule.Provenance != models.ProvenanceNone {
+			err = st.SetProvenance(ctx, &rule.AlertRule, orgID, rule.Provenance)
+		} else if ok {
+			err = st.DeleteProvenance(ctx, &rule.AlertRule, orgID)
+		}
+		if err != nil {
+			return restoreResult{}, fmt.Errorf("failed to restore the provenance of alert rule %s (%s): %w", rule.UID, rule.Title, err)
+		}
+	}
+
+	// the instances of the skipped rules are not restored, as they belong to the existing rules
+	instances := make([]models.AlertInstance, 0, len(o.Instances))
+	for _, i := range o.Instances {
+		if _, ok := restored[i.RuleUID]; !ok {
+			continue
+		}
+		instance := i.toAlertInstance(orgID)
+		if err := models.ValidateAlertInstance(instance); err != nil {
+			return restoreResult{}, fmt.Errorf("invalid alert instance of alert rule %s: %w", i.RuleUID, err)
+		}
+		instances = append(instances, instance)
+	}
+	if len(instances) > 0 {
+		if err := st.SaveAlertInstances(ctx, instances...); err != nil {
+			return restoreResult{}, fmt.Errorf("failed to restore the alert instances: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// remapReferences returns the dumped rules with the folders and data sources they reference resolved in the
+// organization. A folder or data source that does not exist with the same UID is resolved by title, or by name and
+// type. It fails with all references that cannot be resolved.
+func remapReferences(ctx context.Context, st store.DBstore, o orgDump, orgID int64) ([]ruleDump, error) {
+	folders, err := listFolders(ctx, st.SQLStore, orgID)
+	if err != nil {
+		return nil, err
+	}
+	datasources, err := listDatasources(ctx, st.SQLStore, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	folderUIDs := map[string]string{}
+	datasourceUIDs := map[string]string{}
+	// unresolved are the titles of the rules that use each reference that cannot be resolved
+	unresolved := map[string][]string{}
+
+	resolveFolder := func(uid string) (string, bool) {
+		if resolved, ok := folderUIDs[uid]; ok {
+			return resolved, true
+		}
+		if _, ok := folders[uid]; ok {
+			folderUIDs[uid] = uid
+			return uid, true
+		}
+		title, ok := o.Folders[uid]
+		if !ok {
+			return "", false
+		}
+		for candidate, candidateTitle := range folders {
+			if candidateTitle == title {
+				folderUIDs[uid] = candidate
+				return candidate, true
+			}
+		}
+		return "", false
+	}
+	resolveDatasource := func(uid string) (string, bool) {
+		if resolved, ok := datasourceUIDs[uid]; ok {
+			return resolved, true
+		}
+		if _, ok := datasources[uid]; ok {
+			datasourceUIDs[uid] = uid
+			return uid, true
+		}
+		ref, ok := o.Datasources[uid]
+		if !ok {
+			return "", false
+		}
+		for candidate, candidateRef := range datasources {
+			if candidateRef == ref {
+				datasourceUIDs[uid] = candidate
+				return candidate, true
+			}
+		}
+		return "", false
+	}
+
+	result := make([]ruleDump, 0, len(o.Rules))
+	for _, dumped := range o.Rules {
+		rule := dumped
+		rule.ID = 0
+		rule.OrgID = orgID
+		if uid, ok := resolveFolder(rule.NamespaceUID); ok {
+			rule.NamespaceUID = uid
+		} else {
+			ref := fmt.Sprintf("folder %q", rule.NamespaceUID)
+			if title, ok := o.Folders[rule.NamespaceUID]; ok {
+				ref = fmt.Sprintf("folder %q (title %q)", rule.NamespaceUID, title)
+			}
+			unresolved[ref] = append(unresolved[ref], rule.Title)
+		}
+		rule.Data = make([]models.AlertQuery, 0, len(dumped.Data))
+		for _, q := range dumped.Data {
+			if !expr.IsDataSource(q.DatasourceUID) {
+				if uid, ok := resolveDatasource(q.DatasourceUID); ok {
+					q.DatasourceUID = uid
+				} else {
+					ref := fmt.Sprintf("data source %q", q.DatasourceUID)
+					if ds, ok := o.Datasources[q.DatasourceUID]; ok {
+						ref = fmt.Sprintf("data source %q (name %q, type %q)", q.DatasourceUID, ds.Name, ds.Type)
+					}
+					unresolved[ref] = append(unresolved[ref], rule.Title)
+				}
+			}
+			rule.Data = append(rule.Data, q)
+		}
+		result = append(result, rule)
+	}
+
+	if len(unresolved) > 0 {
+		refs := make([]string, 0, len(unresolved))
+		for ref, titles := range unresolved {
+			refs = append(refs, fmt.Sprintf("%s used by %s", ref, strings.Join(quoteUnique(titles), ", ")))
+		}
+		sort.Strings(refs)
+		return nil, fmt.Errorf("the following references cannot be resolved in organization %d: %s", orgID, strings.Join(refs, "; "))
+	}
+	return result, nil
+}
+
+// quoteUnique quotes the strings and removes the duplicates.
+func quoteUnique(s []string) []string {
+	seen := make(map[string]struct{}, len(s))
+	result := make([]string, 0, len(s))
+	for _, v := range s {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, fmt.Sprintf("%q", v))
+	}
+	return result
+}
@@ -0,0 +1,379 @@
+package ngalertdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/logger"
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/server"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// weightedInterval is an evaluation interval and its share of the rule groups.
+type weightedInterval struct {
+	interval time.Duration
+	weight   int
+}
+
+// parseIntervals parses a comma-separated list of intervals with optional weights, e.g. "10s:5,1m:3,5m". An interval
+// without a weight has the weight 1.
+func parseIntervals(s string) ([]weightedInterval, error) {
+	var result []weightedInterval
+	for _, item := range util.SplitString(strings.ReplaceAll(s, ",", " ")) {
+		value, weight, hasWeight := strings.Cut(item, ":")
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval %q", value)
+		}
+		w := 1
+		if hasWeight {
+			if w, err = strconv.Atoi(weight); err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight %q of interval %s", weight, value)
+			}
+		}
+		result = append(result, weightedInterval{interval: interval, weight: w})
+	}
+	if len(result) == 0 {
+		return nil, errors.New("at least one interval is required")
+	}
+	return result, nil
+}
+
+type seedOptions struct {
+	// rules is the number of alert rules to create, spread evenly across the organizations.
+	rules int
+	// orgs is the number of organizations to create the rules in, starting from the organization with the lowest ID.
+	orgs      int
+	groupSize int
+	intervals []weightedInterval
+	// minQueries and maxQueries bound the number of queries and expressions of every rule.
+	minQueries int
+	maxQueries int
+	// labels is the number of labels of every rule, and labelValues the number of distinct values of every label.
+	labels      int
+	labelValues int
+	// paused is the proportion of the rules that are paused.
+	paused float64
+	// instances is the number of alert instances to seed for every rule.
+	instances int
+	// datasourceUID is the Prometheus data source that the queries read. If it is empty, the rules only have math
+	// expressions, which are evaluated without a data source.
+	datasourceUID string
+}
+
+func (o seedOptions) validate() error {
+	switch {
+	case o.rules <= 0:
+		return errors.New("the number of rules must be positive")
+	case o.orgs <= 0:
+		return errors.New("the number of organizations must be positive")
+	case o.groupSize <= 0:
+		return errors.New("the size of the rule groups must be positive")
+	case len(o.intervals) == 0:
+		return errors.New("at least one interval is required")
+	case o.minQueries <= 0 || o.maxQueries < o.minQueries:
+		return errors.New("the number of queries must be positive, and the maximum must not be less than the minimum")
+	case o.labels < 0 || o.labelValues <= 0:
+		return errors.New("the number of labels must not be negative, and the number of their values must be positive")
+	case o.paused < 0 || o.paused > 1:
+		return errors.New("the proportion of paused rules must be between 0 and 1")
+	case o.instances < 0:
+		return errors.New("the number of instances must not be negative")
+	}
+	totalWeight := 0
+	for _, i := range o.intervals {
+		totalWeight += i.weight
+	}
+	if totalWeight == 0 {
+		return errors.New("at least one interval must have a positive weight")
+	}
+	return nil
+}
+
+type seedResult struct {
+	// runID identifies the seeded rules, which are named after it.
+	runID     string
+	orgs      int
+	groups    int
+	rules     int
+	paused    int
+	instances int
+}
+
+// Seed creates synthetic alert rules, and optionally their alert instances, to test Grafana Alerting at scale. The rules
+// are saved by the alert rule store, so they are validated and get UIDs like any other rule. It only runs in development
+// mode, so that it cannot fill a production database with synthetic rules.
+func Seed(c utils.CommandLine, runner server.Runner) error {
+	if runner.Cfg.Env != setting.Dev {
+		return errors.New("seeding alert rules is only allowed if app_mode is development")
+	}
+	intervals, err := parseIntervals(c.String("intervals"))
+	if err != nil {
+		return err
+	}
+	paused, err := strconv.ParseFloat(c.String("paused"), 64)
+	if err != nil {
+		return fmt.Errorf("invalid proportion of paused rules %q", c.String("paused"))
+	}
+	opts := seedOptions{
+		rules:         c.Int("rules"),
+		orgs:          c.Int("orgs"),
+		groupSize:     c.Int("group-size"),
+		intervals:     intervals,
+		minQueries:    c.Int("min-queries"),
+		maxQueries:    c.Int("max-queries"),
+		labels:        c.Int("labels"),
+		labelValues:   c.Int("label-values"),
+		paused:        paused,
+		instances:     c.Int("instances"),
+		datasourceUID: c.String("datasource-uid"),
+	}
+	randSeed := int64(c.Int("seed"))
+	if randSeed == 0 {
+		randSeed = time.Now().UnixNano()
+	}
+
+	start := time.Now()
+	result, err := seed(context.Background(), newStore(runner), opts, rand.New(rand.NewSource(randSeed)))
+	if err != nil {
+		return err
+	}
+	logger.Infof("%s Seeded %d alert rules (%d paused) in %d groups of %d organizations, and %d alert instances, in %s. The rules are named after the run %s.\n",
+		color.GreenString("✔"), result.rules, result.paused, result.groups, result.orgs, result.instances, time.Since(start).Round(time.Millisecond), result.runID)
+	return nil
+}
+
+// seed creates the alert rules of the options. The names of the rules and groups contain an ID of the run, so that it
+// can be run repeatedly on the same database.
+func seed(ctx context.Context, st store.DBstore, opts seedOptions, rnd *rand.Rand) (seedResult, error) {
+	if err := opts.validate(); err != nil {
+		return seedResult{}, err
+	}
+	orgIDs, err := listOrgs(ctx, st.SQLStore)
+	if err != nil {
+		return seedResult{}, err
+	}
+	if len(orgIDs) < opts.orgs {
+		return seedResult{}, fmt.Errorf("%d organizations are required, but only %d exist", opts.orgs, len(orgIDs))
+	}
+	orgIDs = orgIDs[:opts.orgs]
+	folders := make(map[int64][]string, len(orgIDs))
+	for _, orgID := range orgIDs {
+		byUID, err := listFolders(ctx, st.SQLStore, orgID)
+		if err != nil {
+			return seedResult{}, err
+		}
+		if len(byUID) == 0 {
+			return seedResult{}, fmt.Errorf("organization %d has no folder to create the alert rules in", orgID)
+		}
+		for uid := range byUID {
+			folders[orgID] = append(folders[orgID], uid)
+		}
+		sort.Strings(folders[orgID])
+	}
+
+	result := seedResult{runID: util.GenerateShortUID(), orgs: len(orgIDs)}
+	groupSizes := make([]int, 0)
+	for created := 0; created < opts.rules; created += opts.groupSize {
+		size := opts.groupSize
+		if remaining := opts.rules - created; remaining < size {
+			size = remaining
+		}
+		groupSizes = append(groupSizes, size)
+	}
+	intervals := distributeIntervals(opts.intervals, len(groupSizes))
+
+	ruleIdx := 0
+	for groupIdx, size := range groupSizes {
+		orgID := orgIDs[groupIdx%len(orgIDs)]
+		group := fmt.Sprintf("seed-%s-%d", result.runID, groupIdx+1)
+		rules := make([]models.AlertRule, 0, size)
+		for i := 0; i < size; i++ {
+			ruleIdx++
+			// the paused rules are spread evenly, and their number is the proportion rounded down
+			isPaused := int(float64(ruleIdx)*opts.paused) > int(float64(ruleIdx-1)*opts.paused)
+			rules = append(rules, seedRule(opts, rnd, seedRuleParams{
+				orgID:        orgID,
+				namespaceUID: folders[orgID][groupIdx/len(orgIDs)%len(folders[orgID])],
+				group:        group,
+				groupIdx:     i + 1,
+				title:        fmt.Sprintf("Seed %s #%d", result.runID, ruleIdx),
+				interval:     intervals[groupIdx],
+				isPaused:     isPaused,
+			}))
+			if isPaused {
+				result.paused++
+			}
+		}
+		if _, err := st.InsertAlertRules(ctx, rules); err != nil {
+			return result, fmt.Errorf("failed to create the alert rules of group %s: %w", group, err)
+		}
+		result.groups++
+		result.rules += len(rules)
+
+		if opts.instances == 0 {
+			continue
+		}
+		// the rules are read back to get the UIDs that the store generated
+		created, err := st.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID, RuleGroup: group})
+		if err != nil {
+			return result, fmt.Errorf("failed to read the alert rules of group %s: %w", group, err)
+		}
+		instances := make([]models.AlertInstance, 0, len(created)*opts.instances)
+		for _, rule := range created {
+			for i := 0; i < opts.instances; i++ {
+				instance, err := seedInstance(rnd, orgID, rule.UID, rule.Labels, i)
+				if err != nil {
+					return result, err
+				}
+				instances = append(instances, instance)
+			}
+		}
+		if err := st.SaveAlertInstances(ctx, instances...); err != nil {
+			return result, fmt.Errorf("failed to create the alert instances of group %s: %w", group, err)
+		}
+		result.instances += len(instances)
+	}
+	return result, nil
+}
+
+// distributeIntervals returns the intervals of n rule groups, in proportion to the weights of the intervals. The
+// remainders of the proportions go to the intervals with the largest ones.
+func distributeIntervals(intervals []weightedInterval, n int) []time.Duration {
+	totalWeight := 0
+	for _, i := range intervals {
+		totalWeight += i.weight
+	}
+	counts := make([]int, len(intervals))
+	remainders := make([]int, len(intervals))
+	assigned := 0
+	for idx, i := range intervals {
+		counts[idx] = n * i.weight / totalWeight
+		remainders[idx] = n * i.weight % totalWeight
+		assigned += counts[idx]
+	}
+	order := make([]int, len(intervals))
+	for idx := range order {
+		order[idx] = idx
+	}
+	sort.SliceStable(order, func(a, b int) bool { return remainders[order[a]] > remainders[order[b]] })
+	for _, idx := range order[:n-assigned] {
+		counts[idx]++
+	}
+
+	result := make([]time.Duration, 0, n)
+	for idx, i := range intervals {
+		for c := 0; c < counts[idx]; c++ {
+			result = append(result, i.interval)
+		}
+	}
+	return result
+}
+
+type seedRuleParams struct {
+	orgID        int64
+	namespaceUID string
+	group        string
+	groupIdx     int
+	title        string
+	interval     time.Duration
+	isPaused     bool
+}
+
+func seedRule(opts seedOptions, rnd *rand.Rand, p seedRuleParams) models.AlertRule {
+	labels := make(map[string]string, opts.labels)
+	for i := 0; i < opts.labels; i++ {
+		labels[fmt.Sprintf("seed_label_%d", i+1)] = fmt.Sprintf("value-%d", rnd.Intn(opts.labelValues)+1)
+	}
+	data := seedQueries(opts, rnd, opts.minQueries+rnd.Intn(opts.maxQueries-opts.minQueries+1))
+	return models.AlertRule{
+		OrgID:           p.orgID,
+		Title:           p.title,
+		Condition:       data[len(data)-1].RefID,
+		Data:            data,
+		IntervalSeconds: int64(p.interval.Seconds()),
+		NamespaceUID:    p.namespaceUID,
+		RuleGroup:       p.group,
+		RuleGroupIndex:  p.groupIdx,
+		NoDataState:     models.NoData,
+		ExecErrState:    models.ErrorErrState,
+		IsPaused:        p.isPaused,
+		Labels:          labels,
+		Annotations:     map[string]string{"summary": "Synthetic alert rule created by grafana-cli ngalert seed"},
+	}
+}
+
+// seedQueries returns n queries. The last one is the condition, a math expression that fires if the sum of the values of
+// the others is positive, and the others return 0 or 1.
+func seedQueries(opts seedOptions, rnd *rand.Rand, n int) []models.AlertQuery {
+	result := make([]models.AlertQuery, 0, n)
+	refs := make([]string, 0, n-1)
+	for i := 0; i < n-1; i++ {
+		refID := string(rune('A' + i%26))
+		if i >= 26 {
+			refID += strconv.Itoa(i / 26)
+		}
+		refs = append(refs, "$"+refID)
+		value := rnd.Intn(2)
+		if opts.datasourceUID == "" {
+			result = append(result, seedQuery(refID, expr.DatasourceUID, map[string]interface{}{"type": "math", "expression": strconv.Itoa(value)}))
+			continue
+		}
+		q := seedQuery(refID, opts.datasourceUID, map[string]interface{}{"expr": fmt.Sprintf("vector(%d)", value), "instant": true})
+		q.RelativeTimeRange = models.RelativeTimeRange{From: models.Duration(10 * time.Minute)}
+		result = append(result, q)
+	}
+	condition := strconv.Itoa(rnd.Intn(2)) + " > 0"
+	if len(refs) > 0 {
+		condition = strings.Join(refs, " + ") + " > 0"
+	}
+	return append(result, seedQuery("condition", expr.DatasourceUID, map[string]interface{}{"type": "math", "expression": condition}))
+}
+
+func seedQuery(refID, datasourceUID string, model map[string]interface{}) models.AlertQuery {
+	model["refId"] = refID
+	// the model is a map of strings, booleans and numbers, which always encodes
+	b, _ := json.Marshal(model)
+	return models.AlertQuery{
+		RefID:         refID,
+		DatasourceUID: datasourceUID,
+		Model:         b,
+	}
+}
+
+var seedStates = []models.InstanceStateType{models.InstanceStateNormal, models.InstanceStatePending, models.InstanceStateFiring}
+
+func seedInstance(rnd *rand.Rand, orgID int64, ruleUID string, ruleLabels map[string]string, idx int) (models.AlertInstance, error) {
+	labels := make(models.InstanceLabels, len(ruleLabels)+1)
+	for k, v := range ruleLabels {
+		labels[k] = v
+	}
+	labels["instance"] = fmt.Sprintf("instance-%d", idx+1)
+	_, hash, err := labels.StringAndHash()
+	if err != nil {
+		return models.AlertInstance{}, err
+	}
+	now := time.Now()
+	instance := models.AlertInstance{
+		AlertInstanceKey:  models.AlertInstanceKey{RuleOrgID: orgID, RuleUID: ruleUID, LabelsHash: hash},
+		Labels:            labels,
+		CurrentState:      seedStates[rnd.Intn(len(seedStates))],
+		CurrentStateSince: now,
+		LastEvalTime:      now,
+	}
+	return instance, models.ValidateAlertInstance(instance)
+}
@@ -0,0 +1,130 @@
+package ngalertdata
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestSeed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	sqlStore := db.InitTestDB(t)
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(
+			&org.Org{ID: 1, Name: "main", Created: now, Updated: now},
+			&org.Org{ID: 2, Name: "second", Created: now, Updated: now},
+			&org.Org{ID: 3, Name: "unused", Created: now, Updated: now},
+			&dashboards.Dashboard{OrgID: 1, UID: "folder-1", Title: "Infra", Slug: "infra", IsFolder: true, Created: now, Updated: now},
+			&dashboards.Dashboard{OrgID: 2, UID: "folder-2", Title: "Infra", Slug: "infra", IsFolder: true, Created: now, Updated: now},
+		)
+		return err
+	})
+	require.NoError(t, err)
+	st := newDBStore(sqlStore, setting.UnifiedAlertingSettings{BaseInterval: 10 * time.Second})
+
+	intervals, err := parseIntervals("10s:3,1m:2")
+	require.NoError(t, err)
+	opts := seedOptions{
+		rules:       20,
+		orgs:        2,
+		groupSize:   4,
+		intervals:   intervals,
+		minQueries:  2,
+		maxQueries:  3,
+		labels:      2,
+		labelValues: 3,
+		paused:      0.25,
+		instances:   2,
+	}
+	result, err := seed(ctx, st, opts, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	require.Equal(t, 2, result.orgs)
+	require.Equal(t, 5, result.groups)
+	require.Equal(t, 20, result.rules)
+	require.Equal(t, 5, result.paused)
+	require.Equal(t, 40, result.instances)
+
+	var rules []*models.AlertRule
+	for _, orgID := range []int64{1, 2} {
+		orgRules, err := st.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		require.NotEmpty(t, orgRules)
+		rules = append(rules, orgRules...)
+	}
+	require.Len(t, rules, 20)
+
+	byInterval := map[int64]int{}
+	paused := 0
+	labelValues := map[string]map[string]struct{}{}
+	for _, rule := range rules {
+		require.NotEmpty(t, rule.UID)
+		byInterval[rule.IntervalSeconds]++
+		if rule.IsPaused {
+			paused++
+		}
+		require.GreaterOrEqual(t, len(rule.Data), 2)
+		require.LessOrEqual(t, len(rule.Data), 3)
+		require.Equal(t, rule.Data[len(rule.Data)-1].RefID, rule.Condition)
+		require.Len(t, rule.Labels, 2)
+		for name, value := range rule.Labels {
+			if labelValues[name] == nil {
+				labelValues[name] = map[string]struct{}{}
+			}
+			labelValues[name][value] = struct{}{}
+		}
+	}
+	// 3 of the 5 groups are evaluated every 10 seconds, and 2 every minute
+	require.Equal(t, map[int64]int{10: 12, 60: 8}, byInterval)
+	require.Equal(t, 5, paused)
+	require.Len(t, labelValues, 2)
+	for _, values := range labelValues {
+		require.LessOrEqual(t, len(values), 3)
+	}
+
+	// the groups alternate between the organizations, so the first one has 3 groups of 4 rules with 2 instances each
+	for orgID, expected := range map[int64]int64{1: 24, 2: 16} {
+		count, err := st.CountAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: orgID})
+		require.NoError(t, err)
+		require.Equal(t, expected, count)
+	}
+
+	t.Run("should create new alert rules when run again", func(t *testing.T) {
+		again, err := seed(ctx, st, opts, rand.New(rand.NewSource(1)))
+		require.NoError(t, err)
+		require.NotEqual(t, result.runID, again.runID)
+		count, err := st.Count(ctx, 0)
+		require.NoError(t, err)
+		require.EqualValues(t, 40, count)
+	})
+
+	t.Run("should fail if there are not enough organizations with folders", func(t *testing.T) {
+		opts := opts
+		opts.orgs = 3
+		_, err := seed(ctx, st, opts, rand.New(rand.NewSource(1)))
+		require.ErrorContains(t, err, "organization 3 has no folder")
+		opts.orgs = 4
+		_, err = seed(ctx, st, opts, rand.New(rand.NewSource(1)))
+		require.ErrorContains(t, err, "4 organizations are required")
+	})
+}
+
+func TestParseIntervals(t *testing.T) {
+	intervals, err := parseIntervals("10s:5, 1m:3,5m")
+	require.NoError(t, err)
+	require.Equal(t, []weightedInterval{{10 * time.Second, 5}, {time.Minute, 3}, {5 * time.Minute, 1}}, intervals)
+
+	for _, invalid := range []string{"", "10", "10s:x", "-1m", "1m:-2"} {
+		_, err := parseIntervals(invalid)
+		require.Errorf(t, err, "intervals %q", invalid)
+	}
+}
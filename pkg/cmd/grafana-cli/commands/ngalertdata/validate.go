@@ -0,0 +1,249 @@
+package ngalertdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/provisioning/alerting"
+	"github.com/grafana/grafana/pkg/services/provisioning/alerting/file"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+type validateOptions struct {
+	baseInterval time.Duration
+	// datasourceUIDs are the data sources that the queries can use. Any data source is accepted if it is empty.
+	datasourceUIDs map[string]struct{}
+}
+
+// fileProblems are the problems found in a provisioning file.
+type fileProblems struct {
+	path string
+	// err is set if the file cannot be read or parsed, and the rules are not validated then.
+	err      error
+	rules    int
+	problems []string
+}
+
+// Validate checks the alert rules of provisioning files without a database, and fails if any problem is found. The
+// path is a file, or a directory whose .yaml, .yml and .json files are checked.
+func Validate(c utils.CommandLine) error {
+	return validateCommand(c, os.Stdout)
+}
+
+func validateCommand(c utils.CommandLine, w io.Writer) error {
+	path := c.Args().First()
+	if path == "" {
+		return errors.New("the file or directory to validate is required")
+	}
+	opts := validateOptions{}
+	var err error
+	if opts.baseInterval, err = time.ParseDuration(c.String("base-interval")); err != nil || opts.baseInterval < time.Second {
+		return fmt.Errorf("invalid base interval %q", c.String("base-interval"))
+	}
+	if uids := util.SplitString(strings.ReplaceAll(c.String("datasource-uids"), ",", " ")); len(uids) > 0 {
+		opts.datasourceUIDs = make(map[string]struct{}, len(uids))
+		for _, uid := range uids {
+			opts.datasourceUIDs[uid] = struct{}{}
+		}
+	}
+
+	paths, err := listProvisioningFiles(path)
+	if err != nil {
+		return err
+	}
+	problems, rules := 0, 0
+	for _, p := range paths {
+		result := validateFile(p, opts)
+		rules += result.rules
+		if result.err != nil {
+			problems++
+			fmt.Fprintf(w, "%s %s: %s\n", color.RedString("✘"), result.path, result.err)
+			continue
+		}
+		if len(result.problems) == 0 {
+			fmt.Fprintf(w, "%s %s: %d alert rules\n", color.GreenString("✔"), result.path, result.rules)
+			continue
+		}
+		problems += len(result.problems)
+		fmt.Fprintf(w, "%s %s:\n", color.RedString("✘"), result.path)
+		for _, problem := range result.problems {
+			fmt.Fprintf(w, "    %s\n", problem)
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("found %d problems in %d files", problems, len(paths))
+	}
+	fmt.Fprintf(w, "%s %d alert rules of %d files are valid\n", color.GreenString("✔"), rules, len(paths))
+	return nil
+}
+
+// listProvisioningFiles returns the path if it is a file, or the provisioning files of the directory otherwise.
+func listProvisioningFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		result = append(result, filepath.Join(path, e.Name()))
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no .yaml, .yml or .json files found in %s", path)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func validateFile(path string, opts validateOptions) fileProblems {
+	result := fileProblems{path: path}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		result.err = err
+		return result
+	}
+	// yaml is a superset of json, which is how the provisioning reads both formats
+	var f alerting.AlertingFileV1
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		result.err = fmt.Errorf("failed to parse the file: %w", err)
+		return result
+	}
+	for _, group := range f.Groups {
+		groupName := fmt.Sprintf("group %q", group.Name.Value())
+		for _, problem := range validateGroup(group, opts) {
+			result.problems = append(result.problems, fmt.Sprintf("%s: %s", groupName, problem))
+		}
+		for i, rule := range group.Rules {
+			result.rules++
+			ruleName := fmt.Sprintf("rule %q", rule.Title.Value())
+			if rule.Title.Value() == "" {
+				ruleName = fmt.Sprintf("rule #%d", i+1)
+			}
+			for _, problem := range validateRule(rule, opts) {
+				result.problems = append(result.problems, fmt.Sprintf("%s: %s: %s", groupName, ruleName, problem))
+			}
+		}
+	}
+	return result
+}
+
+func validateGroup(group file.AlertRuleGroupV1, opts validateOptions) []string {
+	var problems []string
+	if strings.TrimSpace(group.Name.Value()) == "" {
+		problems = append(problems, "no name set")
+	}
+	if strings.TrimSpace(group.Folder.Value()) == "" {
+		problems = append(problems, "no folder set")
+	}
+	interval, err := model.ParseDuration(group.Interval.Value())
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("invalid interval: %s", err))
+	} else if err := models.ValidateRuleGroupInterval(int64(time.Duration(interval).Seconds()), int64(opts.baseInterval.Seconds())); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if len(group.Rules) == 0 {
+		problems = append(problems, "no rules set")
+	}
+	return problems
+}
+
+func validateRule(rule file.AlertRuleV1, opts validateOptions) []string {
+	var problems []string
+	if rule.Title.Value() == "" {
+		problems = append(problems, "no title set")
+	}
+	if rule.UID.Value() == "" {
+		problems = append(problems, "no UID set")
+	}
+	if _, err := model.ParseDuration(rule.For.Value()); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid for: %s", err))
+	}
+	if s := strings.TrimSpace(rule.NoDataState.Value()); s != "" {
+		if _, err := models.NoDataStateFromString(s); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid noDataState: %s", err))
+		}
+	}
+	if s := strings.TrimSpace(rule.ExecErrState.Value()); s != "" {
+		if _, err := models.ErrStateFromString(s); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid execErrState: %s", err))
+		}
+	}
+
+	if len(rule.Data) == 0 {
+		problems = append(problems, "no data set")
+	}
+	refIDs := make(map[string]struct{}, len(rule.Data))
+	for i, q := range rule.Data {
+		refID := q.RefID.Value()
+		queryName := fmt.Sprintf("query %q", refID)
+		if refID == "" {
+			queryName = fmt.Sprintf("query #%d", i+1)
+			problems = append(problems, fmt.Sprintf("%s: no refId set", queryName))
+		} else if _, ok := refIDs[refID]; ok {
+			problems = append(problems, fmt.Sprintf("%s: duplicate refId", queryName))
+		}
+		refIDs[refID] = struct{}{}
+		for _, problem := range validateQuery(q, opts) {
+			problems = append(problems, fmt.Sprintf("%s: %s", queryName, problem))
+		}
+	}
+
+	condition := rule.Condition.Value()
+	if condition == "" {
+		problems = append(problems, "no condition set")
+	} else if _, ok := refIDs[condition]; !ok && len(rule.Data) > 0 {
+		problems = append(problems, fmt.Sprintf("condition %q does not refer to any query or expression", condition))
+	}
+	return problems
+}
+
+func validateQuery(q file.QueryV1, opts validateOptions) []string {
+	var problems []string
+	// the raw model is used because Value interpolates macros such as $__timeFilter, like the provisioning does
+	mdl, err := json.Marshal(q.Model.Raw)
+	if err != nil {
+		return append(problems, fmt.Sprintf("invalid model: %s", err))
+	}
+	query := models.AlertQuery{
+		RefID:             q.RefID.Value(),
+		QueryType:         q.QueryType.Value(),
+		DatasourceUID:     q.DatasourceUID.Value(),
+		RelativeTimeRange: q.RelativeTimeRange,
+		Model:             mdl,
+	}
+	if err := query.PreSave(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if query.DatasourceUID == "" {
+		problems = append(problems, "no datasourceUid set")
+	} else if opts.datasourceUIDs != nil && !expr.IsDataSource(query.DatasourceUID) {
+		if _, ok := opts.datasourceUIDs[query.DatasourceUID]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown data source %q", query.DatasourceUID))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,66 @@
+package ngalertdata
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/grafana/grafana/pkg/cmd/grafana-cli/utils"
+)
+
+func TestValidate(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	// run runs the command with the arguments, and returns its output and error.
+	run := func(t *testing.T, args ...string) (string, error) {
+		t.Helper()
+		flagSet := flag.NewFlagSet("validate", flag.ContinueOnError)
+		flagSet.String("base-interval", "10s", "")
+		flagSet.String("datasource-uids", "", "")
+		require.NoError(t, flagSet.Parse(args))
+		var out bytes.Buffer
+		err := validateCommand(&utils.ContextCommandLine{Context: cli.NewContext(&cli.App{}, flagSet, nil)}, &out)
+		return out.String(), err
+	}
+
+	t.Run("should accept the valid files of a directory", func(t *testing.T) {
+		out, err := run(t, "--datasource-uids", "prometheus,loki", "testdata/validate/valid")
+		require.NoError(t, err)
+		require.Equal(t, "✔ testdata/validate/valid/rules.json: 1 alert rules\n"+
+			"✔ testdata/validate/valid/rules.yaml: 1 alert rules\n"+
+			"✔ 2 alert rules of 2 files are valid\n", out)
+	})
+
+	t.Run("should validate the interval against the base interval", func(t *testing.T) {
+		out, err := run(t, "--base-interval", "1m", "testdata/validate/valid/rules.json")
+		require.EqualError(t, err, "found 1 problems in 1 files")
+		require.Equal(t, "✘ testdata/validate/valid/rules.json:\n"+
+			"    group \"memory\": invalid alert rule: interval (30s) should be non-zero and divided exactly by scheduler interval: 60\n", out)
+	})
+
+	t.Run("should report every problem of the invalid files", func(t *testing.T) {
+		out, err := run(t, "--datasource-uids", "prometheus", "testdata/validate/invalid")
+		require.EqualError(t, err, "found 9 problems in 2 files")
+		require.Equal(t, "✘ testdata/validate/invalid/broken.yaml: failed to parse the file: yaml: line 1: did not find expected ',' or ']'\n"+
+			"✘ testdata/validate/invalid/rules.yaml:\n"+
+			"    group \"cpu\": invalid alert rule: interval (15s) should be non-zero and divided exactly by scheduler interval: 10\n"+
+			"    group \"cpu\": rule \"High CPU\": query \"A\": invalid relative time range: {From:0s To:10m0s}\n"+
+			"    group \"cpu\": rule \"High CPU\": query \"B\": failed to set query type to query model: query model must be a JSON object\n"+
+			"    group \"cpu\": rule \"High CPU\": query \"B\": unknown data source \"unknown\"\n"+
+			"    group \"cpu\": rule \"High CPU\": condition \"C\" does not refer to any query or expression\n"+
+			"    group \"cpu\": rule #2: no title set\n"+
+			"    group \"cpu\": rule #2: no UID set\n"+
+			"    group \"cpu\": rule #2: invalid execErrState: unknown Error state option Broken\n", out)
+	})
+
+	t.Run("should fail if the path does not exist", func(t *testing.T) {
+		_, err := run(t, "testdata/validate/missing")
+		require.ErrorContains(t, err, "no such file or directory")
+	})
+}
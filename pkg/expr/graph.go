@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"gonum.org/v1/gonum/graph/simple"
 	"gonum.org/v1/gonum/graph/topo"
 
@@ -50,7 +52,7 @@ type DataPipeline []Node
 func (dp *DataPipeline) execute(c context.Context, now time.Time, s *Service) (mathexp.Vars, error) {
 	vars := make(mathexp.Vars)
 	for _, node := range *dp {
-		res, err := node.Execute(c, now, vars, s)
+		res, err := s.executeNode(c, now, vars, node)
 		if err != nil {
 			return nil, err
 		}
@@ -60,6 +62,24 @@ func (dp *DataPipeline) execute(c context.Context, now time.Time, s *Service) (m
 	return vars, nil
 }
 
+// executeNode executes the node in its own span, so that the queries of the data sources and the expressions appear
+// separately in the traces. The context of the span is passed to the node, so that the spans of the data source
+// plugins are its children.
+func (s *Service) executeNode(ctx context.Context, now time.Time, vars mathexp.Vars, node Node) (mathexp.Results, error) {
+	if s.tracer == nil {
+		return node.Execute(ctx, now, vars, s)
+	}
+	ctx, span := s.tracer.Start(ctx, "SSE.Execute"+node.NodeType().String())
+	defer span.End()
+	span.SetAttributes("node.refId", node.RefID(), attribute.String("node.refId", node.RefID()))
+	res, err := node.Execute(ctx, now, vars, s)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return res, err
+}
+
 // BuildPipeline builds a graph of the nodes, and returns the nodes in an
 // executable order.
 func (s *Service) buildPipeline(req *Request) (DataPipeline, error) {
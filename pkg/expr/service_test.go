@@ -12,6 +12,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	datafakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/setting"
@@ -93,6 +94,45 @@ func TestService(t *testing.T) {
 	}
 }
 
+func TestServiceTracesNodes(t *testing.T) {
+	dsDF := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+		data.NewField("value", nil, []*float64{fp(2)}))
+	tracer := tracing.NewFakeTracer()
+	s := Service{
+		cfg:               setting.NewCfg(),
+		dataService:       &mockEndpoint{Frames: []*data.Frame{dsDF}},
+		dataSourceService: &datafakes.FakeDataSourceService{},
+		tracer:            tracer,
+	}
+	req := &Request{Queries: []Query{
+		{
+			RefID:      "A",
+			DataSource: &datasources.DataSource{OrgID: 1, UID: "test", Type: "test"},
+			JSON:       json.RawMessage(`{ "datasource": { "uid": "1" }, "intervalMs": 1000, "maxDataPoints": 1000 }`),
+			TimeRange:  AbsoluteTimeRange{},
+		},
+		{
+			RefID:      "B",
+			DataSource: DataSourceModel(),
+			JSON:       json.RawMessage(`{ "datasource": { "uid": "__expr__", "type": "__expr__"}, "type": "math", "expression": "$A * 2" }`),
+		},
+	}}
+	pl, err := s.BuildPipeline(req)
+	require.NoError(t, err)
+	_, err = s.ExecutePipeline(context.Background(), time.Now(), pl)
+	require.NoError(t, err)
+
+	require.Len(t, tracer.Spans, 2)
+	require.Equal(t, "SSE.ExecuteDatasource", tracer.Spans[0].Name)
+	require.Equal(t, "A", tracer.Spans[0].Attributes["node.refId"].AsString())
+	require.Equal(t, "SSE.ExecuteExpression", tracer.Spans[1].Name)
+	require.Equal(t, "B", tracer.Spans[1].Attributes["node.refId"].AsString())
+	for _, span := range tracer.Spans {
+		require.True(t, span.IsEnded())
+	}
+}
+
 func fp(f float64) *float64 {
 	return &f
 }
@@ -9,12 +9,6 @@ package featuremgmt
 var (
 	// Register each toggle here
 	standardFeatureFlags = []FeatureFlag{
-		{
-			Name:        "alertingBigTransactions",
-			Description: "Use big transactions for alerting database writes",
-			State:       FeatureStateAlpha,
-			Owner:       grafanaAlertingSquad,
-		},
 		{
 			Name:        "trimDefaults",
 			Description: "Use cue schema to remove values that will be applied automatically",
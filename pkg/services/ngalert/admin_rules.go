@@ -0,0 +1,47 @@
+package ngalert
+
+import (
+	"context"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ListRulesAcrossOrgs returns a page of the alert rules of all organizations, optionally only those whose queries read
+// the data source. It ignores the organization of the caller, so it must be exposed only to Grafana admins, and must be
+// called only if Grafana Alerting is enabled.
+func (ng *AlertNG) ListRulesAcrossOrgs(ctx context.Context, datasourceUID string, page, limit int64) (*apimodels.AdminAlertRules, error) {
+	query := models.ListAlertRulesAcrossOrgsQuery{
+		DatasourceUID: datasourceUID,
+		Limit:         limit,
+		Offset:        (page - 1) * limit,
+	}
+	count, err := ng.ruleStore.CountAlertRulesAcrossOrgs(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ng.ruleStore.ListAlertRulesAcrossOrgs(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &apimodels.AdminAlertRules{
+		TotalCount: count,
+		Page:       page,
+		Limit:      limit,
+		Rules:      make([]apimodels.AdminAlertRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		result.Rules = append(result.Rules, apimodels.AdminAlertRule{
+			OrgID:          rule.OrgID,
+			OrgName:        rule.OrgName,
+			UID:            rule.UID,
+			Title:          rule.Title,
+			NamespaceUID:   rule.NamespaceUID,
+			RuleGroup:      rule.RuleGroup,
+			IsPaused:       rule.IsPaused,
+			DatasourceUIDs: rule.GetDatasourceUIDs(),
+		})
+	}
+	return result, nil
+}
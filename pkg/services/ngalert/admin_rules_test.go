@@ -0,0 +1,108 @@
+package ngalert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationListRulesAcrossOrgs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	sqlStore := db.InitTestDB(t)
+	dbstore := &store.DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.NewNopLogger(),
+	}
+
+	var rules []models.AlertRule
+	uniqueID := models.WithUniqueID()
+	for _, datasourceUID := range []string{"prometheus", "loki"} {
+		o := org.Org{Name: datasourceUID + " team", Created: time.Now(), Updated: time.Now()}
+		require.NoError(t, sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Insert(&o)
+			return err
+		}))
+		rules = append(rules, *models.AlertRuleGen(uniqueID, models.WithOrgID(o.ID), func(rule *models.AlertRule) {
+			rule.IntervalSeconds = 60
+			rule.For = time.Minute
+			q := models.GenerateAlertQuery()
+			q.DatasourceUID = datasourceUID
+			rule.Data = []models.AlertQuery{q}
+			rule.Condition = q.RefID
+		})())
+	}
+	_, err := dbstore.InsertAlertRules(ctx, rules)
+	require.NoError(t, err)
+
+	ng := &AlertNG{ruleStore: dbstore}
+	result, err := ng.ListRulesAcrossOrgs(ctx, "loki", 1, 10)
+	require.NoError(t, err)
+	require.Equal(t, &apimodels.AdminAlertRules{
+		TotalCount: 1,
+		Page:       1,
+		Limit:      10,
+		Rules: []apimodels.AdminAlertRule{{
+			OrgID:          rules[1].OrgID,
+			OrgName:        "loki team",
+			UID:            rules[1].UID,
+			Title:          rules[1].Title,
+			NamespaceUID:   rules[1].NamespaceUID,
+			RuleGroup:      rules[1].RuleGroup,
+			IsPaused:       rules[1].IsPaused,
+			DatasourceUIDs: []string{"loki"},
+		}},
+	}, result)
+
+	result, err = ng.ListRulesAcrossOrgs(ctx, "", 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, result.TotalCount)
+	require.Len(t, result.Rules, 2)
+}
+
+func TestListRulesAcrossOrgs(t *testing.T) {
+	ctx := context.Background()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.OrgNames = map[int64]string{1: "prometheus team", 2: "loki team"}
+	var rules []models.AlertRule
+	for i, datasourceUID := range []string{"prometheus", "loki"} {
+		rules = append(rules, *models.AlertRuleGen(models.WithOrgID(int64(i+1)), func(rule *models.AlertRule) {
+			q := models.GenerateAlertQuery()
+			q.DatasourceUID = datasourceUID
+			rule.Data = []models.AlertQuery{q}
+			rule.Condition = q.RefID
+		})())
+	}
+	_, err := ruleStore.InsertAlertRules(ctx, rules)
+	require.NoError(t, err)
+
+	ng := &AlertNG{ruleStore: ruleStore}
+	result, err := ng.ListRulesAcrossOrgs(ctx, "loki", 1, 10)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, result.TotalCount)
+	require.Len(t, result.Rules, 1)
+	require.Equal(t, "loki team", result.Rules[0].OrgName)
+	require.Equal(t, rules[1].UID, result.Rules[0].UID)
+
+	result, err = ng.ListRulesAcrossOrgs(ctx, "", 2, 1)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, result.TotalCount)
+	require.Len(t, result.Rules, 1)
+	require.Equal(t, rules[1].UID, result.Rules[0].UID)
+}
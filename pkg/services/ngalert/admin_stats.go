@@ -0,0 +1,34 @@
+package ngalert
+
+import (
+	"context"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+// GetStats returns the state of the scheduler, the statistics of the evaluations and notifications since Grafana
+// started, and the number of alert instances in every state. Everything is read from memory except the number of rules
+// in the database, which is a single COUNT query. It must be called only if Grafana Alerting is enabled.
+func (ng *AlertNG) GetStats(ctx context.Context) (*apimodels.AlertingStats, error) {
+	storedRules, err := ng.ruleStore.Count(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	schedulerStats, evaluationStats := ng.schedule.Stats()
+	schedulerStats.StoredRules = storedRules
+
+	result := &apimodels.AlertingStats{
+		Enabled:     true,
+		Scheduler:   &schedulerStats,
+		Evaluations: &evaluationStats,
+		States:      make(map[string]int),
+	}
+	for state, count := range ng.stateManager.CountStates() {
+		result.States[state.String()] = count
+	}
+	if ng.AlertsRouter != nil {
+		notificationStats := ng.AlertsRouter.Stats()
+		result.Notifications = &notificationStats
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package ngalert
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+	"github.com/grafana/grafana/pkg/services/ngalert/sender"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeStatsScheduler struct {
+	schedule.ScheduleService
+	scheduler   apimodels.SchedulerStats
+	evaluations apimodels.EvaluationStats
+}
+
+func (f *fakeStatsScheduler) Stats() (apimodels.SchedulerStats, apimodels.EvaluationStats) {
+	return f.scheduler, f.evaluations
+}
+
+func TestIntegrationGetStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	dbstore := &store.DBstore{
+		SQLStore: db.InitTestDB(t),
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.NewNopLogger(),
+	}
+	uniqueID := models.WithUniqueID()
+	rules := []models.AlertRule{
+		*models.AlertRuleGen(uniqueID, models.WithOrgID(1), models.WithInterval(time.Minute))(),
+		*models.AlertRuleGen(uniqueID, models.WithOrgID(2), models.WithInterval(time.Minute))(),
+	}
+	_, err := dbstore.InsertAlertRules(ctx, rules)
+	require.NoError(t, err)
+
+	stateManager := state.NewManager(state.ManagerCfg{
+		Metrics: metrics.NewNGAlert(prometheus.NewRegistry()).GetStateMetrics(),
+		Clock:   clock.New(),
+	})
+	stateManager.Put([]*state.State{
+		{OrgID: 1, AlertRuleUID: rules[0].UID, CacheID: "a", State: eval.Alerting},
+		{OrgID: 1, AlertRuleUID: rules[0].UID, CacheID: "b", State: eval.Alerting},
+		{OrgID: 2, AlertRuleUID: rules[1].UID, CacheID: "c", State: eval.Normal},
+	})
+
+	ng := &AlertNG{
+		ruleStore: dbstore,
+		schedule: &fakeStatsScheduler{
+			scheduler: apimodels.SchedulerStats{
+				Running:             true,
+				BaseIntervalSeconds: 10,
+				ScheduledRules:      1,
+				RuleRoutines:        1,
+				InFlightEvaluations: 1,
+				Saturation:          1,
+			},
+			evaluations: apimodels.EvaluationStats{Total: 10, Failures: 2, P95DurationSeconds: 0.5},
+		},
+		stateManager: stateManager,
+		AlertsRouter: &sender.AlertsRouter{},
+	}
+
+	stats, err := ng.GetStats(ctx)
+	require.NoError(t, err)
+	body, err := json.Marshal(stats)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"enabled": true,
+		"scheduler": {
+			"running": true,
+			"evaluationPaused": false,
+			"baseIntervalSeconds": 10,
+			"scheduledRules": 1,
+			"storedRules": 2,
+			"ruleRoutines": 1,
+			"inFlightEvaluations": 1,
+			"saturation": 1
+		},
+		"evaluations": {"total": 10, "failures": 2, "p95DurationSeconds": 0.5},
+		"notifications": {"dispatched": 0, "failed": 0},
+		"states": {"Normal": 1, "Alerting": 2, "Pending": 0, "NoData": 0, "Error": 0}
+	}`, string(body))
+}
@@ -3,7 +3,8 @@ package api
 import (
 	"context"
 	"net/url"
-	"time"
+
+	"github.com/benbjohnson/clock"
 
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -25,9 +26,6 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 )
 
-// timeNow makes it possible to test usage of time
-var timeNow = time.Now
-
 type ExternalAlertmanagerProvider interface {
 	AlertmanagersFor(orgID int64) []*url.URL
 	DroppedAlertmanagersFor(orgID int64) []*url.URL
@@ -54,6 +52,24 @@ type Alertmanager interface {
 	TestReceivers(ctx context.Context, c apimodels.TestReceiversConfigBodyParams) (*notifier.TestReceiversResult, error)
 }
 
+// RuleScheduler is the scheduler of alert rules.
+type RuleScheduler interface {
+	// EvaluateNow requests an evaluation of the rule outside of its regular schedule.
+	EvaluateNow(key models.AlertRuleKey) error
+	// ResetState resets the state of the instances of the rule that match the command and returns the number of reset instances.
+	ResetState(ctx context.Context, cmd models.ResetAlertInstancesCommand) (int, error)
+	// PauseEvaluation stops launching new evaluations of all rules until ResumeEvaluation is called.
+	PauseEvaluation()
+	// ResumeEvaluation resumes evaluations paused by PauseEvaluation.
+	ResumeEvaluation()
+	// IsEvaluationPaused returns true if evaluations are paused by PauseEvaluation.
+	IsEvaluationPaused() bool
+	// Status returns the rules known to the scheduler and when they are evaluated next.
+	Status() apimodels.SchedulerStatus
+	// ShadowReport returns the rules in shadow mode that disagreed with their legacy alerts.
+	ShadowReport() apimodels.ShadowModeReport
+}
+
 type AlertingStore interface {
 	GetLatestAlertmanagerConfiguration(ctx context.Context, query *models.GetLatestAlertmanagerConfigurationQuery) (*models.AlertConfiguration, error)
 }
@@ -78,11 +94,16 @@ type API struct {
 	ContactPointService  *provisioning.ContactPointService
 	Templates            *provisioning.TemplateService
 	MuteTimings          *provisioning.MuteTimingService
+	MaintenanceWindows   *provisioning.MaintenanceWindowService
+	Silences             *provisioning.SilenceService
 	AlertRules           *provisioning.AlertRuleService
 	AlertsRouter         *sender.AlertsRouter
 	EvaluatorFactory     eval.EvaluatorFactory
 	FeatureManager       featuremgmt.FeatureToggles
 	Historian            Historian
+	Scheduler            RuleScheduler
+	// Clock tells the time at which rules are evaluated and created by the API.
+	Clock clock.Clock
 
 	AppUrl *url.URL
 }
@@ -120,6 +141,13 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 			log:                logger,
 			cfg:                &api.Cfg.UnifiedAlerting,
 			ac:                 api.AccessControl,
+			scheduler:          api.Scheduler,
+			manager:            api.StateManager,
+			evaluator:          api.EvaluatorFactory,
+			notifier:           api.MultiOrgAlertmanager,
+			appURL:             api.AppUrl,
+			datasourceCache:    api.DatasourceCache,
+			clock:              api.Clock,
 		},
 	), m)
 	api.RegisterTestingApiEndpoints(NewTestingApi(
@@ -132,11 +160,13 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 			cfg:             &api.Cfg.UnifiedAlerting,
 			backtesting:     backtesting.NewEngine(api.AppUrl, api.EvaluatorFactory),
 			featureManager:  api.FeatureManager,
+			clock:           api.Clock,
 		}), m)
 	api.RegisterConfigurationApiEndpoints(NewConfiguration(
 		&ConfigSrv{
 			datasourceService:    api.DatasourceService,
 			store:                api.AdminConfigStore,
+			scheduler:            api.Scheduler,
 			log:                  logger,
 			alertmanagerProvider: api.AlertsRouter,
 		},
@@ -144,10 +174,13 @@ func (api *API) RegisterAPIEndpoints(m *metrics.API) {
 
 	api.RegisterProvisioningApiEndpoints(NewProvisioningApi(&ProvisioningSrv{
 		log:                 logger,
+		ac:                  api.AccessControl,
 		policies:            api.Policies,
 		contactPointService: api.ContactPointService,
 		templates:           api.Templates,
 		muteTimings:         api.MuteTimings,
+		maintenanceWindows:  api.MaintenanceWindows,
+		silences:            api.Silences,
 		alertRules:          api.AlertRules,
 	}), m)
 
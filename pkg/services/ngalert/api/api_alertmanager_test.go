@@ -596,8 +596,8 @@ func silenceGen(mutatorFuncs ...func(*apimodels.PostableSilence)) func() apimode
 
 		matchers := amv2.Matchers{&amv2.Matcher{Name: &testString, IsEqual: &isEqual, IsRegex: &isRegex, Value: &value}}
 		comment := util.GenerateShortUID()
-		starts := strfmt.DateTime(timeNow().Add(-time.Duration(rand.Int63n(9)+1) * time.Second))
-		ends := strfmt.DateTime(timeNow().Add(time.Duration(rand.Int63n(9)+1) * time.Second))
+		starts := strfmt.DateTime(time.Now().Add(-time.Duration(rand.Int63n(9)+1) * time.Second))
+		ends := strfmt.DateTime(time.Now().Add(time.Duration(rand.Int63n(9)+1) * time.Second))
 		createdBy := "User-" + util.GenerateShortUID()
 		s := apimodels.PostableSilence{
 			ID: util.GenerateShortUID(),
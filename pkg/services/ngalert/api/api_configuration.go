@@ -23,6 +23,7 @@ type ConfigSrv struct {
 	datasourceService    datasources.DataSourceService
 	alertmanagerProvider ExternalAlertmanagerProvider
 	store                store.AdminConfigurationStore
+	scheduler            RuleScheduler
 	log                  log.Logger
 }
 
@@ -157,6 +158,33 @@ func (srv ConfigSrv) RouteGetAlertingStatus(c *contextmodel.ReqContext) response
 	resp := apimodels.AlertingStatus{
 		AlertmanagersChoice:      apimodels.AlertmanagersChoice(sendsAlertsTo.String()),
 		NumExternalAlertmanagers: len(externalAlertManagers),
+		EvaluationPaused:         srv.scheduler.IsEvaluationPaused(),
 	}
 	return response.JSON(http.StatusOK, resp)
 }
+
+// RouteGetSchedulerStatus returns the rules known to the scheduler of this Grafana instance and when they are evaluated next.
+func (srv ConfigSrv) RouteGetSchedulerStatus(c *contextmodel.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, srv.scheduler.Status())
+}
+
+// RouteGetShadowModeReport returns the rules migrated from legacy alerts that disagreed with their legacy alerts in shadow
+// mode. The comparisons are kept only in memory of this Grafana instance.
+func (srv ConfigSrv) RouteGetShadowModeReport(c *contextmodel.ReqContext) response.Response {
+	return response.JSON(http.StatusOK, srv.scheduler.ShadowReport())
+}
+
+// RoutePostPauseEvaluation stops evaluation of alert rules of all organizations until it is resumed.
+// The pause is kept only in memory of this Grafana instance and is lost when Grafana restarts.
+func (srv ConfigSrv) RoutePostPauseEvaluation(c *contextmodel.ReqContext) response.Response {
+	srv.scheduler.PauseEvaluation()
+	srv.log.Info("Evaluation of alert rules is paused by user", "userID", c.SignedInUser.UserID, "login", c.SignedInUser.Login)
+	return response.JSON(http.StatusOK, util.DynMap{"message": "evaluation of alert rules is paused"})
+}
+
+// RoutePostResumeEvaluation resumes evaluation of alert rules paused by RoutePostPauseEvaluation.
+func (srv ConfigSrv) RoutePostResumeEvaluation(c *contextmodel.ReqContext) response.Response {
+	srv.scheduler.ResumeEvaluation()
+	srv.log.Info("Evaluation of alert rules is resumed by user", "userID", c.SignedInUser.UserID, "login", c.SignedInUser.Login)
+	return response.JSON(http.StatusOK, util.DynMap{"message": "evaluation of alert rules is resumed"})
+}
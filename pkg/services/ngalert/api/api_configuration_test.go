@@ -4,10 +4,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
@@ -92,12 +94,113 @@ func TestExternalAlertmanagerChoice(t *testing.T) {
 	}
 }
 
+func TestRoutePauseEvaluation(t *testing.T) {
+	sut := createAPIAdminSut(t, nil)
+	ctx := createRequestCtxInOrg(1)
+
+	getStatus := func(t *testing.T) definitions.AlertingStatus {
+		t.Helper()
+		resp := sut.RouteGetAlertingStatus(ctx)
+		require.Equal(t, http.StatusOK, resp.Status())
+		var status definitions.AlertingStatus
+		require.NoError(t, json.Unmarshal(resp.Body(), &status))
+		return status
+	}
+
+	require.False(t, getStatus(t).EvaluationPaused)
+
+	resp := sut.RoutePostPauseEvaluation(ctx)
+	require.Equal(t, http.StatusOK, resp.Status())
+	require.True(t, getStatus(t).EvaluationPaused)
+
+	resp = sut.RoutePostResumeEvaluation(ctx)
+	require.Equal(t, http.StatusOK, resp.Status())
+	require.False(t, getStatus(t).EvaluationPaused)
+}
+
+func TestRouteGetSchedulerStatus(t *testing.T) {
+	lastEvaluation := time.Date(2023, 3, 24, 7, 0, 0, 0, time.UTC)
+	nextEvaluation := lastEvaluation.Add(time.Minute)
+	scheduler := &fakeRuleScheduler{status: definitions.SchedulerStatus{
+		InstanceID:               "instance-1",
+		BaseIntervalSeconds:      10,
+		RuleRoutines:             2,
+		InFlightEvaluations:      1,
+		MaxConcurrentEvaluations: 4,
+		Rules: []definitions.ScheduledRule{
+			{
+				ID:                     1,
+				UID:                    "rule-1",
+				OrgID:                  1,
+				Title:                  "evaluated",
+				IntervalSeconds:        60,
+				LastEvaluation:         &lastEvaluation,
+				LastEvaluationDuration: 1.5,
+				LastEvaluationState:    "Error",
+				LastError:              "failed to query data",
+				NextEvaluation:         &nextEvaluation,
+			},
+			{
+				ID:               2,
+				UID:              "rule-2",
+				OrgID:            2,
+				Title:            "paused",
+				IntervalSeconds:  60,
+				Schedule:         "0 8 * * 1-5",
+				ScheduleTimezone: "Europe/Helsinki",
+				IsPaused:         true,
+			},
+		},
+	}}
+	sut := createAPIAdminSut(t, nil)
+	sut.scheduler = scheduler
+
+	resp := sut.RouteGetSchedulerStatus(createRequestCtxInOrg(1))
+	require.Equal(t, http.StatusOK, resp.Status())
+	require.JSONEq(t, `{
+		"instanceId": "instance-1",
+		"baseIntervalSeconds": 10,
+		"evaluationPaused": false,
+		"ruleRoutines": 2,
+		"inFlightEvaluations": 1,
+		"maxConcurrentEvaluations": 4,
+		"rules": [
+			{
+				"id": 1,
+				"uid": "rule-1",
+				"orgId": 1,
+				"title": "evaluated",
+				"intervalSeconds": 60,
+				"isPaused": false,
+				"lastEvaluation": "2023-03-24T07:00:00Z",
+				"lastEvaluationDuration": 1.5,
+				"lastEvaluationState": "Error",
+				"lastError": "failed to query data",
+				"nextEvaluation": "2023-03-24T07:01:00Z"
+			},
+			{
+				"id": 2,
+				"uid": "rule-2",
+				"orgId": 2,
+				"title": "paused",
+				"intervalSeconds": 60,
+				"schedule": "0 8 * * 1-5",
+				"scheduleTimezone": "Europe/Helsinki",
+				"isPaused": true,
+				"lastEvaluationDuration": 0
+			}
+		]
+	}`, string(resp.Body()))
+}
+
 func createAPIAdminSut(t *testing.T,
 	datasources []*datasources.DataSource) ConfigSrv {
 	return ConfigSrv{
 		datasourceService: &fakeDatasources.FakeDataSourceService{
 			DataSources: datasources,
 		},
-		store: store.NewFakeAdminConfigStore(t),
+		store:     store.NewFakeAdminConfigStore(t),
+		scheduler: &fakeRuleScheduler{},
+		log:       log.NewNopLogger(),
 	}
 }
@@ -115,6 +115,8 @@ func (srv PrometheusSrv) RouteGetRuleStatuses(c *contextmodel.ReqContext) respon
 		return ErrResp(http.StatusBadRequest, errors.New("panel_id must be set with dashboard_uid"), "")
 	}
 
+	titleSearch := c.Query("query")
+
 	ruleResponse := apimodels.RuleResponse{
 		DiscoveryBase: apimodels.DiscoveryBase{
 			Status: "success",
@@ -123,6 +125,13 @@ func (srv PrometheusSrv) RouteGetRuleStatuses(c *contextmodel.ReqContext) respon
 			RuleGroups: []*apimodels.RuleGroup{},
 		},
 	}
+	if titleSearch != "" || dashboardUID != "" {
+		ruleResponse.Data.Filters = &apimodels.RuleDiscoveryFilters{
+			Query:        titleSearch,
+			DashboardUID: dashboardUID,
+			PanelID:      panelID,
+		}
+	}
 
 	var labelOptions []ngmodels.LabelOption
 	if !c.QueryBoolWithDefault(queryIncludeInternalLabels, false) {
@@ -149,6 +158,7 @@ func (srv PrometheusSrv) RouteGetRuleStatuses(c *contextmodel.ReqContext) respon
 		NamespaceUIDs: namespaceUIDs,
 		DashboardUID:  dashboardUID,
 		PanelID:       panelID,
+		TitleSearch:   titleSearch,
 	}
 	ruleList, err := srv.store.ListAlertRules(c.Req.Context(), &alertRuleQuery)
 	if err != nil {
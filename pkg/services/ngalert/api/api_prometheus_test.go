@@ -246,7 +246,7 @@ func withAlertingState() forEachState {
 		value := float64(1.1)
 		s.Results = append(s.Results, state.Evaluation{
 			EvaluationState: eval.Alerting,
-			EvaluationTime:  timeNow(),
+			EvaluationTime:  time.Now(),
 			Values:          map[string]*float64{"B": &value},
 			Condition:       "B",
 		})
@@ -255,7 +255,6 @@ func withAlertingState() forEachState {
 }
 
 func TestRouteGetRuleStatuses(t *testing.T) {
-	timeNow = func() time.Time { return time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC) }
 	orgID := int64(1)
 
 	req, err := http.NewRequest("GET", "/api/v1/rules", nil)
@@ -467,6 +466,62 @@ func TestRouteGetRuleStatuses(t *testing.T) {
 		})
 	})
 
+	t.Run("with a query", func(t *testing.T) {
+		ruleStore := fakes.NewRuleStore(t)
+		groupKey := ngmodels.GenerateGroupKey(orgID)
+		titles := []string{"Kafka lag", "consumer kafka errors", "disk usage"}
+		for i, title := range titles {
+			ruleStore.PutRule(context.Background(), ngmodels.AlertRuleGen(withGroupKey(groupKey), func(rule *ngmodels.AlertRule) {
+				rule.Title = title
+				rule.RuleGroupIndex = i
+			})())
+		}
+		api := PrometheusSrv{
+			log:     log.NewNopLogger(),
+			manager: NewFakeAlertInstanceManager(t),
+			store:   ruleStore,
+			ac:      acmock.New().WithDisabled(),
+		}
+		getWithQuery := func(t *testing.T, query string) apimodels.RuleResponse {
+			t.Helper()
+			req, err := http.NewRequest("GET", "/api/v1/rules?"+query, nil)
+			require.NoError(t, err)
+			c := &contextmodel.ReqContext{Context: &web.Context{Req: req}, SignedInUser: &user.SignedInUser{OrgID: orgID, OrgRole: org.RoleViewer}}
+			response := api.RouteGetRuleStatuses(c)
+			require.Equal(t, http.StatusOK, response.Status())
+			var result apimodels.RuleResponse
+			require.NoError(t, json.Unmarshal(response.Body(), &result))
+			return result
+		}
+		ruleNames := func(result apimodels.RuleResponse) []string {
+			var names []string
+			for _, group := range result.Data.RuleGroups {
+				for _, rule := range group.Rules {
+					names = append(names, rule.Name)
+				}
+			}
+			return names
+		}
+
+		t.Run("should return only rules whose name contains the term and echo the filters", func(t *testing.T) {
+			result := getWithQuery(t, "query=KAFKA")
+			require.Equal(t, []string{"Kafka lag", "consumer kafka errors"}, ruleNames(result))
+			require.Equal(t, &apimodels.RuleDiscoveryFilters{Query: "KAFKA"}, result.Data.Filters)
+		})
+
+		t.Run("should compose with the dashboard filter", func(t *testing.T) {
+			result := getWithQuery(t, "query=kafka&dashboard_uid=unknown")
+			require.Empty(t, result.Data.RuleGroups)
+			require.Equal(t, &apimodels.RuleDiscoveryFilters{Query: "kafka", DashboardUID: "unknown"}, result.Data.Filters)
+		})
+
+		t.Run("should return all rules if the query is empty", func(t *testing.T) {
+			result := getWithQuery(t, "query=")
+			require.Equal(t, titles, ruleNames(result))
+			require.Nil(t, result.Data.Filters)
+		})
+	})
+
 	t.Run("when fine-grained access is enabled", func(t *testing.T) {
 		t.Run("should return only rules if the user can query all data sources", func(t *testing.T) {
 			ruleStore := fakes.NewRuleStore(t)
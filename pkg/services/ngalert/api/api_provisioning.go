@@ -2,13 +2,22 @@ package api
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	alerting_models "github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -18,14 +27,20 @@ import (
 	"github.com/grafana/grafana/pkg/util"
 )
 
-const disableProvenanceHeaderName = "X-Disable-Provenance"
+const (
+	disableProvenanceHeaderName = "X-Disable-Provenance"
+	idempotencyKeyHeader        = "Idempotency-Key"
+)
 
 type ProvisioningSrv struct {
 	log                 log.Logger
+	ac                  accesscontrol.AccessControl
 	policies            NotificationPolicyService
 	contactPointService ContactPointService
 	templates           TemplateService
 	muteTimings         MuteTimingService
+	maintenanceWindows  MaintenanceWindowService
+	silences            SilenceService
 	alertRules          AlertRuleService
 }
 
@@ -55,10 +70,28 @@ type MuteTimingService interface {
 	DeleteMuteTiming(ctx context.Context, name string, orgID int64) error
 }
 
+type MaintenanceWindowService interface {
+	GetMaintenanceWindows(ctx context.Context, orgID int64) ([]*alerting_models.MaintenanceWindow, error)
+	GetMaintenanceWindow(ctx context.Context, orgID, id int64) (*alerting_models.MaintenanceWindow, error)
+	CreateMaintenanceWindow(ctx context.Context, window alerting_models.MaintenanceWindow, orgID int64, userID int64) (*alerting_models.MaintenanceWindow, error)
+	UpdateMaintenanceWindow(ctx context.Context, window alerting_models.MaintenanceWindow, orgID int64) (*alerting_models.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(ctx context.Context, orgID, id int64) error
+}
+
+type SilenceService interface {
+	GetSilences(ctx context.Context, orgID int64) ([]*alerting_models.Silence, error)
+	GetSilence(ctx context.Context, orgID, id int64) (*alerting_models.Silence, error)
+	CreateSilence(ctx context.Context, silence alerting_models.Silence, orgID int64, userID int64) (*alerting_models.Silence, error)
+	UpdateSilence(ctx context.Context, silence alerting_models.Silence, orgID int64) (*alerting_models.Silence, error)
+	DeleteSilence(ctx context.Context, orgID, id int64) error
+}
+
 type AlertRuleService interface {
 	GetAlertRules(ctx context.Context, orgID int64) ([]*alerting_models.AlertRule, error)
+	GetAlertRuleSummaries(ctx context.Context, orgID int64) ([]*alerting_models.AlertRuleSummary, map[string]*alerting_models.AlertInstanceStateSummary, error)
 	GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (alerting_models.AlertRule, alerting_models.Provenance, error)
 	CreateAlertRule(ctx context.Context, rule alerting_models.AlertRule, provenance alerting_models.Provenance, userID int64) (alerting_models.AlertRule, error)
+	CreateAlertRuleIdempotently(ctx context.Context, rule alerting_models.AlertRule, provenance alerting_models.Provenance, userID int64, key, requestHash string) (alerting_models.AlertRule, bool, error)
 	UpdateAlertRule(ctx context.Context, rule alerting_models.AlertRule, provenance alerting_models.Provenance) (alerting_models.AlertRule, error)
 	DeleteAlertRule(ctx context.Context, orgID int64, ruleUID string, provenance alerting_models.Provenance) error
 	GetRuleGroup(ctx context.Context, orgID int64, folder, group string) (alerting_models.AlertRuleGroup, error)
@@ -66,6 +99,8 @@ type AlertRuleService interface {
 	GetAlertRuleWithFolderTitle(ctx context.Context, orgID int64, ruleUID string) (provisioning.AlertRuleWithFolderTitle, error)
 	GetAlertRuleGroupWithFolderTitle(ctx context.Context, orgID int64, folder, group string) (file.AlertRuleGroupWithFolderTitle, error)
 	GetAlertGroupsWithFolderTitle(ctx context.Context, orgID int64) ([]file.AlertRuleGroupWithFolderTitle, error)
+	GetPrometheusRulesExport(ctx context.Context, orgID int64, folderUID, group string) (definitions.PrometheusRulesExport, error)
+	ImportPrometheusRules(ctx context.Context, orgID int64, folderUID, datasourceUID string, groups []definitions.PrometheusRuleGroup, userID int64, provenance alerting_models.Provenance) (definitions.PrometheusImportResult, error)
 }
 
 func (srv *ProvisioningSrv) RouteGetPolicyTree(c *contextmodel.ReqContext) response.Response {
@@ -145,6 +180,9 @@ func (srv *ProvisioningSrv) RoutePutContactPoint(c *contextmodel.ReqContext, cp
 func (srv *ProvisioningSrv) RouteDeleteContactPoint(c *contextmodel.ReqContext, UID string) response.Response {
 	err := srv.contactPointService.DeleteContactPoint(c.Req.Context(), c.OrgID, UID)
 	if err != nil {
+		if errors.Is(err, alerting_models.ErrContactPointInUse) {
+			return ErrResp(http.StatusConflict, err, "")
+		}
 		return ErrResp(http.StatusInternalServerError, err, "")
 	}
 	return response.JSON(http.StatusAccepted, util.DynMap{"message": "contactpoint deleted"})
@@ -254,39 +292,232 @@ func (srv *ProvisioningSrv) RouteDeleteMuteTiming(c *contextmodel.ReqContext, na
 	return response.JSON(http.StatusNoContent, nil)
 }
 
+func (srv *ProvisioningSrv) RouteGetMaintenanceWindows(c *contextmodel.ReqContext) response.Response {
+	windows, err := srv.maintenanceWindows.GetMaintenanceWindows(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	result := make(definitions.MaintenanceWindows, 0, len(windows))
+	for _, w := range windows {
+		result = append(result, ApiMaintenanceWindowFromMaintenanceWindow(*w))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (srv *ProvisioningSrv) RouteGetMaintenanceWindow(c *contextmodel.ReqContext, id string) response.Response {
+	windowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid maintenance window ID")
+	}
+	window, err := srv.maintenanceWindows.GetMaintenanceWindow(c.Req.Context(), c.OrgID, windowID)
+	if err != nil {
+		if errors.Is(err, provisioning.ErrNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, ApiMaintenanceWindowFromMaintenanceWindow(*window))
+}
+
+func (srv *ProvisioningSrv) RoutePostMaintenanceWindow(c *contextmodel.ReqContext, mw definitions.MaintenanceWindow) response.Response {
+	created, err := srv.maintenanceWindows.CreateMaintenanceWindow(c.Req.Context(), MaintenanceWindowFromApiMaintenanceWindow(mw), c.OrgID, alerting_models.ActorID(c.SignedInUser))
+	if err != nil {
+		if errors.Is(err, provisioning.ErrValidation) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusCreated, ApiMaintenanceWindowFromMaintenanceWindow(*created))
+}
+
+func (srv *ProvisioningSrv) RoutePutMaintenanceWindow(c *contextmodel.ReqContext, mw definitions.MaintenanceWindow, id string) response.Response {
+	windowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid maintenance window ID")
+	}
+	window := MaintenanceWindowFromApiMaintenanceWindow(mw)
+	window.ID = windowID
+	updated, err := srv.maintenanceWindows.UpdateMaintenanceWindow(c.Req.Context(), window, c.OrgID)
+	if err != nil {
+		if errors.Is(err, provisioning.ErrValidation) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		if errors.Is(err, provisioning.ErrNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, ApiMaintenanceWindowFromMaintenanceWindow(*updated))
+}
+
+func (srv *ProvisioningSrv) RouteDeleteMaintenanceWindow(c *contextmodel.ReqContext, id string) response.Response {
+	windowID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid maintenance window ID")
+	}
+	err = srv.maintenanceWindows.DeleteMaintenanceWindow(c.Req.Context(), c.OrgID, windowID)
+	if err != nil {
+		if errors.Is(err, provisioning.ErrNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusNoContent, nil)
+}
+
+func (srv *ProvisioningSrv) RouteGetSilences(c *contextmodel.ReqContext) response.Response {
+	silences, err := srv.silences.GetSilences(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	result := make(definitions.Silences, 0, len(silences))
+	for _, s := range silences {
+		result = append(result, ApiSilenceFromSilence(*s))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+func (srv *ProvisioningSrv) RouteGetSilence(c *contextmodel.ReqContext, id string) response.Response {
+	silenceID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid silence ID")
+	}
+	silence, err := srv.silences.GetSilence(c.Req.Context(), c.OrgID, silenceID)
+	if err != nil {
+		if errors.Is(err, provisioning.ErrNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, ApiSilenceFromSilence(*silence))
+}
+
+func (srv *ProvisioningSrv) RoutePostSilence(c *contextmodel.ReqContext, s definitions.Silence) response.Response {
+	created, err := srv.silences.CreateSilence(c.Req.Context(), SilenceFromApiSilence(s), c.OrgID, alerting_models.ActorID(c.SignedInUser))
+	if err != nil {
+		if errors.Is(err, provisioning.ErrValidation) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusCreated, ApiSilenceFromSilence(*created))
+}
+
+func (srv *ProvisioningSrv) RoutePutSilence(c *contextmodel.ReqContext, s definitions.Silence, id string) response.Response {
+	silenceID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid silence ID")
+	}
+	silence := SilenceFromApiSilence(s)
+	silence.ID = silenceID
+	updated, err := srv.silences.UpdateSilence(c.Req.Context(), silence, c.OrgID)
+	if err != nil {
+		if errors.Is(err, provisioning.ErrValidation) {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		if errors.Is(err, provisioning.ErrNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, ApiSilenceFromSilence(*updated))
+}
+
+func (srv *ProvisioningSrv) RouteDeleteSilence(c *contextmodel.ReqContext, id string) response.Response {
+	silenceID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "invalid silence ID")
+	}
+	err = srv.silences.DeleteSilence(c.Req.Context(), c.OrgID, silenceID)
+	if err != nil {
+		if errors.Is(err, provisioning.ErrNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusNoContent, nil)
+}
+
 func (srv *ProvisioningSrv) RouteGetAlertRules(c *contextmodel.ReqContext) response.Response {
 	rules, err := srv.alertRules.GetAlertRules(c.Req.Context(), c.OrgID)
 	if err != nil {
 		return ErrResp(http.StatusInternalServerError, err, "")
 	}
-	return response.JSON(http.StatusOK, ProvisionedAlertRuleFromAlertRules(rules))
+	// the list is as new as the most recently updated rule. Deleting a rule does not update the others, so clients
+	// that need to notice deletions must not send If-Modified-Since.
+	var updated time.Time
+	for _, rule := range rules {
+		if rule.Updated.After(updated) {
+			updated = rule.Updated
+		}
+	}
+	if notModifiedSince(c, updated) {
+		return response.Respond(http.StatusNotModified, "")
+	}
+	return withLastModified(response.JSON(http.StatusOK, ProvisionedAlertRuleFromAlertRules(rules)), updated)
+}
+
+// RouteGetAlertRuleSummaries returns the summaries of the alert rules with the state of their alert instances. Unlike
+// RouteGetAlertRules, it does not read the queries and expressions of the rules, which can be large.
+func (srv *ProvisioningSrv) RouteGetAlertRuleSummaries(c *contextmodel.ReqContext) response.Response {
+	rules, states, err := srv.alertRules.GetAlertRuleSummaries(c.Req.Context(), c.OrgID)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return response.JSON(http.StatusOK, ProvisionedAlertRuleSummariesFromAlertRuleSummaries(rules, states))
 }
 
 func (srv *ProvisioningSrv) RouteRouteGetAlertRule(c *contextmodel.ReqContext, UID string) response.Response {
 	rule, provenace, err := srv.alertRules.GetAlertRule(c.Req.Context(), c.OrgID, UID)
 	if err != nil {
+		if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
 		return ErrResp(http.StatusInternalServerError, err, "")
 	}
-	return response.JSON(http.StatusOK, ProvisionedAlertRuleFromAlertRule(rule, provenace))
+	if notModifiedSince(c, rule.Updated) {
+		return response.Respond(http.StatusNotModified, "")
+	}
+	resp := withLastModified(response.JSON(http.StatusOK, ProvisionedAlertRuleFromAlertRule(rule, provenace)), rule.Updated)
+	return withAlertRuleETag(resp, rule)
 }
 
 func (srv *ProvisioningSrv) RoutePostAlertRule(c *contextmodel.ReqContext, ar definitions.ProvisionedAlertRule) response.Response {
 	upstreamModel, err := AlertRuleFromProvisionedAlertRule(ar)
-	upstreamModel.OrgID = c.OrgID
 	if err != nil {
 		return ErrResp(http.StatusBadRequest, err, "")
 	}
+	upstreamModel.OrgID = c.OrgID
+	if err := srv.authorizeDatasourceAccess(c, upstreamModel); err != nil {
+		return ErrResp(http.StatusForbidden, err, "")
+	}
 	provenance := determineProvenance(c)
-	createdAlertRule, err := srv.alertRules.CreateAlertRule(c.Req.Context(), upstreamModel, provenance, c.UserID)
+	var createdAlertRule alerting_models.AlertRule
+	if key := c.Req.Header.Get(idempotencyKeyHeader); key != "" {
+		if len(key) > store.IdempotencyKeyMaxLength {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("the %s header is too long, the maximum length is %d", idempotencyKeyHeader, store.IdempotencyKeyMaxLength), "")
+		}
+		requestHash, hashErr := hashProvisionedAlertRule(ar)
+		if hashErr != nil {
+			return ErrResp(http.StatusBadRequest, hashErr, "")
+		}
+		createdAlertRule, _, err = srv.alertRules.CreateAlertRuleIdempotently(c.Req.Context(), upstreamModel, provenance, alerting_models.ActorID(c.SignedInUser), key, requestHash)
+	} else {
+		createdAlertRule, err = srv.alertRules.CreateAlertRule(c.Req.Context(), upstreamModel, provenance, c.UserID)
+	}
 	if errors.Is(err, alerting_models.ErrAlertRuleFailedValidation) {
 		return ErrResp(http.StatusBadRequest, err, "")
 	}
 	if err != nil {
-		if errors.Is(err, store.ErrOptimisticLock) {
+		if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "the rule created with this idempotency key does not exist anymore")
+		}
+		if errors.Is(err, store.ErrOptimisticLock) || errors.Is(err, alerting_models.ErrAlertRuleUniqueConstraintViolation) ||
+			errors.Is(err, alerting_models.ErrIdempotencyKeyReused) || errors.Is(err, alerting_models.ErrIdempotencyKeyConflict) {
 			return ErrResp(http.StatusConflict, err, "")
 		}
 		if errors.Is(err, alerting_models.ErrQuotaReached) {
-			return ErrResp(http.StatusForbidden, err, "")
+			return quotaReachedResponse(err)
 		}
 		return ErrResp(http.StatusInternalServerError, err, "")
 	}
@@ -298,10 +529,13 @@ func (srv *ProvisioningSrv) RoutePostAlertRule(c *contextmodel.ReqContext, ar de
 func (srv *ProvisioningSrv) RoutePutAlertRule(c *contextmodel.ReqContext, ar definitions.ProvisionedAlertRule, UID string) response.Response {
 	updated, err := AlertRuleFromProvisionedAlertRule(ar)
 	if err != nil {
-		ErrResp(http.StatusBadRequest, err, "")
+		return ErrResp(http.StatusBadRequest, err, "")
 	}
 	updated.OrgID = c.OrgID
 	updated.UID = UID
+	if err := srv.authorizeDatasourceAccess(c, updated); err != nil {
+		return ErrResp(http.StatusForbidden, err, "")
+	}
 	provenance := determineProvenance(c)
 	updatedAlertRule, err := srv.alertRules.UpdateAlertRule(c.Req.Context(), updated, provenance)
 	if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
@@ -311,7 +545,7 @@ func (srv *ProvisioningSrv) RoutePutAlertRule(c *contextmodel.ReqContext, ar def
 		return ErrResp(http.StatusBadRequest, err, "")
 	}
 	if err != nil {
-		if errors.Is(err, store.ErrOptimisticLock) {
+		if errors.Is(err, store.ErrOptimisticLock) || errors.Is(err, alerting_models.ErrAlertRuleUniqueConstraintViolation) {
 			return ErrResp(http.StatusConflict, err, "")
 		}
 		return ErrResp(http.StatusInternalServerError, err, "")
@@ -321,6 +555,66 @@ func (srv *ProvisioningSrv) RoutePutAlertRule(c *contextmodel.ReqContext, ar def
 	return response.JSON(http.StatusOK, resp)
 }
 
+// RoutePatchAlertRule updates the fields of the alert rule that are in the request. If the request has an If-Match header,
+// the rule is updated only if it matches the entity tag of the stored rule. An empty request does not change the rule.
+func (srv *ProvisioningSrv) RoutePatchAlertRule(c *contextmodel.ReqContext, patch definitions.PatchedAlertRule, UID string) response.Response {
+	rule, storedProvenance, err := srv.alertRules.GetAlertRule(c.Req.Context(), c.OrgID, UID)
+	if err != nil {
+		if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	if !matchesIfMatch(c, alertRuleETag(rule)) {
+		return ErrResp(http.StatusPreconditionFailed, fmt.Errorf("alert rule %s was updated, its version is %d", UID, rule.Version), "")
+	}
+	if reflect.DeepEqual(patch, definitions.PatchedAlertRule{}) {
+		return withAlertRuleETag(response.JSON(http.StatusOK, ProvisionedAlertRuleFromAlertRule(rule, storedProvenance)), rule)
+	}
+
+	ar := ProvisionedAlertRuleFromAlertRule(rule, storedProvenance)
+	applyAlertRulePatch(&ar, patch)
+	updated, err := AlertRuleFromProvisionedAlertRule(ar)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	// the rule is updated only if it has not been changed since it was read
+	updated.Version = rule.Version
+	if err := srv.authorizeDatasourceAccess(c, updated); err != nil {
+		return ErrResp(http.StatusForbidden, err, "")
+	}
+	provenance := determineProvenance(c)
+	updatedAlertRule, err := srv.alertRules.UpdateAlertRule(c.Req.Context(), updated, provenance)
+	if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+		return ErrResp(http.StatusNotFound, err, "")
+	}
+	if errors.Is(err, alerting_models.ErrAlertRuleFailedValidation) {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrOptimisticLock) || errors.Is(err, alerting_models.ErrAlertRuleUniqueConstraintViolation) {
+			return ErrResp(http.StatusConflict, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	return withAlertRuleETag(response.JSON(http.StatusOK, ProvisionedAlertRuleFromAlertRule(updatedAlertRule, provenance)), updatedAlertRule)
+}
+
+func (srv *ProvisioningSrv) RoutePostAlertRuleDiff(c *contextmodel.ReqContext, ar definitions.ProvisionedAlertRule, UID string) response.Response {
+	rule, provenance, err := srv.alertRules.GetAlertRule(c.Req.Context(), c.OrgID, UID)
+	if err != nil {
+		if errors.Is(err, alerting_models.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "")
+	}
+	diff, err := diffProvisionedAlertRules(ProvisionedAlertRuleFromAlertRule(rule, provenance), ar)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	return response.JSON(http.StatusOK, diff)
+}
+
 func (srv *ProvisioningSrv) RouteDeleteAlertRule(c *contextmodel.ReqContext, UID string) response.Response {
 	err := srv.alertRules.DeleteAlertRule(c.Req.Context(), c.OrgID, UID, alerting_models.ProvenanceAPI)
 	if err != nil {
@@ -355,6 +649,42 @@ func (srv *ProvisioningSrv) RouteGetAlertRulesExport(c *contextmodel.ReqContext)
 	return exportResponse(c, e)
 }
 
+// RouteGetAlertRulesPrometheusExport retrieves the alert rules as Prometheus rule groups, keyed by the title of their folder.
+func (srv *ProvisioningSrv) RouteGetAlertRulesPrometheusExport(c *contextmodel.ReqContext) response.Response {
+	e, err := srv.alertRules.GetPrometheusRulesExport(c.Req.Context(), c.OrgID, c.Query("folderUid"), c.Query("group"))
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to export alert rules")
+	}
+
+	return exportResponse(c, e)
+}
+
+// RoutePostAlertRulesPrometheusImport creates or updates alert rules from the alerting rules of a Prometheus rule file.
+func (srv *ProvisioningSrv) RoutePostAlertRulesPrometheusImport(c *contextmodel.ReqContext) response.Response {
+	folderUID, datasourceUID := c.Query("folderUid"), c.Query("datasourceUid")
+	if folderUID == "" || datasourceUID == "" {
+		return ErrResp(http.StatusBadRequest, errors.New("the folderUid and datasourceUid query parameters are required"), "")
+	}
+	// YAML is a superset of JSON, so that the rule file can be sent in either format
+	var ruleFile definitions.PrometheusRuleFile
+	if err := yaml.NewDecoder(c.Req.Body).Decode(&ruleFile); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse the Prometheus rule file")
+	}
+	query := alerting_models.AlertRule{Data: []alerting_models.AlertQuery{{DatasourceUID: datasourceUID}}}
+	if err := srv.authorizeDatasourceAccess(c, query); err != nil {
+		return ErrResp(http.StatusForbidden, err, "")
+	}
+
+	result, err := srv.alertRules.ImportPrometheusRules(c.Req.Context(), c.OrgID, folderUID, datasourceUID, ruleFile.Groups, alerting_models.ActorID(c.SignedInUser), determineProvenance(c))
+	if errors.Is(err, alerting_models.ErrAlertRuleFailedValidation) {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to import the Prometheus rule file")
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 // RouteGetAlertRuleGroupExport retrieves the given alert rule group in a format compatible with file provisioning.
 func (srv *ProvisioningSrv) RouteGetAlertRuleGroupExport(c *contextmodel.ReqContext, folder string, group string) response.Response {
 	g, err := srv.alertRules.GetAlertRuleGroupWithFolderTitle(c.Req.Context(), c.OrgID, folder, group)
@@ -407,7 +737,10 @@ func (srv *ProvisioningSrv) RoutePutAlertRuleGroup(c *contextmodel.ReqContext, a
 	if err != nil {
 		ErrResp(http.StatusBadRequest, err, "")
 	}
-	err = srv.alertRules.ReplaceRuleGroup(c.Req.Context(), c.OrgID, groupModel, c.UserID, alerting_models.ProvenanceAPI)
+	if err := srv.authorizeDatasourceAccess(c, groupModel.Rules...); err != nil {
+		return ErrResp(http.StatusForbidden, err, "")
+	}
+	err = srv.alertRules.ReplaceRuleGroup(c.Req.Context(), c.OrgID, groupModel, alerting_models.ActorID(c.SignedInUser), alerting_models.ProvenanceAPI)
 	if errors.Is(err, alerting_models.ErrAlertRuleFailedValidation) {
 		return ErrResp(http.StatusBadRequest, err, "")
 	}
@@ -415,6 +748,9 @@ func (srv *ProvisioningSrv) RoutePutAlertRuleGroup(c *contextmodel.ReqContext, a
 		if errors.Is(err, store.ErrOptimisticLock) {
 			return ErrResp(http.StatusConflict, err, "")
 		}
+		if errors.Is(err, alerting_models.ErrQuotaReached) {
+			return quotaReachedResponse(err)
+		}
 		return ErrResp(http.StatusInternalServerError, err, "")
 	}
 	return response.JSON(http.StatusOK, ag)
@@ -427,6 +763,127 @@ func determineProvenance(ctx *contextmodel.ReqContext) alerting_models.Provenanc
 	return alerting_models.ProvenanceAPI
 }
 
+// hashProvisionedAlertRule returns the hash of the rule that identifies the request that created it with an idempotency key.
+func hashProvisionedAlertRule(ar definitions.ProvisionedAlertRule) (string, error) {
+	b, err := json.Marshal(ar)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// notModifiedSince returns true if the request has a valid If-Modified-Since header and the resource was not updated after it.
+// The header has a precision of seconds, as does the time alert rules are updated at in the database, so both are compared in
+// whole seconds, and the resource is not modified if If-Modified-Since >= updated. A zero updated time is always modified.
+func notModifiedSince(c *contextmodel.ReqContext, updated time.Time) bool {
+	header := c.Req.Header.Get("If-Modified-Since")
+	if header == "" || updated.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(header)
+	if err != nil {
+		// a malformed header is ignored, as required by RFC 9110
+		return false
+	}
+	return since.Unix() >= updated.Unix()
+}
+
+// withLastModified sets the Last-Modified header of the response to the time in whole seconds, unless the time is zero.
+func withLastModified(resp *response.NormalResponse, updated time.Time) *response.NormalResponse {
+	if updated.IsZero() {
+		return resp
+	}
+	return resp.SetHeader("Last-Modified", time.Unix(updated.Unix(), 0).UTC().Format(http.TimeFormat))
+}
+
+// alertRuleETag returns the entity tag of the alert rule, which changes with its version.
+func alertRuleETag(rule alerting_models.AlertRule) string {
+	return fmt.Sprintf(`"%d"`, rule.Version)
+}
+
+// withAlertRuleETag sets the ETag header of the response to the entity tag of the alert rule.
+func withAlertRuleETag(resp *response.NormalResponse, rule alerting_models.AlertRule) *response.NormalResponse {
+	return resp.SetHeader("ETag", alertRuleETag(rule))
+}
+
+// matchesIfMatch returns true if the request has no If-Match header, or if one of its entity tags is the given one or "*".
+// Weak entity tags never match, as required by RFC 9110 for the strong comparison.
+func matchesIfMatch(c *contextmodel.ReqContext, etag string) bool {
+	header := c.Req.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAlertRulePatch sets the fields of the rule that are present in the patch.
+func applyAlertRulePatch(ar *definitions.ProvisionedAlertRule, patch definitions.PatchedAlertRule) {
+	if patch.FolderUID != nil {
+		ar.FolderUID = *patch.FolderUID
+	}
+	if patch.RuleGroup != nil {
+		ar.RuleGroup = *patch.RuleGroup
+	}
+	if patch.Title != nil {
+		ar.Title = *patch.Title
+	}
+	if patch.Condition != nil {
+		ar.Condition = *patch.Condition
+	}
+	if patch.Data != nil {
+		ar.Data = patch.Data
+	}
+	if patch.NoDataState != nil {
+		ar.NoDataState = *patch.NoDataState
+	}
+	if patch.ExecErrState != nil {
+		ar.ExecErrState = *patch.ExecErrState
+	}
+	if patch.For != nil {
+		ar.For = *patch.For
+	}
+	if patch.Annotations != nil {
+		ar.Annotations = patch.Annotations
+	}
+	if patch.Labels != nil {
+		ar.Labels = patch.Labels
+	}
+	if patch.IsPaused != nil {
+		ar.IsPaused = *patch.IsPaused
+	}
+	if patch.Schedule != nil {
+		ar.Schedule = *patch.Schedule
+	}
+	if patch.ScheduleTimezone != nil {
+		ar.ScheduleTimezone = *patch.ScheduleTimezone
+	}
+	if patch.InstanceLimit != nil {
+		ar.InstanceLimit = *patch.InstanceLimit
+	}
+	if patch.ContactPointUIDs != nil {
+		ar.ContactPointUIDs = patch.ContactPointUIDs
+	}
+	if patch.NotificationTitle != nil {
+		ar.NotificationTitle = *patch.NotificationTitle
+	}
+	if patch.NotificationMessage != nil {
+		ar.NotificationMessage = *patch.NotificationMessage
+	}
+	if patch.NotificationRepeatInterval != nil {
+		ar.NotificationRepeatInterval = *patch.NotificationRepeatInterval
+	}
+	if patch.SendResolved != nil {
+		ar.SendResolved = patch.SendResolved
+	}
+}
+
 func exportResponse(c *contextmodel.ReqContext, body any) response.Response {
 	var format = "yaml"
 
@@ -459,3 +916,17 @@ func exportResponse(c *contextmodel.ReqContext, body any) response.Response {
 	}
 	return r(http.StatusOK, body)
 }
+
+// authorizeDatasourceAccess checks that the user can query all data sources that the rules use. Returns an error that wraps
+// ErrDatasourceAuthorization and names the first data source the user cannot query.
+func (srv *ProvisioningSrv) authorizeDatasourceAccess(c *contextmodel.ReqContext, rules ...alerting_models.AlertRule) error {
+	hasAccess := func(evaluator accesscontrol.Evaluator) bool {
+		return accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqOrgAdmin, evaluator)
+	}
+	for _, rule := range rules {
+		if err := authorizeDatasourceAccessForQueries(rule.Data, hasAccess); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	queryAdded    = "added"
+	queryRemoved  = "removed"
+	queryModified = "modified"
+)
+
+// diffProvisionedAlertRules compares the submitted rule with the stored one. Both rules are compared as JSON values, so that
+// the differences are reported with the paths that the client uses. The read-only fields of the submitted rule are ignored.
+func diffProvisionedAlertRules(stored, submitted definitions.ProvisionedAlertRule) (definitions.AlertRuleDiff, error) {
+	result := definitions.AlertRuleDiff{
+		Fields:  []definitions.FieldDiff{},
+		Queries: []definitions.AlertQueryDiff{},
+	}
+
+	storedQueries, submittedQueries := stored.Data, submitted.Data
+	// the queries are compared separately by RefID, and the other fields are set by the server
+	stored.Data, submitted.Data = nil, nil
+	submitted.ID, submitted.UID, submitted.OrgID = stored.ID, stored.UID, stored.OrgID
+	submitted.Updated, submitted.Provenance = stored.Updated, stored.Provenance
+	if submitted.SendResolved == nil {
+		// the rule sends resolved notifications by default
+		submitted.SendResolved = util.Pointer(true)
+	}
+	fields, err := diffAsJSON(stored, submitted)
+	if err != nil {
+		return definitions.AlertRuleDiff{}, err
+	}
+	result.Fields = append(result.Fields, fields...)
+
+	storedByRefID := make(map[string]definitions.AlertQuery, len(storedQueries))
+	for _, q := range storedQueries {
+		storedByRefID[q.RefID] = q
+	}
+	submittedByRefID := make(map[string]definitions.AlertQuery, len(submittedQueries))
+	for _, q := range submittedQueries {
+		submittedByRefID[q.RefID] = q
+	}
+
+	for _, refID := range sortedKeys(storedByRefID, submittedByRefID) {
+		s, inStored := storedByRefID[refID]
+		n, inSubmitted := submittedByRefID[refID]
+		switch {
+		case !inSubmitted:
+			result.Queries = append(result.Queries, definitions.AlertQueryDiff{RefID: refID, Change: queryRemoved})
+		case !inStored:
+			result.Queries = append(result.Queries, definitions.AlertQueryDiff{RefID: refID, Change: queryAdded})
+		default:
+			d, err := diffAlertQueries(s, n)
+			if err != nil {
+				return definitions.AlertRuleDiff{}, fmt.Errorf("query %s: %w", refID, err)
+			}
+			if len(d.Fields) > 0 || len(d.Model) > 0 {
+				result.Queries = append(result.Queries, d)
+			}
+		}
+	}
+	return result, nil
+}
+
+// diffAlertQueries compares two queries with the same RefID. The model is parsed, so the order of its keys and whitespace
+// do not matter.
+func diffAlertQueries(stored, submitted definitions.AlertQuery) (definitions.AlertQueryDiff, error) {
+	storedModel, submittedModel := stored.Model, submitted.Model
+	stored.Model, submitted.Model = nil, nil
+	fields, err := diffAsJSON(stored, submitted)
+	if err != nil {
+		return definitions.AlertQueryDiff{}, err
+	}
+
+	var s, n interface{}
+	if len(storedModel) > 0 {
+		if err := json.Unmarshal(storedModel, &s); err != nil {
+			return definitions.AlertQueryDiff{}, fmt.Errorf("failed to parse the stored model: %w", err)
+		}
+	}
+	if len(submittedModel) > 0 {
+		if err := json.Unmarshal(submittedModel, &n); err != nil {
+			return definitions.AlertQueryDiff{}, fmt.Errorf("failed to parse the model: %w", err)
+		}
+	}
+
+	return definitions.AlertQueryDiff{
+		RefID:  stored.RefID,
+		Change: queryModified,
+		Fields: fields,
+		Model:  diffJSONValues("", s, n, nil),
+	}, nil
+}
+
+// diffAsJSON marshals both values to JSON and returns the differences between the results.
+func diffAsJSON(stored, submitted interface{}) ([]definitions.FieldDiff, error) {
+	var s, n interface{}
+	if err := remarshal(stored, &s); err != nil {
+		return nil, err
+	}
+	if err := remarshal(submitted, &n); err != nil {
+		return nil, err
+	}
+	return diffJSONValues("", s, n, nil), nil
+}
+
+func remarshal(in interface{}, out interface{}) error {
+	b, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, out)
+}
+
+// diffJSONValues appends the differences between two decoded JSON values to diffs. Objects are compared key by key, and
+// arrays of the same length element by element. Any other change is reported at the path of the value.
+func diffJSONValues(path string, stored, submitted interface{}, diffs []definitions.FieldDiff) []definitions.FieldDiff {
+	switch s := stored.(type) {
+	case map[string]interface{}:
+		if n, ok := submitted.(map[string]interface{}); ok {
+			for _, key := range sortedKeys(s, n) {
+				diffs = diffJSONValues(joinPath(path, key), s[key], n[key], diffs)
+			}
+			return diffs
+		}
+	case []interface{}:
+		if n, ok := submitted.([]interface{}); ok && len(s) == len(n) {
+			for i := range s {
+				diffs = diffJSONValues(fmt.Sprintf("%s[%d]", path, i), s[i], n[i], diffs)
+			}
+			return diffs
+		}
+	}
+	if reflect.DeepEqual(stored, submitted) {
+		return diffs
+	}
+	return append(diffs, definitions.FieldDiff{Path: path, Old: stored, New: submitted})
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sortedKeys returns the union of the keys of both maps in ascending order.
+func sortedKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
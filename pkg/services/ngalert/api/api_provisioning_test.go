@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,19 +18,27 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/quota"
+	"github.com/grafana/grafana/pkg/services/quota/quotaimpl"
 	"github.com/grafana/grafana/pkg/services/secrets"
 	secrets_fakes "github.com/grafana/grafana/pkg/services/secrets/fakes"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -175,6 +185,29 @@ func TestProvisioningApi(t *testing.T) {
 
 			require.Equal(t, 404, response.Status())
 		})
+
+		t.Run("are used by alert rules, DELETE returns 409", func(t *testing.T) {
+			env := createTestEnv(t)
+			configs := &provisioning.MockAMConfigStore{}
+			configs.EXPECT().GetsConfig(models.AlertConfiguration{
+				AlertmanagerConfiguration: strings.Replace(testConfig, `"grafana_managed_receiver_configs": [{`, `"grafana_managed_receiver_configs": [{
+				"uid": "slack-uid",
+				"name": "slack receiver",
+				"type": "slack",
+				"settings": {"url": "http://localhost"}
+			}, {`, 1),
+			})
+			configs.EXPECT().
+				UpdateAlertmanagerConfiguration(mock.Anything, mock.Anything).
+				Return(fmt.Errorf("%w: contact point slack-uid is used by alert rules rule", models.ErrContactPointInUse))
+			env.configs = configs
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rc := createTestRequestCtx()
+
+			response := sut.RouteDeleteContactPoint(&rc, "slack-uid")
+
+			require.Equal(t, 409, response.Status())
+		})
 	})
 
 	t.Run("templates", func(t *testing.T) {
@@ -231,6 +264,180 @@ func TestProvisioningApi(t *testing.T) {
 		})
 	})
 
+	t.Run("maintenance windows", func(t *testing.T) {
+		t.Run("exist, can be read, replaced and deleted", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			rc.SignedInUser.UserID = 42
+			mw := createTestMaintenanceWindow()
+
+			response := sut.RoutePostMaintenanceWindow(&rc, mw)
+			require.Equal(t, 201, response.Status())
+			created := deserializeMaintenanceWindow(t, response.Body())
+			require.NotZero(t, created.ID)
+			require.Equal(t, int64(42), created.CreatedBy)
+			id := strconv.FormatInt(created.ID, 10)
+
+			response = sut.RouteGetMaintenanceWindow(&rc, id)
+			require.Equal(t, 200, response.Status())
+			require.Equal(t, mw.Title, deserializeMaintenanceWindow(t, response.Body()).Title)
+
+			response = sut.RouteGetMaintenanceWindows(&rc)
+			require.Equal(t, 200, response.Status())
+			var windows definitions.MaintenanceWindows
+			require.NoError(t, json.Unmarshal(response.Body(), &windows))
+			require.Len(t, windows, 1)
+
+			mw.Title = "updated"
+			mw.Recurrence = "daily"
+			response = sut.RoutePutMaintenanceWindow(&rc, mw, id)
+			require.Equal(t, 200, response.Status())
+			updated := deserializeMaintenanceWindow(t, response.Body())
+			require.Equal(t, "updated", updated.Title)
+			require.Equal(t, "daily", updated.Recurrence)
+			require.Equal(t, int64(42), updated.CreatedBy)
+
+			response = sut.RouteDeleteMaintenanceWindow(&rc, id)
+			require.Equal(t, 204, response.Status())
+
+			response = sut.RouteGetMaintenanceWindow(&rc, id)
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("end in the past without recurrence, POST returns 400", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			mw := createTestMaintenanceWindow()
+			mw.Start = time.Now().Add(-2 * time.Hour)
+			mw.End = time.Now().Add(-time.Hour)
+
+			response := sut.RoutePostMaintenanceWindow(&rc, mw)
+
+			require.Equal(t, 400, response.Status())
+			require.Contains(t, string(response.Body()), "ends in the past")
+		})
+
+		t.Run("have an invalid ID, GET returns 400", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RouteGetMaintenanceWindow(&rc, "abc")
+
+			require.Equal(t, 400, response.Status())
+		})
+
+		t.Run("are missing, PUT returns 404", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RoutePutMaintenanceWindow(&rc, createTestMaintenanceWindow(), "1000")
+
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("exist in another org, GET returns 404", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			response := sut.RoutePostMaintenanceWindow(&rc, createTestMaintenanceWindow())
+			require.Equal(t, 201, response.Status())
+			id := strconv.FormatInt(deserializeMaintenanceWindow(t, response.Body()).ID, 10)
+
+			rc.SignedInUser.OrgID = 2
+			response = sut.RouteGetMaintenanceWindow(&rc, id)
+
+			require.Equal(t, 404, response.Status())
+		})
+	})
+
+	t.Run("silences", func(t *testing.T) {
+		t.Run("exist, can be read, replaced and deleted", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			rc.SignedInUser.UserID = 42
+			silence := createTestSilence()
+
+			response := sut.RoutePostSilence(&rc, silence)
+			require.Equal(t, 201, response.Status())
+			created := deserializeSilence(t, response.Body())
+			require.NotZero(t, created.ID)
+			require.Equal(t, int64(42), created.CreatedBy)
+			require.Equal(t, []definitions.SilenceMatcher{{Name: "cluster", Value: "staging", Type: "="}}, created.Matchers)
+			id := strconv.FormatInt(created.ID, 10)
+
+			response = sut.RouteGetSilence(&rc, id)
+			require.Equal(t, 200, response.Status())
+			require.Equal(t, silence.Comment, deserializeSilence(t, response.Body()).Comment)
+
+			response = sut.RouteGetSilences(&rc)
+			require.Equal(t, 200, response.Status())
+			var silences definitions.Silences
+			require.NoError(t, json.Unmarshal(response.Body(), &silences))
+			require.Len(t, silences, 1)
+
+			silence.Matchers = []definitions.SilenceMatcher{{Name: "cluster", Value: "dev", Type: "="}}
+			silence.Comment = "updated"
+			response = sut.RoutePutSilence(&rc, silence, id)
+			require.Equal(t, 200, response.Status())
+			updated := deserializeSilence(t, response.Body())
+			require.Equal(t, silence.Matchers, updated.Matchers)
+			require.Equal(t, "updated", updated.Comment)
+			require.Equal(t, int64(42), updated.CreatedBy)
+
+			response = sut.RouteDeleteSilence(&rc, id)
+			require.Equal(t, 204, response.Status())
+
+			response = sut.RouteGetSilence(&rc, id)
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("have a regex matcher, POST returns 400", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			silence := createTestSilence()
+			silence.Matchers = []definitions.SilenceMatcher{{Name: "cluster", Value: "stag.*", Type: "=~"}}
+
+			response := sut.RoutePostSilence(&rc, silence)
+
+			require.Equal(t, 400, response.Status())
+			require.Contains(t, string(response.Body()), "unknown type")
+		})
+
+		t.Run("end in the past, POST returns 400", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			silence := createTestSilence()
+			silence.StartsAt = time.Now().Add(-2 * time.Hour)
+			silence.EndsAt = time.Now().Add(-time.Hour)
+
+			response := sut.RoutePostSilence(&rc, silence)
+
+			require.Equal(t, 400, response.Status())
+			require.Contains(t, string(response.Body()), "ends in the past")
+		})
+
+		t.Run("are missing, PUT returns 404", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RoutePutSilence(&rc, createTestSilence(), "1000")
+
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("exist in another org, GET returns 404", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+			response := sut.RoutePostSilence(&rc, createTestSilence())
+			require.Equal(t, 201, response.Status())
+			id := strconv.FormatInt(deserializeSilence(t, response.Body()).ID, 10)
+
+			rc.SignedInUser.OrgID = 2
+			response = sut.RouteGetSilence(&rc, id)
+
+			require.Equal(t, 404, response.Status())
+		})
+	})
+
 	t.Run("alert rules", func(t *testing.T) {
 		t.Run("are invalid", func(t *testing.T) {
 			t.Run("POST returns 400 on wrong body params", func(t *testing.T) {
@@ -261,6 +468,69 @@ func TestProvisioningApi(t *testing.T) {
 			})
 		})
 
+		t.Run("use a data source the user cannot query", func(t *testing.T) {
+			createSut := func(t *testing.T) ProvisioningSrv {
+				env := createTestEnv(t)
+				env.ac = acmock.New().WithPermissions([]accesscontrol.Permission{
+					{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID("allowed-ds")},
+				})
+				return createProvisioningSrvSutFromEnv(t, &env)
+			}
+			withDatasources := func(rule definitions.ProvisionedAlertRule) definitions.ProvisionedAlertRule {
+				query := rule.Data[0]
+				query.DatasourceUID = "allowed-ds"
+				denied := query
+				denied.RefID = "B"
+				denied.DatasourceUID = "denied-ds"
+				rule.Data = []definitions.AlertQuery{query, denied}
+				return rule
+			}
+
+			t.Run("POST returns 403 naming the data source", func(t *testing.T) {
+				sut := createSut(t)
+				rc := createTestRequestCtx()
+
+				response := sut.RoutePostAlertRule(&rc, withDatasources(createTestAlertRule("rule", 1)))
+
+				require.Equal(t, http.StatusForbidden, response.Status())
+				require.Contains(t, string(response.Body()), "denied-ds")
+			})
+
+			t.Run("PUT returns 403 naming the data source", func(t *testing.T) {
+				env := createTestEnv(t)
+				sut := createProvisioningSrvSutFromEnv(t, &env)
+				rule := createTestAlertRule("rule", 1)
+				insertRule(t, sut, rule)
+				env.ac = acmock.New().WithPermissions([]accesscontrol.Permission{
+					{Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID("allowed-ds")},
+				})
+				sut = createProvisioningSrvSutFromEnv(t, &env)
+				rc := createTestRequestCtx()
+
+				response := sut.RoutePutAlertRule(&rc, withDatasources(rule), rule.UID)
+
+				require.Equal(t, http.StatusForbidden, response.Status())
+				require.Contains(t, string(response.Body()), "denied-ds")
+				stored, _, err := sut.alertRules.GetAlertRule(context.Background(), 1, rule.UID)
+				require.NoError(t, err)
+				require.Len(t, stored.Data, 1)
+			})
+
+			t.Run("PUT rule group returns 403 naming the data source", func(t *testing.T) {
+				sut := createSut(t)
+				rc := createTestRequestCtx()
+				group := definitions.AlertRuleGroup{
+					Interval: 60,
+					Rules:    []definitions.ProvisionedAlertRule{withDatasources(createTestAlertRule("rule", 1))},
+				}
+
+				response := sut.RoutePutAlertRuleGroup(&rc, group, "folder-uid", "my-cool-group")
+
+				require.Equal(t, http.StatusForbidden, response.Status())
+				require.Contains(t, string(response.Body()), "denied-ds")
+			})
+		})
+
 		t.Run("exist in non-default orgs", func(t *testing.T) {
 			t.Run("POST sets expected fields with no provenance", func(t *testing.T) {
 				sut := createProvisioningSrvSut(t)
@@ -297,6 +567,152 @@ func TestProvisioningApi(t *testing.T) {
 			})
 		})
 
+		t.Run("POST with idempotency key", func(t *testing.T) {
+			postWithKey := func(sut ProvisioningSrv, rule definitions.ProvisionedAlertRule, key string) response.Response {
+				rc := createTestRequestCtx()
+				rc.Req.Header.Set("Idempotency-Key", key)
+				return sut.RoutePostAlertRule(&rc, rule)
+			}
+			countRules := func(t *testing.T, sut ProvisioningSrv) int {
+				t.Helper()
+				rc := createTestRequestCtx()
+				response := sut.RouteGetAlertRules(&rc)
+				require.Equal(t, 200, response.Status())
+				var rules definitions.ProvisionedAlertRules
+				require.NoError(t, json.Unmarshal(response.Body(), &rules))
+				return len(rules)
+			}
+
+			t.Run("returns the created rule when the request is replayed", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rule := createTestAlertRule("rule", 1)
+				rule.UID = ""
+
+				first := postWithKey(sut, rule, "key")
+				require.Equal(t, 201, first.Status())
+				replayed := postWithKey(sut, rule, "key")
+				require.Equal(t, 201, replayed.Status())
+
+				require.Equal(t, deserializeRule(t, first.Body()).UID, deserializeRule(t, replayed.Body()).UID)
+				require.Equal(t, 1, countRules(t, sut))
+			})
+
+			t.Run("returns 409 when the key is reused with a different body", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rule := createTestAlertRule("rule", 1)
+				rule.UID = ""
+				require.Equal(t, 201, postWithKey(sut, rule, "key").Status())
+
+				rule.Title = "modified"
+				response := postWithKey(sut, rule, "key")
+
+				require.Equal(t, 409, response.Status())
+				require.Equal(t, 1, countRules(t, sut))
+			})
+
+			t.Run("returns 400 when the key is too long", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rule := createTestAlertRule("rule", 1)
+				rule.UID = ""
+
+				response := postWithKey(sut, rule, strings.Repeat("k", store.IdempotencyKeyMaxLength+1))
+
+				require.Equal(t, 400, response.Status())
+				require.Equal(t, 0, countRules(t, sut))
+				require.Equal(t, 201, postWithKey(sut, rule, strings.Repeat("k", store.IdempotencyKeyMaxLength)).Status())
+			})
+
+			t.Run("creates another rule when the key is expired", func(t *testing.T) {
+				env := createTestEnv(t)
+				sut := createProvisioningSrvSutFromEnv(t, &env)
+				rule := createTestAlertRule("rule", 1)
+				rule.UID = ""
+				first := postWithKey(sut, rule, "key")
+				require.Equal(t, 201, first.Status())
+				key, err := env.store.GetIdempotencyKey(context.Background(), 1, "key")
+				require.NoError(t, err)
+				key.Created = time.Now().Add(-models.IdempotencyKeyTTL - time.Minute)
+				require.NoError(t, env.store.SaveIdempotencyKey(context.Background(), key))
+
+				// titles are unique in a folder, and the body does not matter once the key is expired
+				rule.Title = "another rule"
+				second := postWithKey(sut, rule, "key")
+
+				require.Equal(t, 201, second.Status())
+				require.NotEqual(t, deserializeRule(t, first.Body()).UID, deserializeRule(t, second.Body()).UID)
+				require.Equal(t, 2, countRules(t, sut))
+			})
+		})
+
+		t.Run("are missing, POST diff returns 404", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RoutePostAlertRuleDiff(&rc, createTestAlertRule("rule", 1), "does not exist")
+
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("exist, POST diff", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rule := createTestAlertRule("rule", 1)
+			rule.For = model.Duration(time.Minute)
+			rule.Data[0].RelativeTimeRange.From = definitions.Duration(10 * time.Minute)
+			insertRule(t, sut, rule)
+			rc := createTestRequestCtx()
+
+			t.Run("returns an empty diff for the same rule with a reformatted model", func(t *testing.T) {
+				var m map[string]interface{}
+				require.NoError(t, json.Unmarshal(rule.Data[0].Model, &m))
+				submitted := rule
+				submitted.Data = []definitions.AlertQuery{rule.Data[0]}
+				// keys are sorted and whitespace is removed
+				reformatted, err := json.Marshal(m)
+				require.NoError(t, err)
+				submitted.Data[0].Model = reformatted
+
+				response := sut.RoutePostAlertRuleDiff(&rc, submitted, rule.UID)
+
+				require.Equal(t, 200, response.Status())
+				require.JSONEq(t, `{"fields": [], "queries": []}`, string(response.Body()))
+			})
+
+			t.Run("reports changed fields and queries", func(t *testing.T) {
+				submitted := rule
+				submitted.For = model.Duration(5 * time.Minute)
+				edited := rule.Data[0]
+				edited.Model = json.RawMessage(strings.Replace(testModel, `"gt"`, `"lt"`, 1))
+				added := rule.Data[0]
+				added.RefID = "B"
+				submitted.Data = []definitions.AlertQuery{edited, added}
+
+				response := sut.RoutePostAlertRuleDiff(&rc, submitted, rule.UID)
+
+				require.Equal(t, 200, response.Status())
+				require.JSONEq(t, `{
+					"fields": [{"path": "for", "old": "1m", "new": "5m"}],
+					"queries": [
+						{"refId": "A", "change": "modified", "model": [{"path": "conditions[0].evaluator.type", "old": "gt", "new": "lt"}]},
+						{"refId": "B", "change": "added"}
+					]
+				}`, string(response.Body()))
+
+				stored, _, err := sut.alertRules.GetAlertRule(context.Background(), 1, rule.UID)
+				require.NoError(t, err)
+				require.Equal(t, time.Duration(rule.For), stored.For, "rule should not be updated")
+			})
+
+			t.Run("returns 400 if a model is not valid JSON", func(t *testing.T) {
+				submitted := rule
+				submitted.Data = []definitions.AlertQuery{rule.Data[0]}
+				submitted.Data[0].Model = json.RawMessage(`{"expression":`)
+
+				response := sut.RoutePostAlertRuleDiff(&rc, submitted, rule.UID)
+
+				require.Equal(t, 400, response.Status())
+			})
+		})
+
 		t.Run("are missing, PUT returns 404", func(t *testing.T) {
 			sut := createProvisioningSrvSut(t)
 			rc := createTestRequestCtx()
@@ -307,6 +723,434 @@ func TestProvisioningApi(t *testing.T) {
 			require.Equal(t, 404, response.Status())
 		})
 
+		t.Run("are missing, GET returns 404", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rc := createTestRequestCtx()
+
+			response := sut.RouteRouteGetAlertRule(&rc, "does not exist")
+
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("exist, GET returns the rule", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rule := createTestAlertRule("rule", 1)
+			insertRule(t, sut, rule)
+			rc := createTestRequestCtx()
+
+			response := sut.RouteRouteGetAlertRule(&rc, rule.UID)
+
+			require.Equal(t, 200, response.Status())
+			got := deserializeRule(t, response.Body())
+			require.Equal(t, rule.UID, got.UID)
+			require.Equal(t, rule.Title, got.Title)
+			require.Equal(t, int64(1), got.OrgID)
+		})
+
+		t.Run("exist with the same UID, POST returns 409", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rule := createTestAlertRule("rule", 1)
+			insertRule(t, sut, rule)
+			rc := createTestRequestCtx()
+			rule.Title = "another rule"
+
+			response := sut.RoutePostAlertRule(&rc, rule)
+
+			require.Equal(t, 409, response.Status())
+		})
+
+		t.Run("exist with the same title in the folder, PUT returns 409", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			insertRule(t, sut, createTestAlertRule("rule", 1))
+			other := createTestAlertRule("other rule", 1)
+			insertRule(t, sut, other)
+			rc := createTestRequestCtx()
+			other.Title = "rule"
+
+			response := sut.RoutePutAlertRule(&rc, other, other.UID)
+
+			require.Equal(t, 409, response.Status())
+		})
+
+		t.Run("exist, DELETE deletes the rule", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rule := createTestAlertRule("rule", 1)
+			insertRule(t, sut, rule)
+			rc := createTestRequestCtx()
+
+			response := sut.RouteDeleteAlertRule(&rc, rule.UID)
+			require.Equal(t, 204, response.Status())
+
+			response = sut.RouteRouteGetAlertRule(&rc, rule.UID)
+			require.Equal(t, 404, response.Status())
+		})
+
+		t.Run("exist in another org", func(t *testing.T) {
+			sut := createProvisioningSrvSut(t)
+			rule := createTestAlertRule("rule", 1)
+			insertRuleInOrg(t, sut, rule, 3)
+			rc := createTestRequestCtx()
+
+			t.Run("GET returns 404", func(t *testing.T) {
+				response := sut.RouteRouteGetAlertRule(&rc, rule.UID)
+
+				require.Equal(t, 404, response.Status())
+			})
+
+			t.Run("GET all does not return the rule", func(t *testing.T) {
+				response := sut.RouteGetAlertRules(&rc)
+
+				require.Equal(t, 200, response.Status())
+				require.JSONEq(t, "[]", string(response.Body()))
+			})
+
+			t.Run("PUT returns 404", func(t *testing.T) {
+				response := sut.RoutePutAlertRule(&rc, rule, rule.UID)
+
+				require.Equal(t, 404, response.Status())
+			})
+
+			t.Run("DELETE does not delete the rule", func(t *testing.T) {
+				response := sut.RouteDeleteAlertRule(&rc, rule.UID)
+				require.Equal(t, 204, response.Status())
+
+				rc.OrgID = 3
+				response = sut.RouteRouteGetAlertRule(&rc, rule.UID)
+				require.Equal(t, 200, response.Status())
+			})
+		})
+
+		t.Run("GET summaries returns the rules without their queries", func(t *testing.T) {
+			env := createTestEnv(t)
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rule := createTestAlertRule("rule", 1)
+			insertRule(t, sut, rule)
+			paused := createTestAlertRule("paused", 1)
+			paused.IsPaused = true
+			insertRule(t, sut, paused)
+			insertRuleInOrg(t, sut, createTestAlertRule("other", 1), 3)
+			labels := models.InstanceLabels{"test": "a"}
+			_, hash, _ := labels.StringAndHash()
+			require.NoError(t, env.store.SaveAlertInstances(context.Background(), models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: 1, RuleUID: rule.UID, LabelsHash: hash},
+				Labels:           labels,
+				CurrentState:     models.InstanceStateFiring,
+			}))
+			rc := createTestRequestCtx()
+
+			response := sut.RouteGetAlertRuleSummaries(&rc)
+
+			require.Equal(t, 200, response.Status())
+			var summaries definitions.ProvisionedAlertRuleSummaries
+			require.NoError(t, json.Unmarshal(response.Body(), &summaries))
+			require.Len(t, summaries, 2)
+			require.Equal(t, rule.UID, summaries[0].UID)
+			require.Equal(t, "rule", summaries[0].Title)
+			require.Equal(t, rule.FolderUID, summaries[0].FolderUID)
+			require.Equal(t, rule.RuleGroup, summaries[0].RuleGroup)
+			require.Equal(t, int64(60), summaries[0].Interval)
+			require.False(t, summaries[0].IsPaused)
+			require.False(t, summaries[0].Updated.IsZero())
+			require.Equal(t, &definitions.InstanceStateSummary{WorstState: "Alerting", Counts: map[string]int64{"Alerting": 1}}, summaries[0].StateSummary)
+			require.Equal(t, paused.UID, summaries[1].UID)
+			require.True(t, summaries[1].IsPaused)
+			require.Equal(t, &definitions.InstanceStateSummary{WorstState: "Normal", Counts: map[string]int64{}}, summaries[1].StateSummary)
+			require.NotContains(t, string(response.Body()), `"data"`)
+		})
+
+		t.Run("conditional GET", func(t *testing.T) {
+			env := createTestEnv(t)
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rule := createTestAlertRule("rule", 1)
+			insertRule(t, sut, rule)
+			// rules are updated at second precision, so the rule is moved to the past to be able to update it later
+			updated := time.Now().Add(-time.Hour).Truncate(time.Second)
+			err := env.store.SQLStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+				_, err := sess.Exec("UPDATE alert_rule SET updated = ? WHERE uid = ?", updated, rule.UID)
+				return err
+			})
+			require.NoError(t, err)
+
+			get := func(t *testing.T, ifModifiedSince string) []response.Response {
+				rc := createTestRequestCtx()
+				if ifModifiedSince != "" {
+					rc.Req.Header.Set("If-Modified-Since", ifModifiedSince)
+				}
+				return []response.Response{sut.RouteRouteGetAlertRule(&rc, rule.UID), sut.RouteGetAlertRules(&rc)}
+			}
+			lastModified := updated.UTC().Format(http.TimeFormat)
+
+			t.Run("GET returns Last-Modified", func(t *testing.T) {
+				for _, resp := range get(t, "") {
+					require.Equal(t, 200, resp.Status())
+					require.Equal(t, lastModified, resp.(*response.NormalResponse).Header().Get("Last-Modified"))
+				}
+			})
+
+			t.Run("GET returns 304 if not modified", func(t *testing.T) {
+				for _, since := range []string{lastModified, updated.Add(time.Minute).UTC().Format(http.TimeFormat)} {
+					for _, resp := range get(t, since) {
+						require.Equal(t, 304, resp.Status())
+						require.Empty(t, resp.Body())
+					}
+				}
+			})
+
+			t.Run("GET ignores malformed If-Modified-Since", func(t *testing.T) {
+				for _, resp := range get(t, "yesterday") {
+					require.Equal(t, 200, resp.Status())
+					require.NotEmpty(t, resp.Body())
+				}
+			})
+
+			t.Run("GET returns 200 after update", func(t *testing.T) {
+				rc := createTestRequestCtx()
+				rule.Title = "updated rule"
+				resp := sut.RoutePutAlertRule(&rc, rule, rule.UID)
+				require.Equal(t, 200, resp.Status())
+
+				for _, resp := range get(t, lastModified) {
+					require.Equal(t, 200, resp.Status())
+					require.NotEqual(t, lastModified, resp.(*response.NormalResponse).Header().Get("Last-Modified"))
+				}
+				got := deserializeRule(t, get(t, lastModified)[0].Body())
+				require.Equal(t, "updated rule", got.Title)
+			})
+		})
+
+		t.Run("PATCH", func(t *testing.T) {
+			etag := func(resp response.Response) string {
+				return resp.(*response.NormalResponse).Header().Get("ETag")
+			}
+			// durations are stored in seconds, so they are set in whole seconds to be the same after a round trip
+			createRule := func() definitions.ProvisionedAlertRule {
+				rule := createTestAlertRule("rule", 1)
+				rule.For = model.Duration(time.Minute)
+				rule.Data[0].RelativeTimeRange.From = definitions.Duration(time.Minute)
+				return rule
+			}
+
+			t.Run("returns 404 if the rule is missing", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rc := createTestRequestCtx()
+
+				response := sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{Title: util.Pointer("title")}, "does not exist")
+
+				require.Equal(t, 404, response.Status())
+			})
+
+			t.Run("updates only the title", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rule := createRule()
+				rule.Labels = map[string]string{"team": "sre"}
+				insertRule(t, sut, rule)
+				rc := createTestRequestCtx()
+
+				response := sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{Title: util.Pointer("renamed rule")}, rule.UID)
+
+				require.Equal(t, 200, response.Status())
+				got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, "renamed rule", got.Title)
+				require.Equal(t, rule.For, got.For)
+				require.Equal(t, rule.Condition, got.Condition)
+				require.Equal(t, rule.Labels, got.Labels)
+				require.Len(t, got.Data, 1)
+			})
+
+			t.Run("updates only the pending period", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rule := createRule()
+				insertRule(t, sut, rule)
+				rc := createTestRequestCtx()
+
+				response := sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{For: util.Pointer(model.Duration(5 * time.Minute))}, rule.UID)
+
+				require.Equal(t, 200, response.Status())
+				got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, model.Duration(5*time.Minute), got.For)
+				require.Equal(t, rule.Title, got.Title)
+				require.Equal(t, rule.NoDataState, got.NoDataState)
+			})
+
+			t.Run("does not change the rule if the body is empty", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rule := createRule()
+				insertRule(t, sut, rule)
+				rc := createTestRequestCtx()
+				before := sut.RouteRouteGetAlertRule(&rc, rule.UID)
+
+				response := sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{}, rule.UID)
+
+				require.Equal(t, 200, response.Status())
+				require.Equal(t, etag(before), etag(response))
+				after := sut.RouteRouteGetAlertRule(&rc, rule.UID)
+				require.Equal(t, etag(before), etag(after))
+				require.JSONEq(t, string(before.Body()), string(after.Body()))
+			})
+
+			t.Run("replaces the queries and the condition", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rule := createRule()
+				insertRule(t, sut, rule)
+				rc := createTestRequestCtx()
+				query := rule.Data[0]
+				query.RefID = "B"
+
+				response := sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{
+					Condition: util.Pointer("B"),
+					Data:      []definitions.AlertQuery{query},
+				}, rule.UID)
+
+				require.Equal(t, 200, response.Status())
+				got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, "B", got.Condition)
+				require.Len(t, got.Data, 1)
+				require.Equal(t, "B", got.Data[0].RefID)
+			})
+
+			t.Run("with If-Match", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rule := createRule()
+				insertRule(t, sut, rule)
+				rc := createTestRequestCtx()
+				current := etag(sut.RouteRouteGetAlertRule(&rc, rule.UID))
+				require.NotEmpty(t, current)
+
+				rc.Req.Header.Set("If-Match", current)
+				response := sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{Title: util.Pointer("first")}, rule.UID)
+				require.Equal(t, 200, response.Status())
+				require.NotEqual(t, current, etag(response))
+				require.Equal(t, etag(response), etag(sut.RouteRouteGetAlertRule(&rc, rule.UID)))
+
+				t.Run("returns 412 if the rule was updated", func(t *testing.T) {
+					response := sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{Title: util.Pointer("second")}, rule.UID)
+
+					require.Equal(t, 412, response.Status())
+					got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+					require.Equal(t, "first", got.Title)
+				})
+			})
+		})
+
+		t.Run("with contact points", func(t *testing.T) {
+			env := createTestEnv(t)
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			require.NoError(t, env.store.SaveAlertmanagerConfiguration(context.Background(), &models.SaveAlertmanagerConfigurationCmd{
+				AlertmanagerConfiguration: testConfig,
+				OrgID:                     1,
+			}))
+			rc := createTestRequestCtx()
+
+			t.Run("POST returns 400 if a contact point does not exist", func(t *testing.T) {
+				rule := createTestAlertRule("missing contact point", 1)
+				rule.ContactPointUIDs = []string{"missing"}
+
+				response := sut.RoutePostAlertRule(&rc, rule)
+
+				require.Equal(t, 400, response.Status())
+				require.Contains(t, string(response.Body()), "contact point missing does not exist")
+			})
+
+			t.Run("POST saves them and GET returns them", func(t *testing.T) {
+				rule := createTestAlertRule("rule", 1)
+				rule.ContactPointUIDs = []string{"email-uid"}
+
+				response := sut.RoutePostAlertRule(&rc, rule)
+				require.Equal(t, 201, response.Status())
+
+				got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, []string{"email-uid"}, got.ContactPointUIDs)
+			})
+		})
+
+		t.Run("with notification templates", func(t *testing.T) {
+			env := createTestEnv(t)
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rc := createTestRequestCtx()
+
+			t.Run("POST returns 400 if a template cannot be parsed", func(t *testing.T) {
+				rule := createTestAlertRule("invalid template", 1)
+				rule.NotificationTitle = "High latency on {{ $labels.instance"
+
+				response := sut.RoutePostAlertRule(&rc, rule)
+
+				require.Equal(t, 400, response.Status())
+				require.Contains(t, string(response.Body()), "notification title")
+			})
+
+			t.Run("POST saves them and GET returns them", func(t *testing.T) {
+				rule := createTestAlertRule("rule", 1)
+				rule.NotificationTitle = "High latency on {{ $labels.instance }}"
+				rule.NotificationMessage = "Latency is {{ $values.B }}ms"
+
+				response := sut.RoutePostAlertRule(&rc, rule)
+				require.Equal(t, 201, response.Status())
+
+				got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, rule.NotificationTitle, got.NotificationTitle)
+				require.Equal(t, rule.NotificationMessage, got.NotificationMessage)
+			})
+		})
+
+		t.Run("with notification repeat interval", func(t *testing.T) {
+			env := createTestEnv(t)
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rc := createTestRequestCtx()
+
+			t.Run("POST returns 400 if it is negative", func(t *testing.T) {
+				rule := createTestAlertRule("negative repeat", 1)
+				rule.NotificationRepeatInterval = model.Duration(-time.Hour)
+
+				response := sut.RoutePostAlertRule(&rc, rule)
+
+				require.Equal(t, 400, response.Status())
+				require.Contains(t, string(response.Body()), "notification repeat interval")
+			})
+
+			t.Run("POST saves it and GET returns it", func(t *testing.T) {
+				rule := createTestAlertRule("rule", 1)
+				rule.NotificationRepeatInterval = model.Duration(4 * time.Hour)
+
+				response := sut.RoutePostAlertRule(&rc, rule)
+				require.Equal(t, 201, response.Status())
+
+				got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, rule.NotificationRepeatInterval, got.NotificationRepeatInterval)
+			})
+		})
+
+		t.Run("with send resolved", func(t *testing.T) {
+			env := createTestEnv(t)
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rc := createTestRequestCtx()
+
+			t.Run("POST defaults it to true", func(t *testing.T) {
+				rule := createTestAlertRule("default", 1)
+
+				response := sut.RoutePostAlertRule(&rc, rule)
+				require.Equal(t, 201, response.Status())
+
+				got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, util.Pointer(true), got.SendResolved)
+			})
+
+			t.Run("POST saves it and PATCH updates it", func(t *testing.T) {
+				rule := createTestAlertRule("rule", 1)
+				rule.Data[0].RelativeTimeRange.From = definitions.Duration(time.Minute)
+				rule.SendResolved = util.Pointer(false)
+
+				response := sut.RoutePostAlertRule(&rc, rule)
+				require.Equal(t, 201, response.Status())
+				got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, util.Pointer(false), got.SendResolved)
+
+				response = sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{SendResolved: util.Pointer(true)}, rule.UID)
+				require.Equal(t, 200, response.Status())
+				got = deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+				require.Equal(t, util.Pointer(true), got.SendResolved)
+			})
+		})
+
 		t.Run("have reached the rule quota, POST returns 403", func(t *testing.T) {
 			env := createTestEnv(t)
 			quotas := provisioning.MockQuotaChecker{}
@@ -320,6 +1164,47 @@ func TestProvisioningApi(t *testing.T) {
 
 			require.Equal(t, 403, response.Status())
 		})
+
+		t.Run("have reached a quota of one rule, POST returns 403 with the limit and the usage", func(t *testing.T) {
+			env := createTestEnv(t)
+			env.quotas = createTestQuotaService(t, env.store, 1)
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rc := createTestRequestCtx()
+
+			response := sut.RoutePostAlertRule(&rc, createTestAlertRule("rule", 1))
+			require.Equal(t, 201, response.Status())
+
+			response = sut.RoutePostAlertRule(&rc, createTestAlertRule("another rule", 1))
+
+			require.Equal(t, 403, response.Status())
+			var body definitions.QuotaReachedError
+			require.NoError(t, json.Unmarshal(response.Body(), &body))
+			require.Equal(t, int64(1), body.Limit)
+			require.Equal(t, int64(1), body.Usage)
+			require.Equal(t, "another rule", body.Rule)
+			require.Contains(t, body.Message, "quota has been exceeded")
+		})
+
+		t.Run("have reached a quota of one rule, PUT rule group returns 403 naming the rule", func(t *testing.T) {
+			env := createTestEnv(t)
+			env.quotas = createTestQuotaService(t, env.store, 1)
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			rc := createTestRequestCtx()
+			first, second := createTestAlertRule("first", 1), createTestAlertRule("second", 1)
+			first.UID, second.UID = "", ""
+			group := definitions.AlertRuleGroup{
+				Interval: 60,
+				Rules:    []definitions.ProvisionedAlertRule{first, second},
+			}
+
+			response := sut.RoutePutAlertRuleGroup(&rc, group, "folder-uid", "my-cool-group")
+
+			require.Equal(t, 403, response.Status())
+			var body definitions.QuotaReachedError
+			require.NoError(t, json.Unmarshal(response.Body(), &body))
+			require.Equal(t, int64(1), body.Limit)
+			require.Equal(t, "second", body.Rule)
+		})
 	})
 
 	t.Run("alert rule groups", func(t *testing.T) {
@@ -805,7 +1690,93 @@ func TestProvisioningApi(t *testing.T) {
 	})
 }
 
+func TestProvisioningApi_queryModelRoundTrip(t *testing.T) {
+	// the model has keys out of order, a number that float64 cannot represent, deeply nested values and keys that are
+	// specific to the data source. It is compact, as the JSON encoder compacts raw messages.
+	const submittedModel = `{"refId":"A","x-custom":{"nested":{"list":[1,{"k":null},[],"text"],"empty":{}},"flag":true},` +
+		`"bigNumber":9007199254740993,"ratio":0.30000000000000004441,"expr":"rate(requests_total[5m])","unicode":"héllo ✓",` +
+		`"datasource":{"type":"custom-datasource","uid":"custom"}}`
+	setup := func(t *testing.T) (ProvisioningSrv, definitions.ProvisionedAlertRule) {
+		t.Helper()
+		sut := createProvisioningSrvSut(t)
+		rule := createTestAlertRule("rule", 1)
+		// the relative time range is stored in seconds, so it is set in whole seconds to be valid after a round trip
+		rule.Data[0].RelativeTimeRange.From = definitions.Duration(time.Minute)
+		rule.Data[0].Model = json.RawMessage(submittedModel)
+		insertRule(t, sut, rule)
+		return sut, rule
+	}
+	requireModelUnchanged := func(t *testing.T, sut ProvisioningSrv, uid string) {
+		t.Helper()
+		rc := createTestRequestCtx()
+		got := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, uid).Body())
+		require.Len(t, got.Data, 1)
+		require.Equal(t, submittedModel, string(got.Data[0].Model))
+	}
+
+	t.Run("returns the model as it was submitted", func(t *testing.T) {
+		sut, rule := setup(t)
+		requireModelUnchanged(t, sut, rule.UID)
+	})
+
+	t.Run("PATCH of another field does not change the model", func(t *testing.T) {
+		sut, rule := setup(t)
+		rc := createTestRequestCtx()
+
+		response := sut.RoutePatchAlertRule(&rc, definitions.PatchedAlertRule{Title: util.Pointer("renamed rule")}, rule.UID)
+
+		require.Equal(t, 200, response.Status())
+		requireModelUnchanged(t, sut, rule.UID)
+	})
+
+	t.Run("PUT of the fetched rule with another field changed does not change the model", func(t *testing.T) {
+		sut, rule := setup(t)
+		rc := createTestRequestCtx()
+		fetched := deserializeRule(t, sut.RouteRouteGetAlertRule(&rc, rule.UID).Body())
+		fetched.Labels = map[string]string{"team": "sre"}
+
+		response := sut.RoutePutAlertRule(&rc, fetched, rule.UID)
+
+		require.Equal(t, 200, response.Status())
+		requireModelUnchanged(t, sut, rule.UID)
+	})
+}
+
 // testEnvironment binds together common dependencies for testing alerting APIs.
+func TestIntegrationProvisioningApi_retriesWhileDatabaseIsLocked(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	env := createTestEnv(t)
+	if env.store.SQLStore.GetDialect().DriverName() != migrator.SQLite {
+		t.Skip("the transactions are only retried on SQLite")
+	}
+	env.xact = &env.store
+	sut := createProvisioningSrvSutFromEnv(t, &env)
+
+	// another connection keeps a write transaction open for longer than the SQL store retries its transactions by
+	// itself, so that the transaction of the request fails with "database table is locked" until it is retried.
+	locked := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- env.store.SQLStore.WithTransactionalDbSession(context.Background(), func(sess *db.Session) error {
+			if _, err := sess.Exec("UPDATE alert_rule SET version = version"); err != nil {
+				return err
+			}
+			close(locked)
+			time.Sleep(300 * time.Millisecond)
+			return nil
+		})
+	}()
+	<-locked
+
+	rc := createTestRequestCtx()
+	response := sut.RoutePostAlertRule(&rc, createTestAlertRule("rule", 1))
+
+	require.Equal(t, 201, response.Status(), string(response.Body()))
+	require.NoError(t, <-done)
+}
+
 type testEnvironment struct {
 	secrets          secrets.Service
 	log              log.Logger
@@ -815,6 +1786,7 @@ type testEnvironment struct {
 	xact             provisioning.TransactionManager
 	quotas           provisioning.QuotaChecker
 	prov             provisioning.ProvisioningStore
+	ac               *acmock.Mock
 }
 
 func createTestEnv(t *testing.T) testEnvironment {
@@ -833,6 +1805,7 @@ func createTestEnv(t *testing.T) testEnvironment {
 		Cfg: setting.UnifiedAlertingSettings{
 			BaseInterval: time.Second * 10,
 		},
+		Logger: log,
 	}
 	quotas := &provisioning.MockQuotaChecker{}
 	quotas.EXPECT().LimitOK()
@@ -864,9 +1837,36 @@ func createTestEnv(t *testing.T) testEnvironment {
 		xact:             xact,
 		prov:             prov,
 		quotas:           quotas,
+		ac: acmock.New().WithPermissions([]accesscontrol.Permission{
+			{Action: datasources.ActionQuery, Scope: datasources.ScopeAll},
+		}),
 	}
 }
 
+// createTestQuotaService returns a quota service that counts the alert rules of the store, and allows orgLimit rules per
+// organization.
+func createTestQuotaService(t *testing.T, ruleStore store.DBstore, orgLimit int64) quota.Service {
+	t.Helper()
+
+	cfg := setting.NewCfg()
+	cfg.Quota.Enabled = true
+	quotas := quotaimpl.ProvideService(ruleStore.SQLStore, cfg)
+	orgTag, err := quota.NewTag(models.QuotaTargetSrv, models.QuotaTarget, quota.OrgScope)
+	require.NoError(t, err)
+	globalTag, err := quota.NewTag(models.QuotaTargetSrv, models.QuotaTarget, quota.GlobalScope)
+	require.NoError(t, err)
+	limits := &quota.Map{}
+	limits.Set(orgTag, orgLimit)
+	limits.Set(globalTag, -1)
+	api := &API{RuleStore: ruleStore}
+	require.NoError(t, quotas.RegisterQuotaReporter(&quota.NewUsageReporter{
+		TargetSrv:     models.QuotaTargetSrv,
+		DefaultLimits: limits,
+		Reporter:      api.Usage,
+	}))
+	return quotas
+}
+
 func createProvisioningSrvSut(t *testing.T) ProvisioningSrv {
 	t.Helper()
 
@@ -879,14 +1879,48 @@ func createProvisioningSrvSutFromEnv(t *testing.T, env *testEnvironment) Provisi
 
 	return ProvisioningSrv{
 		log:                 env.log,
+		ac:                  env.ac,
 		policies:            newFakeNotificationPolicyService(),
 		contactPointService: provisioning.NewContactPointService(env.configs, env.secrets, env.prov, env.xact, env.log),
 		templates:           provisioning.NewTemplateService(env.configs, env.prov, env.xact, env.log),
 		muteTimings:         provisioning.NewMuteTimingService(env.configs, env.prov, env.xact, env.log),
+		maintenanceWindows:  provisioning.NewMaintenanceWindowService(env.store, env.log),
+		silences:            provisioning.NewSilenceService(env.store, env.log),
 		alertRules:          provisioning.NewAlertRuleService(env.store, env.prov, env.dashboardService, env.quotas, env.xact, 60, 10, env.log),
 	}
 }
 
+func createTestMaintenanceWindow() definitions.MaintenanceWindow {
+	return definitions.MaintenanceWindow{
+		Title: "maintenance",
+		Start: time.Now().Add(time.Hour).Truncate(time.Second),
+		End:   time.Now().Add(2 * time.Hour).Truncate(time.Second),
+	}
+}
+
+func deserializeMaintenanceWindow(t *testing.T, body []byte) definitions.MaintenanceWindow {
+	t.Helper()
+	var mw definitions.MaintenanceWindow
+	require.NoError(t, json.Unmarshal(body, &mw))
+	return mw
+}
+
+func createTestSilence() definitions.Silence {
+	return definitions.Silence{
+		Matchers: []definitions.SilenceMatcher{{Name: "cluster", Value: "staging"}},
+		StartsAt: time.Now().Truncate(time.Second),
+		EndsAt:   time.Now().Add(4 * time.Hour).Truncate(time.Second),
+		Comment:  "staging upgrade",
+	}
+}
+
+func deserializeSilence(t *testing.T, body []byte) definitions.Silence {
+	t.Helper()
+	var s definitions.Silence
+	require.NoError(t, json.Unmarshal(body, &s))
+	return s
+}
+
 func createTestRequestCtx() contextmodel.ReqContext {
 	return contextmodel.ReqContext{
 		Context: &web.Context{
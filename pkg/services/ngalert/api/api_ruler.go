@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/grafana/pkg/api/apierrors"
@@ -16,10 +21,12 @@ import (
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/setting"
@@ -31,6 +38,12 @@ type ConditionValidator interface {
 	Validate(ctx eval.EvaluationContext, condition ngmodels.Condition) error
 }
 
+// TestNotificationSender sends test notifications to contact points.
+type TestNotificationSender interface {
+	// SendTestNotification sends the notification to the contact point once and returns the error of the delivery.
+	SendTestNotification(ctx context.Context, orgID int64, contactPointUID string, n ngmodels.AlertingNotification) error
+}
+
 type RulerSrv struct {
 	xactManager        provisioning.TransactionManager
 	provenanceStore    provisioning.ProvisioningStore
@@ -40,10 +53,28 @@ type RulerSrv struct {
 	cfg                *setting.UnifiedAlertingSettings
 	ac                 accesscontrol.AccessControl
 	conditionValidator ConditionValidator
+	scheduler          RuleScheduler
+	manager            state.AlertInstanceManager
+	evaluator          eval.EvaluatorFactory
+	notifier           TestNotificationSender
+	appURL             *url.URL
+	datasourceCache    datasources.CacheService
+	clock              clock.Clock
 }
 
 var (
-	errProvisionedResource = errors.New("request affects resources created via provisioning API")
+	errProvisionedResource = errors.New("request affects resources created via provisioning, which must be changed by the provisioning that created them")
+)
+
+const (
+	defaultRuleInstancesLimit = 100
+	maxRuleInstancesLimit     = 1000
+	defaultRuleAuditLimit     = 100
+	maxRuleAuditLimit         = 1000
+	// maxEvaluationFramePoints is the maximum number of points of a data frame in the response of RouteGetAlertRuleEvaluation.
+	maxEvaluationFramePoints = 1000
+	// maxBulkAlertRules is the maximum number of rules that RouteBulkAlertRules changes at once.
+	maxBulkAlertRules = 100
 )
 
 // RouteDeleteAlertRules deletes all alert rules the user is authorized to access in the given namespace
@@ -70,7 +101,7 @@ func (srv RulerSrv) RouteDeleteAlertRules(c *contextmodel.ReqContext, namespaceT
 		return accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqOrgAdminOrEditor, evaluator)
 	}
 
-	provenances, err := srv.provenanceStore.GetProvenances(c.Req.Context(), c.SignedInUser.OrgID, (&ngmodels.AlertRule{}).ResourceType())
+	provenances, err := srv.getProvenancesToCheck(c)
 	if err != nil {
 		return ErrResp(http.StatusInternalServerError, err, "failed to fetch provenances of alert rules")
 	}
@@ -146,6 +177,569 @@ func (srv RulerSrv) RouteDeleteAlertRules(c *contextmodel.ReqContext, namespaceT
 	return response.JSON(http.StatusAccepted, util.DynMap{"message": "rules deleted"})
 }
 
+// RouteEvaluateAlertRule requests the scheduler to evaluate the rule with the given UID as soon as possible, outside of its regular schedule.
+// Returns http.StatusNotFound if the rule does not exist or is not scheduled yet, and http.StatusConflict if the rule is paused
+// or is evaluated by another instance of a highly available setup.
+func (srv RulerSrv) RouteEvaluateAlertRule(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	rule, err := srv.store.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: ruleUID, OrgID: c.SignedInUser.OrgID})
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+
+	hasAccess := hasAccessInFolder(srv.ac, c, accesscontrol.ReqOrgAdminOrEditor, rule.NamespaceUID, dashboards.PERMISSION_EDIT)
+	if !hasAccess(accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleUpdate, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID))) ||
+		!authorizeDatasourceAccessForRule(rule, hasAccess) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to evaluate the rule", ErrAuthorization), "")
+	}
+
+	if err := srv.scheduler.EvaluateNow(rule.GetKey()); err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "alert rule is not scheduled for evaluation yet")
+		}
+		if errors.Is(err, ngmodels.ErrAlertRuleIsPaused) || errors.Is(err, ngmodels.ErrEvaluationPaused) {
+			return ErrResp(http.StatusConflict, err, "")
+		}
+		if errors.Is(err, ngmodels.ErrAlertRuleNotOwned) {
+			return ErrResp(http.StatusConflict, err, "the evaluation must be requested from the instance that evaluates the rule")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to request evaluation of the alert rule")
+	}
+	return response.JSON(http.StatusAccepted, util.DynMap{"message": "evaluation of the rule is requested"})
+}
+
+// RouteGetAlertRuleEvaluation evaluates the condition of the rule with the given UID like the scheduler does, and returns the
+// results without changing the state of the instances of the rule. The rule is evaluated at the time in the now query parameter,
+// in RFC3339 format, or at the current time if it is not set. If the includeFrames query parameter is true, the response also
+// contains the data frames of the queries and expressions of the rule, downsampled to maxEvaluationFramePoints points.
+// The evaluation is bounded by the evaluation timeout of the scheduler, and requires permissions to read the rule and to
+// query all of its data sources. Returns http.StatusNotFound if the rule does not exist in the user's organization.
+func (srv RulerSrv) RouteGetAlertRuleEvaluation(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	rule, err := srv.store.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: ruleUID, OrgID: c.SignedInUser.OrgID})
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+
+	hasAccess := hasAccessInFolder(srv.ac, c, accesscontrol.ReqViewer, rule.NamespaceUID, dashboards.PERMISSION_VIEW)
+	if !hasAccess(accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleRead, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID))) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to evaluate the rule", ErrAuthorization), "")
+	}
+	if err := authorizeDatasourceAccessForQueries(rule.Data, hasAccess); err != nil {
+		return ErrResp(http.StatusForbidden, err, "")
+	}
+
+	now := srv.clock.Now()
+	if param := c.Query("now"); param != "" {
+		now, err = time.Parse(time.RFC3339, param)
+		if err != nil {
+			return ErrResp(http.StatusBadRequest, err, "the now parameter must be a time in RFC3339 format")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Req.Context(), srv.cfg.EvaluationTimeout)
+	defer cancel()
+	ruleEval, err := srv.evaluator.Create(eval.Context(ctx, c.SignedInUser), rule.GetEvalCondition())
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to build the evaluator of the rule")
+	}
+
+	if !c.QueryBool("includeFrames") {
+		results, err := ruleEval.Evaluate(ctx, now)
+		if err != nil {
+			return evaluationErrorResponse(err, "Failed to evaluate the rule")
+		}
+		return response.JSON(http.StatusOK, toRuleEvaluationResponse(now, results))
+	}
+
+	raw, err := ruleEval.EvaluateRaw(ctx, now)
+	if err != nil {
+		return evaluationErrorResponse(err, "Failed to evaluate the rule")
+	}
+	resp := toRuleEvaluationResponse(now, eval.EvaluateResponse(rule.GetEvalCondition(), raw, now))
+	resp.Frames = make(map[string]data.Frames, len(raw.Responses))
+	for refID, res := range raw.Responses {
+		frames := make(data.Frames, 0, len(res.Frames))
+		for _, frame := range res.Frames {
+			downsampled, step := downsampleFrame(frame, maxEvaluationFramePoints)
+			if step > 1 {
+				resp.Warnings = append(resp.Warnings, fmt.Sprintf("frame %q of %s has %d points, only every %d point is included", frame.Name, refID, frame.Rows(), step))
+			}
+			frames = append(frames, downsampled)
+		}
+		resp.Frames[refID] = frames
+	}
+	sort.Strings(resp.Warnings)
+	return response.JSON(http.StatusOK, resp)
+}
+
+// downsampleFrame returns a copy of the frame that contains every step-th row, where step is the smallest number that makes
+// the copy contain at most maxPoints rows. Returns the frame itself and step 1 if it has no more than maxPoints rows.
+func downsampleFrame(frame *data.Frame, maxPoints int) (*data.Frame, int) {
+	rows := frame.Rows()
+	if rows <= maxPoints {
+		return frame, 1
+	}
+	step := (rows + maxPoints - 1) / maxPoints
+	result := frame.EmptyCopy()
+	for i := 0; i < rows; i += step {
+		result.AppendRow(frame.RowCopy(i)...)
+	}
+	return result, step
+}
+
+// RoutePauseAlertRule pauses evaluation of the rule with the given UID. See setAlertRulePaused.
+func (srv RulerSrv) RoutePauseAlertRule(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	return srv.setAlertRulePaused(c, ruleUID, true)
+}
+
+// RouteUnpauseAlertRule resumes evaluation of the rule with the given UID. See setAlertRulePaused.
+func (srv RulerSrv) RouteUnpauseAlertRule(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	return srv.setAlertRulePaused(c, ruleUID, false)
+}
+
+// setAlertRulePaused sets the paused flag of the rule with the given UID and returns the rule. It does not update the rule if the flag
+// already has the requested value. Returns http.StatusNotFound if the rule does not exist in the user's organization, and
+// http.StatusBadRequest if the rule was created via the provisioning API.
+func (srv RulerSrv) setAlertRulePaused(c *contextmodel.ReqContext, ruleUID string, paused bool) response.Response {
+	rule, err := srv.store.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: ruleUID, OrgID: c.SignedInUser.OrgID})
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+
+	hasAccess := hasAccessInFolder(srv.ac, c, accesscontrol.ReqOrgAdminOrEditor, rule.NamespaceUID, dashboards.PERMISSION_EDIT)
+	if !hasAccess(accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleUpdate, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID))) ||
+		!authorizeDatasourceAccessForRule(rule, hasAccess) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to update the rule", ErrAuthorization), "")
+	}
+
+	namespaces, err := srv.store.GetUserVisibleNamespaces(c.Req.Context(), c.SignedInUser.OrgID, c.SignedInUser)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get namespaces visible to the user")
+	}
+	namespace, ok := namespaces[rule.NamespaceUID]
+	if !ok {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to access the folder of the rule", ErrAuthorization), "")
+	}
+
+	if rule.IsPaused != paused {
+		if !provenanceOverridden(c) {
+			provenance, err := srv.provenanceStore.GetProvenance(c.Req.Context(), rule, c.SignedInUser.OrgID)
+			if err != nil {
+				return ErrResp(http.StatusInternalServerError, err, "failed to get provenance of the alert rule")
+			}
+			if provenance != ngmodels.ProvenanceNone {
+				return ErrResp(http.StatusBadRequest, fmt.Errorf("%w: alert rule %s", errProvisionedResource, rule.UID), "")
+			}
+		}
+
+		updated := *rule
+		updated.IsPaused = paused
+		err = srv.xactManager.InTransaction(c.Req.Context(), func(ctx context.Context) error {
+			return srv.store.UpdateAlertRules(ctx, []ngmodels.UpdateRule{{Existing: rule, New: updated}})
+		})
+		if err != nil {
+			if errors.Is(err, store.ErrOptimisticLock) {
+				return ErrResp(http.StatusConflict, err, "")
+			}
+			return ErrResp(http.StatusInternalServerError, err, "failed to update alert rule")
+		}
+
+		rule, err = srv.store.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: ruleUID, OrgID: c.SignedInUser.OrgID})
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+		}
+	}
+
+	provenanceRecords, err := srv.provenanceStore.GetProvenances(c.Req.Context(), c.SignedInUser.OrgID, rule.ResourceType())
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+	evaluations, err := srv.getRuleEvaluations(c.Req.Context(), c.SignedInUser.OrgID, ngmodels.RulesGroup{rule})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+	summaries, err := srv.getRuleStateSummaries(c.Req.Context(), c.SignedInUser.OrgID, ngmodels.RulesGroup{rule})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+	return response.JSON(http.StatusOK, toGettableExtendedRuleNode(*rule, namespace.ID, provenanceRecords, evaluations[rule.UID], summaries[rule.UID]))
+}
+
+// bulkAlertRuleError is the failure of a bulk action caused by one of the rules.
+type bulkAlertRuleError struct {
+	uid string
+	err error
+}
+
+func (e bulkAlertRuleError) Error() string {
+	return fmt.Sprintf("alert rule %s: %s", e.uid, e.err)
+}
+
+func (e bulkAlertRuleError) Unwrap() error {
+	return e.err
+}
+
+// RouteBulkAlertRules deletes, pauses or unpauses the rules with the given UIDs in a single transaction. Rules that do not exist
+// in the user's organization are reported as not found. If the user is not authorized to change one of the rules, or one of the
+// rules was created via the provisioning API, no rule is changed and the response names the rule that caused the failure.
+func (srv RulerSrv) RouteBulkAlertRules(c *contextmodel.ReqContext, body apimodels.PostableBulkRuleAction) response.Response {
+	var paused bool
+	action := accesscontrol.ActionAlertingRuleUpdate
+	switch body.Action {
+	case "delete":
+		action = accesscontrol.ActionAlertingRuleDelete
+	case "pause":
+		paused = true
+	case "unpause":
+	default:
+		return bulkAlertRulesErrorResponse(http.StatusBadRequest, fmt.Errorf("unknown action %q, must be one of delete, pause or unpause", body.Action))
+	}
+	if len(body.UIDs) == 0 || len(body.UIDs) > maxBulkAlertRules {
+		return bulkAlertRulesErrorResponse(http.StatusBadRequest, fmt.Errorf("the number of rules must be between 1 and %d", maxBulkAlertRules))
+	}
+
+	provenances, err := srv.getProvenancesToCheck(c)
+	if err != nil {
+		return bulkAlertRulesErrorResponse(http.StatusInternalServerError, fmt.Errorf("failed to get provenances of alert rules: %w", err))
+	}
+
+	var result apimodels.BulkRuleActionResponse
+	err = srv.xactManager.InTransaction(c.Req.Context(), func(ctx context.Context) error {
+		result = apimodels.BulkRuleActionResponse{Items: make([]apimodels.BulkRuleActionItem, 0, len(body.UIDs))}
+		seen := make(map[string]struct{}, len(body.UIDs))
+		toDelete := make([]string, 0, len(body.UIDs))
+		toUpdate := make([]ngmodels.UpdateRule, 0, len(body.UIDs))
+		for _, uid := range body.UIDs {
+			if _, ok := seen[uid]; ok {
+				continue
+			}
+			seen[uid] = struct{}{}
+
+			rule, err := srv.store.GetAlertRuleByUID(ctx, &ngmodels.GetAlertRuleByUIDQuery{UID: uid, OrgID: c.SignedInUser.OrgID})
+			if err != nil {
+				if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+					result.Items = append(result.Items, apimodels.BulkRuleActionItem{UID: uid, Status: "notFound"})
+					continue
+				}
+				return err
+			}
+			hasAccess := hasAccessInFolder(srv.ac, c, accesscontrol.ReqOrgAdminOrEditor, rule.NamespaceUID, dashboards.PERMISSION_EDIT)
+			if !hasAccess(accesscontrol.EvalPermission(action, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID))) ||
+				!authorizeDatasourceAccessForRule(rule, hasAccess) {
+				return bulkAlertRuleError{uid: uid, err: fmt.Errorf("%w to %s the rule", ErrAuthorization, body.Action)}
+			}
+			if provenance, ok := provenances[rule.UID]; ok && provenance != ngmodels.ProvenanceNone {
+				return bulkAlertRuleError{uid: uid, err: errProvisionedResource}
+			}
+
+			result.Items = append(result.Items, apimodels.BulkRuleActionItem{UID: uid, Status: "success"})
+			if body.Action == "delete" {
+				toDelete = append(toDelete, uid)
+			} else if rule.IsPaused != paused {
+				updated := *rule
+				updated.IsPaused = paused
+				toUpdate = append(toUpdate, ngmodels.UpdateRule{Existing: rule, New: updated})
+			}
+		}
+
+		if len(toDelete) > 0 {
+			if err := srv.store.DeleteAlertRulesByUID(ctx, c.SignedInUser.OrgID, toDelete...); err != nil {
+				return err
+			}
+		}
+		if len(toUpdate) > 0 {
+			if err := srv.store.UpdateAlertRules(ctx, toUpdate); err != nil {
+				return err
+			}
+		}
+		result.RowsAffected = int64(len(toDelete) + len(toUpdate))
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAuthorization):
+			return bulkAlertRulesErrorResponse(http.StatusUnauthorized, err)
+		case errors.Is(err, errProvisionedResource):
+			return bulkAlertRulesErrorResponse(http.StatusBadRequest, err)
+		case errors.Is(err, store.ErrOptimisticLock):
+			return bulkAlertRulesErrorResponse(http.StatusConflict, err)
+		}
+		return bulkAlertRulesErrorResponse(http.StatusInternalServerError, fmt.Errorf("failed to %s alert rules: %w", body.Action, err))
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// bulkAlertRulesErrorResponse returns the error as an apimodels.BulkRuleActionError with the UID of the rule that caused it, if any.
+func bulkAlertRulesErrorResponse(status int, err error) response.Response {
+	body := apimodels.BulkRuleActionError{Message: err.Error()}
+	var ruleErr bulkAlertRuleError
+	if errors.As(err, &ruleErr) {
+		body.UID = ruleErr.uid
+	}
+	return response.JSON(status, body)
+}
+
+// RouteTestAlertRuleNotification sends a test notification of the rule with the given UID to the contact points that the rule is
+// associated with, and returns the result of the delivery to each contact point. The notification is rendered for an instance with
+// the labels and values of the body, or sample ones, and is marked as a test. The state and history of the instances of the rule are
+// not changed. Returns http.StatusNotFound if the rule does not exist in the user's organization, and http.StatusBadRequest if the
+// rule is not associated with any contact point.
+func (srv RulerSrv) RouteTestAlertRuleNotification(c *contextmodel.ReqContext, ruleUID string, body apimodels.PostableTestRuleNotification) response.Response {
+	rule, err := srv.store.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: ruleUID, OrgID: c.SignedInUser.OrgID})
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+
+	hasAccess := hasAccessInFolder(srv.ac, c, accesscontrol.ReqOrgAdminOrEditor, rule.NamespaceUID, dashboards.PERMISSION_EDIT)
+	if !hasAccess(accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleUpdate, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID))) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to test the notifications of the rule", ErrAuthorization), "")
+	}
+	if len(rule.ContactPointUIDs) == 0 {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("alert rule %s is not associated with any contact point", rule.UID), "")
+	}
+
+	n := state.NewTestNotification(c.Req.Context(), srv.log, rule, body.Labels, body.Values, srv.clock.Now(), srv.appURL)
+	resp := apimodels.TestRuleNotificationResponse{
+		Title:   n.Title,
+		Results: make([]apimodels.TestRuleNotificationResult, 0, len(rule.ContactPointUIDs)),
+	}
+	for _, uid := range rule.ContactPointUIDs {
+		result := apimodels.TestRuleNotificationResult{ContactPointUID: uid, Status: "success"}
+		if err := srv.notifier.SendTestNotification(c.Req.Context(), rule.OrgID, uid, n); err != nil {
+			srv.log.Warn("Failed to send test notification", "rule", rule.UID, "contactPoint", uid, "error", err)
+			result.Status = "failure"
+			result.Error = err.Error()
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return response.JSON(http.StatusOK, resp)
+}
+
+// RouteResetAlertRuleInstances resets the instances of the rule with the given UID to Normal, so that the next evaluation of the
+// rule establishes their state from scratch. If the labelsHash query parameter is set, only the instance with that hash is reset.
+// Returns http.StatusNotFound if the rule does not exist or is not scheduled yet.
+func (srv RulerSrv) RouteResetAlertRuleInstances(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	rule, err := srv.store.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: ruleUID, OrgID: c.SignedInUser.OrgID})
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+
+	hasAccess := hasAccessInFolder(srv.ac, c, accesscontrol.ReqOrgAdminOrEditor, rule.NamespaceUID, dashboards.PERMISSION_EDIT)
+	if !hasAccess(accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleUpdate, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID))) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to reset the rule", ErrAuthorization), "")
+	}
+
+	count, err := srv.scheduler.ResetState(c.Req.Context(), ngmodels.ResetAlertInstancesCommand{
+		OrgID:      rule.OrgID,
+		RuleUID:    rule.UID,
+		LabelsHash: c.Query("labelsHash"),
+		ResetBy:    ngmodels.ActorName(c.SignedInUser),
+	})
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "alert rule is not scheduled for evaluation yet")
+		}
+		if errors.Is(err, ngmodels.ErrAlertRuleNotOwned) {
+			return ErrResp(http.StatusConflict, err, "the reset must be requested from the instance that evaluates the rule")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to reset the alert instances")
+	}
+	return response.JSON(http.StatusOK, apimodels.ResetRuleInstancesResponse{Reset: int64(count)})
+}
+
+// RouteGetRuleInstances returns a page of the alert instances of the rule with the given UID, optionally filtered by their current state.
+// Instances are ordered from the most severe state and then by labels.
+// Returns http.StatusNotFound if the rule does not exist in the user's organization, and http.StatusBadRequest if a state or the page is not valid.
+func (srv RulerSrv) RouteGetRuleInstances(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	rule, err := srv.store.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: ruleUID, OrgID: c.SignedInUser.OrgID})
+	if err != nil {
+		if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+			return ErrResp(http.StatusNotFound, err, "")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+
+	hasAccess := hasAccessInFolder(srv.ac, c, accesscontrol.ReqViewer, rule.NamespaceUID, dashboards.PERMISSION_VIEW)
+	if !hasAccess(accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleRead, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID))) ||
+		!authorizeDatasourceAccessForRule(rule, hasAccess) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to access the rule", ErrAuthorization), "")
+	}
+
+	page := c.QueryInt64("page")
+	if page == 0 {
+		page = 1
+	}
+	limit := c.QueryInt64("limit")
+	if limit == 0 {
+		limit = defaultRuleInstancesLimit
+	}
+	if page < 0 || limit < 0 || limit > maxRuleInstancesLimit {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("page must be positive and limit must be between 1 and %d", maxRuleInstancesLimit), "")
+	}
+
+	query := ngmodels.ListAlertInstancesQuery{
+		RuleOrgID: rule.OrgID,
+		RuleUID:   rule.UID,
+		Limit:     limit,
+		Offset:    (page - 1) * limit,
+	}
+	for _, s := range c.QueryStrings("state") {
+		state := ngmodels.InstanceStateType(s)
+		if !state.IsValid() {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("unknown state %q", s), "")
+		}
+		query.States = append(query.States, state)
+	}
+	for _, m := range c.QueryStrings("matcher") {
+		name, value, ok := strings.Cut(m, "=")
+		if !ok || name == "" {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("matcher %q must be in the form name=value", m), "")
+		}
+		if query.Labels == nil {
+			query.Labels = make(map[string]string)
+		}
+		if v, ok := query.Labels[name]; ok && v != value {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("conflicting matchers for label %q", name), "")
+		}
+		query.Labels[name] = value
+	}
+
+	count, err := srv.store.CountAlertInstances(c.Req.Context(), &query)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to count alert instances")
+	}
+	instances, err := srv.store.ListAlertInstances(c.Req.Context(), &query)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert instances")
+	}
+
+	// The instances are saved only when they change, or every few evaluations otherwise. The time and the values of the
+	// latest evaluation are therefore taken from the states of the rule in memory, if this instance evaluates the rule.
+	latest := make(map[string]*state.State)
+	for _, s := range srv.manager.GetStatesForRuleUID(rule.OrgID, rule.UID) {
+		key, err := s.GetAlertInstanceKey()
+		if err != nil {
+			continue
+		}
+		latest[key.LabelsHash] = s
+	}
+
+	result := apimodels.RuleInstancesResponse{
+		TotalCount: count,
+		Page:       page,
+		Limit:      limit,
+		Instances:  make([]apimodels.GettableAlertInstance, 0, len(instances)),
+	}
+	now := srv.clock.Now()
+	for _, instance := range instances {
+		gettable := apimodels.GettableAlertInstance{
+			Labels:         instance.Labels,
+			LabelsHash:     instance.LabelsHash,
+			State:          string(instance.CurrentState),
+			StateReason:    instance.CurrentReason,
+			StateSince:     instance.CurrentStateSince,
+			LastEvaluation: instance.LastEvalTime,
+			Values:         instance.Values.Nullable(),
+		}
+		if s, ok := latest[instance.LabelsHash]; ok && s.LastEvaluationTime.After(instance.LastEvalTime) {
+			gettable.LastEvaluation = s.LastEvaluationTime
+			gettable.Values = ngmodels.InstanceValues(s.Values).Nullable()
+		}
+		if !instance.ResolvedAt.IsZero() && now.Sub(instance.ResolvedAt) < srv.cfg.ResolvedGracePeriod {
+			resolvedAt := instance.ResolvedAt
+			gettable.Resolved = true
+			gettable.ResolvedAt = &resolvedAt
+		}
+		result.Instances = append(result.Instances, gettable)
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
+// RouteGetRuleAudit returns a page of the audit trail of a rule, from the newest change. The trail of a deleted rule
+// is kept, and access to it is checked against the folder that the rule was in when it was last changed.
+func (srv RulerSrv) RouteGetRuleAudit(c *contextmodel.ReqContext, ruleUID string) response.Response {
+	page := c.QueryInt64("page")
+	if page == 0 {
+		page = 1
+	}
+	limit := c.QueryInt64("limit")
+	if limit == 0 {
+		limit = defaultRuleAuditLimit
+	}
+	if page < 0 || limit < 0 || limit > maxRuleAuditLimit {
+		return ErrResp(http.StatusBadRequest, fmt.Errorf("page must be positive and limit must be between 1 and %d", maxRuleAuditLimit), "")
+	}
+
+	query := ngmodels.ListAlertRuleAuditQuery{
+		OrgID:   c.SignedInUser.OrgID,
+		RuleUID: ruleUID,
+		Limit:   limit,
+		Offset:  (page - 1) * limit,
+	}
+
+	var namespaceUID string
+	rule, err := srv.store.GetAlertRuleByUID(c.Req.Context(), &ngmodels.GetAlertRuleByUIDQuery{UID: ruleUID, OrgID: c.SignedInUser.OrgID})
+	switch {
+	case err == nil:
+		namespaceUID = rule.NamespaceUID
+	case errors.Is(err, ngmodels.ErrAlertRuleNotFound):
+		latest, err := srv.store.ListAlertRuleAuditEntries(c.Req.Context(), &ngmodels.ListAlertRuleAuditQuery{OrgID: query.OrgID, RuleUID: ruleUID, Limit: 1})
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to get audit entries")
+		}
+		if len(latest) == 0 {
+			return ErrResp(http.StatusNotFound, ngmodels.ErrAlertRuleNotFound, "")
+		}
+		namespaceUID = latest[0].NamespaceUID
+	default:
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rule")
+	}
+
+	hasAccess := hasAccessInFolder(srv.ac, c, accesscontrol.ReqViewer, namespaceUID, dashboards.PERMISSION_VIEW)
+	if !hasAccess(accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleRead, dashboards.ScopeFoldersProvider.GetResourceScopeUID(namespaceUID))) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to access the rule", ErrAuthorization), "")
+	}
+
+	count, err := srv.store.CountAlertRuleAuditEntries(c.Req.Context(), &query)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to count audit entries")
+	}
+	entries, err := srv.store.ListAlertRuleAuditEntries(c.Req.Context(), &query)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get audit entries")
+	}
+
+	result := apimodels.RuleAuditResponse{
+		TotalCount: count,
+		Page:       page,
+		Limit:      limit,
+		Entries:    make([]apimodels.GettableRuleAuditEntry, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		result.Entries = append(result.Entries, apimodels.GettableRuleAuditEntry{
+			Action:    string(entry.Action),
+			ActorID:   entry.ActorID,
+			Timestamp: entry.Created,
+			Changes:   entry.Changes,
+		})
+	}
+	return response.JSON(http.StatusOK, result)
+}
+
 // RouteGetNamespaceRulesConfig returns all rules in a specific folder that user has access to
 func (srv RulerSrv) RouteGetNamespaceRulesConfig(c *contextmodel.ReqContext, namespaceTitle string) response.Response {
 	namespace, err := srv.store.GetNamespaceByTitle(c.Req.Context(), namespaceTitle, c.SignedInUser.OrgID, c.SignedInUser, false)
@@ -173,6 +767,16 @@ func (srv RulerSrv) RouteGetNamespaceRulesConfig(c *contextmodel.ReqContext, nam
 		return ErrResp(http.StatusInternalServerError, err, "failed to get provenance for rule group")
 	}
 
+	evaluations, err := srv.getRuleEvaluations(c.Req.Context(), c.SignedInUser.OrgID, ruleList)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get evaluations of alert rules")
+	}
+
+	summaries, err := srv.getRuleStateSummaries(c.Req.Context(), c.SignedInUser.OrgID, ruleList)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get states of alert rules")
+	}
+
 	ruleGroups := make(map[string]ngmodels.RulesGroup)
 	for _, r := range ruleList {
 		ruleGroups[r.RuleGroup] = append(ruleGroups[r.RuleGroup], r)
@@ -182,7 +786,7 @@ func (srv RulerSrv) RouteGetNamespaceRulesConfig(c *contextmodel.ReqContext, nam
 		if !authorizeAccessToRuleGroup(rules, hasAccess) {
 			continue
 		}
-		result[namespaceTitle] = append(result[namespaceTitle], toGettableRuleGroupConfig(groupName, rules, namespace.ID, provenanceRecords))
+		result[namespaceTitle] = append(result[namespaceTitle], toGettableRuleGroupConfig(groupName, rules, namespace.ID, provenanceRecords, evaluations, summaries))
 	}
 
 	return response.JSON(http.StatusAccepted, result)
@@ -219,8 +823,18 @@ func (srv RulerSrv) RouteGetRulesGroupConfig(c *contextmodel.ReqContext, namespa
 		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to access the group because it does not have access to one or many data sources one or many rules in the group use", ErrAuthorization), "")
 	}
 
+	evaluations, err := srv.getRuleEvaluations(c.Req.Context(), c.SignedInUser.OrgID, ruleList)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
+	}
+
+	summaries, err := srv.getRuleStateSummaries(c.Req.Context(), c.SignedInUser.OrgID, ruleList)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
+	}
+
 	result := apimodels.RuleGroupConfigResponse{
-		GettableRuleGroupConfig: toGettableRuleGroupConfig(ruleGroup, ruleList, namespace.ID, provenanceRecords),
+		GettableRuleGroupConfig: toGettableRuleGroupConfig(ruleGroup, ruleList, namespace.ID, provenanceRecords, evaluations, summaries),
 	}
 	return response.JSON(http.StatusAccepted, result)
 }
@@ -273,6 +887,16 @@ func (srv RulerSrv) RouteGetRulesConfig(c *contextmodel.ReqContext) response.Res
 		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rules")
 	}
 
+	evaluations, err := srv.getRuleEvaluations(c.Req.Context(), c.SignedInUser.OrgID, nil)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rules")
+	}
+
+	summaries, err := srv.getRuleStateSummaries(c.Req.Context(), c.SignedInUser.OrgID, nil)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rules")
+	}
+
 	configs := make(map[ngmodels.AlertRuleGroupKey]ngmodels.RulesGroup)
 	for _, r := range ruleList {
 		groupKey := r.GetGroupKey()
@@ -291,7 +915,7 @@ func (srv RulerSrv) RouteGetRulesConfig(c *contextmodel.ReqContext) response.Res
 			continue
 		}
 		namespace := folder.Title
-		result[namespace] = append(result[namespace], toGettableRuleGroupConfig(groupKey.RuleGroup, rules, folder.ID, provenanceRecords))
+		result[namespace] = append(result[namespace], toGettableRuleGroupConfig(groupKey.RuleGroup, rules, folder.ID, provenanceRecords, evaluations, summaries))
 	}
 	return response.JSON(http.StatusOK, result)
 }
@@ -306,7 +930,7 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *contextmodel.ReqContext, ruleGro
 		return srv.conditionValidator.Validate(eval.Context(c.Req.Context(), c.SignedInUser), condition)
 	}, srv.cfg)
 	if err != nil {
-		return ErrResp(http.StatusBadRequest, err, "")
+		return validationErrorResponse(err)
 	}
 
 	groupKey := ngmodels.AlertRuleGroupKey{
@@ -336,26 +960,42 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 			return nil
 		}
 
-		// if RBAC is disabled the permission are limited to folder access that is done upstream
+		// if RBAC is disabled the permission are limited to folder access. The target folder is checked upstream,
+		// and the folders that rules are moved from are checked here.
 		if !srv.ac.IsDisabled() {
 			err = authorizeRuleChanges(groupChanges, func(evaluator accesscontrol.Evaluator) bool {
 				return hasAccess(accesscontrol.ReqOrgAdminOrEditor, evaluator)
 			})
-			if err != nil {
-				return err
-			}
+		} else {
+			err = authorizeRuleMovesFromFolders(c, groupChanges)
 		}
-
-		if err := verifyProvisionedRulesNotAffected(c.Req.Context(), srv.provenanceStore, c.OrgID, groupChanges); err != nil {
+		if err != nil {
 			return err
 		}
 
+		if !provenanceOverridden(c) {
+			if err := verifyProvisionedRulesNotAffected(c.Req.Context(), srv.provenanceStore, c.OrgID, groupChanges); err != nil {
+				return err
+			}
+		}
+
 		finalChanges = store.UpdateCalculatedRuleFields(groupChanges)
 		logger.Debug("updating database with the authorized changes", "add", len(finalChanges.New), "update", len(finalChanges.New), "delete", len(finalChanges.Delete))
 
+		if len(finalChanges.Delete) > 0 {
+			UIDs := make([]string, 0, len(finalChanges.Delete))
+			for _, rule := range finalChanges.Delete {
+				UIDs = append(UIDs, rule.UID)
+			}
+
+			// the rules are deleted first, so that they do not count against the quota of the new ones
+			if err = srv.store.DeleteAlertRulesByUID(tranCtx, c.SignedInUser.OrgID, UIDs...); err != nil {
+				return fmt.Errorf("failed to delete rules: %w", err)
+			}
+		}
+
 		if len(finalChanges.Update) > 0 || len(finalChanges.New) > 0 {
 			updates := make([]ngmodels.UpdateRule, 0, len(finalChanges.Update))
-			inserts := make([]ngmodels.AlertRule, 0, len(finalChanges.New))
 			for _, update := range finalChanges.Update {
 				logger.Debug("updating rule", "rule_uid", update.New.UID, "diff", update.Diff.String())
 				updates = append(updates, ngmodels.UpdateRule{
@@ -363,42 +1003,28 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 					New:      *update.New,
 				})
 			}
+			// the new rules are inserted one by one, so that the rule that reaches the quota is known
 			for _, rule := range finalChanges.New {
-				inserts = append(inserts, *rule)
-			}
-			_, err = srv.store.InsertAlertRules(tranCtx, inserts)
-			if err != nil {
-				return fmt.Errorf("failed to add rules: %w", err)
+				limitReached, err := srv.QuotaService.CheckQuotaReached(tranCtx, ngmodels.QuotaTargetSrv, &quota.ScopeParameters{
+					OrgID:  c.OrgID,
+					UserID: c.UserID,
+				}) // alert rule is table name
+				if err != nil {
+					return fmt.Errorf("failed to get alert rules quota: %w", err)
+				}
+				if limitReached {
+					return &ngmodels.QuotaReachedError{RuleTitle: rule.Title}
+				}
+				rule.CreatedBy = ngmodels.ActorID(c.SignedInUser)
+				if _, err = srv.store.InsertAlertRules(tranCtx, []ngmodels.AlertRule{*rule}); err != nil {
+					return fmt.Errorf("failed to add rules: %w", err)
+				}
 			}
 			err = srv.store.UpdateAlertRules(tranCtx, updates)
 			if err != nil {
 				return fmt.Errorf("failed to update rules: %w", err)
 			}
 		}
-
-		if len(finalChanges.Delete) > 0 {
-			UIDs := make([]string, 0, len(finalChanges.Delete))
-			for _, rule := range finalChanges.Delete {
-				UIDs = append(UIDs, rule.UID)
-			}
-
-			if err = srv.store.DeleteAlertRulesByUID(tranCtx, c.SignedInUser.OrgID, UIDs...); err != nil {
-				return fmt.Errorf("failed to delete rules: %w", err)
-			}
-		}
-
-		if len(finalChanges.New) > 0 {
-			limitReached, err := srv.QuotaService.CheckQuotaReached(tranCtx, ngmodels.QuotaTargetSrv, &quota.ScopeParameters{
-				OrgID:  c.OrgID,
-				UserID: c.UserID,
-			}) // alert rule is table name
-			if err != nil {
-				return fmt.Errorf("failed to get alert rules quota: %w", err)
-			}
-			if limitReached {
-				return ngmodels.ErrQuotaReached
-			}
-		}
 		return nil
 	})
 
@@ -408,6 +1034,8 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 		} else if errors.Is(err, ngmodels.ErrAlertRuleFailedValidation) || errors.Is(err, errProvisionedResource) {
 			return ErrResp(http.StatusBadRequest, err, "failed to update rule group")
 		} else if errors.Is(err, ngmodels.ErrQuotaReached) {
+			return quotaReachedResponse(provisioning.WithQuotaUsage(c.Req.Context(), srv.QuotaService, c.OrgID, err))
+		} else if errors.Is(err, ErrDatasourceAuthorization) {
 			return ErrResp(http.StatusForbidden, err, "")
 		} else if errors.Is(err, ErrAuthorization) {
 			return ErrResp(http.StatusUnauthorized, err, "")
@@ -424,7 +1052,54 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 	return response.JSON(http.StatusAccepted, util.DynMap{"message": "rule group updated successfully"})
 }
 
-func toGettableRuleGroupConfig(groupName string, rules ngmodels.RulesGroup, namespaceID int64, provenanceRecords map[string]ngmodels.Provenance) apimodels.GettableRuleGroupConfig {
+// getRuleEvaluations returns the latest evaluations of the given rules by rule UID. If rules is nil, it returns the evaluations of all rules of the organization.
+func (srv RulerSrv) getRuleEvaluations(ctx context.Context, orgID int64, rules ngmodels.RulesGroup) (map[string]*ngmodels.AlertRuleEvaluation, error) {
+	query := ngmodels.GetAlertRuleEvaluationsQuery{OrgID: orgID}
+	if rules != nil {
+		if len(rules) == 0 {
+			return nil, nil
+		}
+		query.RuleUIDs = make([]string, 0, len(rules))
+		for _, r := range rules {
+			query.RuleUIDs = append(query.RuleUIDs, r.UID)
+		}
+	}
+	evaluations, err := srv.store.GetAlertRuleEvaluations(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*ngmodels.AlertRuleEvaluation, len(evaluations))
+	for _, e := range evaluations {
+		result[e.RuleUID] = e
+	}
+	return result, nil
+}
+
+// getRuleStateSummaries returns the number of alert instances per state of the given rules by rule UID. If rules is nil,
+// it returns the summaries of all rules of the organization. Rules without instances are omitted.
+func (srv RulerSrv) getRuleStateSummaries(ctx context.Context, orgID int64, rules ngmodels.RulesGroup) (map[string]*ngmodels.AlertInstanceStateSummary, error) {
+	query := ngmodels.GetAlertInstanceStateSummariesQuery{OrgID: orgID}
+	if rules != nil {
+		if len(rules) == 0 {
+			return nil, nil
+		}
+		query.RuleUIDs = make([]string, 0, len(rules))
+		for _, r := range rules {
+			query.RuleUIDs = append(query.RuleUIDs, r.UID)
+		}
+	}
+	summaries, err := srv.store.GetAlertInstanceStateSummaries(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*ngmodels.AlertInstanceStateSummary, len(summaries))
+	for _, s := range summaries {
+		result[s.RuleUID] = s
+	}
+	return result, nil
+}
+
+func toGettableRuleGroupConfig(groupName string, rules ngmodels.RulesGroup, namespaceID int64, provenanceRecords map[string]ngmodels.Provenance, evaluations map[string]*ngmodels.AlertRuleEvaluation, summaries map[string]*ngmodels.AlertInstanceStateSummary) apimodels.GettableRuleGroupConfig {
 	rules.SortByGroupIndex()
 	ruleNodes := make([]apimodels.GettableExtendedRuleNode, 0, len(rules))
 	var interval time.Duration
@@ -432,7 +1107,7 @@ func toGettableRuleGroupConfig(groupName string, rules ngmodels.RulesGroup, name
 		interval = time.Duration(rules[0].IntervalSeconds) * time.Second
 	}
 	for _, r := range rules {
-		ruleNodes = append(ruleNodes, toGettableExtendedRuleNode(*r, namespaceID, provenanceRecords))
+		ruleNodes = append(ruleNodes, toGettableExtendedRuleNode(*r, namespaceID, provenanceRecords, evaluations[r.UID], summaries[r.UID]))
 	}
 	return apimodels.GettableRuleGroupConfig{
 		Name:     groupName,
@@ -441,31 +1116,56 @@ func toGettableRuleGroupConfig(groupName string, rules ngmodels.RulesGroup, name
 	}
 }
 
-func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64, provenanceRecords map[string]ngmodels.Provenance) apimodels.GettableExtendedRuleNode {
+func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64, provenanceRecords map[string]ngmodels.Provenance, lastEvaluation *ngmodels.AlertRuleEvaluation, summary *ngmodels.AlertInstanceStateSummary) apimodels.GettableExtendedRuleNode {
 	provenance := ngmodels.ProvenanceNone
 	if prov, exists := provenanceRecords[r.ResourceID()]; exists {
 		provenance = prov
 	}
 	gettableExtendedRuleNode := apimodels.GettableExtendedRuleNode{
 		GrafanaManagedAlert: &apimodels.GettableGrafanaRule{
-			ID:              r.ID,
-			OrgID:           r.OrgID,
-			Title:           r.Title,
-			Condition:       r.Condition,
-			Data:            ApiAlertQueriesFromAlertQueries(r.Data),
-			Updated:         r.Updated,
-			IntervalSeconds: r.IntervalSeconds,
-			Version:         r.Version,
-			UID:             r.UID,
-			NamespaceUID:    r.NamespaceUID,
-			NamespaceID:     namespaceID,
-			RuleGroup:       r.RuleGroup,
-			NoDataState:     apimodels.NoDataState(r.NoDataState),
-			ExecErrState:    apimodels.ExecutionErrorState(r.ExecErrState),
-			Provenance:      apimodels.Provenance(provenance),
-			IsPaused:        r.IsPaused,
+			ID:                         r.ID,
+			OrgID:                      r.OrgID,
+			Title:                      r.Title,
+			Condition:                  r.Condition,
+			Data:                       ApiAlertQueriesFromAlertQueries(r.Data),
+			Updated:                    r.Updated,
+			IntervalSeconds:            r.IntervalSeconds,
+			Version:                    r.Version,
+			UID:                        r.UID,
+			NamespaceUID:               r.NamespaceUID,
+			NamespaceID:                namespaceID,
+			RuleGroup:                  r.RuleGroup,
+			NoDataState:                apimodels.NoDataState(r.NoDataState),
+			ExecErrState:               apimodels.ExecutionErrorState(r.ExecErrState),
+			Provenance:                 apimodels.Provenance(provenance),
+			IsPaused:                   r.IsPaused,
+			Schedule:                   r.Schedule,
+			ScheduleTimezone:           r.ScheduleTimezone,
+			InstanceLimit:              r.InstanceLimit,
+			ContactPointUIDs:           r.ContactPointUIDs,
+			NotificationTitle:          r.NotificationTitle,
+			NotificationMessage:        r.NotificationMessage,
+			NotificationRepeatInterval: model.Duration(r.NotificationRepeatInterval),
+			SendResolved:               r.SendResolved,
 		},
 	}
+	if lastEvaluation != nil {
+		evaluatedAt := lastEvaluation.EvaluatedAt
+		gettableExtendedRuleNode.GrafanaManagedAlert.LastEvaluation = &evaluatedAt
+		gettableExtendedRuleNode.GrafanaManagedAlert.LastEvaluationDuration = lastEvaluation.Duration.Seconds()
+		gettableExtendedRuleNode.GrafanaManagedAlert.LastEvaluationState = lastEvaluation.State
+		gettableExtendedRuleNode.GrafanaManagedAlert.LastEvaluationError = lastEvaluation.Error
+	}
+	stateSummary := &apimodels.InstanceStateSummary{
+		WorstState: string(summary.WorstState()),
+		Counts:     map[string]int64{},
+	}
+	if summary != nil {
+		for state, count := range summary.Counts {
+			stateSummary.Counts[string(state)] = count
+		}
+	}
+	gettableExtendedRuleNode.GrafanaManagedAlert.StateSummary = stateSummary
 	forDuration := model.Duration(r.For)
 	gettableExtendedRuleNode.ApiRuleNode = &apimodels.ApiRuleNode{
 		For:         &forDuration,
@@ -475,6 +1175,36 @@ func toGettableExtendedRuleNode(r ngmodels.AlertRule, namespaceID int64, provena
 	return gettableExtendedRuleNode
 }
 
+func toRuleEvaluationResponse(evaluatedAt time.Time, results eval.Results) apimodels.RuleEvaluationResponse {
+	resp := apimodels.RuleEvaluationResponse{
+		EvaluatedAt: evaluatedAt,
+		State:       results.State().String(),
+		Results:     make([]apimodels.RuleEvaluationResult, 0, len(results)),
+	}
+	for _, result := range results {
+		r := apimodels.RuleEvaluationResult{
+			Labels: result.Instance,
+			State:  result.State.String(),
+		}
+		if len(result.Values) > 0 {
+			values := make(ngmodels.InstanceValues, len(result.Values))
+			for refID, capture := range result.Values {
+				values[refID] = math.NaN()
+				if capture.Value != nil {
+					values[refID] = *capture.Value
+				}
+			}
+			r.Values = values.Nullable()
+		}
+		if result.Error != nil {
+			r.Error = result.Error.Error()
+			r.ErrorReason = eval.ErrorReason(result.Error)
+		}
+		resp.Results = append(resp.Results, r)
+	}
+	return resp
+}
+
 func toNamespaceErrorResponse(err error) response.Response {
 	if errors.Is(err, ngmodels.ErrCannotEditNamespace) {
 		return ErrResp(http.StatusForbidden, err, err.Error())
@@ -485,6 +1215,15 @@ func toNamespaceErrorResponse(err error) response.Response {
 	return apierrors.ToFolderErrorResponse(err)
 }
 
+// getProvenancesToCheck returns the provenances of the alert rules of the user's organization by rule UID.
+// Returns an empty map if the request overrides the provenance checks, so that no rule is considered provisioned.
+func (srv RulerSrv) getProvenancesToCheck(c *contextmodel.ReqContext) (map[string]ngmodels.Provenance, error) {
+	if provenanceOverridden(c) {
+		return map[string]ngmodels.Provenance{}, nil
+	}
+	return srv.provenanceStore.GetProvenances(c.Req.Context(), c.SignedInUser.OrgID, (&ngmodels.AlertRule{}).ResourceType())
+}
+
 // verifyProvisionedRulesNotAffected check that neither of provisioned alerts are affected by changes.
 // Returns errProvisionedResource if there is at least one rule in groups affected by changes that was provisioned.
 func verifyProvisionedRulesNotAffected(ctx context.Context, provenanceStore provisioning.ProvisioningStore, orgID int64, ch *store.GroupDelta) error {
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/folder"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+// RouteGetPrometheusRulesConfig returns the alert rules that the user has access to as Prometheus rule groups, keyed by
+// the title of their folder like the Cortex ruler API.
+func (srv RulerSrv) RouteGetPrometheusRulesConfig(c *contextmodel.ReqContext) response.Response {
+	namespaces, err := srv.store.GetUserVisibleNamespaces(c.Req.Context(), c.OrgID, c.SignedInUser)
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get namespaces visible to the user")
+	}
+	if len(namespaces) == 0 {
+		return response.YAML(http.StatusOK, apimodels.PrometheusRulesExport{})
+	}
+	namespaceUIDs := make([]string, 0, len(namespaces))
+	for uid := range namespaces {
+		namespaceUIDs = append(namespaceUIDs, uid)
+	}
+
+	result, err := srv.getPrometheusRules(c, namespaces, &ngmodels.ListAlertRulesQuery{
+		OrgID:         c.SignedInUser.OrgID,
+		NamespaceUIDs: namespaceUIDs,
+	})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rules")
+	}
+	return response.YAML(http.StatusOK, result)
+}
+
+// RouteGetNamespacePrometheusRulesConfig returns the alert rules of the folder that the user has access to as
+// Prometheus rule groups. Returns http.StatusNotFound if there are none, like the Cortex ruler API.
+func (srv RulerSrv) RouteGetNamespacePrometheusRulesConfig(c *contextmodel.ReqContext, namespaceTitle string) response.Response {
+	namespace, err := srv.store.GetNamespaceByTitle(c.Req.Context(), namespaceTitle, c.SignedInUser.OrgID, c.SignedInUser, false)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+
+	result, err := srv.getPrometheusRules(c, map[string]*folder.Folder{namespace.UID: namespace}, &ngmodels.ListAlertRulesQuery{
+		OrgID:         c.SignedInUser.OrgID,
+		NamespaceUIDs: []string{namespace.UID},
+	})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get alert rules")
+	}
+	if len(result[namespace.Title]) == 0 {
+		return ErrResp(http.StatusNotFound, errors.New("no rule groups found"), "")
+	}
+	return response.YAML(http.StatusOK, result)
+}
+
+// RouteGetPrometheusRuleGroupConfig returns the rule group of the folder as a Prometheus rule group. Returns
+// http.StatusNotFound if the group does not exist, and http.StatusUnauthorized if the user does not have access to one
+// of its rules.
+func (srv RulerSrv) RouteGetPrometheusRuleGroupConfig(c *contextmodel.ReqContext, namespaceTitle string, ruleGroup string) response.Response {
+	namespace, err := srv.store.GetNamespaceByTitle(c.Req.Context(), namespaceTitle, c.SignedInUser.OrgID, c.SignedInUser, false)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+
+	ruleList, err := srv.store.ListAlertRules(c.Req.Context(), &ngmodels.ListAlertRulesQuery{
+		OrgID:         c.SignedInUser.OrgID,
+		NamespaceUIDs: []string{namespace.UID},
+		RuleGroup:     ruleGroup,
+	})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
+	}
+	if len(ruleList) == 0 {
+		return ErrResp(http.StatusNotFound, errors.New("rule group does not exist"), "")
+	}
+	hasAccess := func(evaluator accesscontrol.Evaluator) bool {
+		return accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqViewer, evaluator)
+	}
+	if !authorizeAccessToRuleGroup(ruleList, hasAccess) {
+		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to access the group because it does not have access to one or many data sources one or many rules in the group use", ErrAuthorization), "")
+	}
+
+	result, err := store.ConvertToPrometheusRules(ruleList, map[string]string{namespace.UID: namespace.Title}, srv.getDatasourceTypes(c, ruleList))
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
+	}
+	return response.YAML(http.StatusOK, result[namespace.Title][0])
+}
+
+// RoutePostNamePrometheusRulesConfig creates or updates the rule group of the folder from a Prometheus rule group in
+// YAML or JSON, like the Cortex ruler API. The rules are matched with the rules of the group by alert name, and keep
+// the fields that Prometheus rules do not have. The rules of the group that are not in the request are deleted. The
+// rules query the data source with the given UID, unless they were exported with a condition that cannot be expressed
+// in PromQL, in which case the exported condition is restored.
+func (srv RulerSrv) RoutePostNamePrometheusRulesConfig(c *contextmodel.ReqContext, datasourceUID string, namespaceTitle string) response.Response {
+	namespace, err := srv.store.GetNamespaceByTitle(c.Req.Context(), namespaceTitle, c.SignedInUser.OrgID, c.SignedInUser, true)
+	if err != nil {
+		return toNamespaceErrorResponse(err)
+	}
+
+	// YAML is a superset of JSON, so that the rule group can be sent in either format
+	var group apimodels.PrometheusRuleGroup
+	if err := yaml.NewDecoder(c.Req.Body).Decode(&group); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "failed to parse the Prometheus rule group")
+	}
+	if err := provisioning.ValidatePrometheusRuleGroups([]apimodels.PrometheusRuleGroup{group}); err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	groupKey := ngmodels.AlertRuleGroupKey{
+		OrgID:        c.SignedInUser.OrgID,
+		NamespaceUID: namespace.UID,
+		RuleGroup:    group.Name,
+	}
+	existing, err := srv.store.ListAlertRules(c.Req.Context(), &ngmodels.ListAlertRulesQuery{
+		OrgID:         groupKey.OrgID,
+		NamespaceUIDs: []string{groupKey.NamespaceUID},
+		RuleGroup:     groupKey.RuleGroup,
+	})
+	if err != nil {
+		return ErrResp(http.StatusInternalServerError, err, "failed to get group alert rules")
+	}
+	byTitle := make(map[string]*ngmodels.AlertRule, len(existing))
+	for _, rule := range existing {
+		byTitle[rule.Title] = rule
+	}
+
+	interval := time.Duration(group.Interval)
+	if interval == 0 {
+		interval = srv.cfg.DefaultRuleEvaluationInterval
+		if len(existing) > 0 {
+			interval = time.Duration(existing[0].IntervalSeconds) * time.Second
+		}
+	}
+	intervalSeconds, err := validateInterval(srv.cfg, interval)
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	rules := make([]*ngmodels.AlertRuleWithOptionals, 0, len(group.Rules))
+	for i, node := range group.Rules {
+		var rule ngmodels.AlertRule
+		if e, ok := byTitle[node.Alert]; ok {
+			// the fields that Prometheus rules do not have, like the contact points, are kept
+			rule = *e
+		} else {
+			rule = ngmodels.AlertRule{
+				Title:        node.Alert,
+				NoDataState:  ngmodels.OK,
+				ExecErrState: ngmodels.ErrorErrState,
+			}
+		}
+		if err := provisioning.SetPrometheusRuleFields(&rule, node, datasourceUID); err != nil {
+			return ErrResp(http.StatusBadRequest, err, "")
+		}
+		rule.OrgID = groupKey.OrgID
+		rule.NamespaceUID = groupKey.NamespaceUID
+		rule.RuleGroup = groupKey.RuleGroup
+		rule.RuleGroupIndex = i + 1
+		rule.IntervalSeconds = intervalSeconds
+		if err := srv.conditionValidator.Validate(eval.Context(c.Req.Context(), c.SignedInUser), rule.GetEvalCondition()); err != nil {
+			return ErrResp(http.StatusBadRequest, fmt.Errorf("%w: invalid condition of alert %q: %s", ngmodels.ErrAlertRuleFailedValidation, node.Alert, err), "")
+		}
+		rules = append(rules, &ngmodels.AlertRuleWithOptionals{AlertRule: rule})
+	}
+
+	return srv.updateAlertRulesInGroup(c, groupKey, rules)
+}
+
+// getPrometheusRules returns the rules that match the query as Prometheus rule groups, without the groups that have a
+// rule the user does not have access to.
+func (srv RulerSrv) getPrometheusRules(c *contextmodel.ReqContext, namespaces map[string]*folder.Folder, q *ngmodels.ListAlertRulesQuery) (apimodels.PrometheusRulesExport, error) {
+	ruleList, err := srv.store.ListAlertRules(c.Req.Context(), q)
+	if err != nil {
+		return nil, err
+	}
+
+	hasAccess := func(evaluator accesscontrol.Evaluator) bool {
+		return accesscontrol.HasAccess(srv.ac, c)(accesscontrol.ReqViewer, evaluator)
+	}
+	groups := make(map[ngmodels.AlertRuleGroupKey]ngmodels.RulesGroup)
+	for _, r := range ruleList {
+		groups[r.GetGroupKey()] = append(groups[r.GetGroupKey()], r)
+	}
+	authorized := make([]*ngmodels.AlertRule, 0, len(ruleList))
+	for groupKey, rules := range groups {
+		if _, ok := namespaces[groupKey.NamespaceUID]; !ok {
+			srv.log.Error("namespace not visible to the user", "user", c.SignedInUser.UserID, "namespace", groupKey.NamespaceUID)
+			continue
+		}
+		if !authorizeAccessToRuleGroup(rules, hasAccess) {
+			continue
+		}
+		authorized = append(authorized, rules...)
+	}
+
+	folderTitles := make(map[string]string, len(namespaces))
+	for uid, namespace := range namespaces {
+		folderTitles[uid] = namespace.Title
+	}
+	return store.ConvertToPrometheusRules(authorized, folderTitles, srv.getDatasourceTypes(c, authorized))
+}
+
+// getDatasourceTypes returns the types of the data sources that the rules query by UID. The data sources that do not
+// exist are left out, so that the rules that query them are exported with their condition.
+func (srv RulerSrv) getDatasourceTypes(c *contextmodel.ReqContext, rules []*ngmodels.AlertRule) map[string]string {
+	result := map[string]string{}
+	for _, rule := range rules {
+		for _, q := range rule.Data {
+			if _, ok := result[q.DatasourceUID]; ok || expr.IsDataSource(q.DatasourceUID) {
+				continue
+			}
+			ds, err := srv.datasourceCache.GetDatasourceByUID(c.Req.Context(), q.DatasourceUID, c.SignedInUser, c.SkipCache)
+			if err != nil {
+				if !errors.Is(err, datasources.ErrDataSourceNotFound) {
+					srv.log.Warn("failed to get the data source of an alert rule", "rule_uid", rule.UID, "datasource_uid", q.DatasourceUID, "error", err)
+				}
+				continue
+			}
+			result[q.DatasourceUID] = ds.Type
+		}
+	}
+	return result
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	acMock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	fakeDatasources "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/folder"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestPrometheusRulerApi(t *testing.T) {
+	const orgID = int64(1)
+	namespace := &folder.Folder{ID: 1, UID: "folder-uid", Title: "Infra"}
+
+	// highCPU is a rule that can be expressed in PromQL, and divergence is a rule whose condition compares two queries.
+	newRules := func(t *testing.T) (*models.AlertRule, *models.AlertRule) {
+		t.Helper()
+		highCPU := &models.AlertRule{
+			OrgID:           orgID,
+			UID:             "high-cpu",
+			Title:           "HighCPU",
+			NamespaceUID:    namespace.UID,
+			RuleGroup:       "infra",
+			RuleGroupIndex:  1,
+			IntervalSeconds: 60,
+			NoDataState:     models.NoData,
+			ExecErrState:    models.AlertingErrState,
+			Version:         1,
+		}
+		forDuration := model.Duration(5 * time.Minute)
+		require.NoError(t, provisioning.SetPrometheusRuleFields(highCPU, apimodels.ApiRuleNode{
+			Alert:       "HighCPU",
+			Expr:        "cpu_usage > 80",
+			For:         &forDuration,
+			Labels:      map[string]string{"severity": "critical"},
+			Annotations: map[string]string{"summary": "CPU usage is high"},
+		}, "prom-uid"))
+
+		divergence := &models.AlertRule{
+			OrgID:           orgID,
+			UID:             "divergence",
+			Title:           "Divergence",
+			Condition:       "C",
+			NamespaceUID:    namespace.UID,
+			RuleGroup:       "infra",
+			RuleGroupIndex:  2,
+			IntervalSeconds: 60,
+			NoDataState:     models.NoData,
+			ExecErrState:    models.AlertingErrState,
+			Annotations:     map[string]string{"summary": "The replicas diverge"},
+			Version:         1,
+			Data: []models.AlertQuery{
+				{RefID: "A", DatasourceUID: "prom-uid", RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(10 * time.Minute)}, Model: json.RawMessage(`{"expr":"replica_a"}`)},
+				{RefID: "B", DatasourceUID: "prom-uid", RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(10 * time.Minute)}, Model: json.RawMessage(`{"expr":"replica_b"}`)},
+				{RefID: "C", DatasourceUID: "__expr__", Model: json.RawMessage(`{"type":"math","expression":"abs($A - $B) > 10"}`)},
+			},
+		}
+		return highCPU, divergence
+	}
+
+	queryExpr := func(t *testing.T, q models.AlertQuery) string {
+		t.Helper()
+		var m struct {
+			Expr string `json:"expr"`
+		}
+		require.NoError(t, json.Unmarshal(q.Model, &m))
+		return m.Expr
+	}
+
+	setup := func(t *testing.T) (*fakes.RuleStore, *RulerApiHandler) {
+		t.Helper()
+		ruleStore := fakes.NewRuleStore(t)
+		ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], namespace)
+		highCPU, divergence := newRules(t)
+		ruleStore.PutRule(context.Background(), highCPU, divergence)
+
+		cache := &fakeDatasources.FakeCacheService{DataSources: []*datasources.DataSource{
+			{UID: "prom-uid", Type: datasources.DS_PROMETHEUS},
+			{UID: "graphite-uid", Type: datasources.DS_GRAPHITE},
+		}}
+		svc := createService(acMock.New().WithDisabled(), ruleStore)
+		svc.datasourceCache = cache
+		svc.conditionValidator = eval_mocks.NewEvaluatorFactory(nil)
+		svc.QuotaService = quotatest.New(false, nil)
+		svc.cfg = &setting.UnifiedAlertingSettings{BaseInterval: 10 * time.Second, DefaultRuleEvaluationInterval: time.Minute}
+		return ruleStore, NewForkingRuler(cache, nil, svc)
+	}
+
+	request := func(params map[string]string, body string) *contextmodel.ReqContext {
+		params[":DatasourceUID"] = "prom-uid"
+		req := createRequestContext(orgID, org.RoleEditor, params)
+		req.Req.Body = io.NopCloser(strings.NewReader(body))
+		return req
+	}
+
+	t.Run("should list the rules as Prometheus rule groups", func(t *testing.T) {
+		_, handler := setup(t)
+
+		resp := handler.RouteGetGrafanaPrometheusRulesConfig(request(map[string]string{}, ""))
+		require.Equal(t, http.StatusOK, resp.Status())
+		var result apimodels.PrometheusRulesExport
+		require.NoError(t, yaml.Unmarshal(resp.Body(), &result))
+
+		require.Len(t, result, 1)
+		require.Len(t, result["Infra"], 1)
+		group := result["Infra"][0]
+		require.Equal(t, "infra", group.Name)
+		require.Equal(t, model.Duration(time.Minute), group.Interval)
+		require.Len(t, group.Rules, 2)
+		require.Equal(t, "HighCPU", group.Rules[0].Alert)
+		require.Equal(t, "cpu_usage > 80", group.Rules[0].Expr)
+		require.Equal(t, map[string]string{"summary": "CPU usage is high"}, group.Rules[0].Annotations)
+		require.Equal(t, "Divergence", group.Rules[1].Alert)
+		require.Contains(t, group.Rules[1].Annotations, "__grafana_condition__")
+	})
+
+	t.Run("should get the rule group of a namespace", func(t *testing.T) {
+		_, handler := setup(t)
+
+		resp := handler.RouteGetGrafanaPrometheusRuleGroupConfig(request(map[string]string{":Namespace": "Infra", ":Groupname": "infra"}, ""))
+		require.Equal(t, http.StatusOK, resp.Status())
+		var group apimodels.PrometheusRuleGroup
+		require.NoError(t, yaml.Unmarshal(resp.Body(), &group))
+		require.Equal(t, "infra", group.Name)
+		require.Len(t, group.Rules, 2)
+
+		resp = handler.RouteGetGrafanaPrometheusRuleGroupConfig(request(map[string]string{":Namespace": "Infra", ":Groupname": "unknown"}, ""))
+		require.Equal(t, http.StatusNotFound, resp.Status())
+	})
+
+	t.Run("should upsert the rule group and keep the rules that are written back unchanged", func(t *testing.T) {
+		ruleStore, handler := setup(t)
+		resp := handler.RouteGetGrafanaPrometheusRuleGroupConfig(request(map[string]string{":Namespace": "Infra", ":Groupname": "infra"}, ""))
+		require.Equal(t, http.StatusOK, resp.Status())
+		var group apimodels.PrometheusRuleGroup
+		require.NoError(t, yaml.Unmarshal(resp.Body(), &group))
+
+		group.Rules[0].Expr = "cpu_usage > 90"
+		group.Rules = append(group.Rules, apimodels.ApiRuleNode{
+			Alert:  "DiskFull",
+			Expr:   "disk_used_percent > 95",
+			Labels: map[string]string{"severity": "warning"},
+		})
+		body, err := yaml.Marshal(group)
+		require.NoError(t, err)
+
+		resp = handler.RoutePostNameGrafanaPrometheusRulesConfig(request(map[string]string{":Namespace": "Infra"}, string(body)))
+		require.Equal(t, http.StatusAccepted, resp.Status(), string(resp.Body()))
+
+		inserts := ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			c, ok := cmd.([]models.AlertRule)
+			return c, ok
+		})
+		require.Len(t, inserts, 1)
+		created := inserts[0].([]models.AlertRule)
+		require.Len(t, created, 1)
+		require.Equal(t, "DiskFull", created[0].Title)
+		require.Equal(t, namespace.UID, created[0].NamespaceUID)
+		require.Equal(t, "infra", created[0].RuleGroup)
+		require.Equal(t, 3, created[0].RuleGroupIndex)
+		require.EqualValues(t, 60, created[0].IntervalSeconds)
+		require.Equal(t, "prom-uid", created[0].Data[0].DatasourceUID)
+		require.Equal(t, "disk_used_percent > 95", queryExpr(t, created[0].Data[0]))
+
+		updates := ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			c, ok := cmd.([]models.UpdateRule)
+			return c, ok
+		})
+		require.Len(t, updates, 1)
+		updated := updates[0].([]models.UpdateRule)
+		require.Len(t, updated, 2)
+		byUID := make(map[string]models.UpdateRule, len(updated))
+		for _, u := range updated {
+			byUID[u.New.UID] = u
+		}
+		highCPU := byUID["high-cpu"]
+		require.Equal(t, "cpu_usage > 90", queryExpr(t, highCPU.New.Data[0]))
+		require.Equal(t, models.NoData, highCPU.New.NoDataState, "the fields that Prometheus rules do not have must be kept")
+		divergence := byUID["divergence"]
+		require.Empty(t, divergence.Existing.Diff(&divergence.New), "the rule exported with its condition must be written back unchanged")
+	})
+
+	t.Run("should delete the rules of the group that are not in the request", func(t *testing.T) {
+		ruleStore, handler := setup(t)
+		body := "name: infra\nrules:\n  - alert: HighCPU\n    expr: cpu_usage > 80\n    for: 5m\n    labels:\n      severity: critical\n    annotations:\n      summary: CPU usage is high\n"
+
+		resp := handler.RoutePostNameGrafanaPrometheusRulesConfig(request(map[string]string{":Namespace": "Infra"}, body))
+		require.Equal(t, http.StatusAccepted, resp.Status(), string(resp.Body()))
+
+		rules, err := ruleStore.ListAlertRules(context.Background(), &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+		require.Equal(t, "high-cpu", rules[0].UID)
+	})
+
+	t.Run("should reject an invalid rule group", func(t *testing.T) {
+		ruleStore, handler := setup(t)
+		body := "name: infra\nrules:\n  - record: job:cpu_usage:sum\n    expr: sum(cpu_usage)\n"
+
+		resp := handler.RoutePostNameGrafanaPrometheusRulesConfig(request(map[string]string{":Namespace": "Infra"}, body))
+		require.Equal(t, http.StatusBadRequest, resp.Status())
+		rules, err := ruleStore.ListAlertRules(context.Background(), &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+	})
+
+	t.Run("should delete the rules of the namespace", func(t *testing.T) {
+		ruleStore, handler := setup(t)
+
+		resp := handler.RouteDeleteNamespaceGrafanaPrometheusRulesConfig(request(map[string]string{":Namespace": "Infra"}, ""))
+		require.Equal(t, http.StatusAccepted, resp.Status(), string(resp.Body()))
+
+		rules, err := ruleStore.ListAlertRules(context.Background(), &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		require.Empty(t, rules)
+		resp = handler.RouteGetNamespaceGrafanaPrometheusRulesConfig(request(map[string]string{":Namespace": "Infra"}, ""))
+		require.Equal(t, http.StatusNotFound, resp.Status())
+	})
+
+	t.Run("should fail if the data source is not a Prometheus or Loki data source", func(t *testing.T) {
+		_, handler := setup(t)
+		req := createRequestContext(orgID, org.RoleEditor, map[string]string{":DatasourceUID": "graphite-uid"})
+		resp := handler.RouteGetGrafanaPrometheusRulesConfig(req)
+		require.Equal(t, http.StatusBadRequest, resp.Status())
+
+		req = createRequestContext(orgID, org.RoleEditor, map[string]string{":DatasourceUID": "unknown"})
+		resp = handler.RouteGetGrafanaPrometheusRulesConfig(req)
+		require.Equal(t, http.StatusNotFound, resp.Status())
+	})
+}
@@ -3,29 +3,43 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	acMock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/guardian"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 	"github.com/grafana/grafana/pkg/web"
 )
@@ -369,6 +383,43 @@ func TestRouteGetNamespaceRulesConfig(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("should return the latest evaluation of rules", func(t *testing.T) {
+		orgID := rand.Int63()
+		folder := randFolder()
+		ruleStore := fakes.NewRuleStore(t)
+		ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+		groupKey := models.GenerateGroupKey(orgID)
+		groupKey.NamespaceUID = folder.UID
+
+		rules := models.GenerateAlertRules(2, models.AlertRuleGen(withGroupKey(groupKey), models.WithUniqueGroupIndex()))
+		models.RulesGroup(rules).SortByGroupIndex()
+		ruleStore.PutRule(context.Background(), rules...)
+		evaluatedAt := time.Date(2023, 3, 24, 7, 0, 0, 0, time.UTC)
+		ruleStore.Evaluations[orgID] = []*models.AlertRuleEvaluation{
+			{RuleOrgID: orgID, RuleUID: rules[0].UID, EvaluatedAt: evaluatedAt, Duration: 1500 * time.Millisecond, State: "Error", Error: "failed to query data"},
+		}
+
+		response := createService(acMock.New().WithDisabled(), ruleStore).RouteGetNamespaceRulesConfig(createRequestContext(orgID, org.RoleViewer, nil), folder.Title)
+
+		require.Equal(t, http.StatusAccepted, response.Status())
+		result := &apimodels.NamespaceConfigResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		groups := (*result)[folder.Title]
+		require.Len(t, groups, 1)
+		require.Len(t, groups[0].Rules, 2)
+
+		evaluated := groups[0].Rules[0].GrafanaManagedAlert
+		require.NotNil(t, evaluated.LastEvaluation)
+		require.True(t, evaluatedAt.Equal(*evaluated.LastEvaluation))
+		require.Equal(t, 1.5, evaluated.LastEvaluationDuration)
+		require.Equal(t, "Error", evaluated.LastEvaluationState)
+		require.Equal(t, "failed to query data", evaluated.LastEvaluationError)
+
+		notEvaluated := groups[0].Rules[1].GrafanaManagedAlert
+		require.Nil(t, notEvaluated.LastEvaluation)
+		require.Empty(t, notEvaluated.LastEvaluationState)
+	})
 }
 
 func TestRouteGetRulesConfig(t *testing.T) {
@@ -451,6 +502,43 @@ func TestRouteGetRulesConfig(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("should return the summary of instance states of rules", func(t *testing.T) {
+		orgID := rand.Int63()
+		folder := randFolder()
+		ruleStore := fakes.NewRuleStore(t)
+		ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+		groupKey := models.GenerateGroupKey(orgID)
+		groupKey.NamespaceUID = folder.UID
+
+		rules := models.GenerateAlertRules(2, models.AlertRuleGen(withGroupKey(groupKey), models.WithUniqueGroupIndex()))
+		models.RulesGroup(rules).SortByGroupIndex()
+		ruleStore.PutRule(context.Background(), rules...)
+		for i, state := range []models.InstanceStateType{models.InstanceStateNormal, models.InstanceStatePending, models.InstanceStateFiring, models.InstanceStateFiring, models.InstanceStateNoData} {
+			ruleStore.Instances[orgID] = append(ruleStore.Instances[orgID], &models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: orgID, RuleUID: rules[0].UID, LabelsHash: fmt.Sprint(i)},
+				CurrentState:     state,
+			})
+		}
+
+		response := createService(acMock.New().WithDisabled(), ruleStore).RouteGetRulesConfig(createRequestContext(orgID, org.RoleViewer, nil))
+
+		require.Equal(t, http.StatusOK, response.Status())
+		result := &apimodels.NamespaceConfigResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		groups := (*result)[folder.Title]
+		require.Len(t, groups, 1)
+		require.Len(t, groups[0].Rules, 2)
+
+		require.Equal(t, &apimodels.InstanceStateSummary{
+			WorstState: "Alerting",
+			Counts:     map[string]int64{"Normal": 1, "Pending": 1, "Alerting": 2, "NoData": 1},
+		}, groups[0].Rules[0].GrafanaManagedAlert.StateSummary)
+		require.Equal(t, &apimodels.InstanceStateSummary{
+			WorstState: "Normal",
+			Counts:     map[string]int64{},
+		}, groups[0].Rules[1].GrafanaManagedAlert.StateSummary)
+	})
 }
 
 func TestRouteGetRulesGroupConfig(t *testing.T) {
@@ -528,6 +616,1284 @@ func TestRouteGetRulesGroupConfig(t *testing.T) {
 	})
 }
 
+func TestRouteEvaluateAlertRule(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder))()
+	ruleStore.PutRule(context.Background(), rule)
+
+	createServiceWithScheduler := func(ac *acMock.Mock, scheduler *fakeRuleScheduler) *RulerSrv {
+		svc := createService(ac, ruleStore)
+		svc.scheduler = scheduler
+		return svc
+	}
+	rulePermissions := append(createPermissionsForRules([]*models.AlertRule{rule}), accesscontrol.Permission{
+		Action: accesscontrol.ActionAlertingRuleUpdate, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+	})
+
+	t.Run("should request evaluation from the scheduler and return 202", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteEvaluateAlertRule(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusAccepted, response.Status())
+		require.Equal(t, []models.AlertRuleKey{rule.GetKey()}, scheduler.Requested)
+	})
+
+	t.Run("should return 404 if rule does not exist", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteEvaluateAlertRule(createRequestContext(orgID, "", nil), util.GenerateShortUID())
+		require.Equal(t, http.StatusNotFound, response.Status())
+		require.Empty(t, scheduler.Requested)
+	})
+
+	t.Run("should return 404 if rule belongs to another organization", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteEvaluateAlertRule(createRequestContext(orgID+1, "", nil), rule.UID)
+		require.Equal(t, http.StatusNotFound, response.Status())
+		require.Empty(t, scheduler.Requested)
+	})
+
+	t.Run("should return 404 if rule is not scheduled", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{err: models.ErrAlertRuleNotFound}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteEvaluateAlertRule(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 409 if rule is paused", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{err: models.ErrAlertRuleIsPaused}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteEvaluateAlertRule(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusConflict, response.Status())
+	})
+
+	t.Run("should return 409 if rule is evaluated by another instance", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{err: fmt.Errorf("%w: the rule is evaluated by instance other", models.ErrAlertRuleNotOwned)}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteEvaluateAlertRule(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusConflict, response.Status())
+		require.Contains(t, string(response.Body()), "instance other")
+	})
+
+	t.Run("should return 401 if user cannot update rules in the folder", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{}
+		ac := acMock.New().WithPermissions(createPermissionsForRules([]*models.AlertRule{rule}))
+		response := createServiceWithScheduler(ac, scheduler).RouteEvaluateAlertRule(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+		require.Empty(t, scheduler.Requested)
+	})
+
+	t.Run("should return 401 if user does not have access to data sources of the rule", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{}
+		ac := acMock.New().WithPermissions(rulePermissions[len(rulePermissions)-1:])
+		response := createServiceWithScheduler(ac, scheduler).RouteEvaluateAlertRule(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+		require.Empty(t, scheduler.Requested)
+	})
+}
+
+func TestRouteGetAlertRuleEvaluation(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder))()
+	ruleStore.PutRule(context.Background(), rule)
+
+	createServiceWithEvaluator := func(evaluator *eval_mocks.ConditionEvaluatorMock) *RulerSrv {
+		ac := acMock.New().WithPermissions(append(createPermissionsForRules([]*models.AlertRule{rule}), accesscontrol.Permission{
+			Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+		}))
+		svc := createService(ac, ruleStore)
+		svc.cfg = &setting.UnifiedAlertingSettings{EvaluationTimeout: time.Minute}
+		svc.evaluator = eval_mocks.NewEvaluatorFactory(evaluator)
+		return svc
+	}
+	value := 42.0
+	nan := math.NaN()
+	results := eval.Results{
+		{
+			Instance: data.Labels{"instance": "a"},
+			State:    eval.Alerting,
+			Values: map[string]eval.NumberValueCapture{
+				"A": {Var: "A", Value: &value},
+				"B": {Var: "B", Value: &nan},
+			},
+		},
+		{
+			Instance: data.Labels{"instance": "b"},
+			State:    eval.Normal,
+		},
+	}
+
+	t.Run("should evaluate the rule at the current time by default", func(t *testing.T) {
+		now := time.Now().Truncate(time.Second)
+		clk := clock.NewMock()
+		clk.Set(now)
+
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		evaluator.EXPECT().Evaluate(mock.Anything, now).Return(results, nil)
+		svc := createServiceWithEvaluator(evaluator)
+		svc.clock = clk
+		response := svc.RouteGetAlertRuleEvaluation(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		evaluator.AssertExpectations(t)
+
+		var result apimodels.RuleEvaluationResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.True(t, now.Equal(result.EvaluatedAt))
+		require.Equal(t, eval.Alerting.String(), result.State)
+		require.Equal(t, []apimodels.RuleEvaluationResult{
+			{
+				Labels: map[string]string{"instance": "a"},
+				State:  eval.Alerting.String(),
+				Values: map[string]*float64{"A": &value, "B": nil},
+			},
+			{
+				Labels: map[string]string{"instance": "b"},
+				State:  eval.Normal.String(),
+			},
+		}, result.Results)
+		require.Nil(t, result.Frames)
+		evaluator.AssertNotCalled(t, "EvaluateRaw", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return data frames by RefID if includeFrames is true", func(t *testing.T) {
+		now := time.Now().Truncate(time.Second)
+		clk := clock.NewMock()
+		clk.Set(now)
+
+		one := 1.0
+		series := make([]float64, maxEvaluationFramePoints+1)
+		times := make([]time.Time, maxEvaluationFramePoints+1)
+		for i := range series {
+			series[i] = float64(i)
+			times[i] = now.Add(time.Duration(i-len(series)) * time.Second)
+		}
+		raw := &backend.QueryDataResponse{Responses: backend.Responses{
+			"query":        {Frames: data.Frames{data.NewFrame("series", data.NewField("time", nil, times), data.NewField("value", data.Labels{"instance": "a"}, series))}},
+			rule.Condition: {Frames: data.Frames{data.NewFrame("", data.NewField("", data.Labels{"instance": "a"}, []*float64{&one}))}},
+		}}
+
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		evaluator.EXPECT().EvaluateRaw(mock.Anything, now).Return(raw, nil)
+		req := createRequestContext(orgID, "", nil)
+		req.Req.URL.RawQuery = "includeFrames=true"
+		svc := createServiceWithEvaluator(evaluator)
+		svc.clock = clk
+		response := svc.RouteGetAlertRuleEvaluation(req, rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		evaluator.AssertExpectations(t)
+		evaluator.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything)
+
+		var result apimodels.RuleEvaluationResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, eval.Alerting.String(), result.State)
+		require.Len(t, result.Results, 1)
+		require.Len(t, result.Frames, 2)
+
+		require.Len(t, result.Frames[rule.Condition], 1)
+		require.Equal(t, 1, result.Frames[rule.Condition][0].Rows())
+
+		require.Len(t, result.Frames["query"], 1)
+		downsampled := result.Frames["query"][0]
+		require.Equal(t, "series", downsampled.Name)
+		require.Equal(t, (maxEvaluationFramePoints+2)/2, downsampled.Rows())
+		require.Equal(t, series[2], downsampled.Fields[1].At(1))
+		require.Len(t, result.Warnings, 1)
+		require.Contains(t, result.Warnings[0], "query")
+	})
+
+	t.Run("should evaluate the rule at the time in the now parameter", func(t *testing.T) {
+		historical := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		evaluator.EXPECT().Evaluate(mock.Anything, historical).Return(results, nil)
+		req := createRequestContext(orgID, "", nil)
+		req.Req.URL.RawQuery = "now=" + url.QueryEscape(historical.Format(time.RFC3339))
+		response := createServiceWithEvaluator(evaluator).RouteGetAlertRuleEvaluation(req, rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		evaluator.AssertExpectations(t)
+
+		var result apimodels.RuleEvaluationResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.True(t, historical.Equal(result.EvaluatedAt))
+	})
+
+	t.Run("should return 400 if the now parameter is not a valid time", func(t *testing.T) {
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		req := createRequestContext(orgID, "", nil)
+		req.Req.URL.RawQuery = "now=yesterday"
+		response := createServiceWithEvaluator(evaluator).RouteGetAlertRuleEvaluation(req, rule.UID)
+		require.Equal(t, http.StatusBadRequest, response.Status())
+		evaluator.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return 404 if rule does not exist", func(t *testing.T) {
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		response := createServiceWithEvaluator(evaluator).RouteGetAlertRuleEvaluation(createRequestContext(orgID, "", nil), util.GenerateShortUID())
+		require.Equal(t, http.StatusNotFound, response.Status())
+		evaluator.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return 404 if rule belongs to another organization", func(t *testing.T) {
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		response := createServiceWithEvaluator(evaluator).RouteGetAlertRuleEvaluation(createRequestContext(orgID+1, "", nil), rule.UID)
+		require.Equal(t, http.StatusNotFound, response.Status())
+		evaluator.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return 403 naming the data source if user cannot query it", func(t *testing.T) {
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		denied := rule.Data[len(rule.Data)-1].DatasourceUID
+		permissions := []accesscontrol.Permission{{
+			Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+		}}
+		for _, query := range rule.Data[:len(rule.Data)-1] {
+			permissions = append(permissions, accesscontrol.Permission{
+				Action: datasources.ActionQuery, Scope: datasources.ScopeProvider.GetResourceScopeUID(query.DatasourceUID),
+			})
+		}
+		svc := createService(acMock.New().WithPermissions(permissions), ruleStore)
+		svc.evaluator = eval_mocks.NewEvaluatorFactory(evaluator)
+		response := svc.RouteGetAlertRuleEvaluation(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusForbidden, response.Status())
+		require.Contains(t, string(response.Body()), denied)
+		evaluator.AssertNotCalled(t, "Evaluate", mock.Anything, mock.Anything)
+	})
+
+	t.Run("should return 504 if the evaluation times out", func(t *testing.T) {
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, context.DeadlineExceeded)
+		response := createServiceWithEvaluator(evaluator).RouteGetAlertRuleEvaluation(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusGatewayTimeout, response.Status())
+	})
+
+	t.Run("should bound the evaluation by the evaluation timeout", func(t *testing.T) {
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		evaluator.EXPECT().Evaluate(mock.MatchedBy(func(ctx context.Context) bool {
+			deadline, ok := ctx.Deadline()
+			return ok && time.Until(deadline) <= 5*time.Second
+		}), mock.Anything).Return(results, nil)
+		svc := createServiceWithEvaluator(evaluator)
+		svc.cfg.EvaluationTimeout = 5 * time.Second
+		response := svc.RouteGetAlertRuleEvaluation(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		evaluator.AssertExpectations(t)
+	})
+}
+
+func TestRoutePauseAlertRule(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder), func(rule *models.AlertRule) {
+		rule.IsPaused = false
+	})()
+	ruleStore.PutRule(context.Background(), rule)
+
+	rulePermissions := append(createPermissionsForRules([]*models.AlertRule{rule}), accesscontrol.Permission{
+		Action: accesscontrol.ActionAlertingRuleUpdate, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+	})
+	setPaused := func(t *testing.T, orgID int64, ruleUID string, paused bool) response.Response {
+		t.Helper()
+		svc := createService(acMock.New().WithPermissions(rulePermissions), ruleStore)
+		if paused {
+			return svc.RoutePauseAlertRule(createRequestContext(orgID, "", nil), ruleUID)
+		}
+		return svc.RouteUnpauseAlertRule(createRequestContext(orgID, "", nil), ruleUID)
+	}
+	requireIsPaused := func(t *testing.T, response response.Response, expected bool) {
+		t.Helper()
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.GettableExtendedRuleNode
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, rule.UID, result.GrafanaManagedAlert.UID)
+		require.Equal(t, expected, result.GrafanaManagedAlert.IsPaused)
+	}
+	getIsPaused := func(t *testing.T) bool {
+		t.Helper()
+		svc := createService(acMock.New().WithPermissions(rulePermissions), ruleStore)
+		response := svc.RouteGetRulesGroupConfig(createRequestContext(orgID, "", nil), folder.Title, rule.RuleGroup)
+		require.Equal(t, http.StatusAccepted, response.Status())
+		var result apimodels.RuleGroupConfigResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result.Rules, 1)
+		return result.Rules[0].GrafanaManagedAlert.IsPaused
+	}
+	countUpdates := func() int {
+		return len(ruleStore.GetRecordedCommands(func(cmd interface{}) (interface{}, bool) {
+			c, ok := cmd.([]models.UpdateRule)
+			return c, ok
+		}))
+	}
+
+	t.Run("should pause the rule", func(t *testing.T) {
+		requireIsPaused(t, setPaused(t, orgID, rule.UID, true), true)
+		require.True(t, getIsPaused(t))
+		require.Equal(t, 1, countUpdates())
+	})
+
+	t.Run("should return 200 and not update the rule if it is already paused", func(t *testing.T) {
+		requireIsPaused(t, setPaused(t, orgID, rule.UID, true), true)
+		require.True(t, getIsPaused(t))
+		require.Equal(t, 1, countUpdates())
+	})
+
+	t.Run("should unpause the rule", func(t *testing.T) {
+		requireIsPaused(t, setPaused(t, orgID, rule.UID, false), false)
+		require.False(t, getIsPaused(t))
+		require.Equal(t, 2, countUpdates())
+	})
+
+	t.Run("should return 404 if rule does not exist", func(t *testing.T) {
+		response := setPaused(t, orgID, util.GenerateShortUID(), true)
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 404 if rule belongs to another organization", func(t *testing.T) {
+		response := setPaused(t, orgID+1, rule.UID, true)
+		require.Equal(t, http.StatusNotFound, response.Status())
+		require.False(t, getIsPaused(t))
+	})
+
+	t.Run("should return 401 if user cannot update rules in the folder", func(t *testing.T) {
+		svc := createService(acMock.New().WithPermissions(createPermissionsForRules([]*models.AlertRule{rule})), ruleStore)
+		response := svc.RoutePauseAlertRule(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+		require.False(t, getIsPaused(t))
+	})
+
+	t.Run("should return 400 if rule is provisioned", func(t *testing.T) {
+		provisioningStore := provisioning.NewFakeProvisioningStore()
+		require.NoError(t, provisioningStore.SetProvenance(context.Background(), rule, orgID, models.ProvenanceAPI))
+		svc := createServiceWithProvenanceStore(acMock.New().WithPermissions(rulePermissions), ruleStore, provisioningStore)
+		response := svc.RoutePauseAlertRule(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusBadRequest, response.Status())
+		require.False(t, getIsPaused(t))
+	})
+}
+
+// fakeTestNotificationSender records the test notifications, and fails the deliveries to the contact points in failures.
+type fakeTestNotificationSender struct {
+	failures      map[string]error
+	notifications map[string][]models.AlertingNotification
+}
+
+func (f *fakeTestNotificationSender) SendTestNotification(_ context.Context, _ int64, contactPointUID string, n models.AlertingNotification) error {
+	if f.notifications == nil {
+		f.notifications = map[string][]models.AlertingNotification{}
+	}
+	f.notifications[contactPointUID] = append(f.notifications[contactPointUID], n)
+	return f.failures[contactPointUID]
+}
+
+func TestRouteTestAlertRuleNotification(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder), func(rule *models.AlertRule) {
+		rule.Title = "High CPU"
+		rule.Labels = map[string]string{"team": "infra"}
+		rule.Annotations = map[string]string{"summary": "CPU of {{ $labels.instance }} is high"}
+		rule.NotificationTitle = ""
+		rule.NotificationMessage = ""
+		rule.ContactPointUIDs = []string{"healthy", "failing"}
+	})()
+	ruleStore.PutRule(context.Background(), rule)
+	ruleWithoutContactPoints := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder), func(rule *models.AlertRule) {
+		rule.ContactPointUIDs = nil
+	})()
+	ruleStore.PutRule(context.Background(), ruleWithoutContactPoints)
+
+	rulePermissions := append(createPermissionsForRules([]*models.AlertRule{rule, ruleWithoutContactPoints}), accesscontrol.Permission{
+		Action: accesscontrol.ActionAlertingRuleUpdate, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+	})
+	sendTest := func(t *testing.T, orgID int64, ruleUID string, body apimodels.PostableTestRuleNotification) (response.Response, *fakeTestNotificationSender) {
+		t.Helper()
+		sender := &fakeTestNotificationSender{failures: map[string]error{"failing": errors.New("webhook response status 401 Unauthorized")}}
+		svc := createService(acMock.New().WithPermissions(rulePermissions), ruleStore)
+		svc.notifier = sender
+		return svc.RouteTestAlertRuleNotification(createRequestContext(orgID, "", nil), ruleUID, body), sender
+	}
+
+	t.Run("should send the test notification to every contact point and return the result of each", func(t *testing.T) {
+		response, sender := sendTest(t, orgID, rule.UID, apimodels.PostableTestRuleNotification{})
+		require.Equal(t, http.StatusOK, response.Status())
+
+		var result apimodels.TestRuleNotificationResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, "[TEST] CPU of test-instance is high", result.Title)
+		require.Equal(t, []apimodels.TestRuleNotificationResult{
+			{ContactPointUID: "healthy", Status: "success"},
+			{ContactPointUID: "failing", Status: "failure", Error: "webhook response status 401 Unauthorized"},
+		}, result.Results)
+
+		require.Len(t, sender.notifications["healthy"], 1)
+		require.Len(t, sender.notifications["failing"], 1)
+		n := sender.notifications["healthy"][0]
+		require.Equal(t, rule.UID, n.RuleUID)
+		require.Equal(t, map[string]string{"instance": "test-instance", "team": "infra", state.TestNotificationLabel: "true"}, n.Labels)
+		require.False(t, n.Resolved())
+	})
+
+	t.Run("should render the notification with the labels and values of the body", func(t *testing.T) {
+		response, sender := sendTest(t, orgID, rule.UID, apimodels.PostableTestRuleNotification{
+			Labels: map[string]string{"instance": "server-1"},
+			Values: map[string]float64{"A": 95},
+		})
+		require.Equal(t, http.StatusOK, response.Status())
+		n := sender.notifications["healthy"][0]
+		require.Equal(t, "[TEST] CPU of server-1 is high", n.Title)
+		require.Equal(t, "server-1", n.Labels["instance"])
+		require.Equal(t, "[ var='A' labels={instance=server-1} value=95 ]", n.Value)
+	})
+
+	t.Run("should return 400 if the rule is not associated with any contact point", func(t *testing.T) {
+		response, sender := sendTest(t, orgID, ruleWithoutContactPoints.UID, apimodels.PostableTestRuleNotification{})
+		require.Equal(t, http.StatusBadRequest, response.Status())
+		require.Empty(t, sender.notifications)
+	})
+
+	t.Run("should return 404 if rule does not exist", func(t *testing.T) {
+		response, _ := sendTest(t, orgID, util.GenerateShortUID(), apimodels.PostableTestRuleNotification{})
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 404 if rule belongs to another organization", func(t *testing.T) {
+		response, sender := sendTest(t, orgID+1, rule.UID, apimodels.PostableTestRuleNotification{})
+		require.Equal(t, http.StatusNotFound, response.Status())
+		require.Empty(t, sender.notifications)
+	})
+
+	t.Run("should return 401 if user cannot update rules in the folder", func(t *testing.T) {
+		sender := &fakeTestNotificationSender{}
+		svc := createService(acMock.New().WithPermissions(createPermissionsForRules([]*models.AlertRule{rule})), ruleStore)
+		svc.notifier = sender
+		response := svc.RouteTestAlertRuleNotification(createRequestContext(orgID, "", nil), rule.UID, apimodels.PostableTestRuleNotification{})
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+		require.Empty(t, sender.notifications)
+	})
+}
+
+func TestRouteBulkAlertRules(t *testing.T) {
+	orgID := rand.Int63()
+	namespace := randFolder()
+	otherNamespace := randFolder()
+	newRules := func(t *testing.T, f *folder.Folder, count int) (*fakes.RuleStore, []*models.AlertRule) {
+		t.Helper()
+		ruleStore := fakes.NewRuleStore(t)
+		ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], namespace, otherNamespace)
+		rules := models.GenerateAlertRules(count, models.AlertRuleGen(withOrgID(orgID), withNamespace(f), func(rule *models.AlertRule) {
+			rule.IsPaused = false
+		}))
+		ruleStore.PutRule(context.Background(), rules...)
+		return ruleStore, rules
+	}
+	permissions := func(rules []*models.AlertRule, action string, folders ...*folder.Folder) []accesscontrol.Permission {
+		result := createPermissionsForRules(rules)
+		for _, f := range folders {
+			result = append(result, accesscontrol.Permission{Action: action, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(f.UID)})
+		}
+		return result
+	}
+	uids := func(rules []*models.AlertRule) []string {
+		result := make([]string, 0, len(rules))
+		for _, rule := range rules {
+			result = append(result, rule.UID)
+		}
+		return result
+	}
+	countWrites := func(ruleStore *fakes.RuleStore) int {
+		return len(ruleStore.GetRecordedCommands(func(cmd interface{}) (interface{}, bool) {
+			switch c := cmd.(type) {
+			case []models.UpdateRule:
+				return c, true
+			case fakes.GenericRecordedQuery:
+				return c, c.Name == "DeleteAlertRulesByUID"
+			}
+			return nil, false
+		}))
+	}
+	requireError := func(t *testing.T, response response.Response, status int, uid string) {
+		t.Helper()
+		require.Equal(t, status, response.Status())
+		var result apimodels.BulkRuleActionError
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.NotEmpty(t, result.Message)
+		require.Equal(t, uid, result.UID)
+	}
+
+	t.Run("should delete the rules and report unknown ones as not found", func(t *testing.T) {
+		ruleStore, rules := newRules(t, namespace, 3)
+		svc := createService(acMock.New().WithPermissions(permissions(rules, accesscontrol.ActionAlertingRuleDelete, namespace)), ruleStore)
+		unknown := util.GenerateShortUID()
+		body := apimodels.PostableBulkRuleAction{Action: "delete", UIDs: append(uids(rules[:2]), unknown, rules[0].UID)}
+
+		response := svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), body)
+
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.BulkRuleActionResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, int64(2), result.RowsAffected)
+		require.Equal(t, []apimodels.BulkRuleActionItem{
+			{UID: rules[0].UID, Status: "success"},
+			{UID: rules[1].UID, Status: "success"},
+			{UID: unknown, Status: "notFound"},
+		}, result.Items)
+		require.Len(t, ruleStore.Rules[orgID], 1)
+		require.Equal(t, rules[2].UID, ruleStore.Rules[orgID][0].UID)
+	})
+
+	t.Run("should pause and unpause the rules", func(t *testing.T) {
+		ruleStore, rules := newRules(t, namespace, 2)
+		svc := createService(acMock.New().WithPermissions(permissions(rules, accesscontrol.ActionAlertingRuleUpdate, namespace)), ruleStore)
+
+		response := svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), apimodels.PostableBulkRuleAction{Action: "pause", UIDs: uids(rules)})
+		require.Equal(t, http.StatusOK, response.Status())
+		var result apimodels.BulkRuleActionResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, int64(2), result.RowsAffected)
+		for _, rule := range ruleStore.Rules[orgID] {
+			require.True(t, rule.IsPaused)
+		}
+
+		// rules that are already paused are not updated again
+		response = svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), apimodels.PostableBulkRuleAction{Action: "pause", UIDs: uids(rules[:1])})
+		require.Equal(t, http.StatusOK, response.Status())
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, int64(0), result.RowsAffected)
+		require.Equal(t, []apimodels.BulkRuleActionItem{{UID: rules[0].UID, Status: "success"}}, result.Items)
+
+		response = svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), apimodels.PostableBulkRuleAction{Action: "unpause", UIDs: uids(rules)})
+		require.Equal(t, http.StatusOK, response.Status())
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, int64(2), result.RowsAffected)
+		for _, rule := range ruleStore.Rules[orgID] {
+			require.False(t, rule.IsPaused)
+		}
+	})
+
+	t.Run("should not change any rule if user cannot change one of them", func(t *testing.T) {
+		ruleStore, rules := newRules(t, namespace, 2)
+		denied := models.AlertRuleGen(withOrgID(orgID), withNamespace(otherNamespace))()
+		ruleStore.PutRule(context.Background(), denied)
+		svc := createService(acMock.New().WithPermissions(permissions(append(rules, denied), accesscontrol.ActionAlertingRuleDelete, namespace)), ruleStore)
+
+		response := svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), apimodels.PostableBulkRuleAction{Action: "delete", UIDs: append(uids(rules), denied.UID)})
+
+		requireError(t, response, http.StatusUnauthorized, denied.UID)
+		require.Len(t, ruleStore.Rules[orgID], 3)
+		require.Zero(t, countWrites(ruleStore))
+	})
+
+	t.Run("should not change any rule if one of them is provisioned", func(t *testing.T) {
+		ruleStore, rules := newRules(t, namespace, 2)
+		provisioningStore := provisioning.NewFakeProvisioningStore()
+		require.NoError(t, provisioningStore.SetProvenance(context.Background(), rules[1], orgID, models.ProvenanceAPI))
+		svc := createServiceWithProvenanceStore(acMock.New().WithPermissions(permissions(rules, accesscontrol.ActionAlertingRuleUpdate, namespace)), ruleStore, provisioningStore)
+
+		response := svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), apimodels.PostableBulkRuleAction{Action: "pause", UIDs: uids(rules)})
+
+		requireError(t, response, http.StatusBadRequest, rules[1].UID)
+		require.Zero(t, countWrites(ruleStore))
+	})
+
+	t.Run("should return 400 if action is unknown", func(t *testing.T) {
+		ruleStore, rules := newRules(t, namespace, 1)
+		svc := createService(acMock.New().WithPermissions(permissions(rules, accesscontrol.ActionAlertingRuleUpdate, namespace)), ruleStore)
+		response := svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), apimodels.PostableBulkRuleAction{Action: "archive", UIDs: uids(rules)})
+		requireError(t, response, http.StatusBadRequest, "")
+	})
+
+	t.Run("should return 400 if there are no rules or too many rules", func(t *testing.T) {
+		ruleStore, rules := newRules(t, namespace, 1)
+		svc := createService(acMock.New().WithPermissions(permissions(rules, accesscontrol.ActionAlertingRuleDelete, namespace)), ruleStore)
+		response := svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), apimodels.PostableBulkRuleAction{Action: "delete"})
+		requireError(t, response, http.StatusBadRequest, "")
+
+		tooMany := make([]string, maxBulkAlertRules+1)
+		for i := range tooMany {
+			tooMany[i] = util.GenerateShortUID()
+		}
+		response = svc.RouteBulkAlertRules(createRequestContext(orgID, "", nil), apimodels.PostableBulkRuleAction{Action: "delete", UIDs: tooMany})
+		requireError(t, response, http.StatusBadRequest, "")
+		require.Zero(t, countWrites(ruleStore))
+	})
+}
+
+func TestRouteResetAlertRuleInstances(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder))()
+	ruleStore.PutRule(context.Background(), rule)
+
+	createServiceWithScheduler := func(ac *acMock.Mock, scheduler *fakeRuleScheduler) *RulerSrv {
+		svc := createService(ac, ruleStore)
+		svc.scheduler = scheduler
+		return svc
+	}
+	rulePermissions := []accesscontrol.Permission{{
+		Action: accesscontrol.ActionAlertingRuleUpdate, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+	}}
+
+	t.Run("should reset the instances via the scheduler as the signed in user", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{resetN: 2}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		req := createRequestContext(orgID, "", nil)
+		req.SignedInUser.Login = "editor"
+		req.Req.URL.RawQuery = "labelsHash=abc"
+		response := createServiceWithScheduler(ac, scheduler).RouteResetAlertRuleInstances(req, rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		require.Equal(t, []models.ResetAlertInstancesCommand{{
+			OrgID:      orgID,
+			RuleUID:    rule.UID,
+			LabelsHash: "abc",
+			ResetBy:    "editor",
+		}}, scheduler.Reset)
+
+		var result apimodels.ResetRuleInstancesResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, int64(2), result.Reset)
+	})
+
+	t.Run("should return 404 if rule does not exist", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteResetAlertRuleInstances(createRequestContext(orgID, "", nil), util.GenerateShortUID())
+		require.Equal(t, http.StatusNotFound, response.Status())
+		require.Empty(t, scheduler.Reset)
+	})
+
+	t.Run("should return 404 if rule is not scheduled", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{err: models.ErrAlertRuleNotFound}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteResetAlertRuleInstances(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 409 if rule is evaluated by another instance", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{err: fmt.Errorf("%w: the rule is evaluated by instance other", models.ErrAlertRuleNotOwned)}
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createServiceWithScheduler(ac, scheduler).RouteResetAlertRuleInstances(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusConflict, response.Status())
+		require.Contains(t, string(response.Body()), "instance other")
+	})
+
+	t.Run("should return 401 if user cannot update rules in the folder", func(t *testing.T) {
+		scheduler := &fakeRuleScheduler{}
+		ac := acMock.New().WithPermissions(createPermissionsForRules([]*models.AlertRule{rule}))
+		response := createServiceWithScheduler(ac, scheduler).RouteResetAlertRuleInstances(createRequestContext(orgID, "", nil), rule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+		require.Empty(t, scheduler.Reset)
+	})
+}
+
+func TestRouteGetRuleInstances(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder))()
+	ruleStore.PutRule(context.Background(), rule)
+	for i := 0; i < 5; i++ {
+		state := models.InstanceStateNormal
+		if i%2 == 0 {
+			state = models.InstanceStateFiring
+		}
+		ruleStore.Instances[orgID] = append(ruleStore.Instances[orgID], &models.AlertInstance{
+			AlertInstanceKey:  models.AlertInstanceKey{RuleOrgID: orgID, RuleUID: rule.UID, LabelsHash: fmt.Sprint(i)},
+			Labels:            models.InstanceLabels{"test": fmt.Sprint(i)},
+			CurrentState:      state,
+			CurrentStateSince: time.Unix(int64(i), 0).UTC(),
+			LastEvalTime:      time.Unix(10, 0).UTC(),
+			Values:            models.InstanceValues{"A": float64(i) + 0.5, "B": math.NaN()},
+		})
+	}
+
+	rulePermissions := append(createPermissionsForRules([]*models.AlertRule{rule}), accesscontrol.Permission{
+		Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+	})
+	request := func(orgID int64, query string) *contextmodel.ReqContext {
+		c := createRequestContext(orgID, org.RoleViewer, nil)
+		c.Req.URL.RawQuery = query
+		return c
+	}
+
+	t.Run("should return a page of instances filtered by state", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createService(ac, ruleStore).RouteGetRuleInstances(request(orgID, "state=Alerting&page=2&limit=2"), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+
+		result := &apimodels.RuleInstancesResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		require.EqualValues(t, 3, result.TotalCount)
+		require.EqualValues(t, 2, result.Page)
+		require.EqualValues(t, 2, result.Limit)
+		value := 4.5
+		require.Equal(t, []apimodels.GettableAlertInstance{{
+			Labels:         map[string]string{"test": "4"},
+			LabelsHash:     "4",
+			State:          string(models.InstanceStateFiring),
+			StateSince:     time.Unix(4, 0).UTC(),
+			LastEvaluation: time.Unix(10, 0).UTC(),
+			Values:         map[string]*float64{"A": &value, "B": nil},
+		}}, result.Instances)
+	})
+
+	t.Run("should filter instances by label matchers", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createService(ac, ruleStore).RouteGetRuleInstances(request(orgID, "matcher=test=3&state=Normal"), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		result := &apimodels.RuleInstancesResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		require.EqualValues(t, 1, result.TotalCount)
+		require.Len(t, result.Instances, 1)
+		require.Equal(t, map[string]string{"test": "3"}, result.Instances[0].Labels)
+
+		response = createService(ac, ruleStore).RouteGetRuleInstances(request(orgID, "matcher=test=3&matcher=cluster=eu"), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		result = &apimodels.RuleInstancesResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		require.Zero(t, result.TotalCount)
+		require.Empty(t, result.Instances)
+	})
+
+	t.Run("should reject invalid matchers", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(rulePermissions)
+		for _, query := range []string{"matcher=test", "matcher==3", "matcher=test=1&matcher=test=2"} {
+			response := createService(ac, ruleStore).RouteGetRuleInstances(request(orgID, query), rule.UID)
+			require.Equalf(t, http.StatusBadRequest, response.Status(), "query %s", query)
+		}
+	})
+
+	t.Run("should report instances resolved within the grace period", func(t *testing.T) {
+		orgID := rand.Int63()
+		ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+		rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder))()
+		ruleStore.PutRule(context.Background(), rule)
+		resolvedAt := time.Now().Add(-time.Minute).Truncate(time.Second).UTC()
+		for i, at := range []time.Time{{}, resolvedAt, resolvedAt.Add(-time.Hour)} {
+			ruleStore.Instances[orgID] = append(ruleStore.Instances[orgID], &models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: orgID, RuleUID: rule.UID, LabelsHash: fmt.Sprint(i)},
+				Labels:           models.InstanceLabels{"test": fmt.Sprint(i)},
+				CurrentState:     models.InstanceStateNormal,
+				ResolvedAt:       at,
+			})
+		}
+		permissions := append(createPermissionsForRules([]*models.AlertRule{rule}), accesscontrol.Permission{
+			Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+		})
+		svc := createService(acMock.New().WithPermissions(permissions), ruleStore)
+		svc.cfg = &setting.UnifiedAlertingSettings{ResolvedGracePeriod: 5 * time.Minute}
+
+		response := svc.RouteGetRuleInstances(request(orgID, ""), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		result := &apimodels.RuleInstancesResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		require.Len(t, result.Instances, 3)
+		require.False(t, result.Instances[0].Resolved)
+		require.Nil(t, result.Instances[0].ResolvedAt)
+		require.True(t, result.Instances[1].Resolved)
+		require.NotNil(t, result.Instances[1].ResolvedAt)
+		require.True(t, resolvedAt.Equal(*result.Instances[1].ResolvedAt))
+		require.False(t, result.Instances[2].Resolved)
+		require.Nil(t, result.Instances[2].ResolvedAt)
+	})
+
+	t.Run("should report the latest evaluation of the states in memory", func(t *testing.T) {
+		orgID := rand.Int63()
+		ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+		rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder))()
+		ruleStore.PutRule(context.Background(), rule)
+		saved := time.Unix(10, 0).UTC()
+		for _, name := range []string{"current", "stale"} {
+			labels := models.InstanceLabels{"test": name}
+			_, hash, err := labels.StringAndHash()
+			require.NoError(t, err)
+			ruleStore.Instances[orgID] = append(ruleStore.Instances[orgID], &models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: orgID, RuleUID: rule.UID, LabelsHash: hash},
+				Labels:           labels,
+				CurrentState:     models.InstanceStateNormal,
+				LastEvalTime:     saved,
+				Values:           models.InstanceValues{"A": 1},
+			})
+		}
+		permissions := append(createPermissionsForRules([]*models.AlertRule{rule}), accesscontrol.Permission{
+			Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+		})
+		svc := createService(acMock.New().WithPermissions(permissions), ruleStore)
+		svc.manager = &fakeAlertInstanceManager{states: map[int64]map[string][]*state.State{orgID: {rule.UID: {
+			{OrgID: orgID, AlertRuleUID: rule.UID, Labels: data.Labels{"test": "current"}, LastEvaluationTime: saved.Add(time.Minute), Values: map[string]float64{"A": 2}},
+			{OrgID: orgID, AlertRuleUID: rule.UID, Labels: data.Labels{"test": "stale"}, LastEvaluationTime: saved.Add(-time.Minute), Values: map[string]float64{"A": 3}},
+		}}}}
+
+		response := svc.RouteGetRuleInstances(request(orgID, ""), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		result := &apimodels.RuleInstancesResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		require.Len(t, result.Instances, 2)
+		current, stale := 2.0, 1.0
+		require.Equal(t, map[string]string{"test": "current"}, result.Instances[0].Labels)
+		require.Equal(t, saved.Add(time.Minute), result.Instances[0].LastEvaluation)
+		require.Equal(t, map[string]*float64{"A": &current}, result.Instances[0].Values)
+		require.Equal(t, map[string]string{"test": "stale"}, result.Instances[1].Labels)
+		require.Equal(t, saved, result.Instances[1].LastEvaluation, "the saved instance is newer than the state in memory")
+		require.Equal(t, map[string]*float64{"A": &stale}, result.Instances[1].Values)
+	})
+
+	t.Run("should use default page", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createService(ac, ruleStore).RouteGetRuleInstances(request(orgID, ""), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+
+		result := &apimodels.RuleInstancesResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		require.EqualValues(t, 5, result.TotalCount)
+		require.EqualValues(t, 1, result.Page)
+		require.EqualValues(t, defaultRuleInstancesLimit, result.Limit)
+		order := make([]string, 0, len(result.Instances))
+		for _, instance := range result.Instances {
+			order = append(order, instance.State+" "+instance.Labels["test"])
+		}
+		require.Equal(t, []string{"Alerting 0", "Alerting 2", "Alerting 4", "Normal 1", "Normal 3"}, order, "instances should be ordered by severity and labels")
+	})
+
+	t.Run("should return an empty list if the rule has no instances", func(t *testing.T) {
+		rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder))()
+		ruleStore.PutRule(context.Background(), rule)
+		ac := acMock.New().WithPermissions(append(createPermissionsForRules([]*models.AlertRule{rule}), accesscontrol.Permission{
+			Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+		}))
+		response := createService(ac, ruleStore).RouteGetRuleInstances(request(orgID, ""), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		require.JSONEq(t, fmt.Sprintf(`{"totalCount": 0, "page": 1, "limit": %d, "instances": []}`, defaultRuleInstancesLimit), string(response.Body()))
+	})
+
+	t.Run("should return 400 if state or page is not valid", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(rulePermissions)
+		for _, query := range []string{"state=Firing", "page=-1", fmt.Sprintf("limit=%d", maxRuleInstancesLimit+1)} {
+			response := createService(ac, ruleStore).RouteGetRuleInstances(request(orgID, query), rule.UID)
+			require.Equalf(t, http.StatusBadRequest, response.Status(), query)
+		}
+	})
+
+	t.Run("should return 404 if rule belongs to another organization", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(rulePermissions)
+		response := createService(ac, ruleStore).RouteGetRuleInstances(request(orgID+1, ""), rule.UID)
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 401 if user cannot read rules in the folder", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(createPermissionsForRules([]*models.AlertRule{rule}))
+		response := createService(ac, ruleStore).RouteGetRuleInstances(request(orgID, ""), rule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+	})
+}
+
+func TestRouteGetRuleAudit(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.AuditEntries = map[int64][]*models.AlertRuleAuditEntry{}
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder))()
+	ruleStore.PutRule(context.Background(), rule)
+	actions := []models.AlertRuleAuditAction{models.AlertRuleAuditCreate, models.AlertRuleAuditUpdate, models.AlertRuleAuditPause}
+	for i, action := range actions {
+		entry := &models.AlertRuleAuditEntry{
+			OrgID:        orgID,
+			RuleUID:      rule.UID,
+			NamespaceUID: folder.UID,
+			Action:       action,
+			ActorID:      int64(i + 1),
+			Created:      time.Unix(int64(i), 0).UTC(),
+		}
+		if action == models.AlertRuleAuditUpdate {
+			entry.Changes = []string{"Title"}
+		}
+		ruleStore.AuditEntries[orgID] = append(ruleStore.AuditEntries[orgID], entry)
+	}
+	deletedUID := util.GenerateShortUID()
+	ruleStore.AuditEntries[orgID] = append(ruleStore.AuditEntries[orgID],
+		&models.AlertRuleAuditEntry{OrgID: orgID, RuleUID: deletedUID, NamespaceUID: folder.UID, Action: models.AlertRuleAuditCreate, ActorID: 1},
+		&models.AlertRuleAuditEntry{OrgID: orgID, RuleUID: deletedUID, NamespaceUID: folder.UID, Action: models.AlertRuleAuditDelete, ActorID: 2},
+	)
+
+	folderPermissions := []accesscontrol.Permission{{
+		Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID),
+	}}
+	request := func(query string) *contextmodel.ReqContext {
+		c := createRequestContext(orgID, org.RoleViewer, nil)
+		c.Req.URL.RawQuery = query
+		return c
+	}
+
+	t.Run("should return a page of entries from the newest", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(folderPermissions)
+		response := createService(ac, ruleStore).RouteGetRuleAudit(request("page=1&limit=2"), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+
+		result := &apimodels.RuleAuditResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		require.EqualValues(t, 3, result.TotalCount)
+		require.EqualValues(t, 1, result.Page)
+		require.EqualValues(t, 2, result.Limit)
+		require.Equal(t, []apimodels.GettableRuleAuditEntry{
+			{Action: "pause", ActorID: 3, Timestamp: time.Unix(2, 0).UTC()},
+			{Action: "update", ActorID: 2, Timestamp: time.Unix(1, 0).UTC(), Changes: []string{"Title"}},
+		}, result.Entries)
+	})
+
+	t.Run("should return the entries of a deleted rule", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(folderPermissions)
+		response := createService(ac, ruleStore).RouteGetRuleAudit(request(""), deletedUID)
+		require.Equal(t, http.StatusOK, response.Status())
+
+		result := &apimodels.RuleAuditResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), result))
+		require.EqualValues(t, 2, result.TotalCount)
+		require.EqualValues(t, defaultRuleAuditLimit, result.Limit)
+		require.Len(t, result.Entries, 2)
+		require.Equal(t, "delete", result.Entries[0].Action)
+	})
+
+	t.Run("should return 404 if the rule has no entries", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(folderPermissions)
+		response := createService(ac, ruleStore).RouteGetRuleAudit(request(""), util.GenerateShortUID())
+		require.Equal(t, http.StatusNotFound, response.Status())
+	})
+
+	t.Run("should return 401 without access to the folder of the rule", func(t *testing.T) {
+		ac := acMock.New()
+		response := createService(ac, ruleStore).RouteGetRuleAudit(request(""), rule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+		response = createService(ac, ruleStore).RouteGetRuleAudit(request(""), deletedUID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+	})
+
+	t.Run("should reject invalid pages", func(t *testing.T) {
+		ac := acMock.New().WithPermissions(folderPermissions)
+		for _, query := range []string{"page=-1", "limit=-1", "limit=1001"} {
+			response := createService(ac, ruleStore).RouteGetRuleAudit(request(query), rule.UID)
+			require.Equalf(t, http.StatusBadRequest, response.Status(), "query %s", query)
+		}
+	})
+}
+
+func TestRouteProvisionedAlertRules(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder), func(rule *models.AlertRule) {
+		rule.IsPaused = false
+	})()
+	ruleStore.PutRule(context.Background(), rule)
+	provisioningStore := provisioning.NewFakeProvisioningStore()
+	require.NoError(t, provisioningStore.SetProvenance(context.Background(), rule, orgID, models.ProvenanceFile))
+
+	scope := dashboards.ScopeFoldersProvider.GetResourceScopeUID(folder.UID)
+	permissions := append(createPermissionsForRules([]*models.AlertRule{rule}),
+		accesscontrol.Permission{Action: accesscontrol.ActionAlertingRuleRead, Scope: scope},
+		accesscontrol.Permission{Action: accesscontrol.ActionAlertingRuleUpdate, Scope: scope},
+		accesscontrol.Permission{Action: accesscontrol.ActionAlertingRuleDelete, Scope: scope},
+	)
+	svc := createServiceWithProvenanceStore(acMock.New().WithPermissions(permissions), ruleStore, provisioningStore)
+	request := func(role org.RoleType, override bool) *contextmodel.ReqContext {
+		c := createRequestContext(orgID, role, nil)
+		c.Req.Header = http.Header{}
+		if override {
+			c.Req.Header.Set(disableProvenanceHeaderName, "true")
+		}
+		return c
+	}
+	getRule := func(t *testing.T) *models.AlertRule {
+		t.Helper()
+		r, err := ruleStore.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+		require.NoError(t, err)
+		return r
+	}
+	updateTitle := func(t *testing.T, c *contextmodel.ReqContext, title string) response.Response {
+		t.Helper()
+		updated := models.AlertRuleWithOptionals{AlertRule: *models.CopyRule(getRule(t))}
+		updated.Title = title
+		return svc.updateAlertRulesInGroup(c, rule.GetGroupKey(), []*models.AlertRuleWithOptionals{&updated})
+	}
+
+	t.Run("should return the provenance of the rule", func(t *testing.T) {
+		response := svc.RouteGetRulesGroupConfig(request(org.RoleViewer, false), folder.Title, rule.RuleGroup)
+		require.Equal(t, http.StatusAccepted, response.Status())
+		var result apimodels.RuleGroupConfigResponse
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result.Rules, 1)
+		require.Equal(t, apimodels.Provenance(models.ProvenanceFile), result.Rules[0].GrafanaManagedAlert.Provenance)
+	})
+
+	t.Run("should reject changes to the rule", func(t *testing.T) {
+		for _, c := range []*contextmodel.ReqContext{request(org.RoleEditor, false), request(org.RoleEditor, true), request(org.RoleAdmin, false)} {
+			response := svc.RoutePauseAlertRule(c, rule.UID)
+			require.Equal(t, http.StatusBadRequest, response.Status())
+			require.Contains(t, string(response.Body()), "provisioning")
+
+			response = updateTitle(t, c, "changed")
+			require.Equal(t, http.StatusBadRequest, response.Status())
+
+			response = svc.RouteDeleteAlertRules(c, folder.Title, rule.RuleGroup)
+			require.Equal(t, http.StatusBadRequest, response.Status())
+		}
+		require.False(t, getRule(t).IsPaused)
+		require.Equal(t, rule.Title, getRule(t).Title)
+	})
+
+	t.Run("should allow admins to override the provenance", func(t *testing.T) {
+		response := svc.RoutePauseAlertRule(request(org.RoleAdmin, true), rule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		require.True(t, getRule(t).IsPaused)
+
+		response = updateTitle(t, request(org.RoleAdmin, true), "changed")
+		require.Equal(t, http.StatusAccepted, response.Status())
+		require.Equal(t, "changed", getRule(t).Title)
+
+		provenance, err := provisioningStore.GetProvenance(context.Background(), rule, orgID)
+		require.NoError(t, err)
+		require.Equal(t, models.ProvenanceFile, provenance)
+
+		response = svc.RouteDeleteAlertRules(request(org.RoleAdmin, true), folder.Title, rule.RuleGroup)
+		require.Equal(t, http.StatusAccepted, response.Status())
+		_, err = ruleStore.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+		require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+	})
+}
+
+func TestRulerAPIKeys(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+	rule := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder), func(rule *models.AlertRule) {
+		rule.IsPaused = false
+	})()
+	ruleStore.PutRule(context.Background(), rule)
+
+	// the default permissions of a folder, where viewers can view and editors can edit
+	origNewGuardian := guardian.NewByUID
+	t.Cleanup(func() {
+		guardian.NewByUID = origNewGuardian
+	})
+	guardian.NewByUID = func(_ context.Context, _ string, _ int64, u *user.SignedInUser) (guardian.DashboardGuardian, error) {
+		return &guardian.FakeDashboardGuardian{
+			CanViewValue: u.HasRole(org.RoleViewer),
+			CanSaveValue: u.HasRole(org.RoleEditor),
+		}, nil
+	}
+
+	svc := createService(acMock.New().WithDisabled(), ruleStore)
+	svc.QuotaService = quotatest.New(false, nil)
+	apiKey := func(orgID int64, role org.RoleType) *contextmodel.ReqContext {
+		c := createRequestContext(orgID, role, nil)
+		c.SignedInUser.ApiKeyID = 42
+		return c
+	}
+
+	t.Run("should let keys of any role read rules", func(t *testing.T) {
+		for _, role := range []org.RoleType{org.RoleViewer, org.RoleEditor, org.RoleAdmin} {
+			response := svc.RouteGetRuleInstances(apiKey(orgID, role), rule.UID)
+			require.Equalf(t, http.StatusOK, response.Status(), "role %s", role)
+
+			response = svc.RouteGetRulesConfig(apiKey(orgID, role))
+			require.Equalf(t, http.StatusOK, response.Status(), "role %s", role)
+			result := apimodels.NamespaceConfigResponse{}
+			require.NoError(t, json.Unmarshal(response.Body(), &result))
+			require.Containsf(t, result, folder.Title, "role %s", role)
+		}
+	})
+
+	t.Run("should reject changes by viewer keys", func(t *testing.T) {
+		response := svc.RoutePauseAlertRule(apiKey(orgID, org.RoleViewer), rule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+		response = svc.RouteResetAlertRuleInstances(apiKey(orgID, org.RoleViewer), rule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+	})
+
+	t.Run("should accept changes by editor and admin keys", func(t *testing.T) {
+		for _, role := range []org.RoleType{org.RoleEditor, org.RoleAdmin} {
+			response := svc.RoutePauseAlertRule(apiKey(orgID, role), rule.UID)
+			require.Equalf(t, http.StatusOK, response.Status(), "role %s", role)
+		}
+	})
+
+	t.Run("should record the key as the creator of rules", func(t *testing.T) {
+		created := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder), withGroup("created-by-key"))()
+		created.UID = ""
+		groupKey := created.GetGroupKey()
+		response := svc.updateAlertRulesInGroup(apiKey(orgID, org.RoleEditor), groupKey, []*models.AlertRuleWithOptionals{{AlertRule: *created}})
+		require.Equal(t, http.StatusAccepted, response.Status())
+
+		inserts := ruleStore.GetRecordedCommands(func(cmd interface{}) (interface{}, bool) {
+			c, ok := cmd.([]models.AlertRule)
+			return c, ok
+		})
+		require.Len(t, inserts, 1)
+		require.Equal(t, int64(-42), inserts[0].([]models.AlertRule)[0].CreatedBy)
+	})
+
+	t.Run("should not find rules of other organizations", func(t *testing.T) {
+		for _, role := range []org.RoleType{org.RoleViewer, org.RoleEditor, org.RoleAdmin} {
+			response := svc.RouteGetRuleInstances(apiKey(orgID+1, role), rule.UID)
+			require.Equalf(t, http.StatusNotFound, response.Status(), "role %s", role)
+			response = svc.RoutePauseAlertRule(apiKey(orgID+1, role), rule.UID)
+			require.Equalf(t, http.StatusNotFound, response.Status(), "role %s", role)
+			response = svc.RouteGetAlertRuleEvaluation(apiKey(orgID+1, role), rule.UID)
+			require.Equalf(t, http.StatusNotFound, response.Status(), "role %s", role)
+		}
+	})
+}
+
+func TestRulerInMemoryStore(t *testing.T) {
+	orgID := rand.Int63()
+	folder := randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], folder)
+
+	origNewGuardian := guardian.NewByUID
+	t.Cleanup(func() {
+		guardian.NewByUID = origNewGuardian
+	})
+	guardian.NewByUID = func(_ context.Context, _ string, _ int64, _ *user.SignedInUser) (guardian.DashboardGuardian, error) {
+		return &guardian.FakeDashboardGuardian{CanViewValue: true, CanSaveValue: true}, nil
+	}
+
+	svc := createService(acMock.New().WithDisabled(), ruleStore)
+	svc.QuotaService = quotatest.New(false, nil)
+
+	created := models.AlertRuleGen(withOrgID(orgID), withNamespace(folder), withGroup("in-memory"))()
+	created.UID = ""
+	response := svc.updateAlertRulesInGroup(createRequestContext(orgID, org.RoleEditor, nil), created.GetGroupKey(), []*models.AlertRuleWithOptionals{{AlertRule: *created}})
+	require.Equal(t, http.StatusAccepted, response.Status())
+
+	rules, err := ruleStore.ListAlertRules(context.Background(), &models.ListAlertRulesQuery{OrgID: orgID, RuleGroup: "in-memory"})
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	rule := rules[0]
+	require.NotEmpty(t, rule.UID)
+	require.EqualValues(t, 1, rule.Version)
+
+	require.NoError(t, ruleStore.SaveAlertInstances(context.Background(), models.AlertInstance{
+		AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: orgID, RuleUID: rule.UID, LabelsHash: "hash"},
+		CurrentState:     models.InstanceStateFiring,
+	}))
+	response = svc.RouteGetRuleInstances(createRequestContext(orgID, org.RoleViewer, nil), rule.UID)
+	require.Equal(t, http.StatusOK, response.Status())
+	response = svc.RouteGetRuleInstances(createRequestContext(orgID+1, org.RoleViewer, nil), rule.UID)
+	require.Equal(t, http.StatusNotFound, response.Status())
+
+	response = svc.RouteDeleteAlertRules(createRequestContext(orgID, org.RoleEditor, nil), folder.Title, "in-memory")
+	require.Equal(t, http.StatusAccepted, response.Status())
+	require.Empty(t, ruleStore.Instances[orgID])
+	response = svc.RouteGetRuleInstances(createRequestContext(orgID, org.RoleViewer, nil), rule.UID)
+	require.Equal(t, http.StatusNotFound, response.Status())
+}
+
+func TestRuleAccessInheritsFolderPermissions(t *testing.T) {
+	orgID := rand.Int63()
+	payments, operations, billing, secret := randFolder(), randFolder(), randFolder(), randFolder()
+	ruleStore := fakes.NewRuleStore(t)
+	ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], payments, operations, billing, secret)
+	ruleStore.FolderPermissions = map[string]dashboards.PermissionType{
+		payments.UID:   dashboards.PERMISSION_EDIT,
+		operations.UID: dashboards.PERMISSION_EDIT,
+		billing.UID:    dashboards.PERMISSION_VIEW,
+	}
+
+	origNewGuardian := guardian.NewByUID
+	t.Cleanup(func() {
+		guardian.NewByUID = origNewGuardian
+	})
+	guardian.NewByUID = func(_ context.Context, uid string, _ int64, _ *user.SignedInUser) (guardian.DashboardGuardian, error) {
+		permission := ruleStore.FolderPermissions[uid]
+		return &guardian.FakeDashboardGuardian{
+			CanViewValue: permission >= dashboards.PERMISSION_VIEW,
+			CanSaveValue: permission >= dashboards.PERMISSION_EDIT,
+		}, nil
+	}
+
+	paymentsRule := models.AlertRuleGen(withOrgID(orgID), withNamespace(payments), withGroup("payments"))()
+	billingRule := models.AlertRuleGen(withOrgID(orgID), withNamespace(billing), withGroup("billing"))()
+	secretRule := models.AlertRuleGen(withOrgID(orgID), withNamespace(secret), withGroup("secret"))()
+	ruleStore.PutRule(context.Background(), paymentsRule, billingRule, secretRule)
+
+	svc := createService(acMock.New().WithDisabled(), ruleStore)
+	moveRule := func(rule *models.AlertRule, target *folder.Folder) response.Response {
+		moved := models.AlertRuleWithOptionals{AlertRule: *models.CopyRule(rule)}
+		moved.NamespaceUID = target.UID
+		groupKey := models.AlertRuleGroupKey{OrgID: orgID, NamespaceUID: target.UID, RuleGroup: rule.RuleGroup}
+		return svc.updateAlertRulesInGroup(createRequestContext(orgID, org.RoleEditor, nil), groupKey, []*models.AlertRuleWithOptionals{&moved})
+	}
+	getRule := func(uid string) *models.AlertRule {
+		rule, err := ruleStore.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: uid})
+		require.NoError(t, err)
+		return rule
+	}
+
+	t.Run("should list only the rules in folders the user can view", func(t *testing.T) {
+		response := svc.RouteGetRulesConfig(createRequestContext(orgID, org.RoleEditor, nil))
+		require.Equal(t, http.StatusOK, response.Status())
+		result := apimodels.NamespaceConfigResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result, 2)
+		require.Contains(t, result, payments.Title)
+		require.Contains(t, result, billing.Title)
+		require.NotContains(t, result, secret.Title)
+	})
+
+	t.Run("should read rules in folders the user can view", func(t *testing.T) {
+		for _, rule := range []*models.AlertRule{paymentsRule, billingRule} {
+			response := svc.RouteGetRuleInstances(createRequestContext(orgID, org.RoleEditor, nil), rule.UID)
+			require.Equal(t, http.StatusOK, response.Status())
+		}
+		response := svc.RouteGetRuleInstances(createRequestContext(orgID, org.RoleEditor, nil), secretRule.UID)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+	})
+
+	t.Run("should write rules only in folders the user can edit", func(t *testing.T) {
+		response := svc.RoutePauseAlertRule(createRequestContext(orgID, org.RoleEditor, nil), paymentsRule.UID)
+		require.Equal(t, http.StatusOK, response.Status())
+		require.True(t, getRule(paymentsRule.UID).IsPaused)
+
+		for _, rule := range []*models.AlertRule{billingRule, secretRule} {
+			response := svc.RoutePauseAlertRule(createRequestContext(orgID, org.RoleEditor, nil), rule.UID)
+			require.Equal(t, http.StatusUnauthorized, response.Status())
+			require.False(t, getRule(rule.UID).IsPaused)
+		}
+	})
+
+	t.Run("should reject moving a rule into a folder the user cannot edit", func(t *testing.T) {
+		response := svc.RoutePostNameRulesConfig(createRequestContext(orgID, org.RoleEditor, nil), apimodels.PostableRuleGroupConfig{Name: paymentsRule.RuleGroup}, billing.Title)
+		require.Equal(t, http.StatusForbidden, response.Status())
+		require.Equal(t, payments.UID, getRule(paymentsRule.UID).NamespaceUID)
+	})
+
+	t.Run("should reject moving a rule out of a folder the user cannot edit", func(t *testing.T) {
+		response := moveRule(billingRule, operations)
+		require.Equal(t, http.StatusUnauthorized, response.Status())
+		require.Equal(t, billing.UID, getRule(billingRule.UID).NamespaceUID)
+	})
+
+	t.Run("should move a rule between folders the user can edit", func(t *testing.T) {
+		response := moveRule(getRule(paymentsRule.UID), operations)
+		require.Equal(t, http.StatusAccepted, response.Status())
+		require.Equal(t, operations.UID, getRule(paymentsRule.UID).NamespaceUID)
+	})
+}
+
 func TestVerifyProvisionedRulesNotAffected(t *testing.T) {
 	orgID := rand.Int63()
 	group := models.GenerateGroupKey(orgID)
@@ -604,6 +1970,8 @@ func createService(ac *acMock.Mock, store *fakes.RuleStore) *RulerSrv {
 		log:             log.New("test"),
 		cfg:             nil,
 		ac:              ac,
+		manager:         &fakeAlertInstanceManager{states: map[int64]map[string][]*state.State{}},
+		clock:           clock.New(),
 	}
 }
 
@@ -3,16 +3,21 @@ package api
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	"github.com/grafana/grafana/pkg/api/response"
 	"github.com/grafana/grafana/pkg/services/folder"
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/template"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
-// validateRuleNode validates API model (definitions.PostableExtendedRuleNode) and converts it to models.AlertRule
+// validateRuleNode validates API model (definitions.PostableExtendedRuleNode) and converts it to models.AlertRule.
+// Problems with the fields of the model are returned as validationErrors, with paths relative to the rule node.
 func validateRuleNode(
 	ruleNode *apimodels.PostableExtendedRuleNode,
 	groupName string,
@@ -30,79 +35,110 @@ func validateRuleNode(
 		return nil, fmt.Errorf("not Grafana managed alert rule")
 	}
 
+	alert := ruleNode.GrafanaManagedAlert
+	var errs validationErrors
+
 	// if UID is specified then we can accept partial model. Therefore, some validation can be skipped as it will be patched later
-	canPatch := ruleNode.GrafanaManagedAlert.UID != ""
+	canPatch := alert.UID != ""
 
-	if ruleNode.GrafanaManagedAlert.Title == "" && !canPatch {
-		return nil, errors.New("alert rule title cannot be empty")
+	if alert.Title == "" && !canPatch {
+		errs.add("grafana_alert.title", errors.New("alert rule title cannot be empty"))
 	}
 
-	if len(ruleNode.GrafanaManagedAlert.Title) > store.AlertRuleMaxTitleLength {
-		return nil, fmt.Errorf("alert rule title is too long. Max length is %d", store.AlertRuleMaxTitleLength)
+	if len(alert.Title) > store.AlertRuleMaxTitleLength {
+		errs.add("grafana_alert.title", fmt.Errorf("alert rule title is too long. Max length is %d", store.AlertRuleMaxTitleLength))
 	}
 
 	noDataState := ngmodels.NoData
-	if ruleNode.GrafanaManagedAlert.NoDataState == "" && canPatch {
+	if alert.NoDataState == "" && canPatch {
 		noDataState = ""
 	}
 
-	if ruleNode.GrafanaManagedAlert.NoDataState != "" {
-		noDataState, err = ngmodels.NoDataStateFromString(string(ruleNode.GrafanaManagedAlert.NoDataState))
+	if alert.NoDataState != "" {
+		noDataState, err = ngmodels.NoDataStateFromString(string(alert.NoDataState))
 		if err != nil {
-			return nil, err
+			errs.add("grafana_alert.no_data_state", err)
 		}
 	}
 
 	errorState := ngmodels.AlertingErrState
 
-	if ruleNode.GrafanaManagedAlert.ExecErrState == "" && canPatch {
+	if alert.ExecErrState == "" && canPatch {
 		errorState = ""
 	}
 
-	if ruleNode.GrafanaManagedAlert.ExecErrState != "" {
-		errorState, err = ngmodels.ErrStateFromString(string(ruleNode.GrafanaManagedAlert.ExecErrState))
+	if alert.ExecErrState != "" {
+		errorState, err = ngmodels.ErrStateFromString(string(alert.ExecErrState))
 		if err != nil {
-			return nil, err
+			errs.add("grafana_alert.exec_err_state", err)
 		}
 	}
 
-	if len(ruleNode.GrafanaManagedAlert.Data) == 0 {
+	if len(alert.Data) == 0 {
 		if canPatch {
-			if ruleNode.GrafanaManagedAlert.Condition != "" {
-				return nil, fmt.Errorf("%w: query is not specified by condition is. You must specify both query and condition to update existing alert rule", ngmodels.ErrAlertRuleFailedValidation)
+			if alert.Condition != "" {
+				errs.add("grafana_alert.data", fmt.Errorf("%w: query is not specified by condition is. You must specify both query and condition to update existing alert rule", ngmodels.ErrAlertRuleFailedValidation))
 			}
 		} else {
-			return nil, fmt.Errorf("%w: no queries or expressions are found", ngmodels.ErrAlertRuleFailedValidation)
+			errs.add("grafana_alert.data", fmt.Errorf("%w: no queries or expressions are found", ngmodels.ErrAlertRuleFailedValidation))
 		}
 	}
 
-	queries := AlertQueriesFromApiAlertQueries(ruleNode.GrafanaManagedAlert.Data)
-	if len(queries) != 0 {
+	queries := AlertQueriesFromApiAlertQueries(alert.Data)
+	// the condition is validated only if the queries are valid because the validator would fail on the same problems
+	if len(queries) != 0 && validateQueries(queries, &errs) {
 		cond := ngmodels.Condition{
-			Condition: ruleNode.GrafanaManagedAlert.Condition,
+			Condition: alert.Condition,
 			Data:      queries,
 		}
 		if err = conditionValidator(cond); err != nil {
-			return nil, fmt.Errorf("failed to validate condition of alert rule %s: %w", ruleNode.GrafanaManagedAlert.Title, err)
+			errs.add("grafana_alert.condition", fmt.Errorf("failed to validate condition of alert rule %s: %w", alert.Title, err))
 		}
 	}
 
 	newAlertRule := ngmodels.AlertRule{
-		OrgID:           orgId,
-		Title:           ruleNode.GrafanaManagedAlert.Title,
-		Condition:       ruleNode.GrafanaManagedAlert.Condition,
-		Data:            queries,
-		UID:             ruleNode.GrafanaManagedAlert.UID,
-		IntervalSeconds: intervalSeconds,
-		NamespaceUID:    namespace.UID,
-		RuleGroup:       groupName,
-		NoDataState:     noDataState,
-		ExecErrState:    errorState,
+		OrgID:                      orgId,
+		Title:                      alert.Title,
+		Condition:                  alert.Condition,
+		Data:                       queries,
+		UID:                        alert.UID,
+		IntervalSeconds:            intervalSeconds,
+		NamespaceUID:               namespace.UID,
+		RuleGroup:                  groupName,
+		NoDataState:                noDataState,
+		ExecErrState:               errorState,
+		Schedule:                   alert.Schedule,
+		ScheduleTimezone:           alert.ScheduleTimezone,
+		InstanceLimit:              alert.InstanceLimit,
+		ContactPointUIDs:           alert.ContactPointUIDs,
+		NotificationTitle:          alert.NotificationTitle,
+		NotificationMessage:        alert.NotificationMessage,
+		NotificationRepeatInterval: time.Duration(alert.NotificationRepeatInterval),
+		SendResolved:               alert.SendResolved == nil || *alert.SendResolved,
+	}
+
+	if err = newAlertRule.ValidateSchedule(); err != nil {
+		errs.add("grafana_alert.schedule", err)
+	}
+
+	if err = newAlertRule.ValidateInstanceLimit(cfg.MaxInstancesPerRuleLimit); err != nil {
+		errs.add("grafana_alert.instance_limit", err)
+	}
+
+	if err = newAlertRule.ValidateNotificationRepeatInterval(); err != nil {
+		errs.add("grafana_alert.notification_repeat_interval", err)
+	}
+
+	if err = template.ParseNotificationTemplate(newAlertRule.NotificationTitle); err != nil {
+		errs.add("grafana_alert.notification_title", fmt.Errorf("%w: %s", ngmodels.ErrAlertRuleFailedValidation, err))
+	}
+	if err = template.ParseNotificationTemplate(newAlertRule.NotificationMessage); err != nil {
+		errs.add("grafana_alert.notification_message", fmt.Errorf("%w: %s", ngmodels.ErrAlertRuleFailedValidation, err))
 	}
 
 	newAlertRule.For, err = validateForInterval(ruleNode)
 	if err != nil {
-		return nil, err
+		errs.add("for", err)
 	}
 
 	if ruleNode.ApiRuleNode != nil {
@@ -111,12 +147,41 @@ func validateRuleNode(
 
 		err = newAlertRule.SetDashboardAndPanelFromAnnotations()
 		if err != nil {
-			return nil, err
+			errs.add("annotations", err)
 		}
 	}
+	if err = errs.orNil(); err != nil {
+		return nil, err
+	}
 	return &newAlertRule, nil
 }
 
+// validateQueries checks the queries and expressions of a rule and adds the problems it finds to errs. Returns true if it
+// did not find any problem.
+func validateQueries(queries []ngmodels.AlertQuery, errs *validationErrors) bool {
+	count := len(errs.errs)
+	refIDs := make(map[string]int, len(queries))
+	for idx, query := range queries {
+		field := fmt.Sprintf("grafana_alert.data[%d]", idx)
+		if query.RefID == "" {
+			errs.add(field+".refId", errors.New("refId cannot be empty"))
+		} else if existingIdx, ok := refIDs[query.RefID]; ok {
+			errs.add(field+".refId", fmt.Errorf("refId %s is already used by the query at index %d", query.RefID, existingIdx))
+		} else {
+			refIDs[query.RefID] = idx
+		}
+		isExpression, err := query.IsExpression()
+		if err != nil {
+			errs.add(field+".model", err)
+			continue
+		}
+		if !isExpression && query.RelativeTimeRange.From <= query.RelativeTimeRange.To {
+			errs.add(field+".relativeTimeRange", errors.New("from must be greater than to"))
+		}
+	}
+	return len(errs.errs) == count
+}
+
 func validateInterval(cfg *setting.UnifiedAlertingSettings, interval time.Duration) (int64, error) {
 	intervalSeconds := int64(interval.Seconds())
 
@@ -149,7 +214,8 @@ func validateForInterval(ruleNode *apimodels.PostableExtendedRuleNode) (time.Dur
 }
 
 // validateRuleGroup validates API model (definitions.PostableRuleGroupConfig) and converts it to a collection of models.AlertRule.
-// Returns a slice that contains all rules described by API model or error if either group specification or an alert definition is not valid.
+// Returns a slice that contains all rules described by API model or validationErrors with all problems of the group specification
+// and its alert definitions.
 // It also returns a map containing current existing alerts that don't contain the is_paused field in the body of the call.
 func validateRuleGroup(
 	ruleGroupConfig *apimodels.PostableRuleGroupConfig,
@@ -157,12 +223,13 @@ func validateRuleGroup(
 	namespace *folder.Folder,
 	conditionValidator func(ngmodels.Condition) error,
 	cfg *setting.UnifiedAlertingSettings) ([]*ngmodels.AlertRuleWithOptionals, error) {
+	var errs validationErrors
 	if ruleGroupConfig.Name == "" {
-		return nil, errors.New("rule group name cannot be empty")
+		errs.add("name", errors.New("rule group name cannot be empty"))
 	}
 
 	if len(ruleGroupConfig.Name) > store.AlertRuleMaxRuleGroupNameLength {
-		return nil, fmt.Errorf("rule group name is too long. Max length is %d", store.AlertRuleMaxRuleGroupNameLength)
+		errs.add("name", fmt.Errorf("rule group name is too long. Max length is %d", store.AlertRuleMaxRuleGroupNameLength))
 	}
 
 	interval := time.Duration(ruleGroupConfig.Interval)
@@ -172,7 +239,9 @@ func validateRuleGroup(
 	}
 
 	if interval < 0 || int64(interval.Seconds())%int64(cfg.BaseInterval.Seconds()) != 0 {
-		return nil, fmt.Errorf("rule evaluation interval (%d second) should be positive number that is multiple of the base interval of %d seconds", int64(interval.Seconds()), int64(cfg.BaseInterval.Seconds()))
+		errs.add("interval", fmt.Errorf("rule evaluation interval (%d second) should be positive number that is multiple of the base interval of %d seconds", int64(interval.Seconds()), int64(cfg.BaseInterval.Seconds())))
+		// validate the rules with the default interval to report their problems as well
+		interval = cfg.DefaultRuleEvaluationInterval
 	}
 
 	// TODO should we validate that interval is >= cfg.MinInterval? Currently, we allow to save but fix the specified interval if it is < cfg.MinInterval
@@ -180,14 +249,16 @@ func validateRuleGroup(
 	result := make([]*ngmodels.AlertRuleWithOptionals, 0, len(ruleGroupConfig.Rules))
 	uids := make(map[string]int, cap(result))
 	for idx := range ruleGroupConfig.Rules {
+		field := fmt.Sprintf("rules[%d]", idx)
 		rule, err := validateRuleNode(&ruleGroupConfig.Rules[idx], ruleGroupConfig.Name, interval, orgId, namespace, conditionValidator, cfg)
-		// TODO do not stop on the first failure but return all failures
 		if err != nil {
-			return nil, fmt.Errorf("invalid rule specification at index [%d]: %w", idx, err)
+			errs.addAll(field, err)
+			continue
 		}
 		if rule.UID != "" {
 			if existingIdx, ok := uids[rule.UID]; ok {
-				return nil, fmt.Errorf("rule [%d] has UID %s that is already assigned to another rule at index %d", idx, rule.UID, existingIdx)
+				errs.add(field+".grafana_alert.uid", fmt.Errorf("rule [%d] has UID %s that is already assigned to another rule at index %d", idx, rule.UID, existingIdx))
+				continue
 			}
 			uids[rule.UID] = idx
 		}
@@ -209,5 +280,74 @@ func validateRuleGroup(
 
 		result = append(result, &ruleWithOptionals)
 	}
+	if err := errs.orNil(); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
+
+// fieldError is a problem with the field of the request body at the given path.
+type fieldError struct {
+	field string
+	err   error
+}
+
+// validationErrors accumulates the problems found in a request body so that all of them can be reported at once.
+// It unwraps to the first problem, which lets callers that handle a single error keep working.
+type validationErrors struct {
+	errs []fieldError
+}
+
+func (e *validationErrors) add(field string, err error) {
+	e.errs = append(e.errs, fieldError{field: field, err: err})
+}
+
+// addAll adds the problems in err with their fields prefixed with the given path. If err is not validationErrors, it is
+// added as the problem of the field at the path.
+func (e *validationErrors) addAll(path string, err error) {
+	var verr *validationErrors
+	if !errors.As(err, &verr) {
+		e.add(path, err)
+		return
+	}
+	for _, fe := range verr.errs {
+		e.add(path+"."+fe.field, fe.err)
+	}
+}
+
+// orNil returns e if it contains any problem, and nil otherwise.
+func (e *validationErrors) orNil() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *validationErrors) Error() string {
+	msgs := make([]string, 0, len(e.errs))
+	for _, fe := range e.errs {
+		msgs = append(msgs, fmt.Sprintf("%s: %s", fe.field, fe.err))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *validationErrors) Unwrap() error {
+	return e.errs[0].err
+}
+
+// validationErrorResponse returns http.StatusBadRequest with every problem in err and the path of its field, if err is
+// validationErrors, and with err as the message otherwise.
+func validationErrorResponse(err error) response.Response {
+	var verr *validationErrors
+	if !errors.As(err, &verr) {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+	body := apimodels.ValidationErrors{
+		Message: verr.Error(),
+		Errors:  make([]apimodels.FieldValidationError, 0, len(verr.errs)),
+	}
+	for _, fe := range verr.errs {
+		body.Errors = append(body.Errors, apimodels.FieldValidationError{Field: fe.field, Message: fe.err.Error()})
+	}
+	return response.JSON(http.StatusBadRequest, body)
+}
@@ -1,8 +1,10 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"testing"
 	"time"
@@ -225,6 +227,66 @@ func TestValidateRuleGroupFailures(t *testing.T) {
 	}
 }
 
+func TestValidateRuleGroupReportsAllFailures(t *testing.T) {
+	orgId := rand.Int63()
+	folder := randFolder()
+	cfg := config(t)
+
+	r1 := validRule()
+	r1.GrafanaManagedAlert.UID = ""
+	r1.GrafanaManagedAlert.Title = ""
+	r1.GrafanaManagedAlert.Data = append(r1.GrafanaManagedAlert.Data, apimodels.AlertQuery{
+		RefID:             "B",
+		RelativeTimeRange: apimodels.RelativeTimeRange{From: 0, To: 600},
+		DatasourceUID:     "DATASOURCE_TEST",
+	})
+	r2 := validRule()
+	r2.GrafanaManagedAlert.NoDataState = "unknown"
+	r2.GrafanaManagedAlert.Data[0].RefID = ""
+	g := validGroup(cfg, r1, r2)
+	g.Name = ""
+
+	conditionValidated := false
+	_, err := validateRuleGroup(&g, orgId, folder, func(condition models.Condition) error {
+		conditionValidated = true
+		return nil
+	}, cfg)
+
+	var verr *validationErrors
+	require.ErrorAs(t, err, &verr)
+	fields := make([]string, 0, len(verr.errs))
+	for _, fe := range verr.errs {
+		fields = append(fields, fe.field)
+	}
+	require.Equal(t, []string{
+		"name",
+		"rules[0].grafana_alert.title",
+		"rules[0].grafana_alert.data[1].relativeTimeRange",
+		"rules[1].grafana_alert.no_data_state",
+		"rules[1].grafana_alert.data[0].refId",
+	}, fields)
+	require.Equal(t, "from must be greater than to", verr.errs[2].err.Error())
+	require.False(t, conditionValidated, "condition should not be validated if queries are invalid")
+
+	t.Run("response should list every failure with its field", func(t *testing.T) {
+		resp := validationErrorResponse(err)
+		require.Equal(t, http.StatusBadRequest, resp.Status())
+		var body apimodels.ValidationErrors
+		require.NoError(t, json.Unmarshal(resp.Body(), &body))
+		require.Len(t, body.Errors, len(fields))
+		require.Equal(t, apimodels.FieldValidationError{
+			Field:   "rules[0].grafana_alert.data[1].relativeTimeRange",
+			Message: "from must be greater than to",
+		}, body.Errors[2])
+	})
+
+	t.Run("response should contain a single message if error is not validationErrors", func(t *testing.T) {
+		resp := validationErrorResponse(errors.New("test error"))
+		require.Equal(t, http.StatusBadRequest, resp.Status())
+		require.Contains(t, string(resp.Body()), "test error")
+	})
+}
+
 func TestValidateRuleNode_NoUID(t *testing.T) {
 	orgId := rand.Int63()
 	folder := randFolder()
@@ -316,6 +378,19 @@ func TestValidateRuleNode_NoUID(t *testing.T) {
 				require.Equal(t, int64(panelId), *alert.PanelID)
 			},
 		},
+		{
+			name: "copies schedule and its time zone",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				r.GrafanaManagedAlert.Schedule = "0 8 * * 1-5"
+				r.GrafanaManagedAlert.ScheduleTimezone = "Europe/Helsinki"
+				return &r
+			},
+			assert: func(t *testing.T, api *apimodels.PostableExtendedRuleNode, alert *models.AlertRule) {
+				require.Equal(t, "0 8 * * 1-5", alert.Schedule)
+				require.Equal(t, "Europe/Helsinki", alert.ScheduleTimezone)
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -383,6 +458,15 @@ func TestValidateRuleNodeFailures_NoUID(t *testing.T) {
 				return &r
 			},
 		},
+		{
+			name: "fail if For is negative",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				forDuration := model.Duration(-time.Minute)
+				r.ApiRuleNode.For = &forDuration
+				return &r
+			},
+		},
 		{
 			name: "fail if NoDataState is not known",
 			rule: func() *apimodels.PostableExtendedRuleNode {
@@ -456,6 +540,32 @@ func TestValidateRuleNodeFailures_NoUID(t *testing.T) {
 				return &r
 			},
 		},
+		{
+			name: "fail if schedule is not a valid cron expression",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				r.GrafanaManagedAlert.Schedule = "0 8 * *"
+				return &r
+			},
+			assert: func(t *testing.T, model *apimodels.PostableExtendedRuleNode, err error) {
+				var scheduleErr *models.InvalidScheduleError
+				require.ErrorAs(t, err, &scheduleErr)
+				require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+			},
+		},
+		{
+			name: "fail if schedule time zone is unknown",
+			rule: func() *apimodels.PostableExtendedRuleNode {
+				r := validRule()
+				r.GrafanaManagedAlert.Schedule = "0 8 * * 1-5"
+				r.GrafanaManagedAlert.ScheduleTimezone = "Mars/Olympus_Mons"
+				return &r
+			},
+			assert: func(t *testing.T, model *apimodels.PostableExtendedRuleNode, err error) {
+				var scheduleErr *models.InvalidScheduleError
+				require.ErrorAs(t, err, &scheduleErr)
+			},
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -2,12 +2,12 @@ package api
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/api/response"
@@ -33,6 +33,7 @@ type TestingApiSrv struct {
 	cfg             *setting.UnifiedAlertingSettings
 	backtesting     *backtesting.Engine
 	featureManager  featuremgmt.FeatureToggles
+	clock           clock.Clock
 }
 
 func (srv TestingApiSrv) RouteTestGrafanaRuleConfig(c *contextmodel.ReqContext, body apimodels.TestRulePayload) response.Response {
@@ -42,10 +43,10 @@ func (srv TestingApiSrv) RouteTestGrafanaRuleConfig(c *contextmodel.ReqContext,
 
 	queries := AlertQueriesFromApiAlertQueries(body.GrafanaManagedCondition.Data)
 
-	if !authorizeDatasourceAccessForRule(&ngmodels.AlertRule{Data: queries}, func(evaluator accesscontrol.Evaluator) bool {
+	if err := authorizeDatasourceAccessForQueries(queries, func(evaluator accesscontrol.Evaluator) bool {
 		return accesscontrol.HasAccess(srv.accessControl, c)(accesscontrol.ReqSignedIn, evaluator)
-	}) {
-		return errorToResponse(fmt.Errorf("%w to query one or many data sources used by the rule", ErrAuthorization))
+	}); err != nil {
+		return errorToResponse(err)
 	}
 
 	evalCond := ngmodels.Condition{
@@ -61,18 +62,38 @@ func (srv TestingApiSrv) RouteTestGrafanaRuleConfig(c *contextmodel.ReqContext,
 
 	now := body.GrafanaManagedCondition.Now
 	if now.IsZero() {
-		now = timeNow()
+		now = srv.clock.Now()
 	}
 
 	evalResults, err := conditionEval.Evaluate(c.Req.Context(), now)
 	if err != nil {
-		return ErrResp(500, err, "Failed to evaluate the rule")
+		return evaluationErrorResponse(err, "Failed to evaluate the rule")
 	}
 
 	frame := evalResults.AsDataFrame()
-	return response.JSONStreaming(http.StatusOK, util.DynMap{
+	resp := util.DynMap{
 		"instances": []*data.Frame{&frame},
-	})
+		"state":     evalResults.State().String(),
+	}
+	for _, result := range evalResults {
+		if result.Error != nil {
+			resp["errorReason"] = eval.ErrorReason(result.Error)
+			break
+		}
+	}
+	return response.JSONStreaming(http.StatusOK, resp)
+}
+
+// evaluationErrorResponse returns the response for an evaluation that failed, with a status code that depends on the cause of the error.
+func evaluationErrorResponse(err error, message string) response.Response {
+	switch eval.ErrorReason(err) {
+	case eval.ErrorReasonTimeout:
+		return ErrResp(http.StatusGatewayTimeout, err, "%s: the evaluation timed out", message)
+	case eval.ErrorReasonDatasource:
+		return ErrResp(http.StatusBadGateway, err, "%s: a data source query failed", message)
+	default:
+		return ErrResp(http.StatusBadRequest, err, "%s", message)
+	}
 }
 
 func (srv TestingApiSrv) RouteTestRuleConfig(c *contextmodel.ReqContext, body apimodels.TestRulePayload, datasourceUID string) response.Response {
@@ -94,7 +115,7 @@ func (srv TestingApiSrv) RouteTestRuleConfig(c *contextmodel.ReqContext, body ap
 		return errorToResponse(unexpectedDatasourceTypeError(ds.Type, "loki, prometheus"))
 	}
 
-	t := timeNow()
+	t := srv.clock.Now()
 	queryURL, err := url.Parse(path)
 	if err != nil {
 		return ErrResp(http.StatusInternalServerError, err, "failed to parse url")
@@ -115,10 +136,10 @@ func (srv TestingApiSrv) RouteTestRuleConfig(c *contextmodel.ReqContext, body ap
 
 func (srv TestingApiSrv) RouteEvalQueries(c *contextmodel.ReqContext, cmd apimodels.EvalQueriesPayload) response.Response {
 	queries := AlertQueriesFromApiAlertQueries(cmd.Data)
-	if !authorizeDatasourceAccessForRule(&ngmodels.AlertRule{Data: queries}, func(evaluator accesscontrol.Evaluator) bool {
+	if err := authorizeDatasourceAccessForQueries(queries, func(evaluator accesscontrol.Evaluator) bool {
 		return accesscontrol.HasAccess(srv.accessControl, c)(accesscontrol.ReqSignedIn, evaluator)
-	}) {
-		return ErrResp(http.StatusUnauthorized, fmt.Errorf("%w to query one or many data sources used by the rule", ErrAuthorization), "")
+	}); err != nil {
+		return errorToResponse(err)
 	}
 
 	cond := ngmodels.Condition{
@@ -136,13 +157,13 @@ func (srv TestingApiSrv) RouteEvalQueries(c *contextmodel.ReqContext, cmd apimod
 
 	now := cmd.Now
 	if now.IsZero() {
-		now = timeNow()
+		now = srv.clock.Now()
 	}
 
 	evalResults, err := evaluator.EvaluateRaw(c.Req.Context(), now)
 
 	if err != nil {
-		return ErrResp(http.StatusInternalServerError, err, "Failed to evaluate queries and expressions")
+		return evaluationErrorResponse(err, "Failed to evaluate queries and expressions")
 	}
 
 	return response.JSONStreaming(http.StatusOK, evalResults)
@@ -173,10 +194,10 @@ func (srv TestingApiSrv) BacktestAlertRule(c *contextmodel.ReqContext, cmd apimo
 	}
 
 	queries := AlertQueriesFromApiAlertQueries(cmd.Data)
-	if !authorizeDatasourceAccessForRule(&ngmodels.AlertRule{Data: queries}, func(evaluator accesscontrol.Evaluator) bool {
+	if err := authorizeDatasourceAccessForQueries(queries, func(evaluator accesscontrol.Evaluator) bool {
 		return accesscontrol.HasAccess(srv.accessControl, c)(accesscontrol.ReqSignedIn, evaluator)
-	}) {
-		return errorToResponse(fmt.Errorf("%w to query one or many data sources used by the rule", ErrAuthorization))
+	}); err != nil {
+		return errorToResponse(err)
 	}
 
 	rule := &ngmodels.AlertRule{
@@ -1,14 +1,23 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	acMock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
@@ -19,6 +28,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -33,7 +43,7 @@ func TestRouteTestGrafanaRuleConfig(t *testing.T) {
 			},
 		}
 
-		t.Run("should return 401 if user cannot query a data source", func(t *testing.T) {
+		t.Run("should return 403 naming the data source if user cannot query it", func(t *testing.T) {
 			data1 := models.GenerateAlertQuery()
 			data2 := models.GenerateAlertQuery()
 
@@ -52,7 +62,8 @@ func TestRouteTestGrafanaRuleConfig(t *testing.T) {
 				},
 			})
 
-			require.Equal(t, http.StatusUnauthorized, response.Status())
+			require.Equal(t, http.StatusForbidden, response.Status())
+			require.Contains(t, string(response.Body()), data2.DatasourceUID)
 		})
 
 		t.Run("should return 200 if user can query all data sources", func(t *testing.T) {
@@ -129,7 +140,7 @@ func TestRouteTestGrafanaRuleConfig(t *testing.T) {
 				},
 			})
 
-			require.Equal(t, http.StatusUnauthorized, response.Status())
+			require.Equal(t, http.StatusForbidden, response.Status())
 			evaluator.AssertNotCalled(t, "Evaluate", mock.Anything, currentTime)
 
 			rc.IsSignedIn = true
@@ -148,6 +159,112 @@ func TestRouteTestGrafanaRuleConfig(t *testing.T) {
 			evaluator.AssertCalled(t, "Evaluate", mock.Anything, currentTime)
 		})
 	})
+
+	t.Run("evaluation", func(t *testing.T) {
+		rc := &contextmodel.ReqContext{
+			Context: &web.Context{
+				Req:  &http.Request{},
+				Resp: web.NewResponseWriter(http.MethodPost, httptest.NewRecorder()),
+			},
+			IsSignedIn: true,
+			SignedInUser: &user.SignedInUser{
+				OrgID: 1,
+			},
+		}
+		mathQuery := func(refID, expression string) definitions.AlertQuery {
+			return definitions.AlertQuery{
+				RefID:             refID,
+				DatasourceUID:     expr.DatasourceUID,
+				Model:             json.RawMessage(fmt.Sprintf(`{"datasourceUid": "__expr__", "type": "math", "expression": %q}`, expression)),
+				RelativeTimeRange: definitions.RelativeTimeRange{From: definitions.Duration(time.Hour)},
+			}
+		}
+		readBody := func(t *testing.T, resp response.Response) map[string]interface{} {
+			t.Helper()
+			recorder := httptest.NewRecorder()
+			resp.WriteTo(&contextmodel.ReqContext{Context: &web.Context{Resp: web.NewResponseWriter(http.MethodPost, recorder)}})
+			var body map[string]interface{}
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &body))
+			return body
+		}
+		evaluatorFactory := eval.NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, nil, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, nil), &plugins.FakePluginStore{})
+
+		t.Run("should return the state of a condition that is true", func(t *testing.T) {
+			srv := createTestingApiSrv(nil, nil, evaluatorFactory)
+
+			resp := srv.RouteTestGrafanaRuleConfig(rc, definitions.TestRulePayload{
+				GrafanaManagedCondition: &definitions.EvalAlertConditionCommand{
+					Condition: "A",
+					Data:      []definitions.AlertQuery{mathQuery("A", "2 + 2 > 1")},
+				},
+			})
+
+			require.Equal(t, http.StatusOK, resp.Status())
+			body := readBody(t, resp)
+			require.Equal(t, "Alerting", body["state"])
+			require.NotContains(t, body, "errorReason")
+			require.Len(t, body["instances"], 1)
+		})
+
+		t.Run("should return 400 if the condition refers to a missing query", func(t *testing.T) {
+			srv := createTestingApiSrv(nil, nil, evaluatorFactory)
+
+			resp := srv.RouteTestGrafanaRuleConfig(rc, definitions.TestRulePayload{
+				GrafanaManagedCondition: &definitions.EvalAlertConditionCommand{
+					Condition: "B",
+					Data:      []definitions.AlertQuery{mathQuery("A", "2 + 2 > 1")},
+				},
+			})
+
+			require.Equal(t, http.StatusBadRequest, resp.Status())
+		})
+
+		t.Run("should return the reason of an error of a data source", func(t *testing.T) {
+			queryErr := expr.QueryError{RefID: "A", Err: errors.New("connection refused")}
+			evaluator := &eval_mocks.ConditionEvaluatorMock{}
+			evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{eval.NewResultFromError(queryErr, time.Now(), 0)}, nil)
+			srv := createTestingApiSrv(nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+
+			resp := srv.RouteTestGrafanaRuleConfig(rc, definitions.TestRulePayload{
+				GrafanaManagedCondition: &definitions.EvalAlertConditionCommand{
+					Condition: "A",
+					Data:      []definitions.AlertQuery{mathQuery("A", "1")},
+				},
+			})
+
+			require.Equal(t, http.StatusOK, resp.Status())
+			body := readBody(t, resp)
+			require.Equal(t, "Error", body["state"])
+			require.Equal(t, eval.ErrorReasonDatasource, body["errorReason"])
+		})
+
+		t.Run("should map errors of the evaluation by their reason", func(t *testing.T) {
+			queryErr := expr.QueryError{RefID: "A", Err: errors.New("connection refused")}
+			testCases := []struct {
+				err    error
+				status int
+			}{
+				{err: fmt.Errorf("failed to execute pipeline: %w", queryErr), status: http.StatusBadGateway},
+				{err: fmt.Errorf("failed to execute pipeline: %w", context.DeadlineExceeded), status: http.StatusGatewayTimeout},
+				{err: errors.New("invalid expression"), status: http.StatusBadRequest},
+			}
+			for _, tc := range testCases {
+				evaluator := &eval_mocks.ConditionEvaluatorMock{}
+				evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, tc.err)
+				srv := createTestingApiSrv(nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+
+				resp := srv.RouteTestGrafanaRuleConfig(rc, definitions.TestRulePayload{
+					GrafanaManagedCondition: &definitions.EvalAlertConditionCommand{
+						Condition: "A",
+						Data:      []definitions.AlertQuery{mathQuery("A", "1")},
+					},
+				})
+
+				require.Equal(t, tc.status, resp.Status(), tc.err.Error())
+				require.Contains(t, string(resp.Body()), tc.err.Error())
+			}
+		})
+	})
 }
 
 func TestRouteEvalQueries(t *testing.T) {
@@ -161,7 +278,7 @@ func TestRouteEvalQueries(t *testing.T) {
 			},
 		}
 
-		t.Run("should return 401 if user cannot query a data source", func(t *testing.T) {
+		t.Run("should return 403 naming the data source if user cannot query it", func(t *testing.T) {
 			data1 := models.GenerateAlertQuery()
 			data2 := models.GenerateAlertQuery()
 
@@ -178,7 +295,8 @@ func TestRouteEvalQueries(t *testing.T) {
 				Now:  time.Time{},
 			})
 
-			require.Equal(t, http.StatusUnauthorized, response.Status())
+			require.Equal(t, http.StatusForbidden, response.Status())
+			require.Contains(t, string(response.Body()), data2.DatasourceUID)
 		})
 
 		t.Run("should return 200 if user can query all data sources", func(t *testing.T) {
@@ -260,7 +378,7 @@ func TestRouteEvalQueries(t *testing.T) {
 				Now:  currentTime,
 			})
 
-			require.Equal(t, http.StatusUnauthorized, response.Status())
+			require.Equal(t, http.StatusForbidden, response.Status())
 			evaluator.AssertNotCalled(t, "EvaluateRaw", mock.Anything, mock.Anything)
 
 			rc.IsSignedIn = true
@@ -286,5 +404,6 @@ func createTestingApiSrv(ds *fakes.FakeCacheService, ac *acMock.Mock, evaluator
 		DatasourceCache: ds,
 		accessControl:   ac,
 		evaluator:       evaluator,
+		clock:           clock.New(),
 	}
 }
@@ -8,8 +8,10 @@ import (
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/middleware"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/guardian"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/web"
@@ -17,6 +19,8 @@ import (
 
 var (
 	ErrAuthorization = errors.New("user is not authorized")
+	// ErrDatasourceAuthorization is returned when the user is not allowed to query a data source that a rule uses.
+	ErrDatasourceAuthorization = fmt.Errorf("%w to query data source", ErrAuthorization)
 )
 
 //nolint:gocyclo
@@ -49,7 +53,16 @@ func (api *API) authorize(method, path string) web.Handler {
 		eval = ac.EvalPermission(ac.ActionAlertingRuleRead, dashboards.ScopeFoldersProvider.GetResourceScopeName(ac.Parameter(":Namespace")))
 	case http.MethodGet + "/api/ruler/grafana/api/v1/rules":
 		eval = ac.EvalPermission(ac.ActionAlertingRuleRead)
-	case http.MethodPost + "/api/ruler/grafana/api/v1/rules/{Namespace}":
+	case http.MethodDelete + "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname}",
+		http.MethodDelete + "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}":
+		eval = ac.EvalPermission(ac.ActionAlertingRuleDelete, dashboards.ScopeFoldersProvider.GetResourceScopeName(ac.Parameter(":Namespace")))
+	case http.MethodGet + "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname}",
+		http.MethodGet + "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}":
+		eval = ac.EvalPermission(ac.ActionAlertingRuleRead, dashboards.ScopeFoldersProvider.GetResourceScopeName(ac.Parameter(":Namespace")))
+	case http.MethodGet + "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules":
+		eval = ac.EvalPermission(ac.ActionAlertingRuleRead)
+	case http.MethodPost + "/api/ruler/grafana/api/v1/rules/{Namespace}",
+		http.MethodPost + "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}":
 		fallback = middleware.ReqSignedIn // if RBAC is disabled then we need to delegate permission check to folder because its permissions can allow editing for Viewer role
 		scope := dashboards.ScopeFoldersProvider.GetResourceScopeName(ac.Parameter(":Namespace"))
 		// more granular permissions are enforced by the handler via "authorizeRuleChanges"
@@ -58,6 +71,31 @@ func (api *API) authorize(method, path string) web.Handler {
 			ac.EvalPermission(ac.ActionAlertingRuleCreate, scope),
 			ac.EvalPermission(ac.ActionAlertingRuleDelete, scope),
 		)
+	case http.MethodPost + "/api/ruler/grafana/api/v1/rule/{RuleUID}/eval":
+		// the rule's folder and data sources are checked by the handler
+		eval = ac.EvalPermission(ac.ActionAlertingRuleUpdate)
+	case http.MethodPost + "/api/ruler/grafana/api/v1/rule/{RuleUID}/reset":
+		// the rule's folder is checked by the handler
+		eval = ac.EvalPermission(ac.ActionAlertingRuleUpdate)
+	case http.MethodPost + "/api/ruler/grafana/api/v1/rule/{RuleUID}/pause",
+		http.MethodPost + "/api/ruler/grafana/api/v1/rule/{RuleUID}/unpause":
+		// the rule's folder and data sources are checked by the handler
+		eval = ac.EvalPermission(ac.ActionAlertingRuleUpdate)
+	case http.MethodPost + "/api/ruler/grafana/api/v1/rule/{RuleUID}/test-notification":
+		// the rule's folder is checked by the handler
+		eval = ac.EvalPermission(ac.ActionAlertingRuleUpdate)
+	case http.MethodPost + "/api/ruler/grafana/api/v1/rule/bulk":
+		// the folders and data sources of the rules are checked by the handler
+		eval = ac.EvalAny(ac.EvalPermission(ac.ActionAlertingRuleUpdate), ac.EvalPermission(ac.ActionAlertingRuleDelete))
+	case http.MethodGet + "/api/ruler/grafana/api/v1/rule/{RuleUID}/eval":
+		// the rule's folder and data sources are checked by the handler
+		eval = ac.EvalPermission(ac.ActionAlertingRuleRead)
+	case http.MethodGet + "/api/ruler/grafana/api/v1/rule/{RuleUID}/instances":
+		// the rule's folder and data sources are checked by the handler
+		eval = ac.EvalPermission(ac.ActionAlertingRuleRead)
+	case http.MethodGet + "/api/ruler/grafana/api/v1/rule/{RuleUID}/audit":
+		// the rule's folder is checked by the handler
+		eval = ac.EvalPermission(ac.ActionAlertingRuleRead)
 	// Grafana rule state history paths
 	case http.MethodGet + "/api/v1/rules/history":
 		fallback = middleware.ReqSignedIn
@@ -196,6 +234,13 @@ func (api *API) authorize(method, path string) web.Handler {
 		http.MethodGet + "/api/v1/ngalert/alertmanagers":
 		return middleware.ReqOrgAdmin
 
+	// Scheduler Paths. They affect or expose all organizations.
+	case http.MethodGet + "/api/v1/ngalert/scheduler",
+		http.MethodGet + "/api/v1/ngalert/scheduler/shadow",
+		http.MethodPost + "/api/v1/ngalert/scheduler/pause",
+		http.MethodPost + "/api/v1/ngalert/scheduler/resume":
+		return middleware.ReqGrafanaAdmin
+
 	// Grafana-only Provisioning Read Paths
 	case http.MethodGet + "/api/v1/provisioning/policies",
 		http.MethodGet + "/api/v1/provisioning/contact-points",
@@ -203,10 +248,17 @@ func (api *API) authorize(method, path string) web.Handler {
 		http.MethodGet + "/api/v1/provisioning/templates/{name}",
 		http.MethodGet + "/api/v1/provisioning/mute-timings",
 		http.MethodGet + "/api/v1/provisioning/mute-timings/{name}",
+		http.MethodGet + "/api/v1/provisioning/maintenance-windows",
+		http.MethodGet + "/api/v1/provisioning/maintenance-windows/{ID}",
+		http.MethodGet + "/api/v1/provisioning/silences",
+		http.MethodGet + "/api/v1/provisioning/silences/{ID}",
 		http.MethodGet + "/api/v1/provisioning/alert-rules",
 		http.MethodGet + "/api/v1/provisioning/alert-rules/{UID}",
+		http.MethodGet + "/api/v1/provisioning/alert-rules/summaries",
 		http.MethodGet + "/api/v1/provisioning/alert-rules/export",
+		http.MethodGet + "/api/v1/provisioning/alert-rules/export/prometheus",
 		http.MethodGet + "/api/v1/provisioning/alert-rules/{UID}/export",
+		http.MethodPost + "/api/v1/provisioning/alert-rules/{UID}/diff",
 		http.MethodGet + "/api/v1/provisioning/folder/{FolderUID}/rule-groups/{Group}",
 		http.MethodGet + "/api/v1/provisioning/folder/{FolderUID}/rule-groups/{Group}/export":
 		fallback = middleware.ReqOrgAdmin
@@ -222,8 +274,16 @@ func (api *API) authorize(method, path string) web.Handler {
 		http.MethodPost + "/api/v1/provisioning/mute-timings",
 		http.MethodPut + "/api/v1/provisioning/mute-timings/{name}",
 		http.MethodDelete + "/api/v1/provisioning/mute-timings/{name}",
+		http.MethodPost + "/api/v1/provisioning/maintenance-windows",
+		http.MethodPut + "/api/v1/provisioning/maintenance-windows/{ID}",
+		http.MethodDelete + "/api/v1/provisioning/maintenance-windows/{ID}",
+		http.MethodPost + "/api/v1/provisioning/silences",
+		http.MethodPut + "/api/v1/provisioning/silences/{ID}",
+		http.MethodDelete + "/api/v1/provisioning/silences/{ID}",
 		http.MethodPost + "/api/v1/provisioning/alert-rules",
+		http.MethodPost + "/api/v1/provisioning/alert-rules/import/prometheus",
 		http.MethodPut + "/api/v1/provisioning/alert-rules/{UID}",
+		http.MethodPatch + "/api/v1/provisioning/alert-rules/{UID}",
 		http.MethodDelete + "/api/v1/provisioning/alert-rules/{UID}",
 		http.MethodPut + "/api/v1/provisioning/folder/{FolderUID}/rule-groups/{Group}":
 		fallback = middleware.ReqOrgAdmin
@@ -239,6 +299,12 @@ func (api *API) authorize(method, path string) web.Handler {
 
 // authorizeDatasourceAccessForRule checks that user has access to all data sources declared by the rule
 func authorizeDatasourceAccessForRule(rule *ngmodels.AlertRule, evaluator func(evaluator ac.Evaluator) bool) bool {
+	return unauthorizedDatasourceForRule(rule, evaluator) == ""
+}
+
+// unauthorizedDatasourceForRule returns the UID of the first data source declared by the rule that the user does not have access to,
+// or an empty string if the user has access to all of them.
+func unauthorizedDatasourceForRule(rule *ngmodels.AlertRule, evaluator func(evaluator ac.Evaluator) bool) string {
 	for _, query := range rule.Data {
 		if query.QueryType == expr.DatasourceType || query.DatasourceUID == expr.DatasourceUID || query.
 			DatasourceUID == expr.
@@ -246,10 +312,79 @@ func authorizeDatasourceAccessForRule(rule *ngmodels.AlertRule, evaluator func(e
 			continue
 		}
 		if !evaluator(ac.EvalPermission(datasources.ActionQuery, datasources.ScopeProvider.GetResourceScopeUID(query.DatasourceUID))) {
-			return false
+			return query.DatasourceUID
 		}
 	}
-	return true
+	return ""
+}
+
+// authorizeDatasourceAccessForQueries returns an error that wraps ErrDatasourceAuthorization and names the first data source
+// used by the queries that the user does not have access to, or nil if the user has access to all of them.
+func authorizeDatasourceAccessForQueries(queries []ngmodels.AlertQuery, evaluator func(evaluator ac.Evaluator) bool) error {
+	if uid := unauthorizedDatasourceForRule(&ngmodels.AlertRule{Data: queries}, evaluator); uid != "" {
+		return fmt.Errorf("%w %s", ErrDatasourceAuthorization, uid)
+	}
+	return nil
+}
+
+// hasAccessInFolder returns a function that checks evaluators of the actions on the rules in the folder with the given UID.
+// When access control is enabled, alert rules inherit the permissions of their folder through the folder-scoped actions.
+// When it is disabled, the user must pass the fallback and have the given permission on the folder according to the dashboard guardian,
+// which grants the role defaults on the General folder. The folder is checked at most once.
+func hasAccessInFolder(accessControl ac.AccessControl, c *contextmodel.ReqContext, fallback func(*contextmodel.ReqContext) bool, folderUID string, permission dashboards.PermissionType) func(evaluator ac.Evaluator) bool {
+	var checked, allowed bool
+	folderFallback := func(c *contextmodel.ReqContext) bool {
+		if !checked {
+			checked = true
+			allowed = fallback(c) && canAccessFolder(c, folderUID, permission)
+		}
+		return allowed
+	}
+	return func(evaluator ac.Evaluator) bool {
+		return ac.HasAccess(accessControl, c)(folderFallback, evaluator)
+	}
+}
+
+// canAccessFolder returns true if the dashboard guardian grants the user the given permission on the folder with the given UID.
+// Any permission other than dashboards.PERMISSION_VIEW requires the user to be able to save in the folder.
+func canAccessFolder(c *contextmodel.ReqContext, folderUID string, permission dashboards.PermissionType) bool {
+	g, err := guardian.NewByUID(c.Req.Context(), folderUID, c.SignedInUser.OrgID, c.SignedInUser)
+	if err != nil {
+		c.Logger.Error("Failed to create the guardian of the folder", "folderUID", folderUID, "error", err)
+		return false
+	}
+	var allowed bool
+	if permission == dashboards.PERMISSION_VIEW {
+		allowed, err = g.CanView()
+	} else {
+		allowed, err = g.CanSave()
+	}
+	if err != nil {
+		c.Logger.Error("Failed to check the permissions on the folder", "folderUID", folderUID, "error", err)
+		return false
+	}
+	return allowed
+}
+
+// authorizeRuleMovesFromFolders checks that the user can edit the folders that the rules are moved from.
+// It is only needed when access control is disabled, as the target folder is checked when the namespace is fetched
+// and otherwise authorizeRuleChanges checks both folders.
+func authorizeRuleMovesFromFolders(c *contextmodel.ReqContext, change *store.GroupDelta) error {
+	checked := make(map[string]struct{})
+	for _, rule := range change.Update {
+		folderUID := rule.Existing.NamespaceUID
+		if folderUID == rule.New.NamespaceUID {
+			continue
+		}
+		if _, ok := checked[folderUID]; ok {
+			continue
+		}
+		if !canAccessFolder(c, folderUID, dashboards.PERMISSION_EDIT) {
+			return fmt.Errorf("%w to move alert rules from folder UID %s", ErrAuthorization, folderUID)
+		}
+		checked[folderUID] = struct{}{}
+	}
+	return nil
 }
 
 // authorizeAccessToRuleGroup checks all rules against authorizeDatasourceAccessForRule and exits on the first negative result
@@ -299,17 +434,15 @@ func authorizeRuleChanges(change *store.GroupDelta, evaluator func(evaluator ac.
 			return fmt.Errorf("%w to create alert rules in the folder %s", ErrAuthorization, change.GroupKey.NamespaceUID)
 		}
 		for _, rule := range change.New {
-			dsAllowed := authorizeDatasourceAccessForRule(rule, evaluator)
-			if !dsAllowed {
-				return fmt.Errorf("%w to create a new alert rule '%s' because the user does not have read permissions for one or many datasources the rule uses", ErrAuthorization, rule.Title)
+			if uid := unauthorizedDatasourceForRule(rule, evaluator); uid != "" {
+				return fmt.Errorf("%w %s, which the new alert rule '%s' uses", ErrDatasourceAuthorization, uid, rule.Title)
 			}
 		}
 	}
 
 	for _, rule := range change.Update {
-		dsAllowed := authorizeDatasourceAccessForRule(rule.New, evaluator)
-		if !dsAllowed {
-			return fmt.Errorf("%w to update alert rule '%s' (UID: %s) because the user does not have read permissions for one or many datasources the rule uses", ErrAuthorization, rule.Existing.Title, rule.Existing.UID)
+		if uid := unauthorizedDatasourceForRule(rule.New, evaluator); uid != "" {
+			return fmt.Errorf("%w %s, which the updated alert rule '%s' (UID: %s) uses", ErrDatasourceAuthorization, uid, rule.Existing.Title, rule.Existing.UID)
 		}
 
 		// Check if the rule is moved from one folder to the current. If yes, then the user must have the authorization to delete rules from the source folder and add rules to the target folder.
@@ -4,21 +4,27 @@ import (
 	"math"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/go-openapi/loads"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slices"
 
 	"github.com/grafana/grafana/pkg/expr"
 	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/web"
 )
 
 func TestAuthorize(t *testing.T) {
@@ -49,7 +55,7 @@ func TestAuthorize(t *testing.T) {
 		}
 		paths[p] = methods
 	}
-	require.Len(t, paths, 45)
+	require.Len(t, paths, 67)
 
 	ac := acmock.New()
 	api := &API{AccessControl: ac}
@@ -71,6 +77,78 @@ func TestAuthorize(t *testing.T) {
 	})
 }
 
+func TestAuthorizeAPIKeys(t *testing.T) {
+	api := &API{AccessControl: acmock.New().WithDisabled()}
+	isAllowed := func(t *testing.T, method, path string, role org.RoleType) bool {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		c := &contextmodel.ReqContext{
+			Context:      &web.Context{Req: req, Resp: web.NewResponseWriter(method, httptest.NewRecorder())},
+			IsSignedIn:   true,
+			SignedInUser: &user.SignedInUser{ApiKeyID: 1, OrgID: 1, OrgRole: role},
+		}
+		handler, ok := api.authorize(method, path).(func(*contextmodel.ReqContext))
+		require.True(t, ok)
+		handler(c)
+		return !c.Resp.Written()
+	}
+
+	testCases := []struct {
+		name    string
+		method  string
+		path    string
+		allowed []org.RoleType
+	}{
+		{
+			name:    "rules are read by any key",
+			method:  http.MethodGet,
+			path:    "/api/ruler/grafana/api/v1/rules",
+			allowed: []org.RoleType{org.RoleViewer, org.RoleEditor, org.RoleAdmin},
+		},
+		{
+			name:    "rules are changed by any key, the folder of the rules is checked by the handler",
+			method:  http.MethodPost,
+			path:    "/api/ruler/grafana/api/v1/rules/{Namespace}",
+			allowed: []org.RoleType{org.RoleViewer, org.RoleEditor, org.RoleAdmin},
+		},
+		{
+			name:    "silences are created by editor keys",
+			method:  http.MethodPost,
+			path:    "/api/alertmanager/grafana/api/v2/silences",
+			allowed: []org.RoleType{org.RoleEditor, org.RoleAdmin},
+		},
+		{
+			name:    "rules are provisioned by admin keys",
+			method:  http.MethodPost,
+			path:    "/api/v1/provisioning/alert-rules",
+			allowed: []org.RoleType{org.RoleAdmin},
+		},
+		{
+			name:    "the admin configuration is changed by admin keys",
+			method:  http.MethodPost,
+			path:    "/api/v1/ngalert/admin_config",
+			allowed: []org.RoleType{org.RoleAdmin},
+		},
+		{
+			name:   "the scheduler of all organizations is not read by any key",
+			method: http.MethodGet,
+			path:   "/api/v1/ngalert/scheduler",
+		},
+		{
+			name:   "the evaluation of all organizations is not paused by any key",
+			method: http.MethodPost,
+			path:   "/api/v1/ngalert/scheduler/pause",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, role := range []org.RoleType{org.RoleViewer, org.RoleEditor, org.RoleAdmin} {
+				require.Equalf(t, slices.Contains(tc.allowed, role), isAllowed(t, tc.method, tc.path, role), "role %s", role)
+			}
+		})
+	}
+}
+
 func createAllCombinationsOfPermissions(permissions map[string][]string) []map[string][]string {
 	type actionscope struct {
 		action string
@@ -450,6 +528,42 @@ func TestCheckDatasourcePermissionsForRule(t *testing.T) {
 	})
 }
 
+func TestAuthorizeDatasourceAccessForQueries(t *testing.T) {
+	allowed := models.GenerateAlertQuery()
+	denied := models.GenerateAlertQuery()
+	permissions := map[string][]string{
+		datasources.ActionQuery: {datasources.ScopeProvider.GetResourceScopeUID(allowed.DatasourceUID)},
+	}
+	evaluator := func(evaluator ac.Evaluator) bool {
+		return evaluator.Evaluate(permissions)
+	}
+
+	t.Run("should return nil if user can query all data sources", func(t *testing.T) {
+		require.NoError(t, authorizeDatasourceAccessForQueries([]models.AlertQuery{allowed}, evaluator))
+	})
+
+	t.Run("should return error naming the data source the user cannot query", func(t *testing.T) {
+		err := authorizeDatasourceAccessForQueries([]models.AlertQuery{allowed, denied}, evaluator)
+		require.ErrorIs(t, err, ErrDatasourceAuthorization)
+		require.ErrorIs(t, err, ErrAuthorization)
+		require.Contains(t, err.Error(), denied.DatasourceUID)
+	})
+
+	t.Run("should name the data source when a new rule uses it", func(t *testing.T) {
+		groupKey := models.GenerateGroupKey(rand.Int63())
+		rule := models.AlertRuleGen(withGroupKey(groupKey))()
+		rule.Data = []models.AlertQuery{allowed, denied}
+		err := authorizeRuleChanges(&store.GroupDelta{GroupKey: groupKey, New: []*models.AlertRule{rule}}, func(e ac.Evaluator) bool {
+			return e.Evaluate(map[string][]string{
+				ac.ActionAlertingRuleCreate: {dashboards.ScopeFoldersProvider.GetResourceScopeUID(groupKey.NamespaceUID)},
+				datasources.ActionQuery:     permissions[datasources.ActionQuery],
+			})
+		})
+		require.ErrorIs(t, err, ErrDatasourceAuthorization)
+		require.Contains(t, err.Error(), denied.DatasourceUID)
+	})
+}
+
 func Test_authorizeAccessToRuleGroup(t *testing.T) {
 	t.Run("should return true if user has access to all datasources of all rules in group", func(t *testing.T) {
 		rules := models.GenerateAlertRules(rand.Intn(4)+1, models.AlertRuleGen())
@@ -12,43 +12,59 @@ import (
 // AlertRuleFromProvisionedAlertRule converts definitions.ProvisionedAlertRule to models.AlertRule
 func AlertRuleFromProvisionedAlertRule(a definitions.ProvisionedAlertRule) (models.AlertRule, error) {
 	return models.AlertRule{
-		ID:           a.ID,
-		UID:          a.UID,
-		OrgID:        a.OrgID,
-		NamespaceUID: a.FolderUID,
-		RuleGroup:    a.RuleGroup,
-		Title:        a.Title,
-		Condition:    a.Condition,
-		Data:         AlertQueriesFromApiAlertQueries(a.Data),
-		Updated:      a.Updated,
-		NoDataState:  models.NoDataState(a.NoDataState),          // TODO there must be a validation
-		ExecErrState: models.ExecutionErrorState(a.ExecErrState), // TODO there must be a validation
-		For:          time.Duration(a.For),
-		Annotations:  a.Annotations,
-		Labels:       a.Labels,
-		IsPaused:     a.IsPaused,
+		ID:                         a.ID,
+		UID:                        a.UID,
+		OrgID:                      a.OrgID,
+		NamespaceUID:               a.FolderUID,
+		RuleGroup:                  a.RuleGroup,
+		Title:                      a.Title,
+		Condition:                  a.Condition,
+		Data:                       AlertQueriesFromApiAlertQueries(a.Data),
+		Updated:                    a.Updated,
+		NoDataState:                models.NoDataState(a.NoDataState),          // TODO there must be a validation
+		ExecErrState:               models.ExecutionErrorState(a.ExecErrState), // TODO there must be a validation
+		For:                        time.Duration(a.For),
+		Annotations:                a.Annotations,
+		Labels:                     a.Labels,
+		IsPaused:                   a.IsPaused,
+		Schedule:                   a.Schedule,
+		ScheduleTimezone:           a.ScheduleTimezone,
+		InstanceLimit:              a.InstanceLimit,
+		ContactPointUIDs:           a.ContactPointUIDs,
+		NotificationTitle:          a.NotificationTitle,
+		NotificationMessage:        a.NotificationMessage,
+		NotificationRepeatInterval: time.Duration(a.NotificationRepeatInterval),
+		SendResolved:               a.SendResolved == nil || *a.SendResolved,
 	}, nil
 }
 
 // ProvisionedAlertRuleFromAlertRule converts models.AlertRule to definitions.ProvisionedAlertRule and sets provided provenance status
 func ProvisionedAlertRuleFromAlertRule(rule models.AlertRule, provenance models.Provenance) definitions.ProvisionedAlertRule {
 	return definitions.ProvisionedAlertRule{
-		ID:           rule.ID,
-		UID:          rule.UID,
-		OrgID:        rule.OrgID,
-		FolderUID:    rule.NamespaceUID,
-		RuleGroup:    rule.RuleGroup,
-		Title:        rule.Title,
-		For:          model.Duration(rule.For),
-		Condition:    rule.Condition,
-		Data:         ApiAlertQueriesFromAlertQueries(rule.Data),
-		Updated:      rule.Updated,
-		NoDataState:  definitions.NoDataState(rule.NoDataState),          // TODO there may be a validation
-		ExecErrState: definitions.ExecutionErrorState(rule.ExecErrState), // TODO there may be a validation
-		Annotations:  rule.Annotations,
-		Labels:       rule.Labels,
-		Provenance:   definitions.Provenance(provenance), // TODO validate enum conversion?
-		IsPaused:     rule.IsPaused,
+		ID:                         rule.ID,
+		UID:                        rule.UID,
+		OrgID:                      rule.OrgID,
+		FolderUID:                  rule.NamespaceUID,
+		RuleGroup:                  rule.RuleGroup,
+		Title:                      rule.Title,
+		For:                        model.Duration(rule.For),
+		Condition:                  rule.Condition,
+		Data:                       ApiAlertQueriesFromAlertQueries(rule.Data),
+		Updated:                    rule.Updated,
+		NoDataState:                definitions.NoDataState(rule.NoDataState),          // TODO there may be a validation
+		ExecErrState:               definitions.ExecutionErrorState(rule.ExecErrState), // TODO there may be a validation
+		Annotations:                rule.Annotations,
+		Labels:                     rule.Labels,
+		Provenance:                 definitions.Provenance(provenance), // TODO validate enum conversion?
+		IsPaused:                   rule.IsPaused,
+		Schedule:                   rule.Schedule,
+		ScheduleTimezone:           rule.ScheduleTimezone,
+		InstanceLimit:              rule.InstanceLimit,
+		ContactPointUIDs:           rule.ContactPointUIDs,
+		NotificationTitle:          rule.NotificationTitle,
+		NotificationMessage:        rule.NotificationMessage,
+		NotificationRepeatInterval: model.Duration(rule.NotificationRepeatInterval),
+		SendResolved:               &rule.SendResolved,
 	}
 }
 
@@ -61,6 +77,35 @@ func ProvisionedAlertRuleFromAlertRules(rules []*models.AlertRule) definitions.P
 	return result
 }
 
+// ProvisionedAlertRuleSummariesFromAlertRuleSummaries converts the summaries of alert rules and the number of alert
+// instances per state of the rules by rule UID to definitions.ProvisionedAlertRuleSummaries.
+func ProvisionedAlertRuleSummariesFromAlertRuleSummaries(rules []*models.AlertRuleSummary, states map[string]*models.AlertInstanceStateSummary) definitions.ProvisionedAlertRuleSummaries {
+	result := make([]definitions.ProvisionedAlertRuleSummary, 0, len(rules))
+	for _, r := range rules {
+		summary := states[r.UID]
+		stateSummary := &definitions.InstanceStateSummary{
+			WorstState: string(summary.WorstState()),
+			Counts:     map[string]int64{},
+		}
+		if summary != nil {
+			for state, count := range summary.Counts {
+				stateSummary.Counts[string(state)] = count
+			}
+		}
+		result = append(result, definitions.ProvisionedAlertRuleSummary{
+			UID:          r.UID,
+			FolderUID:    r.NamespaceUID,
+			RuleGroup:    r.RuleGroup,
+			Title:        r.Title,
+			Interval:     r.IntervalSeconds,
+			IsPaused:     r.IsPaused,
+			Updated:      r.Updated,
+			StateSummary: stateSummary,
+		})
+	}
+	return result
+}
+
 // AlertQueriesFromApiAlertQueries converts a collection of definitions.AlertQuery to collection of models.AlertQuery
 func AlertQueriesFromApiAlertQueries(queries []definitions.AlertQuery) []models.AlertQuery {
 	result := make([]models.AlertQuery, 0, len(queries))
@@ -125,3 +170,67 @@ func ApiAlertRuleGroupFromAlertRuleGroup(d models.AlertRuleGroup) definitions.Al
 		Rules:     rules,
 	}
 }
+
+func MaintenanceWindowFromApiMaintenanceWindow(w definitions.MaintenanceWindow) models.MaintenanceWindow {
+	return models.MaintenanceWindow{
+		ID:         w.ID,
+		Title:      w.Title,
+		Start:      w.Start,
+		End:        w.End,
+		Recurrence: models.MaintenanceWindowRecurrence(w.Recurrence),
+	}
+}
+
+func ApiMaintenanceWindowFromMaintenanceWindow(w models.MaintenanceWindow) definitions.MaintenanceWindow {
+	return definitions.MaintenanceWindow{
+		ID:         w.ID,
+		Title:      w.Title,
+		Start:      w.Start,
+		End:        w.End,
+		Recurrence: string(w.Recurrence),
+		CreatedBy:  w.CreatedBy,
+		Updated:    w.Updated,
+	}
+}
+
+func SilenceFromApiSilence(s definitions.Silence) models.Silence {
+	matchers := make([]models.SilenceMatcher, 0, len(s.Matchers))
+	for _, m := range s.Matchers {
+		matchers = append(matchers, models.SilenceMatcher{
+			Name:  m.Name,
+			Value: m.Value,
+			Type:  models.SilenceMatchType(m.Type),
+		})
+	}
+	return models.Silence{
+		ID:       s.ID,
+		Matchers: matchers,
+		Start:    s.StartsAt,
+		End:      s.EndsAt,
+		Comment:  s.Comment,
+	}
+}
+
+func ApiSilenceFromSilence(s models.Silence) definitions.Silence {
+	matchers := make([]definitions.SilenceMatcher, 0, len(s.Matchers))
+	for _, m := range s.Matchers {
+		t := m.Type
+		if t == "" {
+			t = models.SilenceMatchEqual
+		}
+		matchers = append(matchers, definitions.SilenceMatcher{
+			Name:  m.Name,
+			Value: m.Value,
+			Type:  string(t),
+		})
+	}
+	return definitions.Silence{
+		ID:        s.ID,
+		Matchers:  matchers,
+		StartsAt:  s.Start,
+		EndsAt:    s.End,
+		Comment:   s.Comment,
+		CreatedBy: s.CreatedBy,
+		Updated:   s.Updated,
+	}
+}
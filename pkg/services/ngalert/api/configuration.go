@@ -36,3 +36,19 @@ func (f *ConfigurationApiHandler) handleRouteDeleteNGalertConfig(c *contextmodel
 func (f *ConfigurationApiHandler) handleRouteGetStatus(c *contextmodel.ReqContext) response.Response {
 	return f.grafana.RouteGetAlertingStatus(c)
 }
+
+func (f *ConfigurationApiHandler) handleRouteGetSchedulerStatus(c *contextmodel.ReqContext) response.Response {
+	return f.grafana.RouteGetSchedulerStatus(c)
+}
+
+func (f *ConfigurationApiHandler) handleRouteGetShadowModeReport(c *contextmodel.ReqContext) response.Response {
+	return f.grafana.RouteGetShadowModeReport(c)
+}
+
+func (f *ConfigurationApiHandler) handleRoutePostPauseEvaluation(c *contextmodel.ReqContext) response.Response {
+	return f.grafana.RoutePostPauseEvaluation(c)
+}
+
+func (f *ConfigurationApiHandler) handleRoutePostResumeEvaluation(c *contextmodel.ReqContext) response.Response {
+	return f.grafana.RoutePostResumeEvaluation(c)
+}
@@ -31,6 +31,9 @@ func errorToResponse(err error) response.Response {
 	if errors.Is(err, errUnexpectedDatasourceType) {
 		return ErrResp(400, err, "")
 	}
+	if errors.Is(err, ErrDatasourceAuthorization) {
+		return ErrResp(403, err, "")
+	}
 	if errors.Is(err, ErrAuthorization) {
 		return ErrResp(401, err, "")
 	}
@@ -89,10 +89,46 @@ func (f *RulerApiHandler) handleRouteGetGrafanaRuleGroupConfig(ctx *contextmodel
 	return f.GrafanaRuler.RouteGetRulesGroupConfig(ctx, namespace, group)
 }
 
+func (f *RulerApiHandler) handleRouteGetGrafanaRuleAudit(ctx *contextmodel.ReqContext, ruleUID string) response.Response {
+	return f.GrafanaRuler.RouteGetRuleAudit(ctx, ruleUID)
+}
+
+func (f *RulerApiHandler) handleRouteGetGrafanaRuleEvaluation(ctx *contextmodel.ReqContext, ruleUID string) response.Response {
+	return f.GrafanaRuler.RouteGetAlertRuleEvaluation(ctx, ruleUID)
+}
+
+func (f *RulerApiHandler) handleRouteGetGrafanaRuleInstances(ctx *contextmodel.ReqContext, ruleUID string) response.Response {
+	return f.GrafanaRuler.RouteGetRuleInstances(ctx, ruleUID)
+}
+
 func (f *RulerApiHandler) handleRouteGetGrafanaRulesConfig(ctx *contextmodel.ReqContext) response.Response {
 	return f.GrafanaRuler.RouteGetRulesConfig(ctx)
 }
 
+func (f *RulerApiHandler) handleRoutePostGrafanaRuleEvaluation(ctx *contextmodel.ReqContext, ruleUID string) response.Response {
+	return f.GrafanaRuler.RouteEvaluateAlertRule(ctx, ruleUID)
+}
+
+func (f *RulerApiHandler) handleRoutePostGrafanaRulePause(ctx *contextmodel.ReqContext, ruleUID string) response.Response {
+	return f.GrafanaRuler.RoutePauseAlertRule(ctx, ruleUID)
+}
+
+func (f *RulerApiHandler) handleRoutePostGrafanaRuleReset(ctx *contextmodel.ReqContext, ruleUID string) response.Response {
+	return f.GrafanaRuler.RouteResetAlertRuleInstances(ctx, ruleUID)
+}
+
+func (f *RulerApiHandler) handleRoutePostGrafanaRuleTestNotification(ctx *contextmodel.ReqContext, body apimodels.PostableTestRuleNotification, ruleUID string) response.Response {
+	return f.GrafanaRuler.RouteTestAlertRuleNotification(ctx, ruleUID, body)
+}
+
+func (f *RulerApiHandler) handleRoutePostGrafanaRuleUnpause(ctx *contextmodel.ReqContext, ruleUID string) response.Response {
+	return f.GrafanaRuler.RouteUnpauseAlertRule(ctx, ruleUID)
+}
+
+func (f *RulerApiHandler) handleRoutePostGrafanaRulesBulk(ctx *contextmodel.ReqContext, action apimodels.PostableBulkRuleAction) response.Response {
+	return f.GrafanaRuler.RouteBulkAlertRules(ctx, action)
+}
+
 func (f *RulerApiHandler) handleRoutePostNameGrafanaRulesConfig(ctx *contextmodel.ReqContext, conf apimodels.PostableRuleGroupConfig, namespace string) response.Response {
 	payloadType := conf.Type()
 	if payloadType != apimodels.GrafanaBackend {
@@ -101,6 +137,49 @@ func (f *RulerApiHandler) handleRoutePostNameGrafanaRulesConfig(ctx *contextmode
 	return f.GrafanaRuler.RoutePostNameRulesConfig(ctx, conf, namespace)
 }
 
+func (f *RulerApiHandler) handleRouteDeleteNamespaceGrafanaPrometheusRulesConfig(ctx *contextmodel.ReqContext, dsUID, namespace string) response.Response {
+	if _, err := getDatasourceByUID(ctx, f.DatasourceCache, apimodels.LoTexRulerBackend); err != nil {
+		return errorToResponse(err)
+	}
+	return f.GrafanaRuler.RouteDeleteAlertRules(ctx, namespace, "")
+}
+
+func (f *RulerApiHandler) handleRouteDeleteGrafanaPrometheusRuleGroupConfig(ctx *contextmodel.ReqContext, dsUID, namespace, groupName string) response.Response {
+	if _, err := getDatasourceByUID(ctx, f.DatasourceCache, apimodels.LoTexRulerBackend); err != nil {
+		return errorToResponse(err)
+	}
+	return f.GrafanaRuler.RouteDeleteAlertRules(ctx, namespace, groupName)
+}
+
+func (f *RulerApiHandler) handleRouteGetGrafanaPrometheusRulesConfig(ctx *contextmodel.ReqContext, dsUID string) response.Response {
+	if _, err := getDatasourceByUID(ctx, f.DatasourceCache, apimodels.LoTexRulerBackend); err != nil {
+		return errorToResponse(err)
+	}
+	return f.GrafanaRuler.RouteGetPrometheusRulesConfig(ctx)
+}
+
+func (f *RulerApiHandler) handleRouteGetNamespaceGrafanaPrometheusRulesConfig(ctx *contextmodel.ReqContext, dsUID, namespace string) response.Response {
+	if _, err := getDatasourceByUID(ctx, f.DatasourceCache, apimodels.LoTexRulerBackend); err != nil {
+		return errorToResponse(err)
+	}
+	return f.GrafanaRuler.RouteGetNamespacePrometheusRulesConfig(ctx, namespace)
+}
+
+func (f *RulerApiHandler) handleRouteGetGrafanaPrometheusRuleGroupConfig(ctx *contextmodel.ReqContext, dsUID, namespace, group string) response.Response {
+	if _, err := getDatasourceByUID(ctx, f.DatasourceCache, apimodels.LoTexRulerBackend); err != nil {
+		return errorToResponse(err)
+	}
+	return f.GrafanaRuler.RouteGetPrometheusRuleGroupConfig(ctx, namespace, group)
+}
+
+func (f *RulerApiHandler) handleRoutePostNameGrafanaPrometheusRulesConfig(ctx *contextmodel.ReqContext, dsUID, namespace string) response.Response {
+	ds, err := getDatasourceByUID(ctx, f.DatasourceCache, apimodels.LoTexRulerBackend)
+	if err != nil {
+		return errorToResponse(err)
+	}
+	return f.GrafanaRuler.RoutePostNamePrometheusRulesConfig(ctx, ds.UID, namespace)
+}
+
 func (f *RulerApiHandler) getService(ctx *contextmodel.ReqContext) (*LotexRuler, error) {
 	_, err := getDatasourceByUID(ctx, f.DatasourceCache, apimodels.LoTexRulerBackend)
 	if err != nil {
@@ -22,8 +22,12 @@ type ConfigurationApi interface {
 	RouteDeleteNGalertConfig(*contextmodel.ReqContext) response.Response
 	RouteGetAlertmanagers(*contextmodel.ReqContext) response.Response
 	RouteGetNGalertConfig(*contextmodel.ReqContext) response.Response
+	RouteGetSchedulerStatus(*contextmodel.ReqContext) response.Response
+	RouteGetShadowModeReport(*contextmodel.ReqContext) response.Response
 	RouteGetStatus(*contextmodel.ReqContext) response.Response
 	RoutePostNGalertConfig(*contextmodel.ReqContext) response.Response
+	RoutePostPauseEvaluation(*contextmodel.ReqContext) response.Response
+	RoutePostResumeEvaluation(*contextmodel.ReqContext) response.Response
 }
 
 func (f *ConfigurationApiHandler) RouteDeleteNGalertConfig(ctx *contextmodel.ReqContext) response.Response {
@@ -35,6 +39,12 @@ func (f *ConfigurationApiHandler) RouteGetAlertmanagers(ctx *contextmodel.ReqCon
 func (f *ConfigurationApiHandler) RouteGetNGalertConfig(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetNGalertConfig(ctx)
 }
+func (f *ConfigurationApiHandler) RouteGetSchedulerStatus(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetSchedulerStatus(ctx)
+}
+func (f *ConfigurationApiHandler) RouteGetShadowModeReport(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetShadowModeReport(ctx)
+}
 func (f *ConfigurationApiHandler) RouteGetStatus(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetStatus(ctx)
 }
@@ -46,6 +56,12 @@ func (f *ConfigurationApiHandler) RoutePostNGalertConfig(ctx *contextmodel.ReqCo
 	}
 	return f.handleRoutePostNGalertConfig(ctx, conf)
 }
+func (f *ConfigurationApiHandler) RoutePostPauseEvaluation(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRoutePostPauseEvaluation(ctx)
+}
+func (f *ConfigurationApiHandler) RoutePostResumeEvaluation(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRoutePostResumeEvaluation(ctx)
+}
 
 func (api *API) RegisterConfigurationApiEndpoints(srv ConfigurationApi, m *metrics.API) {
 	api.RouteRegister.Group("", func(group routing.RouteRegister) {
@@ -79,6 +95,26 @@ func (api *API) RegisterConfigurationApiEndpoints(srv ConfigurationApi, m *metri
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/ngalert/scheduler"),
+			api.authorize(http.MethodGet, "/api/v1/ngalert/scheduler"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/ngalert/scheduler",
+				srv.RouteGetSchedulerStatus,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/ngalert/scheduler/shadow"),
+			api.authorize(http.MethodGet, "/api/v1/ngalert/scheduler/shadow"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/ngalert/scheduler/shadow",
+				srv.RouteGetShadowModeReport,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/ngalert"),
 			api.authorize(http.MethodGet, "/api/v1/ngalert"),
@@ -99,5 +135,25 @@ func (api *API) RegisterConfigurationApiEndpoints(srv ConfigurationApi, m *metri
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/ngalert/scheduler/pause"),
+			api.authorize(http.MethodPost, "/api/v1/ngalert/scheduler/pause"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/ngalert/scheduler/pause",
+				srv.RoutePostPauseEvaluation,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/ngalert/scheduler/resume"),
+			api.authorize(http.MethodPost, "/api/v1/ngalert/scheduler/resume"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/ngalert/scheduler/resume",
+				srv.RoutePostResumeEvaluation,
+				m,
+			),
+		)
 	}, middleware.ReqSignedIn)
 }
@@ -21,28 +21,43 @@ import (
 type ProvisioningApi interface {
 	RouteDeleteAlertRule(*contextmodel.ReqContext) response.Response
 	RouteDeleteContactpoints(*contextmodel.ReqContext) response.Response
+	RouteDeleteMaintenanceWindow(*contextmodel.ReqContext) response.Response
 	RouteDeleteMuteTiming(*contextmodel.ReqContext) response.Response
+	RouteDeleteSilence(*contextmodel.ReqContext) response.Response
 	RouteDeleteTemplate(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRule(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRuleExport(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRuleGroup(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRuleGroupExport(*contextmodel.ReqContext) response.Response
+	RouteGetAlertRuleSummaries(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRules(*contextmodel.ReqContext) response.Response
 	RouteGetAlertRulesExport(*contextmodel.ReqContext) response.Response
+	RouteGetAlertRulesPrometheusExport(*contextmodel.ReqContext) response.Response
 	RouteGetContactpoints(*contextmodel.ReqContext) response.Response
+	RouteGetMaintenanceWindow(*contextmodel.ReqContext) response.Response
+	RouteGetMaintenanceWindows(*contextmodel.ReqContext) response.Response
 	RouteGetMuteTiming(*contextmodel.ReqContext) response.Response
 	RouteGetMuteTimings(*contextmodel.ReqContext) response.Response
 	RouteGetPolicyTree(*contextmodel.ReqContext) response.Response
+	RouteGetSilence(*contextmodel.ReqContext) response.Response
+	RouteGetSilences(*contextmodel.ReqContext) response.Response
 	RouteGetTemplate(*contextmodel.ReqContext) response.Response
 	RouteGetTemplates(*contextmodel.ReqContext) response.Response
+	RoutePatchAlertRule(*contextmodel.ReqContext) response.Response
 	RoutePostAlertRule(*contextmodel.ReqContext) response.Response
+	RoutePostAlertRuleDiff(*contextmodel.ReqContext) response.Response
+	RoutePostAlertRulesPrometheusImport(*contextmodel.ReqContext) response.Response
 	RoutePostContactpoints(*contextmodel.ReqContext) response.Response
+	RoutePostMaintenanceWindow(*contextmodel.ReqContext) response.Response
 	RoutePostMuteTiming(*contextmodel.ReqContext) response.Response
+	RoutePostSilence(*contextmodel.ReqContext) response.Response
 	RoutePutAlertRule(*contextmodel.ReqContext) response.Response
 	RoutePutAlertRuleGroup(*contextmodel.ReqContext) response.Response
 	RoutePutContactpoint(*contextmodel.ReqContext) response.Response
+	RoutePutMaintenanceWindow(*contextmodel.ReqContext) response.Response
 	RoutePutMuteTiming(*contextmodel.ReqContext) response.Response
 	RoutePutPolicyTree(*contextmodel.ReqContext) response.Response
+	RoutePutSilence(*contextmodel.ReqContext) response.Response
 	RoutePutTemplate(*contextmodel.ReqContext) response.Response
 	RouteResetPolicyTree(*contextmodel.ReqContext) response.Response
 }
@@ -57,11 +72,21 @@ func (f *ProvisioningApiHandler) RouteDeleteContactpoints(ctx *contextmodel.ReqC
 	uIDParam := web.Params(ctx.Req)[":UID"]
 	return f.handleRouteDeleteContactpoints(ctx, uIDParam)
 }
+func (f *ProvisioningApiHandler) RouteDeleteMaintenanceWindow(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	iDParam := web.Params(ctx.Req)[":ID"]
+	return f.handleRouteDeleteMaintenanceWindow(ctx, iDParam)
+}
 func (f *ProvisioningApiHandler) RouteDeleteMuteTiming(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
 	return f.handleRouteDeleteMuteTiming(ctx, nameParam)
 }
+func (f *ProvisioningApiHandler) RouteDeleteSilence(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	iDParam := web.Params(ctx.Req)[":ID"]
+	return f.handleRouteDeleteSilence(ctx, iDParam)
+}
 func (f *ProvisioningApiHandler) RouteDeleteTemplate(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -89,15 +114,29 @@ func (f *ProvisioningApiHandler) RouteGetAlertRuleGroupExport(ctx *contextmodel.
 	groupParam := web.Params(ctx.Req)[":Group"]
 	return f.handleRouteGetAlertRuleGroupExport(ctx, folderUIDParam, groupParam)
 }
+func (f *ProvisioningApiHandler) RouteGetAlertRuleSummaries(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetAlertRuleSummaries(ctx)
+}
 func (f *ProvisioningApiHandler) RouteGetAlertRules(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetAlertRules(ctx)
 }
 func (f *ProvisioningApiHandler) RouteGetAlertRulesExport(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetAlertRulesExport(ctx)
 }
+func (f *ProvisioningApiHandler) RouteGetAlertRulesPrometheusExport(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetAlertRulesPrometheusExport(ctx)
+}
 func (f *ProvisioningApiHandler) RouteGetContactpoints(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetContactpoints(ctx)
 }
+func (f *ProvisioningApiHandler) RouteGetMaintenanceWindow(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	iDParam := web.Params(ctx.Req)[":ID"]
+	return f.handleRouteGetMaintenanceWindow(ctx, iDParam)
+}
+func (f *ProvisioningApiHandler) RouteGetMaintenanceWindows(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetMaintenanceWindows(ctx)
+}
 func (f *ProvisioningApiHandler) RouteGetMuteTiming(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -109,6 +148,14 @@ func (f *ProvisioningApiHandler) RouteGetMuteTimings(ctx *contextmodel.ReqContex
 func (f *ProvisioningApiHandler) RouteGetPolicyTree(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetPolicyTree(ctx)
 }
+func (f *ProvisioningApiHandler) RouteGetSilence(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	iDParam := web.Params(ctx.Req)[":ID"]
+	return f.handleRouteGetSilence(ctx, iDParam)
+}
+func (f *ProvisioningApiHandler) RouteGetSilences(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteGetSilences(ctx)
+}
 func (f *ProvisioningApiHandler) RouteGetTemplate(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -117,6 +164,16 @@ func (f *ProvisioningApiHandler) RouteGetTemplate(ctx *contextmodel.ReqContext)
 func (f *ProvisioningApiHandler) RouteGetTemplates(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetTemplates(ctx)
 }
+func (f *ProvisioningApiHandler) RoutePatchAlertRule(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	// Parse Request Body
+	conf := apimodels.PatchedAlertRule{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePatchAlertRule(ctx, conf, uIDParam)
+}
 func (f *ProvisioningApiHandler) RoutePostAlertRule(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Request Body
 	conf := apimodels.ProvisionedAlertRule{}
@@ -125,6 +182,19 @@ func (f *ProvisioningApiHandler) RoutePostAlertRule(ctx *contextmodel.ReqContext
 	}
 	return f.handleRoutePostAlertRule(ctx, conf)
 }
+func (f *ProvisioningApiHandler) RoutePostAlertRuleDiff(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	uIDParam := web.Params(ctx.Req)[":UID"]
+	// Parse Request Body
+	conf := apimodels.ProvisionedAlertRule{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostAlertRuleDiff(ctx, conf, uIDParam)
+}
+func (f *ProvisioningApiHandler) RoutePostAlertRulesPrometheusImport(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRoutePostAlertRulesPrometheusImport(ctx)
+}
 func (f *ProvisioningApiHandler) RoutePostContactpoints(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Request Body
 	conf := apimodels.EmbeddedContactPoint{}
@@ -133,6 +203,14 @@ func (f *ProvisioningApiHandler) RoutePostContactpoints(ctx *contextmodel.ReqCon
 	}
 	return f.handleRoutePostContactpoints(ctx, conf)
 }
+func (f *ProvisioningApiHandler) RoutePostMaintenanceWindow(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.MaintenanceWindow{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostMaintenanceWindow(ctx, conf)
+}
 func (f *ProvisioningApiHandler) RoutePostMuteTiming(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Request Body
 	conf := apimodels.MuteTimeInterval{}
@@ -141,6 +219,14 @@ func (f *ProvisioningApiHandler) RoutePostMuteTiming(ctx *contextmodel.ReqContex
 	}
 	return f.handleRoutePostMuteTiming(ctx, conf)
 }
+func (f *ProvisioningApiHandler) RoutePostSilence(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.Silence{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostSilence(ctx, conf)
+}
 func (f *ProvisioningApiHandler) RoutePutAlertRule(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	uIDParam := web.Params(ctx.Req)[":UID"]
@@ -172,6 +258,16 @@ func (f *ProvisioningApiHandler) RoutePutContactpoint(ctx *contextmodel.ReqConte
 	}
 	return f.handleRoutePutContactpoint(ctx, conf, uIDParam)
 }
+func (f *ProvisioningApiHandler) RoutePutMaintenanceWindow(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	iDParam := web.Params(ctx.Req)[":ID"]
+	// Parse Request Body
+	conf := apimodels.MaintenanceWindow{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePutMaintenanceWindow(ctx, conf, iDParam)
+}
 func (f *ProvisioningApiHandler) RoutePutMuteTiming(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -190,6 +286,16 @@ func (f *ProvisioningApiHandler) RoutePutPolicyTree(ctx *contextmodel.ReqContext
 	}
 	return f.handleRoutePutPolicyTree(ctx, conf)
 }
+func (f *ProvisioningApiHandler) RoutePutSilence(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	iDParam := web.Params(ctx.Req)[":ID"]
+	// Parse Request Body
+	conf := apimodels.Silence{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePutSilence(ctx, conf, iDParam)
+}
 func (f *ProvisioningApiHandler) RoutePutTemplate(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	nameParam := web.Params(ctx.Req)[":name"]
@@ -226,6 +332,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Delete(
+			toMacaronPath("/api/v1/provisioning/maintenance-windows/{ID}"),
+			api.authorize(http.MethodDelete, "/api/v1/provisioning/maintenance-windows/{ID}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/v1/provisioning/maintenance-windows/{ID}",
+				srv.RouteDeleteMaintenanceWindow,
+				m,
+			),
+		)
 		group.Delete(
 			toMacaronPath("/api/v1/provisioning/mute-timings/{name}"),
 			api.authorize(http.MethodDelete, "/api/v1/provisioning/mute-timings/{name}"),
@@ -236,6 +352,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Delete(
+			toMacaronPath("/api/v1/provisioning/silences/{ID}"),
+			api.authorize(http.MethodDelete, "/api/v1/provisioning/silences/{ID}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/v1/provisioning/silences/{ID}",
+				srv.RouteDeleteSilence,
+				m,
+			),
+		)
 		group.Delete(
 			toMacaronPath("/api/v1/provisioning/templates/{name}"),
 			api.authorize(http.MethodDelete, "/api/v1/provisioning/templates/{name}"),
@@ -286,6 +412,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/alert-rules/summaries"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/alert-rules/summaries"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/alert-rules/summaries",
+				srv.RouteGetAlertRuleSummaries,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/provisioning/alert-rules"),
 			api.authorize(http.MethodGet, "/api/v1/provisioning/alert-rules"),
@@ -306,6 +442,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/alert-rules/export/prometheus"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/alert-rules/export/prometheus"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/alert-rules/export/prometheus",
+				srv.RouteGetAlertRulesPrometheusExport,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/provisioning/contact-points"),
 			api.authorize(http.MethodGet, "/api/v1/provisioning/contact-points"),
@@ -316,6 +462,26 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/maintenance-windows/{ID}"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/maintenance-windows/{ID}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/maintenance-windows/{ID}",
+				srv.RouteGetMaintenanceWindow,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/maintenance-windows"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/maintenance-windows"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/maintenance-windows",
+				srv.RouteGetMaintenanceWindows,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/provisioning/mute-timings/{name}"),
 			api.authorize(http.MethodGet, "/api/v1/provisioning/mute-timings/{name}"),
@@ -346,6 +512,26 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/silences/{ID}"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/silences/{ID}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/silences/{ID}",
+				srv.RouteGetSilence,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/v1/provisioning/silences"),
+			api.authorize(http.MethodGet, "/api/v1/provisioning/silences"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/v1/provisioning/silences",
+				srv.RouteGetSilences,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/v1/provisioning/templates/{name}"),
 			api.authorize(http.MethodGet, "/api/v1/provisioning/templates/{name}"),
@@ -366,6 +552,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Patch(
+			toMacaronPath("/api/v1/provisioning/alert-rules/{UID}"),
+			api.authorize(http.MethodPatch, "/api/v1/provisioning/alert-rules/{UID}"),
+			metrics.Instrument(
+				http.MethodPatch,
+				"/api/v1/provisioning/alert-rules/{UID}",
+				srv.RoutePatchAlertRule,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/v1/provisioning/alert-rules"),
 			api.authorize(http.MethodPost, "/api/v1/provisioning/alert-rules"),
@@ -376,6 +572,26 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/provisioning/alert-rules/{UID}/diff"),
+			api.authorize(http.MethodPost, "/api/v1/provisioning/alert-rules/{UID}/diff"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/provisioning/alert-rules/{UID}/diff",
+				srv.RoutePostAlertRuleDiff,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/v1/provisioning/alert-rules/import/prometheus"),
+			api.authorize(http.MethodPost, "/api/v1/provisioning/alert-rules/import/prometheus"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/provisioning/alert-rules/import/prometheus",
+				srv.RoutePostAlertRulesPrometheusImport,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/v1/provisioning/contact-points"),
 			api.authorize(http.MethodPost, "/api/v1/provisioning/contact-points"),
@@ -386,6 +602,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/provisioning/maintenance-windows"),
+			api.authorize(http.MethodPost, "/api/v1/provisioning/maintenance-windows"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/provisioning/maintenance-windows",
+				srv.RoutePostMaintenanceWindow,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/v1/provisioning/mute-timings"),
 			api.authorize(http.MethodPost, "/api/v1/provisioning/mute-timings"),
@@ -396,6 +622,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/v1/provisioning/silences"),
+			api.authorize(http.MethodPost, "/api/v1/provisioning/silences"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/v1/provisioning/silences",
+				srv.RoutePostSilence,
+				m,
+			),
+		)
 		group.Put(
 			toMacaronPath("/api/v1/provisioning/alert-rules/{UID}"),
 			api.authorize(http.MethodPut, "/api/v1/provisioning/alert-rules/{UID}"),
@@ -426,6 +662,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Put(
+			toMacaronPath("/api/v1/provisioning/maintenance-windows/{ID}"),
+			api.authorize(http.MethodPut, "/api/v1/provisioning/maintenance-windows/{ID}"),
+			metrics.Instrument(
+				http.MethodPut,
+				"/api/v1/provisioning/maintenance-windows/{ID}",
+				srv.RoutePutMaintenanceWindow,
+				m,
+			),
+		)
 		group.Put(
 			toMacaronPath("/api/v1/provisioning/mute-timings/{name}"),
 			api.authorize(http.MethodPut, "/api/v1/provisioning/mute-timings/{name}"),
@@ -446,6 +692,16 @@ func (api *API) RegisterProvisioningApiEndpoints(srv ProvisioningApi, m *metrics
 				m,
 			),
 		)
+		group.Put(
+			toMacaronPath("/api/v1/provisioning/silences/{ID}"),
+			api.authorize(http.MethodPut, "/api/v1/provisioning/silences/{ID}"),
+			metrics.Instrument(
+				http.MethodPut,
+				"/api/v1/provisioning/silences/{ID}",
+				srv.RoutePutSilence,
+				m,
+			),
+		)
 		group.Put(
 			toMacaronPath("/api/v1/provisioning/templates/{name}"),
 			api.authorize(http.MethodPut, "/api/v1/provisioning/templates/{name}"),
@@ -19,26 +19,54 @@ import (
 )
 
 type RulerApi interface {
+	RouteDeleteGrafanaPrometheusRuleGroupConfig(*contextmodel.ReqContext) response.Response
 	RouteDeleteGrafanaRuleGroupConfig(*contextmodel.ReqContext) response.Response
+	RouteDeleteNamespaceGrafanaPrometheusRulesConfig(*contextmodel.ReqContext) response.Response
 	RouteDeleteNamespaceGrafanaRulesConfig(*contextmodel.ReqContext) response.Response
 	RouteDeleteNamespaceRulesConfig(*contextmodel.ReqContext) response.Response
 	RouteDeleteRuleGroupConfig(*contextmodel.ReqContext) response.Response
+	RouteGetGrafanaPrometheusRuleGroupConfig(*contextmodel.ReqContext) response.Response
+	RouteGetGrafanaPrometheusRulesConfig(*contextmodel.ReqContext) response.Response
+	RouteGetGrafanaRuleAudit(*contextmodel.ReqContext) response.Response
+	RouteGetGrafanaRuleEvaluation(*contextmodel.ReqContext) response.Response
 	RouteGetGrafanaRuleGroupConfig(*contextmodel.ReqContext) response.Response
+	RouteGetGrafanaRuleInstances(*contextmodel.ReqContext) response.Response
 	RouteGetGrafanaRulesConfig(*contextmodel.ReqContext) response.Response
+	RouteGetNamespaceGrafanaPrometheusRulesConfig(*contextmodel.ReqContext) response.Response
 	RouteGetNamespaceGrafanaRulesConfig(*contextmodel.ReqContext) response.Response
 	RouteGetNamespaceRulesConfig(*contextmodel.ReqContext) response.Response
 	RouteGetRulegGroupConfig(*contextmodel.ReqContext) response.Response
 	RouteGetRulesConfig(*contextmodel.ReqContext) response.Response
+	RoutePostGrafanaRuleEvaluation(*contextmodel.ReqContext) response.Response
+	RoutePostGrafanaRulePause(*contextmodel.ReqContext) response.Response
+	RoutePostGrafanaRuleReset(*contextmodel.ReqContext) response.Response
+	RoutePostGrafanaRuleTestNotification(*contextmodel.ReqContext) response.Response
+	RoutePostGrafanaRuleUnpause(*contextmodel.ReqContext) response.Response
+	RoutePostGrafanaRulesBulk(*contextmodel.ReqContext) response.Response
+	RoutePostNameGrafanaPrometheusRulesConfig(*contextmodel.ReqContext) response.Response
 	RoutePostNameGrafanaRulesConfig(*contextmodel.ReqContext) response.Response
 	RoutePostNameRulesConfig(*contextmodel.ReqContext) response.Response
 }
 
+func (f *RulerApiHandler) RouteDeleteGrafanaPrometheusRuleGroupConfig(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	datasourceUIDParam := web.Params(ctx.Req)[":DatasourceUID"]
+	namespaceParam := web.Params(ctx.Req)[":Namespace"]
+	groupnameParam := web.Params(ctx.Req)[":Groupname"]
+	return f.handleRouteDeleteGrafanaPrometheusRuleGroupConfig(ctx, datasourceUIDParam, namespaceParam, groupnameParam)
+}
 func (f *RulerApiHandler) RouteDeleteGrafanaRuleGroupConfig(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	namespaceParam := web.Params(ctx.Req)[":Namespace"]
 	groupnameParam := web.Params(ctx.Req)[":Groupname"]
 	return f.handleRouteDeleteGrafanaRuleGroupConfig(ctx, namespaceParam, groupnameParam)
 }
+func (f *RulerApiHandler) RouteDeleteNamespaceGrafanaPrometheusRulesConfig(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	datasourceUIDParam := web.Params(ctx.Req)[":DatasourceUID"]
+	namespaceParam := web.Params(ctx.Req)[":Namespace"]
+	return f.handleRouteDeleteNamespaceGrafanaPrometheusRulesConfig(ctx, datasourceUIDParam, namespaceParam)
+}
 func (f *RulerApiHandler) RouteDeleteNamespaceGrafanaRulesConfig(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	namespaceParam := web.Params(ctx.Req)[":Namespace"]
@@ -57,15 +85,48 @@ func (f *RulerApiHandler) RouteDeleteRuleGroupConfig(ctx *contextmodel.ReqContex
 	groupnameParam := web.Params(ctx.Req)[":Groupname"]
 	return f.handleRouteDeleteRuleGroupConfig(ctx, datasourceUIDParam, namespaceParam, groupnameParam)
 }
+func (f *RulerApiHandler) RouteGetGrafanaPrometheusRuleGroupConfig(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	datasourceUIDParam := web.Params(ctx.Req)[":DatasourceUID"]
+	namespaceParam := web.Params(ctx.Req)[":Namespace"]
+	groupnameParam := web.Params(ctx.Req)[":Groupname"]
+	return f.handleRouteGetGrafanaPrometheusRuleGroupConfig(ctx, datasourceUIDParam, namespaceParam, groupnameParam)
+}
+func (f *RulerApiHandler) RouteGetGrafanaPrometheusRulesConfig(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	datasourceUIDParam := web.Params(ctx.Req)[":DatasourceUID"]
+	return f.handleRouteGetGrafanaPrometheusRulesConfig(ctx, datasourceUIDParam)
+}
+func (f *RulerApiHandler) RouteGetGrafanaRuleAudit(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	ruleUIDParam := web.Params(ctx.Req)[":RuleUID"]
+	return f.handleRouteGetGrafanaRuleAudit(ctx, ruleUIDParam)
+}
+func (f *RulerApiHandler) RouteGetGrafanaRuleEvaluation(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	ruleUIDParam := web.Params(ctx.Req)[":RuleUID"]
+	return f.handleRouteGetGrafanaRuleEvaluation(ctx, ruleUIDParam)
+}
 func (f *RulerApiHandler) RouteGetGrafanaRuleGroupConfig(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	namespaceParam := web.Params(ctx.Req)[":Namespace"]
 	groupnameParam := web.Params(ctx.Req)[":Groupname"]
 	return f.handleRouteGetGrafanaRuleGroupConfig(ctx, namespaceParam, groupnameParam)
 }
+func (f *RulerApiHandler) RouteGetGrafanaRuleInstances(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	ruleUIDParam := web.Params(ctx.Req)[":RuleUID"]
+	return f.handleRouteGetGrafanaRuleInstances(ctx, ruleUIDParam)
+}
 func (f *RulerApiHandler) RouteGetGrafanaRulesConfig(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteGetGrafanaRulesConfig(ctx)
 }
+func (f *RulerApiHandler) RouteGetNamespaceGrafanaPrometheusRulesConfig(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	datasourceUIDParam := web.Params(ctx.Req)[":DatasourceUID"]
+	namespaceParam := web.Params(ctx.Req)[":Namespace"]
+	return f.handleRouteGetNamespaceGrafanaPrometheusRulesConfig(ctx, datasourceUIDParam, namespaceParam)
+}
 func (f *RulerApiHandler) RouteGetNamespaceGrafanaRulesConfig(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	namespaceParam := web.Params(ctx.Req)[":Namespace"]
@@ -89,6 +150,50 @@ func (f *RulerApiHandler) RouteGetRulesConfig(ctx *contextmodel.ReqContext) resp
 	datasourceUIDParam := web.Params(ctx.Req)[":DatasourceUID"]
 	return f.handleRouteGetRulesConfig(ctx, datasourceUIDParam)
 }
+func (f *RulerApiHandler) RoutePostGrafanaRuleEvaluation(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	ruleUIDParam := web.Params(ctx.Req)[":RuleUID"]
+	return f.handleRoutePostGrafanaRuleEvaluation(ctx, ruleUIDParam)
+}
+func (f *RulerApiHandler) RoutePostGrafanaRulePause(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	ruleUIDParam := web.Params(ctx.Req)[":RuleUID"]
+	return f.handleRoutePostGrafanaRulePause(ctx, ruleUIDParam)
+}
+func (f *RulerApiHandler) RoutePostGrafanaRuleReset(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	ruleUIDParam := web.Params(ctx.Req)[":RuleUID"]
+	return f.handleRoutePostGrafanaRuleReset(ctx, ruleUIDParam)
+}
+func (f *RulerApiHandler) RoutePostGrafanaRuleTestNotification(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	ruleUIDParam := web.Params(ctx.Req)[":RuleUID"]
+	// Parse Request Body
+	conf := apimodels.PostableTestRuleNotification{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostGrafanaRuleTestNotification(ctx, conf, ruleUIDParam)
+}
+func (f *RulerApiHandler) RoutePostGrafanaRuleUnpause(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	ruleUIDParam := web.Params(ctx.Req)[":RuleUID"]
+	return f.handleRoutePostGrafanaRuleUnpause(ctx, ruleUIDParam)
+}
+func (f *RulerApiHandler) RoutePostGrafanaRulesBulk(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Request Body
+	conf := apimodels.PostableBulkRuleAction{}
+	if err := web.Bind(ctx.Req, &conf); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	return f.handleRoutePostGrafanaRulesBulk(ctx, conf)
+}
+func (f *RulerApiHandler) RoutePostNameGrafanaPrometheusRulesConfig(ctx *contextmodel.ReqContext) response.Response {
+	// Parse Path Parameters
+	datasourceUIDParam := web.Params(ctx.Req)[":DatasourceUID"]
+	namespaceParam := web.Params(ctx.Req)[":Namespace"]
+	return f.handleRoutePostNameGrafanaPrometheusRulesConfig(ctx, datasourceUIDParam, namespaceParam)
+}
 func (f *RulerApiHandler) RoutePostNameGrafanaRulesConfig(ctx *contextmodel.ReqContext) response.Response {
 	// Parse Path Parameters
 	namespaceParam := web.Params(ctx.Req)[":Namespace"]
@@ -153,6 +258,46 @@ func (api *API) RegisterRulerApiEndpoints(srv RulerApi, m *metrics.API) {
 				m,
 			),
 		)
+		group.Delete(
+			toMacaronPath("/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname}"),
+			api.authorize(http.MethodDelete, "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname}",
+				srv.RouteDeleteGrafanaPrometheusRuleGroupConfig,
+				m,
+			),
+		)
+		group.Delete(
+			toMacaronPath("/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}"),
+			api.authorize(http.MethodDelete, "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}"),
+			metrics.Instrument(
+				http.MethodDelete,
+				"/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}",
+				srv.RouteDeleteNamespaceGrafanaPrometheusRulesConfig,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/{RuleUID}/audit"),
+			api.authorize(http.MethodGet, "/api/ruler/grafana/api/v1/rule/{RuleUID}/audit"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/ruler/grafana/api/v1/rule/{RuleUID}/audit",
+				srv.RouteGetGrafanaRuleAudit,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/{RuleUID}/eval"),
+			api.authorize(http.MethodGet, "/api/ruler/grafana/api/v1/rule/{RuleUID}/eval"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/ruler/grafana/api/v1/rule/{RuleUID}/eval",
+				srv.RouteGetGrafanaRuleEvaluation,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/ruler/grafana/api/v1/rules/{Namespace}/{Groupname}"),
 			api.authorize(http.MethodGet, "/api/ruler/grafana/api/v1/rules/{Namespace}/{Groupname}"),
@@ -163,6 +308,16 @@ func (api *API) RegisterRulerApiEndpoints(srv RulerApi, m *metrics.API) {
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/{RuleUID}/instances"),
+			api.authorize(http.MethodGet, "/api/ruler/grafana/api/v1/rule/{RuleUID}/instances"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/ruler/grafana/api/v1/rule/{RuleUID}/instances",
+				srv.RouteGetGrafanaRuleInstances,
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/ruler/grafana/api/v1/rules"),
 			api.authorize(http.MethodGet, "/api/ruler/grafana/api/v1/rules"),
@@ -213,6 +368,96 @@ func (api *API) RegisterRulerApiEndpoints(srv RulerApi, m *metrics.API) {
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname}"),
+			api.authorize(http.MethodGet, "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname}",
+				srv.RouteGetGrafanaPrometheusRuleGroupConfig,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules"),
+			api.authorize(http.MethodGet, "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules",
+				srv.RouteGetGrafanaPrometheusRulesConfig,
+				m,
+			),
+		)
+		group.Get(
+			toMacaronPath("/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}"),
+			api.authorize(http.MethodGet, "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}",
+				srv.RouteGetNamespaceGrafanaPrometheusRulesConfig,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/bulk"),
+			api.authorize(http.MethodPost, "/api/ruler/grafana/api/v1/rule/bulk"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/grafana/api/v1/rule/bulk",
+				srv.RoutePostGrafanaRulesBulk,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/{RuleUID}/eval"),
+			api.authorize(http.MethodPost, "/api/ruler/grafana/api/v1/rule/{RuleUID}/eval"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/grafana/api/v1/rule/{RuleUID}/eval",
+				srv.RoutePostGrafanaRuleEvaluation,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/{RuleUID}/pause"),
+			api.authorize(http.MethodPost, "/api/ruler/grafana/api/v1/rule/{RuleUID}/pause"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/grafana/api/v1/rule/{RuleUID}/pause",
+				srv.RoutePostGrafanaRulePause,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/{RuleUID}/reset"),
+			api.authorize(http.MethodPost, "/api/ruler/grafana/api/v1/rule/{RuleUID}/reset"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/grafana/api/v1/rule/{RuleUID}/reset",
+				srv.RoutePostGrafanaRuleReset,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/{RuleUID}/test-notification"),
+			api.authorize(http.MethodPost, "/api/ruler/grafana/api/v1/rule/{RuleUID}/test-notification"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/grafana/api/v1/rule/{RuleUID}/test-notification",
+				srv.RoutePostGrafanaRuleTestNotification,
+				m,
+			),
+		)
+		group.Post(
+			toMacaronPath("/api/ruler/grafana/api/v1/rule/{RuleUID}/unpause"),
+			api.authorize(http.MethodPost, "/api/ruler/grafana/api/v1/rule/{RuleUID}/unpause"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/grafana/api/v1/rule/{RuleUID}/unpause",
+				srv.RoutePostGrafanaRuleUnpause,
+				m,
+			),
+		)
 		group.Post(
 			toMacaronPath("/api/ruler/grafana/api/v1/rules/{Namespace}"),
 			api.authorize(http.MethodPost, "/api/ruler/grafana/api/v1/rules/{Namespace}"),
@@ -233,5 +478,15 @@ func (api *API) RegisterRulerApiEndpoints(srv RulerApi, m *metrics.API) {
 				m,
 			),
 		)
+		group.Post(
+			toMacaronPath("/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}"),
+			api.authorize(http.MethodPost, "/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}"),
+			metrics.Instrument(
+				http.MethodPost,
+				"/api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}",
+				srv.RoutePostNameGrafanaPrometheusRulesConfig,
+				m,
+			),
+		)
 	}, middleware.ReqSignedIn)
 }
@@ -12,8 +12,15 @@ import (
 type RuleStore interface {
 	GetUserVisibleNamespaces(context.Context, int64, *user.SignedInUser) (map[string]*folder.Folder, error)
 	GetNamespaceByTitle(context.Context, string, int64, *user.SignedInUser, bool) (*folder.Folder, error)
+	GetAlertRuleByUID(ctx context.Context, query *ngmodels.GetAlertRuleByUIDQuery) (*ngmodels.AlertRule, error)
 	GetAlertRulesGroupByRuleUID(ctx context.Context, query *ngmodels.GetAlertRulesGroupByRuleUIDQuery) ([]*ngmodels.AlertRule, error)
 	ListAlertRules(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (ngmodels.RulesGroup, error)
+	GetAlertRuleEvaluations(ctx context.Context, query *ngmodels.GetAlertRuleEvaluationsQuery) ([]*ngmodels.AlertRuleEvaluation, error)
+	ListAlertInstances(ctx context.Context, query *ngmodels.ListAlertInstancesQuery) ([]*ngmodels.AlertInstance, error)
+	CountAlertInstances(ctx context.Context, query *ngmodels.ListAlertInstancesQuery) (int64, error)
+	GetAlertInstanceStateSummaries(ctx context.Context, query *ngmodels.GetAlertInstanceStateSummariesQuery) ([]*ngmodels.AlertInstanceStateSummary, error)
+	ListAlertRuleAuditEntries(ctx context.Context, query *ngmodels.ListAlertRuleAuditQuery) ([]*ngmodels.AlertRuleAuditEntry, error)
+	CountAlertRuleAuditEntries(ctx context.Context, query *ngmodels.ListAlertRuleAuditQuery) (int64, error)
 
 	// InsertAlertRules will insert all alert rules passed into the function
 	// and return the map of uuid to id.
@@ -76,6 +76,46 @@ func (f *ProvisioningApiHandler) handleRouteDeleteMuteTiming(ctx *contextmodel.R
 	return f.svc.RouteDeleteMuteTiming(ctx, name)
 }
 
+func (f *ProvisioningApiHandler) handleRouteGetMaintenanceWindows(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetMaintenanceWindows(ctx)
+}
+
+func (f *ProvisioningApiHandler) handleRouteGetMaintenanceWindow(ctx *contextmodel.ReqContext, id string) response.Response {
+	return f.svc.RouteGetMaintenanceWindow(ctx, id)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePostMaintenanceWindow(ctx *contextmodel.ReqContext, mw apimodels.MaintenanceWindow) response.Response {
+	return f.svc.RoutePostMaintenanceWindow(ctx, mw)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePutMaintenanceWindow(ctx *contextmodel.ReqContext, mw apimodels.MaintenanceWindow, id string) response.Response {
+	return f.svc.RoutePutMaintenanceWindow(ctx, mw, id)
+}
+
+func (f *ProvisioningApiHandler) handleRouteDeleteMaintenanceWindow(ctx *contextmodel.ReqContext, id string) response.Response {
+	return f.svc.RouteDeleteMaintenanceWindow(ctx, id)
+}
+
+func (f *ProvisioningApiHandler) handleRouteGetSilences(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetSilences(ctx)
+}
+
+func (f *ProvisioningApiHandler) handleRouteGetSilence(ctx *contextmodel.ReqContext, id string) response.Response {
+	return f.svc.RouteGetSilence(ctx, id)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePostSilence(ctx *contextmodel.ReqContext, s apimodels.Silence) response.Response {
+	return f.svc.RoutePostSilence(ctx, s)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePutSilence(ctx *contextmodel.ReqContext, s apimodels.Silence, id string) response.Response {
+	return f.svc.RoutePutSilence(ctx, s, id)
+}
+
+func (f *ProvisioningApiHandler) handleRouteDeleteSilence(ctx *contextmodel.ReqContext, id string) response.Response {
+	return f.svc.RouteDeleteSilence(ctx, id)
+}
+
 func (f *ProvisioningApiHandler) handleRouteGetAlertRules(ctx *contextmodel.ReqContext) response.Response {
 	return f.svc.RouteGetAlertRules(ctx)
 }
@@ -88,10 +128,18 @@ func (f *ProvisioningApiHandler) handleRouteGetAlertRuleExport(ctx *contextmodel
 	return f.svc.RouteGetAlertRuleExport(ctx, UID)
 }
 
+func (f *ProvisioningApiHandler) handleRouteGetAlertRuleSummaries(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetAlertRuleSummaries(ctx)
+}
+
 func (f *ProvisioningApiHandler) handleRouteGetAlertRulesExport(ctx *contextmodel.ReqContext) response.Response {
 	return f.svc.RouteGetAlertRulesExport(ctx)
 }
 
+func (f *ProvisioningApiHandler) handleRouteGetAlertRulesPrometheusExport(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteGetAlertRulesPrometheusExport(ctx)
+}
+
 func (f *ProvisioningApiHandler) handleRoutePostAlertRule(ctx *contextmodel.ReqContext, ar apimodels.ProvisionedAlertRule) response.Response {
 	return f.svc.RoutePostAlertRule(ctx, ar)
 }
@@ -100,6 +148,18 @@ func (f *ProvisioningApiHandler) handleRoutePutAlertRule(ctx *contextmodel.ReqCo
 	return f.svc.RoutePutAlertRule(ctx, ar, UID)
 }
 
+func (f *ProvisioningApiHandler) handleRoutePatchAlertRule(ctx *contextmodel.ReqContext, ar apimodels.PatchedAlertRule, UID string) response.Response {
+	return f.svc.RoutePatchAlertRule(ctx, ar, UID)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePostAlertRuleDiff(ctx *contextmodel.ReqContext, ar apimodels.ProvisionedAlertRule, UID string) response.Response {
+	return f.svc.RoutePostAlertRuleDiff(ctx, ar, UID)
+}
+
+func (f *ProvisioningApiHandler) handleRoutePostAlertRulesPrometheusImport(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RoutePostAlertRulesPrometheusImport(ctx)
+}
+
 func (f *ProvisioningApiHandler) handleRouteDeleteAlertRule(ctx *contextmodel.ReqContext, UID string) response.Response {
 	return f.svc.RouteDeleteAlertRule(ctx, UID)
 }
@@ -1,14 +1,18 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
 )
 
@@ -16,13 +20,18 @@ type fakeAlertInstanceManager struct {
 	mtx sync.Mutex
 	// orgID -> RuleID -> States
 	states map[int64]map[string][]*state.State
+	// clock tells the evaluation time of the generated alert instances.
+	clock *clock.Mock
 }
 
 func NewFakeAlertInstanceManager(t *testing.T) *fakeAlertInstanceManager {
 	t.Helper()
 
+	clk := clock.NewMock()
+	clk.Set(time.Date(2022, 3, 10, 14, 0, 0, 0, time.UTC))
 	return &fakeAlertInstanceManager{
 		states: map[int64]map[string][]*state.State{},
+		clock:  clk,
 	}
 }
 
@@ -53,7 +62,7 @@ func (f *fakeAlertInstanceManager) GenerateAlertInstances(orgID int64, alertRule
 	f.mtx.Lock()
 	defer f.mtx.Unlock()
 
-	evaluationTime := timeNow()
+	evaluationTime := f.clock.Now()
 	evaluationDuration := 1 * time.Minute
 
 	for i := 0; i < count; i++ {
@@ -103,3 +112,58 @@ func (f *fakeAlertInstanceManager) GenerateAlertInstances(orgID int64, alertRule
 		f.states[orgID][alertRuleUID] = append(f.states[orgID][alertRuleUID], newState)
 	}
 }
+
+type fakeRuleScheduler struct {
+	mtx       sync.Mutex
+	err       error
+	paused    bool
+	status    apimodels.SchedulerStatus
+	shadow    apimodels.ShadowModeReport
+	Requested []models.AlertRuleKey
+	Reset     []models.ResetAlertInstancesCommand
+	resetN    int
+}
+
+func (f *fakeRuleScheduler) EvaluateNow(key models.AlertRuleKey) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Requested = append(f.Requested, key)
+	return f.err
+}
+
+func (f *fakeRuleScheduler) ResetState(_ context.Context, cmd models.ResetAlertInstancesCommand) (int, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Reset = append(f.Reset, cmd)
+	return f.resetN, f.err
+}
+
+func (f *fakeRuleScheduler) PauseEvaluation() {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.paused = true
+}
+
+func (f *fakeRuleScheduler) ResumeEvaluation() {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.paused = false
+}
+
+func (f *fakeRuleScheduler) IsEvaluationPaused() bool {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.paused
+}
+
+func (f *fakeRuleScheduler) Status() apimodels.SchedulerStatus {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.status
+}
+
+func (f *fakeRuleScheduler) ShadowReport() apimodels.ShadowModeReport {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.shadow
+}
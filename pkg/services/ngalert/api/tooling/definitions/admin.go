@@ -1,6 +1,8 @@
 package definitions
 
 import (
+	"time"
+
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 )
 
@@ -14,6 +16,46 @@ import (
 //     Responses:
 //		 200: AlertingStatus
 
+// swagger:route POST /api/v1/ngalert/scheduler/pause configuration RoutePostPauseEvaluation
+//
+//  Pause evaluation of alert rules of all organizations. The pause is not persisted and is lost when Grafana restarts.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//		 200: Ack
+
+// swagger:route POST /api/v1/ngalert/scheduler/resume configuration RoutePostResumeEvaluation
+//
+//  Resume evaluation of alert rules.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//		 200: Ack
+
+// swagger:route GET /api/v1/ngalert/scheduler configuration RouteGetSchedulerStatus
+//
+//  Get the alert rules known to the scheduler of this Grafana instance, their latest evaluation and when they are evaluated next.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//		 200: SchedulerStatus
+
+// swagger:route GET /api/v1/ngalert/scheduler/shadow configuration RouteGetShadowModeReport
+//
+//  Get the alert rules migrated from legacy alerts that disagreed with their legacy alerts in shadow mode.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//		 200: ShadowModeReport
+
 // swagger:route GET /api/v1/ngalert/alertmanagers configuration RouteGetAlertmanagers
 //
 //  Get the discovered and dropped Alertmanagers of the user's organization based on the specified configuration.
@@ -94,4 +136,197 @@ type GettableAlertmanagers struct {
 type AlertingStatus struct {
 	AlertmanagersChoice      AlertmanagersChoice `json:"alertmanagersChoice"`
 	NumExternalAlertmanagers int                 `json:"numExternalAlertmanagers"`
+	// EvaluationPaused is true if evaluation of all alert rules is paused by an administrator.
+	EvaluationPaused bool `json:"evaluationPaused"`
+}
+
+// swagger:model
+type SchedulerStatus struct {
+	// InstanceID identifies the scheduler among the instances that share the same database.
+	InstanceID          string `json:"instanceId"`
+	BaseIntervalSeconds int64  `json:"baseIntervalSeconds"`
+	EvaluationPaused    bool   `json:"evaluationPaused"`
+	// RuleRoutines is the number of rule evaluation routines. Every scheduled rule is evaluated by its own routine.
+	RuleRoutines int `json:"ruleRoutines"`
+	// InFlightEvaluations is the number of evaluations that are currently running.
+	InFlightEvaluations int `json:"inFlightEvaluations"`
+	// MaxConcurrentEvaluations is the maximum number of evaluations that run at the same time. Zero means no limit.
+	MaxConcurrentEvaluations int             `json:"maxConcurrentEvaluations"`
+	Rules                    []ScheduledRule `json:"rules"`
+}
+
+// swagger:model
+type AlertingStats struct {
+	// Enabled is false if Grafana Alerting is disabled, in which case only Message is set.
+	Enabled       bool               `json:"enabled"`
+	Message       string             `json:"message,omitempty"`
+	Scheduler     *SchedulerStats    `json:"scheduler,omitempty"`
+	Evaluations   *EvaluationStats   `json:"evaluations,omitempty"`
+	Notifications *NotificationStats `json:"notifications,omitempty"`
+	// States is the number of alert instances of this Grafana instance in every state.
+	States map[string]int `json:"states,omitempty"`
+}
+
+// swagger:model
+type AdminAlertRules struct {
+	// TotalCount is the number of rules that match the filter, regardless of the page.
+	TotalCount int64            `json:"totalCount"`
+	Page       int64            `json:"page"`
+	Limit      int64            `json:"limit"`
+	Rules      []AdminAlertRule `json:"rules"`
+}
+
+// swagger:model
+type AdminAlertRule struct {
+	OrgID        int64  `json:"orgId"`
+	OrgName      string `json:"orgName"`
+	UID          string `json:"uid"`
+	Title        string `json:"title"`
+	NamespaceUID string `json:"namespaceUid"`
+	RuleGroup    string `json:"ruleGroup"`
+	IsPaused     bool   `json:"isPaused"`
+	// DatasourceUIDs are the data sources that the queries of the rule read, without expressions.
+	DatasourceUIDs []string `json:"datasourceUids"`
+}
+
+// swagger:model
+type AlertingSettingsReload struct {
+	// Applied are the changed settings that were applied without a restart, as "section.key".
+	Applied []string `json:"applied"`
+	// RequiresRestart are the changed settings that are applied only when Grafana restarts, as "section.key".
+	RequiresRestart []string `json:"requiresRestart"`
+}
+
+const (
+	AlertingHealthOK       = "ok"
+	AlertingHealthDegraded = "degraded"
+	AlertingHealthFailing  = "failing"
+	AlertingHealthDisabled = "disabled"
+)
+
+// swagger:model
+type AlertingHealth struct {
+	// Status is ok, degraded if the scheduler has not completed a tick for several base intervals, failing if the
+	// scheduler does not run or the alerting tables cannot be read, or disabled if Grafana Alerting is disabled.
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	// Warnings explain why the status is not ok.
+	Warnings []string `json:"warnings,omitempty"`
+	// Scheduler is not set if this Grafana instance does not evaluate alert rules.
+	Scheduler *SchedulerHealth `json:"scheduler,omitempty"`
+	// Database is ok if the tables of the alert rules and alert instances can be read, and failing otherwise.
+	Database string `json:"database,omitempty"`
+	// NotificationBacklog is the number of notifications to contact points that are not delivered yet.
+	NotificationBacklog int `json:"notificationBacklog"`
+}
+
+// swagger:model
+type SchedulerHealth struct {
+	Running bool `json:"running"`
+	// LastTick is when the scheduler last completed a tick, or started if it has not completed any tick yet.
+	LastTick             *time.Time `json:"lastTick,omitempty"`
+	SecondsSinceLastTick float64    `json:"secondsSinceLastTick"`
+}
+
+// swagger:model
+type SchedulerStats struct {
+	// Running is true while the scheduler loop runs.
+	Running             bool  `json:"running"`
+	EvaluationPaused    bool  `json:"evaluationPaused"`
+	BaseIntervalSeconds int64 `json:"baseIntervalSeconds"`
+	// ScheduledRules is the number of rules scheduled by this Grafana instance at the last tick.
+	ScheduledRules int `json:"scheduledRules"`
+	// StoredRules is the number of rules in the database, including the rules scheduled by other instances.
+	StoredRules         int64 `json:"storedRules"`
+	RuleRoutines        int   `json:"ruleRoutines"`
+	InFlightEvaluations int   `json:"inFlightEvaluations"`
+	// Saturation is the fraction of the rule routines that are running an evaluation, between 0 and 1.
+	Saturation float64 `json:"saturation"`
+}
+
+// swagger:model
+type EvaluationStats struct {
+	// Total is the number of evaluations since the scheduler started.
+	Total    int64 `json:"total"`
+	Failures int64 `json:"failures"`
+	// P95DurationSeconds is the 95th percentile of the durations of the latest evaluations.
+	P95DurationSeconds float64 `json:"p95DurationSeconds"`
+}
+
+// swagger:model
+type NotificationStats struct {
+	// Dispatched is the number of alerts that were sent to the Alertmanagers since Grafana started.
+	Dispatched int64 `json:"dispatched"`
+	// Failed is the number of alerts that could not be sent to at least one Alertmanager.
+	Failed int64 `json:"failed"`
+}
+
+// swagger:model
+type ScheduledRule struct {
+	ID               int64  `json:"id"`
+	UID              string `json:"uid"`
+	OrgID            int64  `json:"orgId"`
+	Title            string `json:"title"`
+	IntervalSeconds  int64  `json:"intervalSeconds"`
+	Schedule         string `json:"schedule,omitempty"`
+	ScheduleTimezone string `json:"scheduleTimezone,omitempty"`
+	IsPaused         bool   `json:"isPaused"`
+	// LastEvaluation is the time of the latest completed evaluation. It is empty if the rule has not been evaluated yet.
+	LastEvaluation *time.Time `json:"lastEvaluation,omitempty"`
+	// LastEvaluationDuration is the duration of the latest completed evaluation in seconds.
+	LastEvaluationDuration float64 `json:"lastEvaluationDuration"`
+	// LastEvaluationState is the state of the latest completed evaluation: Normal, Alerting, NoData or Error.
+	LastEvaluationState string `json:"lastEvaluationState,omitempty"`
+	LastError           string `json:"lastError,omitempty"`
+	// ThrottledAt is the tick of the latest evaluation that was deferred to the next tick since the latest completed
+	// evaluation, because the organization exceeded its limit of evaluations per minute or MaxConcurrentEvaluations
+	// evaluations were running. It is empty if none was deferred.
+	ThrottledAt *time.Time `json:"throttledAt,omitempty"`
+	// Owner is the scheduler instance that evaluates the rule. It is empty if this instance does not know it, because its
+	// latest heartbeat failed.
+	Owner string `json:"owner,omitempty"`
+	// BackoffUntil is the time before which the scheduled evaluations of the rule are skipped because it failed repeatedly.
+	// It is empty if the rule is not backing off.
+	BackoffUntil *time.Time `json:"backoffUntil,omitempty"`
+	// NextEvaluation is the tick at which this instance evaluates the rule next, after the backoff if the rule is backing
+	// off. It is empty if the rule is paused or evaluated by another instance.
+	NextEvaluation *time.Time `json:"nextEvaluation,omitempty"`
+}
+
+// swagger:enum ShadowDiscrepancyKind
+type ShadowDiscrepancyKind string
+
+const (
+	// ShadowDiscrepancyAlertingOnly means that the alert rule was alerting while its legacy alert was not.
+	ShadowDiscrepancyAlertingOnly ShadowDiscrepancyKind = "alerting_only"
+	// ShadowDiscrepancyLegacyOnly means that the legacy alert was alerting while its alert rule was not.
+	ShadowDiscrepancyLegacyOnly ShadowDiscrepancyKind = "legacy_only"
+)
+
+// swagger:model
+type ShadowModeReport struct {
+	// Enabled is true if the rules migrated from legacy alerts are evaluated in shadow mode.
+	Enabled bool `json:"enabled"`
+	// ToleranceSeconds is how long a rule must disagree with its legacy alert before the discrepancy is reported.
+	ToleranceSeconds int64 `json:"toleranceSeconds"`
+	// Rules are the rules that disagreed with their legacy alerts since this Grafana instance started.
+	Rules []ShadowRuleDiscrepancy `json:"rules"`
+}
+
+// swagger:model
+type ShadowRuleDiscrepancy struct {
+	UID           string `json:"uid"`
+	OrgID         int64  `json:"orgId"`
+	Title         string `json:"title"`
+	LegacyAlertID int64  `json:"legacyAlertId"`
+	// Disagreeing is true if the rule disagreed with its legacy alert at its latest evaluation for longer than the tolerance.
+	Disagreeing bool `json:"disagreeing"`
+	// Kind is the kind of the latest discrepancy.
+	Kind ShadowDiscrepancyKind `json:"kind"`
+	// Since is when the latest discrepancy started.
+	Since time.Time `json:"since"`
+	// LastComparison is the time of the latest evaluation of the rule that was compared with the legacy alert.
+	LastComparison time.Time `json:"lastComparison"`
+	// Discrepancies is the number of reported discrepancies.
+	Discrepancies int64 `json:"discrepancies"`
 }
@@ -451,7 +451,7 @@ type BodyAlertingConfig struct {
 // prom routes
 // swagger:parameters RouteGetRuleStatuses RouteGetAlertStatuses
 // ruler routes
-// swagger:parameters RouteGetRulesConfig RoutePostNameRulesConfig RouteGetNamespaceRulesConfig RouteDeleteNamespaceRulesConfig RouteGetRulegGroupConfig RouteDeleteRuleGroupConfig
+// swagger:parameters RouteGetRulesConfig RoutePostNameRulesConfig RouteGetNamespaceRulesConfig RouteDeleteNamespaceRulesConfig RouteGetRulegGroupConfig RouteDeleteRuleGroupConfig RouteGetGrafanaPrometheusRulesConfig RouteGetNamespaceGrafanaPrometheusRulesConfig RouteGetGrafanaPrometheusRuleGroupConfig RoutePostNameGrafanaPrometheusRulesConfig RouteDeleteNamespaceGrafanaPrometheusRulesConfig RouteDeleteGrafanaPrometheusRuleGroupConfig
 type DatasourceUIDReference struct {
 	// DatasoureUID should be the datasource UID identifier
 	// in:path
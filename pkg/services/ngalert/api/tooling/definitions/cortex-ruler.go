@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/prometheus/common/model"
 )
 
@@ -40,8 +41,127 @@ import (
 //
 //     Responses:
 //       202: Ack
+//       400: ValidationErrors
+//       403: QuotaReachedError
 //
 
+// swagger:route POST /api/ruler/grafana/api/v1/rule/{RuleUID}/eval ruler RoutePostGrafanaRuleEvaluation
+//
+// Requests an evaluation of the Grafana managed rule as soon as possible, outside of its regular schedule
+//
+//     Responses:
+//       202: Ack
+//       404: NotFound
+//       409: Failure
+
+// swagger:route Get /api/ruler/grafana/api/v1/rule/{RuleUID}/instances ruler RouteGetGrafanaRuleInstances
+//
+// Get the alert instances of the Grafana managed rule
+//
+// The time and the values of the latest evaluation are up to date when the request is served by the instance that
+// evaluates the rule. Otherwise, they can be as old as the number of evaluations set by instance_heartbeat_evaluations.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: RuleInstancesResponse
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route Get /api/ruler/grafana/api/v1/rule/{RuleUID}/audit ruler RouteGetGrafanaRuleAudit
+//
+// Get who created, updated, paused, unpaused or deleted the Grafana managed rule and when, from the newest change
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: RuleAuditResponse
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route Get /api/ruler/grafana/api/v1/rule/{RuleUID}/eval ruler RouteGetGrafanaRuleEvaluation
+//
+// Evaluates the Grafana managed rule and returns the results without changing the state of its alert instances
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: RuleEvaluationResponse
+//       400: ValidationError
+//       404: NotFound
+//       502: ValidationError
+//       504: ValidationError
+
+// swagger:route POST /api/ruler/grafana/api/v1/rule/{RuleUID}/pause ruler RoutePostGrafanaRulePause
+//
+// Pauses evaluation of the Grafana managed rule. Pausing a paused rule does not change it
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableExtendedRuleNode
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route POST /api/ruler/grafana/api/v1/rule/{RuleUID}/unpause ruler RoutePostGrafanaRuleUnpause
+//
+// Resumes evaluation of the Grafana managed rule. Unpausing a rule that is not paused does not change it
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: GettableExtendedRuleNode
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route POST /api/ruler/grafana/api/v1/rule/{RuleUID}/test-notification ruler RoutePostGrafanaRuleTestNotification
+//
+// Sends a test notification of the Grafana managed rule to the contact points that the rule is associated with, without changing the state or the history of its alert instances
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: TestRuleNotificationResponse
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route POST /api/ruler/grafana/api/v1/rule/{RuleUID}/reset ruler RoutePostGrafanaRuleReset
+//
+// Resets the alert instances of the Grafana managed rule to Normal, so that the next evaluation establishes their state from scratch
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: ResetRuleInstancesResponse
+//       404: NotFound
+//       409: Failure
+
+// swagger:route POST /api/ruler/grafana/api/v1/rule/bulk ruler RoutePostGrafanaRulesBulk
+//
+// Deletes, pauses or unpauses many Grafana managed rules in a single transaction. If the action fails for one of the rules, no rule is changed
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: BulkRuleActionResponse
+//       400: BulkRuleActionError
+//       401: BulkRuleActionError
+//       409: BulkRuleActionError
+
 // swagger:route POST /api/ruler/{DatasourceUID}/api/v1/rules/{Namespace} ruler RoutePostNameRulesConfig
 //
 // Creates or updates a rule group
@@ -126,6 +246,284 @@ import (
 //       202: Ack
 //       404: NotFound
 
+// swagger:route Get /api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules ruler RouteGetGrafanaPrometheusRulesConfig
+//
+// List the Grafana managed rule groups in the format of the Cortex and Loki ruler API
+//
+// The namespaces are the titles of the folders. A rule whose condition cannot be expressed in PromQL has an expression
+// that never fires, and its queries and condition in annotations, so that it is not changed when it is written back.
+//
+//     Produces:
+//     - application/yaml
+//
+//     Responses:
+//       200: PrometheusRulesExport
+
+// swagger:route Get /api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace} ruler RouteGetNamespaceGrafanaPrometheusRulesConfig
+//
+// Get the Grafana managed rule groups of a namespace in the format of the Cortex and Loki ruler API
+//
+//     Produces:
+//     - application/yaml
+//
+//     Responses:
+//       200: PrometheusRulesExport
+//       404: NotFound
+
+// swagger:route Get /api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname} ruler RouteGetGrafanaPrometheusRuleGroupConfig
+//
+// Get a Grafana managed rule group in the format of the Cortex and Loki ruler API
+//
+//     Produces:
+//     - application/yaml
+//
+//     Responses:
+//       200: PrometheusRuleGroup
+//       404: NotFound
+
+// swagger:route POST /api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace} ruler RoutePostNameGrafanaPrometheusRulesConfig
+//
+// Creates or replaces a Grafana managed rule group from a rule group in the format of the Cortex and Loki ruler API
+//
+// The body is a rule group in YAML or JSON. The namespace is the title of an existing folder. The rules query the data
+// source with their expression, and fire when it returns a value above 0, unless they have the queries and condition
+// that the rules of Grafana are listed with. The rules are matched with the existing rules of the group by alert name,
+// and the rules of the group that are not in the body are deleted.
+//
+//     Consumes:
+//     - application/yaml
+//     - application/json
+//
+//     Responses:
+//       202: Ack
+//       400: ValidationError
+//       404: NotFound
+
+// swagger:route Delete /api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace} ruler RouteDeleteNamespaceGrafanaPrometheusRulesConfig
+//
+// Delete the Grafana managed rule groups of a namespace
+//
+//     Responses:
+//       202: Ack
+//       404: NotFound
+
+// swagger:route Delete /api/ruler/grafana/prometheus/{DatasourceUID}/api/v1/rules/{Namespace}/{Groupname} ruler RouteDeleteGrafanaPrometheusRuleGroupConfig
+//
+// Delete a Grafana managed rule group
+//
+//     Responses:
+//       202: Ack
+//       404: NotFound
+
+// swagger:parameters RoutePostGrafanaRuleEvaluation RouteGetGrafanaRuleEvaluation RouteGetGrafanaRuleInstances RouteGetGrafanaRuleAudit RoutePostGrafanaRuleReset RoutePostGrafanaRulePause RoutePostGrafanaRuleUnpause RoutePostGrafanaRuleTestNotification
+type PathRuleUIDConfig struct {
+	// in: path
+	RuleUID string
+}
+
+// swagger:parameters RouteGetGrafanaRuleInstances
+type GetRuleInstancesParams struct {
+	// Return only instances in one of the given states.
+	// in: query
+	// required: false
+	State []string `json:"state"`
+	// Return only instances that have all of the given labels, each in the form name=value.
+	// in: query
+	// required: false
+	Matcher []string `json:"matcher"`
+	// in: query
+	// required: false
+	// default: 1
+	Page int64 `json:"page"`
+	// in: query
+	// required: false
+	// default: 100
+	Limit int64 `json:"limit"`
+}
+
+// swagger:parameters RouteGetGrafanaRuleAudit
+type GetRuleAuditParams struct {
+	// in: query
+	// required: false
+	// default: 1
+	Page int64 `json:"page"`
+	// in: query
+	// required: false
+	// default: 100
+	Limit int64 `json:"limit"`
+}
+
+// swagger:parameters RouteGetGrafanaRuleEvaluation
+type GetRuleEvaluationParams struct {
+	// The time of the evaluation in RFC3339 format. The relative time ranges of the queries of the rule are resolved against it.
+	// Defaults to the current time.
+	// in: query
+	// required: false
+	Now string `json:"now"`
+	// If true, the response includes the data frames of every query and expression of the rule by RefID.
+	// in: query
+	// required: false
+	IncludeFrames bool `json:"includeFrames"`
+}
+
+// swagger:model
+type RuleEvaluationResponse struct {
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+	// State is the overall state of the results: Error if any result is an error, otherwise Alerting if any result is
+	// alerting, NoData if any result has no data, and Normal otherwise.
+	State   string                 `json:"state"`
+	Results []RuleEvaluationResult `json:"results"`
+	// Frames are the data frames of the queries and expressions of the rule by RefID. They are included only if requested.
+	// Frames with too many points are downsampled.
+	Frames map[string]data.Frames `json:"frames,omitempty"`
+	// Warnings describe the frames that were downsampled.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// swagger:model
+type RuleEvaluationResult struct {
+	Labels map[string]string `json:"labels"`
+	State  string            `json:"state"`
+	// Values are the values of the expressions of the rule by RefID. A value that is not a finite number is null.
+	Values map[string]*float64 `json:"values,omitempty"`
+	Error  string              `json:"error,omitempty"`
+	// ErrorReason is the cause of the error: datasource, datasource_not_allowed, timeout or expression.
+	ErrorReason string `json:"errorReason,omitempty"`
+}
+
+// swagger:parameters RoutePostGrafanaRuleReset
+type ResetRuleInstancesParams struct {
+	// Reset only the instance with the given labels hash. If empty, all instances of the rule are reset.
+	// in: query
+	// required: false
+	LabelsHash string `json:"labelsHash"`
+}
+
+// swagger:model
+type ResetRuleInstancesResponse struct {
+	// Reset is the number of instances that were reset.
+	Reset int64 `json:"reset"`
+}
+
+// swagger:parameters RoutePostGrafanaRuleTestNotification
+type TestRuleNotificationParams struct {
+	// in:body
+	Body PostableTestRuleNotification
+}
+
+// swagger:model
+type PostableTestRuleNotification struct {
+	// Labels of the alert instance of the test notification. If not set, the instance has sample labels.
+	// example: {"instance": "server-1"}
+	Labels map[string]string `json:"labels,omitempty"`
+	// Values of the queries and expressions of the rule by RefID. If not set, all queries and expressions have the value 1.
+	// example: {"A": 95, "B": 1}
+	Values map[string]float64 `json:"values,omitempty"`
+}
+
+// swagger:model
+type TestRuleNotificationResponse struct {
+	// Title is the title of the test notification, which starts with [TEST].
+	Title string `json:"title"`
+	// Results are the results of the delivery of the test notification to each contact point of the rule.
+	Results []TestRuleNotificationResult `json:"results"`
+}
+
+type TestRuleNotificationResult struct {
+	ContactPointUID string `json:"contactPointUID"`
+	// enum: success,failure
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// swagger:parameters RoutePostGrafanaRulesBulk
+type BulkRuleActionParams struct {
+	// in:body
+	Body PostableBulkRuleAction
+}
+
+// swagger:model
+type PostableBulkRuleAction struct {
+	// required: true
+	// enum: delete,pause,unpause
+	Action string `json:"action"`
+	// UIDs of the rules. At most 100 rules can be changed at once.
+	// required: true
+	UIDs []string `json:"uids"`
+}
+
+// swagger:model
+type BulkRuleActionResponse struct {
+	// RowsAffected is the number of rules that were changed. Pausing a paused rule or unpausing a rule that is not paused
+	// does not change it.
+	RowsAffected int64 `json:"rowsAffected"`
+	// Items are the results of the action for each rule in the order of the request.
+	Items []BulkRuleActionItem `json:"items"`
+}
+
+type BulkRuleActionItem struct {
+	UID string `json:"uid"`
+	// enum: success,notFound
+	Status string `json:"status"`
+}
+
+// swagger:model
+type BulkRuleActionError struct {
+	Message string `json:"message"`
+	// UID is the UID of the rule that caused the failure. It is empty if the failure is not caused by a specific rule.
+	UID string `json:"uid,omitempty"`
+}
+
+// swagger:model
+type RuleInstancesResponse struct {
+	// TotalCount is the number of instances that match the filters, regardless of the page.
+	TotalCount int64 `json:"totalCount"`
+	Page       int64 `json:"page"`
+	Limit      int64 `json:"limit"`
+	// Instances are ordered by state from the most severe (Alerting, Error, NoData, Pending, Normal), and then by labels.
+	Instances []GettableAlertInstance `json:"instances"`
+}
+
+// swagger:model
+type RuleAuditResponse struct {
+	// TotalCount is the number of audit entries of the rule, regardless of the page.
+	TotalCount int64 `json:"totalCount"`
+	Page       int64 `json:"page"`
+	Limit      int64 `json:"limit"`
+	// Entries are ordered from the newest.
+	Entries []GettableRuleAuditEntry `json:"entries"`
+}
+
+// swagger:model
+type GettableRuleAuditEntry struct {
+	// Action is one of create, update, pause, unpause and delete.
+	Action string `json:"action"`
+	// ActorID is the ID of the user who changed the rule. Zero means that the rule was not changed by a user, e.g. by
+	// file provisioning, and a negative ID is the negated ID of the API key that changed it.
+	ActorID   int64     `json:"actorId"`
+	Timestamp time.Time `json:"timestamp"`
+	// Changes are the names of the fields of the rule that were changed by an update.
+	Changes []string `json:"changes,omitempty"`
+}
+
+// swagger:model
+type GettableAlertInstance struct {
+	Labels map[string]string `json:"labels"`
+	// LabelsHash identifies the instance among the instances of the rule.
+	LabelsHash     string    `json:"labelsHash"`
+	State          string    `json:"state"`
+	StateReason    string    `json:"stateReason,omitempty"`
+	StateSince     time.Time `json:"stateSince"`
+	LastEvaluation time.Time `json:"lastEvaluation"`
+	// Resolved is true if the instance went from Alerting to Normal within the configured grace period.
+	Resolved bool `json:"resolved,omitempty"`
+	// ResolvedAt is the time at which the instance went from Alerting to Normal. It is set only if Resolved is true.
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+	// Values are the values of the expressions of the rule by RefID at the latest evaluation.
+	// A value that is absent or not a finite number is null.
+	Values map[string]*float64 `json:"values,omitempty"`
+}
+
 // swagger:parameters RoutePostNameRulesConfig RoutePostNameGrafanaRulesConfig
 type NamespaceConfig struct {
 	// in:path
@@ -134,13 +532,13 @@ type NamespaceConfig struct {
 	Body PostableRuleGroupConfig
 }
 
-// swagger:parameters RouteGetNamespaceRulesConfig RouteDeleteNamespaceRulesConfig RouteGetNamespaceGrafanaRulesConfig RouteDeleteNamespaceGrafanaRulesConfig
+// swagger:parameters RouteGetNamespaceRulesConfig RouteDeleteNamespaceRulesConfig RouteGetNamespaceGrafanaRulesConfig RouteDeleteNamespaceGrafanaRulesConfig RouteGetNamespaceGrafanaPrometheusRulesConfig RoutePostNameGrafanaPrometheusRulesConfig RouteDeleteNamespaceGrafanaPrometheusRulesConfig
 type PathNamespaceConfig struct {
 	// in: path
 	Namespace string
 }
 
-// swagger:parameters RouteGetRulegGroupConfig RouteDeleteRuleGroupConfig RouteGetGrafanaRuleGroupConfig RouteDeleteGrafanaRuleGroupConfig
+// swagger:parameters RouteGetRulegGroupConfig RouteDeleteRuleGroupConfig RouteGetGrafanaRuleGroupConfig RouteDeleteGrafanaRuleGroupConfig RouteGetGrafanaPrometheusRuleGroupConfig RouteDeleteGrafanaPrometheusRuleGroupConfig
 type PathRouleGroupConfig struct {
 	// in: path
 	Namespace string
@@ -148,6 +546,14 @@ type PathRouleGroupConfig struct {
 	Groupname string
 }
 
+// swagger:parameters RoutePostNameGrafanaRulesConfig RouteDeleteNamespaceGrafanaRulesConfig RouteDeleteGrafanaRuleGroupConfig RoutePostNameGrafanaPrometheusRulesConfig RouteDeleteNamespaceGrafanaPrometheusRulesConfig RouteDeleteGrafanaPrometheusRuleGroupConfig RoutePostGrafanaRulePause RoutePostGrafanaRuleUnpause RoutePostGrafanaRulesBulk
+type RulerProvenanceHeaders struct {
+	// If set by an admin of the organization, allows changing alert rules created via provisioning.
+	// The provisioning may overwrite the changes again.
+	// in:header
+	XDisableProvenance string `json:"X-Disable-Provenance"`
+}
+
 // swagger:parameters RouteGetRulesConfig RouteGetGrafanaRulesConfig
 type PathGetRulesParams struct {
 	// in: query
@@ -373,26 +779,80 @@ type PostableGrafanaRule struct {
 	NoDataState  NoDataState         `json:"no_data_state" yaml:"no_data_state"`
 	ExecErrState ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
 	IsPaused     *bool               `json:"is_paused" yaml:"is_paused"`
+	// Schedule is an optional cron expression with 5 fields. If it is set, it is used instead of the group interval.
+	// example: 0 8 * * 1-5
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	// ScheduleTimezone is the IANA time zone of the schedule. Defaults to UTC.
+	// example: Europe/Helsinki
+	ScheduleTimezone string `json:"schedule_timezone,omitempty" yaml:"schedule_timezone,omitempty"`
+	// InstanceLimit is the maximum number of alert instances of the rule. If it is zero, the default limit applies.
+	// example: 1000
+	InstanceLimit int64 `json:"instance_limit,omitempty" yaml:"instance_limit,omitempty"`
+	// NotificationTitle is the template of the title of the notifications sent to the contact points of the rule.
+	// example: High latency on {{ $labels.instance }}
+	NotificationTitle string `json:"notification_title,omitempty" yaml:"notification_title,omitempty"`
+	// NotificationMessage is the template of the message of the notifications sent to the contact points of the rule.
+	// example: Latency is {{ $values.B }}ms, see {{ $runbookURL }}
+	NotificationMessage string `json:"notification_message,omitempty" yaml:"notification_message,omitempty"`
+	// NotificationRepeatInterval is how often the contact points of the rule are notified again about the instances that
+	// are still alerting. Zero means that they are notified only once.
+	// example: 4h
+	NotificationRepeatInterval model.Duration `json:"notification_repeat_interval,omitempty" yaml:"notification_repeat_interval,omitempty"`
+	// SendResolved tells whether the contact points of the rule are notified when an instance stops alerting. Defaults to
+	// true.
+	// example: true
+	SendResolved *bool `json:"send_resolved,omitempty" yaml:"send_resolved,omitempty"`
+	// ContactPointUIDs are the UIDs of the contact points of the organization that the rule is associated with.
+	// The contact points are notified directly, in addition to the notification policies, so a contact point that the
+	// policies also route the alerts of the rule to is notified twice.
+	// example: ["cp_email_sre"]
+	ContactPointUIDs []string `json:"contact_point_uids,omitempty" yaml:"contact_point_uids,omitempty"`
 }
 
 // swagger:model
 type GettableGrafanaRule struct {
-	ID              int64               `json:"id" yaml:"id"`
-	OrgID           int64               `json:"orgId" yaml:"orgId"`
-	Title           string              `json:"title" yaml:"title"`
-	Condition       string              `json:"condition" yaml:"condition"`
-	Data            []AlertQuery        `json:"data" yaml:"data"`
-	Updated         time.Time           `json:"updated" yaml:"updated"`
-	IntervalSeconds int64               `json:"intervalSeconds" yaml:"intervalSeconds"`
-	Version         int64               `json:"version" yaml:"version"`
-	UID             string              `json:"uid" yaml:"uid"`
-	NamespaceUID    string              `json:"namespace_uid" yaml:"namespace_uid"`
-	NamespaceID     int64               `json:"namespace_id" yaml:"namespace_id"`
-	RuleGroup       string              `json:"rule_group" yaml:"rule_group"`
-	NoDataState     NoDataState         `json:"no_data_state" yaml:"no_data_state"`
-	ExecErrState    ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
-	Provenance      Provenance          `json:"provenance,omitempty" yaml:"provenance,omitempty"`
-	IsPaused        bool                `json:"is_paused" yaml:"is_paused"`
+	ID                         int64               `json:"id" yaml:"id"`
+	OrgID                      int64               `json:"orgId" yaml:"orgId"`
+	Title                      string              `json:"title" yaml:"title"`
+	Condition                  string              `json:"condition" yaml:"condition"`
+	Data                       []AlertQuery        `json:"data" yaml:"data"`
+	Updated                    time.Time           `json:"updated" yaml:"updated"`
+	IntervalSeconds            int64               `json:"intervalSeconds" yaml:"intervalSeconds"`
+	Version                    int64               `json:"version" yaml:"version"`
+	UID                        string              `json:"uid" yaml:"uid"`
+	NamespaceUID               string              `json:"namespace_uid" yaml:"namespace_uid"`
+	NamespaceID                int64               `json:"namespace_id" yaml:"namespace_id"`
+	RuleGroup                  string              `json:"rule_group" yaml:"rule_group"`
+	NoDataState                NoDataState         `json:"no_data_state" yaml:"no_data_state"`
+	ExecErrState               ExecutionErrorState `json:"exec_err_state" yaml:"exec_err_state"`
+	Provenance                 Provenance          `json:"provenance,omitempty" yaml:"provenance,omitempty"`
+	IsPaused                   bool                `json:"is_paused" yaml:"is_paused"`
+	Schedule                   string              `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+	ScheduleTimezone           string              `json:"schedule_timezone,omitempty" yaml:"schedule_timezone,omitempty"`
+	InstanceLimit              int64               `json:"instance_limit,omitempty" yaml:"instance_limit,omitempty"`
+	ContactPointUIDs           []string            `json:"contact_point_uids,omitempty" yaml:"contact_point_uids,omitempty"`
+	NotificationTitle          string              `json:"notification_title,omitempty" yaml:"notification_title,omitempty"`
+	NotificationMessage        string              `json:"notification_message,omitempty" yaml:"notification_message,omitempty"`
+	NotificationRepeatInterval model.Duration      `json:"notification_repeat_interval,omitempty" yaml:"notification_repeat_interval,omitempty"`
+	SendResolved               bool                `json:"send_resolved" yaml:"send_resolved"`
+	// LastEvaluation is the time of the latest evaluation of the rule. It is empty if the rule has not been evaluated yet.
+	LastEvaluation *time.Time `json:"last_evaluation,omitempty" yaml:"last_evaluation,omitempty"`
+	// LastEvaluationDuration is how long the latest evaluation took, in seconds.
+	LastEvaluationDuration float64 `json:"last_evaluation_duration,omitempty" yaml:"last_evaluation_duration,omitempty"`
+	LastEvaluationState    string  `json:"last_evaluation_state,omitempty" yaml:"last_evaluation_state,omitempty"`
+	LastEvaluationError    string  `json:"last_evaluation_error,omitempty" yaml:"last_evaluation_error,omitempty"`
+	// StateSummary summarizes the current states of the alert instances of the rule.
+	StateSummary *InstanceStateSummary `json:"state_summary,omitempty" yaml:"state_summary,omitempty"`
+}
+
+// InstanceStateSummary is the number of alert instances of a rule per state.
+type InstanceStateSummary struct {
+	// WorstState is the most severe state that at least one instance is in, ordered from the most severe as
+	// Error, Alerting, Pending, NoData and Normal. It is Normal if the rule has no instances yet.
+	// example: Alerting
+	WorstState string `json:"worst_state" yaml:"worst_state"`
+	// Counts is the number of instances per state. States without instances are omitted.
+	Counts map[string]int64 `json:"counts" yaml:"counts"`
 }
 
 // AlertQuery represents a single query associated with an alert definition.
@@ -66,6 +66,15 @@ type DiscoveryBase struct {
 type RuleDiscovery struct {
 	// required: true
 	RuleGroups []*RuleGroup `json:"groups"`
+	// Filters are the filters that were applied to the rules. They are set only for Grafana managed rules, if any filter was applied.
+	Filters *RuleDiscoveryFilters `json:"filters,omitempty"`
+}
+
+// RuleDiscoveryFilters are the filters that were applied to the rules of a RuleDiscovery.
+type RuleDiscoveryFilters struct {
+	Query        string `json:"query,omitempty"`
+	DashboardUID string `json:"dashboardUID,omitempty"`
+	PanelID      int64  `json:"panelID,omitempty"`
 }
 
 // AlertDiscovery has info for all active alerts.
@@ -171,4 +180,9 @@ type GetGrafanaRuleStatusesParams struct {
 	// in: query
 	// required: false
 	PanelID int64
+
+	// Filter the list of rules to those whose name contains the term, ignoring case. Wildcard characters are matched literally.
+	// in: query
+	// required: false
+	Query string `json:"query"`
 }
@@ -14,6 +14,16 @@ import (
 //
 //     Responses:
 //       200: ProvisionedAlertRules
+//       304: description: Not modified.
+
+// swagger:route GET /api/v1/provisioning/alert-rules/summaries provisioning stable RouteGetAlertRuleSummaries
+//
+// Get the summaries of all the alert rules.
+//
+// The summaries do not have the queries and expressions of the rules, which are read by UID.
+//
+//     Responses:
+//       200: ProvisionedAlertRuleSummaries
 
 // swagger:route GET /api/v1/provisioning/alert-rules/export provisioning stable RouteGetAlertRulesExport
 //
@@ -23,12 +33,44 @@ import (
 //       200: AlertingFileExport
 //       404: description: Not found.
 
+// swagger:route GET /api/v1/provisioning/alert-rules/export/prometheus provisioning stable RouteGetAlertRulesPrometheusExport
+//
+// Export the alert rules as Prometheus rule groups.
+//
+// The rule groups are keyed by the title of their folder. The rules whose condition cannot be expressed in PromQL are
+// exported with their Grafana condition in the annotations and a warning.
+//
+//     Produces:
+//     - application/json
+//     - application/yaml
+//     - text/yaml
+//
+//     Responses:
+//       200: PrometheusRulesExport
+
+// swagger:route POST /api/v1/provisioning/alert-rules/import/prometheus provisioning stable RoutePostAlertRulesPrometheusImport
+//
+// Import the alerting rules of a Prometheus rule file.
+//
+// The body is a Prometheus rule file in YAML or JSON. Every alerting rule becomes an alert rule of the folder that
+// queries the Prometheus data source, and fires when its expression returns a value above 0. The rules are created or
+// updated by group and alert name, and every group is imported in one transaction. Recording rules are rejected.
+//
+//     Consumes:
+//     - application/json
+//     - application/yaml
+//
+//     Responses:
+//       200: PrometheusImportResult
+//       400: ValidationError
+
 // swagger:route GET /api/v1/provisioning/alert-rules/{UID} provisioning stable RouteGetAlertRule
 //
 // Get a specific alert rule by UID.
 //
 //     Responses:
 //       200: ProvisionedAlertRule
+//       304: description: Not modified.
 //       404: description: Not found.
 
 // swagger:route GET /api/v1/provisioning/alert-rules/{UID}/export provisioning stable RouteGetAlertRuleExport
@@ -54,6 +96,9 @@ import (
 //     Responses:
 //       201: ProvisionedAlertRule
 //       400: ValidationError
+//       403: QuotaReachedError
+//       404: description: The rule created by an earlier request with the same idempotency key does not exist anymore.
+//       409: description: Conflict.
 
 // swagger:route PUT /api/v1/provisioning/alert-rules/{UID} provisioning stable RoutePutAlertRule
 //
@@ -65,6 +110,21 @@ import (
 //     Responses:
 //       200: ProvisionedAlertRule
 //       400: ValidationError
+//       409: description: Conflict.
+
+// swagger:route PATCH /api/v1/provisioning/alert-rules/{UID} provisioning stable RoutePatchAlertRule
+//
+// Update the given fields of an existing alert rule.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: ProvisionedAlertRule
+//       400: ValidationError
+//       404: description: Not found.
+//       409: description: Conflict.
+//       412: description: The rule does not match the If-Match header.
 
 // swagger:route DELETE /api/v1/provisioning/alert-rules/{UID} provisioning stable RouteDeleteAlertRule
 //
@@ -73,28 +133,88 @@ import (
 //     Responses:
 //       204: description: The alert rule was deleted successfully.
 
-// swagger:parameters RouteGetAlertRule RoutePutAlertRule RouteDeleteAlertRule RouteGetAlertRuleExport
+// swagger:route POST /api/v1/provisioning/alert-rules/{UID}/diff provisioning stable RoutePostAlertRuleDiff
+//
+// Compare an alert rule with the stored one without updating it.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: AlertRuleDiff
+//       400: ValidationError
+//       404: description: Not found.
+
+// swagger:parameters RouteGetAlertRule RoutePutAlertRule RoutePatchAlertRule RouteDeleteAlertRule RouteGetAlertRuleExport RoutePostAlertRuleDiff
 type AlertRuleUIDReference struct {
 	// Alert rule UID
 	// in:path
 	UID string
 }
 
-// swagger:parameters RoutePostAlertRule RoutePutAlertRule
+// swagger:parameters RoutePostAlertRule RoutePutAlertRule RoutePostAlertRuleDiff
 type AlertRulePayload struct {
 	// in:body
 	Body ProvisionedAlertRule
 }
 
-// swagger:parameters RoutePostAlertRule RoutePutAlertRule
+// swagger:parameters RoutePatchAlertRule
+type AlertRulePatchPayload struct {
+	// in:body
+	Body PatchedAlertRule
+}
+
+// swagger:parameters RoutePostAlertRule RoutePutAlertRule RoutePatchAlertRule RoutePostAlertRulesPrometheusImport
 type AlertRuleHeaders struct {
 	// in:header
 	XDisableProvenance string `json:"X-Disable-Provenance"`
 }
 
+// swagger:parameters RoutePostAlertRule
+type AlertRuleIdempotencyHeaders struct {
+	// If set, retries of the request with the same key and body within 24 hours return the rule created by the first
+	// request instead of creating another one. A request with the same key and a different body returns 409.
+	// in:header
+	IdempotencyKey string `json:"Idempotency-Key"`
+}
+
+// swagger:parameters RouteGetAlertRules RouteGetAlertRule
+type AlertRuleConditionalHeaders struct {
+	// Return 304 with an empty body if the rules were not updated after this time. Deleted rules are not taken into account.
+	// in:header
+	IfModifiedSince string `json:"If-Modified-Since"`
+}
+
+// swagger:parameters RoutePatchAlertRule
+type AlertRulePreconditionHeaders struct {
+	// Update the rule only if its entity tag, which is returned in the ETag header of the GET request, is one of the given
+	// ones, otherwise return 412.
+	// in:header
+	IfMatch string `json:"If-Match"`
+}
+
 // swagger:model
 type ProvisionedAlertRules []ProvisionedAlertRule
 
+// swagger:model
+type ProvisionedAlertRuleSummaries []ProvisionedAlertRuleSummary
+
+// ProvisionedAlertRuleSummary is the part of an alert rule that lists of alert rules show, without its queries and
+// expressions.
+type ProvisionedAlertRuleSummary struct {
+	UID       string `json:"uid"`
+	FolderUID string `json:"folderUID"`
+	RuleGroup string `json:"ruleGroup"`
+	Title     string `json:"title"`
+	// Interval is the evaluation interval of the rule group in seconds.
+	// example: 60
+	Interval int64 `json:"interval"`
+	IsPaused bool  `json:"isPaused"`
+	// readonly: true
+	Updated      time.Time             `json:"updated"`
+	StateSummary *InstanceStateSummary `json:"stateSummary"`
+}
+
 type ProvisionedAlertRule struct {
 	ID  int64  `json:"id"`
 	UID string `json:"uid"`
@@ -135,6 +255,106 @@ type ProvisionedAlertRule struct {
 	Provenance Provenance `json:"provenance,omitempty"`
 	// example: false
 	IsPaused bool `json:"isPaused"`
+	// example: 0 8 * * 1-5
+	Schedule string `json:"schedule,omitempty"`
+	// example: Europe/Helsinki
+	ScheduleTimezone string `json:"scheduleTimezone,omitempty"`
+	// example: 1000
+	InstanceLimit int64 `json:"instanceLimit,omitempty"`
+	// Template of the title of the notifications sent to the contact points of the rule.
+	// example: High latency on {{ $labels.instance }}
+	NotificationTitle string `json:"notificationTitle,omitempty"`
+	// Template of the message of the notifications sent to the contact points of the rule.
+	// example: Latency is {{ $values.B }}ms, see {{ $runbookURL }}
+	NotificationMessage string `json:"notificationMessage,omitempty"`
+	// How often the contact points of the rule are notified again about the instances that are still alerting. Zero means
+	// that they are notified only once.
+	// example: 4h
+	NotificationRepeatInterval model.Duration `json:"notificationRepeatInterval,omitempty"`
+	// Whether the contact points of the rule are notified when an instance stops alerting. Defaults to true.
+	// example: true
+	SendResolved *bool `json:"sendResolved,omitempty"`
+	// UIDs of the contact points of the organization that the rule is associated with.
+	// The contact points are notified directly, in addition to the notification policies, so a contact point that the
+	// policies also route the alerts of the rule to is notified twice.
+	// example: ["cp_email_sre"]
+	ContactPointUIDs []string `json:"contactPointUIDs,omitempty"`
+}
+
+// PatchedAlertRule contains the fields of an alert rule to update. The fields that are missing are not changed. The
+// queries are replaced as a whole, an empty object for the labels or annotations removes all of them, and an empty list
+// of contact points removes all of them.
+//
+// swagger:model
+type PatchedAlertRule struct {
+	// example: project_x
+	FolderUID *string `json:"folderUID,omitempty"`
+	// minLength: 1
+	// maxLength: 190
+	// example: eval_group_1
+	RuleGroup *string `json:"ruleGroup,omitempty"`
+	// minLength: 1
+	// maxLength: 190
+	// example: Always firing
+	Title *string `json:"title,omitempty"`
+	// example: A
+	Condition    *string              `json:"condition,omitempty"`
+	Data         []AlertQuery         `json:"data,omitempty"`
+	NoDataState  *NoDataState         `json:"noDataState,omitempty"`
+	ExecErrState *ExecutionErrorState `json:"execErrState,omitempty"`
+	For          *model.Duration      `json:"for,omitempty"`
+	// example: {"runbook_url": "https://supercoolrunbook.com/page/13"}
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// example: {"team": "sre-team-1"}
+	Labels map[string]string `json:"labels,omitempty"`
+	// example: false
+	IsPaused *bool `json:"isPaused,omitempty"`
+	// example: 0 8 * * 1-5
+	Schedule *string `json:"schedule,omitempty"`
+	// example: Europe/Helsinki
+	ScheduleTimezone *string `json:"scheduleTimezone,omitempty"`
+	// example: 1000
+	InstanceLimit *int64 `json:"instanceLimit,omitempty"`
+	// example: High latency on {{ $labels.instance }}
+	NotificationTitle *string `json:"notificationTitle,omitempty"`
+	// example: Latency is {{ $values.B }}ms, see {{ $runbookURL }}
+	NotificationMessage *string `json:"notificationMessage,omitempty"`
+	// example: 4h
+	NotificationRepeatInterval *model.Duration `json:"notificationRepeatInterval,omitempty"`
+	SendResolved               *bool           `json:"sendResolved,omitempty"`
+	// example: ["cp_email_sre"]
+	ContactPointUIDs []string `json:"contactPointUIDs,omitempty"`
+}
+
+// AlertRuleDiff is the difference between a stored alert rule and the one that would replace it. Read-only fields are
+// not compared, and models are compared as JSON values, so the order of their keys and whitespace do not matter.
+//
+// swagger:model
+type AlertRuleDiff struct {
+	// Fields are the changed fields of the rule other than its queries.
+	Fields []FieldDiff `json:"fields"`
+	// Queries are the added, removed and modified queries ordered by RefID.
+	Queries []AlertQueryDiff `json:"queries"`
+}
+
+// FieldDiff is a changed field. Old is missing if the field is added, and New is missing if the field is removed.
+type FieldDiff struct {
+	// Path is the path of the field in the JSON representation. Array indexes are designated by square brackets.
+	// example: labels.team
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+type AlertQueryDiff struct {
+	// example: A
+	RefID string `json:"refId"`
+	// enum: added,removed,modified
+	Change string `json:"change"`
+	// Fields are the changed fields of a modified query other than its model.
+	Fields []FieldDiff `json:"fields,omitempty"`
+	// Model are the changed fields of the model of a modified query.
+	Model []FieldDiff `json:"model,omitempty"`
 }
 
 // swagger:route GET /api/v1/provisioning/folder/{FolderUID}/rule-groups/{Group} provisioning stable RouteGetAlertRuleGroup
@@ -168,6 +388,7 @@ type ProvisionedAlertRule struct {
 //     Responses:
 //       200: AlertRuleGroup
 //       400: ValidationError
+//       403: QuotaReachedError
 
 // swagger:parameters RouteGetAlertRuleGroup RoutePutAlertRuleGroup RouteGetAlertRuleGroupExport
 type FolderUIDPathParam struct {
@@ -192,7 +413,7 @@ type AlertRuleGroupMetadata struct {
 	Interval int64 `json:"interval"`
 }
 
-// swagger:parameters RouteGetAlertRuleGroupExport RouteGetAlertRuleExport RouteGetAlertRulesExport
+// swagger:parameters RouteGetAlertRuleGroupExport RouteGetAlertRuleExport RouteGetAlertRulesExport RouteGetAlertRulesPrometheusExport
 type ExportQueryParams struct {
 	// Whether to initiate a download of the file or not.
 	// in: query
@@ -207,6 +428,32 @@ type ExportQueryParams struct {
 	Format string `json:"format"`
 }
 
+// swagger:parameters RouteGetAlertRulesPrometheusExport
+type PrometheusExportQueryParams struct {
+	// Export only the rules of the folder with this UID.
+	// in: query
+	// required: false
+	FolderUID string `json:"folderUid"`
+
+	// Export only the rules of the rule group with this name.
+	// in: query
+	// required: false
+	Group string `json:"group"`
+}
+
+// swagger:parameters RoutePostAlertRulesPrometheusImport
+type PrometheusImportQueryParams struct {
+	// The UID of the folder of the imported rules.
+	// in: query
+	// required: true
+	FolderUID string `json:"folderUid"`
+
+	// The UID of the Prometheus data source that the imported rules query.
+	// in: query
+	// required: true
+	DatasourceUID string `json:"datasourceUid"`
+}
+
 // swagger:model
 type AlertRuleGroup struct {
 	Title     string                 `json:"title"`
@@ -218,3 +465,40 @@ type AlertRuleGroup struct {
 // AlertingFileExport is the full provisioned file export.
 // swagger:model
 type AlertingFileExport = file.AlertingFileExport
+
+// PrometheusRulesExport is the export of alert rules as Prometheus rule groups, keyed by the title of their folder like
+// the namespaces of the Cortex ruler API.
+// swagger:model
+type PrometheusRulesExport map[string][]PrometheusRuleGroup
+
+// PrometheusRuleGroup is a rule group in the format of the Prometheus rule files.
+// swagger:model
+type PrometheusRuleGroup struct {
+	Name     string         `yaml:"name" json:"name"`
+	Interval model.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+	Rules    []ApiRuleNode  `yaml:"rules" json:"rules"`
+}
+
+// PrometheusRuleFile is a Prometheus rule file.
+// swagger:model
+type PrometheusRuleFile struct {
+	Groups []PrometheusRuleGroup `yaml:"groups" json:"groups"`
+}
+
+// PrometheusImportResult is the result of the import of a Prometheus rule file.
+// swagger:model
+type PrometheusImportResult struct {
+	Groups []PrometheusImportGroupResult `json:"groups"`
+}
+
+// PrometheusImportGroupResult is the result of the import of a Prometheus rule group. Either all the rules of the group
+// are imported or none of them.
+type PrometheusImportGroupResult struct {
+	Name string `json:"name"`
+	// Created are the alert names of the rules that were created.
+	Created []string `json:"created"`
+	// Updated are the alert names of the rules that were updated.
+	Updated []string `json:"updated"`
+	// Error is why the group could not be imported.
+	Error string `json:"error,omitempty"`
+}
@@ -50,6 +50,7 @@ import (
 //
 //     Responses:
 //       204: description: The contact point was deleted successfully.
+//       409: description: The contact point is used by alert rules.
 
 // swagger:parameters RoutePutContactpoint RouteDeleteContactpoints
 type ContactPointUIDReference struct {
@@ -0,0 +1,95 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /api/v1/provisioning/maintenance-windows provisioning RouteGetMaintenanceWindows
+//
+// Get all the maintenance windows.
+//
+//     Responses:
+//       200: MaintenanceWindows
+
+// swagger:route GET /api/v1/provisioning/maintenance-windows/{ID} provisioning RouteGetMaintenanceWindow
+//
+// Get a maintenance window.
+//
+//     Responses:
+//       200: MaintenanceWindow
+//       400: ValidationError
+//       404: description: Not found.
+
+// swagger:route POST /api/v1/provisioning/maintenance-windows provisioning RoutePostMaintenanceWindow
+//
+// Create a new maintenance window.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       201: MaintenanceWindow
+//       400: ValidationError
+
+// swagger:route PUT /api/v1/provisioning/maintenance-windows/{ID} provisioning RoutePutMaintenanceWindow
+//
+// Replace an existing maintenance window.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: MaintenanceWindow
+//       400: ValidationError
+//       404: description: Not found.
+
+// swagger:route DELETE /api/v1/provisioning/maintenance-windows/{ID} provisioning RouteDeleteMaintenanceWindow
+//
+// Delete a maintenance window.
+//
+//     Responses:
+//       204: description: The maintenance window was deleted successfully.
+//       404: description: Not found.
+
+// swagger:model
+type MaintenanceWindows []MaintenanceWindow
+
+// MaintenanceWindow is a period of time during which the notifications of the alerts of the organization are suppressed.
+// Alert rules are still evaluated, and the transitions of their alert instances are recorded in the state history.
+// If several windows overlap, the transitions are attributed to the window with the lowest ID.
+//
+// swagger:model
+type MaintenanceWindow struct {
+	// readonly: true
+	ID int64 `json:"id"`
+	// required: true
+	// example: Weekly maintenance
+	Title string `json:"title"`
+	// required: true
+	// example: 2023-06-03T02:00:00Z
+	Start time.Time `json:"start"`
+	// End must be after Start. A window that does not repeat cannot end in the past.
+	// required: true
+	// example: 2023-06-03T04:00:00Z
+	End time.Time `json:"end"`
+	// Recurrence is how often the window repeats, starting at Start. Empty means that the window does not repeat.
+	// A window that repeats must be shorter than its recurrence.
+	// enum: daily,weekly
+	Recurrence string `json:"recurrence,omitempty"`
+	// CreatedBy is the ID of the user who created the window. A negative ID is the negated ID of an API key.
+	// readonly: true
+	CreatedBy int64 `json:"createdBy"`
+	// readonly: true
+	Updated time.Time `json:"updated"`
+}
+
+// swagger:parameters RouteGetMaintenanceWindow RoutePutMaintenanceWindow RouteDeleteMaintenanceWindow
+type MaintenanceWindowIDParam struct {
+	// Maintenance window ID
+	// in:path
+	ID int64 `json:"ID"`
+}
+
+// swagger:parameters RoutePostMaintenanceWindow RoutePutMaintenanceWindow
+type MaintenanceWindowPayload struct {
+	// in:body
+	Body MaintenanceWindow
+}
@@ -0,0 +1,105 @@
+package definitions
+
+import "time"
+
+// swagger:route GET /api/v1/provisioning/silences provisioning RouteGetSilences
+//
+// Get all the silences.
+//
+//     Responses:
+//       200: Silences
+
+// swagger:route GET /api/v1/provisioning/silences/{ID} provisioning RouteGetSilence
+//
+// Get a silence.
+//
+//     Responses:
+//       200: Silence
+//       400: ValidationError
+//       404: description: Not found.
+
+// swagger:route POST /api/v1/provisioning/silences provisioning RoutePostSilence
+//
+// Create a new silence.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       201: Silence
+//       400: ValidationError
+
+// swagger:route PUT /api/v1/provisioning/silences/{ID} provisioning RoutePutSilence
+//
+// Replace an existing silence.
+//
+//     Consumes:
+//     - application/json
+//
+//     Responses:
+//       200: Silence
+//       400: ValidationError
+//       404: description: Not found.
+
+// swagger:route DELETE /api/v1/provisioning/silences/{ID} provisioning RouteDeleteSilence
+//
+// Delete a silence.
+//
+//     Responses:
+//       204: description: The silence was deleted successfully.
+//       404: description: Not found.
+
+// swagger:model
+type Silences []Silence
+
+// Silence suppresses the notifications of the alert instances of the organization that match all its matchers.
+// Alert rules are still evaluated, and the transitions of the silenced instances are recorded in the state history.
+// Silences are deleted once they end.
+//
+// swagger:model
+type Silence struct {
+	// readonly: true
+	ID int64 `json:"id"`
+	// required: true
+	Matchers []SilenceMatcher `json:"matchers"`
+	// required: true
+	// example: 2023-06-03T02:00:00Z
+	StartsAt time.Time `json:"startsAt"`
+	// EndsAt must be after StartsAt and cannot be in the past.
+	// required: true
+	// example: 2023-06-03T06:00:00Z
+	EndsAt time.Time `json:"endsAt"`
+	// example: Upgrade of the staging cluster
+	Comment string `json:"comment,omitempty"`
+	// CreatedBy is the ID of the user who created the silence. A negative ID is the negated ID of an API key.
+	// readonly: true
+	CreatedBy int64 `json:"createdBy"`
+	// readonly: true
+	Updated time.Time `json:"updated"`
+}
+
+// SilenceMatcher matches the alert instances that have the label name with the value.
+type SilenceMatcher struct {
+	// required: true
+	// example: cluster
+	Name string `json:"name"`
+	// An empty value also matches the instances that do not have the label.
+	// example: staging
+	Value string `json:"value"`
+	// Type is how the value is compared. Only exact matches are supported.
+	// enum: =
+	Type string `json:"type,omitempty"`
+}
+
+// swagger:parameters RouteGetSilence RoutePutSilence RouteDeleteSilence
+type SilenceIDParam struct {
+	// Silence ID
+	// in:path
+	ID int64 `json:"ID"`
+}
+
+// swagger:parameters RoutePostSilence RoutePutSilence
+type SilencePayload struct {
+	// in:body
+	Body Silence
+}
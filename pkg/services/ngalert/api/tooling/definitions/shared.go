@@ -11,3 +11,34 @@ type ValidationError struct {
 	// example: error message
 	Msg string `json:"msg"`
 }
+
+// swagger:model
+type ValidationErrors struct {
+	// example: rules[0].grafana_alert.title: alert rule title cannot be empty
+	Message string                 `json:"message"`
+	Errors  []FieldValidationError `json:"errors"`
+}
+
+// swagger:model
+type FieldValidationError struct {
+	// Field is the path of the invalid field in the request body.
+	// example: rules[0].grafana_alert.data[1].relativeTimeRange
+	Field string `json:"field"`
+	// example: from must be greater than to
+	Message string `json:"message"`
+}
+
+// swagger:model
+type QuotaReachedError struct {
+	// example: quota has been exceeded: cannot create alert rule 'cpu usage', 100 of 100 alert rules are used
+	Message string `json:"message"`
+	// Limit is the number of alert rules allowed by the reached quota.
+	// example: 100
+	Limit int64 `json:"limit"`
+	// Usage is the number of alert rules that count against the reached quota.
+	// example: 100
+	Usage int64 `json:"usage"`
+	// Rule is the title of the first rule of the request that could not be created.
+	// example: cpu usage
+	Rule string `json:"rule"`
+}
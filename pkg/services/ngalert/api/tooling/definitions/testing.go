@@ -24,6 +24,9 @@ import (
 //
 //     Responses:
 //       200: TestRuleResponse
+//       400: ValidationError
+//       502: description: A query of a data source failed.
+//       504: description: The evaluation timed out.
 
 // swagger:route Post /api/v1/rule/test/{DatasourceUID} testing RouteTestRuleConfig
 //
@@ -144,6 +147,11 @@ type AlertInstancesResponse struct {
 	// Instances is an array of arrow encoded dataframes
 	// each frame has a single row, and a column for each instance (alert identified by unique labels) with a boolean value (firing/not firing)
 	Instances [][]byte `json:"instances"`
+	// State summarizes the instances: Error if any instance has an error, otherwise Alerting if any instance is alerting,
+	// NoData if any instance has no data, and Normal in all other cases.
+	State string `json:"state,omitempty"`
+	// ErrorReason is the cause of the error of the first instance that has one: datasource, datasource_not_allowed, timeout or expression.
+	ErrorReason string `json:"errorReason,omitempty"`
 }
 
 // swagger:model
@@ -212,12 +212,35 @@ func ErrResp(status int, err error, msg string, args ...interface{}) *response.N
 	return response.Error(status, err.Error(), err)
 }
 
+// quotaReachedResponse returns http.StatusForbidden with the limit and the usage of the reached quota, and the title of the
+// rule that could not be created, if err is models.QuotaReachedError, and with err as the message otherwise.
+func quotaReachedResponse(err error) response.Response {
+	var qerr *ngmodels.QuotaReachedError
+	if !errors.As(err, &qerr) {
+		return ErrResp(http.StatusForbidden, err, "")
+	}
+	return response.JSON(http.StatusForbidden, apimodels.QuotaReachedError{
+		Message: err.Error(),
+		Limit:   qerr.Limit,
+		Usage:   qerr.Usage,
+		Rule:    qerr.RuleTitle,
+	})
+}
+
 // accessForbiddenResp creates a response of forbidden access.
 func accessForbiddenResp() response.Response {
 	//nolint:stylecheck // Grandfathered capitalization of error.
 	return ErrResp(http.StatusForbidden, errors.New("Permission denied"), "")
 }
 
+// provenanceOverridden returns true if the request has the X-Disable-Provenance header and the user is an admin of the organization.
+// This allows admins to change provisioned alert rules via the ruler API. The provenance of the rules is kept, so the provisioning
+// that created them can overwrite the changes again.
+func provenanceOverridden(c *contextmodel.ReqContext) bool {
+	_, disabled := c.Req.Header[disableProvenanceHeaderName]
+	return disabled && c.SignedInUser.HasRole(org.RoleAdmin)
+}
+
 func containsProvisionedAlerts(provenances map[string]ngmodels.Provenance, rules []*ngmodels.AlertRule) bool {
 	if len(provenances) == 0 {
 		return false
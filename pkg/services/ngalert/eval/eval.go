@@ -10,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
@@ -33,6 +34,12 @@ type EvaluatorFactory interface {
 	Create(ctx EvaluationContext, condition models.Condition) (ConditionEvaluator, error)
 }
 
+// TimeoutSetter is implemented by the evaluator factories whose evaluation timeout can be changed while they are used,
+// such as the one returned by NewEvaluatorFactory.
+type TimeoutSetter interface {
+	SetEvaluationTimeout(timeout time.Duration)
+}
+
 //go:generate mockery --name ConditionEvaluator --structname ConditionEvaluatorMock --with-expecter --output eval_mocks --outpkg eval_mocks
 type ConditionEvaluator interface {
 	// EvaluateRaw evaluates the condition and returns raw backend response backend.QueryDataResponse
@@ -80,15 +87,24 @@ func (r *conditionEvaluator) Evaluate(ctx context.Context, now time.Time) (Resul
 	if err != nil {
 		return nil, err
 	}
-	execResults := queryDataResponseToExecutionResults(r.condition, response)
-	return evaluateExecutionResult(execResults, now), nil
+	return EvaluateResponse(r.condition, response, now), nil
+}
+
+// EvaluateResponse converts the raw response of the evaluation of the condition, as returned by ConditionEvaluator.EvaluateRaw, to Results
+func EvaluateResponse(condition models.Condition, response *backend.QueryDataResponse, now time.Time) Results {
+	execResults := queryDataResponseToExecutionResults(condition, response)
+	return evaluateExecutionResult(execResults, now)
 }
 
 type evaluatorImpl struct {
-	evaluationTimeout time.Duration
+	// evaluationTimeout is the timeout of the evaluators that are created, in nanoseconds. It can be changed while the
+	// factory is used.
+	evaluationTimeout atomic.Int64
 	dataSourceCache   datasources.CacheService
 	expressionService *expr.Service
 	pluginsStore      plugins.Store
+	// allowedDatasources are the UIDs and types of the data sources that conditions may query. Empty allows all data sources.
+	allowedDatasources map[string]struct{}
 }
 
 func NewEvaluatorFactory(
@@ -97,11 +113,63 @@ func NewEvaluatorFactory(
 	expressionService *expr.Service,
 	pluginsStore plugins.Store,
 ) EvaluatorFactory {
-	return &evaluatorImpl{
-		evaluationTimeout: cfg.EvaluationTimeout,
-		dataSourceCache:   datasourceCache,
-		expressionService: expressionService,
-		pluginsStore:      pluginsStore,
+	allowedDatasources := make(map[string]struct{}, len(cfg.AllowedDatasources))
+	for _, ds := range cfg.AllowedDatasources {
+		allowedDatasources[ds] = struct{}{}
+	}
+	factory := &evaluatorImpl{
+		dataSourceCache:    datasourceCache,
+		expressionService:  expressionService,
+		pluginsStore:       pluginsStore,
+		allowedDatasources: allowedDatasources,
+	}
+	factory.SetEvaluationTimeout(cfg.EvaluationTimeout)
+	return factory
+}
+
+// SetEvaluationTimeout changes the timeout of the evaluators that the factory creates from now on. The evaluators that
+// were created before keep their timeout.
+func (e *evaluatorImpl) SetEvaluationTimeout(timeout time.Duration) {
+	e.evaluationTimeout.Store(int64(timeout))
+}
+
+// DatasourceNotAllowedError is returned when a query of a condition uses a data source that is not in the allowed data sources
+// of the configuration.
+type DatasourceNotAllowedError struct {
+	RefID string
+	UID   string
+	Type  string
+}
+
+func (e DatasourceNotAllowedError) Error() string {
+	return fmt.Sprintf("data source %s of type %s, used by query %s, is not allowed to be queried by alert rules", e.UID, e.Type, e.RefID)
+}
+
+const (
+	// ErrorReasonDatasource is the reason of errors of queries to data sources, including data sources that are not available.
+	ErrorReasonDatasource = "datasource"
+	// ErrorReasonDatasourceNotAllowed is the reason of conditions that query a data source that is not allowed by the configuration.
+	// The data source is not queried.
+	ErrorReasonDatasourceNotAllowed = "datasource_not_allowed"
+	// ErrorReasonTimeout is the reason of evaluations that took longer than the evaluation timeout.
+	ErrorReasonTimeout = "timeout"
+	// ErrorReasonExpression is the reason of all other errors, such as invalid expressions or results of an unexpected format.
+	ErrorReasonExpression = "expression"
+)
+
+// ErrorReason classifies an error returned by an evaluation, or of a result in the Error state, by its cause.
+func ErrorReason(err error) string {
+	var queryErr expr.QueryError
+	var notAllowedErr DatasourceNotAllowedError
+	switch {
+	case errors.As(err, &notAllowedErr):
+		return ErrorReasonDatasourceNotAllowed
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorReasonTimeout
+	case errors.As(err, &queryErr), errors.Is(err, plugins.ErrPluginUnavailable):
+		return ErrorReasonDatasource
+	default:
+		return ErrorReasonExpression
 	}
 }
 
@@ -151,6 +219,25 @@ func (evalResults Results) HasErrors() bool {
 	return false
 }
 
+// State summarizes the results as a single state: Error if any result has an error, otherwise Alerting if any result is alerting,
+// NoData if any result has no data, and Normal in all other cases.
+func (evalResults Results) State() State {
+	state := Normal
+	for _, result := range evalResults {
+		switch result.State {
+		case Error:
+			return Error
+		case Alerting:
+			state = Alerting
+		case NoData:
+			if state == Normal {
+				state = NoData
+			}
+		}
+	}
+	return state
+}
+
 // Result contains the evaluated State of an alert instance
 // identified by its labels.
 type Result struct {
@@ -605,6 +692,9 @@ func (e *evaluatorImpl) Validate(ctx EvaluationContext, condition models.Conditi
 	if err != nil {
 		return err
 	}
+	if err := e.checkAllowedDatasources(req); err != nil {
+		return err
+	}
 	for _, query := range req.Queries {
 		if query.DataSource == nil || expr.IsDataSource(query.DataSource.UID) {
 			continue
@@ -632,9 +722,34 @@ func (e *evaluatorImpl) Create(ctx EvaluationContext, condition models.Condition
 	if err != nil {
 		return nil, err
 	}
+	if err := e.checkAllowedDatasources(req); err != nil {
+		return nil, err
+	}
 	return e.create(condition, req)
 }
 
+// checkAllowedDatasources returns a DatasourceNotAllowedError for the first query of the request whose data source is not allowed
+// by its UID or its type. Expressions are always allowed.
+func (e *evaluatorImpl) checkAllowedDatasources(req *expr.Request) error {
+	if len(e.allowedDatasources) == 0 {
+		return nil
+	}
+	for _, query := range req.Queries {
+		ds := query.DataSource
+		if ds == nil || expr.IsDataSource(ds.UID) {
+			continue
+		}
+		if _, ok := e.allowedDatasources[ds.UID]; ok {
+			continue
+		}
+		if _, ok := e.allowedDatasources[ds.Type]; ok {
+			continue
+		}
+		return DatasourceNotAllowedError{RefID: query.RefID, UID: ds.UID, Type: ds.Type}
+	}
+	return nil
+}
+
 func (e *evaluatorImpl) create(condition models.Condition, req *expr.Request) (ConditionEvaluator, error) {
 	pipeline, err := e.expressionService.BuildPipeline(req)
 	if err != nil {
@@ -647,7 +762,7 @@ func (e *evaluatorImpl) create(condition models.Condition, req *expr.Request) (C
 				pipeline:          pipeline,
 				expressionService: e.expressionService,
 				condition:         condition,
-				evalTimeout:       e.evaluationTimeout,
+				evalTimeout:       time.Duration(e.evaluationTimeout.Load()),
 			}, nil
 		}
 		conditions = append(conditions, node.RefID())
@@ -532,7 +532,7 @@ func TestValidate(t *testing.T) {
 				pluginsStore: store,
 			})
 
-			evaluator := NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, cacheService, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil), store)
+			evaluator := NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, cacheService, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, nil), store)
 			evalCtx := Context(context.Background(), u)
 
 			err := evaluator.Validate(evalCtx, condition)
@@ -545,6 +545,67 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestAllowedDatasources(t *testing.T) {
+	cacheService := &fakes.FakeCacheService{}
+	store := &plugins.FakePluginStore{}
+	addDatasource := func(uid, typ string) models.AlertQuery {
+		cacheService.DataSources = append(cacheService.DataSources, &datasources.DataSource{UID: uid, Type: typ})
+		store.PluginList = append(store.PluginList, plugins.PluginDTO{JSONData: plugins.JSONData{ID: typ, Backend: true}})
+		query := models.GenerateAlertQuery()
+		query.DatasourceUID = uid
+		return query
+	}
+	byUID := addDatasource("allowed", "prometheus")
+	byType := addDatasource(util.GenerateShortUID(), "loki")
+	denied := addDatasource("pii", "mysql")
+	condition := func(query models.AlertQuery) models.Condition {
+		return models.Condition{
+			Condition: "B",
+			Data: []models.AlertQuery{
+				query,
+				models.CreateClassicConditionExpression("B", query.RefID, "last", "gt", rand.Int()),
+			},
+		}
+	}
+	evaluatorFactory := func(allowed ...string) EvaluatorFactory {
+		cfg := setting.UnifiedAlertingSettings{AllowedDatasources: allowed}
+		return NewEvaluatorFactory(cfg, cacheService, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, nil), store)
+	}
+	evalCtx := Context(context.Background(), &user.SignedInUser{})
+
+	t.Run("should allow data sources by UID and type", func(t *testing.T) {
+		evaluator := evaluatorFactory("allowed", "loki")
+		for _, query := range []models.AlertQuery{byUID, byType} {
+			require.NoError(t, evaluator.Validate(evalCtx, condition(query)))
+			_, err := evaluator.Create(evalCtx, condition(query))
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("should reject other data sources when saved", func(t *testing.T) {
+		err := evaluatorFactory("allowed", "loki").Validate(evalCtx, condition(denied))
+		var notAllowedErr DatasourceNotAllowedError
+		require.ErrorAs(t, err, &notAllowedErr)
+		require.Equal(t, DatasourceNotAllowedError{RefID: denied.RefID, UID: "pii", Type: "mysql"}, notAllowedErr)
+		require.Contains(t, err.Error(), "pii")
+	})
+
+	t.Run("should refuse to evaluate other data sources", func(t *testing.T) {
+		_, err := evaluatorFactory("allowed", "loki").Create(evalCtx, condition(denied))
+		require.ErrorAs(t, err, &DatasourceNotAllowedError{})
+		require.Equal(t, ErrorReasonDatasourceNotAllowed, ErrorReason(err))
+	})
+
+	t.Run("should allow all data sources by default", func(t *testing.T) {
+		evaluator := evaluatorFactory()
+		for _, query := range []models.AlertQuery{byUID, byType, denied} {
+			require.NoError(t, evaluator.Validate(evalCtx, condition(query)))
+			_, err := evaluator.Create(evalCtx, condition(query))
+			require.NoError(t, err)
+		}
+	})
+}
+
 func TestEvaluateRaw(t *testing.T) {
 	t.Run("should timeout if request takes too long", func(t *testing.T) {
 		unexpectedResponse := &backend.QueryDataResponse{}
@@ -0,0 +1,99 @@
+package ngalert
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+const (
+	// healthCheckTimeout bounds the database check, so that a slow database fails the check instead of blocking it.
+	healthCheckTimeout = 2 * time.Second
+	// healthCheckCacheTTL is how long the result of the database check is reused, so that frequent health checks do not
+	// load the database.
+	healthCheckCacheTTL = 5 * time.Second
+)
+
+// Health reports whether the scheduler completes its ticks, the tables of the alert rules and alert instances can be
+// read, and how many notifications are not delivered yet. The scheduler is read from memory and the result of the
+// database check is cached, so it does not wait for evaluations and is cheap enough to be polled by load balancers. It
+// must be called only if Grafana Alerting is enabled.
+func (ng *AlertNG) Health(ctx context.Context) apimodels.AlertingHealth {
+	result := apimodels.AlertingHealth{Status: apimodels.AlertingHealthOK, Database: apimodels.AlertingHealthOK}
+	degrade := func(status, warning string) {
+		if result.Status != apimodels.AlertingHealthFailing {
+			result.Status = status
+		}
+		result.Warnings = append(result.Warnings, warning)
+	}
+
+	if ng.Cfg.UnifiedAlerting.ExecuteAlerts {
+		running, lastTick := ng.schedule.Liveness()
+		scheduler := &apimodels.SchedulerHealth{Running: running}
+		if !lastTick.IsZero() {
+			scheduler.LastTick = &lastTick
+			scheduler.SecondsSinceLastTick = ng.clock.Since(lastTick).Seconds()
+		}
+		result.Scheduler = scheduler
+
+		staleTicks := ng.Cfg.UnifiedAlerting.HealthStaleTicks
+		if staleTicks < 1 {
+			staleTicks = 1
+		}
+		staleAfter := time.Duration(staleTicks) * ng.Cfg.UnifiedAlerting.BaseInterval
+		switch {
+		case !running:
+			degrade(apimodels.AlertingHealthFailing, "the scheduler is not running")
+		case ng.clock.Since(lastTick) > staleAfter:
+			degrade(apimodels.AlertingHealthDegraded, fmt.Sprintf("the scheduler has not completed a tick for %s, which is more than %d base intervals",
+				ng.clock.Since(lastTick).Round(time.Second), staleTicks))
+		}
+	}
+
+	if err := ng.checkDatabaseHealth(ctx); err != nil {
+		// the error is only logged because the health check is not authenticated
+		ng.Log.Warn("Alerting health check failed to read the database", "error", err)
+		result.Database = apimodels.AlertingHealthFailing
+		degrade(apimodels.AlertingHealthFailing, "the alerting tables cannot be read")
+	}
+
+	if ng.MultiOrgAlertmanager != nil {
+		result.NotificationBacklog = ng.MultiOrgAlertmanager.NotificationBacklog()
+	}
+	return result
+}
+
+// checkDatabaseHealth returns the result of the latest database check if it is recent enough, or checks the database
+// otherwise.
+func (ng *AlertNG) checkDatabaseHealth(ctx context.Context) error {
+	ng.healthMtx.Lock()
+	if !ng.healthCheckedAt.IsZero() && ng.clock.Since(ng.healthCheckedAt) < healthCheckCacheTTL {
+		err := ng.healthErr
+		ng.healthMtx.Unlock()
+		return err
+	}
+	ng.healthMtx.Unlock()
+
+	// the callers that find the result expired at the same time share one check, so that a burst of health checks reads
+	// the database once. The check does not use the context of the caller that starts it, so that the cancellation of that
+	// caller does not fail the check of the others.
+	result := ng.healthCheck.DoChan("database", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+		defer cancel()
+		err := ng.ruleStore.CheckHealth(ctx)
+
+		ng.healthMtx.Lock()
+		defer ng.healthMtx.Unlock()
+		ng.healthCheckedAt = ng.clock.Now()
+		ng.healthErr = err
+		return nil, err
+	})
+	select {
+	case r := <-result:
+		return r.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
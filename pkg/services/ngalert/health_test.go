@@ -0,0 +1,148 @@
+package ngalert
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+type fakeLivenessScheduler struct {
+	schedule.ScheduleService
+	running  bool
+	lastTick time.Time
+}
+
+func (f *fakeLivenessScheduler) Liveness() (bool, time.Time) {
+	return f.running, f.lastTick
+}
+
+func TestHealth(t *testing.T) {
+	setup := func(t *testing.T) (*AlertNG, *fakeLivenessScheduler, *fakes.RuleStore, *clock.Mock) {
+		clk := clock.NewMock()
+		cfg := setting.NewCfg()
+		cfg.UnifiedAlerting.ExecuteAlerts = true
+		cfg.UnifiedAlerting.BaseInterval = 10 * time.Second
+		cfg.UnifiedAlerting.HealthStaleTicks = 3
+		scheduler := &fakeLivenessScheduler{running: true, lastTick: clk.Now()}
+		ruleStore := fakes.NewRuleStore(t)
+		return &AlertNG{Cfg: cfg, Log: log.NewNopLogger(), schedule: scheduler, ruleStore: ruleStore, clock: clk}, scheduler, ruleStore, clk
+	}
+
+	t.Run("should be ok if the scheduler ticks and the database can be read", func(t *testing.T) {
+		ng, _, _, clk := setup(t)
+		clk.Add(25 * time.Second)
+
+		health := ng.Health(context.Background())
+		require.Equal(t, apimodels.AlertingHealthOK, health.Status)
+		require.Empty(t, health.Warnings)
+		require.Equal(t, apimodels.AlertingHealthOK, health.Database)
+		require.True(t, health.Scheduler.Running)
+		require.Equal(t, 25.0, health.Scheduler.SecondsSinceLastTick)
+	})
+
+	t.Run("should be degraded if the scheduler has not ticked for more than the stale ticks", func(t *testing.T) {
+		ng, _, _, clk := setup(t)
+		clk.Add(31 * time.Second)
+
+		health := ng.Health(context.Background())
+		require.Equal(t, apimodels.AlertingHealthDegraded, health.Status)
+		require.Equal(t, []string{"the scheduler has not completed a tick for 31s, which is more than 3 base intervals"}, health.Warnings)
+		require.Equal(t, 31.0, health.Scheduler.SecondsSinceLastTick)
+	})
+
+	t.Run("should be failing if the scheduler does not run", func(t *testing.T) {
+		ng, scheduler, _, _ := setup(t)
+		scheduler.running = false
+
+		health := ng.Health(context.Background())
+		require.Equal(t, apimodels.AlertingHealthFailing, health.Status)
+		require.Equal(t, []string{"the scheduler is not running"}, health.Warnings)
+	})
+
+	t.Run("should not check the scheduler if this instance does not evaluate alert rules", func(t *testing.T) {
+		ng, scheduler, _, _ := setup(t)
+		scheduler.running = false
+		ng.Cfg.UnifiedAlerting.ExecuteAlerts = false
+
+		health := ng.Health(context.Background())
+		require.Equal(t, apimodels.AlertingHealthOK, health.Status)
+		require.Nil(t, health.Scheduler)
+	})
+
+	t.Run("should be failing if the database cannot be read, and cache the result of the check", func(t *testing.T) {
+		ng, _, ruleStore, clk := setup(t)
+		ruleStore.Hook = func(interface{}) error { return errors.New("database is down") }
+		clk.Add(time.Minute)
+
+		health := ng.Health(context.Background())
+		require.Equal(t, apimodels.AlertingHealthFailing, health.Status)
+		require.Equal(t, apimodels.AlertingHealthFailing, health.Database)
+		require.Equal(t, []string{
+			"the scheduler has not completed a tick for 1m0s, which is more than 3 base intervals",
+			"the alerting tables cannot be read",
+		}, health.Warnings)
+
+		ruleStore.Hook = func(interface{}) error { return nil }
+		require.Equal(t, apimodels.AlertingHealthFailing, ng.Health(context.Background()).Database)
+		clk.Add(healthCheckCacheTTL)
+		require.Equal(t, apimodels.AlertingHealthOK, ng.Health(context.Background()).Database)
+	})
+	t.Run("should check the database once for concurrent callers", func(t *testing.T) {
+		ng, _, ruleStore, _ := setup(t)
+		var checks int32
+		started := make(chan struct{})
+		release := make(chan struct{})
+		ruleStore.Hook = func(interface{}) error {
+			if atomic.AddInt32(&checks, 1) == 1 {
+				close(started)
+			}
+			<-release
+			return nil
+		}
+
+		var wg sync.WaitGroup
+		check := func() {
+			defer wg.Done()
+			require.Equal(t, apimodels.AlertingHealthOK, ng.Health(context.Background()).Database)
+		}
+		wg.Add(1)
+		go check()
+		<-started
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go check()
+		}
+		// give the other callers the time to find the result expired before the first check completes
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		require.EqualValues(t, 1, atomic.LoadInt32(&checks))
+	})
+
+	t.Run("should not wait for the database check once the context of the caller is cancelled", func(t *testing.T) {
+		ng, _, ruleStore, _ := setup(t)
+		release := make(chan struct{})
+		t.Cleanup(func() { close(release) })
+		ruleStore.Hook = func(interface{}) error {
+			<-release
+			return nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		health := ng.Health(ctx)
+		require.Equal(t, apimodels.AlertingHealthFailing, health.Database)
+	})
+}
@@ -0,0 +1,106 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	"github.com/grafana/grafana/pkg/services/live/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// RuleStore represents the ability to fetch alert rules.
+type RuleStore interface {
+	GetAlertRuleByUID(ctx context.Context, query *models.GetAlertRuleByUIDQuery) (*models.AlertRule, error)
+}
+
+// ChannelHandler manages all the `grafana/ngalert/*` channels. Only the server publishes on them, and the clients that
+// can read the alert rules can subscribe.
+type ChannelHandler struct {
+	ac    accesscontrol.AccessControl
+	rules RuleStore
+}
+
+func NewChannelHandler(ac accesscontrol.AccessControl, rules RuleStore) *ChannelHandler {
+	return &ChannelHandler{
+		ac:    ac,
+		rules: rules,
+	}
+}
+
+// GetHandlerForPath called on init
+func (h *ChannelHandler) GetHandlerForPath(_ string) (model.ChannelHandler, error) {
+	return h, nil // all channels share the same handler
+}
+
+// OnSubscribe allows subscribing to the definitions channel of the organization of the user if they can read alert rules,
+// and to the instances channel of a rule if they can read the rule. When access control is disabled, the rule can be read
+// by the users that can view its folder according to the dashboard guardian, like in the ruler API.
+func (h *ChannelHandler) OnSubscribe(ctx context.Context, u *user.SignedInUser, e model.SubscribeEvent) (model.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	parts := strings.Split(e.Path, "/")
+	if len(parts) != 2 {
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	var evaluator accesscontrol.Evaluator
+	switch parts[0] {
+	case definitionsPath:
+		if parts[1] != strconv.FormatInt(u.OrgID, 10) {
+			return model.SubscribeReply{}, backend.SubscribeStreamStatusPermissionDenied, nil
+		}
+		evaluator = accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleRead)
+	case instancesPath:
+		rule, err := h.rules.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: u.OrgID, UID: parts[1]})
+		if err != nil {
+			if errors.Is(err, models.ErrAlertRuleNotFound) {
+				return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+			}
+			return model.SubscribeReply{}, 0, err
+		}
+		if h.ac.IsDisabled() {
+			return subscribeInFolder(ctx, u, rule.NamespaceUID)
+		}
+		evaluator = accesscontrol.EvalPermission(accesscontrol.ActionAlertingRuleRead, dashboards.ScopeFoldersProvider.GetResourceScopeUID(rule.NamespaceUID))
+	default:
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusNotFound, nil
+	}
+
+	if !h.ac.IsDisabled() {
+		ok, err := h.ac.Evaluate(ctx, u, evaluator)
+		if err != nil {
+			return model.SubscribeReply{}, 0, err
+		}
+		if !ok {
+			return model.SubscribeReply{}, backend.SubscribeStreamStatusPermissionDenied, nil
+		}
+	}
+	return model.SubscribeReply{}, backend.SubscribeStreamStatusOK, nil
+}
+
+// subscribeInFolder allows subscribing if the dashboard guardian lets the user view the folder with the given UID.
+func subscribeInFolder(ctx context.Context, u *user.SignedInUser, folderUID string) (model.SubscribeReply, backend.SubscribeStreamStatus, error) {
+	g, err := guardian.NewByUID(ctx, folderUID, u.OrgID, u)
+	if err != nil {
+		return model.SubscribeReply{}, 0, err
+	}
+	canView, err := g.CanView()
+	if err != nil {
+		return model.SubscribeReply{}, 0, err
+	}
+	if !canView {
+		return model.SubscribeReply{}, backend.SubscribeStreamStatusPermissionDenied, nil
+	}
+	return model.SubscribeReply{}, backend.SubscribeStreamStatusOK, nil
+}
+
+// OnPublish does not allow the clients to publish.
+func (h *ChannelHandler) OnPublish(_ context.Context, _ *user.SignedInUser, _ model.PublishEvent) (model.PublishReply, backend.PublishStreamStatus, error) {
+	return model.PublishReply{}, backend.PublishStreamStatusPermissionDenied, nil
+}
@@ -0,0 +1,120 @@
+// Package live publishes the changes of alert rules and of the state of their instances on Grafana Live channels, so
+// that the clients that watch them do not have to poll.
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/live/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+const (
+	// Namespace is the namespace of the channels in the Grafana scope.
+	Namespace = "ngalert"
+
+	definitionsPath = "definitions"
+	instancesPath   = "instances"
+
+	// ActionStateChanged is the action of the events of the instances channels.
+	ActionStateChanged = "state-changed"
+)
+
+// DefinitionsChannel returns the channel of the changes of the alert rules of an organization.
+func DefinitionsChannel(orgID int64) string {
+	return fmt.Sprintf("grafana/%s/%s/%d", Namespace, definitionsPath, orgID)
+}
+
+// InstancesChannel returns the channel of the state changes of the instances of an alert rule.
+func InstancesChannel(ruleUID string) string {
+	return fmt.Sprintf("grafana/%s/%s/%s", Namespace, instancesPath, ruleUID)
+}
+
+// RuleEvent is published on DefinitionsChannel when an alert rule is created, updated or deleted.
+type RuleEvent struct {
+	ID        int64                        `json:"id,omitempty"`
+	UID       string                       `json:"uid"`
+	Action    models.AlertRuleChangeAction `json:"action"`
+	Timestamp time.Time                    `json:"timestamp"`
+}
+
+// InstanceEvent is published on InstancesChannel when an instance of the alert rule changes state.
+type InstanceEvent struct {
+	UID           string      `json:"uid"`
+	Action        string      `json:"action"`
+	Labels        data.Labels `json:"labels"`
+	State         string      `json:"state"`
+	PreviousState string      `json:"previousState"`
+	Timestamp     time.Time   `json:"timestamp"`
+}
+
+// Publisher publishes the events on Grafana Live. Publishing is best-effort: failures are logged, and never fail the
+// change or the evaluation that caused the event.
+type Publisher struct {
+	publish model.ChannelPublisher
+	log     log.Logger
+}
+
+func NewPublisher(publish model.ChannelPublisher, log log.Logger) *Publisher {
+	return &Publisher{
+		publish: publish,
+		log:     log,
+	}
+}
+
+// RuleChanged publishes a RuleEvent for the change. It is meant to be a bus listener of models.AlertRuleChanged, and
+// always returns nil.
+func (p *Publisher) RuleChanged(_ context.Context, e *models.AlertRuleChanged) error {
+	p.send(e.OrgID, DefinitionsChannel(e.OrgID), RuleEvent{
+		ID:        e.ID,
+		UID:       e.UID,
+		Action:    e.Action,
+		Timestamp: e.Timestamp,
+	})
+	return nil
+}
+
+// PublishTransitions publishes an InstanceEvent for every transition of an instance of the rule.
+func (p *Publisher) PublishTransitions(_ context.Context, rule *models.AlertRule, transitions []state.StateTransition) {
+	channel := InstancesChannel(rule.UID)
+	for _, t := range transitions {
+		p.send(rule.OrgID, channel, InstanceEvent{
+			UID:           rule.UID,
+			Action:        ActionStateChanged,
+			Labels:        withoutPrivateLabels(t.Labels),
+			State:         t.Formatted(),
+			PreviousState: state.FormatStateAndReason(t.PreviousState, t.PreviousStateReason),
+			Timestamp:     t.LastEvaluationTime,
+		})
+	}
+}
+
+func (p *Publisher) send(orgID int64, channel string, event interface{}) {
+	msg, err := json.Marshal(event)
+	if err != nil {
+		p.log.Error("Failed to encode live event", "channel", channel, "error", err)
+		return
+	}
+	if err := p.publish(orgID, channel, msg); err != nil {
+		p.log.Warn("Failed to publish live event", "org_id", orgID, "channel", channel, "error", err)
+	}
+}
+
+// withoutPrivateLabels returns the labels without the ones that Grafana adds for internal use, such as the UID of the rule.
+func withoutPrivateLabels(labels data.Labels) data.Labels {
+	result := make(data.Labels, len(labels))
+	for k, v := range labels {
+		if !strings.HasPrefix(k, "__") && !strings.HasSuffix(k, "__") {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,188 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/guardian"
+	livemodel "github.com/grafana/grafana/pkg/services/live/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+type publishedMessage struct {
+	orgID   int64
+	channel string
+	data    []byte
+}
+
+type fakeLive struct {
+	messages []publishedMessage
+	err      error
+}
+
+func (f *fakeLive) Publish(orgID int64, channel string, data []byte) error {
+	f.messages = append(f.messages, publishedMessage{orgID: orgID, channel: channel, data: data})
+	return f.err
+}
+
+func TestPublisher_RuleChanged(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, action := range []models.AlertRuleChangeAction{models.AlertRuleCreated, models.AlertRuleUpdated, models.AlertRuleDeleted} {
+		t.Run(string(action), func(t *testing.T) {
+			live := &fakeLive{}
+			p := NewPublisher(live.Publish, log.NewNopLogger())
+
+			err := p.RuleChanged(context.Background(), &models.AlertRuleChanged{OrgID: 3, ID: 7, UID: "rule-uid", Action: action, Timestamp: now})
+
+			require.NoError(t, err)
+			require.Len(t, live.messages, 1)
+			require.Equal(t, int64(3), live.messages[0].orgID)
+			require.Equal(t, "grafana/ngalert/definitions/3", live.messages[0].channel)
+			require.JSONEq(t, `{"id": 7, "uid": "rule-uid", "action": "`+string(action)+`", "timestamp": "2023-05-01T12:00:00Z"}`, string(live.messages[0].data))
+		})
+	}
+
+	t.Run("failure to publish is not returned", func(t *testing.T) {
+		live := &fakeLive{err: errors.New("live is down")}
+		p := NewPublisher(live.Publish, log.NewNopLogger())
+
+		err := p.RuleChanged(context.Background(), &models.AlertRuleChanged{OrgID: 1, UID: "rule-uid", Action: models.AlertRuleDeleted, Timestamp: now})
+
+		require.NoError(t, err)
+		require.Len(t, live.messages, 1)
+	})
+}
+
+func TestPublisher_PublishTransitions(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+	live := &fakeLive{}
+	p := NewPublisher(live.Publish, log.NewNopLogger())
+	rule := &models.AlertRule{OrgID: 2, UID: "rule-uid"}
+
+	p.PublishTransitions(context.Background(), rule, []state.StateTransition{
+		{
+			State: &state.State{
+				State:              eval.Alerting,
+				Labels:             data.Labels{"team": "a", "__alert_rule_uid__": "rule-uid"},
+				LastEvaluationTime: now,
+			},
+			PreviousState: eval.Pending,
+		},
+		{
+			State: &state.State{
+				State:              eval.Normal,
+				StateReason:        models.StateReasonPaused,
+				Labels:             data.Labels{"team": "b"},
+				LastEvaluationTime: now,
+			},
+			PreviousState: eval.Alerting,
+		},
+	})
+
+	require.Len(t, live.messages, 2)
+	for _, m := range live.messages {
+		require.Equal(t, int64(2), m.orgID)
+		require.Equal(t, "grafana/ngalert/instances/rule-uid", m.channel)
+	}
+	require.JSONEq(t, `{"uid": "rule-uid", "action": "state-changed", "labels": {"team": "a"}, "state": "Alerting", "previousState": "Pending", "timestamp": "2023-05-01T12:00:00Z"}`, string(live.messages[0].data))
+	var event InstanceEvent
+	require.NoError(t, json.Unmarshal(live.messages[1].data, &event))
+	require.Equal(t, "Normal (Paused)", event.State)
+	require.Equal(t, "Alerting", event.PreviousState)
+}
+
+type fakeRuleStore struct {
+	rules []*models.AlertRule
+}
+
+func (f *fakeRuleStore) GetAlertRuleByUID(_ context.Context, query *models.GetAlertRuleByUIDQuery) (*models.AlertRule, error) {
+	for _, r := range f.rules {
+		if r.OrgID == query.OrgID && r.UID == query.UID {
+			return r, nil
+		}
+	}
+	return nil, models.ErrAlertRuleNotFound
+}
+
+func TestChannelHandler_OnSubscribe(t *testing.T) {
+	rules := &fakeRuleStore{rules: []*models.AlertRule{{OrgID: 1, UID: "rule-uid", NamespaceUID: "folder-uid"}}}
+	canRead := acmock.New().WithPermissions([]accesscontrol.Permission{
+		{Action: accesscontrol.ActionAlertingRuleRead, Scope: dashboards.ScopeFoldersProvider.GetResourceScopeUID("folder-uid")},
+	})
+	cannotRead := acmock.New()
+	u := &user.SignedInUser{OrgID: 1}
+
+	testCases := []struct {
+		name     string
+		ac       accesscontrol.AccessControl
+		path     string
+		expected backend.SubscribeStreamStatus
+	}{
+		{name: "definitions of the organization of the user", ac: canRead, path: "definitions/1", expected: backend.SubscribeStreamStatusOK},
+		{name: "definitions of another organization", ac: canRead, path: "definitions/2", expected: backend.SubscribeStreamStatusPermissionDenied},
+		{name: "definitions without permission", ac: cannotRead, path: "definitions/1", expected: backend.SubscribeStreamStatusPermissionDenied},
+		{name: "instances of a readable rule", ac: canRead, path: "instances/rule-uid", expected: backend.SubscribeStreamStatusOK},
+		{name: "instances without permission", ac: cannotRead, path: "instances/rule-uid", expected: backend.SubscribeStreamStatusPermissionDenied},
+		{name: "instances of an unknown rule", ac: canRead, path: "instances/unknown", expected: backend.SubscribeStreamStatusNotFound},
+		{name: "unknown path", ac: canRead, path: "unknown/1", expected: backend.SubscribeStreamStatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewChannelHandler(tc.ac, rules)
+
+			_, status, err := h.OnSubscribe(context.Background(), u, livemodel.SubscribeEvent{Path: tc.path})
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, status)
+		})
+	}
+}
+
+func TestChannelHandler_OnSubscribe_AccessControlDisabled(t *testing.T) {
+	rules := &fakeRuleStore{rules: []*models.AlertRule{
+		{OrgID: 1, UID: "visible-rule", NamespaceUID: "visible-folder"},
+		{OrgID: 1, UID: "hidden-rule", NamespaceUID: "hidden-folder"},
+	}}
+	origNewGuardian := guardian.NewByUID
+	t.Cleanup(func() {
+		guardian.NewByUID = origNewGuardian
+	})
+	guardian.NewByUID = func(_ context.Context, folderUID string, _ int64, _ *user.SignedInUser) (guardian.DashboardGuardian, error) {
+		return &guardian.FakeDashboardGuardian{CanViewValue: folderUID == "visible-folder"}, nil
+	}
+	h := NewChannelHandler(acmock.New().WithDisabled(), rules)
+	viewer := &user.SignedInUser{OrgID: 1, OrgRole: org.RoleViewer}
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected backend.SubscribeStreamStatus
+	}{
+		{name: "instances of a rule in a folder the viewer can view", path: "instances/visible-rule", expected: backend.SubscribeStreamStatusOK},
+		{name: "instances of a rule in a folder the viewer cannot view", path: "instances/hidden-rule", expected: backend.SubscribeStreamStatusPermissionDenied},
+		{name: "instances of an unknown rule", path: "instances/unknown", expected: backend.SubscribeStreamStatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, status, err := h.OnSubscribe(context.Background(), viewer, livemodel.SubscribeEvent{Path: tc.path})
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, status)
+		})
+	}
+}
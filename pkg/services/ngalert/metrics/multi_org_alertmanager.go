@@ -18,6 +18,12 @@ type MultiOrgAlertmanager struct {
 	ActiveConfigurations     prometheus.Gauge
 	DiscoveredConfigurations prometheus.Gauge
 
+	// ContactPointDeliveryAttempts, ContactPointDeliveryRetries and ContactPointDeliveryPermanentFailures count the
+	// attempts to deliver the notifications of alert rules to their contact points, by type of contact point.
+	ContactPointDeliveryAttempts          *prometheus.CounterVec
+	ContactPointDeliveryRetries           *prometheus.CounterVec
+	ContactPointDeliveryPermanentFailures *prometheus.CounterVec
+
 	aggregatedMetrics *AlertmanagerAggregatedMetrics
 }
 
@@ -38,6 +44,24 @@ func NewMultiOrgAlertmanagerMetrics(r prometheus.Registerer) *MultiOrgAlertmanag
 			Name:      "active_configurations",
 			Help:      "The number of active Alertmanager configurations.",
 		}),
+		ContactPointDeliveryAttempts: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "contact_point_delivery_attempts_total",
+			Help:      "The total number of attempts to deliver notifications of alert rules to contact points.",
+		}, []string{"type"}),
+		ContactPointDeliveryRetries: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "contact_point_delivery_retries_total",
+			Help:      "The total number of retries of failed deliveries of notifications of alert rules to contact points.",
+		}, []string{"type"}),
+		ContactPointDeliveryPermanentFailures: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "contact_point_delivery_permanent_failures_total",
+			Help:      "The total number of notifications of alert rules that could not be delivered to contact points after all attempts.",
+		}, []string{"type"}),
 		aggregatedMetrics: NewAlertmanagerAggregatedMetrics(registries),
 	}
 
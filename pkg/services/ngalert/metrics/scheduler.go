@@ -17,6 +17,9 @@ type Scheduler struct {
 	BehindSeconds                       prometheus.Gauge
 	EvalTotal                           *prometheus.CounterVec
 	EvalFailures                        *prometheus.CounterVec
+	EvalFailuresByReason                *prometheus.CounterVec
+	EvalInFlight                        prometheus.Gauge
+	EvalPanics                          *prometheus.CounterVec
 	EvalDuration                        *prometheus.HistogramVec
 	GroupRules                          *prometheus.GaugeVec
 	SchedulePeriodicDuration            prometheus.Histogram
@@ -25,6 +28,9 @@ type Scheduler struct {
 	UpdateSchedulableAlertRulesDuration prometheus.Histogram
 	Ticker                              *ticker.Metrics
 	EvaluationMissed                    *prometheus.CounterVec
+	EvaluationThrottled                 *prometheus.CounterVec
+	EvaluationPaused                    prometheus.Gauge
+	ShadowDiscrepancies                 *prometheus.CounterVec
 }
 
 func NewSchedulerMetrics(r prometheus.Registerer) *Scheduler {
@@ -58,6 +64,32 @@ func NewSchedulerMetrics(r prometheus.Registerer) *Scheduler {
 			},
 			[]string{"org"},
 		),
+		EvalFailuresByReason: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "rule_evaluation_failures_by_reason_total",
+				Help:      "The total number of rule evaluation failures by the class of the error.",
+			},
+			[]string{"org", "reason"},
+		),
+		EvalInFlight: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "rule_evaluations_in_flight",
+				Help:      "The number of rule evaluations that are currently running.",
+			},
+		),
+		EvalPanics: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "rule_evaluation_panics_total",
+				Help:      "The total number of rule evaluations that panicked.",
+			},
+			[]string{"org"},
+		),
 		EvalDuration: promauto.With(r).NewHistogramVec(
 			prometheus.HistogramOpts{
 				Namespace: Namespace,
@@ -121,5 +153,31 @@ func NewSchedulerMetrics(r prometheus.Registerer) *Scheduler {
 			},
 			[]string{"org", "name"},
 		),
+		EvaluationThrottled: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "schedule_rule_evaluations_throttled_total",
+				Help:      "The total number of rule evaluations deferred to the next tick because the organization exceeded its limit of evaluations per minute (rate_limit), or the maximum number of evaluations were running (concurrency_limit).",
+			},
+			[]string{"org", "reason"},
+		),
+		EvaluationPaused: promauto.With(r).NewGauge(
+			prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "schedule_evaluation_paused",
+				Help:      "Whether evaluation of all alert rules is paused by an administrator (1) or not (0).",
+			},
+		),
+		ShadowDiscrepancies: promauto.With(r).NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: Namespace,
+				Subsystem: Subsystem,
+				Name:      "shadow_discrepancies_total",
+				Help:      "The total number of times an alert rule in shadow mode disagreed with its legacy alert for longer than the tolerance.",
+			},
+			[]string{"org", "rule_uid", "kind"},
+		),
 	}
 }
@@ -6,7 +6,14 @@ import (
 )
 
 type State struct {
-	AlertState *prometheus.GaugeVec
+	AlertState            *prometheus.GaugeVec
+	InstanceLimitExceeded *prometheus.CounterVec
+	// ContactPointNotifications and ContactPointNotificationFailures count the notifications sent to the contact points of
+	// alert rules when their instances start alerting.
+	ContactPointNotifications        *prometheus.CounterVec
+	ContactPointNotificationFailures *prometheus.CounterVec
+	// StateHistoryFailures counts the state transitions that could not be converted to entries of the state history.
+	StateHistoryFailures *prometheus.CounterVec
 }
 
 func NewStateMetrics(r prometheus.Registerer) *State {
@@ -17,5 +24,29 @@ func NewStateMetrics(r prometheus.Registerer) *State {
 			Name:      "alerts",
 			Help:      "How many alerts by state.",
 		}, []string{"state"}),
+		InstanceLimitExceeded: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "rule_instance_limit_exceeded_total",
+			Help:      "The total number of evaluations that returned more series than the instance limit of the rule.",
+		}, []string{"org"}),
+		ContactPointNotifications: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "contact_point_notifications_total",
+			Help:      "The total number of notifications sent to the contact points of alert rules.",
+		}, []string{"org"}),
+		ContactPointNotificationFailures: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "contact_point_notification_failures_total",
+			Help:      "The total number of notifications that could not be sent to the contact points of alert rules.",
+		}, []string{"org"}),
+		StateHistoryFailures: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "state_history_failures_total",
+			Help:      "The total number of state transitions that could not be recorded in the state history.",
+		}, []string{"org"}),
 	}
 }
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Store struct {
+	OperationDuration *prometheus.HistogramVec
+	OperationFailures *prometheus.CounterVec
+}
+
+// NewStoreMetrics creates the metrics of the database operations of the alerting store. They can be created more than
+// once with the same registerer, in which case the metrics that are already registered are returned.
+func NewStoreMetrics(r prometheus.Registerer) *Store {
+	return &Store{
+		OperationDuration: registerOrGet(r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "ngalert_store_operation_duration_seconds",
+			Help:      "Histogram of the durations of the database operations of the alerting store.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"})),
+		OperationFailures: registerOrGet(r, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: Subsystem,
+			Name:      "ngalert_store_operation_failures_total",
+			Help:      "The total number of database operations of the alerting store that failed.",
+		}, []string{"operation"})),
+	}
+}
+
+// registerOrGet registers the collector, or returns the collector that is already registered with the same
+// description.
+func registerOrGet[T prometheus.Collector](r prometheus.Registerer, c T) T {
+	err := r.Register(c)
+	if err == nil {
+		return c
+	}
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(T); ok {
+			return existing
+		}
+	}
+	panic(err)
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// ActorID returns the ID that records the user as the author of changes to alerting resources, such as the creator of an
+// alert rule or the actor of an audit entry. Users and service accounts are recorded by their user ID. API keys that are not
+// linked to a service account have no user, so they are recorded by the negated ID of the key.
+func ActorID(u *user.SignedInUser) int64 {
+	if u == nil {
+		return 0
+	}
+	if u.UserID == 0 && u.IsApiKeyUser() {
+		return -u.ApiKeyID
+	}
+	return u.UserID
+}
+
+// ActorName returns the name that records the user as the author of changes to alerting resources, such as the reset of an
+// alert instance. Users and service accounts are recorded by their login, and API keys that are not linked to a service
+// account as "api-key:<ID>".
+func ActorName(u *user.SignedInUser) string {
+	if u == nil {
+		return ""
+	}
+	if u.UserID == 0 && u.IsApiKeyUser() {
+		return fmt.Sprintf("api-key:%d", u.ApiKeyID)
+	}
+	return u.Login
+}
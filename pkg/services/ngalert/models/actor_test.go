@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestActor(t *testing.T) {
+	testCases := []struct {
+		name         string
+		user         *user.SignedInUser
+		expectedID   int64
+		expectedName string
+	}{
+		{
+			name:         "no user",
+			expectedID:   0,
+			expectedName: "",
+		},
+		{
+			name:         "user",
+			user:         &user.SignedInUser{UserID: 3, Login: "editor"},
+			expectedID:   3,
+			expectedName: "editor",
+		},
+		{
+			name:         "service account",
+			user:         &user.SignedInUser{UserID: 4, Login: "sa-terraform", IsServiceAccount: true},
+			expectedID:   4,
+			expectedName: "sa-terraform",
+		},
+		{
+			name:         "API key linked to a service account",
+			user:         &user.SignedInUser{UserID: 4, Login: "sa-terraform", IsServiceAccount: true, ApiKeyID: 7},
+			expectedID:   4,
+			expectedName: "sa-terraform",
+		},
+		{
+			name:         "API key",
+			user:         &user.SignedInUser{ApiKeyID: 7},
+			expectedID:   -7,
+			expectedName: "api-key:7",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedID, ActorID(tc.user))
+			require.Equal(t, tc.expectedName, ActorName(tc.user))
+		})
+	}
+}
@@ -103,6 +103,9 @@ func (aq *AlertQuery) setModelProps() error {
 	if err != nil {
 		return fmt.Errorf("failed to unmarshal query model: %w", err)
 	}
+	if aq.modelProps == nil {
+		return errors.New("query model must be a JSON object")
+	}
 
 	return nil
 }
@@ -216,6 +219,9 @@ func (aq *AlertQuery) GetQuery() (string, error) {
 	return q, nil
 }
 
+// GetModel returns the model with maxDataPoints and intervalMs set to their defaults if they are missing or invalid, as
+// the data sources and expressions require them. The other properties are returned as they are in Model, so that the
+// properties that are specific to the data source are passed on unchanged, including the precision of their numbers.
 func (aq *AlertQuery) GetModel() ([]byte, error) {
 	err := aq.setMaxDatapoints()
 	if err != nil {
@@ -227,11 +233,25 @@ func (aq *AlertQuery) GetModel() ([]byte, error) {
 		return nil, err
 	}
 
-	model, err := json.Marshal(aq.modelProps)
+	var model map[string]json.RawMessage
+	if err := json.Unmarshal(aq.Model, &model); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal query model: %w", err)
+	}
+	if model == nil {
+		model = make(map[string]json.RawMessage, 2)
+	}
+	for _, key := range []string{"maxDataPoints", "intervalMs"} {
+		value, err := json.Marshal(aq.modelProps[key])
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal query model: %w", err)
+		}
+		model[key] = value
+	}
+	result, err := json.Marshal(model)
 	if err != nil {
 		return nil, fmt.Errorf("unable to marshal query model: %w", err)
 	}
-	return model, nil
+	return result, nil
 }
 
 func (aq *AlertQuery) setQueryType() error {
@@ -255,20 +275,14 @@ func (aq *AlertQuery) setQueryType() error {
 	return nil
 }
 
-// PreSave sets query's properties.
-// It should be called before being saved.
+// PreSave sets query's properties and validates the query.
+// It should be called before being saved. The model is saved as it was submitted: the defaults of maxDataPoints and
+// intervalMs are applied by GetModel when the query is evaluated.
 func (aq *AlertQuery) PreSave() error {
 	if err := aq.setQueryType(); err != nil {
 		return fmt.Errorf("failed to set query type to query model: %w", err)
 	}
 
-	// override model
-	model, err := aq.GetModel()
-	if err != nil {
-		return err
-	}
-	aq.Model = model
-
 	isExpression, err := aq.IsExpression()
 	if err != nil {
 		return err
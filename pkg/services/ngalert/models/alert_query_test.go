@@ -282,3 +282,30 @@ func TestAlertQuery_GetQuery(t *testing.T) {
 		})
 	}
 }
+
+func TestAlertQuery_PreSave(t *testing.T) {
+	const model = `{ "refId": "A", "queryType": "range", "custom": {"nested": [1, {"key": null}]}, "bigNumber": 9007199254740993 }`
+
+	t.Run("should keep the model as it was submitted", func(t *testing.T) {
+		aq := AlertQuery{RefID: "A", Model: json.RawMessage(model), RelativeTimeRange: RelativeTimeRange{From: Duration(time.Hour)}}
+		require.NoError(t, aq.PreSave())
+		require.Equal(t, model, string(aq.Model))
+		require.Equal(t, "range", aq.QueryType)
+	})
+
+	t.Run("should fail if the model is not a JSON object", func(t *testing.T) {
+		for _, model := range []string{``, `null`, `[1]`, `{"refId":`} {
+			aq := AlertQuery{RefID: "A", Model: json.RawMessage(model), RelativeTimeRange: RelativeTimeRange{From: Duration(time.Hour)}}
+			require.Errorf(t, aq.PreSave(), "model %q", model)
+		}
+	})
+
+	t.Run("GetModel should set the defaults and keep the other properties", func(t *testing.T) {
+		aq := AlertQuery{RefID: "A", Model: json.RawMessage(model)}
+		result, err := aq.GetModel()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"refId":"A","queryType":"range","custom":{"nested":[1,{"key":null}]},"bigNumber":9007199254740993,"maxDataPoints":43200,"intervalMs":1000}`, string(result))
+		require.Contains(t, string(result), `"bigNumber":9007199254740993`)
+		require.Equal(t, model, string(aq.Model))
+	})
+}
@@ -20,6 +20,12 @@ import (
 var (
 	// ErrAlertRuleNotFound is an error for an unknown alert rule.
 	ErrAlertRuleNotFound = fmt.Errorf("could not find alert rule")
+	// ErrAlertRuleIsPaused is an error returned when an operation requires the alert rule to be active.
+	ErrAlertRuleIsPaused = errors.New("alert rule is paused")
+	// ErrEvaluationPaused is an error returned when evaluation of all alert rules is paused by an administrator.
+	ErrEvaluationPaused = errors.New("evaluation of alert rules is paused")
+	// ErrAlertRuleNotOwned is an error returned when an operation must run on the scheduler instance that evaluates the alert rule.
+	ErrAlertRuleNotOwned = errors.New("alert rule is evaluated by another instance")
 	// ErrAlertRuleFailedGenerateUniqueUID is an error for failure to generate alert rule UID
 	ErrAlertRuleFailedGenerateUniqueUID = errors.New("failed to generate alert rule UID")
 	// ErrCannotEditNamespace is an error returned if the user does not have permissions to edit the namespace
@@ -93,6 +99,8 @@ const (
 	// Annotations are actually a set of labels, so technically this is the label name of an annotation.
 	DashboardUIDAnnotation = "__dashboardUid__"
 	PanelIDAnnotation      = "__panelId__"
+	// MigratedAlertIDAnnotation is the ID of the legacy dashboard alert that the rule was migrated from.
+	MigratedAlertIDAnnotation = "__alertId__"
 
 	// GrafanaReservedLabelPrefix contains the prefix for Grafana reserved labels. These differ from "__<label>__" labels
 	// in that they are not meant for internal-use only and will be passed-through to AMs and available to users in the same
@@ -112,6 +120,11 @@ const (
 	StateReasonPaused        = "Paused"
 	StateReasonUpdated       = "Updated"
 	StateReasonRuleDeleted   = "RuleDeleted"
+	// StateReasonResolving is the reason of an Alerting state that is kept although the latest evaluations are normal,
+	// because there have not been enough consecutive normal evaluations to resolve it.
+	StateReasonResolving = "Resolving"
+	// StateReasonManualReset is the reason of a Normal state that a user set by resetting the instance.
+	StateReasonManualReset = "ManualReset"
 )
 
 var (
@@ -160,6 +173,42 @@ type AlertRule struct {
 	Annotations map[string]string
 	Labels      map[string]string
 	IsPaused    bool
+	// Schedule is an optional cron expression. If it is empty, the rule is evaluated every IntervalSeconds.
+	Schedule string
+	// ScheduleTimezone is the IANA time zone in which Schedule is interpreted. Empty means UTC.
+	ScheduleTimezone string
+	// InstanceLimit is the maximum number of instances of the rule. Zero means that the default limit applies.
+	InstanceLimit int64
+	// NotificationTitle and NotificationMessage are the templates of the title and the message of the notifications that
+	// are sent to the contact points of the rule. Empty templates keep the defaults of the contact points.
+	NotificationTitle   string
+	NotificationMessage string
+	// NotificationRepeatInterval is how often the contact points of the rule are notified again about the instances that
+	// are still alerting. Zero means that they are notified only once.
+	NotificationRepeatInterval time.Duration
+	// SendResolved tells whether the contact points of the rule are notified when an instance stops alerting.
+	SendResolved bool
+	// CreatedBy is the ID of the user who created the rule, as returned by ActorID. Zero means that the creator is unknown,
+	// and a negative ID is the negated ID of an API key.
+	CreatedBy int64
+	// ContactPointUIDs are the UIDs of the contact points of the organization that the rule is associated with. They are
+	// stored in the alert_rule_contact_point table.
+	ContactPointUIDs []string `xorm:"-"`
+}
+
+// AlertRuleSummary is the part of an alert rule that lists of alert rules show. It does not have the queries and
+// expressions of the rule, which can be large, so that lists of many rules do not read them.
+type AlertRuleSummary struct {
+	ID              int64  `xorm:"pk autoincr 'id'"`
+	OrgID           int64  `xorm:"org_id"`
+	UID             string `xorm:"uid"`
+	Title           string
+	NamespaceUID    string `xorm:"namespace_uid"`
+	RuleGroup       string
+	RuleGroupIndex  int `xorm:"rule_group_idx"`
+	IntervalSeconds int64
+	IsPaused        bool
+	Updated         time.Time
 }
 
 // AlertRuleWithOptionals This is to avoid having to pass in additional arguments deep in the call stack. Alert rule
@@ -319,6 +368,26 @@ func (alertRule *AlertRule) PreSave(timeNow func() time.Time) error {
 	return nil
 }
 
+// ValidateNotificationRepeatInterval checks that the notification repeat interval of the rule is not negative.
+func (alertRule *AlertRule) ValidateNotificationRepeatInterval() error {
+	if alertRule.NotificationRepeatInterval < 0 {
+		return fmt.Errorf("%w: notification repeat interval cannot be negative", ErrAlertRuleFailedValidation)
+	}
+	return nil
+}
+
+// ValidateInstanceLimit checks that the instance limit of the rule is not negative and does not exceed max. Zero max means
+// that the limit is not bounded.
+func (alertRule *AlertRule) ValidateInstanceLimit(max int64) error {
+	if alertRule.InstanceLimit < 0 {
+		return fmt.Errorf("%w: instance limit cannot be negative", ErrAlertRuleFailedValidation)
+	}
+	if max > 0 && alertRule.InstanceLimit > max {
+		return fmt.Errorf("%w: instance limit cannot be greater than %d", ErrAlertRuleFailedValidation, max)
+	}
+	return nil
+}
+
 func (alertRule *AlertRule) ResourceType() string {
 	return "alertRule"
 }
@@ -356,6 +425,21 @@ type AlertRuleVersion struct {
 	Annotations map[string]string
 	Labels      map[string]string
 	IsPaused    bool
+	// Schedule is an optional cron expression. If it is empty, the rule is evaluated every IntervalSeconds.
+	Schedule string
+	// ScheduleTimezone is the IANA time zone in which Schedule is interpreted. Empty means UTC.
+	ScheduleTimezone string
+	// InstanceLimit is the maximum number of instances of the rule. Zero means that the default limit applies.
+	InstanceLimit int64
+	// NotificationTitle and NotificationMessage are the templates of the title and the message of the notifications that
+	// are sent to the contact points of the rule. Empty templates keep the defaults of the contact points.
+	NotificationTitle   string
+	NotificationMessage string
+	// NotificationRepeatInterval is how often the contact points of the rule are notified again about the instances that
+	// are still alerting. Zero means that they are notified only once.
+	NotificationRepeatInterval time.Duration
+	// SendResolved tells whether the contact points of the rule are notified when an instance stops alerting.
+	SendResolved bool
 }
 
 // GetAlertRuleByUIDQuery is the query for retrieving/deleting an alert rule by UID and organisation ID.
@@ -381,6 +465,18 @@ type ListAlertRulesQuery struct {
 	// to return just those for a dashboard and panel.
 	DashboardUID string
 	PanelID      int64
+
+	// TitleSearch is optional and allows filtering rules to return just those whose title contains it, ignoring case.
+	// Wildcard characters are matched literally.
+	TitleSearch string
+}
+
+// ExportPrometheusRulesQuery is the query for exporting alert rules as Prometheus rule groups
+type ExportPrometheusRulesQuery struct {
+	OrgID int64
+	// NamespaceUID and RuleGroup are optional and allow exporting just the rules of a folder or of a rule group.
+	NamespaceUID string
+	RuleGroup    string
 }
 
 // CountAlertRulesQuery is the query for counting alert rules
@@ -499,6 +595,25 @@ const (
 	QuotaTarget    quota.Target    = "alert_rule"
 )
 
+// QuotaReachedError is returned when the alert rule quota does not allow creating the rule with the title RuleTitle.
+// Limit and Usage describe the quota that is reached, they are zero if they could not be fetched.
+type QuotaReachedError struct {
+	Limit     int64
+	Usage     int64
+	RuleTitle string
+}
+
+func (e *QuotaReachedError) Error() string {
+	if e.Limit == 0 && e.Usage == 0 {
+		return fmt.Sprintf("%s: cannot create alert rule '%s'", ErrQuotaReached, e.RuleTitle)
+	}
+	return fmt.Sprintf("%s: cannot create alert rule '%s', %d of %d alert rules are used", ErrQuotaReached, e.RuleTitle, e.Usage, e.Limit)
+}
+
+func (e *QuotaReachedError) Unwrap() error {
+	return ErrQuotaReached
+}
+
 type ruleKeyContextKey struct{}
 
 func WithRuleKey(ctx context.Context, ruleKey AlertRuleKey) context.Context {
@@ -509,3 +624,40 @@ func RuleKeyFromContext(ctx context.Context) (AlertRuleKey, bool) {
 	key, ok := ctx.Value(ruleKeyContextKey{}).(AlertRuleKey)
 	return key, ok
 }
+
+type ruleEvaluationContextKey struct{}
+
+type ruleEvaluation struct {
+	id          int64
+	title       string
+	evaluatedAt time.Time
+}
+
+// WithRule returns a context that carries the key, ID and title of the rule.
+func WithRule(ctx context.Context, rule *AlertRule) context.Context {
+	return WithRuleEvaluation(ctx, rule, time.Time{})
+}
+
+// WithRuleEvaluation returns a context that carries the key, ID and title of the rule, and the time of its evaluation.
+func WithRuleEvaluation(ctx context.Context, rule *AlertRule, evaluatedAt time.Time) context.Context {
+	ctx = WithRuleKey(ctx, rule.GetKey())
+	return context.WithValue(ctx, ruleEvaluationContextKey{}, ruleEvaluation{id: rule.ID, title: rule.Title, evaluatedAt: evaluatedAt})
+}
+
+// RuleLogContextFromContext returns the log context of the rule that the context carries, so that all the log lines
+// about a rule have the same keys. It is registered as a contextual log provider, and returns false if the context
+// carries no rule.
+func RuleLogContextFromContext(ctx context.Context) ([]interface{}, bool) {
+	key, ok := RuleKeyFromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	logCtx := key.LogContext()
+	if e, ok := ctx.Value(ruleEvaluationContextKey{}).(ruleEvaluation); ok {
+		logCtx = append(logCtx, "rule_id", e.id, "rule_title", e.title)
+		if !e.evaluatedAt.IsZero() {
+			logCtx = append(logCtx, "eval_time", e.evaluatedAt)
+		}
+	}
+	return logCtx, true
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// AlertRuleAuditAction is the kind of change of an alert rule that is recorded in its audit trail.
+type AlertRuleAuditAction string
+
+const (
+	AlertRuleAuditCreate  AlertRuleAuditAction = "create"
+	AlertRuleAuditUpdate  AlertRuleAuditAction = "update"
+	AlertRuleAuditPause   AlertRuleAuditAction = "pause"
+	AlertRuleAuditUnpause AlertRuleAuditAction = "unpause"
+	AlertRuleAuditDelete  AlertRuleAuditAction = "delete"
+)
+
+// AlertRuleAuditEntry records who changed an alert rule, when and how. It is written in the same transaction as the
+// change, and it is kept when the rule is deleted.
+type AlertRuleAuditEntry struct {
+	ID      int64  `xorm:"pk autoincr 'id'"`
+	OrgID   int64  `xorm:"org_id"`
+	RuleUID string `xorm:"rule_uid"`
+	// NamespaceUID is the folder of the rule after the change, which authorizes access to the entries of deleted rules.
+	NamespaceUID string `xorm:"namespace_uid"`
+	Action       AlertRuleAuditAction
+	// ActorID is the ID of the user who changed the rule, as returned by ActorID. Zero means that the rule was not changed
+	// by a user, e.g. by file provisioning, and a negative ID is the negated ID of an API key.
+	ActorID int64 `xorm:"actor_id"`
+	Created time.Time
+	// Changes are the names of the fields of the rule that were changed by an update. They are empty for the other
+	// actions.
+	Changes []string
+}
+
+// A XORM interface that defines the used table for this struct.
+func (e *AlertRuleAuditEntry) TableName() string {
+	return "alert_rule_audit"
+}
+
+// ListAlertRuleAuditQuery is the query for a page of the audit entries of a rule, from the newest.
+type ListAlertRuleAuditQuery struct {
+	OrgID   int64
+	RuleUID string
+	// Limit is the maximum number of entries. Zero returns all the entries.
+	Limit  int64
+	Offset int64
+}
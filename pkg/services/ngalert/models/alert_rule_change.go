@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AlertRuleChangeAction is the kind of change of an alert rule.
+type AlertRuleChangeAction string
+
+const (
+	AlertRuleCreated AlertRuleChangeAction = "created"
+	AlertRuleUpdated AlertRuleChangeAction = "updated"
+	AlertRuleDeleted AlertRuleChangeAction = "deleted"
+)
+
+// AlertRuleChanged is published on the bus once the transaction that created, updated or deleted an alert rule is
+// committed.
+type AlertRuleChanged struct {
+	OrgID int64
+	// ID is the ID of the rule. It is zero if the rule is deleted.
+	ID        int64
+	UID       string
+	Action    AlertRuleChangeAction
+	Timestamp time.Time
+}
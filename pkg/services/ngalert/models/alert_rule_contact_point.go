@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// RunbookURLAnnotation is the name of the annotation that links to the runbook of an alert rule.
+const RunbookURLAnnotation = "runbook_url"
+
+var ErrContactPointInUse = errors.New("contact point is used by alert rules")
+
+// AlertRuleContactPoint associates an alert rule with a contact point of the same organization.
+type AlertRuleContactPoint struct {
+	ID              int64  `xorm:"pk autoincr 'id'"`
+	RuleOrgID       int64  `xorm:"rule_org_id"`
+	RuleUID         string `xorm:"rule_uid"`
+	ContactPointUID string `xorm:"contact_point_uid"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (c *AlertRuleContactPoint) TableName() string {
+	return "alert_rule_contact_point"
+}
+
+// AlertingNotification describes an alert instance that started alerting, is still alerting, or was resolved. It is sent
+// to the contact points that the rule of the instance is associated with.
+type AlertingNotification struct {
+	OrgID     int64
+	RuleUID   string
+	RuleTitle string
+	// Labels are the labels of the instance, including the labels of the rule.
+	Labels map[string]string
+	// Annotations are the annotations of the rule, after their templates are expanded.
+	Annotations map[string]string
+	// Value is the string representation of the values of the evaluation that fired the instance.
+	Value      string
+	RunbookURL string
+	StartsAt   time.Time
+	// EndsAt is the time at which the instance was resolved. It is zero if the instance is alerting.
+	EndsAt time.Time
+	// Title and Message are rendered from the notification templates of the rule, and are sent as the summary and
+	// description annotations. They are empty if the rule does not have the templates.
+	Title   string
+	Message string
+	// Count is the number of instances that the notification is about. Notifications of several instances that started
+	// alerting at about the same time have only the labels and annotations that the instances have in common.
+	Count int
+	// Instances are the labels of the first instances of a notification of several instances. It is empty for the
+	// notification of a single instance.
+	Instances []map[string]string
+}
+
+// Resolved returns true if the notification is about an instance that was resolved.
+func (n AlertingNotification) Resolved() bool {
+	return !n.EndsAt.IsZero()
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"sort"
+)
+
+// AlertRuleDatasource associates an alert rule with a data source that its queries read. The associations are derived
+// from the Data of the rule when it is saved, so that rules can be found by data source without parsing their queries.
+type AlertRuleDatasource struct {
+	ID            int64  `xorm:"pk autoincr 'id'"`
+	RuleOrgID     int64  `xorm:"rule_org_id"`
+	RuleUID       string `xorm:"rule_uid"`
+	DatasourceUID string `xorm:"datasource_uid"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (d *AlertRuleDatasource) TableName() string {
+	return "alert_rule_datasource"
+}
+
+// GetDatasourceUIDs returns the sorted UIDs of the data sources that the queries of the rule read, without expressions.
+func (alertRule *AlertRule) GetDatasourceUIDs() []string {
+	seen := make(map[string]struct{}, len(alertRule.Data))
+	result := make([]string, 0, len(alertRule.Data))
+	for i := range alertRule.Data {
+		q := alertRule.Data[i]
+		if isExpression, _ := q.IsExpression(); isExpression {
+			continue
+		}
+		if _, ok := seen[q.DatasourceUID]; ok {
+			continue
+		}
+		seen[q.DatasourceUID] = struct{}{}
+		result = append(result, q.DatasourceUID)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ListAlertRulesAcrossOrgsQuery is the query of a page of the alert rules of all organizations.
+type ListAlertRulesAcrossOrgsQuery struct {
+	// DatasourceUID limits the rules to those whose queries read the data source, if it is set.
+	DatasourceUID string
+	Limit         int64
+	Offset        int64
+}
+
+// AlertRuleWithOrg is an alert rule with the name of its organization.
+type AlertRuleWithOrg struct {
+	*AlertRule
+	OrgName string
+}
@@ -684,3 +684,11 @@ func TestTimeRangeYAML(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, yamlRaw, string(serialized))
 }
+
+func TestValidateInstanceLimit(t *testing.T) {
+	require.NoError(t, (&AlertRule{}).ValidateInstanceLimit(0))
+	require.NoError(t, (&AlertRule{InstanceLimit: 100}).ValidateInstanceLimit(0))
+	require.NoError(t, (&AlertRule{InstanceLimit: 100}).ValidateInstanceLimit(100))
+	require.ErrorIs(t, (&AlertRule{InstanceLimit: 101}).ValidateInstanceLimit(100), ErrAlertRuleFailedValidation)
+	require.ErrorIs(t, (&AlertRule{InstanceLimit: -1}).ValidateInstanceLimit(0), ErrAlertRuleFailedValidation)
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// AlertRuleEvaluation is the outcome of the latest evaluation of an alert rule.
+type AlertRuleEvaluation struct {
+	RuleOrgID int64
+	RuleUID   string
+	// EvaluatedAt is the time of the tick at which the rule was evaluated.
+	EvaluatedAt time.Time
+	// Duration is the wall-clock time the evaluation took.
+	Duration time.Duration
+	// State is the state the evaluation resulted in, for example Normal, Alerting, NoData or Error.
+	State string
+	// Error is the message of the error the evaluation failed with, if any.
+	Error string
+}
+
+// GetAlertRuleEvaluationsQuery is the query for the latest evaluations of the alert rules of an organization.
+type GetAlertRuleEvaluationsQuery struct {
+	OrgID int64
+	// RuleUIDs limits the result to the given rules. If empty, the evaluations of all rules of the organization are returned.
+	RuleUIDs []string
+}
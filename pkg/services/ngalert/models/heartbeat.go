@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SchedulerHeartbeat records the last time a scheduler instance reported that it is alive.
+type SchedulerHeartbeat struct {
+	ID         int64     `xorm:"pk autoincr 'id'"`
+	InstanceID string    `xorm:"instance_id"`
+	Updated    time.Time `xorm:"'updated'"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (h *SchedulerHeartbeat) TableName() string {
+	return "alert_scheduler_heartbeat"
+}
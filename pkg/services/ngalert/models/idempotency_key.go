@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long an idempotency key of a request that created an alert rule is remembered.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+var (
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyConflict = errors.New("idempotency key was saved by a concurrent request")
+)
+
+// IdempotencyKey records the alert rule that was created by a request with the given key, so that retries of the same
+// request return that rule instead of creating another one. RequestHash identifies the body of the request.
+type IdempotencyKey struct {
+	ID          int64     `xorm:"pk autoincr 'id'"`
+	OrgID       int64     `xorm:"org_id"`
+	Key         string    `xorm:"idempotency_key"`
+	RequestHash string    `xorm:"request_hash"`
+	RuleUID     string    `xorm:"rule_uid"`
+	Created     time.Time `xorm:"'created'"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (k *IdempotencyKey) TableName() string {
+	return "alert_rule_idempotency_key"
+}
+
+// IsExpired returns true if the key is older than IdempotencyKeyTTL at the time now.
+func (k *IdempotencyKey) IsExpired(now time.Time) bool {
+	return !k.Created.Add(IdempotencyKeyTTL).After(now)
+}
@@ -25,9 +25,9 @@ func (i *Image) ExtendDuration(d time.Duration) {
 	i.ExpiresAt = i.ExpiresAt.Add(d)
 }
 
-// HasExpired returns true if the image has expired.
-func (i *Image) HasExpired() bool {
-	return timeNow().After(i.ExpiresAt)
+// HasExpired returns true if the image has expired at the given time.
+func (i *Image) HasExpired(now time.Time) bool {
+	return now.After(i.ExpiresAt)
 }
 
 // HasPath returns true if the image has a path on disk.
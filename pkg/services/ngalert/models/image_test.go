@@ -21,19 +21,14 @@ func TestImage_ExtendDuration(t *testing.T) {
 }
 
 func TestImage_HasExpired(t *testing.T) {
-	oldTimeNow := timeNow
-	timeNow = clock.NewMock().Now
-	t.Cleanup(func() {
-		timeNow = oldTimeNow
-	})
-
+	now := clock.NewMock().Now()
 	var i Image
-	i.ExpiresAt = timeNow().Add(time.Minute)
-	assert.False(t, i.HasExpired())
-	i.ExpiresAt = timeNow()
-	assert.False(t, i.HasExpired())
-	i.ExpiresAt = timeNow().Add(-time.Minute)
-	assert.True(t, i.HasExpired())
+	i.ExpiresAt = now.Add(time.Minute)
+	assert.False(t, i.HasExpired(now))
+	i.ExpiresAt = now
+	assert.False(t, i.HasExpired(now))
+	i.ExpiresAt = now.Add(-time.Minute)
+	assert.True(t, i.HasExpired(now))
 }
 
 func TestImage_HasPath(t *testing.T) {
@@ -1,10 +1,14 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
 
+// ErrAlertInstanceNotFound is returned when the requested alert instance does not exist.
+var ErrAlertInstanceNotFound = errors.New("alert instance not found")
+
 // AlertInstance represents a single alert instance.
 type AlertInstance struct {
 	AlertInstanceKey  `xorm:"extends"`
@@ -14,6 +18,11 @@ type AlertInstance struct {
 	CurrentStateSince time.Time
 	CurrentStateEnd   time.Time
 	LastEvalTime      time.Time
+	// ResolvedAt is the time at which the instance went from Alerting to Normal. It is zero if the instance is not Normal,
+	// or if it has not been Alerting before.
+	ResolvedAt time.Time
+	// Values are the values of the expressions of the rule by RefID at the latest evaluation.
+	Values InstanceValues `xorm:"state_values"`
 }
 
 type AlertInstanceKey struct {
@@ -48,10 +57,86 @@ func (i InstanceStateType) IsValid() bool {
 		i == InstanceStateError
 }
 
+// InstanceStatesBySeverity are the instance states ordered from the most to the least severe.
+var InstanceStatesBySeverity = []InstanceStateType{
+	InstanceStateFiring,
+	InstanceStateError,
+	InstanceStateNoData,
+	InstanceStatePending,
+	InstanceStateNormal,
+}
+
 // ListAlertInstancesQuery is the query list alert Instances.
 type ListAlertInstancesQuery struct {
 	RuleUID   string
 	RuleOrgID int64 `json:"-"`
+	// States limits the result to the instances in one of the given states. If empty, instances in all states are returned.
+	States []InstanceStateType
+	// Labels limits the result to the instances that have all of the given labels with exactly the given values.
+	// An instance that does not have one of the labels does not match.
+	Labels map[string]string
+	// Limit is the maximum number of instances to return after skipping Offset instances. If it is set, instances are ordered
+	// by rule UID, by state from the most severe as in InstanceStatesBySeverity, and by labels so that pages are stable.
+	// Zero means no limit.
+	Limit  int64
+	Offset int64
+}
+
+// ListAlertInstancesPageQuery is the query for a page of the alert instances of an organization, ordered by rule UID and
+// labels hash, so that all instances can be read in pages of bounded size.
+type ListAlertInstancesPageQuery struct {
+	RuleOrgID int64
+	// After is the key of the last instance of the previous page. If it is nil, the first page is returned.
+	After *AlertInstanceKey
+	// Limit is the maximum number of instances in the page.
+	Limit int64
+}
+
+// ResetAlertInstancesCommand is the command to reset the instances of a rule to Normal, so that the next evaluation of the rule
+// establishes their state from scratch.
+type ResetAlertInstancesCommand struct {
+	OrgID   int64
+	RuleUID string
+	// LabelsHash limits the reset to the instance with the given labels hash. If empty, all instances of the rule are reset.
+	LabelsHash string
+	// ResetBy is the login of the user who requested the reset, as returned by ActorName. It is recorded in the state history.
+	ResetBy string
+}
+
+// instanceStateSeverity orders the instance states from the most to the least severe.
+var instanceStateSeverity = []InstanceStateType{
+	InstanceStateError,
+	InstanceStateFiring,
+	InstanceStatePending,
+	InstanceStateNoData,
+	InstanceStateNormal,
+}
+
+// GetAlertInstanceStateSummariesQuery is the query for the number of alert instances per state of the alert rules of an organization.
+type GetAlertInstanceStateSummariesQuery struct {
+	OrgID int64
+	// RuleUIDs limits the result to the given rules. If empty, the summaries of all rules of the organization are returned.
+	RuleUIDs []string
+}
+
+// AlertInstanceStateSummary is the number of alert instances of an alert rule per state.
+type AlertInstanceStateSummary struct {
+	RuleUID string
+	Counts  map[InstanceStateType]int64
+}
+
+// WorstState returns the most severe state that at least one instance is in. The order from the most severe is
+// Error, Alerting, Pending, NoData and Normal. It returns Normal if there are no instances.
+func (s *AlertInstanceStateSummary) WorstState() InstanceStateType {
+	if s == nil {
+		return InstanceStateNormal
+	}
+	for _, state := range instanceStateSeverity {
+		if s.Counts[state] > 0 {
+			return state
+		}
+	}
+	return InstanceStateNormal
 }
 
 // ValidateAlertInstance validates that the alert instance contains an alert rule id,
@@ -47,6 +47,16 @@ func (il *InstanceLabels) StringKey() (string, error) {
 	return string(b), nil
 }
 
+// LabelTupleString returns the JSON representation of a single label as it appears in the string returned by StringKey,
+// so that labels can be matched in the database without decoding the stored labels.
+func LabelTupleString(name, value string) (string, error) {
+	b, err := json.Marshal(tupleLabel{name, value})
+	if err != nil {
+		return "", fmt.Errorf("could not encode label %s: %w", name, err)
+	}
+	return string(b), nil
+}
+
 // StringAndHash returns a the json representation of the labels as tuples
 // sorted by key. It also returns the a hash of that representation.
 func (il *InstanceLabels) StringAndHash() (string, string, error) {
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceLabels_StringAndHash(t *testing.T) {
+	t.Run("should not depend on the order of labels", func(t *testing.T) {
+		var fromDB1, fromDB2 InstanceLabels
+		require.NoError(t, fromDB1.FromDB([]byte(`[["alertname","HighCPU"],["instance","host-1"],["severity","critical"]]`)))
+		require.NoError(t, fromDB2.FromDB([]byte(`[["severity","critical"],["alertname","HighCPU"],["instance","host-1"]]`)))
+
+		key1, hash1, err := fromDB1.StringAndHash()
+		require.NoError(t, err)
+		key2, hash2, err := fromDB2.StringAndHash()
+		require.NoError(t, err)
+		require.Equal(t, hash1, hash2)
+		require.Equal(t, key1, key2)
+		require.Equal(t, `[["alertname","HighCPU"],["instance","host-1"],["severity","critical"]]`, key1)
+	})
+
+	t.Run("should differ if a label value differs", func(t *testing.T) {
+		labels1 := InstanceLabels{"alertname": "HighCPU", "instance": "host-1"}
+		labels2 := InstanceLabels{"alertname": "HighCPU", "instance": "host-2"}
+		_, hash1, err := labels1.StringAndHash()
+		require.NoError(t, err)
+		_, hash2, err := labels2.StringAndHash()
+		require.NoError(t, err)
+		require.NotEqual(t, hash1, hash2)
+	})
+
+	t.Run("should reject duplicate labels", func(t *testing.T) {
+		var labels InstanceLabels
+		require.Error(t, labels.FromDB([]byte(`[["instance","host-1"],["instance","host-2"]]`)))
+	})
+}
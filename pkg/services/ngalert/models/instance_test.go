@@ -103,3 +103,30 @@ func TestValidateAlertInstance(t *testing.T) {
 		})
 	}
 }
+
+func TestAlertInstanceStateSummary_WorstState(t *testing.T) {
+	testCases := []struct {
+		name     string
+		counts   map[InstanceStateType]int64
+		expected InstanceStateType
+	}{
+		{name: "no instances", counts: nil, expected: InstanceStateNormal},
+		{name: "only normal", counts: map[InstanceStateType]int64{InstanceStateNormal: 3}, expected: InstanceStateNormal},
+		{name: "no data over normal", counts: map[InstanceStateType]int64{InstanceStateNormal: 3, InstanceStateNoData: 1}, expected: InstanceStateNoData},
+		{name: "pending over no data", counts: map[InstanceStateType]int64{InstanceStateNoData: 1, InstanceStatePending: 1}, expected: InstanceStatePending},
+		{name: "alerting over pending", counts: map[InstanceStateType]int64{InstanceStatePending: 5, InstanceStateFiring: 1}, expected: InstanceStateFiring},
+		{name: "error over alerting", counts: map[InstanceStateType]int64{InstanceStateFiring: 5, InstanceStateError: 1, InstanceStateNormal: 2}, expected: InstanceStateError},
+		{name: "zero counts are ignored", counts: map[InstanceStateType]int64{InstanceStateError: 0, InstanceStatePending: 1}, expected: InstanceStatePending},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			summary := &AlertInstanceStateSummary{Counts: tc.counts}
+			require.Equal(t, tc.expected, summary.WorstState())
+		})
+	}
+
+	t.Run("nil summary is normal", func(t *testing.T) {
+		var summary *AlertInstanceStateSummary
+		require.Equal(t, InstanceStateNormal, summary.WorstState())
+	})
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// InstanceValues are the values of the expressions of an alert rule by RefID at an evaluation, with methods for database
+// serialization. A value that is absent or not a finite number is NaN, and is stored as null because JSON cannot represent it.
+type InstanceValues map[string]float64
+
+// FromDB loads values stored in the database as a JSON object into InstanceValues.
+// FromDB is part of the xorm Conversion interface.
+func (v *InstanceValues) FromDB(b []byte) error {
+	if len(b) == 0 {
+		*v = nil
+		return nil
+	}
+	m := map[string]*float64{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return fmt.Errorf("failed to decode values of the alert instance: %w", err)
+	}
+	values := make(InstanceValues, len(m))
+	for k, value := range m {
+		if value == nil {
+			values[k] = math.NaN()
+			continue
+		}
+		values[k] = *value
+	}
+	*v = values
+	return nil
+}
+
+// ToDB encodes the values as a JSON object. It returns nil if there are no values.
+// ToDB is part of the xorm Conversion interface.
+func (v *InstanceValues) ToDB() ([]byte, error) {
+	if v == nil || *v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v.Nullable())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode values of the alert instance: %w", err)
+	}
+	return b, nil
+}
+
+// Nullable returns the values with nil in place of the values that are not finite numbers.
+func (v InstanceValues) Nullable() map[string]*float64 {
+	if v == nil {
+		return nil
+	}
+	m := make(map[string]*float64, len(v))
+	for k, value := range v {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			m[k] = nil
+			continue
+		}
+		value := value
+		m[k] = &value
+	}
+	return m
+}
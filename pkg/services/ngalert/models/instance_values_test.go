@@ -0,0 +1,39 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceValues(t *testing.T) {
+	t.Run("should encode values that are not finite as null", func(t *testing.T) {
+		values := InstanceValues{"A": 1.5, "B": math.NaN(), "C": math.Inf(1)}
+		b, err := values.ToDB()
+		require.NoError(t, err)
+		require.JSONEq(t, `{"A": 1.5, "B": null, "C": null}`, string(b))
+
+		var decoded InstanceValues
+		require.NoError(t, decoded.FromDB(b))
+		require.Len(t, decoded, 3)
+		require.Equal(t, 1.5, decoded["A"])
+		require.True(t, math.IsNaN(decoded["B"]))
+		require.True(t, math.IsNaN(decoded["C"]))
+	})
+
+	t.Run("should encode no values as nil", func(t *testing.T) {
+		var values InstanceValues
+		b, err := values.ToDB()
+		require.NoError(t, err)
+		require.Nil(t, b)
+
+		require.NoError(t, values.FromDB(nil))
+		require.Nil(t, values)
+	})
+
+	t.Run("should fail to decode invalid JSON", func(t *testing.T) {
+		var values InstanceValues
+		require.Error(t, values.FromDB([]byte("{")))
+	})
+}
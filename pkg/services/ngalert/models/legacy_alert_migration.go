@@ -0,0 +1,68 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// LegacyAlertMigrationStatus is the outcome of the migration of a legacy dashboard alert.
+type LegacyAlertMigrationStatus string
+
+const (
+	// LegacyAlertMigrated means that an alert rule was created for the legacy alert.
+	LegacyAlertMigrated LegacyAlertMigrationStatus = "migrated"
+	// LegacyAlertSkipped means that an alert rule was already migrated from the legacy alert.
+	LegacyAlertSkipped LegacyAlertMigrationStatus = "skipped"
+	// LegacyAlertFailed means that the legacy alert could not be migrated. The reason explains why.
+	LegacyAlertFailed LegacyAlertMigrationStatus = "failed"
+)
+
+// LegacyAlertMigrationResult is the outcome of the migration of a legacy dashboard alert.
+type LegacyAlertMigrationResult struct {
+	AlertID      int64
+	OrgID        int64
+	DashboardUID string
+	PanelID      int64
+	Name         string
+	Status       LegacyAlertMigrationStatus
+	// RuleUID is the UID of the alert rule that was created for the legacy alert, or that was migrated from it before.
+	RuleUID string
+	Reason  string
+}
+
+// LegacyAlertMigrationReport lists the outcome of the migration of every legacy dashboard alert.
+type LegacyAlertMigrationReport struct {
+	Results []LegacyAlertMigrationResult
+}
+
+// Count returns the number of legacy alerts with the given status.
+func (r *LegacyAlertMigrationReport) Count(status LegacyAlertMigrationStatus) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// MigratedAlertID returns the ID of the legacy dashboard alert that the rule was migrated from, if any.
+func (alertRule *AlertRule) MigratedAlertID() (int64, bool) {
+	v, ok := alertRule.Annotations[MigratedAlertIDAnnotation]
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// LegacyAlertState is the state of a legacy dashboard alert, as the legacy alerting engine stored it.
+type LegacyAlertState struct {
+	// State is the state of the legacy alert, such as ok, pending, alerting, no_data or paused.
+	State string
+	// NewStateDate is when the legacy alert changed to its state.
+	NewStateDate time.Time
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrMaintenanceWindowNotFound = errors.New("maintenance window not found")
+	ErrMaintenanceWindowInvalid  = errors.New("invalid maintenance window")
+)
+
+// MaintenanceWindowRecurrence is how often a maintenance window repeats.
+type MaintenanceWindowRecurrence string
+
+const (
+	// MaintenanceWindowOnce means that the window does not repeat.
+	MaintenanceWindowOnce   MaintenanceWindowRecurrence = ""
+	MaintenanceWindowDaily  MaintenanceWindowRecurrence = "daily"
+	MaintenanceWindowWeekly MaintenanceWindowRecurrence = "weekly"
+)
+
+// Period returns the time between the starts of two consecutive occurrences of the window, or zero if it does not repeat.
+// Periods are fixed durations, so occurrences do not follow daylight saving time changes.
+func (r MaintenanceWindowRecurrence) Period() time.Duration {
+	switch r {
+	case MaintenanceWindowDaily:
+		return 24 * time.Hour
+	case MaintenanceWindowWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// MaintenanceWindow is a period of time during which the notifications of the alert instances of an organization are
+// suppressed. Alert rules are still evaluated and the states of their instances are updated.
+type MaintenanceWindow struct {
+	ID         int64                       `xorm:"pk autoincr 'id'"`
+	OrgID      int64                       `xorm:"org_id"`
+	Title      string                      `xorm:"title"`
+	Start      time.Time                   `xorm:"starts_at"`
+	End        time.Time                   `xorm:"ends_at"`
+	Recurrence MaintenanceWindowRecurrence `xorm:"recurrence"`
+	// CreatedBy is the ID of the user who created the window. A negative ID is the negated ID of an API key.
+	CreatedBy int64     `xorm:"created_by"`
+	Created   time.Time `xorm:"created"`
+	Updated   time.Time `xorm:"updated"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (w *MaintenanceWindow) TableName() string {
+	return "alert_maintenance_window"
+}
+
+// Validate checks that the window ends after it starts, that its occurrences do not overlap, and that it is not over at
+// the time now. Windows that repeat can start in the past.
+func (w *MaintenanceWindow) Validate(now time.Time) error {
+	if w.Title == "" {
+		return fmt.Errorf("%w: title cannot be empty", ErrMaintenanceWindowInvalid)
+	}
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("%w: end must be after start", ErrMaintenanceWindowInvalid)
+	}
+	switch w.Recurrence {
+	case MaintenanceWindowOnce:
+		if !w.End.After(now) {
+			return fmt.Errorf("%w: window ends in the past", ErrMaintenanceWindowInvalid)
+		}
+	case MaintenanceWindowDaily, MaintenanceWindowWeekly:
+		if w.End.Sub(w.Start) >= w.Recurrence.Period() {
+			return fmt.Errorf("%w: window must be shorter than its %s recurrence", ErrMaintenanceWindowInvalid, w.Recurrence)
+		}
+	default:
+		return fmt.Errorf("%w: unknown recurrence %q, must be one of daily, weekly or empty", ErrMaintenanceWindowInvalid, w.Recurrence)
+	}
+	return nil
+}
+
+// IsActive returns true if the time t is in the window or in one of its occurrences. The start is inclusive and the end
+// is exclusive.
+func (w *MaintenanceWindow) IsActive(t time.Time) bool {
+	if t.Before(w.Start) {
+		return false
+	}
+	offset := t.Sub(w.Start)
+	if period := w.Recurrence.Period(); period > 0 {
+		offset %= period
+	}
+	return offset < w.End.Sub(w.Start)
+}
+
+// ActiveMaintenanceWindow returns the window that is active at the time t. If several windows overlap, it returns the one
+// with the lowest ID. Returns nil if no window is active.
+func ActiveMaintenanceWindow(windows []*MaintenanceWindow, t time.Time) *MaintenanceWindow {
+	var active *MaintenanceWindow
+	for _, w := range windows {
+		if w.IsActive(t) && (active == nil || w.ID < active.ID) {
+			active = w
+		}
+	}
+	return active
+}
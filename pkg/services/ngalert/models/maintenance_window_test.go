@@ -0,0 +1,87 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindowIsActive(t *testing.T) {
+	// Saturday 02:00 - 04:00 UTC
+	start := time.Date(2023, 6, 3, 2, 0, 0, 0, time.UTC)
+	window := MaintenanceWindow{Start: start, End: start.Add(2 * time.Hour)}
+
+	require.False(t, window.IsActive(start.Add(-time.Second)))
+	require.True(t, window.IsActive(start))
+	require.True(t, window.IsActive(start.Add(2*time.Hour-time.Second)))
+	require.False(t, window.IsActive(start.Add(2*time.Hour)))
+	require.False(t, window.IsActive(start.Add(7*24*time.Hour)))
+
+	t.Run("weekly window should be active every week", func(t *testing.T) {
+		window := window
+		window.Recurrence = MaintenanceWindowWeekly
+		require.True(t, window.IsActive(start.Add(7*24*time.Hour)))
+		require.True(t, window.IsActive(start.Add(5*7*24*time.Hour+time.Hour)))
+		require.False(t, window.IsActive(start.Add(24*time.Hour)))
+		require.False(t, window.IsActive(start.Add(-7*24*time.Hour)), "window should not be active before its first occurrence")
+	})
+
+	t.Run("daily window should be active every day", func(t *testing.T) {
+		window := window
+		window.Recurrence = MaintenanceWindowDaily
+		require.True(t, window.IsActive(start.Add(24*time.Hour)))
+		require.False(t, window.IsActive(start.Add(24*time.Hour+2*time.Hour)))
+	})
+}
+
+func TestActiveMaintenanceWindow(t *testing.T) {
+	now := time.Now()
+	first := &MaintenanceWindow{ID: 2, Start: now.Add(-time.Hour), End: now.Add(time.Hour)}
+	second := &MaintenanceWindow{ID: 1, Start: now.Add(-time.Minute), End: now.Add(time.Minute)}
+	past := &MaintenanceWindow{ID: 3, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)}
+
+	require.Nil(t, ActiveMaintenanceWindow(nil, now))
+	require.Nil(t, ActiveMaintenanceWindow([]*MaintenanceWindow{past}, now))
+	require.Equal(t, first, ActiveMaintenanceWindow([]*MaintenanceWindow{first, past}, now))
+	require.Equal(t, second, ActiveMaintenanceWindow([]*MaintenanceWindow{first, second, past}, now), "overlapping windows should resolve to the lowest ID")
+}
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	now := time.Now()
+	valid := MaintenanceWindow{Title: "maintenance", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}
+	require.NoError(t, valid.Validate(now))
+
+	testCases := []struct {
+		name   string
+		mutate func(w *MaintenanceWindow)
+	}{
+		{name: "empty title", mutate: func(w *MaintenanceWindow) { w.Title = "" }},
+		{name: "end before start", mutate: func(w *MaintenanceWindow) { w.End = w.Start.Add(-time.Minute) }},
+		{name: "end equal to start", mutate: func(w *MaintenanceWindow) { w.End = w.Start }},
+		{name: "ends in the past", mutate: func(w *MaintenanceWindow) {
+			w.Start = now.Add(-2 * time.Hour)
+			w.End = now.Add(-time.Hour)
+		}},
+		{name: "longer than daily recurrence", mutate: func(w *MaintenanceWindow) {
+			w.Recurrence = MaintenanceWindowDaily
+			w.End = w.Start.Add(24 * time.Hour)
+		}},
+		{name: "unknown recurrence", mutate: func(w *MaintenanceWindow) { w.Recurrence = "monthly" }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := valid
+			tc.mutate(&w)
+			require.ErrorIs(t, w.Validate(now), ErrMaintenanceWindowInvalid)
+		})
+	}
+
+	t.Run("recurring window can start in the past", func(t *testing.T) {
+		w := valid
+		w.Start = now.Add(-30 * 24 * time.Hour)
+		w.End = w.Start.Add(time.Hour)
+		w.Recurrence = MaintenanceWindowWeekly
+		require.NoError(t, w.Validate(now))
+	})
+}
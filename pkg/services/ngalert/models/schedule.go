@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// scheduleParser accepts standard 5-field cron expressions only: minute, hour, day of month, month and day of week.
+var scheduleParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// InvalidScheduleError is returned when the schedule of an alert rule is not a valid cron expression or its time zone is unknown.
+// It wraps ErrAlertRuleFailedValidation.
+type InvalidScheduleError struct {
+	Schedule string
+	Timezone string
+	Reason   error
+}
+
+func (e *InvalidScheduleError) Error() string {
+	return fmt.Sprintf("%s: schedule %q in time zone %q is not valid: %s", ErrAlertRuleFailedValidation, e.Schedule, e.Timezone, e.Reason)
+}
+
+func (e *InvalidScheduleError) Unwrap() error {
+	return ErrAlertRuleFailedValidation
+}
+
+// Schedule calculates evaluation times of a rule from a cron expression interpreted in a specific time zone.
+type Schedule struct {
+	spec     cron.Schedule
+	location *time.Location
+}
+
+// Next returns the first evaluation time strictly after t.
+func (s Schedule) Next(t time.Time) time.Time {
+	return s.spec.Next(t.In(s.location))
+}
+
+// ParseSchedule parses a standard 5-field cron expression. The expression is interpreted in the given IANA time zone,
+// or in UTC if the time zone is empty. It returns InvalidScheduleError if either of them is not valid.
+// Across DST transitions, the schedule keeps the wall-clock time: a time that is skipped when clocks are set forward is
+// not matched on that day, and a time that is repeated when clocks are set back is matched twice.
+func ParseSchedule(expr, timezone string) (Schedule, error) {
+	if strings.HasPrefix(expr, "TZ=") || strings.HasPrefix(expr, "CRON_TZ=") {
+		return Schedule{}, &InvalidScheduleError{Schedule: expr, Timezone: timezone, Reason: errors.New("time zone must be specified separately")}
+	}
+	location := time.UTC
+	if timezone != "" {
+		l, err := time.LoadLocation(timezone)
+		if err != nil {
+			return Schedule{}, &InvalidScheduleError{Schedule: expr, Timezone: timezone, Reason: err}
+		}
+		location = l
+	}
+	spec, err := scheduleParser.Parse(expr)
+	if err != nil {
+		return Schedule{}, &InvalidScheduleError{Schedule: expr, Timezone: timezone, Reason: err}
+	}
+	return Schedule{spec: spec, location: location}, nil
+}
+
+// ValidateSchedule checks that the schedule of the rule can be parsed. A time zone without a schedule is not allowed.
+func (alertRule *AlertRule) ValidateSchedule() error {
+	if alertRule.Schedule == "" {
+		if alertRule.ScheduleTimezone != "" {
+			return &InvalidScheduleError{Timezone: alertRule.ScheduleTimezone, Reason: errors.New("time zone is set but schedule is empty")}
+		}
+		return nil
+	}
+	_, err := ParseSchedule(alertRule.Schedule, alertRule.ScheduleTimezone)
+	return err
+}
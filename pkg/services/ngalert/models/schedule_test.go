@@ -0,0 +1,73 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	t.Run("should fail with typed error", func(t *testing.T) {
+		testCases := map[string]struct {
+			schedule string
+			timezone string
+		}{
+			"too few fields":        {schedule: "0 8 * *"},
+			"seconds field":         {schedule: "0 0 8 * * *"},
+			"descriptor":            {schedule: "@every 5m"},
+			"out of range":          {schedule: "60 8 * * *"},
+			"unknown time zone":     {schedule: "0 8 * * *", timezone: "Mars/Olympus_Mons"},
+			"time zone in schedule": {schedule: "CRON_TZ=Europe/Berlin 0 8 * * *"},
+		}
+		for name, testCase := range testCases {
+			t.Run(name, func(t *testing.T) {
+				_, err := ParseSchedule(testCase.schedule, testCase.timezone)
+				var scheduleErr *InvalidScheduleError
+				require.ErrorAs(t, err, &scheduleErr)
+				require.Equal(t, testCase.schedule, scheduleErr.Schedule)
+				require.ErrorIs(t, err, ErrAlertRuleFailedValidation)
+			})
+		}
+	})
+
+	t.Run("should default to UTC", func(t *testing.T) {
+		s, err := ParseSchedule("0 8 * * 1-5", "")
+		require.NoError(t, err)
+		// Friday
+		next := s.Next(time.Date(2023, 3, 24, 8, 0, 0, 0, time.UTC))
+		require.True(t, time.Date(2023, 3, 27, 8, 0, 0, 0, time.UTC).Equal(next), next)
+	})
+
+	t.Run("should keep wall-clock time across DST transitions", func(t *testing.T) {
+		s, err := ParseSchedule("0 8 * * *", "Europe/Berlin")
+		require.NoError(t, err)
+		// clocks are set forward on 2023-03-26 and back on 2023-10-29
+		expected := []time.Time{
+			time.Date(2023, 3, 25, 7, 0, 0, 0, time.UTC),
+			time.Date(2023, 3, 26, 6, 0, 0, 0, time.UTC),
+			time.Date(2023, 3, 27, 6, 0, 0, 0, time.UTC),
+		}
+		next := time.Date(2023, 3, 25, 0, 0, 0, 0, time.UTC)
+		for _, e := range expected {
+			next = s.Next(next)
+			require.True(t, e.Equal(next), "expected %s but got %s", e, next)
+		}
+		next = s.Next(time.Date(2023, 10, 29, 0, 0, 0, 0, time.UTC))
+		require.True(t, time.Date(2023, 10, 29, 7, 0, 0, 0, time.UTC).Equal(next), next)
+	})
+
+	t.Run("should skip wall-clock time that does not exist because of DST", func(t *testing.T) {
+		s, err := ParseSchedule("30 2 * * *", "America/New_York")
+		require.NoError(t, err)
+		// 02:30 does not exist on 2023-03-12
+		next := s.Next(time.Date(2023, 3, 11, 12, 0, 0, 0, time.UTC))
+		require.True(t, time.Date(2023, 3, 13, 6, 30, 0, 0, time.UTC).Equal(next), next)
+	})
+}
+
+func TestValidateSchedule(t *testing.T) {
+	require.NoError(t, (&AlertRule{}).ValidateSchedule())
+	require.NoError(t, (&AlertRule{Schedule: "*/5 * * * *", ScheduleTimezone: "Europe/Helsinki"}).ValidateSchedule())
+	require.ErrorIs(t, (&AlertRule{ScheduleTimezone: "Europe/Helsinki"}).ValidateSchedule(), ErrAlertRuleFailedValidation)
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrSilenceNotFound = errors.New("silence not found")
+	ErrSilenceInvalid  = errors.New("invalid silence")
+)
+
+// SilenceMatchType is how a silence matcher compares the value of a label.
+type SilenceMatchType string
+
+const (
+	// SilenceMatchEqual matches labels whose value is exactly the value of the matcher. It is the default type.
+	SilenceMatchEqual SilenceMatchType = "="
+)
+
+// SilenceMatcher matches the alert instances that have the label Name. Only exact matches are supported for now; other
+// types, such as regular expressions, can be added as new values of Type.
+type SilenceMatcher struct {
+	Name  string           `json:"name"`
+	Value string           `json:"value"`
+	Type  SilenceMatchType `json:"type,omitempty"`
+}
+
+// Matches returns true if the labels match the matcher. A matcher with an empty value also matches labels that do not have
+// the label Name.
+func (m SilenceMatcher) Matches(labels map[string]string) bool {
+	switch m.Type {
+	case SilenceMatchEqual, "":
+		return labels[m.Name] == m.Value
+	default:
+		return false
+	}
+}
+
+// Silence suppresses the notifications of the alert instances of an organization that match all its matchers between
+// its start and its end. Alert rules are still evaluated and the states of their instances are updated.
+type Silence struct {
+	ID       int64            `xorm:"pk autoincr 'id'"`
+	OrgID    int64            `xorm:"org_id"`
+	Matchers []SilenceMatcher `xorm:"matchers"`
+	Start    time.Time        `xorm:"starts_at"`
+	End      time.Time        `xorm:"ends_at"`
+	Comment  string           `xorm:"comment"`
+	// CreatedBy is the ID of the user who created the silence. A negative ID is the negated ID of an API key.
+	CreatedBy int64     `xorm:"created_by"`
+	Created   time.Time `xorm:"created"`
+	Updated   time.Time `xorm:"updated"`
+}
+
+// A XORM interface that defines the used table for this struct.
+func (s *Silence) TableName() string {
+	return "alert_silence"
+}
+
+// Validate checks that the silence has at least one matcher of a known type, that it ends after it starts, and that it is
+// not over at the time now.
+func (s *Silence) Validate(now time.Time) error {
+	if len(s.Matchers) == 0 {
+		return fmt.Errorf("%w: at least one matcher is required", ErrSilenceInvalid)
+	}
+	for _, m := range s.Matchers {
+		if m.Name == "" {
+			return fmt.Errorf("%w: matcher name cannot be empty", ErrSilenceInvalid)
+		}
+		if m.Type != "" && m.Type != SilenceMatchEqual {
+			return fmt.Errorf("%w: unknown type %q of matcher %s, must be %s", ErrSilenceInvalid, m.Type, m.Name, SilenceMatchEqual)
+		}
+	}
+	if !s.End.After(s.Start) {
+		return fmt.Errorf("%w: end must be after start", ErrSilenceInvalid)
+	}
+	if !s.End.After(now) {
+		return fmt.Errorf("%w: silence ends in the past", ErrSilenceInvalid)
+	}
+	return nil
+}
+
+// IsActive returns true if the time t is in the silence. The start is inclusive and the end is exclusive.
+func (s *Silence) IsActive(t time.Time) bool {
+	return !t.Before(s.Start) && t.Before(s.End)
+}
+
+// Matches returns true if the labels match all the matchers of the silence.
+func (s *Silence) Matches(labels map[string]string) bool {
+	for _, m := range s.Matchers {
+		if !m.Matches(labels) {
+			return false
+		}
+	}
+	return len(s.Matchers) > 0
+}
+
+// ActiveSilence returns the silence that is active at the time t and matches the labels. If several silences match, it
+// returns the one with the lowest ID. Returns nil if no silence matches.
+func ActiveSilence(silences []*Silence, labels map[string]string, t time.Time) *Silence {
+	var active *Silence
+	for _, s := range silences {
+		if s.IsActive(t) && s.Matches(labels) && (active == nil || s.ID < active.ID) {
+			active = s
+		}
+	}
+	return active
+}
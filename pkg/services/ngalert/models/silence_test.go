@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSilenceMatches(t *testing.T) {
+	silence := Silence{Matchers: []SilenceMatcher{
+		{Name: "cluster", Value: "staging"},
+		{Name: "team", Value: "sre", Type: SilenceMatchEqual},
+	}}
+
+	require.True(t, silence.Matches(map[string]string{"cluster": "staging", "team": "sre", "instance": "server-1"}))
+	require.False(t, silence.Matches(map[string]string{"cluster": "staging"}))
+	require.False(t, silence.Matches(map[string]string{"cluster": "staging-2", "team": "sre"}))
+	require.False(t, (&Silence{}).Matches(map[string]string{"cluster": "staging"}), "silence without matchers should not match")
+
+	t.Run("empty value should match missing label", func(t *testing.T) {
+		s := Silence{Matchers: []SilenceMatcher{{Name: "cluster", Value: "staging"}, {Name: "region", Value: ""}}}
+		require.True(t, s.Matches(map[string]string{"cluster": "staging"}))
+		require.False(t, s.Matches(map[string]string{"cluster": "staging", "region": "eu"}))
+	})
+}
+
+func TestActiveSilence(t *testing.T) {
+	now := time.Now()
+	labels := map[string]string{"cluster": "staging"}
+	matchers := []SilenceMatcher{{Name: "cluster", Value: "staging"}}
+	first := &Silence{ID: 2, Matchers: matchers, Start: now.Add(-time.Hour), End: now.Add(time.Hour)}
+	second := &Silence{ID: 1, Matchers: matchers, Start: now.Add(-time.Minute), End: now.Add(time.Minute)}
+	expired := &Silence{ID: 3, Matchers: matchers, Start: now.Add(-2 * time.Hour), End: now}
+	other := &Silence{ID: 0, Matchers: []SilenceMatcher{{Name: "cluster", Value: "prod"}}, Start: now.Add(-time.Hour), End: now.Add(time.Hour)}
+
+	require.Nil(t, ActiveSilence(nil, labels, now))
+	require.Nil(t, ActiveSilence([]*Silence{expired, other}, labels, now))
+	require.Equal(t, first, ActiveSilence([]*Silence{first, expired, other}, labels, now))
+	require.Equal(t, second, ActiveSilence([]*Silence{first, second, expired}, labels, now), "overlapping silences should resolve to the lowest ID")
+}
+
+func TestSilenceValidate(t *testing.T) {
+	now := time.Now()
+	valid := Silence{
+		Matchers: []SilenceMatcher{{Name: "cluster", Value: "staging"}},
+		Start:    now.Add(-time.Hour),
+		End:      now.Add(4 * time.Hour),
+	}
+	require.NoError(t, valid.Validate(now))
+
+	testCases := []struct {
+		name   string
+		mutate func(s *Silence)
+	}{
+		{name: "no matchers", mutate: func(s *Silence) { s.Matchers = nil }},
+		{name: "empty matcher name", mutate: func(s *Silence) { s.Matchers = []SilenceMatcher{{Value: "staging"}} }},
+		{name: "unknown matcher type", mutate: func(s *Silence) {
+			s.Matchers = []SilenceMatcher{{Name: "cluster", Value: "stag.*", Type: "=~"}}
+		}},
+		{name: "end before start", mutate: func(s *Silence) { s.End = s.Start.Add(-time.Minute) }},
+		{name: "ends in the past", mutate: func(s *Silence) {
+			s.Start = now.Add(-2 * time.Hour)
+			s.End = now.Add(-time.Hour)
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := valid
+			tc.mutate(&s)
+			require.ErrorIs(t, s.Validate(now), ErrSilenceInvalid)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// AlertStateHistory is a transition of an alert instance from one state to another caused by an evaluation of its rule.
+type AlertStateHistory struct {
+	RuleOrgID     int64
+	RuleUID       string
+	LabelsHash    string
+	Labels        InstanceLabels
+	PreviousState InstanceStateType
+	State         InstanceStateType
+	StateReason   string
+	// Values are the values of the expressions of the rule at the evaluation that caused the transition.
+	Values         InstanceValues
+	TransitionedAt time.Time
+	// ResetBy is the login of the user who reset the instance manually. It is empty for transitions caused by evaluations.
+	ResetBy string
+	// MaintenanceWindowID is the ID of the maintenance window that suppressed the notification of the transition. Zero means
+	// that the transition was not suppressed.
+	MaintenanceWindowID int64
+	// SilenceID is the ID of the silence that suppressed the notification of the transition. Zero means that the transition
+	// was not silenced.
+	SilenceID int64
+}
+
+// GetAlertStateHistoryQuery is the query for the state transitions of the instances of an alert rule.
+type GetAlertStateHistoryQuery struct {
+	OrgID   int64
+	RuleUID string
+	// From and To limit the result to the transitions in the time range [From, To). A zero value does not limit the range.
+	From time.Time
+	To   time.Time
+}
@@ -78,6 +78,7 @@ func AlertRuleGen(mutators ...AlertRuleMutator) func() *AlertRule {
 			For:             forInterval,
 			Annotations:     annotations,
 			Labels:          labels,
+			SendResolved:    true,
 		}
 
 		for _, mutator := range mutators {
@@ -140,6 +141,12 @@ func WithOrgID(orgId int64) AlertRuleMutator {
 	}
 }
 
+func WithIsPaused(isPaused bool) AlertRuleMutator {
+	return func(rule *AlertRule) {
+		rule.IsPaused = isPaused
+	}
+}
+
 func WithNamespace(namespace *folder.Folder) AlertRuleMutator {
 	return func(rule *AlertRule) {
 		rule.NamespaceUID = namespace.UID
@@ -256,6 +263,7 @@ func CopyRule(r *AlertRule) *AlertRule {
 		NoDataState:     r.NoDataState,
 		ExecErrState:    r.ExecErrState,
 		For:             r.For,
+		SendResolved:    r.SendResolved,
 	}
 
 	if r.DashboardUID != nil {
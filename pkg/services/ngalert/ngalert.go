@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"sync"
 	"time"
 
 	"github.com/benbjohnson/clock"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/bus"
@@ -17,6 +19,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/kvstore"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/annotations"
@@ -25,9 +28,11 @@ import (
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
+	grafanalive "github.com/grafana/grafana/pkg/services/live"
 	"github.com/grafana/grafana/pkg/services/ngalert/api"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/image"
+	"github.com/grafana/grafana/pkg/services/ngalert/live"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
@@ -44,6 +49,11 @@ import (
 	"github.com/grafana/grafana/pkg/setting"
 )
 
+// suppressionCacheTTL is how long the maintenance windows and silences read by the evaluations of the rules are kept in memory. Their
+// changes through this instance apply immediately, and the changes through the other instances of a highly available
+// setup once they expire.
+const suppressionCacheTTL = 10 * time.Second
+
 func ProvideService(
 	cfg *setting.Cfg,
 	featureToggles featuremgmt.FeatureToggles,
@@ -67,6 +77,8 @@ func ProvideService(
 	annotationsRepo annotations.Repository,
 	pluginsStore plugins.Store,
 	tracer tracing.Tracer,
+	liveService *grafanalive.GrafanaLive,
+	usageStats usagestats.Service,
 ) (*AlertNG, error) {
 	ng := &AlertNG{
 		Cfg:                  cfg,
@@ -92,6 +104,9 @@ func ProvideService(
 		annotationsRepo:      annotationsRepo,
 		pluginsStore:         pluginsStore,
 		tracer:               tracer,
+		live:                 liveService,
+		usageStats:           usageStats,
+		clock:                clock.New(),
 	}
 
 	if ng.IsDisabled() {
@@ -105,6 +120,20 @@ func ProvideService(
 	return ng, nil
 }
 
+// RuleStore is the store of alert rules and alert instances that AlertNG and its components depend on. store.DBstore
+// implements it on top of the database, and fakes.RuleStore in memory for unit tests.
+type RuleStore interface {
+	api.RuleStore
+	schedule.RulesStore
+	state.InstanceStore
+
+	CountAlertRulesAcrossOrgs(ctx context.Context, query *models.ListAlertRulesAcrossOrgsQuery) (int64, error)
+	ListAlertRulesAcrossOrgs(ctx context.Context, query *models.ListAlertRulesAcrossOrgsQuery) ([]*models.AlertRuleWithOrg, error)
+	CheckHealth(ctx context.Context) error
+}
+
+var _ RuleStore = &store.DBstore{}
+
 // AlertNG is the service for evaluating the condition of an alert definition.
 type AlertNG struct {
 	Cfg                 *setting.Cfg
@@ -135,10 +164,26 @@ type AlertNG struct {
 	accesscontrolService accesscontrol.Service
 	annotationsRepo      annotations.Repository
 	store                *store.DBstore
+	// ruleStore is the store of alert rules and alert instances. It is the database store unless a test injects another.
+	ruleStore RuleStore
 
 	bus          bus.Bus
 	pluginsStore plugins.Store
 	tracer       tracing.Tracer
+	// live publishes the changes of rules and instances. If it is nil, they are not published.
+	live *grafanalive.GrafanaLive
+	// usageStats collects the usage statistics of alerting. If it is nil, they are not reported.
+	usageStats usagestats.Service
+	// clock tells the time to the store, the scheduler, the state manager and the API. If it is nil, the wall clock is used.
+	clock clock.Clock
+	// reloadMtx serializes the reloads of the settings.
+	reloadMtx sync.Mutex
+	// healthMtx guards the result of the latest database check of Health.
+	healthMtx       sync.Mutex
+	healthCheckedAt time.Time
+	healthErr       error
+	// healthCheck shares the database check of Health between concurrent callers.
+	healthCheck singleflight.Group
 }
 
 func (ng *AlertNG) init() error {
@@ -148,6 +193,19 @@ func (ng *AlertNG) init() error {
 	initCtx, cancelFunc := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancelFunc()
 
+	if ng.clock == nil {
+		ng.clock = clock.New()
+	}
+
+	var ruleCache *store.RuleCache
+	if ng.Cfg.UnifiedAlerting.RuleCacheTTL > 0 {
+		ruleCache = store.NewRuleCache(ng.Cfg.UnifiedAlerting.RuleCacheTTL, ng.Cfg.UnifiedAlerting.RuleCacheMaxEntriesPerOrg)
+		// the rules can also be changed through other stores, for example by file provisioning
+		ng.bus.AddEventListener(func(_ context.Context, e *models.AlertRuleChanged) error {
+			ruleCache.InvalidateOrg(e.OrgID)
+			return nil
+		})
+	}
 	store := &store.DBstore{
 		Cfg:              ng.Cfg.UnifiedAlerting,
 		FeatureToggles:   ng.FeatureToggles,
@@ -156,8 +214,20 @@ func (ng *AlertNG) init() error {
 		FolderService:    ng.folderService,
 		AccessControl:    ng.accesscontrol,
 		DashboardService: ng.dashboardService,
+		RuleCache:        ruleCache,
+		Metrics:          ng.Metrics.GetStoreMetrics(),
+		Clock:            ng.clock,
+
+		MaintenanceWindowCache: store.NewMaintenanceWindowCache(suppressionCacheTTL),
+		SilenceCache:           store.NewSilenceCache(suppressionCacheTTL),
 	}
 	ng.store = store
+	if ng.ruleStore == nil {
+		ng.ruleStore = store
+	}
+	if ng.usageStats != nil {
+		ng.usageStats.RegisterMetricsFunc(ng.getUsageStats)
+	}
 
 	decryptFn := ng.SecretsService.GetDecryptedValue
 	multiOrgMetrics := ng.Metrics.GetMultiOrgAlertmanagerMetrics()
@@ -183,10 +253,17 @@ func (ng *AlertNG) init() error {
 		appUrl = nil
 	}
 
-	clk := clock.New()
+	var forwarder *sender.AlertmanagerForwarder
+	if len(ng.Cfg.UnifiedAlerting.ExternalAlertmanagers) > 0 {
+		forwarder, err = sender.NewAlertmanagerForwarder(ng.Cfg.UnifiedAlerting.ExternalAlertmanagers, ng.clock)
+		if err != nil {
+			return fmt.Errorf("failed to initialize the forwarding of alerts to the external Alertmanagers: %w", err)
+		}
+	}
 
-	alertsRouter := sender.NewAlertsRouter(ng.MultiOrgAlertmanager, store, clk, appUrl, ng.Cfg.UnifiedAlerting.DisabledOrgs,
-		ng.Cfg.UnifiedAlerting.AdminConfigPollInterval, ng.DataSourceService, ng.SecretsService)
+	alertsRouter := sender.NewAlertsRouter(ng.MultiOrgAlertmanager, store, ng.clock, appUrl, ng.Cfg.UnifiedAlerting.DisabledOrgs,
+		ng.Cfg.UnifiedAlerting.AdminConfigPollInterval, ng.DataSourceService, ng.SecretsService,
+		forwarder, ng.Cfg.UnifiedAlerting.ExternalAlertmanagersOnly)
 
 	// Make sure we sync at least once as Grafana starts to get the router up and running before we start sending any alerts.
 	if err := alertsRouter.SyncAndApplyConfigFromDatabase(); err != nil {
@@ -197,38 +274,80 @@ func (ng *AlertNG) init() error {
 
 	evalFactory := eval.NewEvaluatorFactory(ng.Cfg.UnifiedAlerting, ng.DataSourceCache, ng.ExpressionService, ng.pluginsStore)
 	schedCfg := schedule.SchedulerCfg{
-		MaxAttempts:          ng.Cfg.UnifiedAlerting.MaxAttempts,
-		C:                    clk,
-		BaseInterval:         ng.Cfg.UnifiedAlerting.BaseInterval,
-		MinRuleInterval:      ng.Cfg.UnifiedAlerting.MinInterval,
-		DisableGrafanaFolder: ng.Cfg.UnifiedAlerting.ReservedLabels.IsReservedLabelDisabled(models.FolderTitleLabel),
-		AppURL:               appUrl,
-		EvaluatorFactory:     evalFactory,
-		RuleStore:            store,
-		Metrics:              ng.Metrics.GetSchedulerMetrics(),
-		AlertSender:          alertsRouter,
-		Tracer:               ng.tracer,
+		MaxAttempts:                   ng.Cfg.UnifiedAlerting.MaxAttempts,
+		DrainTimeout:                  ng.Cfg.UnifiedAlerting.DrainTimeout,
+		EvaluationBackoffThreshold:    ng.Cfg.UnifiedAlerting.EvaluationBackoffThreshold,
+		EvaluationBackoffMax:          ng.Cfg.UnifiedAlerting.EvaluationBackoffMax,
+		C:                             ng.clock,
+		BaseInterval:                  ng.Cfg.UnifiedAlerting.BaseInterval,
+		MinRuleInterval:               ng.Cfg.UnifiedAlerting.MinInterval,
+		DisableGrafanaFolder:          ng.Cfg.UnifiedAlerting.ReservedLabels.IsReservedLabelDisabled(models.FolderTitleLabel),
+		AppURL:                        appUrl,
+		EvaluatorFactory:              evalFactory,
+		RuleStore:                     ng.ruleStore,
+		Metrics:                       ng.Metrics.GetSchedulerMetrics(),
+		AlertSender:                   alertsRouter,
+		Tracer:                        ng.tracer,
+		EvaluationStore:               store,
+		EvaluationSaveInterval:        ng.Cfg.UnifiedAlerting.EvaluationSaveInterval,
+		EvaluationsPerMinuteLimit:     ng.Cfg.UnifiedAlerting.EvaluationsPerMinuteLimit,
+		EvaluationsPerMinuteOrgLimits: ng.Cfg.UnifiedAlerting.EvaluationsPerMinuteOrgLimits,
+		MaxConcurrentEvaluations:      ng.Cfg.UnifiedAlerting.MaxConcurrentEvaluations,
+	}
+	if ng.Cfg.UnifiedAlerting.ShadowMode {
+		schedCfg.LegacyAlertStore = store
+		schedCfg.ShadowModeTolerance = ng.Cfg.UnifiedAlerting.ShadowModeTolerance
+	}
+	if ng.Cfg.UnifiedAlerting.HAEvaluationCoordination {
+		schedCfg.HeartbeatStore = store
+		schedCfg.InstanceID = ng.Cfg.UnifiedAlerting.HAInstanceID
+		schedCfg.HeartbeatTTL = ng.Cfg.UnifiedAlerting.HAHeartbeatTTL
+		schedCfg.ShardingEnabled = ng.Cfg.UnifiedAlerting.HAEvaluationSharding
 	}
 
-	history, err := configureHistorianBackend(initCtx, ng.Cfg.UnifiedAlerting.StateHistory, ng.annotationsRepo, ng.dashboardService, ng.store, ng.Metrics.GetHistorianMetrics(), ng.Log)
+	history, err := configureHistorianBackend(initCtx, ng.Cfg.UnifiedAlerting.StateHistory, ng.annotationsRepo, ng.dashboardService, ng.store, ng.store, ng.Metrics.GetHistorianMetrics(), ng.Log)
 	if err != nil {
 		return err
 	}
 	cfg := state.ManagerCfg{
 		Metrics:              ng.Metrics.GetStateMetrics(),
 		ExternalURL:          appUrl,
-		InstanceStore:        store,
+		InstanceStore:        ng.ruleStore,
 		Images:               ng.imageService,
-		Clock:                clk,
+		Clock:                ng.clock,
 		Historian:            history,
+		MaintenanceWindows:   store,
+		Silences:             store,
+		ContactPointNotifier: ng.MultiOrgAlertmanager,
 		DoNotSaveNormalState: ng.FeatureToggles.IsEnabled(featuremgmt.FlagAlertingNoNormalState),
+
+		MissingSeriesEvalsToResolve: ng.Cfg.UnifiedAlerting.MissingSeriesEvalsToResolve,
+		CleanupInterval:             ng.Cfg.UnifiedAlerting.InstanceCleanupInterval,
+		CleanupBatchSize:            ng.Cfg.UnifiedAlerting.InstanceCleanupBatchSize,
+		SaveStateHistory:            usesSQLHistorian(ng.Cfg.UnifiedAlerting.StateHistory),
+		HistoryRetention:            ng.Cfg.UnifiedAlerting.InstanceHistoryRetention,
+		NormalEvalsToResolve:        ng.Cfg.UnifiedAlerting.NormalEvalsToResolve,
+		InstancesPerRuleLimit:       ng.Cfg.UnifiedAlerting.InstancesPerRuleLimit,
+		MaxInstancesPerRuleLimit:    ng.Cfg.UnifiedAlerting.MaxInstancesPerRuleLimit,
+		RestoredStateMaxAge:         ng.Cfg.UnifiedAlerting.RestoredStateMaxAge,
+		RestorationBatchSize:        ng.Cfg.UnifiedAlerting.StateRestorationBatchSize,
+		NotificationGroupWait:       ng.Cfg.UnifiedAlerting.NotificationGroupWait,
+		NotificationGroupBy:         ng.Cfg.UnifiedAlerting.NotificationGroupBy,
+		ShadowMode:                  ng.Cfg.UnifiedAlerting.ShadowMode,
+		HeartbeatEvaluations:        ng.Cfg.UnifiedAlerting.InstanceHeartbeatEvaluations,
+	}
+	if ng.live != nil {
+		publisher := live.NewPublisher(ng.live.Publish, ng.Log.New("component", "live"))
+		cfg.Publisher = publisher
+		ng.bus.AddEventListener(publisher.RuleChanged)
+		ng.live.GrafanaScope.Features[live.Namespace] = live.NewChannelHandler(ng.accesscontrol, ng.ruleStore)
 	}
 	stateManager := state.NewManager(cfg)
 	scheduler := schedule.NewScheduler(schedCfg, stateManager)
 
 	// if it is required to include folder title to the alerts, we need to subscribe to changes of alert title
 	if !ng.Cfg.UnifiedAlerting.ReservedLabels.IsReservedLabelDisabled(models.FolderTitleLabel) {
-		subscribeToFolderChanges(ng.Log, ng.bus, store)
+		subscribeToFolderChanges(ng.Log, ng.bus, ng.ruleStore)
 	}
 
 	ng.stateManager = stateManager
@@ -239,6 +358,8 @@ func (ng *AlertNG) init() error {
 	contactPointService := provisioning.NewContactPointService(store, ng.SecretsService, store, store, ng.Log)
 	templateService := provisioning.NewTemplateService(store, store, store, ng.Log)
 	muteTimingService := provisioning.NewMuteTimingService(store, store, store, ng.Log)
+	maintenanceWindowService := provisioning.NewMaintenanceWindowService(store, ng.Log)
+	silenceService := provisioning.NewSilenceService(store, ng.Log)
 	alertRuleService := provisioning.NewAlertRuleService(store, store, ng.dashboardService, ng.QuotaService, store,
 		int64(ng.Cfg.UnifiedAlerting.DefaultRuleEvaluationInterval.Seconds()),
 		int64(ng.Cfg.UnifiedAlerting.BaseInterval.Seconds()), ng.Log)
@@ -251,7 +372,7 @@ func (ng *AlertNG) init() error {
 		DataProxy:            ng.DataProxy,
 		QuotaService:         ng.QuotaService,
 		TransactionManager:   store,
-		RuleStore:            store,
+		RuleStore:            ng.ruleStore,
 		AlertingStore:        store,
 		AdminConfigStore:     store,
 		ProvenanceStore:      store,
@@ -262,12 +383,16 @@ func (ng *AlertNG) init() error {
 		ContactPointService:  contactPointService,
 		Templates:            templateService,
 		MuteTimings:          muteTimingService,
+		MaintenanceWindows:   maintenanceWindowService,
+		Silences:             silenceService,
 		AlertRules:           alertRuleService,
 		AlertsRouter:         alertsRouter,
 		EvaluatorFactory:     evalFactory,
 		FeatureManager:       ng.FeatureToggles,
 		AppUrl:               appUrl,
 		Historian:            history,
+		Scheduler:            scheduler,
+		Clock:                ng.clock,
 	}
 	api.RegisterAPIEndpoints(ng.Metrics.GetAPIMetrics())
 
@@ -284,13 +409,7 @@ func (ng *AlertNG) init() error {
 		return err
 	}
 
-	log.RegisterContextualLogProvider(func(ctx context.Context) ([]interface{}, bool) {
-		key, ok := models.RuleKeyFromContext(ctx)
-		if !ok {
-			return nil, false
-		}
-		return key.LogContext(), true
-	})
+	log.RegisterContextualLogProvider(models.RuleLogContextFromContext)
 
 	return DeclareFixedRoles(ng.accesscontrolService)
 }
@@ -315,7 +434,10 @@ func subscribeToFolderChanges(logger log.Logger, bus bus.Bus, dbStore api.RuleSt
 // Run starts the scheduler and Alertmanager.
 func (ng *AlertNG) Run(ctx context.Context) error {
 	ng.Log.Debug("Starting")
-	ng.stateManager.Warm(ctx, ng.store)
+	if ng.Cfg.UnifiedAlerting.MigrateLegacyAlerts {
+		ng.migrateLegacyAlerts(ctx)
+	}
+	ng.stateManager.Warm(ctx, ng.ruleStore)
 
 	children, subCtx := errgroup.WithContext(ctx)
 
@@ -329,6 +451,12 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 	children.Go(func() error {
 		return ng.AlertsRouter.Run(subCtx)
 	})
+	children.Go(func() error {
+		return ng.deleteExpiredIdempotencyKeys(subCtx)
+	})
+	children.Go(func() error {
+		return ng.reloadSettingsOnSignal(subCtx)
+	})
 
 	if ng.Cfg.UnifiedAlerting.ExecuteAlerts {
 		children.Go(func() error {
@@ -338,6 +466,44 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 	return children.Wait()
 }
 
+// migrateLegacyAlerts migrates the legacy dashboard alerts that have not been migrated yet to alert rules, and logs the
+// legacy alerts that cannot be migrated.
+func (ng *AlertNG) migrateLegacyAlerts(ctx context.Context) {
+	report, err := ng.store.MigrateLegacyAlerts(ctx)
+	if err != nil {
+		ng.Log.Error("Failed to migrate the legacy alerts", "error", err)
+		return
+	}
+	for _, r := range report.Results {
+		if r.Status == models.LegacyAlertFailed {
+			ng.Log.Warn("Failed to migrate legacy alert", "org", r.OrgID, "alertId", r.AlertID, "dashboard", r.DashboardUID, "panel", r.PanelID, "name", r.Name, "reason", r.Reason)
+		}
+	}
+	ng.Log.Info("Migrated the legacy alerts", "migrated", report.Count(models.LegacyAlertMigrated),
+		"skipped", report.Count(models.LegacyAlertSkipped), "failed", report.Count(models.LegacyAlertFailed))
+}
+
+// deleteExpiredIdempotencyKeys deletes the idempotency keys of alert rule creation requests once an hour, after they expire.
+func (ng *AlertNG) deleteExpiredIdempotencyKeys(ctx context.Context) error {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			deleted, err := ng.store.DeleteIdempotencyKeys(ctx, time.Now().Add(-models.IdempotencyKeyTTL))
+			if err != nil {
+				ng.Log.Error("Failed to delete expired idempotency keys", "error", err)
+				continue
+			}
+			if deleted > 0 {
+				ng.Log.Debug("Deleted expired idempotency keys", "count", deleted)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 // IsDisabled returns true if the alerting service is disabled for this instance.
 func (ng *AlertNG) IsDisabled() bool {
 	if ng.Cfg == nil {
@@ -380,7 +546,25 @@ type Historian interface {
 	state.Historian
 }
 
-func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingStateHistorySettings, ar annotations.Repository, ds dashboards.DashboardService, rs historian.RuleStore, met *metrics.Historian, l log.Logger) (Historian, error) {
+// usesSQLHistorian returns true if the state history is enabled and the sql backend is configured, on its own or as
+// primary or secondary of the multiple backend.
+func usesSQLHistorian(cfg setting.UnifiedAlertingStateHistorySettings) bool {
+	if !cfg.Enabled {
+		return false
+	}
+	backends := []string{cfg.Backend}
+	if backend, err := historian.ParseBackendType(cfg.Backend); err == nil && backend == historian.BackendTypeMultiple {
+		backends = append([]string{cfg.MultiPrimary}, cfg.MultiSecondaries...)
+	}
+	for _, b := range backends {
+		if backend, err := historian.ParseBackendType(b); err == nil && backend == historian.BackendTypeSQL {
+			return true
+		}
+	}
+	return false
+}
+
+func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingStateHistorySettings, ar annotations.Repository, ds dashboards.DashboardService, rs historian.RuleStore, hs historian.StateHistoryStore, met *metrics.Historian, l log.Logger) (Historian, error) {
 	if !cfg.Enabled {
 		met.Info.WithLabelValues("noop").Set(0)
 		return historian.NewNopHistorian(), nil
@@ -395,7 +579,7 @@ func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingS
 	if backend == historian.BackendTypeMultiple {
 		primaryCfg := cfg
 		primaryCfg.Backend = cfg.MultiPrimary
-		primary, err := configureHistorianBackend(ctx, primaryCfg, ar, ds, rs, met, l)
+		primary, err := configureHistorianBackend(ctx, primaryCfg, ar, ds, rs, hs, met, l)
 		if err != nil {
 			return nil, fmt.Errorf("multi-backend target \"%s\" was misconfigured: %w", cfg.MultiPrimary, err)
 		}
@@ -404,7 +588,7 @@ func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingS
 		for _, b := range cfg.MultiSecondaries {
 			secCfg := cfg
 			secCfg.Backend = b
-			sec, err := configureHistorianBackend(ctx, secCfg, ar, ds, rs, met, l)
+			sec, err := configureHistorianBackend(ctx, secCfg, ar, ds, rs, hs, met, l)
 			if err != nil {
 				return nil, fmt.Errorf("multi-backend target \"%s\" was miconfigured: %w", b, err)
 			}
@@ -415,7 +599,7 @@ func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingS
 		return historian.NewMultipleBackend(primary, secondaries...), nil
 	}
 	if backend == historian.BackendTypeAnnotations {
-		return historian.NewAnnotationBackend(ar, ds, rs, met), nil
+		return historian.NewAnnotationBackend(ar, ds, rs, met, cfg.AnnotatePending), nil
 	}
 	if backend == historian.BackendTypeLoki {
 		lcfg, err := historian.NewLokiConfig(cfg)
@@ -433,7 +617,7 @@ func configureHistorianBackend(ctx context.Context, cfg setting.UnifiedAlertingS
 		return backend, nil
 	}
 	if backend == historian.BackendTypeSQL {
-		return historian.NewSqlBackend(), nil
+		return historian.NewSqlBackend(hs), nil
 	}
 
 	return nil, fmt.Errorf("unrecognized state history backend: %s", backend)
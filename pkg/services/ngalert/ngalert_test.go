@@ -7,22 +7,40 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/api/routing"
 	"github.com/grafana/grafana/pkg/bus"
 	"github.com/grafana/grafana/pkg/events"
+	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins"
+	acmock "github.com/grafana/grafana/pkg/services/accesscontrol/mock"
+	"github.com/grafana/grafana/pkg/services/annotations/annotationstest"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	databasestore "github.com/grafana/grafana/pkg/services/dashboards/database"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/folder/folderimpl"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/services/secrets/database"
+	secretsManager "github.com/grafana/grafana/pkg/services/secrets/manager"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/tag/tagimpl"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
 
+var _ RuleStore = &fakes.RuleStore{}
+
 func Test_subscribeToFolderChanges(t *testing.T) {
 	orgID := rand.Int63()
 	folder := &folder.Folder{
@@ -68,7 +86,7 @@ func TestConfigureHistorianBackend(t *testing.T) {
 			Backend: "invalid-backend",
 		}
 
-		_, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, met, logger)
+		_, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, nil, met, logger)
 
 		require.ErrorContains(t, err, "unrecognized")
 	})
@@ -82,7 +100,7 @@ func TestConfigureHistorianBackend(t *testing.T) {
 			MultiPrimary: "invalid-backend",
 		}
 
-		_, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, met, logger)
+		_, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, nil, met, logger)
 
 		require.ErrorContains(t, err, "multi-backend target")
 		require.ErrorContains(t, err, "unrecognized")
@@ -98,7 +116,7 @@ func TestConfigureHistorianBackend(t *testing.T) {
 			MultiSecondaries: []string{"sql", "invalid-backend"},
 		}
 
-		_, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, met, logger)
+		_, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, nil, met, logger)
 
 		require.ErrorContains(t, err, "multi-backend target")
 		require.ErrorContains(t, err, "unrecognized")
@@ -115,7 +133,7 @@ func TestConfigureHistorianBackend(t *testing.T) {
 			LokiWriteURL: "http://gone.invalid",
 		}
 
-		h, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, met, logger)
+		h, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, nil, met, logger)
 
 		require.NotNil(t, h)
 		require.NoError(t, err)
@@ -130,7 +148,7 @@ func TestConfigureHistorianBackend(t *testing.T) {
 			Backend: "annotations",
 		}
 
-		h, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, met, logger)
+		h, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, nil, met, logger)
 
 		require.NotNil(t, h)
 		require.NoError(t, err)
@@ -151,7 +169,7 @@ grafana_alerting_state_history_info{backend="annotations"} 1
 			Enabled: false,
 		}
 
-		h, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, met, logger)
+		h, err := configureHistorianBackend(context.Background(), cfg, nil, nil, nil, nil, met, logger)
 
 		require.NotNil(t, h)
 		require.NoError(t, err)
@@ -164,3 +182,113 @@ grafana_alerting_state_history_info{backend="noop"} 0
 		require.NoError(t, err)
 	})
 }
+
+func TestUsesSQLHistorian(t *testing.T) {
+	testCases := []struct {
+		name     string
+		cfg      setting.UnifiedAlertingStateHistorySettings
+		expected bool
+	}{
+		{name: "sql backend", cfg: setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "sql"}, expected: true},
+		{name: "disabled state history", cfg: setting.UnifiedAlertingStateHistorySettings{Enabled: false, Backend: "sql"}, expected: false},
+		{name: "annotations backend", cfg: setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "annotations"}, expected: false},
+		{name: "sql primary", cfg: setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "multiple", MultiPrimary: "sql", MultiSecondaries: []string{"loki"}}, expected: true},
+		{name: "sql secondary", cfg: setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "multiple", MultiPrimary: "annotations", MultiSecondaries: []string{"loki", "sql"}}, expected: true},
+		{name: "multiple without sql", cfg: setting.UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "multiple", MultiPrimary: "annotations", MultiSecondaries: []string{"loki"}}, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, usesSQLHistorian(tc.cfg))
+		})
+	}
+}
+
+func TestRestoreStateOnStartup(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	sqlStore := db.InitTestDB(t)
+
+	rule := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(10*time.Second), models.WithFor(time.Minute))()
+	rule.ID = 0
+	rule.IsPaused = false
+	firstNG := setupAlertNG(t, sqlStore, 0)
+	_, err := firstNG.store.InsertAlertRules(ctx, []models.AlertRule{*rule})
+	require.NoError(t, err)
+
+	// the rule keeps firing for longer than its "for" duration, and its instance is saved to the database
+	startsAt := time.Now().Add(-10 * time.Minute)
+	result := func(evaluatedAt time.Time) eval.Results {
+		return eval.Results{{Instance: data.Labels{"instance": "host-1"}, State: eval.Alerting, EvaluatedAt: evaluatedAt}}
+	}
+	firstNG.stateManager.ProcessEvalResults(ctx, startsAt, rule, result(startsAt), nil)
+	firing := startsAt.Add(rule.For)
+	states := firstNG.stateManager.ProcessEvalResults(ctx, firing, rule, result(firing), nil)
+	require.Len(t, states, 1)
+	require.Equal(t, eval.Alerting, states[0].State.State)
+
+	t.Run("state of the instance is restored when Grafana starts", func(t *testing.T) {
+		restarted := setupAlertNG(t, sqlStore, time.Hour)
+		restarted.stateManager.Warm(ctx, restarted.store)
+
+		next := firing.Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		transitions := restarted.stateManager.ProcessEvalResults(ctx, next, rule, result(next), nil)
+		require.Len(t, transitions, 1)
+		require.Equal(t, eval.Alerting, transitions[0].PreviousState)
+		require.Equal(t, eval.Alerting, transitions[0].State.State)
+		require.Equal(t, firing.Unix(), transitions[0].State.StartsAt.Unix())
+	})
+
+	t.Run("state of the instance that is too old is discarded", func(t *testing.T) {
+		restarted := setupAlertNG(t, sqlStore, 5*time.Minute)
+		restarted.stateManager.Warm(ctx, restarted.store)
+		require.Empty(t, restarted.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+
+		instances, err := restarted.store.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		require.Empty(t, instances)
+
+		now := time.Now()
+		transitions := restarted.stateManager.ProcessEvalResults(ctx, now, rule, result(now), nil)
+		require.Len(t, transitions, 1)
+		require.Equal(t, eval.Normal, transitions[0].PreviousState)
+		require.Equal(t, eval.Pending, transitions[0].State.State)
+	})
+}
+
+// setupAlertNG creates the alerting service on top of the given database, as it is created when Grafana starts.
+func setupAlertNG(t *testing.T, sqlStore *sqlstore.SQLStore, restoredStateMaxAge time.Duration) *AlertNG {
+	t.Helper()
+	cfg := setting.NewCfg()
+	cfg.IsFeatureToggleEnabled = featuremgmt.WithFeatures().IsEnabled
+	cfg.UnifiedAlerting = setting.UnifiedAlertingSettings{
+		BaseInterval:        setting.SchedulerBaseInterval,
+		RestoredStateMaxAge: restoredStateMaxAge,
+	}
+	cfg.UnifiedAlerting.Enabled = util.Pointer(true)
+	return provideAlertNG(t, sqlStore, cfg)
+}
+
+// provideAlertNG creates the alerting service with the configuration on top of the given database.
+func provideAlertNG(t *testing.T, sqlStore *sqlstore.SQLStore, cfg *setting.Cfg) *AlertNG {
+	t.Helper()
+	features := featuremgmt.WithFeatures()
+	quotaService := quotatest.New(false, nil)
+	dashboardStore, err := databasestore.ProvideDashboardStore(sqlStore, sqlStore.Cfg, features, tagimpl.ProvideService(sqlStore, sqlStore.Cfg), quotaService)
+	require.NoError(t, err)
+	ac := acmock.New()
+	folderStore := folderimpl.ProvideDashboardFolderStore(sqlStore)
+	tracer := tracing.InitializeTracerForTest()
+	bus := bus.ProvideBus(tracer)
+	folderService := folderimpl.ProvideService(ac, bus, cfg, dashboardStore, folderStore, nil, features)
+	secretsService := secretsManager.SetupTestService(t, database.ProvideSecretsStore(sqlStore))
+
+	ng, err := ProvideService(
+		cfg, features, nil, nil, routing.NewRouteRegister(), sqlStore, nil, nil, nil, quotaService,
+		secretsService, nil, metrics.NewNGAlert(prometheus.NewRegistry()), folderService, ac, &dashboards.FakeDashboardService{}, nil, bus, ac,
+		annotationstest.NewFakeAnnotationsRepo(), &plugins.FakePluginStore{}, tracer, nil, nil,
+	)
+	require.NoError(t, err)
+	return ng
+}
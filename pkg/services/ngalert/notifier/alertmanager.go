@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	alertingNotify "github.com/grafana/alerting/notify"
@@ -56,6 +57,11 @@ type Alertmanager struct {
 
 	decryptFn receivers.GetDecryptedValueFn
 	orgID     int64
+
+	// contactPoints are the contact points of the applied configuration, which the notifications of rules are sent to
+	// directly. They are replaced whenever a changed configuration is applied.
+	contactPointsMtx sync.Mutex
+	contactPoints    *contactPoints
 }
 
 // maintenanceOptions represent the options for components that need maintenance on a frequency within the Alertmanager.
@@ -290,6 +296,7 @@ func (am *Alertmanager) applyConfig(cfg *apimodels.PostableUserConfig, rawConfig
 	if err != nil {
 		return false, err
 	}
+	am.setContactPoints(newContactPoints(cfg.AlertmanagerConfig.Receivers, tmpl))
 
 	return true, nil
 }
@@ -344,18 +351,9 @@ func (am *Alertmanager) buildReceiverIntegrations(receiver *apimodels.PostableAp
 }
 
 func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaReceiver, tmpl *alertingNotify.Template) (alertingNotify.NotificationChannel, error) {
-	// secure settings are already encrypted at this point
-	secureSettings := make(map[string][]byte, len(r.SecureSettings))
-
-	for k, v := range r.SecureSettings {
-		d, err := base64.StdEncoding.DecodeString(v)
-		if err != nil {
-			return nil, InvalidReceiverError{
-				Receiver: r,
-				Err:      errors.New("failed to decode secure setting"),
-			}
-		}
-		secureSettings[k] = d
+	secureSettings, err := decodeSecureSettings(r)
+	if err != nil {
+		return nil, err
 	}
 
 	var (
@@ -393,6 +391,22 @@ func (am *Alertmanager) buildReceiverIntegration(r *apimodels.PostableGrafanaRec
 	return n, nil
 }
 
+// decodeSecureSettings decodes the secure settings of the receiver. They are still encrypted.
+func decodeSecureSettings(r *apimodels.PostableGrafanaReceiver) (map[string][]byte, error) {
+	secureSettings := make(map[string][]byte, len(r.SecureSettings))
+	for k, v := range r.SecureSettings {
+		d, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, InvalidReceiverError{
+				Receiver: r,
+				Err:      errors.New("failed to decode secure setting"),
+			}
+		}
+		secureSettings[k] = d
+	}
+	return secureSettings, nil
+}
+
 // PutAlerts receives the alerts and then sends them through the corresponding route based on whenever the alert has a receiver embedded or not
 func (am *Alertmanager) PutAlerts(postableAlerts apimodels.PostableAlerts) error {
 	alerts := make(alertingNotify.PostableAlerts, 0, len(postableAlerts.PostableAlerts))
@@ -0,0 +1,161 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+const (
+	// AlertmanagerPayloadFormat is the value of the payloadFormat setting of a webhook contact point that sends the
+	// notifications of the alert rules associated with it in the format of the webhooks of the Prometheus Alertmanager.
+	AlertmanagerPayloadFormat = "alertmanager"
+
+	// alertmanagerWebhookVersion is the version of the format of the webhooks of the Prometheus Alertmanager.
+	alertmanagerWebhookVersion = "4"
+)
+
+// webhookSettings are the settings of a webhook contact point that are used to send notifications in the format of the
+// Prometheus Alertmanager.
+type webhookSettings struct {
+	URL                 string `json:"url"`
+	HTTPMethod          string `json:"httpMethod"`
+	Username            string `json:"username"`
+	Password            string `json:"password"`
+	AuthorizationScheme string `json:"authorization_scheme"`
+	// MaxAlerts is a number, or a string for backward compatibility of the webhook contact points.
+	MaxAlerts     json.Number `json:"maxAlerts"`
+	PayloadFormat string      `json:"payloadFormat"`
+}
+
+// isAlertmanagerWebhook returns true if the contact point is a webhook that sends notifications in the format of the
+// Prometheus Alertmanager.
+func isAlertmanagerWebhook(r *apimodels.PostableGrafanaReceiver) bool {
+	if r.Type != "webhook" {
+		return false
+	}
+	var settings webhookSettings
+	if err := json.Unmarshal(r.Settings, &settings); err != nil {
+		return false
+	}
+	return settings.PayloadFormat == AlertmanagerPayloadFormat
+}
+
+// notifyAlertmanagerWebhook sends the notification to the webhook contact point in the format of the webhooks of the Prometheus
+// Alertmanager, so that receivers of the Alertmanager can be used without changes.
+func (am *Alertmanager) notifyAlertmanagerWebhook(ctx context.Context, r *apimodels.PostableGrafanaReceiver, tmpl *alertingNotify.Template, groupKey string, n ngmodels.AlertingNotification) error {
+	var settings webhookSettings
+	if err := json.Unmarshal(r.Settings, &settings); err != nil {
+		return InvalidReceiverError{Receiver: r, Err: fmt.Errorf("failed to parse settings: %w", err)}
+	}
+	if settings.URL == "" {
+		return InvalidReceiverError{Receiver: r, Err: errors.New("could not find url property in settings")}
+	}
+	var maxAlerts int64
+	if settings.MaxAlerts != "" {
+		var err error
+		if maxAlerts, err = settings.MaxAlerts.Int64(); err != nil || maxAlerts < 0 {
+			return InvalidReceiverError{Receiver: r, Err: fmt.Errorf("invalid maxAlerts: %s", settings.MaxAlerts)}
+		}
+	}
+	secureSettings, err := decodeSecureSettings(r)
+	if err != nil {
+		return err
+	}
+
+	msg := newAlertmanagerWebhookMessage(tmpl, r.Name, groupKey, n, am.Settings.AppURL, int(maxAlerts))
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	cmd := &notifications.SendWebhookSync{
+		Url:         settings.URL,
+		User:        settings.Username,
+		Password:    am.decryptFn(ctx, secureSettings, "password", settings.Password),
+		Body:        string(body),
+		HttpMethod:  settings.HTTPMethod,
+		ContentType: "application/json",
+	}
+	if cmd.HttpMethod == "" {
+		cmd.HttpMethod = http.MethodPost
+	}
+	if credentials := am.decryptFn(ctx, secureSettings, "authorization_credentials", ""); credentials != "" {
+		if cmd.User != "" || cmd.Password != "" {
+			return InvalidReceiverError{Receiver: r, Err: errors.New("both HTTP Basic Authentication and Authorization Header are set, only 1 is permitted")}
+		}
+		scheme := settings.AuthorizationScheme
+		if scheme == "" {
+			scheme = "Bearer"
+		}
+		cmd.HttpHeader = map[string]string{"Authorization": fmt.Sprintf("%s %s", scheme, credentials)}
+	}
+	return am.NotificationService.SendWebhookSync(ctx, cmd)
+}
+
+// newAlertmanagerWebhookMessage returns the payload of a webhook of the Prometheus Alertmanager for the instances of the
+// notification. At most maxAlerts instances are included in the payload, unless it is zero. The number of the instances
+// that are not included is reported as truncated. The instances are grouped by alert name. Like the alerts that the
+// Prometheus Alertmanager receives, the alerts do not have the internal labels and annotations that start with "__".
+func newAlertmanagerWebhookMessage(tmpl *alertingNotify.Template, receiver, groupKey string, n ngmodels.AlertingNotification, appURL string, maxAlerts int) *webhook.Message {
+	alerts := newContactPointAlerts(n, appURL)
+	for _, alert := range alerts {
+		removeInternalLabels(alert.Labels)
+		removeInternalLabels(alert.Annotations)
+	}
+	var truncated uint64
+	if n.Count > len(alerts) {
+		truncated = uint64(n.Count - len(alerts))
+	}
+	if maxAlerts > 0 && len(alerts) > maxAlerts {
+		truncated += uint64(len(alerts) - maxAlerts)
+		alerts = alerts[:maxAlerts]
+	}
+	var groupLabels model.LabelSet
+	if len(alerts) > 0 {
+		groupLabels = model.LabelSet{model.AlertNameLabel: alerts[0].Labels[model.AlertNameLabel]}
+	}
+	return &webhook.Message{
+		Data:            tmpl.Data(receiver, groupLabels, alerts...),
+		Version:         alertmanagerWebhookVersion,
+		GroupKey:        groupKey,
+		TruncatedAlerts: truncated,
+	}
+}
+
+func removeInternalLabels(labels model.LabelSet) {
+	for k := range labels {
+		if strings.HasPrefix(string(k), model.ReservedLabelPrefix) {
+			delete(labels, k)
+		}
+	}
+}
+
+// newContactPointAlerts converts the notification to the alerts of its instances. A notification of several instances
+// only has the labels of its first instances, so the other instances are not included.
+func newContactPointAlerts(n ngmodels.AlertingNotification, appURL string) []*types.Alert {
+	if len(n.Instances) == 0 {
+		return []*types.Alert{newContactPointAlert(n, appURL)}
+	}
+	alerts := make([]*types.Alert, 0, len(n.Instances))
+	for _, labels := range n.Instances {
+		instance := n
+		instance.Labels = labels
+		instance.Instances = nil
+		instance.Count = 1
+		alerts = append(alerts, newContactPointAlert(instance, appURL))
+	}
+	return alerts
+}
@@ -0,0 +1,126 @@
+package notifier
+
+import (
+	"encoding/json"
+	"flag"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/template"
+	"github.com/stretchr/testify/require"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of the Alertmanager webhook payloads")
+
+func TestNewAlertmanagerWebhookMessage(t *testing.T) {
+	tmpl, err := template.FromGlobs(nil)
+	require.NoError(t, err)
+	tmpl.ExternalURL, err = url.Parse("http://localhost:3000/")
+	require.NoError(t, err)
+
+	startsAt := time.Date(2023, 3, 20, 10, 0, 0, 0, time.UTC)
+	firing := ngmodels.AlertingNotification{
+		OrgID:       1,
+		RuleUID:     "rule-uid",
+		RuleTitle:   "High CPU",
+		Labels:      map[string]string{"instance": "server-1", "team": "infra"},
+		Annotations: map[string]string{"summary": "CPU is high"},
+		Value:       "[ var='A' value=95 ]",
+		RunbookURL:  "https://example.com/runbook",
+		StartsAt:    startsAt,
+		Count:       1,
+	}
+	resolved := firing
+	resolved.EndsAt = startsAt.Add(15 * time.Minute)
+	grouped := firing
+	grouped.Labels = map[string]string{"team": "infra"}
+	grouped.Count = 3
+	grouped.Instances = []map[string]string{
+		{"instance": "server-1", "team": "infra"},
+		{"instance": "server-2", "team": "infra"},
+	}
+
+	testCases := []struct {
+		name      string
+		n         ngmodels.AlertingNotification
+		maxAlerts int
+	}{
+		{name: "firing", n: firing},
+		{name: "resolved", n: resolved},
+		{name: "grouped", n: grouped, maxAlerts: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := newAlertmanagerWebhookMessage(tmpl, "team", "hook-group-key", tc.n, "http://localhost:3000/", tc.maxAlerts)
+			actual, err := json.MarshalIndent(msg, "", "  ")
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "alertmanager_webhook_"+tc.name+".golden.json")
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(golden, append(actual, '\n'), 0600))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), string(actual)+"\n")
+
+			example, err := os.ReadFile(filepath.Join("testdata", "alertmanager_webhook_example.json"))
+			require.NoError(t, err)
+			requireSameWebhookSchema(t, example, actual)
+		})
+	}
+
+	t.Run("endsAt of firing alerts is the zero time", func(t *testing.T) {
+		msg := newAlertmanagerWebhookMessage(tmpl, "team", "hook-group-key", firing, "", 0)
+		require.Equal(t, "firing", msg.Status)
+		require.True(t, msg.Alerts[0].EndsAt.IsZero())
+		require.Empty(t, msg.Alerts[0].GeneratorURL)
+	})
+
+	t.Run("grouped notification reports instances that are not included as truncated", func(t *testing.T) {
+		msg := newAlertmanagerWebhookMessage(tmpl, "team", "hook-group-key", grouped, "", 0)
+		require.Len(t, msg.Alerts, 2)
+		require.Equal(t, uint64(1), msg.TruncatedAlerts)
+	})
+}
+
+// requireSameWebhookSchema checks that the payload has the fields of the captured payload of the Prometheus Alertmanager,
+// with values of the same types, and that its timestamps are in RFC3339 format.
+func requireSameWebhookSchema(t *testing.T, example, payload []byte) {
+	t.Helper()
+	var expected, actual map[string]interface{}
+	require.NoError(t, json.Unmarshal(example, &expected))
+	require.NoError(t, json.Unmarshal(payload, &actual))
+	requireSameFields(t, expected, actual)
+
+	expectedAlert := expected["alerts"].([]interface{})[0].(map[string]interface{})
+	for _, a := range actual["alerts"].([]interface{}) {
+		alert := a.(map[string]interface{})
+		requireSameFields(t, expectedAlert, alert)
+		for _, field := range []string{"startsAt", "endsAt"} {
+			_, err := time.Parse(time.RFC3339, alert[field].(string))
+			require.NoError(t, err, "%s should be in RFC3339 format", field)
+		}
+	}
+}
+
+func requireSameFields(t *testing.T, expected, actual map[string]interface{}) {
+	t.Helper()
+	fields := func(m map[string]interface{}) []string {
+		result := make([]string, 0, len(m))
+		for k := range m {
+			result = append(result, k)
+		}
+		sort.Strings(result)
+		return result
+	}
+	require.Equal(t, fields(expected), fields(actual))
+	for k, v := range expected {
+		require.IsType(t, v, actual[k], "field %s", k)
+	}
+}
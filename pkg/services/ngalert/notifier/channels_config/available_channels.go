@@ -842,6 +842,22 @@ func GetAvailableNotifiers() []*NotifierPlugin {
 					PropertyName: "message",
 					Placeholder:  alertingTemplates.DefaultMessageEmbed,
 				},
+				{
+					Label:       "Payload format",
+					Description: "Format of the notifications of the alert rules that are associated with the contact point. The Alertmanager format is the one of the webhooks of the Prometheus Alertmanager, which ignores the title and message.",
+					Element:     ElementTypeSelect,
+					SelectOptions: []SelectOption{
+						{
+							Value: "",
+							Label: "Grafana",
+						},
+						{
+							Value: "alertmanager",
+							Label: "Alertmanager",
+						},
+					},
+					PropertyName: "payloadFormat",
+				},
 			},
 		},
 		{
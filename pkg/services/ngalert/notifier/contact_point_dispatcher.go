@@ -0,0 +1,229 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// retriableError is a failed delivery that the integration of the contact point reported as temporary.
+type retriableError struct {
+	err error
+}
+
+func (e retriableError) Error() string {
+	return e.err.Error()
+}
+
+func (e retriableError) Unwrap() error {
+	return e.err
+}
+
+// retryAfter returns true if the failed delivery can be retried, and the minimum delay before the retry that the contact
+// point asked for, if any. Network errors, 5xx and 429 responses are retried, but other responses, such as 4xx responses
+// to invalid credentials, are not.
+func retryAfter(err error) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) {
+		return false, 0
+	}
+	var respErr notifications.WebhookResponseError
+	if errors.As(err, &respErr) {
+		if respErr.StatusCode == http.StatusTooManyRequests || respErr.StatusCode >= 500 {
+			return true, respErr.RetryAfter
+		}
+		return false, 0
+	}
+	if errors.As(err, &retriableError{}) {
+		return true, 0
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr), 0
+}
+
+// maxPendingContactPointDeliveries is the maximum number of deliveries that can wait for their first or next attempt, or
+// be sent, at the same time. Every pending delivery holds a timer, so new deliveries are dropped beyond this limit rather
+// than piling up while the contact points are down.
+const maxPendingContactPointDeliveries = 10000
+
+// contactPointDispatcher delivers the notifications of alert rules to contact points in the background, so that slow or
+// failing contact points do not delay the evaluation of the rules. Deliveries that fail with an error that can be
+// temporary are retried up to maxAttempts times in total, with an exponential backoff between the attempts.
+type contactPointDispatcher struct {
+	clock   clock.Clock
+	log     log.Logger
+	metrics *metrics.MultiOrgAlertmanager
+	// jitter returns the actual delay before a retry, given the backoff.
+	jitter func(backoff time.Duration) time.Duration
+	// maxPending is the maximum number of deliveries in the backlog. New deliveries beyond it are dropped.
+	maxPending int
+
+	mtx sync.Mutex
+	// maxAttempts, initialBackoff and maxBackoff are guarded by mtx because setRetry can change them at runtime.
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	ctx            context.Context
+	cancel         context.CancelFunc
+	timers         map[*clock.Timer]struct{}
+	// sending is the number of attempts in progress.
+	sending int
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+func newContactPointDispatcher(clk clock.Clock, logger log.Logger, m *metrics.MultiOrgAlertmanager, maxAttempts int, initialBackoff, maxBackoff time.Duration) *contactPointDispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &contactPointDispatcher{
+		clock:          clk,
+		log:            logger,
+		metrics:        m,
+		maxAttempts:    maxAttempts,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		jitter:         equalJitter,
+		maxPending:     maxPendingContactPointDeliveries,
+		ctx:            ctx,
+		cancel:         cancel,
+		timers:         map[*clock.Timer]struct{}{},
+	}
+}
+
+// equalJitter returns a random delay between half the backoff and the backoff, so that the retries of notifications that
+// failed together are spread out.
+func equalJitter(backoff time.Duration) time.Duration {
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return backoff
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// dispatch delivers the notification in the background. Deliveries dispatched after stop, or when the backlog already
+// has maxPending deliveries, are dropped.
+func (d *contactPointDispatcher) dispatch(delivery *contactPointDelivery) {
+	d.schedule(delivery, 1, 0)
+}
+
+func (d *contactPointDispatcher) schedule(delivery *contactPointDelivery, attempt int, delay time.Duration) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.stopped {
+		d.log.Warn("Dropped notification to contact point on shutdown", "contactPoint", delivery.contactPointUID, "type", delivery.integrationType, "attempt", attempt)
+		return
+	}
+	// retries are already counted in the backlog by the attempt that schedules them
+	if attempt == 1 && len(d.timers)+d.sending >= d.maxPending {
+		d.metrics.ContactPointDeliveryPermanentFailures.WithLabelValues(delivery.integrationType).Inc()
+		d.log.Error("Dropped notification to contact point because too many notifications are pending", "contactPoint", delivery.contactPointUID, "type", delivery.integrationType, "pending", d.maxPending)
+		return
+	}
+	var timer *clock.Timer
+	timer = d.clock.AfterFunc(delay, func() {
+		d.mtx.Lock()
+		if _, ok := d.timers[timer]; !ok {
+			// the timer was stopped on shutdown, but fired concurrently
+			d.mtx.Unlock()
+			return
+		}
+		delete(d.timers, timer)
+		d.sending++
+		d.wg.Add(1)
+		d.mtx.Unlock()
+		defer d.wg.Done()
+		d.attempt(delivery, attempt)
+		d.mtx.Lock()
+		d.sending--
+		d.mtx.Unlock()
+	})
+	d.timers[timer] = struct{}{}
+}
+
+func (d *contactPointDispatcher) attempt(delivery *contactPointDelivery, attempt int) {
+	d.metrics.ContactPointDeliveryAttempts.WithLabelValues(delivery.integrationType).Inc()
+	err := delivery.send(d.ctx)
+	if err == nil {
+		return
+	}
+	logger := d.log.New("contactPoint", delivery.contactPointUID, "type", delivery.integrationType, "attempt", attempt)
+	retriable, minDelay := retryAfter(err)
+	d.mtx.Lock()
+	maxAttempts, maxBackoff := d.maxAttempts, d.maxBackoff
+	d.mtx.Unlock()
+	if !retriable || attempt >= maxAttempts || d.ctx.Err() != nil {
+		d.metrics.ContactPointDeliveryPermanentFailures.WithLabelValues(delivery.integrationType).Inc()
+		logger.Error("Failed to notify contact point", "retriable", retriable, "error", err)
+		return
+	}
+	if minDelay > maxBackoff {
+		// the contact point asked to wait longer than any retry is allowed to, and the notification would be stale by then
+		d.metrics.ContactPointDeliveryPermanentFailures.WithLabelValues(delivery.integrationType).Inc()
+		logger.Error("Failed to notify contact point, and it asked to retry later than the maximum backoff", "retryAfter", minDelay, "maxBackoff", maxBackoff, "error", err)
+		return
+	}
+	delay := d.backoff(attempt)
+	if delay < minDelay {
+		delay = minDelay
+	}
+	d.metrics.ContactPointDeliveryRetries.WithLabelValues(delivery.integrationType).Inc()
+	logger.Warn("Failed to notify contact point, retrying", "delay", delay, "error", err)
+	d.schedule(delivery, attempt+1, delay)
+}
+
+// backoff returns the delay before the retry of the given attempt. The backoff doubles with every attempt up to
+// maxBackoff, and is jittered.
+func (d *contactPointDispatcher) backoff(attempt int) time.Duration {
+	d.mtx.Lock()
+	backoff, maxBackoff := d.initialBackoff, d.maxBackoff
+	d.mtx.Unlock()
+	for i := 1; i < attempt && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return d.jitter(backoff)
+}
+
+// setRetry changes how deliveries are retried. Deliveries that wait for their next attempt keep their delay, but the
+// new settings decide whether they are retried again.
+func (d *contactPointDispatcher) setRetry(maxAttempts int, initialBackoff, maxBackoff time.Duration) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.maxAttempts = maxAttempts
+	d.initialBackoff = initialBackoff
+	d.maxBackoff = maxBackoff
+}
+
+// backlog returns the number of deliveries that wait for their first or next attempt, or are being sent.
+func (d *contactPointDispatcher) backlog() int {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return len(d.timers) + d.sending
+}
+
+// stop drops the deliveries that wait for their next attempt, cancels the attempts in progress and waits for them to
+// return.
+func (d *contactPointDispatcher) stop() {
+	d.mtx.Lock()
+	d.stopped = true
+	for timer := range d.timers {
+		timer.Stop()
+	}
+	if len(d.timers) > 0 {
+		d.log.Warn("Dropped notifications to contact points on shutdown", "notifications", len(d.timers))
+	}
+	d.timers = map[*clock.Timer]struct{}{}
+	d.cancel()
+	d.mtx.Unlock()
+	d.wg.Wait()
+}
@@ -0,0 +1,293 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+// fakeContactPoint fails the first failures attempts to deliver a notification with err, and then succeeds.
+type fakeContactPoint struct {
+	mtx       sync.Mutex
+	failures  int
+	err       error
+	attempts  int
+	delivered int
+}
+
+func (f *fakeContactPoint) delivery() *contactPointDelivery {
+	return &contactPointDelivery{
+		contactPointUID: "contact-point",
+		integrationType: "webhook",
+		send: func(ctx context.Context) error {
+			f.mtx.Lock()
+			defer f.mtx.Unlock()
+			f.attempts++
+			if f.attempts <= f.failures {
+				return fmt.Errorf("failed to notify contact point contact-point: %w", f.err)
+			}
+			f.delivered++
+			return nil
+		},
+	}
+}
+
+func (f *fakeContactPoint) counts() (int, int) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.attempts, f.delivered
+}
+
+func newTestContactPointDispatcher(clk clock.Clock, maxAttempts int) (*contactPointDispatcher, *metrics.MultiOrgAlertmanager) {
+	m := metrics.NewMultiOrgAlertmanagerMetrics(prometheus.NewRegistry())
+	d := newContactPointDispatcher(clk, log.NewNopLogger(), m, maxAttempts, time.Second, 4*time.Second)
+	d.jitter = func(backoff time.Duration) time.Duration { return backoff }
+	return d, m
+}
+
+func TestContactPointDispatcher(t *testing.T) {
+	serverError := notifications.WebhookResponseError{StatusCode: http.StatusServiceUnavailable, Status: "503 Service Unavailable"}
+
+	t.Run("retries a failed delivery with exponential backoff until it succeeds", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, m := newTestContactPointDispatcher(clk, 5)
+		cp := &fakeContactPoint{failures: 3, err: serverError}
+
+		d.dispatch(cp.delivery())
+		clk.Add(0)
+		attempts, delivered := cp.counts()
+		require.Equal(t, 1, attempts)
+		require.Equal(t, 0, delivered)
+
+		// the backoffs are 1s, 2s and 4s
+		for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+			clk.Add(backoff - time.Millisecond)
+			previous, _ := cp.counts()
+			clk.Add(time.Millisecond)
+			attempts, _ = cp.counts()
+			require.Equal(t, previous+1, attempts)
+		}
+
+		attempts, delivered = cp.counts()
+		require.Equal(t, 4, attempts)
+		require.Equal(t, 1, delivered)
+		require.Equal(t, 4.0, testutil.ToFloat64(m.ContactPointDeliveryAttempts.WithLabelValues("webhook")))
+		require.Equal(t, 3.0, testutil.ToFloat64(m.ContactPointDeliveryRetries.WithLabelValues("webhook")))
+		require.Equal(t, 0.0, testutil.ToFloat64(m.ContactPointDeliveryPermanentFailures.WithLabelValues("webhook")))
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, m := newTestContactPointDispatcher(clk, 3)
+		cp := &fakeContactPoint{failures: 10, err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+
+		d.dispatch(cp.delivery())
+		clk.Add(time.Hour)
+		clk.Add(time.Hour)
+
+		attempts, delivered := cp.counts()
+		require.Equal(t, 3, attempts)
+		require.Equal(t, 0, delivered)
+		require.Equal(t, 2.0, testutil.ToFloat64(m.ContactPointDeliveryRetries.WithLabelValues("webhook")))
+		require.Equal(t, 1.0, testutil.ToFloat64(m.ContactPointDeliveryPermanentFailures.WithLabelValues("webhook")))
+	})
+
+	t.Run("applies changed retry settings to the next retry", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, _ := newTestContactPointDispatcher(clk, 2)
+		cp := &fakeContactPoint{failures: 10, err: serverError}
+
+		d.dispatch(cp.delivery())
+		clk.Add(0)
+		d.setRetry(3, 10*time.Second, time.Minute)
+		// the pending retry keeps the delay it was scheduled with
+		clk.Add(time.Second)
+		attempts, _ := cp.counts()
+		require.Equal(t, 2, attempts)
+
+		// the third attempt is allowed now, after the backoff of the new settings
+		clk.Add(20*time.Second - time.Millisecond)
+		attempts, _ = cp.counts()
+		require.Equal(t, 2, attempts)
+		clk.Add(time.Millisecond)
+		attempts, _ = cp.counts()
+		require.Equal(t, 3, attempts)
+	})
+
+	t.Run("does not retry errors that are not temporary", func(t *testing.T) {
+		testCases := map[string]error{
+			"unauthorized": notifications.WebhookResponseError{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized"},
+			"bad request":  notifications.WebhookResponseError{StatusCode: http.StatusBadRequest, Status: "400 Bad Request"},
+			"invalid":      errors.New("invalid settings"),
+		}
+		for name, err := range testCases {
+			t.Run(name, func(t *testing.T) {
+				clk := clock.NewMock()
+				d, m := newTestContactPointDispatcher(clk, 3)
+				cp := &fakeContactPoint{failures: 1, err: err}
+
+				d.dispatch(cp.delivery())
+				clk.Add(time.Hour)
+
+				attempts, delivered := cp.counts()
+				require.Equal(t, 1, attempts)
+				require.Equal(t, 0, delivered)
+				require.Equal(t, 1.0, testutil.ToFloat64(m.ContactPointDeliveryPermanentFailures.WithLabelValues("webhook")))
+			})
+		}
+	})
+
+	t.Run("retries that the integration reports as temporary", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, _ := newTestContactPointDispatcher(clk, 3)
+		cp := &fakeContactPoint{failures: 1, err: retriableError{err: errors.New("unexpected 5xx status code: 502")}}
+
+		d.dispatch(cp.delivery())
+		clk.Add(0)
+		clk.Add(time.Second)
+
+		attempts, delivered := cp.counts()
+		require.Equal(t, 2, attempts)
+		require.Equal(t, 1, delivered)
+	})
+
+	t.Run("waits at least as long as Retry-After of a 429 response", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, _ := newTestContactPointDispatcher(clk, 3)
+		tooManyRequests := notifications.WebhookResponseError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests", RetryAfter: 3 * time.Second}
+		cp := &fakeContactPoint{failures: 1, err: tooManyRequests}
+
+		d.dispatch(cp.delivery())
+		clk.Add(0)
+		clk.Add(3*time.Second - time.Millisecond)
+		attempts, _ := cp.counts()
+		require.Equal(t, 1, attempts)
+
+		clk.Add(time.Millisecond)
+		attempts, delivered := cp.counts()
+		require.Equal(t, 2, attempts)
+		require.Equal(t, 1, delivered)
+	})
+
+	t.Run("gives up if Retry-After is longer than the max backoff", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, m := newTestContactPointDispatcher(clk, 3)
+		tooManyRequests := notifications.WebhookResponseError{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests", RetryAfter: time.Hour}
+		cp := &fakeContactPoint{failures: 1, err: tooManyRequests}
+
+		d.dispatch(cp.delivery())
+		clk.Add(0)
+		require.Equal(t, 0, d.backlog())
+		clk.Add(2 * time.Hour)
+
+		attempts, delivered := cp.counts()
+		require.Equal(t, 1, attempts)
+		require.Equal(t, 0, delivered)
+		require.Equal(t, 1.0, testutil.ToFloat64(m.ContactPointDeliveryPermanentFailures.WithLabelValues("webhook")))
+	})
+
+	t.Run("drops new deliveries when the backlog is full", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, m := newTestContactPointDispatcher(clk, 3)
+		d.maxPending = 2
+		failing, dropped := &fakeContactPoint{failures: 1, err: serverError}, &fakeContactPoint{}
+
+		d.dispatch(failing.delivery())
+		d.dispatch(failing.delivery())
+		d.dispatch(dropped.delivery())
+		require.Equal(t, 2, d.backlog())
+
+		// the retries of the pending deliveries are not dropped
+		clk.Add(0)
+		require.Equal(t, 1, d.backlog())
+		clk.Add(time.Second)
+		require.Equal(t, 0, d.backlog())
+
+		attempts, _ := dropped.counts()
+		require.Equal(t, 0, attempts)
+		_, delivered := failing.counts()
+		require.Equal(t, 2, delivered)
+		require.Equal(t, 1.0, testutil.ToFloat64(m.ContactPointDeliveryPermanentFailures.WithLabelValues("webhook")))
+	})
+
+	t.Run("counts the deliveries that are not delivered yet in the backlog", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, _ := newTestContactPointDispatcher(clk, 3)
+		failing, succeeding := &fakeContactPoint{failures: 1, err: serverError}, &fakeContactPoint{}
+
+		d.dispatch(failing.delivery())
+		d.dispatch(succeeding.delivery())
+		require.Equal(t, 2, d.backlog())
+
+		// the failed delivery waits for its retry
+		clk.Add(0)
+		require.Equal(t, 1, d.backlog())
+
+		clk.Add(time.Second)
+		require.Equal(t, 0, d.backlog())
+	})
+
+	t.Run("stop drops pending retries and new deliveries", func(t *testing.T) {
+		clk := clock.NewMock()
+		d, _ := newTestContactPointDispatcher(clk, 3)
+		cp := &fakeContactPoint{failures: 1, err: serverError}
+
+		d.dispatch(cp.delivery())
+		clk.Add(0)
+		d.stop()
+		d.dispatch(cp.delivery())
+		clk.Add(time.Hour)
+
+		attempts, delivered := cp.counts()
+		require.Equal(t, 1, attempts)
+		require.Equal(t, 0, delivered)
+	})
+
+	t.Run("stop cancels the attempts in progress and waits for them", func(t *testing.T) {
+		d, m := newTestContactPointDispatcher(clock.New(), 3)
+		started := make(chan struct{})
+		var canceled bool
+		d.dispatch(&contactPointDelivery{
+			contactPointUID: "contact-point",
+			integrationType: "slack",
+			send: func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				canceled = true
+				return ctx.Err()
+			},
+		})
+		<-started
+		d.stop()
+
+		require.True(t, canceled)
+		require.Equal(t, 0.0, testutil.ToFloat64(m.ContactPointDeliveryRetries.WithLabelValues("slack")))
+		require.Equal(t, 1.0, testutil.ToFloat64(m.ContactPointDeliveryPermanentFailures.WithLabelValues("slack")))
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	retriable, delay := retryAfter(fmt.Errorf("failed: %w", notifications.WebhookResponseError{StatusCode: http.StatusBadGateway, RetryAfter: time.Minute}))
+	require.True(t, retriable)
+	require.Equal(t, time.Minute, delay)
+
+	retriable, _ = retryAfter(fmt.Errorf("failed: %w", context.Canceled))
+	require.False(t, retriable)
+
+	retriable, _ = retryAfter(&net.DNSError{Err: "no such host", IsTemporary: true})
+	require.True(t, retriable)
+}
@@ -0,0 +1,226 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	alertingModels "github.com/grafana/alerting/models"
+	alertingNotify "github.com/grafana/alerting/notify"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/common/model"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// The rendered notification templates of the rule replace these annotations, which the default templates of the contact
+// points include in the notifications.
+const (
+	summaryAnnotation     = "summary"
+	descriptionAnnotation = "description"
+)
+
+// NotifyContactPoint sends the notification to the contact point of the organization with the given UID. The contact
+// point is looked up synchronously, so that a missing or invalid contact point is returned as an error. The notification
+// is then delivered in the background, and retried if the delivery fails with an error that can be temporary.
+//
+// The notification does not go through the notification policies, and the alerts of the rule are still sent to the
+// Alertmanager. A contact point that the policies route the alerts of the rule to is therefore notified twice, once by
+// the rule and once by the policies.
+func (moa *MultiOrgAlertmanager) NotifyContactPoint(ctx context.Context, orgID int64, contactPointUID string, n ngmodels.AlertingNotification) error {
+	am, err := moa.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+	d, err := am.newContactPointDelivery(contactPointUID, n)
+	if err != nil || d == nil {
+		return err
+	}
+	moa.dispatcher.dispatch(d)
+	return nil
+}
+
+// SendTestNotification sends the notification to the contact point of the organization with the given UID once, without
+// retries, and returns the error of the delivery.
+func (moa *MultiOrgAlertmanager) SendTestNotification(ctx context.Context, orgID int64, contactPointUID string, n ngmodels.AlertingNotification) error {
+	am, err := moa.AlertmanagerFor(orgID)
+	if err != nil {
+		return err
+	}
+	return am.NotifyContactPoint(ctx, contactPointUID, n)
+}
+
+// NotifyContactPoint sends the notification to the contact point with the given UID directly, without routing it through
+// the notification policies. The contact point is looked up in the applied configuration of the Alertmanager. Resolved
+// notifications are not sent to contact points that disable resolve messages. The notification is sent once, without
+// retries.
+func (am *Alertmanager) NotifyContactPoint(ctx context.Context, contactPointUID string, n ngmodels.AlertingNotification) error {
+	d, err := am.newContactPointDelivery(contactPointUID, n)
+	if err != nil || d == nil {
+		return err
+	}
+	return d.send(ctx)
+}
+
+// contactPointDelivery is a notification that is ready to be sent to a contact point.
+type contactPointDelivery struct {
+	contactPointUID string
+	// integrationType is the type of the contact point, such as slack or webhook.
+	integrationType string
+	send            func(ctx context.Context) error
+}
+
+// contactPoints are the Grafana managed contact points of a configuration, with the templates of the configuration. The
+// integrations of the contact points are built, which decrypts their secure settings, when they are first notified, and
+// are reused until another configuration is applied.
+type contactPoints struct {
+	receivers map[string]*apimodels.PostableGrafanaReceiver
+	tmpl      *alertingNotify.Template
+
+	mtx          sync.Mutex
+	integrations map[string]alertingNotify.NotificationChannel
+}
+
+func newContactPoints(apiReceivers []*apimodels.PostableApiReceiver, tmpl *alertingNotify.Template) *contactPoints {
+	cps := &contactPoints{
+		receivers:    map[string]*apimodels.PostableGrafanaReceiver{},
+		tmpl:         tmpl,
+		integrations: map[string]alertingNotify.NotificationChannel{},
+	}
+	for _, r := range apiReceivers {
+		for _, integration := range r.GrafanaManagedReceivers {
+			cps.receivers[integration.UID] = integration
+		}
+	}
+	return cps
+}
+
+// setContactPoints replaces the contact points of the Alertmanager, and drops the integrations built for the previous ones.
+func (am *Alertmanager) setContactPoints(cps *contactPoints) {
+	am.contactPointsMtx.Lock()
+	defer am.contactPointsMtx.Unlock()
+	am.contactPoints = cps
+}
+
+func (am *Alertmanager) getContactPoints() *contactPoints {
+	am.contactPointsMtx.Lock()
+	defer am.contactPointsMtx.Unlock()
+	return am.contactPoints
+}
+
+// integration returns the integration of the contact point, and builds it on first use.
+func (cps *contactPoints) integration(am *Alertmanager, receiver *apimodels.PostableGrafanaReceiver) (alertingNotify.NotificationChannel, error) {
+	cps.mtx.Lock()
+	defer cps.mtx.Unlock()
+	if integration, ok := cps.integrations[receiver.UID]; ok {
+		return integration, nil
+	}
+	integration, err := am.buildReceiverIntegration(receiver, cps.tmpl)
+	if err != nil {
+		return nil, err
+	}
+	cps.integrations[receiver.UID] = integration
+	return integration, nil
+}
+
+// newContactPointDelivery prepares the delivery of the notification to the contact point with the given UID. It returns
+// nil if the notification must not be sent to the contact point.
+func (am *Alertmanager) newContactPointDelivery(contactPointUID string, n ngmodels.AlertingNotification) (*contactPointDelivery, error) {
+	cps := am.getContactPoints()
+	if cps == nil {
+		return nil, fmt.Errorf("contact point %s cannot be notified until the Alertmanager configuration is applied", contactPointUID)
+	}
+	receiver, ok := cps.receivers[contactPointUID]
+	if !ok {
+		return nil, fmt.Errorf("contact point %s does not exist", contactPointUID)
+	}
+	if n.Resolved() && receiver.DisableResolveMessage {
+		return nil, nil
+	}
+	tmpl := cps.tmpl
+
+	d := &contactPointDelivery{contactPointUID: contactPointUID, integrationType: receiver.Type}
+	alert := newContactPointAlert(n, am.Settings.AppURL)
+	// the group key must be unique, as some integrations use it to deduplicate the notifications. The resolved notification
+	// of an alert has the same group key as its alerting notifications.
+	groupKey := fmt.Sprintf("%s-%s-%d", contactPointUID, alert.Labels.Fingerprint(), alert.StartsAt.UnixNano())
+	if isAlertmanagerWebhook(receiver) {
+		d.send = func(ctx context.Context) error {
+			if err := am.notifyAlertmanagerWebhook(ctx, receiver, tmpl, groupKey, n); err != nil {
+				return fmt.Errorf("failed to notify contact point %s: %w", contactPointUID, err)
+			}
+			return nil
+		}
+		return d, nil
+	}
+
+	integration, err := cps.integration(am, receiver)
+	if err != nil {
+		return nil, err
+	}
+	d.send = func(ctx context.Context) error {
+		retry, err := integration.Notify(notify.WithGroupKey(ctx, groupKey), alert)
+		if err != nil {
+			if retry {
+				err = retriableError{err: err}
+			}
+			return fmt.Errorf("failed to notify contact point %s: %w", contactPointUID, err)
+		}
+		return nil
+	}
+	return d, nil
+}
+
+// newContactPointAlert converts the notification to an alert. Like the alerts that are sent to the Alertmanager, the
+// generator URL of the alert points to the view of the rule.
+func newContactPointAlert(n ngmodels.AlertingNotification, appURL string) *types.Alert {
+	labels := make(model.LabelSet, len(n.Labels))
+	for k, v := range n.Labels {
+		labels[model.LabelName(k)] = model.LabelValue(v)
+	}
+	if _, ok := labels[model.AlertNameLabel]; !ok {
+		labels[model.AlertNameLabel] = model.LabelValue(n.RuleTitle)
+	}
+	if _, ok := labels[alertingModels.RuleUIDLabel]; !ok {
+		labels[alertingModels.RuleUIDLabel] = model.LabelValue(n.RuleUID)
+	}
+
+	annotations := make(model.LabelSet, len(n.Annotations)+4)
+	for k, v := range n.Annotations {
+		annotations[model.LabelName(k)] = model.LabelValue(v)
+	}
+	if n.Value != "" {
+		annotations[alertingModels.ValueStringAnnotation] = model.LabelValue(n.Value)
+	}
+	if n.RunbookURL != "" {
+		annotations[ngmodels.RunbookURLAnnotation] = model.LabelValue(n.RunbookURL)
+	}
+	if n.Title != "" {
+		annotations[summaryAnnotation] = model.LabelValue(n.Title)
+	}
+	if n.Message != "" {
+		annotations[descriptionAnnotation] = model.LabelValue(n.Message)
+	}
+
+	var generatorURL string
+	if u, err := url.Parse(appURL); err == nil && appURL != "" {
+		u.Path = path.Join(u.Path, fmt.Sprintf("/alerting/grafana/%s/view", n.RuleUID))
+		generatorURL = u.String()
+	}
+
+	return &types.Alert{
+		Alert: model.Alert{
+			Labels:       labels,
+			Annotations:  annotations,
+			StartsAt:     n.StartsAt,
+			EndsAt:       n.EndsAt,
+			GeneratorURL: generatorURL,
+		},
+		UpdatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,148 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/notifications"
+)
+
+func TestAlertmanager_NotifyContactPoint(t *testing.T) {
+	am := setupAMTest(t)
+	ns := notifications.MockNotificationService()
+	am.NotificationService = ns
+	am.Settings.AppURL = "http://localhost:3000/"
+	ctx := context.Background()
+
+	config := `{
+		"alertmanager_config": {
+			"route": {"receiver": "team"},
+			"receivers": [{
+				"name": "team",
+				"grafana_managed_receiver_configs": [
+					{"uid": "hook", "name": "team", "type": "webhook", "settings": {"url": "http://localhost/hook"}},
+					{"uid": "am-hook", "name": "team", "type": "webhook", "settings": {"url": "http://localhost/am-hook", "username": "user", "payloadFormat": "alertmanager"}},
+					{"uid": "quiet-hook", "name": "team", "type": "webhook", "disableResolveMessage": true, "settings": {"url": "http://localhost/quiet-hook"}}
+				]
+			}]
+		}
+	}`
+	cfg, err := Load([]byte(config))
+	require.NoError(t, err)
+	require.NoError(t, am.SaveAndApplyConfig(ctx, cfg))
+
+	n := ngmodels.AlertingNotification{
+		OrgID:       am.orgID,
+		RuleUID:     "rule-uid",
+		RuleTitle:   "High CPU",
+		Labels:      map[string]string{"instance": "server-1"},
+		Annotations: map[string]string{"summary": "CPU is high"},
+		Value:       "[ var='A' value=95 ]",
+		RunbookURL:  "https://example.com/runbook",
+		StartsAt:    time.Now(),
+	}
+
+	t.Run("sends the notification to the contact point", func(t *testing.T) {
+		require.NoError(t, am.NotifyContactPoint(ctx, "hook", n))
+
+		require.Equal(t, "http://localhost/hook", ns.Webhook.Url)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(ns.Webhook.Body), &body))
+		require.Equal(t, "firing", body["status"])
+		alerts := body["alerts"].([]interface{})
+		require.Len(t, alerts, 1)
+		alert := alerts[0].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{
+			"alertname": "High CPU",
+			"instance":  "server-1",
+		}, alert["labels"])
+		require.Equal(t, map[string]interface{}{
+			"summary":     "CPU is high",
+			"runbook_url": "https://example.com/runbook",
+		}, alert["annotations"])
+		require.Equal(t, "[ var='A' value=95 ]", alert["valueString"])
+		require.Equal(t, "http://localhost:3000/alerting/grafana/rule-uid/view", alert["generatorURL"])
+	})
+
+	t.Run("sends the rendered templates as summary and description", func(t *testing.T) {
+		withTemplates := n
+		withTemplates.Title = "High CPU on server-1"
+		withTemplates.Message = "CPU is at 95%"
+		require.NoError(t, am.NotifyContactPoint(ctx, "hook", withTemplates))
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(ns.Webhook.Body), &body))
+		alert := body["alerts"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, map[string]interface{}{
+			"summary":     "High CPU on server-1",
+			"description": "CPU is at 95%",
+			"runbook_url": "https://example.com/runbook",
+		}, alert["annotations"])
+	})
+
+	t.Run("sends the notification in the format of the Alertmanager", func(t *testing.T) {
+		resolved := n
+		resolved.StartsAt = n.StartsAt.Add(-time.Hour)
+		resolved.EndsAt = n.StartsAt
+		require.NoError(t, am.NotifyContactPoint(ctx, "am-hook", resolved))
+
+		require.Equal(t, "http://localhost/am-hook", ns.Webhook.Url)
+		require.Equal(t, "user", ns.Webhook.User)
+		require.Equal(t, "POST", ns.Webhook.HttpMethod)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(ns.Webhook.Body), &body))
+		require.Equal(t, "4", body["version"])
+		require.Equal(t, "resolved", body["status"])
+		require.Equal(t, "team", body["receiver"])
+		require.NotEmpty(t, body["groupKey"])
+		alert := body["alerts"].([]interface{})[0].(map[string]interface{})
+		require.Equal(t, "resolved", alert["status"])
+		require.Equal(t, "http://localhost:3000/alerting/grafana/rule-uid/view", alert["generatorURL"])
+	})
+
+	t.Run("does not send resolved notifications if the contact point disables them", func(t *testing.T) {
+		ns.Webhook = notifications.SendWebhookSync{}
+		resolved := n
+		resolved.StartsAt = n.StartsAt.Add(-time.Hour)
+		resolved.EndsAt = n.StartsAt
+		require.NoError(t, am.NotifyContactPoint(ctx, "quiet-hook", resolved))
+		require.Empty(t, ns.Webhook.Url)
+
+		require.NoError(t, am.NotifyContactPoint(ctx, "quiet-hook", n))
+		require.Equal(t, "http://localhost/quiet-hook", ns.Webhook.Url)
+	})
+
+	t.Run("fails if the contact point does not exist", func(t *testing.T) {
+		err := am.NotifyContactPoint(ctx, "missing", n)
+		require.ErrorContains(t, err, "contact point missing does not exist")
+	})
+
+	t.Run("reuses the integration of the contact point until another configuration is applied", func(t *testing.T) {
+		require.NoError(t, am.NotifyContactPoint(ctx, "hook", n))
+		cps := am.getContactPoints()
+		integration := cps.integrations["hook"]
+		require.NotNil(t, integration)
+		require.NoError(t, am.NotifyContactPoint(ctx, "hook", n))
+		require.Same(t, cps, am.getContactPoints())
+		require.Equal(t, integration, cps.integrations["hook"])
+
+		changed, err := Load([]byte(strings.ReplaceAll(config, "http://localhost/hook", "http://localhost/changed-hook")))
+		require.NoError(t, err)
+		require.NoError(t, am.SaveAndApplyConfig(ctx, changed))
+		require.NotSame(t, cps, am.getContactPoints())
+		require.NoError(t, am.NotifyContactPoint(ctx, "hook", n))
+		require.Equal(t, "http://localhost/changed-hook", ns.Webhook.Url)
+	})
+}
+
+func TestAlertmanager_NotifyContactPoint_NotApplied(t *testing.T) {
+	am := setupAMTest(t)
+	err := am.NotifyContactPoint(context.Background(), "hook", ngmodels.AlertingNotification{StartsAt: time.Now()})
+	require.ErrorContains(t, err, "cannot be notified until the Alertmanager configuration is applied")
+}
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/prometheus/alertmanager/cluster"
 	"github.com/prometheus/client_golang/prometheus"
 
@@ -53,6 +54,8 @@ type MultiOrgAlertmanager struct {
 
 	metrics *metrics.MultiOrgAlertmanager
 	ns      notifications.Service
+
+	dispatcher *contactPointDispatcher
 }
 
 func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgStore store.OrgStore,
@@ -73,6 +76,8 @@ func NewMultiOrgAlertmanager(cfg *setting.Cfg, configStore AlertingStore, orgSto
 		metrics:       m,
 		ns:            ns,
 	}
+	moa.dispatcher = newContactPointDispatcher(clock.New(), l.New("component", "contact-point-dispatcher"), m,
+		cfg.UnifiedAlerting.NotificationMaxAttempts, cfg.UnifiedAlerting.NotificationRetryInitialBackoff, cfg.UnifiedAlerting.NotificationRetryMaxBackoff)
 
 	clusterLogger := l.New("component", "cluster")
 	moa.peer = &NilPeer{}
@@ -128,6 +133,17 @@ func (moa *MultiOrgAlertmanager) Run(ctx context.Context) error {
 	}
 }
 
+// NotificationBacklog returns the number of notifications to contact points that are not delivered yet, including the
+// ones that wait to be retried.
+func (moa *MultiOrgAlertmanager) NotificationBacklog() int {
+	return moa.dispatcher.backlog()
+}
+
+// SetNotificationRetry changes how notifications of alert rules to contact points are retried when they fail.
+func (moa *MultiOrgAlertmanager) SetNotificationRetry(maxAttempts int, initialBackoff, maxBackoff time.Duration) {
+	moa.dispatcher.setRetry(maxAttempts, initialBackoff, maxBackoff)
+}
+
 func (moa *MultiOrgAlertmanager) LoadAndSyncAlertmanagersForOrgs(ctx context.Context) error {
 	moa.logger.Debug("synchronizing Alertmanagers for orgs")
 	// First, load all the organizations from the database.
@@ -294,6 +310,8 @@ func (moa *MultiOrgAlertmanager) cleanupOrphanLocalOrgState(ctx context.Context,
 }
 
 func (moa *MultiOrgAlertmanager) StopAndWait() {
+	moa.dispatcher.stop()
+
 	moa.alertmanagersMtx.Lock()
 	defer moa.alertmanagersMtx.Unlock()
 
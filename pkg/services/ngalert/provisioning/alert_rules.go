@@ -7,8 +7,11 @@ import (
 	"sort"
 	"time"
 
+	"github.com/benbjohnson/clock"
+
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/provisioning/alerting/file"
@@ -24,6 +27,7 @@ type AlertRuleService struct {
 	dashboardService       dashboards.DashboardService
 	quotas                 QuotaChecker
 	xact                   TransactionManager
+	clock                  clock.Clock
 	log                    log.Logger
 }
 
@@ -43,6 +47,7 @@ func NewAlertRuleService(ruleStore RuleStore,
 		dashboardService:       dashboardService,
 		quotas:                 quotas,
 		xact:                   xact,
+		clock:                  clock.New(),
 		log:                    log,
 	}
 }
@@ -59,6 +64,24 @@ func (service *AlertRuleService) GetAlertRules(ctx context.Context, orgID int64)
 	return rules, nil
 }
 
+// GetAlertRuleSummaries returns the summaries of the alert rules of the organization, and the number of alert instances
+// per state of the rules by rule UID. The queries and expressions of the rules are not read.
+func (service *AlertRuleService) GetAlertRuleSummaries(ctx context.Context, orgID int64) ([]*models.AlertRuleSummary, map[string]*models.AlertInstanceStateSummary, error) {
+	rules, err := service.ruleStore.ListAlertRuleSummaries(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+	if err != nil {
+		return nil, nil, err
+	}
+	summaries, err := service.ruleStore.GetAlertInstanceStateSummaries(ctx, &models.GetAlertInstanceStateSummariesQuery{OrgID: orgID})
+	if err != nil {
+		return nil, nil, err
+	}
+	states := make(map[string]*models.AlertInstanceStateSummary, len(summaries))
+	for _, summary := range summaries {
+		states[summary.RuleUID] = summary
+	}
+	return rules, states, nil
+}
+
 func (service *AlertRuleService) GetAlertRule(ctx context.Context, orgID int64, ruleUID string) (models.AlertRule, models.Provenance, error) {
 	query := &models.GetAlertRuleByUIDQuery{
 		OrgID: orgID,
@@ -81,6 +104,30 @@ type AlertRuleWithFolderTitle struct {
 }
 
 // GetAlertRuleWithFolderTitle returns a single alert rule with its folder title.
+// GetProvisionedAlertRules returns the alert rules of all organizations that have the given provenance.
+func (service *AlertRuleService) GetProvisionedAlertRules(ctx context.Context, provenance models.Provenance) ([]*models.AlertRule, error) {
+	rules, err := service.ruleStore.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: -1})
+	if err != nil {
+		return nil, err
+	}
+	provenances := make(map[int64]map[string]models.Provenance)
+	result := make([]*models.AlertRule, 0)
+	for _, rule := range rules {
+		orgProvenances, ok := provenances[rule.OrgID]
+		if !ok {
+			orgProvenances, err = service.provenanceStore.GetProvenances(ctx, rule.OrgID, rule.ResourceType())
+			if err != nil {
+				return nil, err
+			}
+			provenances[rule.OrgID] = orgProvenances
+		}
+		if orgProvenances[rule.UID] == provenance {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
 func (service *AlertRuleService) GetAlertRuleWithFolderTitle(ctx context.Context, orgID int64, ruleUID string) (AlertRuleWithFolderTitle, error) {
 	query := &models.GetAlertRuleByUIDQuery{
 		OrgID: orgID,
@@ -127,7 +174,12 @@ func (service *AlertRuleService) CreateAlertRule(ctx context.Context, rule model
 		return models.AlertRule{}, err
 	}
 	rule.Updated = time.Now()
+	rule.CreatedBy = userID
 	err = service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		if err := service.checkLimitsTransactionCtx(ctx, rule.OrgID, userID, rule.Title); err != nil {
+			return err
+		}
+
 		ids, err := service.ruleStore.InsertAlertRules(ctx, []models.AlertRule{
 			rule,
 		})
@@ -140,16 +192,74 @@ func (service *AlertRuleService) CreateAlertRule(ctx context.Context, rule model
 			return errors.New("couldn't find newly created id")
 		}
 
-		if err = service.checkLimitsTransactionCtx(ctx, rule.OrgID, userID); err != nil {
+		return service.provenanceStore.SetProvenance(ctx, &rule, rule.OrgID, provenance)
+	})
+	if err != nil {
+		return models.AlertRule{}, WithQuotaUsage(ctx, service.quotas, rule.OrgID, err)
+	}
+	return rule, nil
+}
+
+// CreateAlertRuleIdempotently creates a new alert rule like CreateAlertRule, and records its UID under the given idempotency
+// key and the hash of the request. If the key was recorded for the same request less than models.IdempotencyKeyTTL ago, no
+// rule is created, and the rule created by that request is returned with true. If the key was recorded for a different
+// request, models.ErrIdempotencyKeyReused is returned.
+func (service *AlertRuleService) CreateAlertRuleIdempotently(ctx context.Context, rule models.AlertRule, provenance models.Provenance, userID int64, key, requestHash string) (models.AlertRule, bool, error) {
+	var result models.AlertRule
+	replayed := false
+	err := service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		existing, err := service.ruleStore.GetIdempotencyKey(ctx, rule.OrgID, key)
+		if err != nil && !errors.Is(err, models.ErrIdempotencyKeyNotFound) {
+			return err
+		}
+		if err == nil && !existing.IsExpired(service.clock.Now()) {
+			result, err = service.replayIdempotencyKey(ctx, existing, requestHash)
+			replayed = err == nil
 			return err
 		}
 
-		return service.provenanceStore.SetProvenance(ctx, &rule, rule.OrgID, provenance)
+		result, err = service.CreateAlertRule(ctx, rule, provenance, userID)
+		if err != nil {
+			return err
+		}
+		return service.ruleStore.SaveIdempotencyKey(ctx, &models.IdempotencyKey{
+			OrgID:       rule.OrgID,
+			Key:         key,
+			RequestHash: requestHash,
+			RuleUID:     result.UID,
+			Created:     service.clock.Now(),
+		})
 	})
+	if errors.Is(err, models.ErrIdempotencyKeyConflict) {
+		// A concurrent request with the same key saved it first, and the rule created by this one was rolled back.
+		// Answer like the retry of that request would.
+		existing, getErr := service.ruleStore.GetIdempotencyKey(ctx, rule.OrgID, key)
+		if getErr != nil {
+			if errors.Is(getErr, models.ErrIdempotencyKeyNotFound) {
+				return models.AlertRule{}, false, err
+			}
+			return models.AlertRule{}, false, getErr
+		}
+		result, err = service.replayIdempotencyKey(ctx, existing, requestHash)
+		replayed = err == nil
+	}
+	if err != nil {
+		return models.AlertRule{}, false, err
+	}
+	return result, replayed, nil
+}
+
+// replayIdempotencyKey returns the alert rule that was created by the request of the idempotency key, or
+// models.ErrIdempotencyKeyReused if that request is not the one with the given hash.
+func (service *AlertRuleService) replayIdempotencyKey(ctx context.Context, existing *models.IdempotencyKey, requestHash string) (models.AlertRule, error) {
+	if existing.RequestHash != requestHash {
+		return models.AlertRule{}, models.ErrIdempotencyKeyReused
+	}
+	created, err := service.ruleStore.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: existing.OrgID, UID: existing.RuleUID})
 	if err != nil {
 		return models.AlertRule{}, err
 	}
-	return rule, nil
+	return *created, nil
 }
 
 func (service *AlertRuleService) GetRuleGroup(ctx context.Context, orgID int64, namespaceUID, group string) (models.AlertRuleGroup, error) {
@@ -260,15 +370,42 @@ func (service *AlertRuleService) ReplaceRuleGroup(ctx context.Context, orgID int
 		return nil
 	}
 
-	return service.xact.InTransaction(ctx, func(ctx context.Context) error {
-		uids, err := service.ruleStore.InsertAlertRules(ctx, withoutNilAlertRules(delta.New))
-		if err != nil {
-			return fmt.Errorf("failed to insert alert rules: %w", err)
+	for _, rule := range delta.New {
+		if rule != nil {
+			rule.CreatedBy = userID
+		}
+	}
+
+	err = service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		for _, delete := range delta.Delete {
+			// check that provenance is not changed in a invalid way
+			storedProvenance, err := service.provenanceStore.GetProvenance(ctx, delete, orgID)
+			if err != nil {
+				return err
+			}
+			if storedProvenance != provenance && storedProvenance != models.ProvenanceNone {
+				return fmt.Errorf("cannot update with provided provenance '%s', needs '%s'", provenance, storedProvenance)
+			}
+		}
+		// the rules are deleted first, so that they do not count against the quota of the new ones
+		if err := service.deleteRules(ctx, orgID, delta.Delete...); err != nil {
+			return err
 		}
-		for uid := range uids {
-			if err := service.provenanceStore.SetProvenance(ctx, &models.AlertRule{UID: uid}, orgID, provenance); err != nil {
+
+		// the new rules are inserted one by one, so that the rule that reaches the quota is known
+		for _, rule := range withoutNilAlertRules(delta.New) {
+			if err := service.checkLimitsTransactionCtx(ctx, orgID, userID, rule.Title); err != nil {
 				return err
 			}
+			uids, err := service.ruleStore.InsertAlertRules(ctx, []models.AlertRule{rule})
+			if err != nil {
+				return fmt.Errorf("failed to insert alert rules: %w", err)
+			}
+			for uid := range uids {
+				if err := service.provenanceStore.SetProvenance(ctx, &models.AlertRule{UID: uid}, orgID, provenance); err != nil {
+					return err
+				}
+			}
 		}
 
 		updates := make([]models.UpdateRule, 0, len(delta.Update))
@@ -295,36 +432,24 @@ func (service *AlertRuleService) ReplaceRuleGroup(ctx context.Context, orgID int
 			}
 		}
 
-		for _, delete := range delta.Delete {
-			// check that provenance is not changed in a invalid way
-			storedProvenance, err := service.provenanceStore.GetProvenance(ctx, delete, orgID)
-			if err != nil {
-				return err
-			}
-			if storedProvenance != provenance && storedProvenance != models.ProvenanceNone {
-				return fmt.Errorf("cannot update with provided provenance '%s', needs '%s'", provenance, storedProvenance)
-			}
-		}
-		if err := service.deleteRules(ctx, orgID, delta.Delete...); err != nil {
-			return err
-		}
-
-		if err = service.checkLimitsTransactionCtx(ctx, orgID, userID); err != nil {
-			return err
-		}
-
 		return nil
 	})
+	return WithQuotaUsage(ctx, service.quotas, orgID, err)
 }
 
 // CreateAlertRule creates a new alert rule. This function will ignore any
 // interval that is set in the rule struct and fetch the current group interval
 // from database.
+// UpdateAlertRule replaces the stored alert rule with the same UID. If the version of the rule is set, the stored rule is
+// replaced only if it has the same version, otherwise store.ErrOptimisticLock is returned.
 func (service *AlertRuleService) UpdateAlertRule(ctx context.Context, rule models.AlertRule, provenance models.Provenance) (models.AlertRule, error) {
 	storedRule, storedProvenance, err := service.GetAlertRule(ctx, rule.OrgID, rule.UID)
 	if err != nil {
 		return models.AlertRule{}, err
 	}
+	if rule.Version != 0 && rule.Version != storedRule.Version {
+		return models.AlertRule{}, fmt.Errorf("%w: alert rule UID %s version %d", store.ErrOptimisticLock, rule.UID, rule.Version)
+	}
 	if storedProvenance != provenance && storedProvenance != models.ProvenanceNone {
 		return models.AlertRule{}, fmt.Errorf("cannot changed provenance from '%s' to '%s'", storedProvenance, provenance)
 	}
@@ -350,6 +475,8 @@ func (service *AlertRuleService) UpdateAlertRule(ctx context.Context, rule model
 	if err != nil {
 		return models.AlertRule{}, err
 	}
+	// the version is increased by the store
+	rule.Version = storedRule.Version + 1
 	return rule, err
 }
 
@@ -371,8 +498,9 @@ func (service *AlertRuleService) DeleteAlertRule(ctx context.Context, orgID int6
 	})
 }
 
-// checkLimitsTransactionCtx checks whether the current transaction (as identified by the ctx) breaches configured alert rule limits.
-func (service *AlertRuleService) checkLimitsTransactionCtx(ctx context.Context, orgID, userID int64) error {
+// checkLimitsTransactionCtx checks whether the current transaction (as identified by the ctx) allows creating the rule with
+// the given title without breaching the configured alert rule limits. It must be called before the rule is inserted.
+func (service *AlertRuleService) checkLimitsTransactionCtx(ctx context.Context, orgID, userID int64, ruleTitle string) error {
 	limitReached, err := service.quotas.CheckQuotaReached(ctx, models.QuotaTargetSrv, &quota.ScopeParameters{
 		OrgID:  orgID,
 		UserID: userID,
@@ -381,11 +509,44 @@ func (service *AlertRuleService) checkLimitsTransactionCtx(ctx context.Context,
 		return fmt.Errorf("failed to check alert rule quota: %w", err)
 	}
 	if limitReached {
-		return models.ErrQuotaReached
+		return &models.QuotaReachedError{RuleTitle: ruleTitle}
 	}
 	return nil
 }
 
+// WithQuotaUsage sets the limit and the usage of the reached alert rule quota in err if it is a models.QuotaReachedError,
+// and returns err. The quota with the fewest remaining rules is reported, the organization one if both are equal. This
+// must be called once the transaction that returned err is rolled back, so the usage is the number of stored rules.
+func WithQuotaUsage(ctx context.Context, quotas QuotaChecker, orgID int64, err error) error {
+	var quotaErr *models.QuotaReachedError
+	if !errors.As(err, &quotaErr) {
+		return err
+	}
+	scopes := []struct {
+		scope quota.Scope
+		id    int64
+	}{{quota.OrgScope, orgID}, {quota.GlobalScope, 0}}
+	var reached *quota.QuotaDTO
+	for _, s := range scopes {
+		dtos, qerr := quotas.GetQuotasByScope(ctx, s.scope, s.id)
+		if qerr != nil {
+			return err
+		}
+		for i, q := range dtos {
+			if q.Target != string(models.QuotaTarget) || q.Limit < 0 {
+				continue
+			}
+			if reached == nil || q.Limit-q.Used < reached.Limit-reached.Used {
+				reached = &dtos[i]
+			}
+		}
+	}
+	if reached != nil {
+		quotaErr.Limit, quotaErr.Usage = reached.Limit, reached.Used
+	}
+	return err
+}
+
 // deleteRules deletes a set of target rules and associated data, while checking for database consistency.
 func (service *AlertRuleService) deleteRules(ctx context.Context, orgID int64, targets ...*models.AlertRule) error {
 	uids := make([]string, 0, len(targets))
@@ -516,6 +677,16 @@ func (service *AlertRuleService) GetAlertGroupsWithFolderTitle(ctx context.Conte
 	return result, nil
 }
 
+// GetPrometheusRulesExport returns the alert rules of the organization as Prometheus rule groups, keyed by the title of
+// their folder. The folder UID and the group are optional and restrict the export to the rules of a folder or a group.
+func (service *AlertRuleService) GetPrometheusRulesExport(ctx context.Context, orgID int64, folderUID, group string) (definitions.PrometheusRulesExport, error) {
+	return service.ruleStore.ExportPrometheusRules(ctx, &models.ExportPrometheusRulesQuery{
+		OrgID:        orgID,
+		NamespaceUID: folderUID,
+		RuleGroup:    group,
+	})
+}
+
 // syncRuleGroupFields synchronizes calculated fields across multiple rules in a group.
 func syncGroupRuleFields(group *models.AlertRuleGroup, orgID int64) *models.AlertRuleGroup {
 	for i := range group.Rules {
@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/stretchr/testify/require"
 
@@ -166,6 +167,25 @@ func TestAlertRuleService(t *testing.T) {
 		require.Equal(t, int64(2), readGroup.Rules[0].Version)
 	})
 
+	t.Run("group creation should record the creator and updates should keep it", func(t *testing.T) {
+		var orgID int64 = 1
+		group := createDummyGroup("group-test-created-by", orgID)
+		err := ruleService.ReplaceRuleGroup(context.Background(), orgID, group, 42, models.ProvenanceAPI)
+		require.NoError(t, err)
+		createdGroup, err := ruleService.GetRuleGroup(context.Background(), orgID, "my-namespace", "group-test-created-by")
+		require.NoError(t, err)
+		require.Equal(t, int64(42), createdGroup.Rules[0].CreatedBy)
+
+		createdGroup.Rules[0].Title = "created-by-new-title"
+		err = ruleService.ReplaceRuleGroup(context.Background(), orgID, createdGroup, 7, models.ProvenanceAPI)
+		require.NoError(t, err)
+
+		readGroup, err := ruleService.GetRuleGroup(context.Background(), orgID, "my-namespace", "group-test-created-by")
+		require.NoError(t, err)
+		require.Equal(t, "created-by-new-title", readGroup.Rules[0].Title)
+		require.Equal(t, int64(42), readGroup.Rules[0].CreatedBy)
+	})
+
 	t.Run("updating a group by updating a rule should not remove dashboard and panel ids", func(t *testing.T) {
 		var orgID int64 = 1
 		dashboardUid := "huYnkl7H"
@@ -206,6 +226,12 @@ func TestAlertRuleService(t *testing.T) {
 				to:     models.ProvenanceFile,
 				errNil: true,
 			},
+			{
+				name:   "should be able to update from provenance file to file",
+				from:   models.ProvenanceFile,
+				to:     models.ProvenanceFile,
+				errNil: true,
+			},
 			{
 				name:   "should not be able to update from provenance api to file",
 				from:   models.ProvenanceAPI,
@@ -267,6 +293,12 @@ func TestAlertRuleService(t *testing.T) {
 				to:     models.ProvenanceFile,
 				errNil: true,
 			},
+			{
+				name:   "should be able to update from provenance file to file",
+				from:   models.ProvenanceFile,
+				to:     models.ProvenanceFile,
+				errNil: true,
+			},
 			{
 				name:   "should not be able to update from provenance api to file",
 				from:   models.ProvenanceAPI,
@@ -319,6 +351,9 @@ func TestAlertRuleService(t *testing.T) {
 		_, err := ruleService.CreateAlertRule(context.Background(), dummyRule("test#1", 1), models.ProvenanceNone, 0)
 
 		require.ErrorIs(t, err, models.ErrQuotaReached)
+		var quotaErr *models.QuotaReachedError
+		require.ErrorAs(t, err, &quotaErr)
+		require.Equal(t, "test#1", quotaErr.RuleTitle)
 	})
 
 	t.Run("quota met causes group write to be rejected", func(t *testing.T) {
@@ -334,6 +369,76 @@ func TestAlertRuleService(t *testing.T) {
 	})
 }
 
+func TestCreateAlertRuleIdempotently(t *testing.T) {
+	t.Run("should create another rule once the key is expired", func(t *testing.T) {
+		ruleService := createAlertRuleService(t)
+		mockClock := clock.NewMock()
+		mockClock.Set(time.Now())
+		ruleService.clock = mockClock
+
+		first, replayed, err := ruleService.CreateAlertRuleIdempotently(context.Background(), dummyRule("test#1", 1), models.ProvenanceAPI, 0, "key", "hash")
+		require.NoError(t, err)
+		require.False(t, replayed)
+
+		mockClock.Add(models.IdempotencyKeyTTL - time.Second)
+		again, replayed, err := ruleService.CreateAlertRuleIdempotently(context.Background(), dummyRule("test#1", 1), models.ProvenanceAPI, 0, "key", "hash")
+		require.NoError(t, err)
+		require.True(t, replayed)
+		require.Equal(t, first.UID, again.UID)
+
+		mockClock.Add(time.Second)
+		second, replayed, err := ruleService.CreateAlertRuleIdempotently(context.Background(), dummyRule("test#2", 1), models.ProvenanceAPI, 0, "key", "other-hash")
+		require.NoError(t, err)
+		require.False(t, replayed)
+		require.NotEqual(t, first.UID, second.UID)
+	})
+
+	t.Run("should replay the rule of a concurrent request that saved the key first", func(t *testing.T) {
+		ruleService := createAlertRuleService(t)
+		first, _, err := ruleService.CreateAlertRuleIdempotently(context.Background(), dummyRule("test#1", 1), models.ProvenanceAPI, 0, "key", "hash")
+		require.NoError(t, err)
+		ruleService.ruleStore = &concurrentIdempotencyKeyStore{RuleStore: ruleService.ruleStore}
+
+		replayed, ok, err := ruleService.CreateAlertRuleIdempotently(context.Background(), dummyRule("test#2", 1), models.ProvenanceAPI, 0, "key", "hash")
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, first.UID, replayed.UID)
+
+		rules, err := ruleService.GetAlertRules(context.Background(), 1)
+		require.NoError(t, err)
+		require.Len(t, rules, 1, "the rule created by the request that lost the race should be rolled back")
+	})
+
+	t.Run("should return ErrIdempotencyKeyReused if a concurrent request with a different body saved the key first", func(t *testing.T) {
+		ruleService := createAlertRuleService(t)
+		_, _, err := ruleService.CreateAlertRuleIdempotently(context.Background(), dummyRule("test#1", 1), models.ProvenanceAPI, 0, "key", "hash")
+		require.NoError(t, err)
+		ruleService.ruleStore = &concurrentIdempotencyKeyStore{RuleStore: ruleService.ruleStore}
+
+		_, _, err = ruleService.CreateAlertRuleIdempotently(context.Background(), dummyRule("test#2", 1), models.ProvenanceAPI, 0, "key", "other-hash")
+		require.ErrorIs(t, err, models.ErrIdempotencyKeyReused)
+	})
+}
+
+// concurrentIdempotencyKeyStore behaves as if a concurrent request saved the idempotency key after the key was read in
+// the transaction of the request and before it was saved.
+type concurrentIdempotencyKeyStore struct {
+	RuleStore
+	reads int
+}
+
+func (s *concurrentIdempotencyKeyStore) GetIdempotencyKey(ctx context.Context, orgID int64, key string) (*models.IdempotencyKey, error) {
+	s.reads++
+	if s.reads == 1 {
+		return nil, models.ErrIdempotencyKeyNotFound
+	}
+	return s.RuleStore.GetIdempotencyKey(ctx, orgID, key)
+}
+
+func (s *concurrentIdempotencyKeyStore) SaveIdempotencyKey(context.Context, *models.IdempotencyKey) error {
+	return models.ErrIdempotencyKeyConflict
+}
+
 func createAlertRuleService(t *testing.T) AlertRuleService {
 	t.Helper()
 	sqlStore := db.InitTestDB(t)
@@ -351,6 +456,7 @@ func createAlertRuleService(t *testing.T) AlertRuleService {
 		provenanceStore:        store,
 		quotas:                 &quotas,
 		xact:                   sqlStore,
+		clock:                  clock.New(),
 		log:                    log.New("testing"),
 		baseIntervalSeconds:    10,
 		defaultIntervalSeconds: 60,
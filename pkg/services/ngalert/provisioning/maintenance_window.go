@@ -0,0 +1,93 @@
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// MaintenanceWindowStore is the storage of maintenance windows.
+type MaintenanceWindowStore interface {
+	GetMaintenanceWindows(ctx context.Context, orgID int64) ([]*models.MaintenanceWindow, error)
+	GetMaintenanceWindow(ctx context.Context, orgID, id int64) (*models.MaintenanceWindow, error)
+	InsertMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error
+	UpdateMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error
+	DeleteMaintenanceWindow(ctx context.Context, orgID, id int64) error
+}
+
+type MaintenanceWindowService struct {
+	store MaintenanceWindowStore
+	now   func() time.Time
+	log   log.Logger
+}
+
+func NewMaintenanceWindowService(store MaintenanceWindowStore, log log.Logger) *MaintenanceWindowService {
+	return &MaintenanceWindowService{
+		store: store,
+		now:   time.Now,
+		log:   log,
+	}
+}
+
+// GetMaintenanceWindows returns all maintenance windows within the specified org.
+func (svc *MaintenanceWindowService) GetMaintenanceWindows(ctx context.Context, orgID int64) ([]*models.MaintenanceWindow, error) {
+	return svc.store.GetMaintenanceWindows(ctx, orgID)
+}
+
+// GetMaintenanceWindow returns the maintenance window with the given ID within the specified org, or ErrNotFound.
+func (svc *MaintenanceWindowService) GetMaintenanceWindow(ctx context.Context, orgID, id int64) (*models.MaintenanceWindow, error) {
+	window, err := svc.store.GetMaintenanceWindow(ctx, orgID, id)
+	if errors.Is(err, models.ErrMaintenanceWindowNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, err.Error())
+	}
+	return window, err
+}
+
+// CreateMaintenanceWindow adds a new maintenance window created by the given user within the specified org. Windows that
+// do not repeat cannot end in the past. The created window is returned.
+func (svc *MaintenanceWindowService) CreateMaintenanceWindow(ctx context.Context, window models.MaintenanceWindow, orgID int64, userID int64) (*models.MaintenanceWindow, error) {
+	now := svc.now()
+	if err := window.Validate(now); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+	window.ID = 0
+	window.OrgID = orgID
+	window.CreatedBy = userID
+	window.Created = now
+	window.Updated = now
+	if err := svc.store.InsertMaintenanceWindow(ctx, &window); err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// UpdateMaintenanceWindow replaces the maintenance window with the ID of the given one within the specified org. The updated
+// window is returned.
+func (svc *MaintenanceWindowService) UpdateMaintenanceWindow(ctx context.Context, window models.MaintenanceWindow, orgID int64) (*models.MaintenanceWindow, error) {
+	now := svc.now()
+	if err := window.Validate(now); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+	window.OrgID = orgID
+	window.Updated = now
+	if err := svc.store.UpdateMaintenanceWindow(ctx, &window); err != nil {
+		if errors.Is(err, models.ErrMaintenanceWindowNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, err.Error())
+		}
+		return nil, err
+	}
+	return svc.store.GetMaintenanceWindow(ctx, orgID, window.ID)
+}
+
+// DeleteMaintenanceWindow deletes the maintenance window with the given ID within the specified org.
+func (svc *MaintenanceWindowService) DeleteMaintenanceWindow(ctx context.Context, orgID, id int64) error {
+	err := svc.store.DeleteMaintenanceWindow(ctx, orgID, id)
+	if errors.Is(err, models.ErrMaintenanceWindowNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err.Error())
+	}
+	return err
+}
@@ -37,11 +37,16 @@ type TransactionManager interface {
 type RuleStore interface {
 	GetAlertRuleByUID(ctx context.Context, query *models.GetAlertRuleByUIDQuery) (*models.AlertRule, error)
 	ListAlertRules(ctx context.Context, query *models.ListAlertRulesQuery) (models.RulesGroup, error)
+	ListAlertRuleSummaries(ctx context.Context, query *models.ListAlertRulesQuery) ([]*models.AlertRuleSummary, error)
+	GetAlertInstanceStateSummaries(ctx context.Context, query *models.GetAlertInstanceStateSummariesQuery) ([]*models.AlertInstanceStateSummary, error)
 	GetRuleGroupInterval(ctx context.Context, orgID int64, namespaceUID string, ruleGroup string) (int64, error)
 	InsertAlertRules(ctx context.Context, rule []models.AlertRule) (map[string]int64, error)
 	UpdateAlertRules(ctx context.Context, rule []models.UpdateRule) error
 	DeleteAlertRulesByUID(ctx context.Context, orgID int64, ruleUID ...string) error
 	GetAlertRulesGroupByRuleUID(ctx context.Context, query *models.GetAlertRulesGroupByRuleUIDQuery) ([]*models.AlertRule, error)
+	GetIdempotencyKey(ctx context.Context, orgID int64, key string) (*models.IdempotencyKey, error)
+	SaveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+	ExportPrometheusRules(ctx context.Context, query *models.ExportPrometheusRulesQuery) (definitions.PrometheusRulesExport, error)
 }
 
 // QuotaChecker represents the ability to evaluate whether quotas are met.
@@ -49,6 +54,7 @@ type RuleStore interface {
 //go:generate mockery --name QuotaChecker --structname MockQuotaChecker --inpackage --filename quota_checker_mock.go --with-expecter
 type QuotaChecker interface {
 	CheckQuotaReached(ctx context.Context, target quota.TargetSrv, scopeParams *quota.ScopeParameters) (bool, error)
+	GetQuotasByScope(ctx context.Context, scope quota.Scope, id int64) ([]quota.QuotaDTO, error)
 }
 
 // PersistConfig validates to config before eventually persisting it if no error occurs
@@ -0,0 +1,222 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+)
+
+const (
+	prometheusImportQueryRefID     = "A"
+	prometheusImportConditionRefID = "B"
+	// prometheusImportTimeRange is the time range of the queries of the imported rules. The queries are instant queries,
+	// so that the time range only sets the time of the evaluation.
+	prometheusImportTimeRange = 10 * time.Minute
+)
+
+// ImportPrometheusRules creates an alert rule in the folder for every alerting rule of the Prometheus rule groups, or
+// updates the alert rule with the same group and title. The rules query the given Prometheus data source with the
+// expression of the alerting rule, and fire when it returns a value above 0. The rules of a group that are not in the
+// file are kept. Every group is imported in one transaction, and the groups that cannot be imported are reported with
+// the reason. The rule groups must be valid and must not have recording rules, otherwise nothing is imported.
+func (service *AlertRuleService) ImportPrometheusRules(ctx context.Context, orgID int64, folderUID, datasourceUID string,
+	groups []definitions.PrometheusRuleGroup, userID int64, provenance models.Provenance) (definitions.PrometheusImportResult, error) {
+	if err := ValidatePrometheusRuleGroups(groups); err != nil {
+		return definitions.PrometheusImportResult{}, err
+	}
+	_, err := service.dashboardService.GetDashboard(ctx, &dashboards.GetDashboardQuery{OrgID: orgID, UID: folderUID})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			return definitions.PrometheusImportResult{}, fmt.Errorf("%w: folder %s does not exist", models.ErrAlertRuleFailedValidation, folderUID)
+		}
+		return definitions.PrometheusImportResult{}, err
+	}
+
+	result := definitions.PrometheusImportResult{Groups: make([]definitions.PrometheusImportGroupResult, 0, len(groups))}
+	for _, group := range groups {
+		groupResult := definitions.PrometheusImportGroupResult{Name: group.Name, Created: []string{}, Updated: []string{}}
+		created, updated, err := service.importPrometheusRuleGroup(ctx, orgID, folderUID, datasourceUID, group, userID, provenance)
+		if err != nil {
+			service.log.Warn("Failed to import Prometheus rule group", "org", orgID, "folder", folderUID, "group", group.Name, "error", err)
+			groupResult.Error = err.Error()
+		} else {
+			groupResult.Created, groupResult.Updated = created, updated
+		}
+		result.Groups = append(result.Groups, groupResult)
+	}
+	return result, nil
+}
+
+// importPrometheusRuleGroup creates or updates the rules of the Prometheus rule group, and returns the alert names of the
+// rules that were created and of the rules that were updated.
+func (service *AlertRuleService) importPrometheusRuleGroup(ctx context.Context, orgID int64, folderUID, datasourceUID string,
+	group definitions.PrometheusRuleGroup, userID int64, provenance models.Provenance) ([]string, []string, error) {
+	existing, err := service.ruleStore.ListAlertRules(ctx, &models.ListAlertRulesQuery{
+		OrgID:         orgID,
+		NamespaceUIDs: []string{folderUID},
+		RuleGroup:     group.Name,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list alert rules: %w", err)
+	}
+	byTitle := make(map[string]*models.AlertRule, len(existing))
+	for _, rule := range existing {
+		byTitle[rule.Title] = rule
+	}
+
+	interval := int64(time.Duration(group.Interval).Seconds())
+	if interval == 0 {
+		interval = service.defaultIntervalSeconds
+		if len(existing) > 0 {
+			interval = existing[0].IntervalSeconds
+		}
+	}
+	ruleGroup := models.AlertRuleGroup{
+		Title:     group.Name,
+		FolderUID: folderUID,
+		Interval:  interval,
+		Rules:     make([]models.AlertRule, 0, len(group.Rules)+len(existing)),
+	}
+	created, updated := []string{}, []string{}
+	for _, node := range group.Rules {
+		var rule models.AlertRule
+		if e, ok := byTitle[node.Alert]; ok {
+			// the fields that Prometheus rules do not have, like the contact points, are kept
+			rule = *e
+			delete(byTitle, node.Alert)
+			updated = append(updated, node.Alert)
+		} else {
+			rule = models.AlertRule{
+				Title:        node.Alert,
+				NoDataState:  models.OK,
+				ExecErrState: models.ErrorErrState,
+			}
+			created = append(created, node.Alert)
+		}
+		if err := SetPrometheusRuleFields(&rule, node, datasourceUID); err != nil {
+			return nil, nil, err
+		}
+		rule.RuleGroupIndex = len(ruleGroup.Rules) + 1
+		ruleGroup.Rules = append(ruleGroup.Rules, rule)
+	}
+	// the rules of the group that are not in the file are kept after the imported rules
+	for _, rule := range existing {
+		if _, ok := byTitle[rule.Title]; ok {
+			rule.RuleGroupIndex = len(ruleGroup.Rules) + 1
+			ruleGroup.Rules = append(ruleGroup.Rules, *rule)
+		}
+	}
+
+	if err := service.ReplaceRuleGroup(ctx, orgID, ruleGroup, userID, provenance); err != nil {
+		return nil, nil, err
+	}
+	return created, updated, nil
+}
+
+// SetPrometheusRuleFields sets the fields of the alert rule that the Prometheus alerting rule defines. The rule runs an
+// instant query of the expression, and its condition is a threshold expression that fires when the query returns a
+// value above 0. If the Prometheus rule was exported from an alert rule whose condition cannot be expressed in PromQL,
+// the exported condition and queries are restored instead.
+func SetPrometheusRuleFields(rule *models.AlertRule, node definitions.ApiRuleNode, datasourceUID string) error {
+	exported, annotations, err := store.ParsePrometheusRuleCondition(node)
+	if err != nil {
+		return err
+	}
+	rule.For = 0
+	if node.For != nil {
+		rule.For = time.Duration(*node.For)
+	}
+	rule.Labels = node.Labels
+	rule.Annotations = annotations
+	if exported != nil {
+		rule.Condition = exported.Condition
+		rule.Data = exported.Data
+		return nil
+	}
+
+	query, err := json.Marshal(map[string]interface{}{
+		"refId":   prometheusImportQueryRefID,
+		"expr":    node.Expr,
+		"instant": true,
+		"range":   false,
+	})
+	if err != nil {
+		return err
+	}
+	condition, err := json.Marshal(map[string]interface{}{
+		"refId":      prometheusImportConditionRefID,
+		"type":       "threshold",
+		"expression": prometheusImportQueryRefID,
+		"conditions": []expr.ThresholdConditionJSON{{
+			Evaluator: expr.ConditionEvalJSON{Type: expr.ThresholdIsAbove, Params: []float64{0}},
+		}},
+		"datasource": map[string]string{
+			"uid":  expr.DatasourceUID,
+			"type": expr.DatasourceType,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	rule.Condition = prometheusImportConditionRefID
+	rule.Data = []models.AlertQuery{
+		{
+			RefID:             prometheusImportQueryRefID,
+			DatasourceUID:     datasourceUID,
+			RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(prometheusImportTimeRange)},
+			Model:             query,
+		},
+		{
+			RefID:         prometheusImportConditionRefID,
+			QueryType:     expr.DatasourceType,
+			DatasourceUID: expr.DatasourceUID,
+			Model:         condition,
+		},
+	}
+	return nil
+}
+
+// ValidatePrometheusRuleGroups checks that the rule groups have unique names, and that their rules are alerting rules with
+// unique alert names and valid PromQL expressions.
+func ValidatePrometheusRuleGroups(groups []definitions.PrometheusRuleGroup) error {
+	groupNames := make(map[string]struct{}, len(groups))
+	for _, group := range groups {
+		if group.Name == "" {
+			return fmt.Errorf("%w: rule group has no name", models.ErrAlertRuleFailedValidation)
+		}
+		if _, ok := groupNames[group.Name]; ok {
+			return fmt.Errorf("%w: rule group %q is defined more than once", models.ErrAlertRuleFailedValidation, group.Name)
+		}
+		groupNames[group.Name] = struct{}{}
+
+		alertNames := make(map[string]struct{}, len(group.Rules))
+		for i, node := range group.Rules {
+			if node.Record != "" {
+				return fmt.Errorf("%w: rule %q of group %q is a recording rule, which cannot be imported as an alert rule",
+					models.ErrAlertRuleFailedValidation, node.Record, group.Name)
+			}
+			if node.Alert == "" {
+				return fmt.Errorf("%w: rule %d of group %q has no alert name", models.ErrAlertRuleFailedValidation, i+1, group.Name)
+			}
+			if _, ok := alertNames[node.Alert]; ok {
+				return fmt.Errorf("%w: alert %q is defined more than once in group %q", models.ErrAlertRuleFailedValidation, node.Alert, group.Name)
+			}
+			alertNames[node.Alert] = struct{}{}
+			if _, err := parser.ParseExpr(node.Expr); err != nil {
+				return fmt.Errorf("%w: expression of alert %q of group %q is not valid PromQL: %s", models.ErrAlertRuleFailedValidation, node.Alert, group.Name, err)
+			}
+		}
+	}
+	return nil
+}
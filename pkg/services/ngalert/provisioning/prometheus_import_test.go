@@ -0,0 +1,260 @@
+package provisioning
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const prometheusRuleFile = `
+groups:
+  - name: node
+    interval: 30s
+    rules:
+      - alert: NodeDown
+        expr: up{job="node"} == 0
+        for: 5m
+        labels:
+          severity: critical
+        annotations:
+          summary: Node {{ $labels.instance }} is down
+      - alert: NodeHighCPU
+        expr: 100 - avg by (instance) (rate(node_cpu_seconds_total{mode="idle"}[5m])) * 100 > 90
+        for: 10m
+        labels:
+          severity: warning
+  - name: api
+    rules:
+      - alert: APIHighErrorRate
+        expr: sum(rate(http_requests_total{code=~"5.."}[5m])) / sum(rate(http_requests_total[5m])) > 0.05
+        annotations:
+          description: More than 5% of the requests fail
+`
+
+func TestImportPrometheusRules(t *testing.T) {
+	ctx := context.Background()
+	orgID := int64(1)
+	parse := func(t *testing.T, file string) []definitions.PrometheusRuleGroup {
+		t.Helper()
+		var ruleFile definitions.PrometheusRuleFile
+		require.NoError(t, yaml.Unmarshal([]byte(file), &ruleFile))
+		return ruleFile.Groups
+	}
+	createService := func(t *testing.T) AlertRuleService {
+		t.Helper()
+		ruleService := createAlertRuleService(t)
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.MatchedBy(func(q *dashboards.GetDashboardQuery) bool {
+			return q.UID == "folder"
+		})).Return(&dashboards.Dashboard{UID: "folder", Title: "Folder", IsFolder: true}, nil).Maybe()
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(nil, dashboards.ErrDashboardNotFound).Maybe()
+		ruleService.dashboardService = dashboardService
+		return ruleService
+	}
+	listRules := func(t *testing.T, ruleService AlertRuleService) map[string]*models.AlertRule {
+		t.Helper()
+		rules, err := ruleService.ruleStore.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		result := make(map[string]*models.AlertRule, len(rules))
+		for _, rule := range rules {
+			result[rule.Title] = rule
+		}
+		return result
+	}
+
+	t.Run("creates an alert rule for every alerting rule", func(t *testing.T) {
+		ruleService := createService(t)
+		result, err := ruleService.ImportPrometheusRules(ctx, orgID, "folder", "prometheus", parse(t, prometheusRuleFile), 1, models.ProvenanceAPI)
+		require.NoError(t, err)
+		require.Equal(t, definitions.PrometheusImportResult{Groups: []definitions.PrometheusImportGroupResult{
+			{Name: "node", Created: []string{"NodeDown", "NodeHighCPU"}, Updated: []string{}},
+			{Name: "api", Created: []string{"APIHighErrorRate"}, Updated: []string{}},
+		}}, result)
+
+		rules := listRules(t, ruleService)
+		require.Len(t, rules, 3)
+		down := rules["NodeDown"]
+		require.Equal(t, "folder", down.NamespaceUID)
+		require.Equal(t, "node", down.RuleGroup)
+		require.Equal(t, 1, down.RuleGroupIndex)
+		require.Equal(t, int64(30), down.IntervalSeconds)
+		require.Equal(t, 5*time.Minute, down.For)
+		require.Equal(t, map[string]string{"severity": "critical"}, down.Labels)
+		require.Equal(t, map[string]string{"summary": "Node {{ $labels.instance }} is down"}, down.Annotations)
+		require.Equal(t, models.OK, down.NoDataState)
+		require.Equal(t, models.ErrorErrState, down.ExecErrState)
+
+		require.Equal(t, "B", down.Condition)
+		require.Len(t, down.Data, 2)
+		require.Equal(t, "prometheus", down.Data[0].DatasourceUID)
+		var query map[string]interface{}
+		require.NoError(t, json.Unmarshal(down.Data[0].Model, &query))
+		require.Equal(t, `up{job="node"} == 0`, query["expr"])
+		require.Equal(t, true, query["instant"])
+		require.Equal(t, "__expr__", down.Data[1].DatasourceUID)
+		var condition map[string]interface{}
+		require.NoError(t, json.Unmarshal(down.Data[1].Model, &condition))
+		require.Equal(t, "threshold", condition["type"])
+		require.Equal(t, "A", condition["expression"])
+		require.Equal(t, []interface{}{map[string]interface{}{"evaluator": map[string]interface{}{"type": "gt", "params": []interface{}{0.0}}}}, condition["conditions"])
+
+		require.Equal(t, 2, rules["NodeHighCPU"].RuleGroupIndex)
+		api := rules["APIHighErrorRate"]
+		require.Equal(t, "api", api.RuleGroup)
+		require.Equal(t, int64(60), api.IntervalSeconds, "the group without interval gets the default interval")
+		require.Zero(t, api.For)
+		require.Empty(t, api.Labels)
+	})
+
+	t.Run("updates the rules with the same group and alert name when it imports again", func(t *testing.T) {
+		ruleService := createService(t)
+		_, err := ruleService.ImportPrometheusRules(ctx, orgID, "folder", "prometheus", parse(t, prometheusRuleFile), 1, models.ProvenanceAPI)
+		require.NoError(t, err)
+		before := listRules(t, ruleService)
+		// a rule that was created in the group in Grafana
+		grafanaRule := createTestRule("Grafana rule", "node", orgID)
+		grafanaRule.NamespaceUID = "folder"
+		grafanaRule.IntervalSeconds = 30
+		_, err = ruleService.CreateAlertRule(ctx, grafanaRule, models.ProvenanceNone, 1)
+		require.NoError(t, err)
+
+		result, err := ruleService.ImportPrometheusRules(ctx, orgID, "folder", "prometheus", parse(t, `
+groups:
+  - name: node
+    interval: 30s
+    rules:
+      - alert: NodeDown
+        expr: up{job="node"} == 0
+        for: 1m
+        labels:
+          severity: page
+      - alert: NodeOutOfMemory
+        expr: node_memory_MemAvailable_bytes / node_memory_MemTotal_bytes < 0.05
+`), 1, models.ProvenanceAPI)
+		require.NoError(t, err)
+		require.Equal(t, definitions.PrometheusImportResult{Groups: []definitions.PrometheusImportGroupResult{
+			{Name: "node", Created: []string{"NodeOutOfMemory"}, Updated: []string{"NodeDown"}},
+		}}, result)
+
+		rules := listRules(t, ruleService)
+		require.Len(t, rules, 5)
+		down := rules["NodeDown"]
+		require.Equal(t, before["NodeDown"].UID, down.UID)
+		require.Equal(t, time.Minute, down.For)
+		require.Equal(t, map[string]string{"severity": "page"}, down.Labels)
+		require.Empty(t, down.Annotations)
+		require.Equal(t, 1, down.RuleGroupIndex)
+		require.Equal(t, 2, rules["NodeOutOfMemory"].RuleGroupIndex)
+		// the rules of the group that are not in the file are kept
+		require.Equal(t, before["NodeHighCPU"].UID, rules["NodeHighCPU"].UID)
+		require.Contains(t, rules, "Grafana rule")
+	})
+
+	t.Run("imports every group in its own transaction", func(t *testing.T) {
+		ruleService := createService(t)
+		result, err := ruleService.ImportPrometheusRules(ctx, orgID, "folder", "prometheus", parse(t, `
+groups:
+  - name: invalid-interval
+    interval: 15s
+    rules:
+      - alert: First
+        expr: up == 0
+  - name: valid
+    rules:
+      - alert: Second
+        expr: up == 0
+`), 1, models.ProvenanceAPI)
+		require.NoError(t, err)
+		require.Len(t, result.Groups, 2)
+		require.Contains(t, result.Groups[0].Error, "interval")
+		require.Empty(t, result.Groups[0].Created)
+		require.Empty(t, result.Groups[1].Error)
+		require.Equal(t, []string{"Second"}, result.Groups[1].Created)
+
+		rules := listRules(t, ruleService)
+		require.Len(t, rules, 1)
+		require.Contains(t, rules, "Second")
+	})
+
+	t.Run("rejects invalid rule files", func(t *testing.T) {
+		testCases := []struct {
+			desc   string
+			file   string
+			reason string
+		}{
+			{
+				desc: "recording rule",
+				file: `
+groups:
+  - name: node
+    rules:
+      - alert: NodeDown
+        expr: up == 0
+      - record: instance:node_cpu:rate5m
+        expr: rate(node_cpu_seconds_total[5m])
+`,
+				reason: `rule "instance:node_cpu:rate5m" of group "node" is a recording rule, which cannot be imported as an alert rule`,
+			},
+			{
+				desc: "invalid expression",
+				file: `
+groups:
+  - name: node
+    rules:
+      - alert: NodeDown
+        expr: up ==
+`,
+				reason: `expression of alert "NodeDown" of group "node" is not valid PromQL`,
+			},
+			{
+				desc: "duplicated alert name",
+				file: `
+groups:
+  - name: node
+    rules:
+      - alert: NodeDown
+        expr: up == 0
+      - alert: NodeDown
+        expr: up{job="node"} == 0
+`,
+				reason: `alert "NodeDown" is defined more than once in group "node"`,
+			},
+			{
+				desc: "duplicated group",
+				file: `
+groups:
+  - name: node
+    rules: []
+  - name: node
+    rules: []
+`,
+				reason: `rule group "node" is defined more than once`,
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.desc, func(t *testing.T) {
+				ruleService := createService(t)
+				_, err := ruleService.ImportPrometheusRules(ctx, orgID, "folder", "prometheus", parse(t, tc.file), 1, models.ProvenanceAPI)
+				require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+				require.ErrorContains(t, err, tc.reason)
+				require.Empty(t, listRules(t, ruleService))
+			})
+		}
+	})
+
+	t.Run("fails if the folder does not exist", func(t *testing.T) {
+		ruleService := createService(t)
+		_, err := ruleService.ImportPrometheusRules(ctx, orgID, "missing", "prometheus", parse(t, prometheusRuleFile), 1, models.ProvenanceAPI)
+		require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+		require.ErrorContains(t, err, "folder missing does not exist")
+	})
+}
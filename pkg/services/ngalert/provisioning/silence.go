@@ -0,0 +1,93 @@
+package provisioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// SilenceStore is the storage of silences.
+type SilenceStore interface {
+	GetSilences(ctx context.Context, orgID int64) ([]*models.Silence, error)
+	GetSilence(ctx context.Context, orgID, id int64) (*models.Silence, error)
+	InsertSilence(ctx context.Context, silence *models.Silence) error
+	UpdateSilence(ctx context.Context, silence *models.Silence) error
+	DeleteSilence(ctx context.Context, orgID, id int64) error
+}
+
+type SilenceService struct {
+	store SilenceStore
+	now   func() time.Time
+	log   log.Logger
+}
+
+func NewSilenceService(store SilenceStore, log log.Logger) *SilenceService {
+	return &SilenceService{
+		store: store,
+		now:   time.Now,
+		log:   log,
+	}
+}
+
+// GetSilences returns all silences within the specified org.
+func (svc *SilenceService) GetSilences(ctx context.Context, orgID int64) ([]*models.Silence, error) {
+	return svc.store.GetSilences(ctx, orgID)
+}
+
+// GetSilence returns the silence with the given ID within the specified org, or ErrNotFound.
+func (svc *SilenceService) GetSilence(ctx context.Context, orgID, id int64) (*models.Silence, error) {
+	silence, err := svc.store.GetSilence(ctx, orgID, id)
+	if errors.Is(err, models.ErrSilenceNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, err.Error())
+	}
+	return silence, err
+}
+
+// CreateSilence adds a new silence created by the given user within the specified org. Silences cannot end in the past.
+// The created silence is returned.
+func (svc *SilenceService) CreateSilence(ctx context.Context, silence models.Silence, orgID int64, userID int64) (*models.Silence, error) {
+	now := svc.now()
+	if err := silence.Validate(now); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+	silence.ID = 0
+	silence.OrgID = orgID
+	silence.CreatedBy = userID
+	silence.Created = now
+	silence.Updated = now
+	if err := svc.store.InsertSilence(ctx, &silence); err != nil {
+		return nil, err
+	}
+	return &silence, nil
+}
+
+// UpdateSilence replaces the silence with the ID of the given one within the specified org. The updated silence is
+// returned.
+func (svc *SilenceService) UpdateSilence(ctx context.Context, silence models.Silence, orgID int64) (*models.Silence, error) {
+	now := svc.now()
+	if err := silence.Validate(now); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrValidation, err.Error())
+	}
+	silence.OrgID = orgID
+	silence.Updated = now
+	if err := svc.store.UpdateSilence(ctx, &silence); err != nil {
+		if errors.Is(err, models.ErrSilenceNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, err.Error())
+		}
+		return nil, err
+	}
+	return svc.store.GetSilence(ctx, orgID, silence.ID)
+}
+
+// DeleteSilence deletes the silence with the given ID within the specified org.
+func (svc *SilenceService) DeleteSilence(ctx context.Context, orgID, id int64) error {
+	err := svc.store.DeleteSilence(ctx, orgID, id)
+	if errors.Is(err, models.ErrSilenceNotFound) {
+		return fmt.Errorf("%w: %s", ErrNotFound, err.Error())
+	}
+	return err
+}
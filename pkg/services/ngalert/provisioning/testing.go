@@ -9,6 +9,7 @@ import (
 	mock "github.com/stretchr/testify/mock"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/quota"
 )
 
 const defaultAlertmanagerConfigJSON = `
@@ -184,5 +185,6 @@ func (m *MockQuotaChecker_Expecter) LimitOK() *MockQuotaChecker_Expecter {
 
 func (m *MockQuotaChecker_Expecter) LimitExceeded() *MockQuotaChecker_Expecter {
 	m.CheckQuotaReached(mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	m.GetQuotasByScope(mock.Anything, mock.Anything, mock.Anything).Return([]quota.QuotaDTO{}, nil).Maybe()
 	return m
 }
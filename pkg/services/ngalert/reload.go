@@ -0,0 +1,75 @@
+package ngalert
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+)
+
+// reloadableSettings are the keys of the configuration that ReloadSettings applies without a restart. The evaluation
+// timeout and the number of attempts can also be set in the legacy [alerting] section.
+var reloadableSettings = map[string]struct{}{
+	"unified_alerting.max_concurrent_evaluations":         {},
+	"unified_alerting.max_attempts":                       {},
+	"unified_alerting.evaluation_timeout":                 {},
+	"unified_alerting.instance_history_retention":         {},
+	"unified_alerting.notification_max_attempts":          {},
+	"unified_alerting.notification_retry_initial_backoff": {},
+	"unified_alerting.notification_retry_max_backoff":     {},
+	"alerting.max_attempts":                               {},
+	"alerting.evaluation_timeout_seconds":                 {},
+}
+
+// ReloadSettings reads the configuration again and applies the settings that can be changed without a restart: the
+// scheduler applies its settings at its next tick, the others are applied immediately. It returns the changed keys,
+// split into those that are applied and those that require a restart. Keys are compared with the configuration that
+// Grafana was started with, so a changed key is reported on every reload until the next restart.
+func (ng *AlertNG) ReloadSettings(_ context.Context) (*apimodels.AlertingSettingsReload, error) {
+	ng.reloadMtx.Lock()
+	defer ng.reloadMtx.Unlock()
+
+	settings, changed, err := ng.Cfg.ReloadUnifiedAlertingSettings()
+	if err != nil {
+		return nil, err
+	}
+	result := &apimodels.AlertingSettingsReload{Applied: []string{}, RequiresRestart: []string{}}
+	for _, key := range changed {
+		if _, ok := reloadableSettings[key]; ok {
+			result.Applied = append(result.Applied, key)
+		} else {
+			result.RequiresRestart = append(result.RequiresRestart, key)
+		}
+	}
+
+	ng.schedule.UpdateSettings(schedule.RuntimeSettings{
+		MaxAttempts:              settings.MaxAttempts,
+		MaxConcurrentEvaluations: settings.MaxConcurrentEvaluations,
+		EvaluationTimeout:        settings.EvaluationTimeout,
+	})
+	ng.stateManager.SetHistoryRetention(settings.InstanceHistoryRetention)
+	ng.MultiOrgAlertmanager.SetNotificationRetry(settings.NotificationMaxAttempts, settings.NotificationRetryInitialBackoff, settings.NotificationRetryMaxBackoff)
+
+	ng.Log.Info("Reloaded alerting settings", "applied", result.Applied, "requiresRestart", result.RequiresRestart)
+	return result, nil
+}
+
+// reloadSettingsOnSignal reloads the settings every time the process receives SIGHUP, until the context is done.
+func (ng *AlertNG) reloadSettingsOnSignal(ctx context.Context) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	for {
+		select {
+		case <-signals:
+			if _, err := ng.ReloadSettings(ctx); err != nil {
+				ng.Log.Error("Failed to reload alerting settings", "error", err)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
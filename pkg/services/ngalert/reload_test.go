@@ -0,0 +1,80 @@
+package ngalert
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationReloadSettings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	configFile := filepath.Join(t.TempDir(), "custom.ini")
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0600))
+	}
+	writeConfig(`
+[unified_alerting]
+enabled = true
+max_concurrent_evaluations = 1
+`)
+	cfg := setting.NewCfg()
+	require.NoError(t, cfg.Load(setting.CommandLineArgs{HomePath: "../../../", Config: configFile}))
+	cfg.IsFeatureToggleEnabled = featuremgmt.WithFeatures().IsEnabled
+	ng := provideAlertNG(t, db.InitTestDB(t), cfg)
+
+	// the scheduler runs with a mock clock, so that the test decides when it ticks.
+	mockClock := clock.NewMock()
+	sch := schedule.NewScheduler(schedule.SchedulerCfg{
+		BaseInterval:             cfg.UnifiedAlerting.BaseInterval,
+		MaxAttempts:              cfg.UnifiedAlerting.MaxAttempts,
+		MaxConcurrentEvaluations: cfg.UnifiedAlerting.MaxConcurrentEvaluations,
+		C:                        mockClock,
+		RuleStore:                ng.store,
+		Metrics:                  metrics.NewNGAlert(prometheus.NewRegistry()).GetSchedulerMetrics(),
+		Tracer:                   tracing.InitializeTracerForTest(),
+	}, ng.stateManager)
+	ng.schedule = sch
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- sch.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	require.Equal(t, 1, sch.Status().MaxConcurrentEvaluations)
+
+	writeConfig(`
+[unified_alerting]
+enabled = true
+max_concurrent_evaluations = 4
+ha_listen_address = 127.0.0.1:9095
+`)
+	result, err := ng.ReloadSettings(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"unified_alerting.max_concurrent_evaluations"}, result.Applied)
+	require.Equal(t, []string{"unified_alerting.ha_listen_address"}, result.RequiresRestart)
+
+	// the scheduler applies the settings at its next tick, not during the reload.
+	require.Equal(t, 1, sch.Status().MaxConcurrentEvaluations)
+	require.Eventually(t, func() bool {
+		mockClock.Add(cfg.UnifiedAlerting.BaseInterval)
+		return sch.Status().MaxConcurrentEvaluations == 4
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,55 @@
+package schedule
+
+import (
+	"time"
+)
+
+// maxBackoffFactor limits the delay between evaluations of a failing rule to this number of rule intervals.
+const maxBackoffFactor = 10
+
+// evaluationBackoff tracks consecutive failures of a rule and calculates when the rule should be evaluated next.
+// It is owned by the rule evaluation routine and is not safe for concurrent use.
+type evaluationBackoff struct {
+	threshold int64
+	max       time.Duration
+
+	failures       int64
+	nextEvaluation time.Time
+}
+
+// skip returns true if the evaluation scheduled at the given time should be skipped because the rule is backing off.
+func (b *evaluationBackoff) skip(scheduledAt time.Time) bool {
+	return scheduledAt.Before(b.nextEvaluation)
+}
+
+// failed records a failed evaluation. If the number of consecutive failures reached the threshold, it returns the delay
+// after which the rule should be evaluated again. The delay doubles with every failure and is capped at maxBackoffFactor
+// intervals or the configured maximum, whichever is lower, but it is never shorter than the interval.
+func (b *evaluationBackoff) failed(scheduledAt time.Time, interval time.Duration) time.Duration {
+	b.failures++
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return 0
+	}
+	limit := maxBackoffFactor * interval
+	if b.max > 0 && b.max < limit {
+		limit = b.max
+	}
+	if limit < interval {
+		limit = interval
+	}
+	delay := interval
+	for i := b.threshold; i < b.failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+	b.nextEvaluation = scheduledAt.Add(delay)
+	return delay
+}
+
+// reset clears the failures, e.g. when the rule is evaluated successfully or updated.
+func (b *evaluationBackoff) reset() {
+	b.failures = 0
+	b.nextEvaluation = time.Time{}
+}
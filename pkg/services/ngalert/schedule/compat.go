@@ -137,7 +137,9 @@ func FromStateTransitionToPostableAlerts(firingStates []state.StateTransition, s
 	ts := time.Now()
 
 	for _, alertState := range firingStates {
-		if !alertState.NeedsSending(stateManager.ResendDelay) {
+		// transitions in a maintenance window or silenced are not sent, and are sent by the first evaluation after the window
+		// or the silence if still firing
+		if alertState.MaintenanceWindowID != 0 || alertState.SilenceID != 0 || !alertState.NeedsSending(stateManager.ResendDelay) {
 			continue
 		}
 		alert := stateToPostableAlert(alertState.State, appURL)
@@ -250,6 +250,46 @@ func Test_FromAlertsStateToStoppedAlert(t *testing.T) {
 	require.Equal(t, expected, result.PostableAlerts)
 }
 
+func Test_FromStateTransitionToPostableAlerts(t *testing.T) {
+	appURL := &url.URL{
+		Scheme: "http:",
+		Host:   fmt.Sprintf("host-%d", rand.Int()),
+		Path:   fmt.Sprintf("path-%d", rand.Int()),
+	}
+	manager := state.NewManager(state.ManagerCfg{Clock: clock.NewMock()})
+
+	t.Run("should not send transitions suppressed by a maintenance window", func(t *testing.T) {
+		firing := randomState(eval.Alerting)
+		firing.LastSentAt = time.Time{}
+		suppressed := randomState(eval.Alerting)
+		suppressed.LastSentAt = time.Time{}
+
+		result := FromStateTransitionToPostableAlerts([]state.StateTransition{
+			{State: firing, PreviousState: eval.Pending},
+			{State: suppressed, PreviousState: eval.Pending, MaintenanceWindowID: 1},
+		}, manager, appURL)
+
+		require.Equal(t, []models.PostableAlert{*stateToPostableAlert(firing, appURL)}, result.PostableAlerts)
+		require.False(t, firing.LastSentAt.IsZero())
+		require.True(t, suppressed.LastSentAt.IsZero(), "suppressed alert should be sent after the maintenance window")
+	})
+
+	t.Run("should not send silenced transitions", func(t *testing.T) {
+		firing := randomState(eval.Alerting)
+		firing.LastSentAt = time.Time{}
+		silenced := randomState(eval.Alerting)
+		silenced.LastSentAt = time.Time{}
+
+		result := FromStateTransitionToPostableAlerts([]state.StateTransition{
+			{State: firing, PreviousState: eval.Pending},
+			{State: silenced, PreviousState: eval.Pending, SilenceID: 1},
+		}, manager, appURL)
+
+		require.Equal(t, []models.PostableAlert{*stateToPostableAlert(firing, appURL)}, result.PostableAlerts)
+		require.True(t, silenced.LastSentAt.IsZero(), "silenced alert should be sent after the silence")
+	})
+}
+
 func randomMapOfStrings() map[string]string {
 	max := 5
 	result := make(map[string]string, max)
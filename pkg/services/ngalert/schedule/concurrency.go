@@ -0,0 +1,52 @@
+package schedule
+
+import (
+	"sync"
+)
+
+// evaluationLimiter limits the number of evaluations that run at the same time. The limit can be changed while
+// evaluations run: if it is lowered, the running evaluations complete, and new evaluations are refused until fewer
+// evaluations than the new limit are running.
+type evaluationLimiter struct {
+	mtx sync.Mutex
+	// limit is the maximum number of running evaluations. Zero disables the limit.
+	limit   int
+	running int
+}
+
+func newEvaluationLimiter(limit int) *evaluationLimiter {
+	return &evaluationLimiter{limit: limit}
+}
+
+// tryAcquire returns true if the evaluation can run, and false without waiting if the limit of running evaluations is
+// reached. Every successful call must be followed by a call to release when the evaluation completes.
+func (l *evaluationLimiter) tryAcquire() bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.limit > 0 && l.running >= l.limit {
+		return false
+	}
+	l.running++
+	return true
+}
+
+// release is called when an evaluation that was acquired completes.
+func (l *evaluationLimiter) release() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.running--
+}
+
+// setLimit changes the maximum number of running evaluations. Zero disables the limit.
+func (l *evaluationLimiter) setLimit(limit int) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.limit = limit
+}
+
+// stats returns the limit and the number of running evaluations.
+func (l *evaluationLimiter) stats() (limit, running int) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.limit, l.running
+}
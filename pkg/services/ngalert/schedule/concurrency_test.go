@@ -0,0 +1,59 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluationLimiter(t *testing.T) {
+	t.Run("should not limit evaluations if the limit is zero", func(t *testing.T) {
+		l := newEvaluationLimiter(0)
+		for i := 0; i < 100; i++ {
+			require.True(t, l.tryAcquire())
+		}
+		limit, running := l.stats()
+		require.Equal(t, 0, limit)
+		require.Equal(t, 100, running)
+	})
+
+	t.Run("should refuse evaluations until a running evaluation completes", func(t *testing.T) {
+		l := newEvaluationLimiter(1)
+		require.True(t, l.tryAcquire())
+		require.False(t, l.tryAcquire())
+
+		l.release()
+		require.True(t, l.tryAcquire())
+		_, running := l.stats()
+		require.Equal(t, 1, running)
+	})
+
+	t.Run("should accept more evaluations when the limit is raised", func(t *testing.T) {
+		l := newEvaluationLimiter(1)
+		require.True(t, l.tryAcquire())
+		require.False(t, l.tryAcquire())
+
+		l.setLimit(3)
+		require.True(t, l.tryAcquire())
+		require.True(t, l.tryAcquire())
+		require.False(t, l.tryAcquire())
+		limit, running := l.stats()
+		require.Equal(t, 3, limit)
+		require.Equal(t, 3, running)
+	})
+
+	t.Run("should refuse evaluations until fewer than the lowered limit are running", func(t *testing.T) {
+		l := newEvaluationLimiter(3)
+		for i := 0; i < 3; i++ {
+			require.True(t, l.tryAcquire())
+		}
+		l.setLimit(1)
+		require.False(t, l.tryAcquire())
+
+		l.release()
+		l.release()
+		require.False(t, l.tryAcquire())
+		l.release()
+		require.True(t, l.tryAcquire())
+	})
+}
@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"time"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+type cronScheduleKey struct {
+	schedule string
+	timezone string
+}
+
+// cronSchedules keeps parsed cron schedules of rules between ticks, so that expressions and time zones are not parsed on every tick.
+// It is used only by the scheduling loop and is not safe for concurrent use.
+type cronSchedules struct {
+	current map[cronScheduleKey]ngmodels.Schedule
+	next    map[cronScheduleKey]ngmodels.Schedule
+}
+
+// get returns the parsed schedule of the rule. Schedules that are not requested between two calls of rotate are dropped.
+func (c *cronSchedules) get(rule *ngmodels.AlertRule) (ngmodels.Schedule, error) {
+	key := cronScheduleKey{schedule: rule.Schedule, timezone: rule.ScheduleTimezone}
+	if s, ok := c.next[key]; ok {
+		return s, nil
+	}
+	s, ok := c.current[key]
+	if !ok {
+		var err error
+		s, err = ngmodels.ParseSchedule(rule.Schedule, rule.ScheduleTimezone)
+		if err != nil {
+			return ngmodels.Schedule{}, err
+		}
+	}
+	if c.next == nil {
+		c.next = make(map[cronScheduleKey]ngmodels.Schedule)
+	}
+	c.next[key] = s
+	return s, nil
+}
+
+// rotate forgets the schedules that were not requested since the previous call.
+func (c *cronSchedules) rotate() {
+	c.current, c.next = c.next, nil
+}
+
+// isDueByCron returns true if the schedule has an evaluation time in the tick period (tick - baseInterval, tick].
+func isDueByCron(s ngmodels.Schedule, tick time.Time, baseInterval time.Duration) bool {
+	return !s.Next(tick.Add(-baseInterval)).After(tick)
+}
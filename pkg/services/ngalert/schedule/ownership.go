@@ -0,0 +1,158 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// updateLiveInstances records the heartbeat of this instance and refreshes the list of scheduler instances
+// that sent a heartbeat within the TTL. If the heartbeat cannot be written, the other instances stop counting this
+// one as live once its heartbeat expires and take over its rules, so this instance evaluates no rule until a heartbeat
+// succeeds. If only the list cannot be read, the previously known list is kept.
+func (sch *schedule) updateLiveInstances(ctx context.Context, now time.Time) {
+	if sch.heartbeatStore == nil {
+		return
+	}
+	if err := sch.heartbeatStore.Heartbeat(ctx, sch.instanceID, now); err != nil {
+		sch.log.Error("Failed to send heartbeat, rules are not evaluated until a heartbeat succeeds", "instance", sch.instanceID, "error", err)
+		sch.ownershipMtx.Lock()
+		sch.heartbeatFailed = true
+		sch.ownershipMtx.Unlock()
+		return
+	}
+	live, err := sch.heartbeatStore.GetLiveInstances(ctx, now.Add(-sch.heartbeatTTL))
+
+	sch.ownershipMtx.Lock()
+	defer sch.ownershipMtx.Unlock()
+	sch.heartbeatFailed = false
+	if err != nil {
+		sch.log.Error("Failed to get live scheduler instances", "instance", sch.instanceID, "error", err)
+		return
+	}
+	if !equalInstances(sch.liveInstances, live) {
+		sch.log.Info("Scheduler instances have changed", "instance", sch.instanceID, "liveInstances", live)
+	}
+	sch.liveInstances = live
+}
+
+// ruleOwnership is a snapshot of the scheduler instances that decides which of them evaluates a rule. The scheduler
+// refreshes the instances at every tick, so the callers that check several rules or check a rule more than once take one
+// snapshot and ask it.
+type ruleOwnership struct {
+	instanceID string
+	// coordinated is false if the instances do not coordinate, and then every instance evaluates all rules.
+	coordinated     bool
+	shardingEnabled bool
+	// liveInstances contains the IDs of the live scheduler instances sorted in ascending order.
+	liveInstances   []string
+	heartbeatFailed bool
+}
+
+// ruleOwnership returns a snapshot of the scheduler instances.
+func (sch *schedule) ruleOwnership() ruleOwnership {
+	sch.ownershipMtx.RLock()
+	defer sch.ownershipMtx.RUnlock()
+	return ruleOwnership{
+		instanceID:      sch.instanceID,
+		coordinated:     sch.heartbeatStore != nil,
+		shardingEnabled: sch.shardingEnabled,
+		liveInstances:   sch.liveInstances,
+		heartbeatFailed: sch.heartbeatFailed,
+	}
+}
+
+// ownsRule returns true if this instance is responsible for evaluating the rule.
+// When sharding is enabled, rules are spread across the live instances. Otherwise, only the live
+// instance with the lowest ID evaluates rules. If coordination is disabled or the live instances
+// are not known yet, this instance evaluates all rules. If the latest heartbeat of this instance failed,
+// it evaluates no rule.
+func (o ruleOwnership) ownsRule(key ngmodels.AlertRuleKey) bool {
+	if !o.coordinated {
+		return true
+	}
+	if o.heartbeatFailed {
+		return false
+	}
+	return len(o.liveInstances) == 0 || o.ruleOwner(key) == o.instanceID
+}
+
+// owner returns the instance that evaluates the rule. It is empty if the latest heartbeat of this instance failed, because
+// then this instance does not know which instance took over the rule.
+func (o ruleOwnership) owner(key ngmodels.AlertRuleKey) string {
+	switch {
+	case !o.coordinated:
+		return o.instanceID
+	case o.heartbeatFailed:
+		return ""
+	case len(o.liveInstances) == 0:
+		return o.instanceID
+	default:
+		return o.ruleOwner(key)
+	}
+}
+
+// ruleOwner returns the live instance that evaluates the rule. It must only be called when the live instances are known.
+func (o ruleOwnership) ruleOwner(key ngmodels.AlertRuleKey) string {
+	if !o.shardingEnabled {
+		return o.liveInstances[0]
+	}
+	return ruleOwner(key, o.liveInstances)
+}
+
+// checkOwner returns ngmodels.ErrAlertRuleNotOwned if this instance does not evaluate the rule.
+func (o ruleOwnership) checkOwner(key ngmodels.AlertRuleKey) error {
+	switch {
+	case o.ownsRule(key):
+		return nil
+	case o.heartbeatFailed:
+		return fmt.Errorf("%w: the heartbeat of this instance failed", ngmodels.ErrAlertRuleNotOwned)
+	default:
+		return fmt.Errorf("%w: the rule is evaluated by instance %s", ngmodels.ErrAlertRuleNotOwned, o.ruleOwner(key))
+	}
+}
+
+// ruleOwner returns the instance that owns the rule among the given instances using rendezvous hashing:
+// the owner is the instance with the highest hash of the instance ID and the rule key. The assignment
+// depends only on the set of instances, and when an instance joins or leaves only the rules it owns move.
+func ruleOwner(key ngmodels.AlertRuleKey, instances []string) string {
+	var owner string
+	var maxScore uint64
+	for _, instance := range instances {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(fmt.Sprintf("%s/%d/%s", instance, key.OrgID, key.UID)))
+		score := h.Sum64()
+		if owner == "" || score > maxScore || (score == maxScore && instance < owner) {
+			owner = instance
+			maxScore = score
+		}
+	}
+	return owner
+}
+
+// deleteHeartbeat removes the heartbeat of this instance so that other instances take over its rules without waiting for the TTL.
+func (sch *schedule) deleteHeartbeat() {
+	if sch.heartbeatStore == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sch.heartbeatStore.DeleteHeartbeat(ctx, sch.instanceID); err != nil {
+		sch.log.Warn("Failed to delete heartbeat", "instance", sch.instanceID, "error", err)
+	}
+}
+
+func equalInstances(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,257 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestRuleOwner(t *testing.T) {
+	generateInstances := func(count int) []string {
+		result := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			result = append(result, fmt.Sprintf("instance-%d", i))
+		}
+		return result
+	}
+	keys := make([]models.AlertRuleKey, 0, 1000)
+	for i := 0; i < cap(keys); i++ {
+		keys = append(keys, models.GenerateRuleKey(rand.Int63n(10)+1))
+	}
+
+	t.Run("every rule should be owned by exactly one live instance", func(t *testing.T) {
+		for size := 1; size <= 10; size++ {
+			instances := generateInstances(size)
+			for _, key := range keys {
+				owners := 0
+				owner := ruleOwner(key, instances)
+				for _, instance := range instances {
+					sch := &schedule{instanceID: instance, heartbeatStore: newFakeHeartbeatStore(), shardingEnabled: true, liveInstances: instances}
+					if sch.ruleOwnership().ownsRule(key) {
+						owners++
+						require.Equal(t, owner, instance)
+					}
+				}
+				require.Equalf(t, 1, owners, "rule %v should be owned by exactly one of %d instances", key, size)
+			}
+		}
+	})
+
+	t.Run("assignment should not depend on the order of instances", func(t *testing.T) {
+		instances := generateInstances(rand.Intn(10) + 2)
+		shuffled := make([]string, len(instances))
+		copy(shuffled, instances)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		for _, key := range keys {
+			require.Equal(t, ruleOwner(key, instances), ruleOwner(key, shuffled))
+		}
+	})
+
+	t.Run("only rules of a removed instance should move", func(t *testing.T) {
+		instances := generateInstances(rand.Intn(10) + 2)
+		removed := instances[rand.Intn(len(instances))]
+		remaining := make([]string, 0, len(instances)-1)
+		for _, instance := range instances {
+			if instance != removed {
+				remaining = append(remaining, instance)
+			}
+		}
+		for _, key := range keys {
+			before := ruleOwner(key, instances)
+			after := ruleOwner(key, remaining)
+			if before != removed {
+				require.Equal(t, before, after)
+			}
+			require.NotEqual(t, removed, after)
+		}
+	})
+
+	t.Run("rules should be spread across instances", func(t *testing.T) {
+		instances := generateInstances(3)
+		counts := make(map[string]int)
+		for _, key := range keys {
+			counts[ruleOwner(key, instances)]++
+		}
+		require.Len(t, counts, len(instances))
+	})
+}
+
+func TestSchedule_sharding(t *testing.T) {
+	const heartbeatTTL = 3 * time.Second
+	ruleStore := newFakeRulesStore()
+	_, rules := models.GenerateUniqueAlertRules(50, models.AlertRuleGen(models.WithInterval(time.Second)))
+	ruleStore.PutRule(context.Background(), rules...)
+	heartbeats := newFakeHeartbeatStore()
+
+	newInstance := func(instanceID string) *schedule {
+		sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewFailingEvaluatorFactory(nil))
+		sch.instanceID = instanceID
+		sch.heartbeatStore = heartbeats
+		sch.heartbeatTTL = heartbeatTTL
+		sch.shardingEnabled = true
+		return sch
+	}
+	instances := []*schedule{newInstance("instance-1"), newInstance("instance-2"), newInstance("instance-3")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	evaluatedBy := func(tick time.Time, instances ...*schedule) map[models.AlertRuleKey][]string {
+		result := make(map[models.AlertRuleKey][]string)
+		for _, sch := range instances {
+			readyToRun, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+			for _, item := range readyToRun {
+				result[item.rule.GetKey()] = append(result[item.rule.GetKey()], sch.instanceID)
+			}
+		}
+		return result
+	}
+
+	tick := instances[0].clock.Now()
+	// the first tick registers heartbeats of all instances.
+	tick = tick.Add(time.Second)
+	evaluatedBy(tick, instances...)
+
+	t.Run("each rule should be evaluated by exactly one live instance", func(t *testing.T) {
+		tick = tick.Add(time.Second)
+		result := evaluatedBy(tick, instances...)
+		require.Len(t, result, len(rules))
+		perInstance := make(map[string]int)
+		for key, evaluatedBy := range result {
+			require.Lenf(t, evaluatedBy, 1, "rule %s was evaluated more than once", key.UID)
+			perInstance[evaluatedBy[0]]++
+		}
+		require.Len(t, perInstance, len(instances))
+	})
+
+	t.Run("rules should be rebalanced when an instance misses heartbeats", func(t *testing.T) {
+		// instance-3 stops sending heartbeats while the other instances keep ticking.
+		for i := 0; i < int(heartbeatTTL/time.Second); i++ {
+			tick = tick.Add(time.Second)
+			evaluatedBy(tick, instances[0], instances[1])
+		}
+		tick = tick.Add(time.Second)
+		result := evaluatedBy(tick, instances[0], instances[1])
+		require.Len(t, result, len(rules))
+		for key, evaluatedBy := range result {
+			require.Lenf(t, evaluatedBy, 1, "rule %s was evaluated more than once", key.UID)
+			require.Equal(t, ruleOwner(key, []string{"instance-1", "instance-2"}), evaluatedBy[0])
+		}
+	})
+
+	t.Run("single instance should evaluate all rules when sharding is disabled", func(t *testing.T) {
+		for _, sch := range instances {
+			sch.shardingEnabled = false
+		}
+		tick = tick.Add(time.Second)
+		result := evaluatedBy(tick, instances...)
+		require.Len(t, result, len(rules))
+		for _, evaluatedBy := range result {
+			require.Equal(t, []string{"instance-1"}, evaluatedBy)
+		}
+	})
+}
+
+func TestSchedule_heartbeatFailure(t *testing.T) {
+	const heartbeatTTL = 3 * time.Second
+	ruleStore := newFakeRulesStore()
+	_, rules := models.GenerateUniqueAlertRules(50, models.AlertRuleGen(models.WithInterval(time.Second)))
+	ruleStore.PutRule(context.Background(), rules...)
+	heartbeats := newFakeHeartbeatStore()
+
+	newInstance := func(instanceID string) *schedule {
+		sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewFailingEvaluatorFactory(nil))
+		sch.instanceID = instanceID
+		sch.heartbeatStore = heartbeats
+		sch.heartbeatTTL = heartbeatTTL
+		sch.shardingEnabled = true
+		return sch
+	}
+	healthy, failing := newInstance("instance-1"), newInstance("instance-2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	evaluated := func(sch *schedule, tick time.Time) int {
+		readyToRun, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		return len(readyToRun)
+	}
+
+	tick := healthy.clock.Now().Add(time.Second)
+	evaluated(healthy, tick)
+	evaluated(failing, tick)
+	tick = tick.Add(time.Second)
+	require.Equal(t, len(rules), evaluated(healthy, tick)+evaluated(failing, tick))
+
+	t.Run("should not evaluate rules while its heartbeat fails", func(t *testing.T) {
+		heartbeats.setFailure("instance-2", errors.New("database is down"))
+		for i := 0; i <= int(heartbeatTTL/time.Second); i++ {
+			tick = tick.Add(time.Second)
+			evaluated(healthy, tick)
+			require.Zero(t, evaluated(failing, tick))
+			require.ErrorIs(t, failing.EvaluateNow(rules[0].GetKey()), models.ErrAlertRuleNotOwned)
+		}
+		// the other instance takes over the rules once the heartbeat expires.
+		tick = tick.Add(time.Second)
+		require.Equal(t, len(rules), evaluated(healthy, tick))
+		require.Zero(t, evaluated(failing, tick))
+	})
+
+	t.Run("should evaluate rules again once a heartbeat succeeds", func(t *testing.T) {
+		heartbeats.setFailure("instance-2", nil)
+		tick = tick.Add(time.Second)
+		evaluated(failing, tick)
+		evaluated(healthy, tick)
+		tick = tick.Add(time.Second)
+		fromFailing := evaluated(failing, tick)
+		require.NotZero(t, fromFailing)
+		require.Equal(t, len(rules), evaluated(healthy, tick)+fromFailing)
+	})
+}
+
+func TestSchedule_ruleOwnershipConcurrency(t *testing.T) {
+	sch := setupScheduler(t, nil, nil, nil, nil, nil)
+	sch.instanceID = "instance-1"
+	heartbeats := newFakeHeartbeatStore()
+	sch.heartbeatStore = heartbeats
+	sch.heartbeatTTL = time.Second
+	sch.shardingEnabled = true
+	key := models.GenerateRuleKey(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// the heartbeats of the other instance expire and come back, so the owner of the rule keeps changing.
+		now := sch.clock.Now()
+		for i := 0; i < 100; i++ {
+			now = now.Add(time.Second)
+			if i%2 == 0 {
+				_ = heartbeats.Heartbeat(ctx, "instance-0", now)
+			}
+			sch.updateLiveInstances(ctx, now)
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			if err := sch.ruleOwnership().checkOwner(key); err != nil {
+				require.ErrorIs(t, err, models.ErrAlertRuleNotOwned)
+				require.ErrorContains(t, err, "instance-0")
+			}
+		}
+	}
+}
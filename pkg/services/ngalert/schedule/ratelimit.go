@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// evaluationRateLimiter limits the number of evaluations of the rules of every organization per minute. Every
+// organization has a token bucket that holds a minute worth of evaluations and is refilled continuously, so that an
+// organization that was idle can evaluate in a burst. It is owned by the scheduler loop and is not safe for concurrent use.
+type evaluationRateLimiter struct {
+	defaultLimit int64
+	orgLimits    map[int64]int64
+	buckets      map[int64]*rate.Limiter
+}
+
+// newEvaluationRateLimiter returns a limiter with the default limit of evaluations per minute and the limits of
+// organizations that override it. Returns nil if no organization is limited.
+func newEvaluationRateLimiter(defaultLimit int64, orgLimits map[int64]int64) *evaluationRateLimiter {
+	limited := defaultLimit > 0
+	for _, limit := range orgLimits {
+		limited = limited || limit > 0
+	}
+	if !limited {
+		return nil
+	}
+	return &evaluationRateLimiter{
+		defaultLimit: defaultLimit,
+		orgLimits:    orgLimits,
+		buckets:      make(map[int64]*rate.Limiter),
+	}
+}
+
+// allow takes a token from the bucket of the organization at the given time, and returns false if the bucket is empty.
+func (l *evaluationRateLimiter) allow(orgID int64, now time.Time) bool {
+	limit, ok := l.orgLimits[orgID]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	if limit <= 0 {
+		return true
+	}
+	bucket, ok := l.buckets[orgID]
+	if !ok {
+		bucket = rate.NewLimiter(rate.Limit(float64(limit)/time.Minute.Seconds()), int(limit))
+		l.buckets[orgID] = bucket
+	}
+	return bucket.AllowN(now, 1)
+}
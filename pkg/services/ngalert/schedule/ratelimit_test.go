@@ -0,0 +1,50 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluationRateLimiter(t *testing.T) {
+	t.Run("should be nil if no organization is limited", func(t *testing.T) {
+		require.Nil(t, newEvaluationRateLimiter(0, nil))
+		require.Nil(t, newEvaluationRateLimiter(0, map[int64]int64{1: 0}))
+		require.NotNil(t, newEvaluationRateLimiter(0, map[int64]int64{1: 60}))
+	})
+
+	t.Run("should throttle at the limit and recover as the bucket refills", func(t *testing.T) {
+		l := newEvaluationRateLimiter(60, nil)
+		now := time.Unix(0, 0)
+		for i := 0; i < 60; i++ {
+			require.Truef(t, l.allow(1, now), "evaluation %d", i)
+		}
+		require.False(t, l.allow(1, now))
+		require.False(t, l.allow(1, now.Add(500*time.Millisecond)))
+
+		now = now.Add(time.Second)
+		require.True(t, l.allow(1, now))
+		require.False(t, l.allow(1, now))
+
+		// after an idle minute, a minute worth of evaluations is allowed in a burst
+		now = now.Add(time.Hour)
+		for i := 0; i < 60; i++ {
+			require.Truef(t, l.allow(1, now), "evaluation %d", i)
+		}
+		require.False(t, l.allow(1, now))
+	})
+
+	t.Run("should limit every organization separately", func(t *testing.T) {
+		l := newEvaluationRateLimiter(1, map[int64]int64{2: 2, 3: 0})
+		now := time.Unix(0, 0)
+		require.True(t, l.allow(1, now))
+		require.False(t, l.allow(1, now))
+		require.True(t, l.allow(2, now))
+		require.True(t, l.allow(2, now))
+		require.False(t, l.allow(2, now))
+		for i := 0; i < 100; i++ {
+			require.True(t, l.allow(3, now))
+		}
+	})
+}
@@ -6,15 +6,34 @@ import (
 	"sync"
 	"time"
 
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/util"
 )
 
 var errRuleDeleted = errors.New("rule deleted")
 
+const (
+	// maxCachedConditions is the maximum number of rules whose condition evaluators are kept between evaluations.
+	// The evaluators of the other rules are built at every evaluation.
+	maxCachedConditions = 10000
+	// cachedConditionMaxAge is how long a condition evaluator is reused. The evaluator holds the data sources of the
+	// queries as they were when it was built, so it is rebuilt from time to time to see changes to the data sources.
+	cachedConditionMaxAge = time.Minute
+)
+
 type alertRuleInfoRegistry struct {
 	mu            sync.Mutex
 	alertRuleInfo map[models.AlertRuleKey]*alertRuleInfo
+	// conditions are the condition evaluators of the rules, which are reused by the evaluations of the same version of
+	// the rule, so that its queries and expressions are not parsed again at every evaluation.
+	conditions map[models.AlertRuleKey]cachedCondition
+}
+
+type cachedCondition struct {
+	version   int64
+	evaluator eval.ConditionEvaluator
+	builtAt   time.Time
 }
 
 // getOrCreateInfo gets rule routine information from registry by the key. If it does not exist, it creates a new one.
@@ -31,6 +50,15 @@ func (r *alertRuleInfoRegistry) getOrCreateInfo(context context.Context, key mod
 	return info, !ok
 }
 
+// get returns rule routine information from registry by the key.
+func (r *alertRuleInfoRegistry) get(key models.AlertRuleKey) (*alertRuleInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	info, ok := r.alertRuleInfo[key]
+	return info, ok
+}
+
 func (r *alertRuleInfoRegistry) exists(key models.AlertRuleKey) bool {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -49,9 +77,50 @@ func (r *alertRuleInfoRegistry) del(key models.AlertRuleKey) (*alertRuleInfo, bo
 	if ok {
 		delete(r.alertRuleInfo, key)
 	}
+	delete(r.conditions, key)
 	return info, ok
 }
 
+// getCondition returns the condition evaluator of the version of the rule, or nil if it is not cached or it is older
+// than cachedConditionMaxAge.
+func (r *alertRuleInfoRegistry) getCondition(key models.AlertRuleKey, version int64, now time.Time) eval.ConditionEvaluator {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.conditions[key]
+	if !ok || c.version != version || now.Sub(c.builtAt) >= cachedConditionMaxAge {
+		return nil
+	}
+	return c.evaluator
+}
+
+// setCondition caches the condition evaluator of the version of the rule. It replaces the evaluator of any other
+// version. If the evaluators of maxCachedConditions rules are already cached, the evaluator is not cached.
+func (r *alertRuleInfoRegistry) setCondition(key models.AlertRuleKey, version int64, evaluator eval.ConditionEvaluator, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conditions == nil {
+		r.conditions = make(map[models.AlertRuleKey]cachedCondition)
+	}
+	if _, ok := r.conditions[key]; !ok && len(r.conditions) >= maxCachedConditions {
+		return
+	}
+	r.conditions[key] = cachedCondition{version: version, evaluator: evaluator, builtAt: now}
+}
+
+// dropCondition forgets the condition evaluator of the rule, when the rule is changed.
+func (r *alertRuleInfoRegistry) dropCondition(key models.AlertRuleKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.conditions, key)
+}
+
+// clearConditions forgets the condition evaluators of all rules, when the settings they were built with are changed.
+func (r *alertRuleInfoRegistry) clearConditions() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conditions = nil
+}
+
 func (r *alertRuleInfoRegistry) keyMap() map[models.AlertRuleKey]struct{} {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -62,22 +131,61 @@ func (r *alertRuleInfoRegistry) keyMap() map[models.AlertRuleKey]struct{} {
 	return definitionsIDs
 }
 
+// len returns the number of rule routines.
+func (r *alertRuleInfoRegistry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.alertRuleInfo)
+}
+
 type ruleVersion int64
 type ruleVersionAndPauseStatus struct {
 	Version  ruleVersion
 	IsPaused bool
 }
 
+// resetRequest is a request to reset the state of the instances of a rule. The number of reset instances is sent to done.
+type resetRequest struct {
+	cmd  models.ResetAlertInstancesCommand
+	done chan int
+}
+
 type alertRuleInfo struct {
 	evalCh   chan *evaluation
 	updateCh chan ruleVersionAndPauseStatus
+	resetCh  chan *resetRequest
 	ctx      context.Context
 	stop     func(reason error)
+
+	mtx            sync.Mutex
+	lastEvaluation evaluationStatus
+	// evaluationSeq is incremented by every completed evaluation, and savedSeq is its value when the last evaluation was saved to the database.
+	evaluationSeq uint64
+	savedSeq      uint64
+	// intervalAnchor is the number of the tick, to which the interval of the rule is aligned. Zero aligns it to the Unix epoch.
+	intervalAnchor int64
+	// throttledAt is the tick of the latest evaluation that was deferred since the latest completed evaluation, or zero.
+	throttledAt time.Time
+	// retryThrottled is true if an evaluation was deferred since the previous tick, and the rule is therefore due at the next one.
+	retryThrottled bool
+	// backoffUntil is the time before which the scheduled evaluations of the rule are skipped because it failed repeatedly, or zero.
+	backoffUntil time.Time
+	// owner is the scheduler instance that evaluated the rule at the last tick, and owned is true if it is this instance.
+	owner string
+	owned bool
+}
+
+// evaluationStatus describes the latest completed evaluation of a rule.
+type evaluationStatus struct {
+	scheduledAt time.Time
+	duration    time.Duration
+	state       eval.State
+	err         error
 }
 
 func newAlertRuleInfo(parent context.Context) *alertRuleInfo {
 	ctx, stop := util.WithCancelCause(parent)
-	return &alertRuleInfo{evalCh: make(chan *evaluation), updateCh: make(chan ruleVersionAndPauseStatus), ctx: ctx, stop: stop}
+	return &alertRuleInfo{evalCh: make(chan *evaluation), updateCh: make(chan ruleVersionAndPauseStatus), resetCh: make(chan *resetRequest), ctx: ctx, stop: stop}
 }
 
 // eval signals the rule evaluation routine to perform the evaluation of the rule. Does nothing if the loop is stopped.
@@ -126,16 +234,136 @@ func (a *alertRuleInfo) update(lastVersion ruleVersionAndPauseStatus) bool {
 	}
 }
 
+// reset sends the request to reset the state of the rule to the rule evaluation routine, which handles it between evaluations.
+// Returns false if the routine is stopped or ctx is done before the request is accepted.
+func (a *alertRuleInfo) reset(ctx context.Context, req *resetRequest) bool {
+	select {
+	case a.resetCh <- req:
+		return true
+	case <-a.ctx.Done():
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// setLastEvaluation records the result of the latest completed evaluation of the rule.
+func (a *alertRuleInfo) setLastEvaluation(status evaluationStatus) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.lastEvaluation = status
+	a.evaluationSeq++
+	a.throttledAt = time.Time{}
+}
+
+// setThrottled records that the evaluation of the rule scheduled at the given time was deferred to the next tick. The
+// result of the latest completed evaluation is kept.
+func (a *alertRuleInfo) setThrottled(scheduledAt time.Time) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.throttledAt = scheduledAt
+	a.retryThrottled = true
+}
+
+// getThrottledAt returns the tick of the latest evaluation that was deferred since the latest completed evaluation. The
+// time is zero if no evaluation was deferred.
+func (a *alertRuleInfo) getThrottledAt() time.Time {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.throttledAt
+}
+
+// takeRetry returns true if an evaluation of the rule was deferred since the previous call, and resets it.
+func (a *alertRuleInfo) takeRetry() bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	retry := a.retryThrottled
+	a.retryThrottled = false
+	return retry
+}
+
+// getUnsavedEvaluation returns the result of the latest completed evaluation of the rule and its sequence number,
+// or false if the rule has not been evaluated since the last time its evaluation was saved.
+func (a *alertRuleInfo) getUnsavedEvaluation() (evaluationStatus, uint64, bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.lastEvaluation, a.evaluationSeq, a.evaluationSeq > a.savedSeq
+}
+
+// markEvaluationSaved records that the evaluation with the given sequence number was saved to the database.
+func (a *alertRuleInfo) markEvaluationSaved(seq uint64) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if seq > a.savedSeq {
+		a.savedSeq = seq
+	}
+}
+
+// setBackoffUntil records the time before which the scheduled evaluations of the rule are skipped. Zero clears the backoff.
+func (a *alertRuleInfo) setBackoffUntil(until time.Time) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.backoffUntil = until
+}
+
+// getBackoffUntil returns the time before which the scheduled evaluations of the rule are skipped. The time is zero if the
+// rule is not backing off.
+func (a *alertRuleInfo) getBackoffUntil() time.Time {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.backoffUntil
+}
+
+// setOwner records the scheduler instance that evaluates the rule, and whether it is this instance.
+func (a *alertRuleInfo) setOwner(owner string, owned bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.owner = owner
+	a.owned = owned
+}
+
+// getOwner returns the scheduler instance that evaluated the rule at the last tick, and whether it is this instance.
+func (a *alertRuleInfo) getOwner() (string, bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.owner, a.owned
+}
+
+// setIntervalAnchor aligns the interval of the rule to the tick with the given number.
+func (a *alertRuleInfo) setIntervalAnchor(tickNum int64) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	a.intervalAnchor = tickNum
+}
+
+// getIntervalAnchor returns the number of the tick, to which the interval of the rule is aligned.
+func (a *alertRuleInfo) getIntervalAnchor() int64 {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.intervalAnchor
+}
+
+// getLastEvaluation returns the result of the latest completed evaluation of the rule. The time is zero if the rule has not been evaluated yet.
+func (a *alertRuleInfo) getLastEvaluation() evaluationStatus {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.lastEvaluation
+}
+
 type evaluation struct {
 	scheduledAt time.Time
 	rule        *models.AlertRule
 	folderTitle string
+	// forced is true if the evaluation was requested outside of the regular schedule.
+	forced bool
 }
 
 type alertRulesRegistry struct {
 	rules        map[models.AlertRuleKey]*models.AlertRule
 	folderTitles map[string]string
-	mu           sync.Mutex
+	// loaded is true after the registry was populated by set for the first time.
+	loaded bool
+	mu     sync.Mutex
 }
 
 // all returns all rules in the registry.
@@ -155,6 +383,13 @@ func (r *alertRulesRegistry) get(k models.AlertRuleKey) *models.AlertRule {
 	return r.rules[k]
 }
 
+// folderTitle returns the title of the folder with the given UID.
+func (r *alertRulesRegistry) folderTitle(namespaceUID string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.folderTitles[namespaceUID]
+}
+
 // set replaces all rules in the registry. Returns difference between previous and the new current version of the registry
 func (r *alertRulesRegistry) set(rules []*models.AlertRule, folders map[string]string) diff {
 	r.mu.Lock()
@@ -165,6 +400,7 @@ func (r *alertRulesRegistry) set(rules []*models.AlertRule, folders map[string]s
 	}
 	d := r.getDiff(rulesMap)
 	r.rules = rulesMap
+	r.loaded = true
 	// return the map as is without copying because it is not mutated
 	r.folderTitles = folders
 	return d
@@ -196,6 +432,12 @@ func (r *alertRulesRegistry) isEmpty() bool {
 	return len(r.rules) == 0
 }
 
+func (r *alertRulesRegistry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.rules)
+}
+
 func (r *alertRulesRegistry) needsUpdate(keys []models.AlertRuleKeyWithVersion) bool {
 	if len(r.rules) != len(keys) {
 		return true
@@ -211,6 +453,8 @@ func (r *alertRulesRegistry) needsUpdate(keys []models.AlertRuleKeyWithVersion)
 
 type diff struct {
 	updated map[models.AlertRuleKey]struct{}
+	// created contains rules that were added after the registry was populated for the first time.
+	created map[models.AlertRuleKey]struct{}
 }
 
 func (d diff) IsEmpty() bool {
@@ -222,14 +466,86 @@ func (d diff) IsEmpty() bool {
 func (r *alertRulesRegistry) getDiff(rules map[models.AlertRuleKey]*models.AlertRule) diff {
 	result := diff{
 		updated: map[models.AlertRuleKey]struct{}{},
+		created: map[models.AlertRuleKey]struct{}{},
 	}
 	for key, newRule := range rules {
 		oldRule, ok := r.rules[key]
-		if !ok || newRule.Version == oldRule.Version {
-			// a new rule or not updated
+		if !ok {
+			// the rules loaded for the first time are not considered new
+			if r.loaded {
+				result.created[key] = struct{}{}
+			}
+			continue
+		}
+		if newRule.Version == oldRule.Version {
 			continue
 		}
 		result.updated[key] = struct{}{}
 	}
 	return result
 }
+
+// evaluationTracker keeps track of rule evaluations that are currently running
+// so the scheduler can wait for them to complete when it is shut down.
+type evaluationTracker struct {
+	mu       sync.Mutex
+	stopping bool
+	running  int
+	// completed is the number of evaluations that completed after stop was called.
+	completed int
+	idle      chan struct{}
+}
+
+// start registers a new evaluation. Returns false if the tracker is stopped and the evaluation should not run.
+func (t *evaluationTracker) start() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopping {
+		return false
+	}
+	t.running++
+	return true
+}
+
+// done marks an evaluation registered by start as completed.
+func (t *evaluationTracker) done() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.running--
+	if !t.stopping {
+		return
+	}
+	t.completed++
+	if t.running == 0 {
+		close(t.idle)
+	}
+}
+
+// stop prevents new evaluations from starting. Returns a channel that is closed when all running evaluations complete.
+func (t *evaluationTracker) stop() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopping {
+		return t.idle
+	}
+	t.stopping = true
+	t.idle = make(chan struct{})
+	if t.running == 0 {
+		close(t.idle)
+	}
+	return t.idle
+}
+
+// stats returns the number of evaluations that completed after the tracker was stopped and the number of those that are still running.
+func (t *evaluationTracker) stats() (completed int, running int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.completed, t.running
+}
+
+// inFlight returns the number of evaluations that are currently running.
+func (t *evaluationTracker) inFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.running
+}
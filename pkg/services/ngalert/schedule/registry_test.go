@@ -2,6 +2,8 @@ package schedule
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"runtime"
@@ -12,6 +14,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -211,6 +215,25 @@ func TestSchedule_alertRuleInfo(t *testing.T) {
 			r.stop(nil)
 		})
 	})
+	t.Run("setThrottled should keep the latest completed evaluation", func(t *testing.T) {
+		r := newAlertRuleInfo(context.Background())
+		evaluatedAt := time.Unix(10, 0)
+		last := evaluationStatus{scheduledAt: evaluatedAt, duration: time.Second, state: eval.Error, err: errors.New("failed")}
+		r.setLastEvaluation(last)
+		_, seq, _ := r.getUnsavedEvaluation()
+
+		r.setThrottled(evaluatedAt.Add(time.Minute))
+
+		require.Equal(t, last, r.getLastEvaluation())
+		_, throttledSeq, _ := r.getUnsavedEvaluation()
+		require.Equal(t, seq, throttledSeq, "a deferred evaluation must not be saved as the latest evaluation")
+		require.Equal(t, evaluatedAt.Add(time.Minute), r.getThrottledAt())
+		require.True(t, r.takeRetry())
+		require.False(t, r.takeRetry())
+
+		r.setLastEvaluation(evaluationStatus{scheduledAt: evaluatedAt.Add(2 * time.Minute), state: eval.Normal})
+		require.True(t, r.getThrottledAt().IsZero())
+	})
 	t.Run("should be thread-safe", func(t *testing.T) {
 		r := newAlertRuleInfo(context.Background())
 		wg := sync.WaitGroup{}
@@ -368,3 +391,39 @@ func TestSchedulableAlertRulesRegistry_set(t *testing.T) {
 		require.Equal(t, expectedUpdated, diff.updated)
 	})
 }
+
+func TestAlertRuleInfoRegistry_conditions(t *testing.T) {
+	now := time.Now()
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	key := models.GenerateRuleKey(1)
+
+	t.Run("should return the condition only for the version it was built for", func(t *testing.T) {
+		r := alertRuleInfoRegistry{alertRuleInfo: make(map[models.AlertRuleKey]*alertRuleInfo)}
+		r.setCondition(key, 1, evaluator, now)
+		require.Equal(t, evaluator, r.getCondition(key, 1, now))
+		require.Nil(t, r.getCondition(key, 2, now))
+		require.Nil(t, r.getCondition(key, 1, now.Add(cachedConditionMaxAge)))
+		r.dropCondition(key)
+		require.Nil(t, r.getCondition(key, 1, now))
+	})
+
+	t.Run("should forget the condition when the rule is deleted", func(t *testing.T) {
+		r := alertRuleInfoRegistry{alertRuleInfo: make(map[models.AlertRuleKey]*alertRuleInfo)}
+		r.getOrCreateInfo(context.Background(), key)
+		r.setCondition(key, 1, evaluator, now)
+		r.del(key)
+		require.Nil(t, r.getCondition(key, 1, now))
+	})
+
+	t.Run("should not cache more than the maximum number of conditions", func(t *testing.T) {
+		r := alertRuleInfoRegistry{alertRuleInfo: make(map[models.AlertRuleKey]*alertRuleInfo)}
+		for i := 0; i < maxCachedConditions; i++ {
+			r.setCondition(models.AlertRuleKey{OrgID: 1, UID: fmt.Sprint(i)}, 1, evaluator, now)
+		}
+		r.setCondition(key, 1, evaluator, now)
+		require.Nil(t, r.getCondition(key, 1, now))
+		// the cached rules can still replace their conditions
+		r.setCondition(models.AlertRuleKey{OrgID: 1, UID: "0"}, 2, evaluator, now)
+		require.Equal(t, evaluator, r.getCondition(models.AlertRuleKey{OrgID: 1, UID: "0"}, 2, now))
+	})
+}
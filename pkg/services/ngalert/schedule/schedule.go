@@ -5,13 +5,18 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/benbjohnson/clock"
 	alertingModels "github.com/grafana/alerting/models"
 	"github.com/hashicorp/go-multierror"
+	"github.com/prometheus/client_golang/prometheus"
 	prometheusModel "github.com/prometheus/common/model"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -27,12 +32,43 @@ import (
 	"github.com/grafana/grafana/pkg/util/ticker"
 )
 
+const (
+	failureReasonDatasource    = eval.ErrorReasonDatasource
+	failureReasonExpression    = eval.ErrorReasonExpression
+	failureReasonTimeout       = eval.ErrorReasonTimeout
+	failureReasonPanic         = "panic"
+	failureReasonTooManySeries = "too_many_series"
+)
+
+// errEvaluationPanic is returned when the evaluation of a rule panicked.
+var errEvaluationPanic = errors.New("rule evaluation panicked")
+
 // ScheduleService is an interface for a service that schedules the evaluation
 // of alert rules.
 type ScheduleService interface {
 	// Run the scheduler until the context is canceled or the scheduler returns
 	// an error. The scheduler is terminated when this function returns.
 	Run(context.Context) error
+	// EvaluateNow requests an evaluation of the rule outside of its regular schedule.
+	EvaluateNow(key ngmodels.AlertRuleKey) error
+	// ResetState resets the state of the instances of the rule that match the command and returns the number of reset instances.
+	ResetState(ctx context.Context, cmd ngmodels.ResetAlertInstancesCommand) (int, error)
+	// PauseEvaluation stops launching new evaluations of all rules until ResumeEvaluation is called.
+	PauseEvaluation()
+	// ResumeEvaluation resumes evaluations paused by PauseEvaluation.
+	ResumeEvaluation()
+	// IsEvaluationPaused returns true if evaluations are paused by PauseEvaluation.
+	IsEvaluationPaused() bool
+	// Status returns the rules known to the scheduler and when they are evaluated next.
+	Status() definitions.SchedulerStatus
+	// Stats returns the state of the scheduler and the statistics of the evaluations since it started.
+	Stats() (definitions.SchedulerStats, definitions.EvaluationStats)
+	// ShadowReport returns the rules in shadow mode that disagreed with their legacy alerts.
+	ShadowReport() definitions.ShadowModeReport
+	// UpdateSettings changes the settings of the running scheduler. They are applied at the next tick.
+	UpdateSettings(settings RuntimeSettings)
+	// Liveness returns whether the scheduler loop runs and when it last completed a tick.
+	Liveness() (running bool, lastTick time.Time)
 }
 
 // AlertsSender is an interface for a service that is responsible for sending notifications to the end-user.
@@ -48,6 +84,18 @@ type RulesStore interface {
 	GetAlertRulesForScheduling(ctx context.Context, query *ngmodels.GetAlertRulesForSchedulingQuery) error
 }
 
+// HeartbeatStore is a store that keeps track of the scheduler instances that are alive.
+type HeartbeatStore interface {
+	Heartbeat(ctx context.Context, instanceID string, at time.Time) error
+	GetLiveInstances(ctx context.Context, since time.Time) ([]string, error)
+	DeleteHeartbeat(ctx context.Context, instanceID string) error
+}
+
+// EvaluationStore is a store that keeps the result of the latest evaluation of every alert rule.
+type EvaluationStore interface {
+	SaveAlertRuleEvaluations(ctx context.Context, evaluations ...ngmodels.AlertRuleEvaluation) error
+}
+
 type schedule struct {
 	// base tick rate (fastest possible configured check)
 	baseInterval time.Duration
@@ -55,7 +103,34 @@ type schedule struct {
 	// each alert rule gets its own channel and routine
 	registry alertRuleInfoRegistry
 
-	maxAttempts int64
+	// maxAttempts is the number of attempts to evaluate a rule. It can be changed at runtime by UpdateSettings.
+	maxAttempts atomic.Int64
+	// evaluationLimiter limits the number of evaluations that run at the same time.
+	evaluationLimiter *evaluationLimiter
+	// pendingSettings contains the settings passed to UpdateSettings that are not yet applied. They are applied at the next tick.
+	pendingSettings atomic.Pointer[RuntimeSettings]
+
+	// backoffThreshold is the number of consecutive failures after which a rule is evaluated less often. Zero disables the backoff.
+	backoffThreshold int64
+	backoffMax       time.Duration
+
+	// drainTimeout is how long the scheduler waits for in-flight evaluations on shutdown before cancelling them.
+	drainTimeout time.Duration
+	evaluations  evaluationTracker
+	// evaluationStats counts the evaluations since the scheduler started.
+	evaluationStats evaluationStats
+
+	// running is true while Run runs the scheduler loop.
+	running atomic.Bool
+	// lastTick is the Unix time in nanoseconds at which the loop last completed a tick, or started if it has not completed
+	// any tick yet.
+	lastTick atomic.Int64
+
+	// evaluationPaused stops launching new evaluations. It is kept only in memory, and therefore it is reset when Grafana restarts.
+	evaluationPaused atomic.Bool
+
+	// rateLimiter limits the evaluations of the rules of every organization per minute. It is nil if no organization is limited.
+	rateLimiter *evaluationRateLimiter
 
 	clock clock.Clock
 
@@ -91,12 +166,36 @@ type schedule struct {
 	// last evaluated.
 	schedulableAlertRules alertRulesRegistry
 
+	// instanceID identifies this scheduler among the instances that share the same database.
+	instanceID     string
+	heartbeatStore HeartbeatStore
+	heartbeatTTL   time.Duration
+	// shardingEnabled spreads the rules across the live instances instead of having a single instance evaluate all of them.
+	shardingEnabled bool
+	// ownershipMtx guards liveInstances and heartbeatFailed, because the API reads them to check the owner of a rule.
+	ownershipMtx sync.RWMutex
+	// liveInstances contains the IDs of the scheduler instances that were alive at the last tick, sorted in ascending order.
+	liveInstances []string
+	// heartbeatFailed is true if the heartbeat of this instance failed at the last tick.
+	heartbeatFailed bool
+
+	cronSchedules cronSchedules
+
+	// evaluationStore keeps the latest evaluations of rules. They are saved in batches at most once per evaluationSaveInterval.
+	evaluationStore        EvaluationStore
+	evaluationSaveInterval time.Duration
+	evaluationsSavedAt     time.Time
+
+	// shadow compares the rules in shadow mode with their legacy alerts. It is nil if the rules are not compared.
+	shadow *shadowComparator
+
 	tracer tracing.Tracer
 }
 
 // SchedulerCfg is the scheduler configuration.
 type SchedulerCfg struct {
 	MaxAttempts          int64
+	DrainTimeout         time.Duration
 	BaseInterval         time.Duration
 	C                    clock.Clock
 	MinRuleInterval      time.Duration
@@ -107,41 +206,172 @@ type SchedulerCfg struct {
 	Metrics              *metrics.Scheduler
 	AlertSender          AlertsSender
 	Tracer               tracing.Tracer
+	// HeartbeatStore enables coordination between scheduler instances that share the same database. If it is nil, this instance evaluates all rules.
+	HeartbeatStore HeartbeatStore
+	InstanceID     string
+	HeartbeatTTL   time.Duration
+	// ShardingEnabled spreads the rules across the instances that share the same database. Requires HeartbeatStore.
+	ShardingEnabled bool
+	// EvaluationBackoffThreshold is the number of consecutive failures after which a rule is evaluated less often. Zero disables the backoff.
+	EvaluationBackoffThreshold int64
+	EvaluationBackoffMax       time.Duration
+	// EvaluationStore persists the result of the latest evaluation of every rule. If it is nil, the results are kept only in memory.
+	EvaluationStore        EvaluationStore
+	EvaluationSaveInterval time.Duration
+	// LegacyAlertStore provides the state of the legacy alerts that the rules in shadow mode are compared with. If it is
+	// nil, the rules in shadow mode are not compared.
+	LegacyAlertStore    LegacyAlertStore
+	ShadowModeTolerance time.Duration
+	// EvaluationsPerMinuteLimit is the maximum number of evaluations of the rules of an organization per minute, and
+	// EvaluationsPerMinuteOrgLimits overrides it for organizations. Zero disables the limit.
+	EvaluationsPerMinuteLimit     int64
+	EvaluationsPerMinuteOrgLimits map[int64]int64
+	// MaxConcurrentEvaluations is the maximum number of evaluations that run at the same time. Zero disables the limit.
+	MaxConcurrentEvaluations int
 }
 
 // NewScheduler returns a new schedule.
 func NewScheduler(cfg SchedulerCfg, stateManager *state.Manager) *schedule {
 	sch := schedule{
-		registry:              alertRuleInfoRegistry{alertRuleInfo: make(map[ngmodels.AlertRuleKey]*alertRuleInfo)},
-		maxAttempts:           cfg.MaxAttempts,
-		clock:                 cfg.C,
-		baseInterval:          cfg.BaseInterval,
-		log:                   log.New("ngalert.scheduler"),
-		evaluatorFactory:      cfg.EvaluatorFactory,
-		ruleStore:             cfg.RuleStore,
-		metrics:               cfg.Metrics,
-		appURL:                cfg.AppURL,
-		disableGrafanaFolder:  cfg.DisableGrafanaFolder,
-		stateManager:          stateManager,
-		minRuleInterval:       cfg.MinRuleInterval,
-		schedulableAlertRules: alertRulesRegistry{rules: make(map[ngmodels.AlertRuleKey]*ngmodels.AlertRule)},
-		alertsSender:          cfg.AlertSender,
-		tracer:                cfg.Tracer,
+		registry:               alertRuleInfoRegistry{alertRuleInfo: make(map[ngmodels.AlertRuleKey]*alertRuleInfo)},
+		drainTimeout:           cfg.DrainTimeout,
+		clock:                  cfg.C,
+		baseInterval:           cfg.BaseInterval,
+		log:                    log.New("ngalert.scheduler"),
+		evaluatorFactory:       cfg.EvaluatorFactory,
+		ruleStore:              cfg.RuleStore,
+		metrics:                cfg.Metrics,
+		appURL:                 cfg.AppURL,
+		disableGrafanaFolder:   cfg.DisableGrafanaFolder,
+		stateManager:           stateManager,
+		minRuleInterval:        cfg.MinRuleInterval,
+		schedulableAlertRules:  alertRulesRegistry{rules: make(map[ngmodels.AlertRuleKey]*ngmodels.AlertRule)},
+		alertsSender:           cfg.AlertSender,
+		tracer:                 cfg.Tracer,
+		instanceID:             cfg.InstanceID,
+		heartbeatStore:         cfg.HeartbeatStore,
+		heartbeatTTL:           cfg.HeartbeatTTL,
+		shardingEnabled:        cfg.ShardingEnabled,
+		backoffThreshold:       cfg.EvaluationBackoffThreshold,
+		backoffMax:             cfg.EvaluationBackoffMax,
+		evaluationStore:        cfg.EvaluationStore,
+		evaluationSaveInterval: cfg.EvaluationSaveInterval,
+		rateLimiter:            newEvaluationRateLimiter(cfg.EvaluationsPerMinuteLimit, cfg.EvaluationsPerMinuteOrgLimits),
+		evaluationLimiter:      newEvaluationLimiter(cfg.MaxConcurrentEvaluations),
+	}
+	sch.maxAttempts.Store(cfg.MaxAttempts)
+	if cfg.LegacyAlertStore != nil {
+		sch.shadow = newShadowComparator(cfg.LegacyAlertStore, cfg.ShadowModeTolerance, sch.log.New("component", "shadow"), cfg.Metrics)
 	}
 
 	return &sch
 }
 
 func (sch *schedule) Run(ctx context.Context) error {
+	sch.lastTick.Store(sch.clock.Now().UnixNano())
+	sch.running.Store(true)
+	defer sch.running.Store(false)
 	t := ticker.New(sch.clock, sch.baseInterval, sch.metrics.Ticker)
 	defer t.Stop()
 
 	if err := sch.schedulePeriodic(ctx, t); err != nil {
 		sch.log.Error("Failure while running the rule evaluation loop", "error", err)
 	}
+	sch.deleteHeartbeat()
+	return nil
+}
+
+// EvaluateNow requests the rule routine to evaluate the rule as soon as it is free, in addition to the regular schedule.
+// The evaluation goes through the same pipeline as scheduled ones, i.e. it updates the state and sends notifications.
+// Returns ngmodels.ErrAlertRuleNotFound if the rule is not scheduled by this instance, ngmodels.ErrAlertRuleNotOwned if another
+// instance evaluates it, and ngmodels.ErrAlertRuleIsPaused if it is paused.
+func (sch *schedule) EvaluateNow(key ngmodels.AlertRuleKey) error {
+	if sch.IsEvaluationPaused() {
+		return ngmodels.ErrEvaluationPaused
+	}
+	// only the owner evaluates the rule, otherwise the forced evaluation would update the state of the rule on an instance
+	// that does not evaluate it, and its notifications would be sent twice.
+	if err := sch.ruleOwnership().checkOwner(key); err != nil {
+		return err
+	}
+	rule := sch.schedulableAlertRules.get(key)
+	if rule == nil {
+		return ngmodels.ErrAlertRuleNotFound
+	}
+	if rule.IsPaused {
+		return ngmodels.ErrAlertRuleIsPaused
+	}
+	ruleInfo, ok := sch.registry.get(key)
+	if !ok {
+		return ngmodels.ErrAlertRuleNotFound
+	}
+	e := &evaluation{
+		scheduledAt: sch.clock.Now(),
+		rule:        rule,
+		folderTitle: sch.schedulableAlertRules.folderTitle(rule.NamespaceUID),
+		forced:      true,
+	}
+	go func() {
+		if success, dropped := ruleInfo.eval(e); !success {
+			sch.log.Debug("Forced evaluation was not sent because the rule routine is stopped", key.LogContext()...)
+		} else if dropped != nil {
+			sch.log.Warn("Forced evaluation replaced an evaluation that was not consumed yet", append(key.LogContext(), "droppedTick", dropped.scheduledAt)...)
+		}
+	}()
+	sch.log.Info("Requested forced evaluation of the rule", key.LogContext()...)
 	return nil
 }
 
+// ResetState resets the state of the instances of the rule that match the command and returns the number of reset instances.
+// The reset is performed by the evaluation routine of the rule, so that it never runs concurrently with an evaluation of the
+// rule, and the next evaluation establishes the state of the instances from scratch.
+func (sch *schedule) ResetState(ctx context.Context, cmd ngmodels.ResetAlertInstancesCommand) (int, error) {
+	key := ngmodels.AlertRuleKey{OrgID: cmd.OrgID, UID: cmd.RuleUID}
+	// only the owner keeps the state of the rule, the reset on another instance would not resolve the alerts of the owner.
+	if err := sch.ruleOwnership().checkOwner(key); err != nil {
+		return 0, err
+	}
+	ruleInfo, ok := sch.registry.get(key)
+	if !ok {
+		return 0, ngmodels.ErrAlertRuleNotFound
+	}
+	req := &resetRequest{cmd: cmd, done: make(chan int, 1)}
+	if !ruleInfo.reset(ctx, req) {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, ngmodels.ErrAlertRuleNotFound
+	}
+	select {
+	case count := <-req.done:
+		return count, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// PauseEvaluation stops launching new evaluations of all rules. Evaluations that are already running are not interrupted,
+// and rules and their state are not changed. The pause is not persisted and does not survive a restart of Grafana.
+func (sch *schedule) PauseEvaluation() {
+	if !sch.evaluationPaused.Swap(true) {
+		sch.log.Warn("Evaluation of alert rules is paused")
+	}
+	sch.metrics.EvaluationPaused.Set(1)
+}
+
+// ResumeEvaluation resumes evaluations paused by PauseEvaluation starting from the next tick.
+func (sch *schedule) ResumeEvaluation() {
+	if sch.evaluationPaused.Swap(false) {
+		sch.log.Info("Evaluation of alert rules is resumed")
+	}
+	sch.metrics.EvaluationPaused.Set(0)
+}
+
+// IsEvaluationPaused returns true if evaluations are paused by PauseEvaluation.
+func (sch *schedule) IsEvaluationPaused() bool {
+	return sch.evaluationPaused.Load()
+}
+
 // deleteAlertRule stops evaluation of the rule, deletes it from active rules, and cleans up state cache.
 func (sch *schedule) deleteAlertRule(keys ...ngmodels.AlertRuleKey) {
 	for _, key := range keys {
@@ -160,13 +390,20 @@ func (sch *schedule) deleteAlertRule(keys ...ngmodels.AlertRuleKey) {
 		// stop rule evaluation
 		ruleInfo.stop(errRuleDeleted)
 	}
+	if sch.shadow != nil {
+		sch.shadow.forget(keys...)
+	}
 	// Our best bet at this point is that we update the metrics with what we hope to schedule in the next tick.
 	alertRules, _ := sch.schedulableAlertRules.all()
 	sch.updateRulesMetrics(alertRules)
 }
 
 func (sch *schedule) schedulePeriodic(ctx context.Context, t *ticker.T) error {
-	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	// Rule routines do not inherit the cancellation of ctx so that in-flight evaluations
+	// can complete, and persist their state, after the scheduler is asked to stop.
+	routinesCtx, cancelRoutines := context.WithCancel(context.Background())
+	defer cancelRoutines()
+	dispatcherGroup, routinesCtx := errgroup.WithContext(routinesCtx)
 	for {
 		select {
 		case tick := <-t.C:
@@ -177,17 +414,44 @@ func (sch *schedule) schedulePeriodic(ctx context.Context, t *ticker.T) error {
 			start := time.Now().Round(0)
 			sch.metrics.BehindSeconds.Set(start.Sub(tick).Seconds())
 
-			sch.processTick(ctx, dispatcherGroup, tick)
+			sch.applySettings()
+			sch.processTick(routinesCtx, dispatcherGroup, tick)
+			sch.saveEvaluations(ctx, tick, false)
+			sch.lastTick.Store(sch.clock.Now().UnixNano())
 
 			sch.metrics.SchedulePeriodicDuration.Observe(time.Since(start).Seconds())
 		case <-ctx.Done():
+			t.Stop()
+			sch.drain()
+			cancelRoutines()
 			// waiting for all rule evaluation routines to stop
 			waitErr := dispatcherGroup.Wait()
+			// save the evaluations that completed since the last save, including the drained ones.
+			saveCtx, cancelSave := context.WithTimeout(context.Background(), time.Minute)
+			sch.saveEvaluations(saveCtx, sch.clock.Now(), true)
+			cancelSave()
 			return waitErr
 		}
 	}
 }
 
+// drain stops new evaluations from starting and waits up to drainTimeout for the running ones to complete.
+func (sch *schedule) drain() {
+	idle := sch.evaluations.stop()
+	_, running := sch.evaluations.stats()
+	sch.log.Info("Waiting for in-flight evaluations to complete", "evaluations", running, "timeout", sch.drainTimeout)
+	select {
+	case <-idle:
+	case <-sch.clock.After(sch.drainTimeout):
+	}
+	completed, cancelled := sch.evaluations.stats()
+	if cancelled > 0 {
+		sch.log.Warn("Drain timeout exceeded, cancelling in-flight evaluations", "completed", completed, "cancelled", cancelled)
+		return
+	}
+	sch.log.Info("All in-flight evaluations completed", "completed", completed)
+}
+
 type readyToRunItem struct {
 	ruleInfo *alertRuleInfo
 	evaluation
@@ -220,6 +484,8 @@ func (sch *schedule) updateRulesMetrics(alertRules []*ngmodels.AlertRule) {
 func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.Group, tick time.Time) ([]readyToRunItem, map[ngmodels.AlertRuleKey]struct{}, []ngmodels.AlertRuleKeyWithVersion) {
 	tickNum := tick.Unix() / int64(sch.baseInterval.Seconds())
 
+	sch.updateLiveInstances(ctx, tick)
+
 	// update the local registry. If there was a difference between the previous state and the current new state, rulesDiff will contains keys of rules that were updated.
 	rulesDiff, err := sch.updateSchedulableAlertRules(ctx)
 	updated := rulesDiff.updated
@@ -241,9 +507,15 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 
 	sch.updateRulesMetrics(alertRules)
 
+	paused := sch.IsEvaluationPaused()
+	if paused {
+		sch.log.Debug("Skip evaluation of alert rules because it is paused", "tick", tick)
+	}
+
 	readyToRun := make([]readyToRunItem, 0)
 	updatedRules := make([]ngmodels.AlertRuleKeyWithVersion, 0, len(updated)) // this is needed for tests only
 	missingFolder := make(map[string][]string)
+	ownership := sch.ruleOwnership()
 	for _, item := range alertRules {
 		key := item.GetKey()
 		ruleInfo, newRoutine := sch.registry.getOrCreateInfo(ctx, key)
@@ -258,7 +530,7 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 
 		if newRoutine && !invalidInterval {
 			dispatcherGroup.Go(func() error {
-				return sch.ruleRoutine(ruleInfo.ctx, key, ruleInfo.evalCh, ruleInfo.updateCh)
+				return sch.ruleRoutine(ruleInfo.ctx, key, ruleInfo.evalCh, ruleInfo.updateCh, ruleInfo.resetCh)
 			})
 		}
 
@@ -269,8 +541,44 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 			continue
 		}
 
-		itemFrequency := item.IntervalSeconds / int64(sch.baseInterval.Seconds())
-		isReadyToRun := item.IntervalSeconds != 0 && tickNum%itemFrequency == 0
+		_, isUpdated := updated[key]
+		_, isCreated := rulesDiff.created[key]
+		if isUpdated {
+			sch.registry.dropCondition(key)
+		}
+		// evaluate new and updated rules at this tick so users do not have to wait for the entire interval to see the result.
+		// Changes made between two ticks result in a single evaluation.
+		saved := isUpdated || isCreated
+		// then the interval is counted from this tick.
+		if saved && item.Schedule == "" {
+			ruleInfo.setIntervalAnchor(tickNum)
+		}
+
+		var isDue bool
+		if item.Schedule != "" {
+			// rules with a cron schedule ignore the interval
+			cronSchedule, err := sch.cronSchedules.get(item)
+			if err != nil {
+				// this is expected to never happen given that we validate the schedule during alert rule updates
+				sch.log.Warn("Rule has an invalid schedule and will be ignored", append(key.LogContext(), "error", err)...)
+			} else {
+				isDue = saved || isDueByCron(cronSchedule, tick, sch.baseInterval)
+			}
+		} else {
+			itemFrequency := item.IntervalSeconds / int64(sch.baseInterval.Seconds())
+			isDue = saved || item.IntervalSeconds != 0 && (tickNum-ruleInfo.getIntervalAnchor())%itemFrequency == 0
+		}
+		// rules that were throttled since the previous tick are due at this one
+		if ruleInfo.takeRetry() {
+			isDue = true
+		}
+		owned := ownership.ownsRule(key)
+		ruleInfo.setOwner(ownership.owner(key), owned)
+		isReadyToRun := isDue && owned && !paused && !item.IsPaused
+		if isReadyToRun && sch.rateLimiter != nil && !sch.rateLimiter.allow(key.OrgID, tick) {
+			sch.throttle(key, ruleInfo, tick, throttleReasonRateLimit)
+			isReadyToRun = false
+		}
 		if isReadyToRun {
 			var folderTitle string
 			if !sch.disableGrafanaFolder {
@@ -287,9 +595,10 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 				folderTitle: folderTitle,
 			}})
 		}
-		if _, isUpdated := updated[key]; isUpdated && !isReadyToRun {
+		// paused rules are not evaluated. Notify a new routine anyway to clean up the state that could be left after the rule was paused.
+		if (isUpdated || (newRoutine && item.IsPaused)) && !isReadyToRun {
 			// if we do not need to eval the rule, check the whether rule was just updated and if it was, notify evaluation routine about that
-			sch.log.Debug("Rule has been updated. Notifying evaluation routine", key.LogContext()...)
+			sch.log.Debug("Rule has been updated. Notifying evaluation routine", append(key.LogContext(), "isPaused", item.IsPaused)...)
 			go func(ri *alertRuleInfo, rule *ngmodels.AlertRule) {
 				ri.update(ruleVersionAndPauseStatus{
 					Version:  ruleVersion(rule.Version),
@@ -306,6 +615,8 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 		delete(registeredDefinitions, key)
 	}
 
+	sch.cronSchedules.rotate()
+
 	if len(missingFolder) > 0 { // if this happens then there can be problems with fetching folders from the database.
 		sch.log.Warn("Unable to obtain folder titles for some rules", "missingFolderUIDToRuleUID", missingFolder)
 	}
@@ -342,7 +653,7 @@ func (sch *schedule) processTick(ctx context.Context, dispatcherGroup *errgroup.
 	return readyToRun, registeredDefinitions, updatedRules
 }
 
-func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertRuleKey, evalCh <-chan *evaluation, updateCh <-chan ruleVersionAndPauseStatus) error {
+func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertRuleKey, evalCh <-chan *evaluation, updateCh <-chan ruleVersionAndPauseStatus, resetCh <-chan *resetRequest) error {
 	grafanaCtx = ngmodels.WithRuleKey(grafanaCtx, key)
 	logger := sch.log.FromContext(grafanaCtx)
 	logger.Debug("Alert rule routine started")
@@ -351,8 +662,15 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 	evalTotal := sch.metrics.EvalTotal.WithLabelValues(orgID)
 	evalDuration := sch.metrics.EvalDuration.WithLabelValues(orgID)
 	evalTotalFailures := sch.metrics.EvalFailures.WithLabelValues(orgID)
+	evalFailuresByReason := sch.metrics.EvalFailuresByReason.MustCurryWith(prometheus.Labels{"org": orgID})
+	evalPanics := sch.metrics.EvalPanics.MustCurryWith(prometheus.Labels{"org": orgID})
 
+	// shadow is true if the rule is in shadow mode at its latest evaluation, and is therefore never notified
+	shadow := false
 	notify := func(states []state.StateTransition) {
+		if shadow {
+			return
+		}
 		expiredAlerts := FromAlertsStateToStoppedAlert(states, sch.appURL, sch.clock)
 		if len(expiredAlerts.PostableAlerts) > 0 {
 			sch.alertsSender.Send(key, expiredAlerts)
@@ -361,6 +679,9 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 
 	resetState := func(ctx context.Context, isPaused bool) {
 		rule := sch.schedulableAlertRules.get(key)
+		if rule != nil {
+			ctx = ngmodels.WithRule(ctx, rule)
+		}
 		reason := ngmodels.StateReasonUpdated
 		if isPaused {
 			reason = ngmodels.StateReasonPaused
@@ -369,8 +690,10 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 		notify(states)
 	}
 
-	evaluate := func(ctx context.Context, attempt int64, e *evaluation, span tracing.Span) {
-		logger := logger.New("version", e.rule.Version, "attempt", attempt, "now", e.scheduledAt)
+	evaluate := func(ctx context.Context, attempt int64, e *evaluation, span tracing.Span) error {
+		// the evaluator, the state manager and the notifications log with the context of the rule and the evaluation
+		ctx = ngmodels.WithRuleEvaluation(ctx, e.rule, e.scheduledAt)
+		logger := sch.log.FromContext(ctx).New("version", e.rule.Version, "attempt", attempt)
 		start := sch.clock.Now()
 
 		schedulerUser := &user.SignedInUser{
@@ -388,22 +711,33 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 			},
 		}
 		evalCtx := eval.Context(ctx, schedulerUser)
-		ruleEval, err := sch.evaluatorFactory.Create(evalCtx, e.rule.GetEvalCondition())
 		var results eval.Results
-		var dur time.Duration
-		if err != nil {
-			dur = sch.clock.Now().Sub(start)
-			logger.Error("Failed to build rule evaluator", "error", err)
-		} else {
+		err := recoverPanic(logger, evalPanics, func() error {
+			var err error
+			ruleEval := sch.registry.getCondition(key, e.rule.Version, start)
+			if ruleEval == nil {
+				ruleEval, err = sch.evaluatorFactory.Create(evalCtx, e.rule.GetEvalCondition())
+				if err != nil {
+					logger.Error("Failed to build rule evaluator", "error", err)
+					return err
+				}
+				sch.registry.setCondition(key, e.rule.Version, ruleEval, start)
+			}
 			results, err = ruleEval.Evaluate(ctx, e.scheduledAt)
-			dur = sch.clock.Now().Sub(start)
 			if err != nil {
-				logger.Error("Failed to evaluate rule", "error", err, "duration", dur)
+				logger.Error("Failed to evaluate rule", "error", err, "duration", sch.clock.Now().Sub(start))
 			}
+			return err
+		})
+		dur := sch.clock.Now().Sub(start)
+		if err == nil {
+			// the results that exceed the instance limit of the rule are dropped, and the evaluation is reported as failed
+			results, err = sch.stateManager.LimitResults(ctx, e.rule, results)
 		}
 
 		evalTotal.Inc()
 		evalDuration.Observe(dur.Seconds())
+		sch.evaluationStats.observe(dur, err != nil || results.HasErrors())
 
 		if err != nil || results.HasErrors() {
 			evalTotalFailures.Inc()
@@ -417,7 +751,11 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 					}
 				}
 			}
+			reason := evaluationFailureReason(err)
+			evalFailuresByReason.WithLabelValues(reason).Inc()
 			span.RecordError(err)
+			span.SetStatus(codes.Error, reason)
+			span.SetAttributes("error_reason", reason, attribute.String("error_reason", reason))
 			span.AddEvents(
 				[]string{"error", "message"},
 				[]tracing.EventValue{
@@ -433,11 +771,31 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 					{Num: int64(len(results))},
 				})
 		}
+		evalState := resultsState(results, err)
+		span.SetAttributes("state", evalState.String(), attribute.String("state", evalState.String()))
+		if info, ok := sch.registry.get(key); ok {
+			info.setLastEvaluation(evaluationStatus{
+				scheduledAt: e.scheduledAt,
+				duration:    dur,
+				state:       evalState,
+				err:         err,
+			})
+		}
 		if ctx.Err() != nil { // check if the context is not cancelled. The evaluation can be a long-running task.
 			logger.Debug("Skip updating the state because the context has been cancelled")
-			return
+			return err
+		}
+		// the state manager persists the states and notifies the contact points of the rule
+		stateCtx, stateSpan := sch.tracer.Start(ctx, "alert rule state update")
+		processedStates := sch.stateManager.ProcessEvalResults(stateCtx, e.scheduledAt, e.rule, results, sch.getRuleExtraLabels(e))
+		stateSpan.SetAttributes("state_transitions", len(processedStates), attribute.Int("state_transitions", len(processedStates)))
+		stateSpan.End()
+		if shadow {
+			if sch.shadow != nil {
+				sch.shadow.compare(ctx, e.rule, e.scheduledAt, isAlerting(processedStates))
+			}
+			return err
 		}
-		processedStates := sch.stateManager.ProcessEvalResults(ctx, e.scheduledAt, e.rule, results, sch.getRuleExtraLabels(e))
 		alerts := FromStateTransitionToPostableAlerts(processedStates, sch.stateManager, sch.appURL)
 		span.AddEvents(
 			[]string{"message", "state_transitions", "alerts_to_send"},
@@ -447,14 +805,18 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 				{Num: int64(len(alerts.PostableAlerts))},
 			})
 		if len(alerts.PostableAlerts) > 0 {
+			_, sendSpan := sch.tracer.Start(ctx, "alert rule notification")
+			sendSpan.SetAttributes("alerts", len(alerts.PostableAlerts), attribute.Int("alerts", len(alerts.PostableAlerts)))
 			sch.alertsSender.Send(key, alerts)
+			sendSpan.End()
 		}
+		return err
 	}
 
 	retryIfError := func(f func(attempt int64) error) error {
 		var attempt int64
 		var err error
-		for attempt = 0; attempt < sch.maxAttempts; attempt++ {
+		for attempt = 0; attempt < sch.maxAttempts.Load(); attempt++ {
 			err = f(attempt)
 			if err == nil {
 				return nil
@@ -464,6 +826,14 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 	}
 
 	evalRunning := false
+	backoff := &evaluationBackoff{threshold: sch.backoffThreshold, max: sch.backoffMax}
+	// resetBackoff clears the backoff of the rule, and publishes it to the status of the scheduler.
+	resetBackoff := func() {
+		backoff.reset()
+		if info, ok := sch.registry.get(key); ok {
+			info.setBackoffUntil(time.Time{})
+		}
+	}
 	var currentRuleVersion int64 = 0
 	defer sch.stopApplied(key)
 	for {
@@ -482,6 +852,16 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 			logger.Info("Clearing the state of the rule because it was updated", "version", currentRuleVersion, "newVersion", ctx.Version, "isPaused", ctx.IsPaused)
 			// clear the state. So the next evaluation will start from the scratch.
 			resetState(grafanaCtx, ctx.IsPaused)
+			resetBackoff()
+		// resetCh - used by external services (API) to reset the state of instances of the rule.
+		case req := <-resetCh:
+			var states []state.StateTransition
+			if rule := sch.schedulableAlertRules.get(key); rule != nil {
+				logger.Info("Resetting the state of the rule on request", "labelsHash", req.cmd.LabelsHash, "resetBy", req.cmd.ResetBy)
+				states = sch.stateManager.ResetAlertInstances(grafanaCtx, rule, &req.cmd)
+				notify(states)
+			}
+			req.done <- len(states)
 		// evalCh - used by the scheduler to signal that evaluation is needed.
 		case ctx, ok := <-evalCh:
 			if !ok {
@@ -491,14 +871,34 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 			if evalRunning {
 				continue
 			}
+			if !ctx.forced && backoff.skip(ctx.scheduledAt) {
+				logger.Debug("Skip evaluation because the rule failed repeatedly", "now", ctx.scheduledAt, "failures", backoff.failures, "nextEvaluation", backoff.nextEvaluation)
+				continue
+			}
+			if !sch.evaluationLimiter.tryAcquire() {
+				if info, ok := sch.registry.get(key); ok {
+					sch.throttle(key, info, ctx.scheduledAt, throttleReasonConcurrencyLimit)
+				}
+				continue
+			}
+			if !sch.evaluations.start() {
+				sch.evaluationLimiter.release()
+				logger.Debug("Skip evaluation because the scheduler is shutting down", "now", ctx.scheduledAt)
+				continue
+			}
+			sch.metrics.EvalInFlight.Inc()
 
 			func() {
 				evalRunning = true
 				defer func() {
 					evalRunning = false
+					sch.evaluations.done()
+					sch.evaluationLimiter.release()
+					sch.metrics.EvalInFlight.Dec()
 					sch.evalApplied(key, ctx.scheduledAt)
 				}()
 
+				shadow = sch.stateManager.IsShadow(ctx.rule)
 				err := retryIfError(func(attempt int64) error {
 					newVersion := ctx.rule.Version
 					isPaused := ctx.rule.IsPaused
@@ -511,6 +911,7 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 						if currentRuleVersion > 0 || isPaused {
 							logger.Debug("Got a new version of alert rule. Clear up the state and refresh extra labels", "version", currentRuleVersion, "newVersion", newVersion)
 							resetState(grafanaCtx, isPaused)
+							resetBackoff()
 						}
 						currentRuleVersion = newVersion
 					}
@@ -526,7 +927,16 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 					utcTick := ctx.scheduledAt.UTC().Format(time.RFC3339Nano)
 					span.SetAttributes("tick", utcTick, attribute.String("tick", utcTick))
 
-					evaluate(tracingCtx, attempt, ctx, span)
+					if err := evaluate(tracingCtx, attempt, ctx, span); err != nil {
+						if delay := backoff.failed(ctx.scheduledAt, time.Duration(ctx.rule.IntervalSeconds)*time.Second); delay > 0 {
+							logger.Warn("Rule failed repeatedly, delaying the next evaluation", "failures", backoff.failures, "delay", delay, "nextEvaluation", backoff.nextEvaluation)
+							if info, ok := sch.registry.get(key); ok {
+								info.setBackoffUntil(backoff.nextEvaluation)
+							}
+						}
+					} else {
+						resetBackoff()
+					}
 					return nil
 				})
 				if err != nil {
@@ -550,6 +960,24 @@ func (sch *schedule) ruleRoutine(grafanaCtx context.Context, key ngmodels.AlertR
 	}
 }
 
+const (
+	// throttleReasonRateLimit is the reason of the evaluations deferred because the organization of the rule exceeded
+	// its limit of evaluations per minute.
+	throttleReasonRateLimit = "rate_limit"
+	// throttleReasonConcurrencyLimit is the reason of the evaluations deferred because the maximum number of evaluations
+	// were running.
+	throttleReasonConcurrencyLimit = "concurrency_limit"
+)
+
+// throttle defers the evaluation of the rule scheduled at the given tick to the next tick for the given reason. It is the
+// only way the evaluations beyond the limits of the scheduler are handled, so that they are never delayed or dropped
+// without being counted.
+func (sch *schedule) throttle(key ngmodels.AlertRuleKey, info *alertRuleInfo, tick time.Time, reason string) {
+	sch.log.Debug("Evaluation deferred to the next tick", append(key.LogContext(), "tick", tick, "reason", reason)...)
+	sch.metrics.EvaluationThrottled.WithLabelValues(fmt.Sprint(key.OrgID), reason).Inc()
+	info.setThrottled(tick)
+}
+
 // evalApplied is only used on tests.
 func (sch *schedule) evalApplied(alertDefKey ngmodels.AlertRuleKey, now time.Time) {
 	if sch.evalAppliedFunc == nil {
@@ -568,6 +996,40 @@ func (sch *schedule) stopApplied(alertDefKey ngmodels.AlertRuleKey) {
 	sch.stopAppliedFunc(alertDefKey)
 }
 
+// recoverPanic calls f and converts a panic into errEvaluationPanic, so that a faulty datasource or expression does not crash
+// Grafana. The panic is logged with the stack trace and counted in the panics metric.
+func recoverPanic(logger log.Logger, panics *prometheus.CounterVec, f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Rule evaluation panicked", "panic", r, "stack", string(debug.Stack()))
+			panics.WithLabelValues().Inc()
+			err = fmt.Errorf("%w: %v", errEvaluationPanic, r)
+		}
+	}()
+	return f()
+}
+
+// resultsState summarizes the results of an evaluation as a single state: Error if the evaluation failed or any result has
+// an error, otherwise Alerting if any result is alerting, NoData if any result has no data, and Normal in all other cases.
+func resultsState(results eval.Results, err error) eval.State {
+	if err != nil {
+		return eval.Error
+	}
+	return results.State()
+}
+
+// evaluationFailureReason classifies the error of a failed evaluation for metrics.
+func evaluationFailureReason(err error) string {
+	switch {
+	case errors.Is(err, errEvaluationPanic):
+		return failureReasonPanic
+	case errors.Is(err, state.ErrTooManySeries):
+		return failureReasonTooManySeries
+	default:
+		return eval.ErrorReason(err)
+	}
+}
+
 func (sch *schedule) getRuleExtraLabels(evalCtx *evaluation) map[string]string {
 	extraLabels := make(map[string]string, 4)
 
@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
+	"net/http"
 	"net/url"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/benbjohnson/clock"
 	alertingModels "github.com/grafana/alerting/models"
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -19,18 +23,27 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	datasourcefakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
 	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
+	"github.com/grafana/grafana/pkg/util/ticker"
 )
 
 type evalAppliedInfo struct {
@@ -130,16 +143,21 @@ func TestProcessTicks(t *testing.T) {
 	alertRule2 := models.AlertRuleGen(models.WithOrgID(mainOrgID), models.WithInterval(3*cfg.BaseInterval), models.WithTitle("rule-2"))()
 	ruleStore.PutRule(ctx, alertRule2)
 
-	t.Run("on 2nd tick first alert rule should be evaluated", func(t *testing.T) {
+	t.Run("on 2nd tick both alert rules should be evaluated because the second one is new", func(t *testing.T) {
 		tick = tick.Add(cfg.BaseInterval)
 		scheduled, stopped, updated := sched.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 2)
+		var keys []models.AlertRuleKey
+		for _, item := range scheduled {
+			keys = append(keys, item.rule.GetKey())
+			require.Equal(t, tick, item.scheduledAt)
+		}
+		require.Contains(t, keys, alertRule1.GetKey())
+		require.Contains(t, keys, alertRule2.GetKey())
 
-		require.Len(t, scheduled, 1)
-		require.Equal(t, alertRule1, scheduled[0].rule)
-		require.Equal(t, tick, scheduled[0].scheduledAt)
 		require.Emptyf(t, stopped, "None rules are expected to be stopped")
 		require.Emptyf(t, updated, "None rules are expected to be updated")
-		assertEvalRun(t, evalAppliedCh, tick, alertRule1.GetKey())
+		assertEvalRun(t, evalAppliedCh, tick, keys...)
 	})
 
 	t.Run("after 2nd tick rule metrics should report two active alert rules", func(t *testing.T) {
@@ -154,21 +172,16 @@ func TestProcessTicks(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	t.Run("on 3rd tick two alert rules should be evaluated", func(t *testing.T) {
+	t.Run("on 3rd tick only one alert rule should be evaluated", func(t *testing.T) {
 		tick = tick.Add(cfg.BaseInterval)
 		scheduled, stopped, updated := sched.processTick(ctx, dispatcherGroup, tick)
-		require.Len(t, scheduled, 2)
-		var keys []models.AlertRuleKey
-		for _, item := range scheduled {
-			keys = append(keys, item.rule.GetKey())
-			require.Equal(t, tick, item.scheduledAt)
-		}
-		require.Contains(t, keys, alertRule1.GetKey())
-		require.Contains(t, keys, alertRule2.GetKey())
 
+		require.Len(t, scheduled, 1)
+		require.Equal(t, alertRule1, scheduled[0].rule)
+		require.Equal(t, tick, scheduled[0].scheduledAt)
 		require.Emptyf(t, stopped, "None rules are expected to be stopped")
 		require.Emptyf(t, updated, "None rules are expected to be updated")
-		assertEvalRun(t, evalAppliedCh, tick, keys...)
+		assertEvalRun(t, evalAppliedCh, tick, alertRule1.GetKey())
 	})
 
 	t.Run("on 4th tick only one alert rule should be evaluated", func(t *testing.T) {
@@ -183,19 +196,20 @@ func TestProcessTicks(t *testing.T) {
 		assertEvalRun(t, evalAppliedCh, tick, alertRule1.GetKey())
 	})
 
-	t.Run("on 5th tick an alert rule is paused (it still enters evaluation but it is early skipped)", func(t *testing.T) {
+	t.Run("on 5th tick an alert rule is paused and should not be evaluated", func(t *testing.T) {
 		tick = tick.Add(cfg.BaseInterval)
 
 		alertRule1.IsPaused = true
 
 		scheduled, stopped, updated := sched.processTick(ctx, dispatcherGroup, tick)
 
+		// the interval of the second rule is counted from the 2nd tick, when it was created
 		require.Len(t, scheduled, 1)
-		require.Equal(t, alertRule1, scheduled[0].rule)
+		require.Equal(t, alertRule2, scheduled[0].rule)
 		require.Equal(t, tick, scheduled[0].scheduledAt)
 		require.Emptyf(t, stopped, "None rules are expected to be stopped")
 		require.Emptyf(t, updated, "None rules are expected to be updated")
-		assertEvalRun(t, evalAppliedCh, tick, alertRule1.GetKey())
+		assertEvalRun(t, evalAppliedCh, tick, alertRule2.GetKey())
 	})
 
 	t.Run("after 5th tick rule metrics should report one active and one paused alert rules", func(t *testing.T) {
@@ -210,25 +224,16 @@ func TestProcessTicks(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	t.Run("on 6th tick all alert rule are paused (it still enters evaluation but it is early skipped)", func(t *testing.T) {
+	t.Run("on 6th tick all alert rule are paused and should not be evaluated", func(t *testing.T) {
 		tick = tick.Add(cfg.BaseInterval)
 
 		alertRule2.IsPaused = true
 
 		scheduled, stopped, updated := sched.processTick(ctx, dispatcherGroup, tick)
 
-		require.Len(t, scheduled, 2)
-		var keys []models.AlertRuleKey
-		for _, item := range scheduled {
-			keys = append(keys, item.rule.GetKey())
-			require.Equal(t, tick, item.scheduledAt)
-		}
-		require.Contains(t, keys, alertRule1.GetKey())
-		require.Contains(t, keys, alertRule2.GetKey())
-
+		require.Empty(t, scheduled)
 		require.Emptyf(t, stopped, "None rules are expected to be stopped")
 		require.Emptyf(t, updated, "None rules are expected to be updated")
-		assertEvalRun(t, evalAppliedCh, tick, keys...)
 	})
 
 	t.Run("after 6th tick rule metrics should report two paused alert rules", func(t *testing.T) {
@@ -278,12 +283,14 @@ func TestProcessTicks(t *testing.T) {
 
 		scheduled, stopped, updated := sched.processTick(ctx, dispatcherGroup, tick)
 
-		require.Empty(t, scheduled)
+		require.Len(t, scheduled, 1)
+		require.Equal(t, alertRule2, scheduled[0].rule)
 		require.Len(t, stopped, 1)
 		require.Emptyf(t, updated, "None rules are expected to be updated")
 		require.Contains(t, stopped, alertRule1.GetKey())
 
 		assertStopRun(t, stopAppliedCh, alertRule1.GetKey())
+		assertEvalRun(t, evalAppliedCh, tick, alertRule2.GetKey())
 	})
 
 	t.Run("after 8th tick rule metrics should report one active alert rule", func(t *testing.T) {
@@ -298,17 +305,14 @@ func TestProcessTicks(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	t.Run("on 9th tick one alert rule should be evaluated", func(t *testing.T) {
+	t.Run("on 9th tick no alert rule should be evaluated", func(t *testing.T) {
 		tick = tick.Add(cfg.BaseInterval)
 
 		scheduled, stopped, updated := sched.processTick(ctx, dispatcherGroup, tick)
 
-		require.Len(t, scheduled, 1)
-		require.Equal(t, alertRule2, scheduled[0].rule)
-		require.Equal(t, tick, scheduled[0].scheduledAt)
+		require.Empty(t, scheduled)
 		require.Emptyf(t, stopped, "None rules are expected to be stopped")
 		require.Emptyf(t, updated, "None rules are expected to be updated")
-		assertEvalRun(t, evalAppliedCh, tick, alertRule2.GetKey())
 	})
 
 	// create alert rule with one base interval
@@ -337,13 +341,13 @@ func TestProcessTicks(t *testing.T) {
 
 		ruleStore.PutRule(context.Background(), newRule2)
 
+		// updated rules are evaluated immediately. While evaluation is paused, the routine is only notified about the update.
+		sched.PauseEvaluation()
 		tick = tick.Add(cfg.BaseInterval)
 		scheduled, stopped, updated := sched.processTick(ctx, dispatcherGroup, tick)
+		sched.ResumeEvaluation()
 
-		require.Len(t, scheduled, 1)
-		require.Equal(t, alertRule3, scheduled[0].rule)
-		require.Equal(t, tick, scheduled[0].scheduledAt)
-
+		require.Empty(t, scheduled)
 		require.Emptyf(t, stopped, "None rules are expected to be stopped")
 
 		require.Len(t, updated, 1)
@@ -384,7 +388,7 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 			go func() {
 				ctx, cancel := context.WithCancel(context.Background())
 				t.Cleanup(cancel)
-				_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus))
+				_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
 			}()
 
 			expectedTime := time.UnixMicro(rand.Int63())
@@ -496,7 +500,7 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 
 			ctx, cancel := context.WithCancel(context.Background())
 			go func() {
-				err := sch.ruleRoutine(ctx, models.AlertRuleKey{}, make(chan *evaluation), make(chan ruleVersionAndPauseStatus))
+				err := sch.ruleRoutine(ctx, models.AlertRuleKey{}, make(chan *evaluation), make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
 				stoppedChan <- err
 			}()
 
@@ -515,7 +519,7 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 
 			ctx, cancel := util.WithCancelCause(context.Background())
 			go func() {
-				err := sch.ruleRoutine(ctx, rule.GetKey(), make(chan *evaluation), make(chan ruleVersionAndPauseStatus))
+				err := sch.ruleRoutine(ctx, rule.GetKey(), make(chan *evaluation), make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
 				stoppedChan <- err
 			}()
 
@@ -544,7 +548,7 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 		go func() {
 			ctx, cancel := context.WithCancel(context.Background())
 			t.Cleanup(cancel)
-			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, updateChan)
+			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, updateChan, make(chan *resetRequest))
 		}()
 
 		// init evaluation loop so it got the rule version
@@ -624,7 +628,7 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 		go func() {
 			ctx, cancel := context.WithCancel(context.Background())
 			t.Cleanup(cancel)
-			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus))
+			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
 		}()
 
 		evalChan <- &evaluation{
@@ -694,7 +698,7 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 			go func() {
 				ctx, cancel := context.WithCancel(context.Background())
 				t.Cleanup(cancel)
-				_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus))
+				_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
 			}()
 
 			evalChan <- &evaluation{
@@ -727,7 +731,7 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 		go func() {
 			ctx, cancel := context.WithCancel(context.Background())
 			t.Cleanup(cancel)
-			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus))
+			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
 		}()
 
 		evalChan <- &evaluation{
@@ -743,6 +747,994 @@ func TestSchedule_ruleRoutine(t *testing.T) {
 	})
 }
 
+func TestSchedule_evaluationMetrics(t *testing.T) {
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, expr.QueryError{RefID: "A", Err: errors.New("datasource is down")}).Once()
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, fmt.Errorf("failed to execute query: %w", context.DeadlineExceeded)).Once()
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, errors.New("failed to execute expression")).Once()
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{}, State: eval.Normal}}, nil).Once()
+
+	reg := prometheus.NewPedanticRegistry()
+	sch := setupScheduler(t, nil, nil, reg, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	evalAppliedChan := make(chan time.Time)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+		evalAppliedChan <- t
+	}
+
+	rule := models.AlertRuleGen(models.WithOrgID(1))()
+	sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+	evalChan := make(chan *evaluation)
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
+	}()
+
+	for i := 0; i < 4; i++ {
+		evalChan <- &evaluation{
+			scheduledAt: sch.clock.Now(),
+			rule:        rule,
+		}
+		waitForTimeChannel(t, evalAppliedChan)
+	}
+
+	expectedMetric := `
+		# HELP grafana_alerting_rule_evaluation_failures_by_reason_total The total number of rule evaluation failures by the class of the error.
+		# TYPE grafana_alerting_rule_evaluation_failures_by_reason_total counter
+		grafana_alerting_rule_evaluation_failures_by_reason_total{org="1",reason="datasource"} 1
+		grafana_alerting_rule_evaluation_failures_by_reason_total{org="1",reason="expression"} 1
+		grafana_alerting_rule_evaluation_failures_by_reason_total{org="1",reason="timeout"} 1
+		# HELP grafana_alerting_rule_evaluation_failures_total The total number of rule evaluation failures.
+		# TYPE grafana_alerting_rule_evaluation_failures_total counter
+		grafana_alerting_rule_evaluation_failures_total{org="1"} 3
+		# HELP grafana_alerting_rule_evaluations_in_flight The number of rule evaluations that are currently running.
+		# TYPE grafana_alerting_rule_evaluations_in_flight gauge
+		grafana_alerting_rule_evaluations_in_flight 0
+		# HELP grafana_alerting_rule_evaluations_total The total number of rule evaluations.
+		# TYPE grafana_alerting_rule_evaluations_total counter
+		grafana_alerting_rule_evaluations_total{org="1"} 4
+`
+	err := testutil.GatherAndCompare(reg, bytes.NewBufferString(expectedMetric),
+		"grafana_alerting_rule_evaluation_failures_by_reason_total",
+		"grafana_alerting_rule_evaluation_failures_total",
+		"grafana_alerting_rule_evaluations_in_flight",
+		"grafana_alerting_rule_evaluations_total",
+	)
+	require.NoError(t, err)
+}
+
+// logRecorder records the key/value pairs of the log lines written to it.
+type logRecorder struct {
+	mtx   sync.Mutex
+	lines []map[string]interface{}
+}
+
+func (r *logRecorder) Log(keyvals ...interface{}) error {
+	line := make(map[string]interface{}, len(keyvals)/2)
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		line[fmt.Sprint(keyvals[i])] = keyvals[i+1]
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.lines = append(r.lines, line)
+	return nil
+}
+
+// find returns the first line with the message, or nil if there is none.
+func (r *logRecorder) find(msg string) map[string]interface{} {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, line := range r.lines {
+		if line["msg"] == msg {
+			return line
+		}
+	}
+	return nil
+}
+
+// registerRuleLogContext registers the contextual log provider of the rules once for all tests, as the service does at
+// startup.
+var registerRuleLogContext sync.Once
+
+func TestSchedule_ruleLogContext(t *testing.T) {
+	registerRuleLogContext.Do(func() {
+		log.RegisterContextualLogProvider(models.RuleLogContextFromContext)
+	})
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, errors.New("failed to execute query")).Once()
+
+	sch := setupScheduler(t, nil, nil, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	recorder := &logRecorder{}
+	logger := log.NewNopLogger()
+	logger.Swap(recorder)
+	sch.log = logger
+	evalAppliedChan := make(chan time.Time)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+		evalAppliedChan <- t
+	}
+
+	rule := models.AlertRuleGen()()
+	sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+	evalChan := make(chan *evaluation)
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
+	}()
+	scheduledAt := sch.clock.Now()
+	evalChan <- &evaluation{
+		scheduledAt: scheduledAt,
+		rule:        rule,
+	}
+	waitForTimeChannel(t, evalAppliedChan)
+
+	line := recorder.find("Failed to evaluate rule")
+	require.NotNil(t, line)
+	require.Equal(t, "error", fmt.Sprint(line["level"]))
+	require.Equal(t, rule.OrgID, line["org_id"])
+	require.Equal(t, rule.UID, line["rule_uid"])
+	require.Equal(t, rule.ID, line["rule_id"])
+	require.Equal(t, rule.Title, line["rule_title"])
+	require.Equal(t, scheduledAt, line["eval_time"])
+}
+
+func TestSchedule_inMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	ruleStore := fakes.NewRuleStore(t)
+	sch := setupScheduler(t, ruleStore, ruleStore, nil, nil, nil)
+	evalAppliedChan := make(chan time.Time)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+		evalAppliedChan <- t
+	}
+
+	rule := models.AlertRuleGen(withQueryForState(t, eval.Alerting))()
+	_, err := ruleStore.InsertAlertRules(ctx, []models.AlertRule{*rule})
+	require.NoError(t, err)
+	_, err = sch.updateSchedulableAlertRules(ctx)
+	require.NoError(t, err)
+	scheduled := sch.schedulableAlertRules.get(rule.GetKey())
+	require.NotNil(t, scheduled)
+	require.EqualValues(t, 1, scheduled.Version)
+
+	evalChan := make(chan *evaluation)
+	go func() {
+		ctx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
+	}()
+	evalChan <- &evaluation{scheduledAt: sch.clock.Now(), rule: scheduled}
+	waitForTimeChannel(t, evalAppliedChan)
+
+	instances, err := ruleStore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	require.Equal(t, models.InstanceStateFiring, instances[0].CurrentState)
+
+	t.Run("deleting the rule should delete its instances", func(t *testing.T) {
+		require.NoError(t, ruleStore.DeleteAlertRulesByUID(ctx, rule.OrgID, rule.UID))
+		_, err = sch.updateSchedulableAlertRules(ctx)
+		require.NoError(t, err)
+		require.Nil(t, sch.schedulableAlertRules.get(rule.GetKey()))
+		instances, err := ruleStore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		require.Empty(t, instances)
+	})
+}
+
+func TestSchedule_notAllowedDatasource(t *testing.T) {
+	cacheService := &datasourcefakes.FakeCacheService{DataSources: []*datasources.DataSource{{UID: "pii", Type: "mysql"}}}
+	cfg := setting.UnifiedAlertingSettings{AllowedDatasources: []string{"prometheus"}}
+	factory := eval.NewEvaluatorFactory(cfg, cacheService, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, nil), &plugins.FakePluginStore{})
+	sch := setupScheduler(t, nil, nil, nil, nil, factory)
+	evalAppliedChan := make(chan time.Time)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+		evalAppliedChan <- t
+	}
+
+	query := models.GenerateAlertQuery()
+	query.DatasourceUID = "pii"
+	rule := models.AlertRuleGen(func(rule *models.AlertRule) {
+		rule.Condition = "B"
+		rule.Data = []models.AlertQuery{query, models.CreateClassicConditionExpression("B", query.RefID, "last", "gt", 1)}
+		rule.ExecErrState = models.ErrorErrState
+	})()
+	sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+	evalChan := make(chan *evaluation)
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
+	}()
+	evalChan <- &evaluation{scheduledAt: sch.clock.Now(), rule: rule}
+	waitForTimeChannel(t, evalAppliedChan)
+
+	states := sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID)
+	require.Len(t, states, 1)
+	require.Equal(t, eval.Error, states[0].State)
+	require.ErrorAs(t, states[0].Error, &eval.DatasourceNotAllowedError{})
+	failures := sch.metrics.EvalFailuresByReason.WithLabelValues(fmt.Sprint(rule.OrgID), eval.ErrorReasonDatasourceNotAllowed)
+	require.Equal(t, float64(1), testutil.ToFloat64(failures))
+}
+
+type spanContextKey struct{}
+
+// recordedSpan is a span with its parent, if it was started in the context of another span.
+type recordedSpan struct {
+	*tracing.FakeSpan
+	parent *recordedSpan
+}
+
+// recordingTracer records the spans that are started, with their parents.
+type recordingTracer struct {
+	mtx   sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Run(context.Context) error {
+	return nil
+}
+
+func (t *recordingTracer) Start(ctx context.Context, spanName string, _ ...trace.SpanStartOption) (context.Context, tracing.Span) {
+	parent, _ := ctx.Value(spanContextKey{}).(*recordedSpan)
+	span := &recordedSpan{
+		FakeSpan: &tracing.FakeSpan{Name: spanName, Attributes: map[attribute.Key]attribute.Value{}, Events: map[string]tracing.EventValue{}},
+		parent:   parent,
+	}
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+func (t *recordingTracer) Inject(context.Context, http.Header, tracing.Span) {
+}
+
+// tree returns the names of the spans, each prefixed with the names of its ancestors.
+func (t *recordingTracer) tree() []string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	result := make([]string, 0, len(t.spans))
+	for _, span := range t.spans {
+		name := span.Name
+		for p := span.parent; p != nil; p = p.parent {
+			name = p.Name + " > " + name
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+func (t *recordingTracer) find(name string) *recordedSpan {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	for _, span := range t.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestSchedule_evaluationSpans(t *testing.T) {
+	evaluate := func(t *testing.T, tracer *recordingTracer, evaluatorFactory eval.EvaluatorFactory, rule *models.AlertRule) {
+		t.Helper()
+		sch := setupScheduler(t, nil, nil, nil, nil, evaluatorFactory)
+		sch.tracer = tracer
+		evalAppliedChan := make(chan time.Time)
+		sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+			evalAppliedChan <- t
+		}
+		sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+		evalChan := make(chan *evaluation)
+		go func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			t.Cleanup(cancel)
+			_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
+		}()
+		evalChan <- &evaluation{
+			scheduledAt: sch.clock.Now(),
+			rule:        rule,
+		}
+		waitForTimeChannel(t, evalAppliedChan)
+	}
+
+	t.Run("should trace the queries, expressions, state update and notification of a successful evaluation", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		factory := eval.NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, nil, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, tracer), &plugins.FakePluginStore{})
+		rule := models.AlertRuleGen(withQueryForState(t, eval.Alerting))()
+		evaluate(t, tracer, factory, rule)
+
+		require.Equal(t, []string{
+			"alert rule execution",
+			"alert rule execution > SSE.ExecuteExpression",
+			"alert rule execution > alert rule state update",
+			"alert rule execution > alert rule notification",
+		}, tracer.tree())
+
+		span := tracer.find("alert rule execution")
+		require.Equal(t, rule.UID, span.Attributes["rule_uid"].AsString())
+		require.Equal(t, rule.OrgID, span.Attributes["org_id"].AsInt64())
+		require.Equal(t, eval.Alerting.String(), span.Attributes["state"].AsString())
+		require.Equal(t, codes.Unset, span.StatusCode)
+		require.Equal(t, "A", tracer.find("SSE.ExecuteExpression").Attributes["node.refId"].AsString())
+		for _, s := range tracer.spans {
+			require.Truef(t, s.IsEnded(), "span %s is not ended", s.Name)
+		}
+	})
+
+	t.Run("should tag the evaluation span with the classification of the error of a failed evaluation", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+		evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, expr.QueryError{RefID: "A", Err: errors.New("datasource is down")}).Once()
+		rule := models.AlertRuleGen()()
+		evaluate(t, tracer, eval_mocks.NewEvaluatorFactory(evaluator), rule)
+
+		span := tracer.find("alert rule execution")
+		require.NotNil(t, span)
+		require.Equal(t, codes.Error, span.StatusCode)
+		require.Equal(t, "datasource", span.Description)
+		require.Equal(t, "datasource", span.Attributes["error_reason"].AsString())
+		require.Equal(t, eval.Error.String(), span.Attributes["state"].AsString())
+		require.ErrorContains(t, span.Err, "datasource is down")
+		require.Equal(t, span, tracer.find("alert rule state update").parent)
+	})
+}
+
+// panickingEvaluator is a condition evaluator that panics, like a faulty datasource plugin would.
+type panickingEvaluator struct{}
+
+func (panickingEvaluator) EvaluateRaw(ctx context.Context, now time.Time) (*backend.QueryDataResponse, error) {
+	panic("datasource plugin crashed")
+}
+
+func (panickingEvaluator) Evaluate(ctx context.Context, now time.Time) (eval.Results, error) {
+	panic("datasource plugin crashed")
+}
+
+// evaluatorByCondition is an evaluator factory that returns the evaluator registered for the condition of the rule.
+type evaluatorByCondition map[string]eval.ConditionEvaluator
+
+func (f evaluatorByCondition) Validate(ctx eval.EvaluationContext, condition models.Condition) error {
+	return nil
+}
+
+func (f evaluatorByCondition) Create(ctx eval.EvaluationContext, condition models.Condition) (eval.ConditionEvaluator, error) {
+	return f[condition.Condition], nil
+}
+
+func TestSchedule_evaluationPanic(t *testing.T) {
+	healthyEvaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	healthyEvaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{}, State: eval.Normal}}, nil).Times(3)
+
+	panicRule := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(time.Second), models.WithIsPaused(false))()
+	panicRule.Condition = "panic"
+	panicRule.ExecErrState = models.ErrorErrState
+	healthyRule := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(time.Second), models.WithIsPaused(false))()
+	healthyRule.Condition = "healthy"
+	ruleStore := newFakeRulesStore()
+	ruleStore.PutRule(context.Background(), panicRule, healthyRule)
+
+	reg := prometheus.NewPedanticRegistry()
+	sch := setupScheduler(t, ruleStore, nil, reg, nil, evaluatorByCondition{
+		panicRule.Condition:   panickingEvaluator{},
+		healthyRule.Condition: healthyEvaluator,
+	})
+	sch.backoffThreshold = 1
+	sch.backoffMax = time.Hour
+	evalAppliedCh := make(chan evalAppliedInfo, 2)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, tick time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: key, now: tick}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	tick := sch.clock.Now()
+
+	t.Run("should convert panic to an evaluation error", func(t *testing.T) {
+		tick = tick.Add(time.Second)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 2)
+		assertEvalRun(t, evalAppliedCh, tick, panicRule.GetKey(), healthyRule.GetKey())
+
+		states := sch.stateManager.GetStatesForRuleUID(panicRule.OrgID, panicRule.UID)
+		require.Len(t, states, 1)
+		require.Equal(t, eval.Error, states[0].State)
+		require.ErrorIs(t, states[0].Error, errEvaluationPanic)
+
+		expectedMetric := `
+		# HELP grafana_alerting_rule_evaluation_panics_total The total number of rule evaluations that panicked.
+		# TYPE grafana_alerting_rule_evaluation_panics_total counter
+		grafana_alerting_rule_evaluation_panics_total{org="1"} 1
+		# HELP grafana_alerting_rule_evaluation_failures_by_reason_total The total number of rule evaluation failures by the class of the error.
+		# TYPE grafana_alerting_rule_evaluation_failures_by_reason_total counter
+		grafana_alerting_rule_evaluation_failures_by_reason_total{org="1",reason="panic"} 1
+		`
+		require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(expectedMetric),
+			"grafana_alerting_rule_evaluation_panics_total",
+			"grafana_alerting_rule_evaluation_failures_by_reason_total",
+		))
+	})
+
+	t.Run("should back off the panicking rule and keep evaluating other rules", func(t *testing.T) {
+		// the first delay is one interval, so the rule panics again at the next tick and is then delayed by two intervals.
+		tick = tick.Add(time.Second)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 2)
+		assertEvalRun(t, evalAppliedCh, tick, panicRule.GetKey(), healthyRule.GetKey())
+
+		tick = tick.Add(time.Second)
+		scheduled, _, _ = sch.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 2)
+		assertEvalRun(t, evalAppliedCh, tick, healthyRule.GetKey())
+		require.Empty(t, evalAppliedCh)
+	})
+}
+
+func TestSchedule_evaluationBackoff(t *testing.T) {
+	const interval = 10 * time.Second
+	failure := errors.New("datasource not found")
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, failure).Times(6)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{}, State: eval.Normal}}, nil).Times(3)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, failure).Times(2)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{}, State: eval.Normal}}, nil).Once()
+
+	sch := setupScheduler(t, nil, nil, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	sch.backoffThreshold = 2
+	sch.backoffMax = time.Hour
+
+	var mtx sync.Mutex
+	var evaluated []int
+	start := sch.clock.Now()
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		evaluated = append(evaluated, int(t.Sub(start)/interval))
+	}
+	evaluatedTicks := func() []int {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return append([]int(nil), evaluated...)
+	}
+
+	rule := models.AlertRuleGen(models.WithInterval(interval))()
+	rule.ExecErrState = models.ErrorErrState
+	sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+	evalChan := make(chan *evaluation)
+	updateChan := make(chan ruleVersionAndPauseStatus)
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		_ = sch.ruleRoutine(ctx, rule.GetKey(), evalChan, updateChan, make(chan *resetRequest))
+	}()
+	tick := func(from, to int, rule *models.AlertRule) {
+		for i := from; i <= to; i++ {
+			evalChan <- &evaluation{scheduledAt: start.Add(time.Duration(i) * interval), rule: rule}
+		}
+	}
+
+	t.Run("should stretch ticks after consecutive failures and snap back after success", func(t *testing.T) {
+		tick(0, 28, rule)
+		// the delay starts at one interval after the second failure and doubles with every further failure: 1, 2, 4, 8
+		// intervals, and then it is capped at 10 intervals.
+		expected := []int{0, 1, 2, 4, 8, 16, 26, 27, 28}
+		require.Eventuallyf(t, func() bool {
+			return len(evaluatedTicks()) == len(expected)
+		}, time.Second, 10*time.Millisecond, "expected evaluations at ticks %v but got %v", expected, evaluatedTicks())
+		require.Equal(t, expected, evaluatedTicks())
+	})
+
+	t.Run("should reset backoff when rule is updated", func(t *testing.T) {
+		tick(29, 30, rule)
+		expected := []int{0, 1, 2, 4, 8, 16, 26, 27, 28, 29, 30}
+		require.Eventuallyf(t, func() bool {
+			return len(evaluatedTicks()) == len(expected)
+		}, time.Second, 10*time.Millisecond, "expected evaluations at ticks %v but got %v", expected, evaluatedTicks())
+		// the rule stays in Error state while backing off.
+		states := sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, states, 1)
+		require.Equal(t, eval.Error, states[0].State)
+
+		updated := models.CopyRule(rule)
+		updated.Version++
+		sch.schedulableAlertRules.update(updated)
+		updateChan <- ruleVersionAndPauseStatus{Version: ruleVersion(updated.Version)}
+		tick(31, 31, updated)
+		expected = []int{0, 1, 2, 4, 8, 16, 26, 27, 28, 29, 30, 31}
+		require.Eventuallyf(t, func() bool {
+			return len(evaluatedTicks()) == len(expected)
+		}, time.Second, 10*time.Millisecond, "expected evaluations at ticks %v but got %v", expected, evaluatedTicks())
+		require.Equal(t, expected, evaluatedTicks())
+	})
+}
+
+// countingEvaluatorFactory counts the condition evaluators that are built.
+type countingEvaluatorFactory struct {
+	eval.EvaluatorFactory
+	mtx     sync.Mutex
+	created int
+}
+
+func (f *countingEvaluatorFactory) Create(ctx eval.EvaluationContext, condition models.Condition) (eval.ConditionEvaluator, error) {
+	f.mtx.Lock()
+	f.created++
+	f.mtx.Unlock()
+	return f.EvaluatorFactory.Create(ctx, condition)
+}
+
+func (f *countingEvaluatorFactory) createdCount() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.created
+}
+
+func newExpressionsEvaluatorFactory() eval.EvaluatorFactory {
+	return eval.NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, nil, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, nil), &plugins.FakePluginStore{})
+}
+
+func TestSchedule_conditionCache(t *testing.T) {
+	ruleStore := newFakeRulesStore()
+	factory := &countingEvaluatorFactory{EvaluatorFactory: newExpressionsEvaluatorFactory()}
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, factory)
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, now time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: key, now: now}
+	}
+
+	rule := models.AlertRuleGen(models.WithInterval(time.Second), models.WithIsPaused(false), withQueryForState(t, eval.Normal), func(rule *models.AlertRule) {
+		rule.Schedule = ""
+	})()
+	ruleStore.PutRule(context.Background(), rule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	tick := sch.clock.Now()
+	evaluate := func(t *testing.T) eval.State {
+		t.Helper()
+		tick = tick.Add(time.Second)
+		sch.processTick(ctx, dispatcherGroup, tick)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+		states := sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, states, 1)
+		return states[0].State
+	}
+
+	t.Run("should build the condition once for the evaluations of the same version of the rule", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			require.Equal(t, eval.Normal, evaluate(t))
+		}
+		require.Equal(t, 1, factory.createdCount())
+	})
+
+	t.Run("should use the new condition at the next evaluation after the rule is updated", func(t *testing.T) {
+		updated := models.CopyRule(rule)
+		withQueryForState(t, eval.Alerting)(updated)
+		updated.Version++
+		ruleStore.PutRule(context.Background(), updated)
+
+		require.Equal(t, eval.Alerting, evaluate(t))
+		require.Equal(t, eval.Alerting, evaluate(t))
+		require.Equal(t, 2, factory.createdCount())
+	})
+
+	t.Run("should rebuild the condition when it is too old", func(t *testing.T) {
+		sch.clock.(*clock.Mock).Add(cachedConditionMaxAge)
+		require.Equal(t, eval.Alerting, evaluate(t))
+		require.Equal(t, 3, factory.createdCount())
+	})
+}
+
+// BenchmarkRuleEvaluation evaluates 1000 rules at 10 ticks, reusing the conditions that are built at the first tick, or
+// building them at every tick as the scheduler did before it cached them.
+func BenchmarkRuleEvaluation(b *testing.B) {
+	const rulesCount = 1000
+	const ticks = 10
+	sch := setupScheduler(b, nil, nil, nil, nil, newExpressionsEvaluatorFactory())
+	var wg sync.WaitGroup
+	sch.evalAppliedFunc = func(models.AlertRuleKey, time.Time) {
+		wg.Done()
+	}
+	rules := models.GenerateAlertRules(rulesCount, models.AlertRuleGen(models.WithUniqueID(), models.WithIsPaused(false), withQueryForState(b, eval.Normal), func(rule *models.AlertRule) {
+		rule.For = 0
+	}))
+	sch.schedulableAlertRules.set(rules, map[string]string{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.Cleanup(cancel)
+	evalChans := make([]chan *evaluation, 0, len(rules))
+	for _, rule := range rules {
+		evalCh := make(chan *evaluation)
+		evalChans = append(evalChans, evalCh)
+		go func(key models.AlertRuleKey) {
+			_ = sch.ruleRoutine(ctx, key, evalCh, make(chan ruleVersionAndPauseStatus), make(chan *resetRequest))
+		}(rule.GetKey())
+	}
+
+	run := func(cached bool) func(b *testing.B) {
+		return func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				for i := 0; i < ticks; i++ {
+					tick := sch.clock.Now().Add(time.Duration(n*ticks+i) * time.Second)
+					wg.Add(len(rules))
+					for j, rule := range rules {
+						if !cached {
+							sch.registry.dropCondition(rule.GetKey())
+						}
+						evalChans[j] <- &evaluation{scheduledAt: tick, rule: rule}
+					}
+					wg.Wait()
+				}
+			}
+		}
+	}
+	b.Run("building the conditions at every tick", run(false))
+	b.Run("reusing the conditions", run(true))
+}
+
+func TestSchedule_pauseEvaluation(t *testing.T) {
+	ruleStore := newFakeRulesStore()
+	reg := prometheus.NewPedanticRegistry()
+	sch := setupScheduler(t, ruleStore, nil, reg, nil, eval_mocks.NewFailingEvaluatorFactory(nil))
+	rules := models.GenerateAlertRules(5, models.AlertRuleGen(models.WithInterval(time.Second)))
+	ruleStore.PutRule(context.Background(), rules...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	tick := sch.clock.Now()
+
+	assertPausedMetric := func(t *testing.T, value int) {
+		t.Helper()
+		expectedMetric := fmt.Sprintf(`
+		# HELP grafana_alerting_schedule_evaluation_paused Whether evaluation of all alert rules is paused by an administrator (1) or not (0).
+		# TYPE grafana_alerting_schedule_evaluation_paused gauge
+		grafana_alerting_schedule_evaluation_paused %d
+`, value)
+		require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(expectedMetric), "grafana_alerting_schedule_evaluation_paused"))
+	}
+
+	tick = tick.Add(time.Second)
+	scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+	require.Len(t, scheduled, len(rules))
+
+	t.Run("should not evaluate rules while paused", func(t *testing.T) {
+		sch.PauseEvaluation()
+		require.True(t, sch.IsEvaluationPaused())
+		assertPausedMetric(t, 1)
+		for i := 0; i < 5; i++ {
+			tick = tick.Add(time.Second)
+			scheduled, stopped, _ := sch.processTick(ctx, dispatcherGroup, tick)
+			require.Empty(t, scheduled)
+			require.Empty(t, stopped)
+		}
+		require.ErrorIs(t, sch.EvaluateNow(rules[0].GetKey()), models.ErrEvaluationPaused)
+		// rules are still known to the scheduler.
+		for _, rule := range rules {
+			require.True(t, sch.registry.exists(rule.GetKey()))
+		}
+	})
+
+	t.Run("should evaluate rules on the next tick after resume", func(t *testing.T) {
+		sch.ResumeEvaluation()
+		require.False(t, sch.IsEvaluationPaused())
+		assertPausedMetric(t, 0)
+		tick = tick.Add(time.Second)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, len(rules))
+	})
+}
+
+func TestSchedule_evaluationRateLimit(t *testing.T) {
+	const limitedOrg, unlimitedOrg = int64(1), int64(2)
+	ruleStore := newFakeRulesStore()
+	reg := prometheus.NewPedanticRegistry()
+	sch := setupScheduler(t, ruleStore, nil, reg, nil, eval_mocks.NewFailingEvaluatorFactory(nil))
+	// the bucket of the limited organization is refilled with one evaluation per second
+	sch.rateLimiter = newEvaluationRateLimiter(60, map[int64]int64{unlimitedOrg: 0})
+	limited := models.GenerateAlertRules(70, models.AlertRuleGen(models.WithOrgID(limitedOrg), models.WithInterval(time.Minute), models.WithIsPaused(false)))
+	unlimited := models.GenerateAlertRules(70, models.AlertRuleGen(models.WithOrgID(unlimitedOrg), models.WithInterval(time.Minute), models.WithIsPaused(false)))
+	ruleStore.PutRule(context.Background(), append(limited, unlimited...)...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	tick := sch.clock.Now()
+
+	countByOrg := func(scheduled []readyToRunItem) map[int64]int {
+		result := make(map[int64]int)
+		for _, item := range scheduled {
+			result[item.rule.OrgID]++
+		}
+		return result
+	}
+	assertThrottledMetric := func(t *testing.T, value int) {
+		t.Helper()
+		expectedMetric := fmt.Sprintf(`
+		# HELP grafana_alerting_schedule_rule_evaluations_throttled_total The total number of rule evaluations deferred to the next tick because the organization exceeded its limit of evaluations per minute (rate_limit), or the maximum number of evaluations were running (concurrency_limit).
+		# TYPE grafana_alerting_schedule_rule_evaluations_throttled_total counter
+		grafana_alerting_schedule_rule_evaluations_throttled_total{org="%d",reason="rate_limit"} %d
+`, limitedOrg, value)
+		require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(expectedMetric), "grafana_alerting_schedule_rule_evaluations_throttled_total"))
+	}
+
+	t.Run("should defer the evaluations beyond the limit", func(t *testing.T) {
+		// the rules are due at every minute
+		tick = tick.Add(time.Minute)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Equal(t, map[int64]int{limitedOrg: 60, unlimitedOrg: 70}, countByOrg(scheduled))
+		assertThrottledMetric(t, 10)
+
+		throttled := 0
+		for _, rule := range sch.Status().Rules {
+			if rule.ThrottledAt != nil {
+				require.Equal(t, limitedOrg, rule.OrgID)
+				require.Equal(t, tick, *rule.ThrottledAt)
+				throttled++
+			}
+		}
+		require.Equal(t, 10, throttled)
+	})
+
+	t.Run("should evaluate the deferred rules at the next tick within the limit", func(t *testing.T) {
+		tick = tick.Add(time.Second)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Equal(t, map[int64]int{limitedOrg: 1}, countByOrg(scheduled))
+		assertThrottledMetric(t, 19)
+	})
+
+	t.Run("should recover once the bucket is refilled", func(t *testing.T) {
+		tick = tick.Add(10 * time.Second)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Equal(t, map[int64]int{limitedOrg: 9}, countByOrg(scheduled))
+		assertThrottledMetric(t, 19)
+
+		tick = tick.Add(time.Second)
+		scheduled, _, _ = sch.processTick(ctx, dispatcherGroup, tick)
+		require.Empty(t, scheduled)
+	})
+}
+
+func TestSchedule_maxConcurrentEvaluations(t *testing.T) {
+	ruleStore := newFakeRulesStore()
+	evaluator := newBlockingEvaluator()
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	sch.evaluationLimiter.setLimit(1)
+	rules := models.GenerateAlertRules(2, models.AlertRuleGen(models.WithInterval(time.Second), models.WithIsPaused(false), models.WithFor(0)))
+	ruleStore.PutRule(context.Background(), rules...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tick := &ticker.T{C: make(chan time.Time)}
+	done := make(chan error, 1)
+	go func() {
+		done <- sch.schedulePeriodic(ctx, tick)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		close(evaluator.release)
+		// the evaluations that were dispatched in the meantime do not block on the started channel.
+		go func() {
+			for range evaluator.started {
+			}
+		}()
+		<-done
+	})
+	waitForStart := func(t *testing.T) {
+		t.Helper()
+		select {
+		case <-evaluator.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("evaluation did not start")
+		}
+	}
+
+	now := sch.clock.Now()
+	tick.C <- now
+	waitForStart(t)
+
+	t.Run("should defer the evaluations beyond the limit to the next tick", func(t *testing.T) {
+		var throttledOrg int64
+		require.Eventually(t, func() bool {
+			for _, rule := range sch.Status().Rules {
+				if rule.ThrottledAt != nil {
+					throttledOrg = rule.OrgID
+					return rule.ThrottledAt.Equal(now)
+				}
+			}
+			return false
+		}, 5*time.Second, 10*time.Millisecond)
+		status := sch.Status()
+		require.Equal(t, 1, status.MaxConcurrentEvaluations)
+		require.Equal(t, 1, status.InFlightEvaluations)
+		throttled := testutil.ToFloat64(sch.metrics.EvaluationThrottled.WithLabelValues(fmt.Sprint(throttledOrg), throttleReasonConcurrencyLimit))
+		require.Equal(t, 1.0, throttled)
+	})
+
+	t.Run("should apply the updated settings at the next tick", func(t *testing.T) {
+		sch.UpdateSettings(RuntimeSettings{MaxAttempts: 2, MaxConcurrentEvaluations: 2})
+		require.Equal(t, 1, sch.Status().MaxConcurrentEvaluations)
+		require.EqualValues(t, 1, sch.maxAttempts.Load())
+
+		tick.C <- now.Add(time.Second)
+		waitForStart(t)
+		status := sch.Status()
+		require.Equal(t, 2, status.MaxConcurrentEvaluations)
+		require.Equal(t, 2, status.InFlightEvaluations)
+		require.EqualValues(t, 2, sch.maxAttempts.Load())
+	})
+}
+
+func TestSchedule_pausedRule(t *testing.T) {
+	ruleStore := newFakeRulesStore()
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewFailingEvaluatorFactory(nil))
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, tick time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: key, now: tick}
+	}
+	rule := models.AlertRuleGen(models.WithInterval(time.Second))()
+	ruleStore.PutRule(context.Background(), rule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+	tick := sch.clock.Now()
+
+	tick = tick.Add(time.Second)
+	scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+	require.Len(t, scheduled, 1)
+	assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+
+	t.Run("should not evaluate rule after it is paused", func(t *testing.T) {
+		paused := models.CopyRule(rule)
+		paused.IsPaused = true
+		paused.Version++
+		ruleStore.PutRule(context.Background(), paused)
+
+		tick = tick.Add(time.Second)
+		scheduled, stopped, updated := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Empty(t, scheduled)
+		require.Empty(t, stopped)
+		require.Equal(t, []models.AlertRuleKeyWithVersion{{Version: paused.Version, AlertRuleKey: paused.GetKey()}}, updated)
+
+		for i := 0; i < 5; i++ {
+			tick = tick.Add(time.Second)
+			scheduled, stopped, _ := sch.processTick(ctx, dispatcherGroup, tick)
+			require.Empty(t, scheduled)
+			require.Empty(t, stopped)
+		}
+		require.Empty(t, evalAppliedCh)
+	})
+
+	t.Run("should resume evaluation on the next tick after rule is unpaused", func(t *testing.T) {
+		unpaused := models.CopyRule(rule)
+		unpaused.IsPaused = false
+		unpaused.Version += 2
+		ruleStore.PutRule(context.Background(), unpaused)
+
+		tick = tick.Add(time.Second)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 1)
+		require.Equal(t, unpaused, scheduled[0].rule)
+		assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+	})
+}
+
+func TestSchedule_cronSchedule(t *testing.T) {
+	ruleStore := newFakeRulesStore()
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewFailingEvaluatorFactory(nil))
+	// at 08:00 on weekdays in Berlin, that is 07:00 UTC before and 06:00 UTC after the DST transition on Sunday 2023-03-26.
+	cronRule := models.AlertRuleGen(models.WithInterval(time.Minute), models.WithIsPaused(false))()
+	cronRule.Schedule = "0 8 * * 1-5"
+	cronRule.ScheduleTimezone = "Europe/Berlin"
+	intervalRule := models.AlertRuleGen(models.WithInterval(time.Second), models.WithIsPaused(false))()
+	ruleStore.PutRule(context.Background(), cronRule, intervalRule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	testCases := []struct {
+		tick     time.Time
+		expected bool
+	}{
+		{tick: time.Date(2023, 3, 24, 6, 59, 59, 0, time.UTC), expected: false},
+		{tick: time.Date(2023, 3, 24, 7, 0, 0, 0, time.UTC), expected: true},
+		{tick: time.Date(2023, 3, 24, 7, 0, 1, 0, time.UTC), expected: false},
+		{tick: time.Date(2023, 3, 24, 7, 1, 0, 0, time.UTC), expected: false},
+		{tick: time.Date(2023, 3, 25, 7, 0, 0, 0, time.UTC), expected: false},
+		{tick: time.Date(2023, 3, 27, 6, 0, 0, 0, time.UTC), expected: true},
+		{tick: time.Date(2023, 3, 27, 7, 0, 0, 0, time.UTC), expected: false},
+		{tick: time.Date(2023, 3, 28, 6, 0, 0, 0, time.UTC), expected: true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.tick.String(), func(t *testing.T) {
+			sch.clock.(*clock.Mock).Set(testCase.tick)
+			scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, testCase.tick)
+			keys := make([]models.AlertRuleKey, 0, len(scheduled))
+			for _, item := range scheduled {
+				keys = append(keys, item.rule.GetKey())
+			}
+			// the rule without a schedule falls back to its interval and is evaluated at every tick.
+			require.Contains(t, keys, intervalRule.GetKey())
+			if testCase.expected {
+				require.Contains(t, keys, cronRule.GetKey())
+			} else {
+				require.NotContains(t, keys, cronRule.GetKey())
+			}
+		})
+	}
+
+	t.Run("should ignore rule with invalid schedule", func(t *testing.T) {
+		invalid := models.CopyRule(cronRule)
+		invalid.Schedule = "not a schedule"
+		invalid.Version++
+		ruleStore.PutRule(context.Background(), invalid)
+
+		tick := time.Date(2023, 3, 29, 6, 0, 0, 0, time.UTC)
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+		require.Len(t, scheduled, 1)
+		require.Equal(t, intervalRule.GetKey(), scheduled[0].rule.GetKey())
+	})
+}
+
+func TestSchedule_evaluateSavedRule(t *testing.T) {
+	ruleStore := newFakeRulesStore()
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewFailingEvaluatorFactory(nil))
+	rule := models.AlertRuleGen(models.WithInterval(10*time.Second), models.WithIsPaused(false))()
+	ruleStore.PutRule(context.Background(), rule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	// evaluatedAt runs ticks from..to seconds and returns the ticks at which the rule was scheduled
+	evaluatedAt := func(from, to int64) []int64 {
+		var result []int64
+		for i := from; i <= to; i++ {
+			scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, time.Unix(i, 0))
+			for _, item := range scheduled {
+				if item.rule.GetKey() == rule.GetKey() {
+					result = append(result, i)
+				}
+			}
+		}
+		return result
+	}
+
+	// rules that exist when the scheduler starts are aligned to the interval
+	require.Equal(t, []int64{10, 20}, evaluatedAt(10, 22))
+
+	t.Run("should evaluate updated rule at the next tick and count the interval from it", func(t *testing.T) {
+		updated := models.CopyRule(rule)
+		updated.Version++
+		ruleStore.PutRule(context.Background(), updated)
+		rule = updated
+
+		require.Equal(t, []int64{23, 33, 43}, evaluatedAt(23, 50))
+
+		sch.clock.(*clock.Mock).Set(time.Unix(50, 0))
+		status := sch.Status()
+		require.Len(t, status.Rules, 1)
+		require.Equal(t, time.Unix(53, 0).UTC(), status.Rules[0].NextEvaluation.UTC())
+	})
+
+	t.Run("should evaluate rule once if it is updated several times between ticks", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			updated := models.CopyRule(rule)
+			updated.Version++
+			ruleStore.PutRule(context.Background(), updated)
+			rule = updated
+		}
+
+		scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, time.Unix(51, 0))
+		require.Len(t, scheduled, 1)
+		require.Equal(t, rule, scheduled[0].rule)
+		require.Equal(t, []int64{61}, evaluatedAt(52, 61))
+	})
+}
+
 func TestSchedule_deleteAlertRule(t *testing.T) {
 	t.Run("when rule exists", func(t *testing.T) {
 		t.Run("it should stop evaluation loop and remove the controller from registry", func(t *testing.T) {
@@ -764,7 +1756,402 @@ func TestSchedule_deleteAlertRule(t *testing.T) {
 	})
 }
 
-func setupScheduler(t *testing.T, rs *fakeRulesStore, is *state.FakeInstanceStore, registry *prometheus.Registry, senderMock *AlertsSenderMock, evalMock eval.EvaluatorFactory) *schedule {
+func TestSchedule_EvaluateNow(t *testing.T) {
+	t.Run("should run forced evaluation via the rule routine", func(t *testing.T) {
+		evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+		evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{}, State: eval.Normal}}, nil).Once()
+		sch := setupScheduler(t, nil, nil, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+		evalAppliedChan := make(chan time.Time)
+		sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+			evalAppliedChan <- t
+		}
+
+		rule := models.AlertRuleGen(models.WithOrgID(1))()
+		sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		info, _ := sch.registry.getOrCreateInfo(ctx, rule.GetKey())
+		go func() {
+			_ = sch.ruleRoutine(info.ctx, rule.GetKey(), info.evalCh, info.updateCh, info.resetCh)
+		}()
+
+		require.NoError(t, sch.EvaluateNow(rule.GetKey()))
+		require.Equal(t, sch.clock.Now(), waitForTimeChannel(t, evalAppliedChan))
+	})
+
+	t.Run("should return ErrAlertRuleNotFound if rule is not scheduled", func(t *testing.T) {
+		sch := setupScheduler(t, nil, nil, nil, nil, nil)
+		require.ErrorIs(t, sch.EvaluateNow(models.GenerateRuleKey(1)), models.ErrAlertRuleNotFound)
+	})
+
+	t.Run("should return ErrAlertRuleIsPaused if rule is paused", func(t *testing.T) {
+		sch := setupScheduler(t, nil, nil, nil, nil, nil)
+		rule := models.AlertRuleGen(models.WithIsPaused(true))()
+		sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+		_, _ = sch.registry.getOrCreateInfo(context.Background(), rule.GetKey())
+		require.ErrorIs(t, sch.EvaluateNow(rule.GetKey()), models.ErrAlertRuleIsPaused)
+	})
+
+	t.Run("should return ErrAlertRuleNotOwned if another instance evaluates the rule", func(t *testing.T) {
+		sch := setupScheduler(t, nil, nil, nil, nil, nil)
+		sch.instanceID = "instance-2"
+		sch.heartbeatStore = newFakeHeartbeatStore()
+		sch.liveInstances = []string{"instance-1", "instance-2"}
+		rule := models.AlertRuleGen()()
+		sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+		_, _ = sch.registry.getOrCreateInfo(context.Background(), rule.GetKey())
+		err := sch.EvaluateNow(rule.GetKey())
+		require.ErrorIs(t, err, models.ErrAlertRuleNotOwned)
+		require.ErrorContains(t, err, "instance-1")
+	})
+}
+
+func TestSchedule_InstanceLimit(t *testing.T) {
+	const limit = 5
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	evaluator.On("Evaluate", mock.Anything, mock.Anything).Return(func(_ context.Context, now time.Time) eval.Results {
+		results := make(eval.Results, 0, limit+10)
+		for i := 0; i < limit+10; i++ {
+			results = append(results, eval.Result{Instance: data.Labels{"series": fmt.Sprint(i)}, State: eval.Alerting, EvaluatedAt: now})
+		}
+		return results
+	}, nil).Once()
+	reg := prometheus.NewPedanticRegistry()
+	sch := setupScheduler(t, nil, nil, reg, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	evalAppliedChan := make(chan time.Time)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+		evalAppliedChan <- t
+	}
+
+	rule := models.AlertRuleGen(models.WithOrgID(1), models.WithFor(0))()
+	rule.InstanceLimit = limit
+	sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	info, _ := sch.registry.getOrCreateInfo(ctx, rule.GetKey())
+	go func() {
+		_ = sch.ruleRoutine(info.ctx, rule.GetKey(), info.evalCh, info.updateCh, info.resetCh)
+	}()
+
+	require.NoError(t, sch.EvaluateNow(rule.GetKey()))
+	waitForTimeChannel(t, evalAppliedChan)
+
+	require.Len(t, sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID), limit)
+	last := info.getLastEvaluation()
+	require.Equal(t, eval.Error, last.state)
+	require.ErrorIs(t, last.err, state.ErrTooManySeries)
+
+	expectedMetric := `
+		# HELP grafana_alerting_rule_evaluation_failures_by_reason_total The total number of rule evaluation failures by the class of the error.
+		# TYPE grafana_alerting_rule_evaluation_failures_by_reason_total counter
+		grafana_alerting_rule_evaluation_failures_by_reason_total{org="1",reason="too_many_series"} 1
+		# HELP grafana_alerting_rule_instance_limit_exceeded_total The total number of evaluations that returned more series than the instance limit of the rule.
+		# TYPE grafana_alerting_rule_instance_limit_exceeded_total counter
+		grafana_alerting_rule_instance_limit_exceeded_total{org="1"} 1
+	`
+	require.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(expectedMetric),
+		"grafana_alerting_rule_evaluation_failures_by_reason_total", "grafana_alerting_rule_instance_limit_exceeded_total"))
+}
+
+func TestSchedule_ResetState(t *testing.T) {
+	t.Run("should reset the state via the rule routine and expire firing alerts", func(t *testing.T) {
+		evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+		evaluator.On("Evaluate", mock.Anything, mock.Anything).Return(func(_ context.Context, now time.Time) eval.Results {
+			return eval.Results{{Instance: data.Labels{}, State: eval.Alerting, EvaluatedAt: now}}
+		}, nil).Twice()
+		sender := &AlertsSenderMock{}
+		sender.EXPECT().Send(mock.Anything, mock.Anything).Return()
+		sch := setupScheduler(t, nil, nil, nil, sender, eval_mocks.NewEvaluatorFactory(evaluator))
+		evalAppliedChan := make(chan time.Time)
+		sch.evalAppliedFunc = func(key models.AlertRuleKey, t time.Time) {
+			evalAppliedChan <- t
+		}
+
+		rule := models.AlertRuleGen(models.WithOrgID(1), models.WithFor(0))()
+		sch.schedulableAlertRules.set([]*models.AlertRule{rule}, map[string]string{})
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		info, _ := sch.registry.getOrCreateInfo(ctx, rule.GetKey())
+		go func() {
+			_ = sch.ruleRoutine(info.ctx, rule.GetKey(), info.evalCh, info.updateCh, info.resetCh)
+		}()
+
+		require.NoError(t, sch.EvaluateNow(rule.GetKey()))
+		waitForTimeChannel(t, evalAppliedChan)
+		states := sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, states, 1)
+		require.Equal(t, eval.Alerting, states[0].State)
+		sendCalls := len(sender.Calls)
+
+		count, err := sch.ResetState(context.Background(), models.ResetAlertInstancesCommand{OrgID: rule.OrgID, RuleUID: rule.UID, ResetBy: "editor"})
+		require.NoError(t, err)
+		require.Equal(t, 1, count)
+		require.Empty(t, sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID))
+		require.Len(t, sender.Calls, sendCalls+1)
+		args, ok := sender.Calls[sendCalls].Arguments[1].(definitions.PostableAlerts)
+		require.True(t, ok)
+		require.Len(t, args.PostableAlerts, 1)
+
+		sch.clock.(*clock.Mock).Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		require.NoError(t, sch.EvaluateNow(rule.GetKey()))
+		waitForTimeChannel(t, evalAppliedChan)
+		states = sch.stateManager.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, states, 1)
+		require.Equal(t, eval.Alerting, states[0].State)
+		require.Equal(t, sch.clock.Now(), states[0].StartsAt)
+		require.Len(t, states[0].Results, 1)
+	})
+
+	t.Run("should return ErrAlertRuleNotFound if rule is not scheduled", func(t *testing.T) {
+		sch := setupScheduler(t, nil, nil, nil, nil, nil)
+		_, err := sch.ResetState(context.Background(), models.ResetAlertInstancesCommand{OrgID: 1, RuleUID: "test"})
+		require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+	})
+
+	t.Run("should return ErrAlertRuleNotOwned if rule is evaluated by another instance", func(t *testing.T) {
+		sch := setupScheduler(t, nil, nil, nil, nil, nil)
+		sch.instanceID = "instance-1"
+		sch.heartbeatStore = newFakeHeartbeatStore()
+		sch.shardingEnabled = true
+		sch.liveInstances = []string{"instance-0", "instance-1"}
+		rule := models.AlertRuleGen(models.WithOrgID(1))()
+		for sch.ruleOwnership().ownsRule(rule.GetKey()) {
+			rule = models.AlertRuleGen(models.WithOrgID(1))()
+		}
+		_, _ = sch.registry.getOrCreateInfo(context.Background(), rule.GetKey())
+
+		_, err := sch.ResetState(context.Background(), models.ResetAlertInstancesCommand{OrgID: rule.OrgID, RuleUID: rule.UID})
+		require.ErrorIs(t, err, models.ErrAlertRuleNotOwned)
+		require.ErrorContains(t, err, "instance-0")
+	})
+}
+
+func TestSchedule_drainOnShutdown(t *testing.T) {
+	const drainTimeout = time.Minute
+
+	run := func(t *testing.T, evaluator *blockingEvaluator) (*schedule, *state.FakeInstanceStore, context.CancelFunc, <-chan error) {
+		ruleStore := newFakeRulesStore()
+		instanceStore := &state.FakeInstanceStore{}
+		sch := setupScheduler(t, ruleStore, instanceStore, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+		sch.drainTimeout = drainTimeout
+
+		rule := models.AlertRuleGen(models.WithInterval(time.Second), models.WithFor(0))()
+		ruleStore.PutRule(context.Background(), rule)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		tick := &ticker.T{C: make(chan time.Time)}
+		done := make(chan error, 1)
+		go func() {
+			done <- sch.schedulePeriodic(ctx, tick)
+		}()
+		tick.C <- sch.clock.Now()
+
+		select {
+		case <-evaluator.started:
+		case <-time.After(5 * time.Second):
+			t.Fatal("evaluation did not start")
+		}
+		return sch, instanceStore, cancel, done
+	}
+
+	savedInstances := func(is *state.FakeInstanceStore) []models.AlertInstance {
+		var result []models.AlertInstance
+		for _, op := range is.RecordedOps {
+			if inst, ok := op.(models.AlertInstance); ok {
+				result = append(result, inst)
+			}
+		}
+		return result
+	}
+
+	t.Run("should wait for in-flight evaluation and persist its state", func(t *testing.T) {
+		evaluator := newBlockingEvaluator()
+		sch, instanceStore, cancel, done := run(t, evaluator)
+
+		cancel()
+		select {
+		case <-done:
+			t.Fatal("scheduler stopped before in-flight evaluation completed")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(evaluator.release)
+		select {
+		case err := <-done:
+			require.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("scheduler did not stop after in-flight evaluation completed")
+		}
+
+		completed, running := sch.evaluations.stats()
+		require.Equal(t, 1, completed)
+		require.Equal(t, 0, running)
+		require.NotEmpty(t, savedInstances(instanceStore))
+	})
+
+	t.Run("should cancel in-flight evaluation when drain timeout is exceeded", func(t *testing.T) {
+		evaluator := newBlockingEvaluator()
+		sch, instanceStore, cancel, done := run(t, evaluator)
+
+		cancel()
+		mockedClock := sch.clock.(*clock.Mock)
+		var err error
+		require.Eventually(t, func() bool {
+			mockedClock.Add(drainTimeout)
+			select {
+			case err = <-done:
+				return true
+			default:
+				return false
+			}
+		}, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, err)
+
+		select {
+		case <-evaluator.cancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("in-flight evaluation was not cancelled")
+		}
+		require.Empty(t, savedInstances(instanceStore))
+	})
+}
+
+func TestSchedule_evaluationCoordination(t *testing.T) {
+	const heartbeatTTL = 3 * time.Second
+	ruleStore := newFakeRulesStore()
+	_, rules := models.GenerateUniqueAlertRules(5, models.AlertRuleGen(models.WithInterval(time.Second)))
+	ruleStore.PutRule(context.Background(), rules...)
+	heartbeats := newFakeHeartbeatStore()
+
+	newInstance := func(instanceID string) *schedule {
+		sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewFailingEvaluatorFactory(nil))
+		sch.instanceID = instanceID
+		sch.heartbeatStore = heartbeats
+		sch.heartbeatTTL = heartbeatTTL
+		return sch
+	}
+	instance1 := newInstance("instance-1")
+	instance2 := newInstance("instance-2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	// evaluatedBy runs the tick on every instance in order and returns the instances that evaluated each rule.
+	evaluatedBy := func(tick time.Time, instances ...*schedule) map[models.AlertRuleKey][]string {
+		result := make(map[models.AlertRuleKey][]string)
+		for _, sch := range instances {
+			readyToRun, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+			for _, item := range readyToRun {
+				result[item.rule.GetKey()] = append(result[item.rule.GetKey()], sch.instanceID)
+			}
+		}
+		return result
+	}
+
+	assertOwner := func(t *testing.T, result map[models.AlertRuleKey][]string, instanceID string) {
+		t.Helper()
+		require.Len(t, result, len(rules))
+		for key, instances := range result {
+			require.Equalf(t, []string{instanceID}, instances, "rule %s was not evaluated by exactly one instance", key.UID)
+		}
+	}
+
+	tick := instance1.clock.Now()
+
+	t.Run("each rule should be evaluated by exactly one instance", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			tick = tick.Add(time.Second)
+			assertOwner(t, evaluatedBy(tick, instance1, instance2), "instance-1")
+		}
+	})
+
+	t.Run("ownership should move when an instance misses heartbeats", func(t *testing.T) {
+		tick = tick.Add(heartbeatTTL + time.Second)
+		assertOwner(t, evaluatedBy(tick, instance2), "instance-2")
+	})
+
+	t.Run("ownership should not be shared when an instance comes back", func(t *testing.T) {
+		tick = tick.Add(time.Second)
+		assertOwner(t, evaluatedBy(tick, instance1, instance2), "instance-1")
+	})
+
+	t.Run("ownership should move immediately when an instance deletes its heartbeat", func(t *testing.T) {
+		instance1.deleteHeartbeat()
+		tick = tick.Add(time.Second)
+		assertOwner(t, evaluatedBy(tick, instance2), "instance-2")
+	})
+}
+
+// blockingEvaluator is a ConditionEvaluator that blocks until it is released or its context is cancelled.
+type blockingEvaluator struct {
+	started   chan struct{}
+	release   chan struct{}
+	cancelled chan struct{}
+}
+
+func newBlockingEvaluator() *blockingEvaluator {
+	return &blockingEvaluator{
+		started:   make(chan struct{}, 1),
+		release:   make(chan struct{}),
+		cancelled: make(chan struct{}),
+	}
+}
+
+func (e *blockingEvaluator) EvaluateRaw(ctx context.Context, now time.Time) (*backend.QueryDataResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *blockingEvaluator) Evaluate(ctx context.Context, now time.Time) (eval.Results, error) {
+	e.started <- struct{}{}
+	select {
+	case <-e.release:
+		return eval.Results{{Instance: data.Labels{}, State: eval.Alerting, EvaluatedAt: now}}, nil
+	case <-ctx.Done():
+		close(e.cancelled)
+		return nil, ctx.Err()
+	}
+}
+
+func TestSchedule_Liveness(t *testing.T) {
+	sch := setupScheduler(t, nil, nil, nil, nil, nil)
+	mockedClock := sch.clock.(*clock.Mock)
+	// the mock clock starts at the Unix epoch, which Liveness cannot tell from no tick
+	mockedClock.Add(time.Hour)
+	running, lastTick := sch.Liveness()
+	require.False(t, running)
+	require.True(t, lastTick.IsZero())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- sch.Run(ctx)
+	}()
+	start := mockedClock.Now()
+	require.Eventually(t, func() bool {
+		running, lastTick := sch.Liveness()
+		return running && lastTick.Equal(start)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	t.Run("should record when the last tick completed", func(t *testing.T) {
+		mockedClock.Add(sch.baseInterval)
+		require.Eventually(t, func() bool {
+			_, lastTick := sch.Liveness()
+			return lastTick.Equal(start.Add(sch.baseInterval))
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should not be running after the scheduler stopped", func(t *testing.T) {
+		cancel()
+		require.NoError(t, <-done)
+		running, lastTick := sch.Liveness()
+		require.False(t, running)
+		require.Equal(t, start.Add(sch.baseInterval), lastTick)
+	})
+}
+
+func setupScheduler(t testing.TB, rs RulesStore, is state.InstanceStore, registry *prometheus.Registry, senderMock *AlertsSenderMock, evalMock eval.EvaluatorFactory) *schedule {
 	t.Helper()
 	testTracer := tracing.InitializeTracerForTest()
 
@@ -780,7 +2167,7 @@ func setupScheduler(t *testing.T, rs *fakeRulesStore, is *state.FakeInstanceStor
 
 	var evaluator = evalMock
 	if evalMock == nil {
-		evaluator = eval.NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, nil, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil), &plugins.FakePluginStore{})
+		evaluator = eval.NewEvaluatorFactory(setting.UnifiedAlertingSettings{}, nil, expr.ProvideService(&setting.Cfg{ExpressionsEnabled: true}, nil, nil, nil), &plugins.FakePluginStore{})
 	}
 
 	if registry == nil {
@@ -827,7 +2214,7 @@ func setupScheduler(t *testing.T, rs *fakeRulesStore, is *state.FakeInstanceStor
 	return NewScheduler(schedCfg, st)
 }
 
-func withQueryForState(t *testing.T, evalResult eval.State) models.AlertRuleMutator {
+func withQueryForState(t testing.TB, evalResult eval.State) models.AlertRuleMutator {
 	var expression string
 	var forMultimplier int64 = 0
 	switch evalResult {
@@ -0,0 +1,39 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+)
+
+// RuntimeSettings are the settings of the scheduler that can be changed without restarting it.
+type RuntimeSettings struct {
+	// MaxAttempts is the number of attempts to evaluate a rule.
+	MaxAttempts int64
+	// MaxConcurrentEvaluations is the maximum number of evaluations that run at the same time. Zero disables the limit.
+	MaxConcurrentEvaluations int
+	// EvaluationTimeout is the timeout of the evaluation of a rule. It is passed to the evaluator factory.
+	EvaluationTimeout time.Duration
+}
+
+// UpdateSettings stores the settings to be applied at the next tick. If it is called several times between ticks, only
+// the latest settings are applied. Evaluations that already run complete with the previous settings.
+func (sch *schedule) UpdateSettings(settings RuntimeSettings) {
+	sch.pendingSettings.Store(&settings)
+}
+
+// applySettings applies the settings passed to UpdateSettings since the previous tick, if any.
+func (sch *schedule) applySettings() {
+	settings := sch.pendingSettings.Swap(nil)
+	if settings == nil {
+		return
+	}
+	sch.maxAttempts.Store(settings.MaxAttempts)
+	sch.evaluationLimiter.setLimit(settings.MaxConcurrentEvaluations)
+	if setter, ok := sch.evaluatorFactory.(eval.TimeoutSetter); ok {
+		setter.SetEvaluationTimeout(settings.EvaluationTimeout)
+		// the cached condition evaluators keep the timeout they were built with
+		sch.registry.clearConditions()
+	}
+	sch.log.Info("Applied new scheduler settings", "maxAttempts", settings.MaxAttempts, "maxConcurrentEvaluations", settings.MaxConcurrentEvaluations, "evaluationTimeout", settings.EvaluationTimeout)
+}
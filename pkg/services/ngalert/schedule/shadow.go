@@ -0,0 +1,183 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+)
+
+const (
+	legacyStateAlerting = "alerting"
+	legacyStatePaused   = "paused"
+)
+
+// LegacyAlertStore is a store that provides the state of the legacy dashboard alerts, which the rules in shadow mode are
+// compared with.
+type LegacyAlertStore interface {
+	GetLegacyAlertState(ctx context.Context, orgID, alertID int64) (*ngmodels.LegacyAlertState, error)
+}
+
+// shadowComparator compares the state of the rules in shadow mode with the state of the legacy alerts that they were
+// migrated from, after every evaluation of the rules. A discrepancy is reported once the rule disagrees with its legacy
+// alert for at least the tolerance, because the rule and the legacy alert are not evaluated at the same time.
+type shadowComparator struct {
+	store     LegacyAlertStore
+	tolerance time.Duration
+	log       log.Logger
+	metrics   *metrics.Scheduler
+
+	mtx   sync.Mutex
+	rules map[ngmodels.AlertRuleKey]*shadowRule
+}
+
+// shadowRule is the result of the comparisons of a rule that disagreed with its legacy alert at least once.
+type shadowRule struct {
+	title   string
+	alertID int64
+	// disagreeing is true if the rule disagreed with its legacy alert at the latest comparison.
+	disagreeing bool
+	// reported is true if the current discrepancy lasted for the tolerance and was reported.
+	reported       bool
+	kind           definitions.ShadowDiscrepancyKind
+	since          time.Time
+	lastComparison time.Time
+	discrepancies  int64
+}
+
+func newShadowComparator(store LegacyAlertStore, tolerance time.Duration, logger log.Logger, m *metrics.Scheduler) *shadowComparator {
+	return &shadowComparator{
+		store:     store,
+		tolerance: tolerance,
+		log:       logger,
+		metrics:   m,
+		rules:     map[ngmodels.AlertRuleKey]*shadowRule{},
+	}
+}
+
+// isAlerting returns true if any instance of the rule is alerting after the transitions.
+func isAlerting(transitions []state.StateTransition) bool {
+	for _, t := range transitions {
+		if t.State.State == eval.Alerting {
+			return true
+		}
+	}
+	return false
+}
+
+// compare compares the state of the rule, evaluated at evaluatedAt, with the state of its legacy alert. Rules whose
+// legacy alert does not exist or is paused are not compared.
+func (c *shadowComparator) compare(ctx context.Context, rule *ngmodels.AlertRule, evaluatedAt time.Time, alerting bool) {
+	alertID, ok := rule.MigratedAlertID()
+	if !ok {
+		return
+	}
+	logger := c.log.FromContext(ctx).New("legacyAlertId", alertID)
+	legacy, err := c.store.GetLegacyAlertState(ctx, rule.OrgID, alertID)
+	if err != nil {
+		logger.Error("Failed to get the state of the legacy alert", "error", err)
+		return
+	}
+	if legacy == nil || legacy.State == legacyStatePaused {
+		logger.Debug("Skip comparing the rule with the legacy alert because it does not exist or is paused")
+		return
+	}
+
+	var kind definitions.ShadowDiscrepancyKind
+	legacyAlerting := legacy.State == legacyStateAlerting
+	switch {
+	case alerting && !legacyAlerting:
+		kind = definitions.ShadowDiscrepancyAlertingOnly
+	case !alerting && legacyAlerting:
+		kind = definitions.ShadowDiscrepancyLegacyOnly
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	key := rule.GetKey()
+	r, ok := c.rules[key]
+	if kind == "" {
+		if ok {
+			r.disagreeing, r.reported = false, false
+			r.lastComparison = evaluatedAt
+		}
+		return
+	}
+	if !ok {
+		r = &shadowRule{}
+		c.rules[key] = r
+	}
+	r.title, r.alertID, r.lastComparison = rule.Title, alertID, evaluatedAt
+	if !r.disagreeing || r.kind != kind {
+		r.disagreeing, r.reported = true, false
+		r.kind, r.since = kind, evaluatedAt
+	}
+	if r.reported || evaluatedAt.Sub(r.since) < c.tolerance {
+		return
+	}
+	r.reported = true
+	r.discrepancies++
+	c.metrics.ShadowDiscrepancies.WithLabelValues(fmt.Sprint(rule.OrgID), rule.UID, string(kind)).Inc()
+	logger.Warn("Alert rule in shadow mode disagrees with its legacy alert", "kind", kind, "since", r.since,
+		"alerting", alerting, "legacyState", legacy.State, "legacyStateSince", legacy.NewStateDate)
+}
+
+// forget drops the results of the comparisons of the deleted rules.
+func (c *shadowComparator) forget(keys ...ngmodels.AlertRuleKey) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, key := range keys {
+		delete(c.rules, key)
+	}
+}
+
+// report returns the rules that disagreed with their legacy alerts for at least the tolerance, sorted by organization
+// and UID.
+func (c *shadowComparator) report() []definitions.ShadowRuleDiscrepancy {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	result := make([]definitions.ShadowRuleDiscrepancy, 0, len(c.rules))
+	for key, r := range c.rules {
+		if r.discrepancies == 0 {
+			continue
+		}
+		result = append(result, definitions.ShadowRuleDiscrepancy{
+			UID:            key.UID,
+			OrgID:          key.OrgID,
+			Title:          r.title,
+			LegacyAlertID:  r.alertID,
+			Disagreeing:    r.disagreeing && r.reported,
+			Kind:           r.kind,
+			Since:          r.since,
+			LastComparison: r.lastComparison,
+			Discrepancies:  r.discrepancies,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].OrgID != result[j].OrgID {
+			return result[i].OrgID < result[j].OrgID
+		}
+		return result[i].UID < result[j].UID
+	})
+	return result
+}
+
+// ShadowReport returns the rules in shadow mode that disagreed with their legacy alerts.
+func (sch *schedule) ShadowReport() definitions.ShadowModeReport {
+	if sch.shadow == nil {
+		return definitions.ShadowModeReport{Rules: []definitions.ShadowRuleDiscrepancy{}}
+	}
+	return definitions.ShadowModeReport{
+		Enabled:          true,
+		ToleranceSeconds: int64(sch.shadow.tolerance.Seconds()),
+		Rules:            sch.shadow.report(),
+	}
+}
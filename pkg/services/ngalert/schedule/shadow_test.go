@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// fakeLegacyAlertStore returns the states of the legacy alerts by ID.
+type fakeLegacyAlertStore struct {
+	states map[int64]string
+}
+
+func (f *fakeLegacyAlertStore) GetLegacyAlertState(_ context.Context, _, alertID int64) (*ngmodels.LegacyAlertState, error) {
+	s, ok := f.states[alertID]
+	if !ok {
+		return nil, nil
+	}
+	return &ngmodels.LegacyAlertState{State: s}, nil
+}
+
+func TestShadowComparator(t *testing.T) {
+	now := time.Now()
+	newComparator := func(legacy *fakeLegacyAlertStore, tolerance time.Duration) (*shadowComparator, *metrics.Scheduler) {
+		m := metrics.NewSchedulerMetrics(prometheus.NewRegistry())
+		return newShadowComparator(legacy, tolerance, log.NewNopLogger(), m), m
+	}
+	migratedRule := func(alertID string) *ngmodels.AlertRule {
+		rule := ngmodels.AlertRuleGen()()
+		rule.Annotations = map[string]string{ngmodels.MigratedAlertIDAnnotation: alertID}
+		return rule
+	}
+	discrepancies := func(m *metrics.Scheduler, rule *ngmodels.AlertRule, kind definitions.ShadowDiscrepancyKind) float64 {
+		return testutil.ToFloat64(m.ShadowDiscrepancies.WithLabelValues(fmt.Sprint(rule.OrgID), rule.UID, string(kind)))
+	}
+
+	t.Run("reports the rules that disagree for at least the tolerance", func(t *testing.T) {
+		legacy := &fakeLegacyAlertStore{states: map[int64]string{1: "ok"}}
+		c, m := newComparator(legacy, time.Minute)
+		rule := migratedRule("1")
+
+		c.compare(context.Background(), rule, now, true)
+		c.compare(context.Background(), rule, now.Add(30*time.Second), true)
+		require.Empty(t, c.report(), "the discrepancy is within the tolerance")
+
+		c.compare(context.Background(), rule, now.Add(time.Minute), true)
+		c.compare(context.Background(), rule, now.Add(2*time.Minute), true)
+		report := c.report()
+		require.Len(t, report, 1)
+		require.Equal(t, definitions.ShadowRuleDiscrepancy{
+			UID:            rule.UID,
+			OrgID:          rule.OrgID,
+			Title:          rule.Title,
+			LegacyAlertID:  1,
+			Disagreeing:    true,
+			Kind:           definitions.ShadowDiscrepancyAlertingOnly,
+			Since:          now,
+			LastComparison: now.Add(2 * time.Minute),
+			Discrepancies:  1,
+		}, report[0])
+		require.Equal(t, 1.0, discrepancies(m, rule, definitions.ShadowDiscrepancyAlertingOnly))
+
+		// the rule agrees with the legacy alert again
+		legacy.states[1] = "alerting"
+		c.compare(context.Background(), rule, now.Add(3*time.Minute), true)
+		report = c.report()
+		require.Len(t, report, 1)
+		require.False(t, report[0].Disagreeing)
+		require.Equal(t, int64(1), report[0].Discrepancies)
+
+		// the legacy alert fires while the rule does not
+		c.compare(context.Background(), rule, now.Add(4*time.Minute), false)
+		c.compare(context.Background(), rule, now.Add(5*time.Minute), false)
+		report = c.report()
+		require.True(t, report[0].Disagreeing)
+		require.Equal(t, definitions.ShadowDiscrepancyLegacyOnly, report[0].Kind)
+		require.Equal(t, now.Add(4*time.Minute), report[0].Since)
+		require.Equal(t, int64(2), report[0].Discrepancies)
+		require.Equal(t, 1.0, discrepancies(m, rule, definitions.ShadowDiscrepancyLegacyOnly))
+	})
+
+	t.Run("ignores discrepancies that are shorter than the tolerance", func(t *testing.T) {
+		legacy := &fakeLegacyAlertStore{states: map[int64]string{1: "alerting"}}
+		c, _ := newComparator(legacy, time.Minute)
+		rule := migratedRule("1")
+
+		// the rule starts alerting later than the legacy alert
+		c.compare(context.Background(), rule, now, false)
+		c.compare(context.Background(), rule, now.Add(30*time.Second), true)
+		// the legacy alert is resolved earlier than the rule
+		legacy.states[1] = "ok"
+		c.compare(context.Background(), rule, now.Add(time.Minute), true)
+		c.compare(context.Background(), rule, now.Add(90*time.Second), false)
+		require.Empty(t, c.report())
+	})
+
+	t.Run("reports immediately without tolerance", func(t *testing.T) {
+		legacy := &fakeLegacyAlertStore{states: map[int64]string{1: "pending"}}
+		c, _ := newComparator(legacy, 0)
+		rule := migratedRule("1")
+
+		c.compare(context.Background(), rule, now, true)
+		require.Len(t, c.report(), 1)
+	})
+
+	t.Run("does not compare rules whose legacy alert is paused or does not exist", func(t *testing.T) {
+		legacy := &fakeLegacyAlertStore{states: map[int64]string{1: "paused"}}
+		c, _ := newComparator(legacy, 0)
+
+		c.compare(context.Background(), migratedRule("1"), now, true)
+		c.compare(context.Background(), migratedRule("2"), now, true)
+		c.compare(context.Background(), ngmodels.AlertRuleGen()(), now, true)
+		require.Empty(t, c.report())
+	})
+
+	t.Run("forgets deleted rules", func(t *testing.T) {
+		legacy := &fakeLegacyAlertStore{states: map[int64]string{1: "ok"}}
+		c, _ := newComparator(legacy, 0)
+		rule := migratedRule("1")
+
+		c.compare(context.Background(), rule, now, true)
+		require.Len(t, c.report(), 1)
+		c.forget(rule.GetKey())
+		require.Empty(t, c.report())
+	})
+}
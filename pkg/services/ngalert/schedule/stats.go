@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+// evaluationStatsWindow is the number of latest evaluations whose durations are kept to calculate the percentile.
+const evaluationStatsWindow = 1000
+
+// evaluationStats counts the evaluations since the scheduler started and keeps the durations of the latest ones.
+// The zero value is ready to use.
+type evaluationStats struct {
+	mtx       sync.Mutex
+	total     int64
+	failures  int64
+	durations []time.Duration
+	// next is the index in durations that is overwritten by the next evaluation once the window is full.
+	next int
+}
+
+func (s *evaluationStats) observe(duration time.Duration, failed bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.total++
+	if failed {
+		s.failures++
+	}
+	if len(s.durations) < evaluationStatsWindow {
+		s.durations = append(s.durations, duration)
+		return
+	}
+	s.durations[s.next] = duration
+	s.next = (s.next + 1) % evaluationStatsWindow
+}
+
+func (s *evaluationStats) get() definitions.EvaluationStats {
+	s.mtx.Lock()
+	durations := make([]time.Duration, len(s.durations))
+	copy(durations, s.durations)
+	result := definitions.EvaluationStats{
+		Total:    s.total,
+		Failures: s.failures,
+	}
+	s.mtx.Unlock()
+
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		idx := int(math.Ceil(0.95*float64(len(durations)))) - 1
+		result.P95DurationSeconds = durations[idx].Seconds()
+	}
+	return result
+}
+
+// Stats returns the state of the scheduler and the statistics of the evaluations since it started. It is cheap enough to
+// be called at any time, as it only reads the in-memory registries.
+func (sch *schedule) Stats() (definitions.SchedulerStats, definitions.EvaluationStats) {
+	routines := sch.registry.len()
+	inFlight := sch.evaluations.inFlight()
+	result := definitions.SchedulerStats{
+		Running:             sch.running.Load(),
+		EvaluationPaused:    sch.IsEvaluationPaused(),
+		BaseIntervalSeconds: int64(sch.baseInterval.Seconds()),
+		ScheduledRules:      sch.schedulableAlertRules.len(),
+		RuleRoutines:        routines,
+		InFlightEvaluations: inFlight,
+	}
+	if routines > 0 {
+		result.Saturation = math.Min(float64(inFlight)/float64(routines), 1)
+	}
+	return result, sch.evaluationStats.get()
+}
+
+// Liveness returns whether the scheduler loop runs and when it last completed a tick, or started if it has not completed
+// any tick yet. It only reads atomics, so it never waits for a tick or an evaluation.
+func (sch *schedule) Liveness() (bool, time.Time) {
+	lastTick := sch.lastTick.Load()
+	if lastTick == 0 {
+		return sch.running.Load(), time.Time{}
+	}
+	return sch.running.Load(), time.Unix(0, lastTick)
+}
@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestEvaluationStats(t *testing.T) {
+	var stats evaluationStats
+	require.Equal(t, float64(0), stats.get().P95DurationSeconds)
+
+	for i := 1; i <= 100; i++ {
+		stats.observe(time.Duration(i)*time.Second, i%10 == 0)
+	}
+	result := stats.get()
+	require.Equal(t, int64(100), result.Total)
+	require.Equal(t, int64(10), result.Failures)
+	require.Equal(t, float64(95), result.P95DurationSeconds)
+
+	// only the latest evaluations are kept for the percentile
+	for i := 0; i < evaluationStatsWindow; i++ {
+		stats.observe(time.Second, false)
+	}
+	result = stats.get()
+	require.Equal(t, int64(100+evaluationStatsWindow), result.Total)
+	require.Equal(t, int64(10), result.Failures)
+	require.Equal(t, float64(1), result.P95DurationSeconds)
+}
+
+func TestSchedule_Stats(t *testing.T) {
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{}, State: eval.Alerting}}, nil)
+
+	ruleStore := newFakeRulesStore()
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, tick time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: key, now: tick}
+	}
+	rule := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(time.Second), models.WithIsPaused(false))()
+	ruleStore.PutRule(context.Background(), rule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	tick := time.Date(2023, 3, 24, 7, 0, 0, 0, time.UTC)
+	sch.clock.(*clock.Mock).Set(tick)
+	sch.processTick(ctx, dispatcherGroup, tick)
+	assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+
+	schedulerStats, evaluationStats := sch.Stats()
+	require.False(t, schedulerStats.Running)
+	require.False(t, schedulerStats.EvaluationPaused)
+	require.Equal(t, int64(1), schedulerStats.BaseIntervalSeconds)
+	require.Equal(t, 1, schedulerStats.ScheduledRules)
+	require.Equal(t, 1, schedulerStats.RuleRoutines)
+	require.Equal(t, int64(1), evaluationStats.Total)
+	require.Equal(t, int64(0), evaluationStats.Failures)
+
+	sch.PauseEvaluation()
+	schedulerStats, _ = sch.Stats()
+	require.True(t, schedulerStats.EvaluationPaused)
+}
@@ -0,0 +1,165 @@
+package schedule
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// Status returns the rules known to the scheduler with their latest evaluation, the instance that evaluates them, and the
+// next tick at which this instance evaluates them. The data is taken from the in-memory registries as they were at the last tick.
+func (sch *schedule) Status() definitions.SchedulerStatus {
+	now := sch.clock.Now()
+	rules, _ := sch.schedulableAlertRules.all()
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].OrgID != rules[j].OrgID {
+			return rules[i].OrgID < rules[j].OrgID
+		}
+		return rules[i].UID < rules[j].UID
+	})
+
+	limit, _ := sch.evaluationLimiter.stats()
+	result := definitions.SchedulerStatus{
+		InstanceID:               sch.instanceID,
+		BaseIntervalSeconds:      int64(sch.baseInterval.Seconds()),
+		EvaluationPaused:         sch.IsEvaluationPaused(),
+		RuleRoutines:             len(sch.registry.keyMap()),
+		InFlightEvaluations:      sch.evaluations.inFlight(),
+		MaxConcurrentEvaluations: limit,
+		Rules:                    make([]definitions.ScheduledRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		status := definitions.ScheduledRule{
+			ID:               rule.ID,
+			UID:              rule.UID,
+			OrgID:            rule.OrgID,
+			Title:            rule.Title,
+			IntervalSeconds:  rule.IntervalSeconds,
+			Schedule:         rule.Schedule,
+			ScheduleTimezone: rule.ScheduleTimezone,
+			IsPaused:         rule.IsPaused,
+		}
+		if info, ok := sch.registry.get(rule.GetKey()); ok {
+			last := info.getLastEvaluation()
+			if !last.scheduledAt.IsZero() {
+				status.LastEvaluation = &last.scheduledAt
+				status.LastEvaluationDuration = last.duration.Seconds()
+				status.LastEvaluationState = last.state.String()
+				if last.err != nil {
+					status.LastError = last.err.Error()
+				}
+			}
+			if throttledAt := info.getThrottledAt(); !throttledAt.IsZero() {
+				status.ThrottledAt = &throttledAt
+			}
+			var owned bool
+			status.Owner, owned = info.getOwner()
+			backoffUntil := info.getBackoffUntil()
+			if !backoffUntil.IsZero() {
+				status.BackoffUntil = &backoffUntil
+			}
+			// the rules evaluated by other instances are not evaluated by this one.
+			if !rule.IsPaused && owned {
+				if next, ok := sch.nextTick(rule, info.getIntervalAnchor(), now); ok {
+					// the evaluations scheduled before the end of the backoff are skipped.
+					if next.Before(backoffUntil) {
+						next, ok = sch.nextTick(rule, info.getIntervalAnchor(), backoffUntil.Add(-time.Second))
+					}
+					if ok {
+						status.NextEvaluation = &next
+					}
+				}
+			}
+		}
+		result.Rules = append(result.Rules, status)
+	}
+	return result
+}
+
+// nextTick returns the first tick after now at which the rule is due according to its schedule, or its interval counted from
+// the tick with number anchor. Returns false if the next tick cannot be calculated, e.g. because the schedule is not valid.
+func (sch *schedule) nextTick(rule *ngmodels.AlertRule, anchor int64, now time.Time) (time.Time, bool) {
+	baseSeconds := int64(sch.baseInterval.Seconds())
+	if rule.Schedule != "" {
+		s, err := ngmodels.ParseSchedule(rule.Schedule, rule.ScheduleTimezone)
+		if err != nil {
+			return time.Time{}, false
+		}
+		// the rule is evaluated at the first tick that is not before the scheduled time
+		next := s.Next(now).Unix()
+		if next%baseSeconds != 0 {
+			next += baseSeconds - next%baseSeconds
+		}
+		return time.Unix(next, 0).In(now.Location()), true
+	}
+	// ticks are aligned to the base interval, and the rule is due at every multiple of its interval after the anchor
+	intervalSeconds := rule.IntervalSeconds
+	if intervalSeconds < int64(sch.minRuleInterval.Seconds()) {
+		intervalSeconds = int64(sch.minRuleInterval.Seconds())
+	}
+	if intervalSeconds <= 0 || intervalSeconds%baseSeconds != 0 {
+		return time.Time{}, false
+	}
+	anchorSeconds := anchor * baseSeconds
+	elapsed := now.Unix() - anchorSeconds
+	if elapsed < 0 {
+		return time.Unix(anchorSeconds, 0).In(now.Location()), true
+	}
+	return time.Unix(anchorSeconds+(elapsed/intervalSeconds+1)*intervalSeconds, 0).In(now.Location()), true
+}
+
+// saveEvaluations writes the results of the evaluations that completed since the previous save to the evaluation store in a single batch.
+// It does nothing if there is no store, or if less than evaluationSaveInterval has passed since the previous save and force is false.
+// Results that failed to be saved are retried with the next save.
+func (sch *schedule) saveEvaluations(ctx context.Context, now time.Time, force bool) {
+	if sch.evaluationStore == nil {
+		return
+	}
+	if !force && now.Sub(sch.evaluationsSavedAt) < sch.evaluationSaveInterval {
+		return
+	}
+	sch.evaluationsSavedAt = now
+
+	type unsaved struct {
+		info *alertRuleInfo
+		seq  uint64
+	}
+	var evaluations []ngmodels.AlertRuleEvaluation
+	var saved []unsaved
+	for key := range sch.registry.keyMap() {
+		info, ok := sch.registry.get(key)
+		if !ok {
+			continue
+		}
+		status, seq, ok := info.getUnsavedEvaluation()
+		if !ok {
+			continue
+		}
+		evaluation := ngmodels.AlertRuleEvaluation{
+			RuleOrgID:   key.OrgID,
+			RuleUID:     key.UID,
+			EvaluatedAt: status.scheduledAt,
+			Duration:    status.duration,
+			State:       status.state.String(),
+		}
+		if status.err != nil {
+			evaluation.Error = status.err.Error()
+		}
+		evaluations = append(evaluations, evaluation)
+		saved = append(saved, unsaved{info: info, seq: seq})
+	}
+	if len(evaluations) == 0 {
+		return
+	}
+	if err := sch.evaluationStore.SaveAlertRuleEvaluations(ctx, evaluations...); err != nil {
+		sch.log.Error("Failed to save the latest evaluations of alert rules", "count", len(evaluations), "error", err)
+		return
+	}
+	for _, s := range saved {
+		s.info.markEvaluationSaved(s.seq)
+	}
+	sch.log.Debug("Saved the latest evaluations of alert rules", "count", len(evaluations))
+}
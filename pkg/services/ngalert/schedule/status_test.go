@@ -0,0 +1,202 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestSchedule_Status(t *testing.T) {
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{}, State: eval.Alerting}}, nil)
+
+	ruleStore := newFakeRulesStore()
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, tick time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: key, now: tick}
+	}
+
+	intervalRule := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(10*time.Second), models.WithIsPaused(false))()
+	cronRule := models.AlertRuleGen(models.WithOrgID(2), models.WithInterval(time.Minute), models.WithIsPaused(false))()
+	cronRule.Schedule = "0 8 * * 1-5"
+	cronRule.ScheduleTimezone = "Europe/Berlin"
+	pausedRule := models.AlertRuleGen(models.WithOrgID(3), models.WithInterval(10*time.Second), models.WithIsPaused(true))()
+	ruleStore.PutRule(context.Background(), intervalRule, cronRule, pausedRule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	// Friday, 08:00 in Berlin is at 07:00 UTC
+	tick := time.Date(2023, 3, 24, 6, 59, 50, 0, time.UTC)
+	sch.clock.(*clock.Mock).Set(tick)
+	scheduled, _, _ := sch.processTick(ctx, dispatcherGroup, tick)
+	require.Len(t, scheduled, 1)
+	assertEvalRun(t, evalAppliedCh, tick, intervalRule.GetKey())
+
+	status := sch.Status()
+	require.Equal(t, int64(1), status.BaseIntervalSeconds)
+	require.False(t, status.EvaluationPaused)
+	require.Equal(t, 3, status.RuleRoutines)
+	require.Len(t, status.Rules, 3)
+
+	evaluated := status.Rules[0]
+	require.Equal(t, intervalRule.UID, evaluated.UID)
+	require.Equal(t, intervalRule.ID, evaluated.ID)
+	require.Equal(t, int64(10), evaluated.IntervalSeconds)
+	require.NotNil(t, evaluated.LastEvaluation)
+	require.True(t, tick.Equal(*evaluated.LastEvaluation))
+	require.Equal(t, eval.Alerting.String(), evaluated.LastEvaluationState)
+	require.Empty(t, evaluated.LastError)
+	require.NotNil(t, evaluated.NextEvaluation)
+	require.True(t, tick.Add(10*time.Second).Equal(*evaluated.NextEvaluation))
+
+	scheduledByCron := status.Rules[1]
+	require.Equal(t, cronRule.UID, scheduledByCron.UID)
+	require.Equal(t, "0 8 * * 1-5", scheduledByCron.Schedule)
+	require.Equal(t, "Europe/Berlin", scheduledByCron.ScheduleTimezone)
+	require.Nil(t, scheduledByCron.LastEvaluation)
+	require.Empty(t, scheduledByCron.LastEvaluationState)
+	require.NotNil(t, scheduledByCron.NextEvaluation)
+	require.True(t, time.Date(2023, 3, 24, 7, 0, 0, 0, time.UTC).Equal(*scheduledByCron.NextEvaluation))
+
+	paused := status.Rules[2]
+	require.Equal(t, pausedRule.UID, paused.UID)
+	require.True(t, paused.IsPaused)
+	require.Nil(t, paused.LastEvaluation)
+	require.Nil(t, paused.NextEvaluation)
+}
+
+func TestSchedule_StatusBackoffAndOwner(t *testing.T) {
+	failure := errors.New("datasource not found")
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, failure)
+
+	ruleStore := newFakeRulesStore()
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	sch.backoffThreshold = 1
+	sch.backoffMax = time.Hour
+	sch.instanceID = "instance-1"
+	heartbeats := newFakeHeartbeatStore()
+	sch.heartbeatStore = heartbeats
+	sch.heartbeatTTL = time.Minute
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, tick time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: key, now: tick}
+	}
+
+	rule := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(10*time.Second), models.WithIsPaused(false))()
+	rule.ExecErrState = models.ErrorErrState
+	ruleStore.PutRule(context.Background(), rule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	tick := time.Date(2023, 3, 24, 7, 0, 0, 0, time.UTC)
+	sch.clock.(*clock.Mock).Set(tick)
+	sch.processTick(ctx, dispatcherGroup, tick)
+	assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+	tick = tick.Add(10 * time.Second)
+	sch.clock.(*clock.Mock).Set(tick)
+	sch.processTick(ctx, dispatcherGroup, tick)
+	assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+
+	t.Run("should report the next evaluation after the backoff", func(t *testing.T) {
+		status := sch.Status().Rules[0]
+		require.Equal(t, "instance-1", status.Owner)
+		// the second failure delays the next evaluation by two intervals, so the tick in one interval is skipped.
+		require.NotNil(t, status.BackoffUntil)
+		require.True(t, tick.Add(20*time.Second).Equal(*status.BackoffUntil))
+		require.NotNil(t, status.NextEvaluation)
+		require.True(t, tick.Add(20*time.Second).Equal(*status.NextEvaluation))
+	})
+
+	t.Run("should not report the next evaluation of a rule evaluated by another instance", func(t *testing.T) {
+		require.NoError(t, heartbeats.Heartbeat(ctx, "instance-0", tick))
+		tick = tick.Add(10 * time.Second)
+		sch.clock.(*clock.Mock).Set(tick)
+		sch.processTick(ctx, dispatcherGroup, tick)
+
+		status := sch.Status().Rules[0]
+		require.Equal(t, "instance-0", status.Owner)
+		require.Nil(t, status.NextEvaluation)
+	})
+}
+
+func TestSchedule_saveEvaluations(t *testing.T) {
+	failure := errors.New("datasource not found")
+	evaluator := eval_mocks.NewConditionEvaluatorMock(t)
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(eval.Results{{Instance: data.Labels{}, State: eval.Alerting}}, nil).Once()
+	evaluator.EXPECT().Evaluate(mock.Anything, mock.Anything).Return(nil, failure).Once()
+
+	ruleStore := newFakeRulesStore()
+	sch := setupScheduler(t, ruleStore, nil, nil, nil, eval_mocks.NewEvaluatorFactory(evaluator))
+	evaluationStore := newFakeEvaluationStore()
+	sch.evaluationStore = evaluationStore
+	sch.evaluationSaveInterval = 30 * time.Second
+	evalAppliedCh := make(chan evalAppliedInfo, 1)
+	sch.evalAppliedFunc = func(key models.AlertRuleKey, tick time.Time) {
+		evalAppliedCh <- evalAppliedInfo{alertDefKey: key, now: tick}
+	}
+
+	rule := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(10*time.Second), models.WithIsPaused(false))()
+	rule.ExecErrState = models.ErrorErrState
+	ruleStore.PutRule(context.Background(), rule)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dispatcherGroup, ctx := errgroup.WithContext(ctx)
+
+	tick := time.Date(2023, 3, 24, 7, 0, 0, 0, time.UTC)
+	sch.clock.(*clock.Mock).Set(tick)
+	sch.processTick(ctx, dispatcherGroup, tick)
+	assertEvalRun(t, evalAppliedCh, tick, rule.GetKey())
+	sch.saveEvaluations(ctx, tick, false)
+
+	first, saves := evaluationStore.get(rule.GetKey())
+	require.Equal(t, 1, saves)
+	require.True(t, tick.Equal(first.EvaluatedAt))
+	require.Equal(t, eval.Alerting.String(), first.State)
+	require.Empty(t, first.Error)
+
+	secondTick := tick.Add(10 * time.Second)
+	sch.clock.(*clock.Mock).Set(secondTick)
+	sch.processTick(ctx, dispatcherGroup, secondTick)
+	assertEvalRun(t, evalAppliedCh, secondTick, rule.GetKey())
+
+	t.Run("should not save more often than the save interval", func(t *testing.T) {
+		sch.saveEvaluations(ctx, secondTick, false)
+		stored, saves := evaluationStore.get(rule.GetKey())
+		require.Equal(t, 1, saves)
+		require.Equal(t, first, stored)
+	})
+
+	t.Run("should save the latest evaluation after the save interval", func(t *testing.T) {
+		sch.saveEvaluations(ctx, tick.Add(30*time.Second), false)
+		second, saves := evaluationStore.get(rule.GetKey())
+		require.Equal(t, 2, saves)
+		require.True(t, second.EvaluatedAt.After(first.EvaluatedAt))
+		require.True(t, secondTick.Equal(second.EvaluatedAt))
+		require.Equal(t, eval.Error.String(), second.State)
+		require.Contains(t, second.Error, failure.Error())
+	})
+
+	t.Run("should not save if the rule has not been evaluated since the last save", func(t *testing.T) {
+		sch.saveEvaluations(ctx, tick.Add(time.Hour), true)
+		_, saves := evaluationStore.get(rule.GetKey())
+		require.Equal(t, 2, saves)
+	})
+}
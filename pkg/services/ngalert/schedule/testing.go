@@ -2,6 +2,8 @@ package schedule
 
 import (
 	"context"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
@@ -80,3 +82,83 @@ func (f *fakeRulesStore) DeleteRule(rules ...*models.AlertRule) {
 func (f *fakeRulesStore) getNamespaceTitle(uid string) string {
 	return "TEST-FOLDER-" + uid
 }
+
+// fakeHeartbeatStore is an in-memory HeartbeatStore that can be shared by several schedulers.
+type fakeHeartbeatStore struct {
+	mtx        sync.Mutex
+	heartbeats map[string]time.Time
+	// failures are the errors returned by the heartbeats of the instances.
+	failures map[string]error
+}
+
+func newFakeHeartbeatStore() *fakeHeartbeatStore {
+	return &fakeHeartbeatStore{
+		heartbeats: map[string]time.Time{},
+		failures:   map[string]error{},
+	}
+}
+
+func (f *fakeHeartbeatStore) setFailure(instanceID string, err error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.failures[instanceID] = err
+}
+
+func (f *fakeHeartbeatStore) Heartbeat(_ context.Context, instanceID string, at time.Time) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if err := f.failures[instanceID]; err != nil {
+		return err
+	}
+	f.heartbeats[instanceID] = at
+	return nil
+}
+
+func (f *fakeHeartbeatStore) GetLiveInstances(_ context.Context, since time.Time) ([]string, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	result := make([]string, 0, len(f.heartbeats))
+	for id, at := range f.heartbeats {
+		if !at.Before(since) {
+			result = append(result, id)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (f *fakeHeartbeatStore) DeleteHeartbeat(_ context.Context, instanceID string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	delete(f.heartbeats, instanceID)
+	return nil
+}
+
+// fakeEvaluationStore is an in-memory EvaluationStore that records the number of saves.
+type fakeEvaluationStore struct {
+	mtx         sync.Mutex
+	evaluations map[models.AlertRuleKey]models.AlertRuleEvaluation
+	saves       int
+}
+
+func newFakeEvaluationStore() *fakeEvaluationStore {
+	return &fakeEvaluationStore{
+		evaluations: map[models.AlertRuleKey]models.AlertRuleEvaluation{},
+	}
+}
+
+func (f *fakeEvaluationStore) SaveAlertRuleEvaluations(_ context.Context, evaluations ...models.AlertRuleEvaluation) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.saves++
+	for _, e := range evaluations {
+		f.evaluations[models.AlertRuleKey{OrgID: e.RuleOrgID, UID: e.RuleUID}] = e
+	}
+	return nil
+}
+
+func (f *fakeEvaluationStore) get(key models.AlertRuleKey) (models.AlertRuleEvaluation, int) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.evaluations[key], f.saves
+}
@@ -0,0 +1,245 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/alertmanager/api/v2/models"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+const (
+	forwarderMaxBatchSize    = 64
+	forwarderMaxAttempts     = 5
+	forwarderInitialBackoff  = time.Second
+	forwarderMaxBackoff      = 30 * time.Second
+	alertmanagerAlertsAPIURL = "/api/v2/alerts"
+)
+
+// AlertmanagerForwarder forwards the alerts of all organizations to the external Alertmanagers of the configuration.
+// Every batch of alerts is posted to all the Alertmanagers, because they do not share the alerts they receive. An
+// Alertmanager that fails to accept a batch is retried with an exponential backoff, independently of the others.
+//
+// After creating an AlertmanagerForwarder, you must call Run to send the alerts.
+type AlertmanagerForwarder struct {
+	logger        log.Logger
+	clock         clock.Clock
+	client        *http.Client
+	alertmanagers []*url.URL
+	// sanitizer sanitizes the labels and annotations like for the external Alertmanagers of the organizations.
+	sanitizer *ExternalAlertmanager
+
+	mtx   sync.Mutex
+	queue models.PostableAlerts
+	more  chan struct{}
+
+	maxBatchSize   int
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+func NewAlertmanagerForwarder(alertmanagers []string, clk clock.Clock) (*AlertmanagerForwarder, error) {
+	if len(alertmanagers) == 0 {
+		return nil, errors.New("no Alertmanager is configured")
+	}
+	urls := make([]*url.URL, 0, len(alertmanagers))
+	for _, am := range alertmanagers {
+		u, err := url.Parse(am)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Alertmanager URL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("invalid Alertmanager URL %s: the scheme must be http or https", u.Redacted())
+		}
+		urls = append(urls, u)
+	}
+	logger := log.New("ngalert.sender.forwarder")
+	return &AlertmanagerForwarder{
+		logger:         logger,
+		clock:          clk,
+		client:         &http.Client{Timeout: defaultTimeout},
+		alertmanagers:  urls,
+		sanitizer:      &ExternalAlertmanager{logger: logger},
+		more:           make(chan struct{}, 1),
+		maxBatchSize:   forwarderMaxBatchSize,
+		maxAttempts:    forwarderMaxAttempts,
+		initialBackoff: forwarderInitialBackoff,
+		maxBackoff:     forwarderMaxBackoff,
+	}, nil
+}
+
+// SendAlerts queues the alerts to be forwarded. The oldest alerts are dropped if the queue is full.
+func (f *AlertmanagerForwarder) SendAlerts(alerts apimodels.PostableAlerts) {
+	if len(alerts.PostableAlerts) == 0 {
+		return
+	}
+	f.mtx.Lock()
+	for _, a := range alerts.PostableAlerts {
+		f.queue = append(f.queue, f.toPostableAlert(a))
+	}
+	if dropped := len(f.queue) - defaultMaxQueueCapacity; dropped > 0 {
+		f.logger.Warn("Alert queue of the external Alertmanagers is full, dropping the oldest alerts", "count", dropped)
+		f.queue = f.queue[dropped:]
+	}
+	f.mtx.Unlock()
+
+	select {
+	case f.more <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends the queued alerts until the context is canceled.
+func (f *AlertmanagerForwarder) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.more:
+		}
+		for batch := f.nextBatch(); len(batch) > 0; batch = f.nextBatch() {
+			f.send(ctx, batch)
+		}
+	}
+}
+
+func (f *AlertmanagerForwarder) nextBatch() models.PostableAlerts {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	n := len(f.queue)
+	if n > f.maxBatchSize {
+		n = f.maxBatchSize
+	}
+	batch := f.queue[:n:n]
+	f.queue = f.queue[n:]
+	return batch
+}
+
+// send posts the batch to all the Alertmanagers concurrently, and logs the Alertmanagers that did not accept it.
+func (f *AlertmanagerForwarder) send(ctx context.Context, batch models.PostableAlerts) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		f.logger.Error("Failed to encode alerts for the external Alertmanagers, dropping them", "count", len(batch), "error", err)
+		return
+	}
+	var wg sync.WaitGroup
+	for _, am := range f.alertmanagers {
+		wg.Add(1)
+		go func(am *url.URL) {
+			defer wg.Done()
+			if err := f.sendTo(ctx, am, body, len(batch)); err != nil {
+				f.logger.Error("Failed to send alerts to the external Alertmanager, dropping them", "alertmanager", am.Redacted(), "count", len(batch), "error", err)
+			}
+		}(am)
+	}
+	wg.Wait()
+}
+
+// sendTo posts the body to the Alertmanager, and retries with backoff while it fails with a retriable error.
+func (f *AlertmanagerForwarder) sendTo(ctx context.Context, am *url.URL, body []byte, count int) error {
+	backoff := f.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := f.postTo(ctx, am, body)
+		if err == nil {
+			return nil
+		}
+		var retriable retriableError
+		if !errors.As(err, &retriable) || attempt >= f.maxAttempts {
+			return err
+		}
+		f.logger.Warn("Failed to send alerts to the external Alertmanager, retrying", "alertmanager", am.Redacted(), "count", count, "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-f.clock.After(backoff):
+		}
+		backoff *= 2
+		if backoff > f.maxBackoff {
+			backoff = f.maxBackoff
+		}
+	}
+}
+
+func (f *AlertmanagerForwarder) postTo(ctx context.Context, am *url.URL, body []byte) error {
+	u := *am
+	u.Path = strings.TrimSuffix(u.Path, "/") + alertmanagerAlertsAPIURL
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return retriableError{fmt.Errorf("failed to send alerts to %s: %w", am.Redacted(), redactURLError(err))}
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	err = fmt.Errorf("alertmanager %s returned status %d", am.Redacted(), resp.StatusCode)
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return retriableError{err}
+	}
+	return err
+}
+
+func (f *AlertmanagerForwarder) toPostableAlert(alert models.PostableAlert) *models.PostableAlert {
+	a := f.sanitizer.alertToNotifierAlert(alert)
+	result := &models.PostableAlert{
+		Annotations: make(models.LabelSet, len(a.Annotations)),
+		StartsAt:    strfmt.DateTime(a.StartsAt),
+		EndsAt:      strfmt.DateTime(a.EndsAt),
+		Alert: models.Alert{
+			Labels:       make(models.LabelSet, len(a.Labels)),
+			GeneratorURL: strfmt.URI(a.GeneratorURL),
+		},
+	}
+	for _, l := range a.Labels {
+		result.Labels[l.Name] = l.Value
+	}
+	for _, l := range a.Annotations {
+		result.Annotations[l.Name] = l.Value
+	}
+	return result
+}
+
+// retriableError is an error of an Alertmanager that might accept the alerts when they are sent again.
+type retriableError struct {
+	err error
+}
+
+func (e retriableError) Error() string {
+	return e.err.Error()
+}
+
+func (e retriableError) Unwrap() error {
+	return e.err
+}
+
+// redactURLError removes the password of the URL from the error of the HTTP client.
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if u, perr := url.Parse(urlErr.URL); perr == nil {
+			return &url.Error{Op: urlErr.Op, URL: u.Redacted(), Err: urlErr.Err}
+		}
+	}
+	return err
+}
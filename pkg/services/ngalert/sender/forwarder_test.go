@@ -0,0 +1,256 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+// recordingAlertmanager is an Alertmanager that records the alerts it receives, and responds with the statuses in
+// order before it accepts the alerts.
+type recordingAlertmanager struct {
+	t        *testing.T
+	mtx      sync.Mutex
+	statuses []int
+	requests int
+	batches  []models.PostableAlerts
+	server   *httptest.Server
+}
+
+func newRecordingAlertmanager(t *testing.T, statuses ...int) *recordingAlertmanager {
+	t.Helper()
+	am := &recordingAlertmanager{t: t, statuses: statuses}
+	am.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		am.mtx.Lock()
+		defer am.mtx.Unlock()
+		am.requests++
+		if len(am.statuses) > 0 {
+			status := am.statuses[0]
+			am.statuses = am.statuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/prefix/api/v2/alerts", r.URL.Path)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		user, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "secret", password)
+		var batch models.PostableAlerts
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		require.NoError(t, batch.Validate(strfmt.Default))
+		am.batches = append(am.batches, batch)
+	}))
+	t.Cleanup(am.server.Close)
+	return am
+}
+
+func (am *recordingAlertmanager) URL() string {
+	return "http://user:secret@" + am.server.Listener.Addr().String() + "/prefix/"
+}
+
+func (am *recordingAlertmanager) received() (int, []models.PostableAlerts) {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+	return am.requests, am.batches
+}
+
+func (am *recordingAlertmanager) alertCount() int {
+	_, batches := am.received()
+	count := 0
+	for _, batch := range batches {
+		count += len(batch)
+	}
+	return count
+}
+
+func newTestForwarder(t *testing.T, alertmanagers ...*recordingAlertmanager) *AlertmanagerForwarder {
+	t.Helper()
+	urls := make([]string, 0, len(alertmanagers))
+	for _, am := range alertmanagers {
+		urls = append(urls, am.URL())
+	}
+	f, err := NewAlertmanagerForwarder(urls, clock.New())
+	require.NoError(t, err)
+	f.initialBackoff = time.Millisecond
+	f.maxBackoff = 4 * time.Millisecond
+	return f
+}
+
+func runForwarder(t *testing.T, f *AlertmanagerForwarder) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		f.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func testAlerts(names ...string) apimodels.PostableAlerts {
+	alerts := apimodels.PostableAlerts{}
+	for _, name := range names {
+		alerts.PostableAlerts = append(alerts.PostableAlerts, models.PostableAlert{
+			Alert: models.Alert{Labels: models.LabelSet{"alertname": name}},
+		})
+	}
+	return alerts
+}
+
+func TestAlertmanagerForwarder(t *testing.T) {
+	t.Run("posts firing and resolved alerts in the format of the Alertmanager API", func(t *testing.T) {
+		am := newRecordingAlertmanager(t)
+		f := newTestForwarder(t, am)
+		startsAt := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+		endsAt := startsAt.Add(time.Hour)
+		f.SendAlerts(apimodels.PostableAlerts{PostableAlerts: []models.PostableAlert{
+			{
+				Alert: models.Alert{
+					Labels:       models.LabelSet{"alertname": "HighLatency", "__alert_rule_uid__": "rule-uid", "team": "api", "invalid label": "value"},
+					GeneratorURL: "http://grafana/alerting/grafana/rule-uid/view",
+				},
+				Annotations: models.LabelSet{"summary": "Latency is high"},
+				StartsAt:    strfmt.DateTime(startsAt),
+			},
+			{
+				Alert:       models.Alert{Labels: models.LabelSet{"alertname": "DiskFull"}},
+				Annotations: models.LabelSet{},
+				StartsAt:    strfmt.DateTime(startsAt),
+				EndsAt:      strfmt.DateTime(endsAt),
+			},
+		}})
+		runForwarder(t, f)
+
+		require.Eventually(t, func() bool { return am.alertCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+		_, batches := am.received()
+		require.Len(t, batches, 1)
+		firing, resolved := batches[0][0], batches[0][1]
+		require.Equal(t, models.LabelSet{"alertname": "HighLatency", "__alert_rule_uid__": "rule-uid", "team": "api", "invalidlabel": "value"}, firing.Labels)
+		require.Equal(t, models.LabelSet{"summary": "Latency is high"}, firing.Annotations)
+		require.Equal(t, strfmt.URI("http://grafana/alerting/grafana/rule-uid/view"), firing.GeneratorURL)
+		require.True(t, startsAt.Equal(time.Time(firing.StartsAt)))
+		require.True(t, time.Time(firing.EndsAt).IsZero())
+		require.Equal(t, models.LabelSet{"alertname": "DiskFull"}, resolved.Labels)
+		require.True(t, endsAt.Equal(time.Time(resolved.EndsAt)))
+	})
+
+	t.Run("posts the alerts in batches", func(t *testing.T) {
+		am := newRecordingAlertmanager(t)
+		f := newTestForwarder(t, am)
+		f.maxBatchSize = 2
+		f.SendAlerts(testAlerts("a", "b", "c"))
+		f.SendAlerts(testAlerts("d", "e"))
+		runForwarder(t, f)
+
+		require.Eventually(t, func() bool { return am.alertCount() == 5 }, 5*time.Second, 10*time.Millisecond)
+		_, batches := am.received()
+		require.Len(t, batches, 3)
+		var names []string
+		for i, batch := range batches {
+			require.LessOrEqual(t, len(batch), 2, "batch %d is too large", i)
+			for _, alert := range batch {
+				names = append(names, alert.Labels["alertname"])
+			}
+		}
+		require.Equal(t, []string{"a", "b", "c", "d", "e"}, names)
+	})
+
+	t.Run("retries with backoff if the Alertmanager fails with 5xx", func(t *testing.T) {
+		am := newRecordingAlertmanager(t, http.StatusInternalServerError, http.StatusServiceUnavailable)
+		f := newTestForwarder(t, am)
+		f.SendAlerts(testAlerts("a"))
+		runForwarder(t, f)
+
+		require.Eventually(t, func() bool { return am.alertCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+		requests, _ := am.received()
+		require.Equal(t, 3, requests)
+	})
+
+	t.Run("drops the alerts after the last attempt", func(t *testing.T) {
+		am := newRecordingAlertmanager(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+		f := newTestForwarder(t, am)
+		f.maxAttempts = 2
+		f.SendAlerts(testAlerts("a"))
+		runForwarder(t, f)
+
+		require.Eventually(t, func() bool { requests, _ := am.received(); return requests == 2 }, 5*time.Second, 10*time.Millisecond)
+		f.SendAlerts(testAlerts("b"))
+		require.Eventually(t, func() bool { requests, _ := am.received(); return requests == 4 }, 5*time.Second, 10*time.Millisecond)
+		f.SendAlerts(testAlerts("c"))
+		require.Eventually(t, func() bool { return am.alertCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+		_, batches := am.received()
+		require.Equal(t, "c", batches[0][0].Labels["alertname"])
+	})
+
+	t.Run("does not retry if the Alertmanager rejects the alerts", func(t *testing.T) {
+		rejecting := newRecordingAlertmanager(t, http.StatusBadRequest)
+		other := newRecordingAlertmanager(t)
+		f := newTestForwarder(t, rejecting, other)
+		f.SendAlerts(testAlerts("a"))
+		runForwarder(t, f)
+
+		require.Eventually(t, func() bool { return other.alertCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+		require.Eventually(t, func() bool { requests, _ := rejecting.received(); return requests == 1 }, 5*time.Second, 10*time.Millisecond)
+		f.SendAlerts(testAlerts("b"))
+		require.Eventually(t, func() bool { return rejecting.alertCount() == 1 && other.alertCount() == 2 }, 5*time.Second, 10*time.Millisecond)
+		requests, _ := rejecting.received()
+		require.Equal(t, 2, requests, "the rejected alerts must not be posted again")
+	})
+
+	t.Run("retries only the Alertmanagers that failed", func(t *testing.T) {
+		failing := newRecordingAlertmanager(t, http.StatusInternalServerError)
+		healthy := newRecordingAlertmanager(t)
+		f := newTestForwarder(t, failing, healthy)
+		f.SendAlerts(testAlerts("a"))
+		runForwarder(t, f)
+
+		require.Eventually(t, func() bool { return failing.alertCount() == 1 && healthy.alertCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+		requests, _ := failing.received()
+		require.Equal(t, 2, requests)
+		requests, _ = healthy.received()
+		require.Equal(t, 1, requests)
+	})
+
+	t.Run("posts every batch to every Alertmanager", func(t *testing.T) {
+		first := newRecordingAlertmanager(t)
+		second := newRecordingAlertmanager(t)
+		f := newTestForwarder(t, first, second)
+		f.maxBatchSize = 1
+		f.SendAlerts(testAlerts("a", "b", "c", "d"))
+		runForwarder(t, f)
+
+		require.Eventually(t, func() bool { return first.alertCount() == 4 && second.alertCount() == 4 }, 5*time.Second, 10*time.Millisecond)
+		for _, am := range []*recordingAlertmanager{first, second} {
+			_, batches := am.received()
+			var names []string
+			for _, batch := range batches {
+				require.Len(t, batch, 1)
+				names = append(names, batch[0].Labels["alertname"])
+			}
+			require.Equal(t, []string{"a", "b", "c", "d"}, names)
+		}
+	})
+
+	t.Run("fails if an Alertmanager URL is invalid", func(t *testing.T) {
+		_, err := NewAlertmanagerForwarder([]string{"alertmanager:9093"}, clock.New())
+		require.Error(t, err)
+		_, err = NewAlertmanagerForwarder(nil, clock.New())
+		require.Error(t, err)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/benbjohnson/clock"
@@ -47,11 +48,22 @@ type AlertsRouter struct {
 
 	datasourceService datasources.DataSourceService
 	secretService     secrets.Service
+
+	// forwarder forwards the alerts of all organizations to the external Alertmanagers of the configuration, if any.
+	forwarder *AlertmanagerForwarder
+	// forwardOnly determines whether the alerts that are forwarded are not sent to the internal Alertmanager.
+	forwardOnly bool
+
+	// dispatched and failed count the alerts that were sent to at least one Alertmanager, and those that failed to be sent
+	// to at least one Alertmanager.
+	dispatched atomic.Int64
+	failed     atomic.Int64
 }
 
 func NewAlertsRouter(multiOrgNotifier *notifier.MultiOrgAlertmanager, store store.AdminConfigurationStore,
 	clk clock.Clock, appURL *url.URL, disabledOrgs map[int64]struct{}, configPollInterval time.Duration,
-	datasourceService datasources.DataSourceService, secretService secrets.Service) *AlertsRouter {
+	datasourceService datasources.DataSourceService, secretService secrets.Service,
+	forwarder *AlertmanagerForwarder, forwardOnly bool) *AlertsRouter {
 	d := &AlertsRouter{
 		logger:           log.New("ngalert.sender.router"),
 		clock:            clk,
@@ -70,6 +82,9 @@ func NewAlertsRouter(multiOrgNotifier *notifier.MultiOrgAlertmanager, store stor
 
 		datasourceService: datasourceService,
 		secretService:     secretService,
+
+		forwarder:   forwarder,
+		forwardOnly: forwardOnly,
 	}
 	return d
 }
@@ -272,21 +287,24 @@ func (d *AlertsRouter) buildExternalURL(ds *datasources.DataSource) (string, err
 func (d *AlertsRouter) Send(key models.AlertRuleKey, alerts definitions.PostableAlerts) {
 	logger := d.logger.New(key.LogContext()...)
 	if len(alerts.PostableAlerts) == 0 {
-		logger.Info("No alerts to notify about")
+		logger.Debug("No alerts to notify about")
 		return
 	}
 	// Send alerts to local notifier if they need to be handled internally
 	// or if no external AMs have been discovered yet.
 	var localNotifierExist, externalNotifierExist bool
-	if d.sendAlertsTo[key.OrgID] == models.ExternalAlertmanagers && len(d.AlertmanagersFor(key.OrgID)) > 0 {
+	if d.forwarder != nil && d.forwardOnly {
+		logger.Debug("All alerts are forwarded to the configured external Alertmanagers only. skipping the internal notifier.")
+	} else if d.sendAlertsTo[key.OrgID] == models.ExternalAlertmanagers && len(d.AlertmanagersFor(key.OrgID)) > 0 {
 		logger.Debug("All alerts for the given org should be routed to external notifiers only. skipping the internal notifier.")
 	} else {
-		logger.Info("Sending alerts to local notifier", "count", len(alerts.PostableAlerts))
+		logger.Debug("Sending alerts to local notifier", "count", len(alerts.PostableAlerts))
 		n, err := d.multiOrgNotifier.AlertmanagerFor(key.OrgID)
 		if err == nil {
 			localNotifierExist = true
 			if err := n.PutAlerts(alerts); err != nil {
 				logger.Error("Failed to put alerts in the local notifier", "count", len(alerts.PostableAlerts), "error", err)
+				d.failed.Add(int64(len(alerts.PostableAlerts)))
 			}
 		} else {
 			if errors.Is(err, notifier.ErrNoAlertmanagerForOrg) {
@@ -303,13 +321,32 @@ func (d *AlertsRouter) Send(key models.AlertRuleKey, alerts definitions.Postable
 	defer d.adminConfigMtx.RUnlock()
 	s, ok := d.externalAlertmanagers[key.OrgID]
 	if ok && d.sendAlertsTo[key.OrgID] != models.InternalAlertmanager {
-		logger.Info("Sending alerts to external notifier", "count", len(alerts.PostableAlerts))
+		logger.Debug("Sending alerts to external notifier", "count", len(alerts.PostableAlerts))
 		s.SendAlerts(alerts)
 		externalNotifierExist = true
 	}
 
+	// Forward the alerts to the external Alertmanager(s) of the configuration.
+	if d.forwarder != nil {
+		logger.Debug("Forwarding alerts to the configured external Alertmanagers", "count", len(alerts.PostableAlerts))
+		d.forwarder.SendAlerts(alerts)
+		externalNotifierExist = true
+	}
+
 	if !localNotifierExist && !externalNotifierExist {
 		logger.Error("No external or internal notifier - alerts not delivered", "count", len(alerts.PostableAlerts))
+		d.failed.Add(int64(len(alerts.PostableAlerts)))
+		return
+	}
+	d.dispatched.Add(int64(len(alerts.PostableAlerts)))
+}
+
+// Stats returns the number of alerts that were dispatched to the Alertmanagers and that failed to be dispatched since
+// the router was created.
+func (d *AlertsRouter) Stats() definitions.NotificationStats {
+	return definitions.NotificationStats{
+		Dispatched: d.dispatched.Load(),
+		Failed:     d.failed.Load(),
 	}
 }
 
@@ -338,6 +375,14 @@ func (d *AlertsRouter) DroppedAlertmanagersFor(orgID int64) []*url.URL {
 
 // Run starts regular updates of the configuration.
 func (d *AlertsRouter) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	if d.forwarder != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.forwarder.Run(ctx)
+		}()
+	}
 	for {
 		select {
 		case <-time.After(d.adminConfigPollInterval):
@@ -353,6 +398,7 @@ func (d *AlertsRouter) Run(ctx context.Context) error {
 			}
 			d.adminConfigMtx.Unlock()
 
+			wg.Wait()
 			return nil
 		}
 	}
@@ -62,7 +62,7 @@ func TestIntegrationSendingToExternalAlertmanager(t *testing.T) {
 		}),
 	}
 	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, mockedClock, appUrl, map[int64]struct{}{}, 10*time.Minute,
-		&fake_ds.FakeDataSourceService{DataSources: []*datasources.DataSource{&ds1}}, fake_secrets.NewFakeSecretsService())
+		&fake_ds.FakeDataSourceService{DataSources: []*datasources.DataSource{&ds1}}, fake_secrets.NewFakeSecretsService(), nil, false)
 
 	mockedGetAdminConfigurations.Return([]*models.AdminConfiguration{
 		{OrgID: ruleKey.OrgID, SendAlertsTo: models.AllAlertmanagers},
@@ -133,7 +133,7 @@ func TestIntegrationSendingToExternalAlertmanager_WithMultipleOrgs(t *testing.T)
 	}
 	fakeDs := &fake_ds.FakeDataSourceService{DataSources: []*datasources.DataSource{&ds1}}
 	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, mockedClock, appUrl, map[int64]struct{}{}, 10*time.Minute,
-		fakeDs, fake_secrets.NewFakeSecretsService())
+		fakeDs, fake_secrets.NewFakeSecretsService(), nil, false)
 
 	mockedGetAdminConfigurations.Return([]*models.AdminConfiguration{
 		{OrgID: ruleKey1.OrgID, SendAlertsTo: models.AllAlertmanagers},
@@ -292,7 +292,7 @@ func TestChangingAlertmanagersChoice(t *testing.T) {
 		}),
 	}
 	alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, mockedClock, appUrl, map[int64]struct{}{},
-		10*time.Minute, &fake_ds.FakeDataSourceService{DataSources: []*datasources.DataSource{&ds}}, fake_secrets.NewFakeSecretsService())
+		10*time.Minute, &fake_ds.FakeDataSourceService{DataSources: []*datasources.DataSource{&ds}}, fake_secrets.NewFakeSecretsService(), nil, false)
 
 	mockedGetAdminConfigurations.Return([]*models.AdminConfiguration{
 		{OrgID: ruleKey.OrgID, SendAlertsTo: models.AllAlertmanagers},
@@ -355,6 +355,57 @@ func TestChangingAlertmanagersChoice(t *testing.T) {
 	require.Len(t, actualAlerts, len(expected))
 }
 
+func TestSendingToConfiguredAlertmanagers(t *testing.T) {
+	ruleKey := models.GenerateRuleKey(1)
+	fakeAdminConfigStore := &store.AdminConfigurationStoreMock{}
+	fakeAdminConfigStore.EXPECT().GetAdminConfigurations().Return([]*models.AdminConfiguration{
+		{OrgID: ruleKey.OrgID, SendAlertsTo: models.AllAlertmanagers},
+	}, nil)
+	appUrl := &url.URL{Scheme: "http", Host: "localhost"}
+
+	for _, forwardOnly := range []bool{false, true} {
+		t.Run(fmt.Sprintf("forward only %t", forwardOnly), func(t *testing.T) {
+			mockedClock := clock.NewMock()
+			moa := createMultiOrgAlertmanager(t, []int64{ruleKey.OrgID})
+			am := newRecordingAlertmanager(t)
+			forwarder, err := NewAlertmanagerForwarder([]string{am.URL()}, mockedClock)
+			require.NoError(t, err)
+			alertsRouter := NewAlertsRouter(moa, fakeAdminConfigStore, mockedClock, appUrl, map[int64]struct{}{}, 10*time.Minute,
+				&fake_ds.FakeDataSourceService{}, fake_secrets.NewFakeSecretsService(), forwarder, forwardOnly)
+			require.NoError(t, alertsRouter.SyncAndApplyConfigFromDatabase())
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				_ = alertsRouter.Run(ctx)
+				close(done)
+			}()
+			t.Cleanup(func() {
+				cancel()
+				<-done
+			})
+
+			// the alert must be active for the internal Alertmanager to list it
+			alert := generatePostableAlert(t, clock.New())
+			alertsRouter.Send(ruleKey, definitions.PostableAlerts{PostableAlerts: []models2.PostableAlert{alert}})
+
+			require.Eventually(t, func() bool { return am.alertCount() == 1 }, 10*time.Second, 10*time.Millisecond)
+			_, batches := am.received()
+			// the label names are sanitized for the Alertmanager
+			require.Len(t, batches[0][0].Labels, len(alert.Labels))
+
+			local, err := moa.AlertmanagerFor(ruleKey.OrgID)
+			require.NoError(t, err)
+			alerts, err := local.GetAlerts(true, true, true, nil, "")
+			require.NoError(t, err)
+			if forwardOnly {
+				require.Empty(t, alerts)
+			} else {
+				require.Len(t, alerts, 1)
+			}
+		})
+	}
+}
+
 func assertAlertmanagersStatusForOrg(t *testing.T, alertsRouter *AlertsRouter, orgID int64, active, dropped int) {
 	t.Helper()
 	require.Eventuallyf(t, func() bool {
@@ -20,6 +20,15 @@ import (
 
 type ruleStates struct {
 	states map[string]*State
+	// saved are the instances of the states as they were last written to the instance store, by state ID.
+	saved map[string]*savedInstance
+}
+
+// savedInstance is an instance as it was last written to the instance store, and the number of evaluations of its
+// state since then.
+type savedInstance struct {
+	instance    ngModels.AlertInstance
+	evaluations int64
 }
 
 type cache struct {
@@ -122,6 +131,7 @@ func (rs *ruleStates) getOrCreate(ctx context.Context, log log.Logger, alertRule
 		}
 		state.Annotations = annotations
 		state.Values = values
+		state.ResultLabels = result.Instance.String()
 		rs.states[id] = state
 		return state
 	}
@@ -132,6 +142,7 @@ func (rs *ruleStates) getOrCreate(ctx context.Context, log log.Logger, alertRule
 		AlertRuleUID:       alertRule.UID,
 		OrgID:              alertRule.OrgID,
 		CacheID:            id,
+		ResultLabels:       result.Instance.String(),
 		Labels:             lbs,
 		Annotations:        annotations,
 		EvaluationDuration: result.EvaluationDuration,
@@ -172,6 +183,7 @@ func (rs *ruleStates) deleteStates(predicate func(s *State) bool) []*State {
 	for id, state := range rs.states {
 		if predicate(state) {
 			delete(rs.states, id)
+			delete(rs.saved, id)
 			deleted = append(deleted, state)
 		}
 	}
@@ -256,6 +268,38 @@ func (c *cache) getStatesForRuleUID(orgID int64, alertRuleUID string, skipNormal
 	return result
 }
 
+// countEvaluation counts an evaluation of the state, and returns its instance as it was last written to the instance
+// store, or nil if it has not been written since the state was created.
+func (c *cache) countEvaluation(s *State) *savedInstance {
+	c.mtxStates.Lock()
+	defer c.mtxStates.Unlock()
+	rs, ok := c.states[s.OrgID][s.AlertRuleUID]
+	if !ok {
+		return nil
+	}
+	saved, ok := rs.saved[s.CacheID]
+	if !ok {
+		return nil
+	}
+	saved.evaluations++
+	result := *saved
+	return &result
+}
+
+// setSaved records the instance of the state that was written to the instance store.
+func (c *cache) setSaved(s *State, instance ngModels.AlertInstance) {
+	c.mtxStates.Lock()
+	defer c.mtxStates.Unlock()
+	rs, ok := c.states[s.OrgID][s.AlertRuleUID]
+	if !ok || rs.states[s.CacheID] != s {
+		return
+	}
+	if rs.saved == nil {
+		rs.saved = make(map[string]*savedInstance)
+	}
+	rs.saved[s.CacheID] = &savedInstance{instance: instance}
+}
+
 // removeByRuleUID deletes all entries in the state cache that match the given UID. Returns removed states
 func (c *cache) removeByRuleUID(orgID int64, uid string) []*State {
 	c.mtxStates.Lock()
@@ -280,6 +324,13 @@ func (c *cache) removeByRuleUID(orgID int64, uid string) []*State {
 }
 
 func (c *cache) recordMetrics(metrics *metrics.State) {
+	for k, n := range c.countStates() {
+		metrics.AlertState.WithLabelValues(strings.ToLower(k.String())).Set(float64(n))
+	}
+}
+
+// countStates returns the number of states in every state. All the states are present, even if there are no states in them.
+func (c *cache) countStates() map[eval.State]int {
 	c.mtxStates.RLock()
 	defer c.mtxStates.RUnlock()
 
@@ -302,9 +353,7 @@ func (c *cache) recordMetrics(metrics *metrics.State) {
 		}
 	}
 
-	for k, n := range ct {
-		metrics.AlertState.WithLabelValues(strings.ToLower(k.String())).Set(float64(n))
-	}
+	return ct
 }
 
 // if duplicate labels exist, keep the value from the first set
@@ -31,6 +31,8 @@ type AnnotationBackend struct {
 	clock       clock.Clock
 	metrics     *metrics.Historian
 	log         log.Logger
+	// recordPending controls whether transitions to and from Pending are annotated.
+	recordPending bool
 }
 
 type RuleStore interface {
@@ -42,7 +44,8 @@ type AnnotationStore interface {
 	SaveMany(ctx context.Context, items []annotations.Item) error
 }
 
-func NewAnnotationBackend(annotations AnnotationStore, dashboards dashboards.DashboardService, rules RuleStore, metrics *metrics.Historian) *AnnotationBackend {
+// NewAnnotationBackend creates a new AnnotationBackend. Transitions to Pending, and from Pending back to Normal, are annotated only if recordPending is true.
+func NewAnnotationBackend(annotations AnnotationStore, dashboards dashboards.DashboardService, rules RuleStore, metrics *metrics.Historian, recordPending bool) *AnnotationBackend {
 	logger := log.New("ngalert.state.historian", "backend", "annotations")
 	return &AnnotationBackend{
 		annotations: annotations,
@@ -51,6 +54,8 @@ func NewAnnotationBackend(annotations AnnotationStore, dashboards dashboards.Das
 		clock:       clock.New(),
 		metrics:     metrics,
 		log:         logger,
+
+		recordPending: recordPending,
 	}
 }
 
@@ -58,6 +63,9 @@ func NewAnnotationBackend(annotations AnnotationStore, dashboards dashboards.Das
 func (h *AnnotationBackend) Record(ctx context.Context, rule history_model.RuleMeta, states []state.StateTransition) <-chan error {
 	logger := h.log.FromContext(ctx)
 	// Build annotations before starting goroutine, to make sure all data is copied and won't mutate underneath us.
+	if !h.recordPending {
+		states = withoutPending(states)
+	}
 	annotations := buildAnnotations(rule, states, logger)
 	panel := parsePanelKey(rule, logger)
 
@@ -168,6 +176,9 @@ func buildAnnotations(rule history_model.RuleMeta, states []state.StateTransitio
 		logger.Debug("Alert state changed creating annotation", "newState", state.Formatted(), "oldState", state.PreviousFormatted())
 
 		annotationText, annotationData := buildAnnotationTextAndData(rule, state.State)
+		if isResolved(state) {
+			annotationData.Set("resolved", true)
+		}
 
 		item := annotations.Item{
 			AlertID:   rule.ID,
@@ -184,6 +195,24 @@ func buildAnnotations(rule history_model.RuleMeta, states []state.StateTransitio
 	return items
 }
 
+// isResolved returns true if the alert stopped firing in the transition.
+func isResolved(transition state.StateTransition) bool {
+	return transition.Kind() == state.TransitionResolved
+}
+
+// withoutPending returns the transitions that are neither to Pending nor from Pending back to Normal.
+// The latter is skipped because the alert did not fire and the start of the pending period is not annotated.
+func withoutPending(states []state.StateTransition) []state.StateTransition {
+	result := make([]state.StateTransition, 0, len(states))
+	for _, s := range states {
+		if s.State.State == eval.Pending || (s.PreviousState == eval.Pending && s.State.State == eval.Normal) {
+			continue
+		}
+		result = append(result, s)
+	}
+	return result
+}
+
 func (h *AnnotationBackend) recordAnnotations(ctx context.Context, panel *panelKey, annotations []annotations.Item, orgID int64, logger log.Logger) error {
 	if panel != nil {
 		dashID, err := h.dashboards.getID(ctx, panel.orgID, panel.dashUID)
@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"math"
+	"sort"
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/mock"
@@ -100,6 +102,83 @@ grafana_alerting_state_history_writes_total{backend="annotations",org="1"} 2
 	})
 }
 
+func TestAnnotationHistorian_Transitions(t *testing.T) {
+	rule := models.AlertRuleGen(withOrgID(1), models.WithFor(2*time.Minute), models.WithInterval(time.Minute))()
+	// a series goes through Pending to Alerting, resolves, and then starts pending again but never fires
+	evaluations := []eval.State{eval.Normal, eval.Normal, eval.Alerting, eval.Alerting, eval.Alerting, eval.Alerting, eval.Normal, eval.Alerting, eval.Normal}
+
+	testCases := []struct {
+		name          string
+		recordPending bool
+		expected      [][2]string
+	}{
+		{
+			name:          "annotates only transitions to Alerting and back to Normal",
+			recordPending: false,
+			expected:      [][2]string{{"Pending", "Alerting"}, {"Alerting", "Normal"}},
+		},
+		{
+			name:          "annotates transitions to and from Pending if enabled",
+			recordPending: true,
+			expected: [][2]string{
+				{"Normal", "Pending"}, {"Pending", "Alerting"}, {"Alerting", "Normal"}, {"Normal", "Pending"}, {"Pending", "Normal"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := annotationstest.NewFakeAnnotationsRepo()
+			anns := NewAnnotationBackend(repo, &dashboards.FakeDashboardService{}, fakes.NewRuleStore(t), metrics.NewHistorianMetrics(prometheus.NewRegistry()), tc.recordPending)
+			st := state.NewManager(state.ManagerCfg{
+				Metrics:   metrics.NewNGAlert(prometheus.NewPedanticRegistry()).GetStateMetrics(),
+				Images:    &state.NoopImageService{},
+				Clock:     clock.NewMock(),
+				Historian: syncHistorian{t: t, backend: anns},
+			})
+
+			start := time.Now()
+			for i, s := range evaluations {
+				evaluatedAt := start.Add(time.Duration(i) * time.Minute)
+				st.ProcessEvalResults(context.Background(), evaluatedAt, rule, eval.Results{{
+					Instance:    data.Labels{"a": "b"},
+					State:       s,
+					EvaluatedAt: evaluatedAt,
+				}}, nil)
+			}
+
+			items := make([]annotations.Item, 0, repo.Len())
+			for _, item := range repo.Items() {
+				items = append(items, item)
+			}
+			sort.Slice(items, func(i, j int) bool {
+				return items[i].Epoch < items[j].Epoch
+			})
+			actual := make([][2]string, 0, len(items))
+			for _, item := range items {
+				require.Equal(t, rule.OrgID, item.OrgID)
+				require.Equal(t, rule.ID, item.AlertID)
+				require.Contains(t, item.Text, rule.Title)
+				require.Contains(t, item.Text, "a=b")
+				actual = append(actual, [2]string{item.PrevState, item.NewState})
+			}
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+// syncHistorian waits until the backend has recorded the transitions, so that the state manager can be tested without polling.
+type syncHistorian struct {
+	t       *testing.T
+	backend state.Historian
+}
+
+func (h syncHistorian) Record(ctx context.Context, rule history_model.RuleMeta, states []state.StateTransition) <-chan error {
+	require.NoError(h.t, <-h.backend.Record(ctx, rule, states))
+	errCh := make(chan error)
+	close(errCh)
+	return errCh
+}
+
 func createTestAnnotationBackendSut(t *testing.T) *AnnotationBackend {
 	return createTestAnnotationBackendSutWithMetrics(t, metrics.NewHistorianMetrics(prometheus.NewRegistry()))
 }
@@ -113,7 +192,7 @@ func createTestAnnotationBackendSutWithMetrics(t *testing.T, met *metrics.Histor
 	}
 	dbs := &dashboards.FakeDashboardService{}
 	dbs.On("GetDashboard", mock.Anything, mock.Anything).Return(&dashboards.Dashboard{}, nil)
-	return NewAnnotationBackend(fakeAnnoRepo, dbs, rules, met)
+	return NewAnnotationBackend(fakeAnnoRepo, dbs, rules, met, true)
 }
 
 func createFailingAnnotationSut(t *testing.T, met *metrics.Historian) *AnnotationBackend {
@@ -124,7 +203,7 @@ func createFailingAnnotationSut(t *testing.T, met *metrics.Historian) *Annotatio
 	}
 	dbs := &dashboards.FakeDashboardService{}
 	dbs.On("GetDashboard", mock.Anything, mock.Anything).Return(&dashboards.Dashboard{}, nil)
-	return NewAnnotationBackend(fakeAnnoRepo, dbs, rules, met)
+	return NewAnnotationBackend(fakeAnnoRepo, dbs, rules, met, true)
 }
 
 func createAnnotation() annotations.Item {
@@ -190,6 +269,19 @@ func TestBuildAnnotations(t *testing.T) {
 	})
 }
 
+func TestBuildAnnotations_Resolved(t *testing.T) {
+	rule := history_model.RuleMeta{}
+	resolved := state.StateTransition{State: &state.State{State: eval.Normal}, PreviousState: eval.Alerting}
+	notFired := state.StateTransition{State: &state.State{State: eval.Normal}, PreviousState: eval.Pending}
+
+	items := buildAnnotations(rule, []state.StateTransition{resolved, notFired}, log.NewNopLogger())
+
+	require.Len(t, items, 2)
+	require.True(t, items[0].Data.Get("resolved").MustBool())
+	_, ok := items[1].Data.CheckGet("resolved")
+	require.False(t, ok)
+}
+
 func makeStateTransition() state.StateTransition {
 	return state.StateTransition{
 		State: &state.State{
@@ -2,6 +2,9 @@ package historian
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
@@ -11,13 +14,22 @@ import (
 	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
 )
 
+// StateHistoryStore reads the state transitions that the state manager saves to the database.
+type StateHistoryStore interface {
+	GetAlertStateHistory(ctx context.Context, query *models.GetAlertStateHistoryQuery) ([]*models.AlertStateHistory, error)
+}
+
+// SqlBackend is a state.Historian that reads the state history from the database.
+// The transitions are written by the state manager in the same transaction as the alert instances, so Record does nothing.
 type SqlBackend struct {
-	log log.Logger
+	store StateHistoryStore
+	log   log.Logger
 }
 
-func NewSqlBackend() *SqlBackend {
+func NewSqlBackend(store StateHistoryStore) *SqlBackend {
 	return &SqlBackend{
-		log: log.New("ngalert.state.historian"),
+		store: store,
+		log:   log.New("ngalert.state.historian"),
 	}
 }
 
@@ -28,5 +40,71 @@ func (h *SqlBackend) Record(ctx context.Context, _ history_model.RuleMeta, _ []s
 }
 
 func (h *SqlBackend) Query(ctx context.Context, query models.HistoryQuery) (*data.Frame, error) {
-	return data.NewFrame("states"), nil
+	if query.RuleUID == "" {
+		return nil, fmt.Errorf("ruleUID is required to query the state history")
+	}
+	history, err := h.store.GetAlertStateHistory(ctx, &models.GetAlertStateHistoryQuery{
+		OrgID:   query.OrgID,
+		RuleUID: query.RuleUID,
+		From:    query.From,
+		To:      query.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query state history: %w", err)
+	}
+
+	lbls := data.Labels(map[string]string{
+		"from":    "state-history",
+		"ruleUID": query.RuleUID,
+	})
+
+	// The frame has the same shape as the one of the annotation backend, except that `text` is replaced by `labels`,
+	// which contains the labels of the alert instance as a JSON object.
+	times := make([]time.Time, 0, len(history))
+	labels := make([]string, 0, len(history))
+	prevStates := make([]string, 0, len(history))
+	nextStates := make([]string, 0, len(history))
+	values := make([]string, 0, len(history))
+	for _, item := range history {
+		if !matchesLabels(item.Labels, query.Labels) {
+			continue
+		}
+		l, err := json.Marshal(item.Labels)
+		if err != nil {
+			h.log.Error("Failed to marshal the labels of a state transition, skipping", "ruleUID", item.RuleUID, "error", err)
+			continue
+		}
+		v, err := json.Marshal(item.Values.Nullable())
+		if err != nil {
+			h.log.Error("Failed to marshal the values of a state transition, skipping", "ruleUID", item.RuleUID, "error", err)
+			continue
+		}
+		next := string(item.State)
+		if item.StateReason != "" {
+			next = fmt.Sprintf("%s (%s)", next, item.StateReason)
+		}
+		times = append(times, item.TransitionedAt)
+		labels = append(labels, string(l))
+		prevStates = append(prevStates, string(item.PreviousState))
+		nextStates = append(nextStates, next)
+		values = append(values, string(v))
+	}
+
+	frame := data.NewFrame("states")
+	frame.Fields = append(frame.Fields, data.NewField("time", lbls, times))
+	frame.Fields = append(frame.Fields, data.NewField("labels", lbls, labels))
+	frame.Fields = append(frame.Fields, data.NewField("prev", lbls, prevStates))
+	frame.Fields = append(frame.Fields, data.NewField("next", lbls, nextStates))
+	frame.Fields = append(frame.Fields, data.NewField("data", lbls, values))
+	return frame, nil
+}
+
+// matchesLabels returns true if the labels contain all of the matchers.
+func matchesLabels(labels models.InstanceLabels, matchers map[string]string) bool {
+	for k, v := range matchers {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,83 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// ErrTooManySeries is returned when an evaluation of a rule returns more series than the instance limit of the rule.
+var ErrTooManySeries = errors.New("too many series")
+
+// TooManySeriesError is returned by LimitResults when the results exceed the instance limit of the rule. It wraps ErrTooManySeries.
+type TooManySeriesError struct {
+	Limit  int64
+	Series int
+}
+
+func (e *TooManySeriesError) Error() string {
+	return fmt.Sprintf("%s: the evaluation returned %d series but the rule is limited to %d instances", ErrTooManySeries, e.Series, e.Limit)
+}
+
+func (e *TooManySeriesError) Unwrap() error {
+	return ErrTooManySeries
+}
+
+// instanceLimit returns the maximum number of instances of the rule, or 0 if the number of instances is not limited.
+func (st *Manager) instanceLimit(alertRule *ngModels.AlertRule) int64 {
+	limit := st.instancesPerRuleLimit
+	if alertRule.InstanceLimit > 0 {
+		limit = alertRule.InstanceLimit
+	}
+	if st.maxInstancesPerRuleLimit > 0 && (limit == 0 || limit > st.maxInstancesPerRuleLimit) {
+		limit = st.maxInstancesPerRuleLimit
+	}
+	return limit
+}
+
+// LimitResults returns at most as many results as the instance limit of the rule allows, and TooManySeriesError if some
+// results were dropped. The results of series that already have a state are kept first, so that the existing instances
+// are not resolved because of new series. The other results are kept in the order of their labels, so that the same series
+// are kept by every evaluation.
+func (st *Manager) LimitResults(ctx context.Context, alertRule *ngModels.AlertRule, results eval.Results) (eval.Results, error) {
+	limit := st.instanceLimit(alertRule)
+	if limit == 0 || int64(len(results)) <= limit {
+		return results, nil
+	}
+
+	existing := make(map[string]struct{})
+	for _, s := range st.cache.getStatesForRuleUID(alertRule.OrgID, alertRule.UID, false) {
+		existing[s.ResultLabels] = struct{}{}
+	}
+	keys := make([]string, len(results))
+	for i, result := range results {
+		keys[i] = result.Instance.String()
+	}
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		_, iExists := existing[keys[order[i]]]
+		_, jExists := existing[keys[order[j]]]
+		if iExists != jExists {
+			return iExists
+		}
+		return keys[order[i]] < keys[order[j]]
+	})
+
+	limited := make(eval.Results, 0, limit)
+	for _, i := range order[:limit] {
+		limited = append(limited, results[i])
+	}
+
+	if st.metrics != nil {
+		st.metrics.InstanceLimitExceeded.WithLabelValues(fmt.Sprint(alertRule.OrgID)).Inc()
+	}
+	st.log.FromContext(ctx).Warn("Evaluation returned more series than the instance limit of the rule, extra series are dropped", "series", len(results), "limit", limit)
+	return limited, &TooManySeriesError{Limit: limit, Series: len(results)}
+}
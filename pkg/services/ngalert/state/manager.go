@@ -2,7 +2,10 @@ package state
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"net/url"
+	"sync/atomic"
 	"time"
 
 	"github.com/benbjohnson/clock"
@@ -13,11 +16,14 @@ import (
 	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/template"
 )
 
 var (
 	ResendDelay           = 30 * time.Second
 	MetricsScrapeInterval = 15 * time.Second // TODO: parameterize? // Setting to a reasonable default scrape interval for Prometheus.
+	// historyCleanupInterval is how often the state transitions older than the history retention are deleted.
+	historyCleanupInterval = time.Hour
 )
 
 // AlertInstanceManager defines the interface for querying the current alert instances.
@@ -34,12 +40,31 @@ type Manager struct {
 	cache       *cache
 	ResendDelay time.Duration
 
-	instanceStore InstanceStore
-	images        ImageCapturer
-	historian     Historian
-	externalURL   *url.URL
-
-	doNotSaveNormalState bool
+	instanceStore      InstanceStore
+	images             ImageCapturer
+	historian          Historian
+	publisher          StatePublisher
+	notifier           ContactPointNotifier
+	maintenanceWindows MaintenanceWindowReader
+	silences           SilenceStore
+	grouper            *notificationGrouper
+	externalURL        *url.URL
+
+	doNotSaveNormalState        bool
+	missingSeriesEvalsToResolve int64
+	cleanupInterval             time.Duration
+	cleanupBatchSize            int
+	normalEvalsToResolve        int64
+	instancesPerRuleLimit       int64
+	maxInstancesPerRuleLimit    int64
+	restoredStateMaxAge         time.Duration
+	restorationBatchSize        int
+	shadowMode                  bool
+	heartbeatEvaluations        int64
+	saveStateHistory            bool
+
+	// historyRetention is a time.Duration. It is atomic because SetHistoryRetention can change it while the manager runs.
+	historyRetention atomic.Int64
 }
 
 type ManagerCfg struct {
@@ -49,12 +74,69 @@ type ManagerCfg struct {
 	Images        ImageCapturer
 	Clock         clock.Clock
 	Historian     Historian
+	// Publisher publishes the state transitions of the instances. If it is nil, they are not published.
+	Publisher StatePublisher
+	// ContactPointNotifier notifies the contact points of a rule when one of its instances starts alerting. If it is nil,
+	// the contact points are not notified.
+	ContactPointNotifier ContactPointNotifier
+	// NotificationGroupWait is how long the notifications of the instances of a rule that start alerting are buffered
+	// before they are sent to the contact points of the rule as a single notification. Zero sends them immediately.
+	NotificationGroupWait time.Duration
+	// NotificationGroupBy is the label by which the buffered notifications of a rule are further grouped. Empty groups
+	// all the notifications of a rule together.
+	NotificationGroupBy string
+	// MaintenanceWindows reads the maintenance windows that suppress notifications. If it is nil, notifications are never suppressed.
+	MaintenanceWindows MaintenanceWindowReader
+	// Silences reads the silences that suppress the notifications of matching instances, and the cleanup deletes the expired
+	// ones. If it is nil, instances are never silenced.
+	Silences SilenceStore
 	// DoNotSaveNormalState controls whether eval.Normal state is persisted to the database and returned by get methods
 	DoNotSaveNormalState bool
+	// MissingSeriesEvalsToResolve is the number of consecutive evaluations that must not return a series before its state is resolved
+	// and deleted. Defaults to 2.
+	MissingSeriesEvalsToResolve int64
+	// CleanupInterval is how often instances of deleted rules are deleted from the instance store. Zero disables the cleanup.
+	CleanupInterval time.Duration
+	// CleanupBatchSize is the maximum number of instances deleted by a single cleanup, and the number of state transitions
+	// older than HistoryRetention deleted by a single statement.
+	CleanupBatchSize int
+	// SaveStateHistory writes the state transitions to the state history table, from which the sql backend of the historian
+	// reads them, and deletes the transitions older than HistoryRetention every hour.
+	SaveStateHistory bool
+	// HistoryRetention is how long state transitions are kept in the state history. Zero keeps them forever.
+	HistoryRetention time.Duration
+	// NormalEvalsToResolve is the number of consecutive normal evaluations required before an Alerting state is resolved.
+	// Until then, the state is kept Alerting with the reason Resolving. Values less than 2 resolve the state immediately.
+	NormalEvalsToResolve int64
+	// InstancesPerRuleLimit is the maximum number of instances of a rule that does not set its own limit. Zero means no limit.
+	InstancesPerRuleLimit int64
+	// MaxInstancesPerRuleLimit bounds the instance limit of every rule, including the limits that rules set. Zero means no bound.
+	MaxInstancesPerRuleLimit int64
+	// RestoredStateMaxAge is how old the last evaluation of an instance can be for Warm to restore its state. It is extended
+	// to three intervals of the rule of the instance, and to HeartbeatEvaluations intervals, if they are longer. Older
+	// instances are deleted from the instance store. Zero restores all instances.
+	RestoredStateMaxAge time.Duration
+	// RestorationBatchSize is the maximum number of instances that Warm reads from the instance store at a time. Defaults
+	// to 1000.
+	RestorationBatchSize int
+	// ShadowMode evaluates the rules migrated from legacy alerts in shadow mode. The transitions of their instances are not
+	// recorded in the state history, published or notified to their contact points.
+	ShadowMode bool
+	// HeartbeatEvaluations is the number of evaluations after which an instance whose state did not change is
+	// written to the instance store again. Values less than 2 write every instance at every evaluation.
+	HeartbeatEvaluations int64
 }
 
 func NewManager(cfg ManagerCfg) *Manager {
-	return &Manager{
+	missingSeriesEvalsToResolve := cfg.MissingSeriesEvalsToResolve
+	if missingSeriesEvalsToResolve <= 0 {
+		missingSeriesEvalsToResolve = 2
+	}
+	restorationBatchSize := cfg.RestorationBatchSize
+	if restorationBatchSize <= 0 {
+		restorationBatchSize = 1000
+	}
+	m := &Manager{
 		cache:                newCache(),
 		ResendDelay:          ResendDelay, // TODO: make this configurable
 		log:                  log.New("ngalert.state.manager"),
@@ -62,27 +144,133 @@ func NewManager(cfg ManagerCfg) *Manager {
 		instanceStore:        cfg.InstanceStore,
 		images:               cfg.Images,
 		historian:            cfg.Historian,
+		publisher:            cfg.Publisher,
+		notifier:             cfg.ContactPointNotifier,
+		maintenanceWindows:   cfg.MaintenanceWindows,
+		silences:             cfg.Silences,
 		clock:                cfg.Clock,
 		externalURL:          cfg.ExternalURL,
 		doNotSaveNormalState: cfg.DoNotSaveNormalState,
+
+		missingSeriesEvalsToResolve: missingSeriesEvalsToResolve,
+		cleanupInterval:             cfg.CleanupInterval,
+		cleanupBatchSize:            cfg.CleanupBatchSize,
+		normalEvalsToResolve:        cfg.NormalEvalsToResolve,
+		instancesPerRuleLimit:       cfg.InstancesPerRuleLimit,
+		maxInstancesPerRuleLimit:    cfg.MaxInstancesPerRuleLimit,
+		restoredStateMaxAge:         cfg.RestoredStateMaxAge,
+		restorationBatchSize:        restorationBatchSize,
+		shadowMode:                  cfg.ShadowMode,
+		heartbeatEvaluations:        cfg.HeartbeatEvaluations,
+		saveStateHistory:            cfg.SaveStateHistory,
 	}
+	if cfg.NotificationGroupWait > 0 {
+		m.grouper = newNotificationGrouper(cfg.Clock, m.log, cfg.NotificationGroupWait, cfg.NotificationGroupBy, m.sendNotification)
+	}
+	m.historyRetention.Store(int64(cfg.HistoryRetention))
+	return m
+}
+
+// SetHistoryRetention changes how long state transitions are kept in the state history. Zero keeps them forever.
+// It takes effect at the next cleanup.
+func (st *Manager) SetHistoryRetention(retention time.Duration) {
+	st.historyRetention.Store(int64(retention))
 }
 
 func (st *Manager) Run(ctx context.Context) error {
 	ticker := st.clock.Ticker(MetricsScrapeInterval)
+	var cleanup <-chan time.Time
+	if st.instanceStore != nil && st.cleanupInterval > 0 {
+		cleanupTicker := st.clock.Ticker(st.cleanupInterval)
+		defer cleanupTicker.Stop()
+		cleanup = cleanupTicker.C
+	}
+	var historyCleanup <-chan time.Time
+	if st.instanceStore != nil && st.saveStateHistory {
+		historyCleanupTicker := st.clock.Ticker(historyCleanupInterval)
+		defer historyCleanupTicker.Stop()
+		historyCleanup = historyCleanupTicker.C
+	}
 	for {
 		select {
 		case <-ticker.C:
 			st.log.Debug("Recording state cache metrics", "now", st.clock.Now())
 			st.cache.recordMetrics(st.metrics)
+		case <-cleanup:
+			st.deleteOrphanedInstances(ctx)
+			st.deleteExpiredSilences(ctx)
+		case <-historyCleanup:
+			st.deleteOldHistory(ctx)
 		case <-ctx.Done():
 			st.log.Debug("Stopping")
 			ticker.Stop()
+			if st.grouper != nil {
+				// the context is canceled, but the buffered notifications must still be sent
+				st.grouper.flushAll(context.Background())
+			}
 			return ctx.Err()
 		}
 	}
 }
 
+// deleteOrphanedInstances deletes at most cleanupBatchSize instances of rules that do not exist anymore from the instance store.
+// Instances are deleted together with their rule, but can be left behind if Grafana is stopped before the scheduler notices that the rule is deleted.
+func (st *Manager) deleteOrphanedInstances(ctx context.Context) {
+	deleted, err := st.instanceStore.DeleteOrphanedAlertInstances(ctx, st.cleanupBatchSize)
+	if err != nil {
+		st.log.Error("Failed to delete alert instances of deleted rules", "error", err)
+		return
+	}
+	if deleted > 0 {
+		st.log.Info("Deleted alert instances of deleted rules", "count", deleted)
+	}
+}
+
+// deleteOldHistory deletes the state transitions that are older than historyRetention, cleanupBatchSize transitions at a time.
+func (st *Manager) deleteOldHistory(ctx context.Context) {
+	retention := time.Duration(st.historyRetention.Load())
+	if retention <= 0 {
+		return
+	}
+	before := st.clock.Now().Add(-retention)
+	var total int64
+	for ctx.Err() == nil {
+		deleted, err := st.instanceStore.DeleteAlertStateHistory(ctx, before, st.cleanupBatchSize)
+		if err != nil {
+			st.log.Error("Failed to delete old alert state history", "error", err)
+			break
+		}
+		total += deleted
+		if deleted < int64(st.cleanupBatchSize) {
+			break
+		}
+	}
+	if total > 0 {
+		st.log.Info("Deleted old alert state history", "count", total, "before", before)
+	}
+}
+
+// deleteExpiredSilences deletes the silences that are over. They cannot suppress notifications anymore, and the state
+// history keeps the IDs of the silences that suppressed transitions.
+func (st *Manager) deleteExpiredSilences(ctx context.Context) {
+	if st.silences == nil {
+		return
+	}
+	deleted, err := st.silences.DeleteExpiredSilences(ctx, st.clock.Now())
+	if err != nil {
+		st.log.Error("Failed to delete expired silences", "error", err)
+		return
+	}
+	if deleted > 0 {
+		st.log.Info("Deleted expired silences", "count", deleted)
+	}
+}
+
+// Warm loads the states of the alert instances saved in the instance store, so that the rules continue from the states they
+// had before a restart. Instances that were last evaluated longer than RestoredStateMaxAge ago, and longer than a few
+// intervals of their rule ago, are deleted instead.
+// The instances are read RestorationBatchSize at a time, and each page is restored before the next one is read. If the
+// context is cancelled, Warm stops without restoring any state.
 func (st *Manager) Warm(ctx context.Context, rulesReader RuleReader) {
 	if st.instanceStore == nil {
 		st.log.Info("Skip warming the state because instance store is not configured")
@@ -96,7 +284,9 @@ func (st *Manager) Warm(ctx context.Context, rulesReader RuleReader) {
 		st.log.Error("Unable to fetch orgIds", "error", err)
 	}
 
+	now := st.clock.Now()
 	statesCount := 0
+	staleCount := 0
 	states := make(map[int64]map[string]*ruleStates, len(orgIds))
 	for _, orgId := range orgIds {
 		// Get Rules
@@ -109,60 +299,124 @@ func (st *Manager) Warm(ctx context.Context, rulesReader RuleReader) {
 		}
 
 		ruleByUID := make(map[string]*ngModels.AlertRule, len(alertRules))
+		schedules := make(map[string]ngModels.Schedule)
 		for _, rule := range alertRules {
 			ruleByUID[rule.UID] = rule
+			if rule.Schedule == "" {
+				continue
+			}
+			if s, err := ngModels.ParseSchedule(rule.Schedule, rule.ScheduleTimezone); err == nil {
+				schedules[rule.UID] = s
+			}
 		}
 
 		orgStates := make(map[string]*ruleStates, len(ruleByUID))
 		states[orgId] = orgStates
 
 		// Get Instances
-		cmd := ngModels.ListAlertInstancesQuery{
+		cmd := ngModels.ListAlertInstancesPageQuery{
 			RuleOrgID: orgId,
+			Limit:     int64(st.restorationBatchSize),
 		}
-		alertInstances, err := st.instanceStore.ListAlertInstances(ctx, &cmd)
-		if err != nil {
-			st.log.Error("Unable to fetch previous state", "error", err)
-		}
-
-		for _, entry := range alertInstances {
-			ruleForEntry, ok := ruleByUID[entry.RuleUID]
-			if !ok {
-				// TODO Should we delete the orphaned state from the db?
-				continue
+		for {
+			if ctx.Err() != nil {
+				st.log.Warn("Warming state cache was interrupted", "error", ctx.Err())
+				return
 			}
-
-			rulesStates, ok := orgStates[entry.RuleUID]
-			if !ok {
-				rulesStates = &ruleStates{states: make(map[string]*State)}
-				orgStates[entry.RuleUID] = rulesStates
+			alertInstances, err := st.instanceStore.ListAlertInstancesPage(ctx, &cmd)
+			if err != nil {
+				st.log.Error("Unable to fetch previous state", "error", err)
+				break
 			}
 
-			lbs := map[string]string(entry.Labels)
-			cacheID, err := entry.Labels.StringKey()
-			if err != nil {
-				st.log.Error("Error getting cacheId for entry", "error", err)
+			var staleKeys []ngModels.AlertInstanceKey
+			for _, entry := range alertInstances {
+				ruleForEntry, ok := ruleByUID[entry.RuleUID]
+				if !ok {
+					// TODO Should we delete the orphaned state from the db?
+					continue
+				}
+
+				// the state of an instance that was not evaluated for too long cannot be trusted to be current anymore
+				if st.restoredStateMaxAge > 0 {
+					interval := time.Duration(ruleForEntry.IntervalSeconds) * time.Second
+					if s, ok := schedules[entry.RuleUID]; ok {
+						interval = s.Next(entry.LastEvalTime).Sub(entry.LastEvalTime)
+					}
+					if now.Sub(entry.LastEvalTime) > st.restoredStateMaxAgeOf(interval) {
+						staleKeys = append(staleKeys, entry.AlertInstanceKey)
+						continue
+					}
+				}
+
+				rulesStates, ok := orgStates[entry.RuleUID]
+				if !ok {
+					rulesStates = &ruleStates{states: make(map[string]*State)}
+					orgStates[entry.RuleUID] = rulesStates
+				}
+
+				lbs := map[string]string(entry.Labels)
+				cacheID, err := entry.Labels.StringKey()
+				if err != nil {
+					st.log.Error("Error getting cacheId for entry", "error", err)
+				}
+				rulesStates.states[cacheID] = &State{
+					AlertRuleUID:         entry.RuleUID,
+					OrgID:                entry.RuleOrgID,
+					CacheID:              cacheID,
+					Labels:               lbs,
+					State:                translateInstanceState(entry.CurrentState),
+					StateReason:          entry.CurrentReason,
+					LastEvaluationString: "",
+					StartsAt:             entry.CurrentStateSince,
+					EndsAt:               entry.CurrentStateEnd,
+					LastEvaluationTime:   entry.LastEvalTime,
+					Annotations:          ruleForEntry.Annotations,
+					Values:               entry.Values,
+					ResolvedAt:           entry.ResolvedAt,
+				}
+				statesCount++
+			}
+			if len(staleKeys) > 0 {
+				if err := st.instanceStore.DeleteAlertInstances(ctx, staleKeys...); err != nil {
+					st.log.Error("Unable to delete stale alert instances", "error", err)
+				}
+				staleCount += len(staleKeys)
 			}
-			rulesStates.states[cacheID] = &State{
-				AlertRuleUID:         entry.RuleUID,
-				OrgID:                entry.RuleOrgID,
-				CacheID:              cacheID,
-				Labels:               lbs,
-				State:                translateInstanceState(entry.CurrentState),
-				StateReason:          entry.CurrentReason,
-				LastEvaluationString: "",
-				StartsAt:             entry.CurrentStateSince,
-				EndsAt:               entry.CurrentStateEnd,
-				LastEvaluationTime:   entry.LastEvalTime,
-				Annotations:          ruleForEntry.Annotations,
+
+			if len(alertInstances) < st.restorationBatchSize {
+				break
 			}
-			statesCount++
+			last := alertInstances[len(alertInstances)-1].AlertInstanceKey
+			cmd.After = &last
 		}
 	}
 	st.cache.setAllStates(states)
+	if staleCount > 0 {
+		st.log.Info("Deleted alert instances that were last evaluated too long ago to be restored", "count", staleCount, "maxAge", st.restoredStateMaxAge)
+	}
 	st.log.Info("State cache has been initialized", "states", statesCount, "duration", time.Since(startTime))
 }
 
+// restoredStateIntervals is the number of intervals of its rule that the last evaluation of an instance can always be
+// older than for Warm to restore its state.
+const restoredStateIntervals = 3
+
+// restoredStateMaxAgeOf returns how old the last evaluation of an instance of a rule with the given interval can be for
+// Warm to restore its state. It is RestoredStateMaxAge, extended for rules that are evaluated less often than that, and
+// for instances that are written to the instance store only by the heartbeat.
+func (st *Manager) restoredStateMaxAgeOf(interval time.Duration) time.Duration {
+	maxAge := st.restoredStateMaxAge
+	if d := restoredStateIntervals * interval; d > maxAge {
+		maxAge = d
+	}
+	// an instance whose state does not change is written only every heartbeatEvaluations evaluations
+	if d := time.Duration(st.heartbeatEvaluations) * interval; d > maxAge {
+		maxAge = d
+	}
+	return maxAge
+}
+
 func (st *Manager) Get(orgID int64, alertRuleUID, stateId string) *State {
 	return st.cache.get(orgID, alertRuleUID, stateId)
 }
@@ -171,10 +425,16 @@ func (st *Manager) Get(orgID int64, alertRuleUID, stateId string) *State {
 // to gracefully handle the clear state step in scheduler in case we do not need to use the historian to save state
 // history.
 func (st *Manager) DeleteStateByRuleUID(ctx context.Context, ruleKey ngModels.AlertRuleKey, reason string) []StateTransition {
+	if _, ok := ngModels.RuleKeyFromContext(ctx); !ok {
+		ctx = ngModels.WithRuleKey(ctx, ruleKey)
+	}
 	logger := st.log.FromContext(ctx)
 	logger.Debug("Resetting state of the rule")
 
 	states := st.cache.removeByRuleUID(ruleKey.OrgID, ruleKey.UID)
+	if st.grouper != nil {
+		st.grouper.removeRule(ruleKey)
+	}
 
 	if len(states) == 0 {
 		return nil
@@ -193,6 +453,9 @@ func (st *Manager) DeleteStateByRuleUID(ctx context.Context, ruleKey ngModels.Al
 		// Set Resolved property so the scheduler knows to send a postable alert
 		// to Alertmanager.
 		s.Resolved = oldState == eval.Alerting
+		if s.Resolved {
+			s.ResolvedAt = now
+		}
 		s.LastEvaluationTime = now
 		s.Values = map[string]float64{}
 		transitions = append(transitions, StateTransition{
@@ -219,7 +482,12 @@ func (st *Manager) ResetStateByRuleUID(ctx context.Context, rule *ngModels.Alert
 	ruleKey := rule.GetKey()
 	transitions := st.DeleteStateByRuleUID(ctx, ruleKey, reason)
 
-	if rule == nil || st.historian == nil || len(transitions) == 0 {
+	if rule == nil || len(transitions) == 0 || st.IsShadow(rule) {
+		return transitions
+	}
+	ctx = ngModels.WithRule(ctx, rule)
+	st.publishTransitions(ctx, rule, transitions)
+	if st.historian == nil {
 		return transitions
 	}
 
@@ -228,15 +496,97 @@ func (st *Manager) ResetStateByRuleUID(ctx context.Context, rule *ngModels.Alert
 	go func() {
 		err := <-errCh
 		if err != nil {
-			st.log.FromContext(ctx).Error("Error updating historian state reset transitions", append(ruleKey.LogContext(), "reason", reason, "error", err)...)
+			st.log.FromContext(ctx).Error("Error updating historian state reset transitions", "reason", reason, "error", err)
 		}
 	}()
 	return transitions
 }
 
+// ResetAlertInstances resets the instances of the rule that match the command to Normal and removes them from cache and
+// instanceStore, so that the next evaluation of the rule establishes their state from scratch. The transitions are
+// recorded in the state history as manual resets by the user of the command. It must not run concurrently with
+// ProcessEvalResults for the same rule.
+func (st *Manager) ResetAlertInstances(ctx context.Context, rule *ngModels.AlertRule, cmd *ngModels.ResetAlertInstancesCommand) []StateTransition {
+	ctx = ngModels.WithRule(ctx, rule)
+	logger := st.log.FromContext(ctx).New("labelsHash", cmd.LabelsHash, "resetBy", cmd.ResetBy)
+	states := st.cache.deleteRuleStates(rule.GetKey(), func(s *State) bool {
+		if cmd.LabelsHash == "" {
+			return true
+		}
+		key, err := s.GetAlertInstanceKey()
+		return err == nil && key.LabelsHash == cmd.LabelsHash
+	})
+	if len(states) == 0 {
+		logger.Debug("No alert instances to reset")
+		return nil
+	}
+
+	now := st.clock.Now()
+	transitions := make([]StateTransition, 0, len(states))
+	keys := make([]ngModels.AlertInstanceKey, 0, len(states))
+	history := make([]ngModels.AlertStateHistory, 0, len(states))
+	for _, s := range states {
+		// the buffered notification of the instance is not sent, the instance is not alerting anymore.
+		if st.grouper != nil {
+			st.grouper.remove(rule, s)
+		}
+		oldState := s.State
+		oldReason := s.StateReason
+		startsAt := s.StartsAt
+		if s.State != eval.Normal {
+			startsAt = now
+		}
+		s.SetNormal(ngModels.StateReasonManualReset, startsAt, now)
+		s.Resolved = oldState == eval.Alerting
+		if s.Resolved {
+			s.ResolvedAt = now
+		}
+		s.LastEvaluationTime = now
+		s.Values = map[string]float64{}
+		transition := StateTransition{
+			State:               s,
+			PreviousState:       oldState,
+			PreviousStateReason: oldReason,
+		}
+		transitions = append(transitions, transition)
+
+		key, err := s.GetAlertInstanceKey()
+		if err != nil {
+			logger.Error("Failed to create a key for alert state to delete it from database", "cacheID", s.CacheID, "error", err)
+			continue
+		}
+		keys = append(keys, key)
+		if !st.saveStateHistory {
+			continue
+		}
+		if h, ok := st.stateHistoryEntry(logger, transition); ok {
+			h.ResetBy = cmd.ResetBy
+			history = append(history, h)
+		}
+	}
+
+	if st.instanceStore != nil {
+		if err := st.instanceStore.DeleteAlertInstancesWithHistory(ctx, keys, history); err != nil {
+			logger.Error("Failed to delete reset alert instances from database", "error", err)
+		}
+	}
+	st.publishTransitions(ctx, rule, transitions)
+	if st.historian != nil {
+		errCh := st.historian.Record(ctx, history_model.NewRuleMeta(rule, logger), transitions)
+		go func() {
+			if err := <-errCh; err != nil {
+				logger.Error("Error updating historian state reset transitions", "error", err)
+			}
+		}()
+	}
+	logger.Info("Alert instances were reset manually", "states", len(states))
+	return transitions
+}
+
 // ProcessEvalResults updates the current states that belong to a rule with the evaluation results.
 // if extraLabels is not empty, those labels will be added to every state. The extraLabels take precedence over rule labels and result labels
 func (st *Manager) ProcessEvalResults(ctx context.Context, evaluatedAt time.Time, alertRule *ngModels.AlertRule, results eval.Results, extraLabels data.Labels) []StateTransition {
+	ctx = ngModels.WithRuleEvaluation(ctx, alertRule, evaluatedAt)
 	logger := st.log.FromContext(ctx)
 	logger.Debug("State manager processing evaluation results", "resultCount", len(results))
 	states := make([]StateTransition, 0, len(results))
@@ -246,17 +596,241 @@ func (st *Manager) ProcessEvalResults(ctx context.Context, evaluatedAt time.Time
 		states = append(states, s)
 	}
 	staleStates := st.deleteStaleStatesFromCache(ctx, logger, evaluatedAt, alertRule)
+	if suppressible(states, staleStates) {
+		if window := st.activeMaintenanceWindow(ctx, logger, alertRule.OrgID, evaluatedAt); window != nil {
+			logger.Debug("Notifications are suppressed by maintenance window", "maintenanceWindowID", window.ID)
+			suppress(states, window.ID)
+			suppress(staleStates, window.ID)
+		}
+		st.silence(ctx, logger, alertRule.OrgID, evaluatedAt, states, staleStates)
+	}
 	st.deleteAlertStates(ctx, logger, staleStates)
 
-	st.saveAlertStates(ctx, logger, states...)
+	shadow := st.IsShadow(alertRule)
+	st.saveAlertStates(ctx, logger, states, staleStates, st.saveStateHistory && !shadow)
 
 	allChanges := append(states, staleStates...)
+	for _, t := range allChanges {
+		if t.Changed() {
+			logger.Info("Alert instance changed state", "instance", t.Labels, "previous_state", t.PreviousFormatted(), "state", t.Formatted())
+		}
+	}
+	if shadow {
+		return allChanges
+	}
 	if st.historian != nil {
 		st.historian.Record(ctx, history_model.NewRuleMeta(alertRule, logger), allChanges)
 	}
+	st.publishTransitions(ctx, alertRule, allChanges)
+	st.notifyContactPoints(ctx, logger, alertRule, allChanges)
 	return allChanges
 }
 
+// IsShadow returns true if the rule is evaluated in shadow mode, because it was migrated from a legacy alert and the
+// shadow mode is enabled. The rules in shadow mode are evaluated to compare them with their legacy alerts, but the
+// transitions of their instances are not recorded in the state history, published or notified.
+func (st *Manager) IsShadow(rule *ngModels.AlertRule) bool {
+	if !st.shadowMode || rule == nil {
+		return false
+	}
+	_, ok := rule.MigratedAlertID()
+	return ok
+}
+
+// publishTransitions publishes the transitions that changed the state of an instance, if the manager has a publisher.
+func (st *Manager) publishTransitions(ctx context.Context, rule *ngModels.AlertRule, transitions []StateTransition) {
+	if st.publisher == nil {
+		return
+	}
+	changed := make([]StateTransition, 0, len(transitions))
+	for _, t := range transitions {
+		if t.Changed() {
+			changed = append(changed, t)
+		}
+	}
+	if len(changed) > 0 {
+		st.publisher.PublishTransitions(ctx, rule, changed)
+	}
+}
+
+// notifyContactPoints sends a notification to every contact point of the rule for each instance that started alerting,
+// again every repeat interval of the rule while it stays alerting, and once it is resolved if the rule sends resolved
+// notifications, unless the transition is suppressed by a maintenance window or a silence. If notifications are grouped,
+// the notifications of the instances that start alerting are buffered instead, and instances that stop alerting are
+// removed from the buffer. Only the instances whose contact points were notified that they are alerting are notified
+// that they are resolved, and resolved notifications are never grouped.
+func (st *Manager) notifyContactPoints(ctx context.Context, logger log.Logger, rule *ngModels.AlertRule, transitions []StateTransition) {
+	if st.notifier == nil || len(rule.ContactPointUIDs) == 0 {
+		return
+	}
+	for _, t := range transitions {
+		if t.State.State != eval.Alerting {
+			notified := !t.State.LastNotifiedAt.IsZero()
+			if st.grouper != nil && st.grouper.remove(rule, t.State) {
+				notified = false
+			}
+			if notified && rule.SendResolved && t.Kind() == TransitionResolved && t.MaintenanceWindowID == 0 && t.SilenceID == 0 {
+				st.sendNotification(ctx, logger, rule, newResolvedNotification(logger, rule, t.State))
+			}
+			t.State.LastNotifiedAt = time.Time{}
+			t.State.NotifiedStartsAt = time.Time{}
+			continue
+		}
+		if t.MaintenanceWindowID != 0 || t.SilenceID != 0 {
+			continue
+		}
+		if t.Kind() != TransitionFired && !repeatDue(rule, t.State) {
+			continue
+		}
+		t.State.LastNotifiedAt = t.State.LastEvaluationTime
+		t.State.NotifiedStartsAt = t.State.StartsAt
+		n := newAlertingNotification(logger, rule, t.State)
+		if st.grouper != nil {
+			st.grouper.add(rule, t.State, n)
+			continue
+		}
+		st.sendNotification(ctx, logger, rule, n)
+	}
+}
+
+// repeatDue returns true if the state has been alerting without its contact points being notified for at least the
+// repeat interval of the rule. The contact points of a state that was suppressed when it started alerting are notified
+// once the repeat interval has elapsed since then.
+func repeatDue(rule *ngModels.AlertRule, s *State) bool {
+	if rule.NotificationRepeatInterval <= 0 {
+		return false
+	}
+	last := s.LastNotifiedAt
+	if last.IsZero() {
+		last = s.StartsAt
+	}
+	return !s.LastEvaluationTime.Before(last.Add(rule.NotificationRepeatInterval))
+}
+
+// sendNotification sends the notification to every contact point of the rule. A failed notification is logged and
+// counted, and does not prevent the notifications to the other contact points.
+func (st *Manager) sendNotification(ctx context.Context, logger log.Logger, rule *ngModels.AlertRule, n ngModels.AlertingNotification) {
+	for _, uid := range rule.ContactPointUIDs {
+		if st.metrics != nil {
+			st.metrics.ContactPointNotifications.WithLabelValues(fmt.Sprint(rule.OrgID)).Inc()
+		}
+		if err := st.notifier.NotifyContactPoint(ctx, rule.OrgID, uid, n); err != nil {
+			if st.metrics != nil {
+				st.metrics.ContactPointNotificationFailures.WithLabelValues(fmt.Sprint(rule.OrgID)).Inc()
+			}
+			logger.Error("Failed to notify contact point", "contactPoint", uid, "instance", n.Labels, "instances", n.Count, "error", err)
+		}
+	}
+}
+
+func newAlertingNotification(logger log.Logger, rule *ngModels.AlertRule, s *State) ngModels.AlertingNotification {
+	n := ngModels.AlertingNotification{
+		OrgID:       rule.OrgID,
+		RuleUID:     rule.UID,
+		RuleTitle:   rule.Title,
+		Labels:      s.Labels.Copy(),
+		Annotations: data.Labels(s.Annotations).Copy(),
+		Value:       s.LastEvaluationString,
+		RunbookURL:  s.Annotations[ngModels.RunbookURLAnnotation],
+		StartsAt:    s.StartsAt,
+		Count:       1,
+	}
+	tmplData := template.NotificationData{
+		Name:       rule.Title,
+		Labels:     template.Labels(s.Labels),
+		Values:     template.NewNotificationValues(s.Values),
+		Value:      s.LastEvaluationString,
+		RunbookURL: n.RunbookURL,
+	}
+	if rule.NotificationTitle != "" {
+		n.Title = expandNotification(logger, "title", rule.NotificationTitle, tmplData, rule.Title)
+	}
+	if rule.NotificationMessage != "" {
+		n.Message = expandNotification(logger, "message", rule.NotificationMessage, tmplData,
+			fmt.Sprintf("%s is alerting for %s", rule.Title, template.Labels(s.Labels)))
+	}
+	return n
+}
+
+// newResolvedNotification returns the notification that the state is resolved. It has the start time of the alert that
+// the contact points were notified about.
+func newResolvedNotification(logger log.Logger, rule *ngModels.AlertRule, s *State) ngModels.AlertingNotification {
+	n := newAlertingNotification(logger, rule, s)
+	n.StartsAt = s.NotifiedStartsAt
+	n.EndsAt = s.ResolvedAt
+	return n
+}
+
+// expandNotification renders the notification template of a rule. If the template cannot be rendered, the error is
+// logged and the fallback is returned, so that the notification is still sent.
+func expandNotification(logger log.Logger, name, tmpl string, data template.NotificationData, fallback string) string {
+	result, err := template.ExpandNotification(tmpl, data)
+	if err != nil {
+		logger.Warn("Failed to render the notification template, the default is used instead", "template", name, "error", err)
+		return fallback
+	}
+	return result
+}
+
+// activeMaintenanceWindow returns the maintenance window of the organization that is active at the time t, or nil if there is
+// none. If the windows cannot be read, notifications are not suppressed.
+func (st *Manager) activeMaintenanceWindow(ctx context.Context, logger log.Logger, orgID int64, t time.Time) *ngModels.MaintenanceWindow {
+	if st.maintenanceWindows == nil {
+		return nil
+	}
+	windows, err := st.maintenanceWindows.GetMaintenanceWindows(ctx, orgID)
+	if err != nil {
+		logger.Error("Failed to get maintenance windows, notifications will not be suppressed", "error", err)
+		return nil
+	}
+	return ngModels.ActiveMaintenanceWindow(windows, t)
+}
+
+// suppressible returns true if any of the transitions can be notified, i.e. it changed the state and is recorded in the state
+// history, or its instance fires and is sent to the Alertmanager or notified to the contact points again. The other
+// transitions do not need to be checked against the maintenance windows and the silences.
+func suppressible(transitions ...[]StateTransition) bool {
+	for _, list := range transitions {
+		for _, t := range list {
+			if t.Changed() || t.State.State != eval.Normal && t.State.State != eval.Pending {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// suppress marks the transitions as suppressed by the maintenance window with the given ID.
+func suppress(transitions []StateTransition, maintenanceWindowID int64) {
+	for i := range transitions {
+		transitions[i].MaintenanceWindowID = maintenanceWindowID
+	}
+}
+
+// silence marks the transitions of the instances that match a silence of the organization active at the time t as
+// silenced by it. If the silences cannot be read, the transitions are not silenced.
+func (st *Manager) silence(ctx context.Context, logger log.Logger, orgID int64, t time.Time, transitions ...[]StateTransition) {
+	if st.silences == nil {
+		return
+	}
+	silences, err := st.silences.GetSilences(ctx, orgID)
+	if err != nil {
+		logger.Error("Failed to get silences, notifications will not be silenced", "error", err)
+		return
+	}
+	if len(silences) == 0 {
+		return
+	}
+	for _, list := range transitions {
+		for i := range list {
+			if s := ngModels.ActiveSilence(silences, list[i].Labels, t); s != nil {
+				logger.Debug("Notification is silenced", "instance", list[i].Labels, "silenceID", s.ID)
+				list[i].SilenceID = s.ID
+			}
+		}
+	}
+}
+
 // Set the current state based on evaluation results
 func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRule, result eval.Result, extraLabels data.Labels, logger log.Logger) StateTransition {
 	currentState := st.cache.getOrCreate(ctx, st.log, alertRule, result, extraLabels, st.externalURL)
@@ -271,43 +845,15 @@ func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRu
 	})
 	currentState.LastEvaluationString = result.EvaluationString
 	currentState.TrimResults(alertRule)
-	oldState := currentState.State
-	oldReason := currentState.StateReason
-
 	// Add the instance to the log context to help correlate log lines for a state
 	logger = logger.New("instance", result.Instance)
 
-	switch result.State {
-	case eval.Normal:
-		logger.Debug("Setting next state", "handler", "resultNormal")
-		resultNormal(currentState, alertRule, result, logger)
-	case eval.Alerting:
-		logger.Debug("Setting next state", "handler", "resultAlerting")
-		resultAlerting(currentState, alertRule, result, logger)
-	case eval.Error:
-		logger.Debug("Setting next state", "handler", "resultError")
-		resultError(currentState, alertRule, result, logger)
-	case eval.NoData:
-		logger.Debug("Setting next state", "handler", "resultNoData")
-		resultNoData(currentState, alertRule, result, logger)
-	case eval.Pending: // we do not emit results with this state
-		logger.Debug("Ignoring set next state as result is pending")
-	}
-
-	// Set reason iff: result and state are different, reason is not Alerting or Normal
-	currentState.StateReason = ""
-
-	if currentState.State != result.State &&
-		result.State != eval.Normal &&
-		result.State != eval.Alerting {
-		currentState.StateReason = result.State.String()
-	}
-
-	// Set Resolved property so the scheduler knows to send a postable alert
-	// to Alertmanager.
-	currentState.Resolved = oldState == eval.Alerting && currentState.State == eval.Normal
-
-	if shouldTakeImage(currentState.State, oldState, currentState.Image, currentState.Resolved) {
+	nextState, kept := keepAlerting(currentState, alertRule, result, st.normalEvalsToResolve, logger)
+	if !kept {
+		nextState = applyEvalResult(currentState, alertRule, result, logger)
+	}
+
+	if shouldTakeImage(currentState.State, nextState.PreviousState, currentState.Image, currentState.Resolved) {
 		image, err := takeImage(ctx, st.images, alertRule)
 		if err != nil {
 			logger.Warn("Failed to take an image",
@@ -320,13 +866,6 @@ func (st *Manager) setNextState(ctx context.Context, alertRule *ngModels.AlertRu
 	}
 
 	st.cache.set(currentState)
-
-	nextState := StateTransition{
-		State:               currentState,
-		PreviousState:       oldState,
-		PreviousStateReason: oldReason,
-	}
-
 	return nextState
 }
 
@@ -334,6 +873,12 @@ func (st *Manager) GetAll(orgID int64) []*State {
 	allStates := st.cache.getAll(orgID, st.doNotSaveNormalState)
 	return allStates
 }
+
+// CountStates returns the number of alert instances of all organizations in every state.
+func (st *Manager) CountStates() map[eval.State]int {
+	return st.cache.countStates()
+}
+
 func (st *Manager) GetStatesForRuleUID(orgID int64, alertRuleUID string) []*State {
 	return st.cache.getStatesForRuleUID(orgID, alertRuleUID, st.doNotSaveNormalState)
 }
@@ -345,15 +890,24 @@ func (st *Manager) Put(states []*State) {
 }
 
 // TODO: Is the `State` type necessary? Should it embed the instance?
-func (st *Manager) saveAlertStates(ctx context.Context, logger log.Logger, states ...StateTransition) {
-	if st.instanceStore == nil || len(states) == 0 {
+// saveAlertStates saves the states to the instance store, and appends the transitions of states and of the deleted stale states
+// to the state history in the same write if withHistory is true.
+func (st *Manager) saveAlertStates(ctx context.Context, logger log.Logger, states []StateTransition, stale []StateTransition, withHistory bool) {
+	if st.instanceStore == nil || len(states)+len(stale) == 0 {
 		return
 	}
 
 	logger.Debug("Saving alert states", "count", len(states))
 	instances := make([]ngModels.AlertInstance, 0, len(states))
+	saved := make([]*State, 0, len(states))
+	history := make([]ngModels.AlertStateHistory, 0)
 
 	for _, s := range states {
+		if withHistory && s.Changed() {
+			if h, ok := st.stateHistoryEntry(logger, s); ok {
+				history = append(history, h)
+			}
+		}
 		// Do not save normal state to database and remove transition to Normal state but keep mapped states
 		if st.doNotSaveNormalState && IsNormalStateWithNoReason(s.State) && !s.Changed() {
 			continue
@@ -372,15 +926,29 @@ func (st *Manager) saveAlertStates(ctx context.Context, logger log.Logger, state
 			LastEvalTime:      s.LastEvaluationTime,
 			CurrentStateSince: s.StartsAt,
 			CurrentStateEnd:   s.EndsAt,
+			ResolvedAt:        s.ResolvedAt,
+			Values:            s.Values,
+		}
+		if !s.Changed() && !st.needsSaving(s.State, fields) {
+			continue
 		}
 		instances = append(instances, fields)
+		saved = append(saved, s.State)
+	}
+	for _, s := range stale {
+		if !withHistory || !s.Changed() {
+			continue
+		}
+		if h, ok := st.stateHistoryEntry(logger, s); ok {
+			history = append(history, h)
+		}
 	}
 
-	if len(instances) == 0 {
+	if len(instances) == 0 && len(history) == 0 {
 		return
 	}
 
-	if err := st.instanceStore.SaveAlertInstances(ctx, instances...); err != nil {
+	if err := st.instanceStore.SaveAlertInstancesWithHistory(ctx, instances, history); err != nil {
 		type debugInfo struct {
 			State  string
 			Labels string
@@ -390,7 +958,105 @@ func (st *Manager) saveAlertStates(ctx context.Context, logger log.Logger, state
 			debug = append(debug, debugInfo{string(inst.CurrentState), data.Labels(inst.Labels).String()})
 		}
 		logger.Error("Failed to save alert states", "states", debug, "error", err)
+		return
+	}
+	if st.heartbeatEvaluations < 2 {
+		return
+	}
+	for i, s := range saved {
+		st.cache.setSaved(s, instances[i])
+	}
+}
+
+// instanceValueTolerance is the relative difference between two values of an instance below which a value is not
+// considered changed, so that the instance is not written to the instance store only because its value changed a little.
+const instanceValueTolerance = 0.01
+
+// needsSaving counts an evaluation of the state, and returns true if its instance must be written to the instance
+// store, because it differs from the instance that was last written, or that was written heartbeatEvaluations
+// evaluations ago. The time of the last evaluation and the end time of the state change at every evaluation and are not
+// compared, so that instances whose state does not change are only written by the heartbeat. The heartbeat is shortened
+// for rules with long intervals, so that the saved instances are never too old to be restored by Warm.
+func (st *Manager) needsSaving(s *State, instance ngModels.AlertInstance) bool {
+	if st.heartbeatEvaluations < 2 {
+		return true
+	}
+	saved := st.cache.countEvaluation(s)
+	if saved == nil || saved.evaluations >= st.heartbeatEvaluations {
+		return true
+	}
+	if st.restoredStateMaxAge > 0 && instance.LastEvalTime.Sub(saved.instance.LastEvalTime) >= st.restoredStateMaxAge/2 {
+		return true
+	}
+	prev := saved.instance
+	return prev.CurrentState != instance.CurrentState ||
+		prev.CurrentReason != instance.CurrentReason ||
+		!prev.CurrentStateSince.Equal(instance.CurrentStateSince) ||
+		!prev.ResolvedAt.Equal(instance.ResolvedAt) ||
+		!valuesEqual(prev.Values, instance.Values, instanceValueTolerance)
+}
+
+// valuesEqual returns true if both maps have the same keys, and the values of each key differ by at most tolerance
+// relative to the larger of them. NaN values are equal to each other.
+func valuesEqual(a, b map[string]float64, tolerance float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, va := range a {
+		vb, ok := b[k]
+		if !ok {
+			return false
+		}
+		if math.IsNaN(va) || math.IsNaN(vb) {
+			if math.IsNaN(va) != math.IsNaN(vb) {
+				return false
+			}
+			continue
+		}
+		if va == vb {
+			continue
+		}
+		if math.IsInf(va, 0) || math.IsInf(vb, 0) || math.Abs(va-vb) > tolerance*math.Max(math.Abs(va), math.Abs(vb)) {
+			return false
+		}
+	}
+	return true
+}
+
+// stateHistoryEntry returns the entry of the state history for the transition. A transition that cannot be converted is logged
+// and counted, and false is returned.
+func (st *Manager) stateHistoryEntry(logger log.Logger, s StateTransition) (ngModels.AlertStateHistory, bool) {
+	h, err := toStateHistory(s)
+	if err != nil {
+		if st.metrics != nil {
+			st.metrics.StateHistoryFailures.WithLabelValues(fmt.Sprint(s.OrgID)).Inc()
+		}
+		logger.Error("Failed to record the state transition in the state history", "cacheID", s.CacheID, "state", s.Formatted(), "previous_state", s.PreviousFormatted(), "error", err)
+		return ngModels.AlertStateHistory{}, false
+	}
+	return h, true
+}
+
+// toStateHistory converts the transition to an entry of the state history.
+func toStateHistory(s StateTransition) (ngModels.AlertStateHistory, error) {
+	key, err := s.GetAlertInstanceKey()
+	if err != nil {
+		return ngModels.AlertStateHistory{}, err
 	}
+	return ngModels.AlertStateHistory{
+		RuleOrgID:      key.RuleOrgID,
+		RuleUID:        key.RuleUID,
+		LabelsHash:     key.LabelsHash,
+		Labels:         ngModels.InstanceLabels(s.Labels),
+		PreviousState:  ngModels.InstanceStateType(s.PreviousState.String()),
+		State:          ngModels.InstanceStateType(s.State.State.String()),
+		StateReason:    s.StateReason,
+		Values:         s.Values,
+		TransitionedAt: s.LastEvaluationTime,
+
+		MaintenanceWindowID: s.MaintenanceWindowID,
+		SilenceID:           s.SilenceID,
+	}, nil
 }
 
 func (st *Manager) deleteAlertStates(ctx context.Context, logger log.Logger, states []StateTransition) {
@@ -439,7 +1105,7 @@ func (st *Manager) deleteStaleStatesFromCache(ctx context.Context, logger log.Lo
 	var resolvedImage *ngModels.Image
 
 	staleStates := st.cache.deleteRuleStates(alertRule.GetKey(), func(s *State) bool {
-		return stateIsStale(evaluatedAt, s.LastEvaluationTime, alertRule.IntervalSeconds)
+		return stateIsStale(evaluatedAt, s.LastEvaluationTime, alertRule.IntervalSeconds, st.missingSeriesEvalsToResolve)
 	})
 	resolvedStates := make([]StateTransition, 0, len(staleStates))
 
@@ -455,6 +1121,7 @@ func (st *Manager) deleteStaleStatesFromCache(ctx context.Context, logger log.Lo
 
 		if oldState == eval.Alerting {
 			s.Resolved = true
+			s.ResolvedAt = evaluatedAt
 			// If there is no resolved image for this rule then take one
 			if resolvedImage == nil {
 				image, err := takeImage(ctx, st.images, alertRule)
@@ -480,6 +1147,7 @@ func (st *Manager) deleteStaleStatesFromCache(ctx context.Context, logger log.Lo
 	return resolvedStates
 }
 
-func stateIsStale(evaluatedAt time.Time, lastEval time.Time, intervalSeconds int64) bool {
-	return !lastEval.Add(2 * time.Duration(intervalSeconds) * time.Second).After(evaluatedAt)
+// stateIsStale returns true if the state was not updated by the last missedEvals evaluations of the rule.
+func stateIsStale(evaluatedAt time.Time, lastEval time.Time, intervalSeconds int64, missedEvals int64) bool {
+	return !lastEval.Add(time.Duration(missedEvals) * time.Duration(intervalSeconds) * time.Second).After(evaluatedAt)
 }
@@ -20,7 +20,7 @@ func BenchmarkProcessEvalResults(b *testing.B) {
 	as := annotations.FakeAnnotationsRepo{}
 	as.On("SaveMany", mock.Anything, mock.Anything).Return(nil)
 	metrics := metrics.NewHistorianMetrics(prometheus.NewRegistry())
-	hist := historian.NewAnnotationBackend(&as, nil, nil, metrics)
+	hist := historian.NewAnnotationBackend(&as, nil, nil, metrics, true)
 	cfg := state.ManagerCfg{
 		Historian: hist,
 	}
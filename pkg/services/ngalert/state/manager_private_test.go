@@ -7,11 +7,14 @@ import (
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/log/logtest"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -65,9 +68,15 @@ func TestStateIsStale(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			require.Equal(t, tc.expectedResult, stateIsStale(now, tc.lastEvaluation, intervalSeconds))
+			require.Equal(t, tc.expectedResult, stateIsStale(now, tc.lastEvaluation, intervalSeconds, 2))
 		})
 	}
+
+	t.Run("should respect the number of missed evaluations", func(t *testing.T) {
+		lastEvaluation := now.Add(-time.Duration(intervalSeconds) * time.Second * 2)
+		require.False(t, stateIsStale(now, lastEvaluation, intervalSeconds, 3))
+		require.True(t, stateIsStale(now, lastEvaluation, intervalSeconds, 1))
+	})
 }
 
 func TestManager_saveAlertStates(t *testing.T) {
@@ -115,7 +124,7 @@ func TestManager_saveAlertStates(t *testing.T) {
 	t.Run("should save all transitions if doNotSaveNormalState is false", func(t *testing.T) {
 		st := &FakeInstanceStore{}
 		m := Manager{instanceStore: st, doNotSaveNormalState: false}
-		m.saveAlertStates(context.Background(), &logtest.Fake{}, transitions...)
+		m.saveAlertStates(context.Background(), &logtest.Fake{}, transitions, nil, true)
 
 		savedKeys := map[ngmodels.AlertInstanceKey]ngmodels.AlertInstance{}
 		for _, op := range st.RecordedOps {
@@ -132,7 +141,7 @@ func TestManager_saveAlertStates(t *testing.T) {
 	t.Run("should not save Normal->Normal if doNotSaveNormalState is true", func(t *testing.T) {
 		st := &FakeInstanceStore{}
 		m := Manager{instanceStore: st, doNotSaveNormalState: true}
-		m.saveAlertStates(context.Background(), &logtest.Fake{}, transitions...)
+		m.saveAlertStates(context.Background(), &logtest.Fake{}, transitions, nil, true)
 
 		savedKeys := map[ngmodels.AlertInstanceKey]ngmodels.AlertInstance{}
 		for _, op := range st.RecordedOps {
@@ -146,4 +155,133 @@ func TestManager_saveAlertStates(t *testing.T) {
 			assert.Containsf(t, savedKeys, key, "state %s (%s) was not saved but should be", tr.State.State, tr.StateReason)
 		}
 	})
+
+	t.Run("should record history of changed transitions including stale states", func(t *testing.T) {
+		st := &FakeInstanceStore{}
+		m := Manager{instanceStore: st, doNotSaveNormalState: true}
+		stale := StateTransition{
+			State: &State{
+				State:       eval.Normal,
+				StateReason: ngmodels.StateReasonMissingSeries,
+				Labels:      ngmodels.GenerateAlertLabels(5, "stale--"),
+			},
+			PreviousState: eval.Alerting,
+		}
+		m.saveAlertStates(context.Background(), &logtest.Fake{}, transitions, []StateTransition{stale}, true)
+
+		expected := 0
+		for _, tr := range transitions {
+			if tr.Changed() {
+				expected++
+			}
+		}
+		require.Len(t, st.History, expected+1)
+		last := st.History[len(st.History)-1]
+		assert.Equal(t, ngmodels.InstanceStateType(eval.Alerting.String()), last.PreviousState)
+		assert.Equal(t, ngmodels.StateReasonMissingSeries, last.StateReason)
+	})
+}
+
+func TestManager_Run_CleansUpOrphanedInstances(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	clk := clock.NewMock()
+	store := &FakeInstanceStore{}
+	st := NewManager(ManagerCfg{
+		Metrics:          metrics.NewNGAlert(prometheus.NewPedanticRegistry()).GetStateMetrics(),
+		InstanceStore:    store,
+		Images:           &NoopImageService{},
+		Clock:            clk,
+		Historian:        &FakeHistorian{},
+		CleanupInterval:  time.Hour,
+		CleanupBatchSize: 10,
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = st.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool {
+		clk.Add(time.Hour)
+		store.mtx.Lock()
+		defer store.mtx.Unlock()
+		for _, op := range store.RecordedOps {
+			if q, ok := op.(FakeInstanceStoreOp); ok && q.Name == "DeleteOrphanedAlertInstances" {
+				return q.Args[1] == 10
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestManager_Run_DeletesOldHistory(t *testing.T) {
+	run := func(t *testing.T, cfg ManagerCfg) (*FakeInstanceStore, *clock.Mock) {
+		ctx, cancel := context.WithCancel(context.Background())
+		clk := clock.NewMock()
+		store := &FakeInstanceStore{}
+		cfg.Metrics = metrics.NewNGAlert(prometheus.NewPedanticRegistry()).GetStateMetrics()
+		cfg.InstanceStore = store
+		cfg.Images = &NoopImageService{}
+		cfg.Clock = clk
+		cfg.Historian = &FakeHistorian{}
+		st := NewManager(cfg)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = st.Run(ctx)
+		}()
+		t.Cleanup(func() {
+			cancel()
+			<-done
+		})
+		return store, clk
+	}
+	deletedBefore := func(store *FakeInstanceStore) (time.Time, bool) {
+		store.mtx.Lock()
+		defer store.mtx.Unlock()
+		for _, op := range store.RecordedOps {
+			if q, ok := op.(FakeInstanceStoreOp); ok && q.Name == "DeleteAlertStateHistory" {
+				return q.Args[1].(time.Time), q.Args[2] == 10
+			}
+		}
+		return time.Time{}, false
+	}
+
+	t.Run("should delete old transitions even if the cleanup of instances is disabled", func(t *testing.T) {
+		store, clk := run(t, ManagerCfg{SaveStateHistory: true, CleanupBatchSize: 10, HistoryRetention: 24 * time.Hour})
+		require.Eventually(t, func() bool {
+			clk.Add(historyCleanupInterval)
+			before, ok := deletedBefore(store)
+			return ok && before.Equal(clk.Now().Add(-24*time.Hour))
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should not delete transitions if the state history is not saved", func(t *testing.T) {
+		store, clk := run(t, ManagerCfg{CleanupInterval: time.Hour, CleanupBatchSize: 10, HistoryRetention: 24 * time.Hour})
+		for i := 0; i < 10; i++ {
+			clk.Add(time.Hour)
+		}
+		_, ok := deletedBefore(store)
+		require.False(t, ok)
+	})
+}
+
+func TestManager_DeleteExpiredSilences(t *testing.T) {
+	clk := clock.NewMock()
+	active := &ngmodels.Silence{ID: 1, OrgID: 1, Start: clk.Now().Add(-time.Hour), End: clk.Now().Add(time.Hour)}
+	expired := &ngmodels.Silence{ID: 2, OrgID: 2, Start: clk.Now().Add(-2 * time.Hour), End: clk.Now().Add(-time.Hour)}
+	silences := &FakeSilenceStore{Silences: []*ngmodels.Silence{active, expired}}
+	st := NewManager(ManagerCfg{
+		Metrics:  metrics.NewNGAlert(prometheus.NewPedanticRegistry()).GetStateMetrics(),
+		Clock:    clk,
+		Silences: silences,
+	})
+
+	st.deleteExpiredSilences(context.Background())
+	require.Equal(t, []*ngmodels.Silence{active}, silences.Silences)
 }
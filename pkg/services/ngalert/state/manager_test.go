@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -221,7 +223,7 @@ func TestDashboardAnnotations(t *testing.T) {
 
 	fakeAnnoRepo := annotationstest.NewFakeAnnotationsRepo()
 	metrics := metrics.NewHistorianMetrics(prometheus.NewRegistry())
-	hist := historian.NewAnnotationBackend(fakeAnnoRepo, &dashboards.FakeDashboardService{}, nil, metrics)
+	hist := historian.NewAnnotationBackend(fakeAnnoRepo, &dashboards.FakeDashboardService{}, nil, metrics, true)
 	cfg := state.ManagerCfg{
 		Metrics:       testMetrics.GetStateMetrics(),
 		ExternalURL:   nil,
@@ -314,6 +316,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid",
@@ -369,6 +372,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid"],["alertname","test_title"],["instance_label_1","test"],["label","test"]]`,
+					ResultLabels: "instance_label_1=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid",
@@ -393,6 +397,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid"],["alertname","test_title"],["instance_label_2","test"],["label","test"]]`,
+					ResultLabels: "instance_label_2=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid",
@@ -451,6 +456,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_1",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_1"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_1",
@@ -513,6 +519,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -586,6 +593,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -681,6 +689,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -763,6 +772,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -784,7 +794,7 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
-					StartsAt:           evaluationTime.Add(20 * time.Second),
+					StartsAt:           evaluationTime.Add(30 * time.Second),
 					EndsAt:             evaluationTime.Add(30 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(30 * time.Second),
 					EvaluationDuration: evaluationDuration,
@@ -828,6 +838,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -893,6 +904,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -958,6 +970,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1024,6 +1037,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1089,6 +1103,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1115,6 +1130,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["label","test"]]`,
+					ResultLabels: "",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1180,6 +1196,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test-1"],["label","test"]]`,
+					ResultLabels: "instance_label=test-1",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1206,6 +1223,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test-2"],["label","test"]]`,
+					ResultLabels: "instance_label=test-2",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1232,6 +1250,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["label","test"]]`,
+					ResultLabels: "",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1299,6 +1318,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1330,6 +1350,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["label","test"]]`,
+					ResultLabels: "",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1389,6 +1410,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1456,6 +1478,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1525,6 +1548,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1617,6 +1641,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1697,6 +1722,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1777,6 +1803,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1851,6 +1878,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -1951,6 +1979,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -2033,6 +2062,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -2121,6 +2151,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid_2",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid_2"],["alertname","test_title"],["instance_label","test"],["label","test"]]`,
+					ResultLabels: "instance_label=test",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid_2",
@@ -2147,7 +2178,7 @@ func TestProcessEvalResults(t *testing.T) {
 							Values:          make(map[string]*float64),
 						},
 					},
-					StartsAt:           evaluationTime.Add(30 * time.Second),
+					StartsAt:           evaluationTime.Add(50 * time.Second),
 					EndsAt:             evaluationTime.Add(50 * time.Second).Add(state.ResendDelay * 3),
 					LastEvaluationTime: evaluationTime.Add(50 * time.Second),
 					EvaluationDuration: evaluationDuration,
@@ -2181,6 +2212,7 @@ func TestProcessEvalResults(t *testing.T) {
 					AlertRuleUID: "test_alert_rule_uid",
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","test_namespace_uid"],["__alert_rule_uid__","test_alert_rule_uid"],["alertname","test_title"],["cluster","us-central-1"],["job","prod/grafana"],["label","test"],["namespace","prod"],["pod","grafana"]]`,
+					ResultLabels: "cluster=us-central-1, namespace=prod, pod=grafana",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "test_namespace_uid",
 						"__alert_rule_uid__":           "test_alert_rule_uid",
@@ -2211,7 +2243,7 @@ func TestProcessEvalResults(t *testing.T) {
 	for _, tc := range testCases {
 		fakeAnnoRepo := annotationstest.NewFakeAnnotationsRepo()
 		metrics := metrics.NewHistorianMetrics(prometheus.NewRegistry())
-		hist := historian.NewAnnotationBackend(fakeAnnoRepo, &dashboards.FakeDashboardService{}, nil, metrics)
+		hist := historian.NewAnnotationBackend(fakeAnnoRepo, &dashboards.FakeDashboardService{}, nil, metrics, true)
 		cfg := state.ManagerCfg{
 			Metrics:       testMetrics.GetStateMetrics(),
 			ExternalURL:   nil,
@@ -2356,6 +2388,7 @@ func TestStaleResultsHandler(t *testing.T) {
 					AlertRuleUID: rule.UID,
 					OrgID:        1,
 					CacheID:      `[["__alert_rule_namespace_uid__","namespace"],["__alert_rule_uid__","` + rule.UID + `"],["alertname","` + rule.Title + `"],["test1","testValue1"]]`,
+					ResultLabels: "test1=testValue1",
 					Labels: data.Labels{
 						"__alert_rule_namespace_uid__": "namespace",
 						"__alert_rule_uid__":           rule.UID,
@@ -2547,6 +2580,1167 @@ func TestStaleResults(t *testing.T) {
 	})
 }
 
+func TestStaleResults_MissingSeriesEvalsToResolve(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	store := &state.FakeInstanceStore{}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:                     testMetrics.GetStateMetrics(),
+		InstanceStore:               store,
+		Images:                      &state.NoopImageService{},
+		Clock:                       clk,
+		Historian:                   &state.FakeHistorian{},
+		MissingSeriesEvalsToResolve: 3,
+	})
+
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	interval := time.Duration(rule.IntervalSeconds) * time.Second
+	kept := eval.ResultGen(eval.WithState(eval.Alerting), eval.WithEvaluatedAt(clk.Now()))()
+	missing := eval.ResultGen(eval.WithState(eval.Alerting), eval.WithEvaluatedAt(clk.Now()))()
+
+	st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{kept, missing}, nil)
+	require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), 2)
+
+	deleted := func() []models.AlertInstanceKey {
+		var keys []models.AlertInstanceKey
+		for _, op := range store.RecordedOps {
+			if q, ok := op.(state.FakeInstanceStoreOp); ok && q.Name == "DeleteAlertInstances" {
+				keys = append(keys, q.Args[1].([]models.AlertInstanceKey)...)
+			}
+		}
+		return keys
+	}
+
+	// the series is missing in the next two evaluations
+	for i := 0; i < 2; i++ {
+		clk.Add(interval)
+		kept.EvaluatedAt = clk.Now()
+		transitions := st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{kept}, nil)
+		require.Len(t, transitions, 1)
+		require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), 2)
+		require.Empty(t, deleted())
+	}
+
+	// and it is resolved and deleted after the third one
+	clk.Add(interval)
+	kept.EvaluatedAt = clk.Now()
+	transitions := st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{kept}, nil)
+	require.Len(t, transitions, 2)
+	var stale *state.StateTransition
+	for i := range transitions {
+		if transitions[i].StateReason == models.StateReasonMissingSeries {
+			stale = &transitions[i]
+		}
+	}
+	require.NotNil(t, stale)
+	require.Equal(t, eval.Normal, stale.State.State)
+	require.Equal(t, eval.Alerting, stale.PreviousState)
+	require.True(t, stale.Resolved)
+
+	require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), 1)
+	key, err := stale.GetAlertInstanceKey()
+	require.NoError(t, err)
+	require.Equal(t, []models.AlertInstanceKey{key}, deleted())
+}
+
+func TestProcessEvalResults_DoesNotSaveStateHistory(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	store := &state.FakeInstanceStore{}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:       testMetrics.GetStateMetrics(),
+		InstanceStore: store,
+		Images:        &state.NoopImageService{},
+		Clock:         clk,
+		Historian:     &state.FakeHistorian{},
+	})
+
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	for _, s := range []eval.State{eval.Alerting, eval.Normal} {
+		clk.Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		result := eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithState(s))()
+		st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil)
+	}
+	st.ResetAlertInstances(ctx, rule, &models.ResetAlertInstancesCommand{OrgID: rule.OrgID, RuleUID: rule.UID})
+
+	// the state history is only saved for the sql backend of the historian
+	require.NotEmpty(t, store.RecordedOps)
+	require.Empty(t, store.History)
+}
+
+func TestProcessEvalResults_NormalEvalsToResolve(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	store := &state.FakeInstanceStore{}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:              testMetrics.GetStateMetrics(),
+		InstanceStore:        store,
+		Images:               &state.NoopImageService{},
+		Clock:                clk,
+		Historian:            &state.FakeHistorian{},
+		SaveStateHistory:     true,
+		NormalEvalsToResolve: 3,
+	})
+
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	interval := time.Duration(rule.IntervalSeconds) * time.Second
+	result := eval.ResultGen(eval.WithEvaluatedAt(clk.Now()))()
+	evaluate := func(s eval.State) state.StateTransition {
+		clk.Add(interval)
+		result.State = s
+		result.EvaluatedAt = clk.Now()
+		transitions := st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil)
+		require.Len(t, transitions, 1)
+		return transitions[0]
+	}
+
+	require.Equal(t, eval.Alerting, evaluate(eval.Alerting).State.State)
+
+	// the rule flaps, and the state stays Alerting because there are never 3 normal evaluations in a row
+	for i := 0; i < 3; i++ {
+		tr := evaluate(eval.Normal)
+		require.Equal(t, eval.Alerting, tr.State.State)
+		require.Equal(t, models.StateReasonResolving, tr.StateReason)
+		require.False(t, tr.Resolved)
+		tr = evaluate(eval.Alerting)
+		require.Equal(t, eval.Alerting, tr.State.State)
+		require.Empty(t, tr.StateReason)
+	}
+	require.Equal(t, eval.Alerting, evaluate(eval.Normal).State.State)
+	require.Equal(t, eval.Alerting, evaluate(eval.Normal).State.State)
+
+	tr := evaluate(eval.Normal)
+	require.Equal(t, eval.Normal, tr.State.State)
+	require.Equal(t, eval.Alerting, tr.PreviousState)
+	require.True(t, tr.Resolved)
+
+	// the history contains the flapping as changes of the state reason
+	var reasons []string
+	for _, h := range store.History {
+		if h.State == models.InstanceStateFiring && h.PreviousState == models.InstanceStateFiring {
+			reasons = append(reasons, h.StateReason)
+		}
+	}
+	require.Equal(t, []string{
+		models.StateReasonResolving, "",
+		models.StateReasonResolving, "",
+		models.StateReasonResolving, "",
+		models.StateReasonResolving,
+	}, reasons)
+}
+
+func TestProcessEvalResults_PublishesChangedStates(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	publisher := &state.FakeStatePublisher{}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:       testMetrics.GetStateMetrics(),
+		InstanceStore: &state.FakeInstanceStore{},
+		Images:        &state.NoopImageService{},
+		Clock:         clk,
+		Historian:     &state.FakeHistorian{},
+		Publisher:     publisher,
+	})
+
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	result := eval.ResultGen(eval.WithEvaluatedAt(clk.Now()))()
+	evaluate := func(s eval.State) {
+		clk.Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		result.State = s
+		result.EvaluatedAt = clk.Now()
+		st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil)
+	}
+
+	evaluate(eval.Alerting)
+	evaluate(eval.Alerting)
+	evaluate(eval.Normal)
+
+	require.Len(t, publisher.StateTransitions, 2)
+	require.Equal(t, eval.Normal, publisher.StateTransitions[0].PreviousState)
+	require.Equal(t, eval.Alerting, publisher.StateTransitions[0].State.State)
+	require.Equal(t, eval.Alerting, publisher.StateTransitions[1].PreviousState)
+	require.Equal(t, eval.Normal, publisher.StateTransitions[1].State.State)
+
+	st.ResetStateByRuleUID(ctx, rule, models.StateReasonPaused)
+
+	require.Len(t, publisher.StateTransitions, 3)
+	require.Equal(t, models.StateReasonPaused, publisher.StateTransitions[2].StateReason)
+}
+
+func TestProcessEvalResults_GroupsNotifications(t *testing.T) {
+	ctx := context.Background()
+	newManager := func(clk clock.Clock, notifier state.ContactPointNotifier) *state.Manager {
+		return state.NewManager(state.ManagerCfg{
+			Metrics:               testMetrics.GetStateMetrics(),
+			InstanceStore:         &state.FakeInstanceStore{},
+			Images:                &state.NoopImageService{},
+			Clock:                 clk,
+			Historian:             &state.FakeHistorian{},
+			ContactPointNotifier:  notifier,
+			NotificationGroupWait: 30 * time.Second,
+			NotificationGroupBy:   "cluster",
+		})
+	}
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	rule.Labels = nil
+	rule.NotificationTitle = ""
+	rule.NotificationMessage = ""
+	rule.IntervalSeconds = 10
+	rule.ContactPointUIDs = []string{"email"}
+	results := func(clk clock.Clock, s eval.State, cluster string, hosts int) eval.Results {
+		var results eval.Results
+		for i := 0; i < hosts; i++ {
+			labels := data.Labels{"cluster": cluster, "host": fmt.Sprintf("host-%02d", i)}
+			results = append(results, eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithState(s), eval.WithLabels(labels))())
+		}
+		return results
+	}
+
+	t.Run("burst of instances should be sent as one notification per group", func(t *testing.T) {
+		clk := clock.NewMock()
+		notifier := &state.FakeContactPointNotifier{}
+		st := newManager(clk, notifier)
+
+		burst := append(results(clk, eval.Alerting, "a", 12), results(clk, eval.Alerting, "b", 1)...)
+		st.ProcessEvalResults(ctx, clk.Now(), rule, burst, nil)
+		require.Empty(t, notifier.Notifications, "notifications should be buffered")
+
+		// the last instance of cluster a resolves before the group is sent
+		clk.Add(10 * time.Second)
+		next := append(results(clk, eval.Alerting, "a", 11), results(clk, eval.Normal, "a", 12)[11])
+		next = append(next, results(clk, eval.Alerting, "b", 1)...)
+		st.ProcessEvalResults(ctx, clk.Now(), rule, next, nil)
+		require.Empty(t, notifier.Notifications)
+
+		clk.Add(20 * time.Second)
+		require.Len(t, notifier.Notifications, 2)
+		byCluster := map[string]models.AlertingNotification{}
+		for _, n := range notifier.Notifications {
+			require.Equal(t, "email", n.ContactPointUID)
+			byCluster[n.Notification.Labels["cluster"]] = n.Notification
+		}
+
+		grouped := byCluster["a"]
+		require.Equal(t, 11, grouped.Count)
+		require.Len(t, grouped.Instances, 10)
+		require.NotContains(t, grouped.Labels, "host", "labels should be the ones that all instances have in common")
+		require.Equal(t, fmt.Sprintf("%s is alerting for 11 instances", rule.Title), grouped.Title)
+		require.Contains(t, grouped.Message, "11 instances are alerting:\n- {host=host-00}\n- {host=host-01}")
+		require.NotContains(t, grouped.Message, "host-11")
+		require.True(t, strings.HasSuffix(grouped.Message, "\n- {host=host-09}\n- and 1 more"), grouped.Message)
+
+		single := byCluster["b"]
+		require.Equal(t, 1, single.Count)
+		require.Empty(t, single.Instances)
+		require.Equal(t, "host-00", single.Labels["host"])
+
+		// the instances are still alerting, and are not notified again
+		clk.Add(10 * time.Second)
+		still := append(results(clk, eval.Alerting, "a", 11), results(clk, eval.Normal, "a", 12)[11])
+		still = append(still, results(clk, eval.Alerting, "b", 1)...)
+		st.ProcessEvalResults(ctx, clk.Now(), rule, still, nil)
+		clk.Add(30 * time.Second)
+		require.Len(t, notifier.Notifications, 2)
+	})
+
+	t.Run("group without alerting instances should not be sent", func(t *testing.T) {
+		clk := clock.NewMock()
+		notifier := &state.FakeContactPointNotifier{}
+		st := newManager(clk, notifier)
+
+		st.ProcessEvalResults(ctx, clk.Now(), rule, results(clk, eval.Alerting, "a", 2), nil)
+		clk.Add(10 * time.Second)
+		st.ProcessEvalResults(ctx, clk.Now(), rule, results(clk, eval.Normal, "a", 2), nil)
+		clk.Add(time.Minute)
+		require.Empty(t, notifier.Notifications)
+	})
+
+	t.Run("reset instances should be removed from their group", func(t *testing.T) {
+		clk := clock.NewMock()
+		notifier := &state.FakeContactPointNotifier{}
+		st := newManager(clk, notifier)
+
+		st.ProcessEvalResults(ctx, clk.Now(), rule, results(clk, eval.Alerting, "a", 2), nil)
+		st.ResetAlertInstances(ctx, rule, &models.ResetAlertInstancesCommand{OrgID: rule.OrgID, RuleUID: rule.UID})
+		clk.Add(time.Minute)
+		require.Empty(t, notifier.Notifications)
+	})
+
+	t.Run("buffered notifications should be sent on shutdown", func(t *testing.T) {
+		clk := clock.NewMock()
+		notifier := &state.FakeContactPointNotifier{}
+		st := newManager(clk, notifier)
+		runCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = st.Run(runCtx)
+		}()
+
+		st.ProcessEvalResults(ctx, clk.Now(), rule, results(clk, eval.Alerting, "a", 3), nil)
+		cancel()
+		<-done
+		require.Len(t, notifier.Notifications, 1)
+		require.Equal(t, 3, notifier.Notifications[0].Notification.Count)
+	})
+}
+
+func TestProcessEvalResults_NotifiesContactPoints(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	notifier := &state.FakeContactPointNotifier{
+		Errors: map[string]error{"failing": errors.New("failed to send")},
+	}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:              testMetrics.GetStateMetrics(),
+		InstanceStore:        &state.FakeInstanceStore{},
+		Images:               &state.NoopImageService{},
+		Clock:                clk,
+		Historian:            &state.FakeHistorian{},
+		ContactPointNotifier: notifier,
+	})
+
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	rule.ContactPointUIDs = []string{"failing", "email"}
+	rule.Annotations = map[string]string{models.RunbookURLAnnotation: "https://example.com/runbook"}
+	result := eval.ResultGen(eval.WithEvaluatedAt(clk.Now()))()
+	evaluate := func(s eval.State) {
+		clk.Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		result.State = s
+		result.EvaluatedAt = clk.Now()
+		result.EvaluationString = "[ var='A' value=1 ]"
+		st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil)
+	}
+
+	evaluate(eval.Normal)
+	require.Empty(t, notifier.Notifications)
+
+	// the failure of the first contact point does not prevent the notification of the second one
+	evaluate(eval.Alerting)
+	require.Len(t, notifier.Notifications, 2)
+	require.Equal(t, "failing", notifier.Notifications[0].ContactPointUID)
+	require.Equal(t, "email", notifier.Notifications[1].ContactPointUID)
+	n := notifier.Notifications[1].Notification
+	require.Equal(t, rule.OrgID, n.OrgID)
+	require.Equal(t, rule.UID, n.RuleUID)
+	require.Equal(t, rule.Title, n.RuleTitle)
+	require.Equal(t, "[ var='A' value=1 ]", n.Value)
+	require.Equal(t, "https://example.com/runbook", n.RunbookURL)
+	require.Equal(t, clk.Now(), n.StartsAt)
+
+	// the state does not change
+	evaluate(eval.Alerting)
+	require.Len(t, notifier.Notifications, 2)
+
+	firedAt := n.StartsAt
+	evaluate(eval.Normal)
+	require.Len(t, notifier.Notifications, 4)
+	resolved := notifier.Notifications[3].Notification
+	require.True(t, resolved.Resolved())
+	require.Equal(t, firedAt, resolved.StartsAt, "resolved notification should have the start of the alert")
+	require.Equal(t, clk.Now(), resolved.EndsAt)
+
+	evaluate(eval.Normal)
+	require.Len(t, notifier.Notifications, 4)
+
+	evaluate(eval.Alerting)
+	require.Len(t, notifier.Notifications, 6)
+	require.False(t, notifier.Notifications[5].Notification.Resolved())
+}
+
+func TestProcessEvalResults_ShadowMode(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	notifier := &state.FakeContactPointNotifier{}
+	historian := &state.FakeHistorian{}
+	publisher := &state.FakeStatePublisher{}
+	instanceStore := &state.FakeInstanceStore{}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:              testMetrics.GetStateMetrics(),
+		InstanceStore:        instanceStore,
+		Images:               &state.NoopImageService{},
+		Clock:                clk,
+		Historian:            historian,
+		Publisher:            publisher,
+		ContactPointNotifier: notifier,
+		ShadowMode:           true,
+	})
+
+	migrated := models.AlertRuleGen(models.WithFor(0))()
+	migrated.ContactPointUIDs = []string{"email"}
+	migrated.Annotations = map[string]string{models.MigratedAlertIDAnnotation: "42"}
+	other := models.AlertRuleGen(models.WithFor(0))()
+	other.ContactPointUIDs = []string{"email"}
+	other.Annotations = map[string]string{}
+	require.True(t, st.IsShadow(migrated))
+	require.False(t, st.IsShadow(other))
+
+	result := eval.ResultGen(eval.WithEvaluatedAt(clk.Now()))()
+	result.State = eval.Alerting
+	transitions := st.ProcessEvalResults(ctx, clk.Now(), migrated, eval.Results{result}, nil)
+	require.Len(t, transitions, 1)
+	require.Equal(t, eval.Alerting, transitions[0].State.State)
+	require.Len(t, st.GetStatesForRuleUID(migrated.OrgID, migrated.UID), 1)
+	require.Empty(t, notifier.Notifications)
+	require.Empty(t, historian.StateTransitions)
+	require.Empty(t, publisher.StateTransitions)
+	require.Empty(t, instanceStore.History)
+	require.NotEmpty(t, instanceStore.RecordedOps, "the state of the instances of the rules in shadow mode is saved")
+
+	// the rules that were not migrated from legacy alerts are not in shadow mode
+	st.ProcessEvalResults(ctx, clk.Now(), other, eval.Results{result}, nil)
+	require.Len(t, notifier.Notifications, 1)
+	require.NotEmpty(t, historian.StateTransitions)
+}
+
+func TestProcessEvalResults_RendersNotificationTemplates(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	notifier := &state.FakeContactPointNotifier{}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:              testMetrics.GetStateMetrics(),
+		InstanceStore:        &state.FakeInstanceStore{},
+		Images:               &state.NoopImageService{},
+		Clock:                clk,
+		Historian:            &state.FakeHistorian{},
+		ContactPointNotifier: notifier,
+	})
+
+	notify := func(rule *models.AlertRule) models.AlertingNotification {
+		t.Helper()
+		notifier.Notifications = nil
+		clk.Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		result := eval.ResultGen(eval.WithEvaluatedAt(clk.Now()))()
+		result.State = eval.Alerting
+		result.Instance = data.Labels{"instance": "server-1"}
+		result.Values = map[string]eval.NumberValueCapture{"B": {Var: "B", Value: util.Pointer(95.5)}}
+		st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil)
+		require.Len(t, notifier.Notifications, 1)
+		return notifier.Notifications[0].Notification
+	}
+
+	t.Run("renders the templates of the rule", func(t *testing.T) {
+		rule := models.AlertRuleGen(models.WithFor(0))()
+		rule.ContactPointUIDs = []string{"email"}
+		rule.NotificationTitle = "High latency on {{ $labels.instance }}: {{ $values.B }}ms"
+		rule.NotificationMessage = "{{ $name }} is firing"
+		n := notify(rule)
+		require.Equal(t, "High latency on server-1: 95.5ms", n.Title)
+		require.Equal(t, rule.Title+" is firing", n.Message)
+	})
+
+	t.Run("keeps the defaults of the contact points without templates", func(t *testing.T) {
+		rule := models.AlertRuleGen(models.WithFor(0))()
+		rule.ContactPointUIDs = []string{"email"}
+		n := notify(rule)
+		require.Empty(t, n.Title)
+		require.Empty(t, n.Message)
+	})
+
+	t.Run("falls back to the default if a template cannot be rendered", func(t *testing.T) {
+		rule := models.AlertRuleGen(models.WithFor(0))()
+		rule.ContactPointUIDs = []string{"email"}
+		rule.Labels = nil
+		rule.NotificationTitle = "{{ humanize $labels.instance }}"
+		rule.NotificationMessage = "{{ $labels.instance"
+		n := notify(rule)
+		require.Equal(t, rule.Title, n.Title)
+		require.Equal(t, rule.Title+" is alerting for instance=server-1", n.Message)
+	})
+}
+
+func TestProcessEvalResults_MaintenanceWindow(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	store := &state.FakeInstanceStore{}
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	interval := time.Duration(rule.IntervalSeconds) * time.Second
+	window := &models.MaintenanceWindow{
+		ID:    5,
+		OrgID: rule.OrgID,
+		Title: "maintenance",
+		Start: clk.Now().Add(2 * interval),
+		End:   clk.Now().Add(4 * interval),
+	}
+	otherOrg := &models.MaintenanceWindow{ID: 1, OrgID: rule.OrgID + 1, Title: "other", Start: clk.Now(), End: clk.Now().Add(10 * interval)}
+	windows := &state.FakeMaintenanceWindowReader{Windows: []*models.MaintenanceWindow{window, otherOrg}}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:            testMetrics.GetStateMetrics(),
+		InstanceStore:      store,
+		Images:             &state.NoopImageService{},
+		Clock:              clk,
+		Historian:          &state.FakeHistorian{},
+		SaveStateHistory:   true,
+		MaintenanceWindows: windows,
+	})
+
+	result := eval.ResultGen(eval.WithEvaluatedAt(clk.Now()))()
+	evaluate := func(s eval.State) state.StateTransition {
+		clk.Add(interval)
+		result.State = s
+		result.EvaluatedAt = clk.Now()
+		transitions := st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil)
+		require.Len(t, transitions, 1)
+		return transitions[0]
+	}
+
+	tr := evaluate(eval.Alerting)
+	require.Equal(t, eval.Alerting, tr.State.State)
+	require.Zero(t, tr.MaintenanceWindowID, "window of another organization should not suppress transitions")
+
+	// the window starts, the state is still updated but the transitions are suppressed
+	tr = evaluate(eval.Normal)
+	require.Equal(t, eval.Normal, tr.State.State)
+	require.Equal(t, window.ID, tr.MaintenanceWindowID)
+	tr = evaluate(eval.Alerting)
+	require.Equal(t, eval.Alerting, tr.State.State)
+	require.Equal(t, window.ID, tr.MaintenanceWindowID)
+
+	// the window ends
+	tr = evaluate(eval.Alerting)
+	require.Equal(t, eval.Alerting, tr.State.State)
+	require.Zero(t, tr.MaintenanceWindowID)
+
+	windowIDs := make([]int64, 0, len(store.History))
+	for _, h := range store.History {
+		windowIDs = append(windowIDs, h.MaintenanceWindowID)
+	}
+	require.Equal(t, []int64{0, window.ID, window.ID}, windowIDs, "history should record the suppressed transitions")
+
+	// nothing can be notified while the instance stays normal, so the windows are not read
+	evaluate(eval.Normal)
+	reads := windows.Reads
+	evaluate(eval.Normal)
+	require.Equal(t, reads, windows.Reads)
+}
+
+func TestProcessEvalResults_Silences(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	store := &state.FakeInstanceStore{}
+	notifier := &state.FakeContactPointNotifier{}
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	rule.Labels = nil
+	rule.ContactPointUIDs = []string{"email"}
+	interval := time.Duration(rule.IntervalSeconds) * time.Second
+	staging := &models.Silence{
+		ID:       3,
+		OrgID:    rule.OrgID,
+		Matchers: []models.SilenceMatcher{{Name: "cluster", Value: "staging"}},
+		Start:    clk.Now(),
+		End:      clk.Now().Add(4 * interval),
+	}
+	expired := &models.Silence{
+		ID:       1,
+		OrgID:    rule.OrgID,
+		Matchers: []models.SilenceMatcher{{Name: "cluster", Value: "prod"}},
+		Start:    clk.Now().Add(-2 * interval),
+		End:      clk.Now(),
+	}
+	otherOrg := &models.Silence{
+		ID:       2,
+		OrgID:    rule.OrgID + 1,
+		Matchers: []models.SilenceMatcher{{Name: "cluster", Value: "prod"}},
+		Start:    clk.Now(),
+		End:      clk.Now().Add(4 * interval),
+	}
+	silences := &state.FakeSilenceStore{Silences: []*models.Silence{staging, expired, otherOrg}}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:              testMetrics.GetStateMetrics(),
+		InstanceStore:        store,
+		Images:               &state.NoopImageService{},
+		Clock:                clk,
+		Historian:            &state.FakeHistorian{},
+		SaveStateHistory:     true,
+		ContactPointNotifier: notifier,
+		Silences:             silences,
+	})
+
+	clk.Add(interval)
+	results := eval.Results{
+		eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithState(eval.Alerting), eval.WithLabels(data.Labels{"cluster": "staging"}))(),
+		eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithState(eval.Alerting), eval.WithLabels(data.Labels{"cluster": "prod"}))(),
+	}
+	transitions := st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil)
+	require.Len(t, transitions, 2)
+
+	// the state of the silenced instance is still updated
+	require.Equal(t, eval.Alerting, transitions[0].State.State)
+	require.Equal(t, staging.ID, transitions[0].SilenceID)
+	require.Equal(t, eval.Alerting, transitions[1].State.State)
+	require.Zero(t, transitions[1].SilenceID, "expired silence and silence of another organization should not silence the instance")
+
+	require.Len(t, notifier.Notifications, 1)
+	require.Equal(t, "prod", notifier.Notifications[0].Notification.Labels["cluster"])
+
+	silenceIDs := make(map[string]int64, len(store.History))
+	for _, h := range store.History {
+		silenceIDs[h.Labels["cluster"]] = h.SilenceID
+	}
+	require.Equal(t, map[string]int64{"staging": staging.ID, "prod": 0}, silenceIDs, "history should record the silenced transitions")
+
+	// only the instance whose contact points were notified is notified that it is resolved
+	clk.Add(interval)
+	results = eval.Results{
+		eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithState(eval.Normal), eval.WithLabels(data.Labels{"cluster": "staging"}))(),
+		eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithState(eval.Normal), eval.WithLabels(data.Labels{"cluster": "prod"}))(),
+	}
+	st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil)
+	require.Len(t, notifier.Notifications, 2)
+	require.True(t, notifier.Notifications[1].Notification.Resolved())
+	require.Equal(t, "prod", notifier.Notifications[1].Notification.Labels["cluster"])
+
+	// nothing can be notified while the instances stay normal, so the silences are not read
+	reads := silences.Reads
+	clk.Add(interval)
+	st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil)
+	require.Equal(t, reads, silences.Reads)
+}
+
+func TestProcessEvalResults_SendResolved(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name         string
+		sendResolved bool
+		groupWait    time.Duration
+		expected     []bool
+	}{
+		{name: "should notify that the instance is resolved", sendResolved: true, expected: []bool{false, true}},
+		{name: "should not notify that the instance is resolved", sendResolved: false, expected: []bool{false}},
+		{name: "should not notify that a grouped instance is resolved", sendResolved: false, groupWait: 30 * time.Second, expected: []bool{false}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clk := clock.NewMock()
+			notifier := &state.FakeContactPointNotifier{}
+			historian := &state.FakeHistorian{}
+			st := state.NewManager(state.ManagerCfg{
+				Metrics:               testMetrics.GetStateMetrics(),
+				InstanceStore:         &state.FakeInstanceStore{},
+				Images:                &state.NoopImageService{},
+				Clock:                 clk,
+				Historian:             historian,
+				ContactPointNotifier:  notifier,
+				NotificationGroupWait: tc.groupWait,
+			})
+			rule := models.AlertRuleGen(models.WithFor(0))()
+			rule.Labels = nil
+			rule.ContactPointUIDs = []string{"email"}
+			rule.SendResolved = tc.sendResolved
+			interval := time.Duration(rule.IntervalSeconds) * time.Second
+
+			evaluate := func(s eval.State) {
+				clk.Add(interval)
+				results := eval.Results{
+					eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithState(s), eval.WithLabels(data.Labels{"host": "a"}))(),
+				}
+				st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil)
+			}
+
+			evaluate(eval.Alerting)
+			clk.Add(tc.groupWait)
+			evaluate(eval.Normal)
+			clk.Add(tc.groupWait)
+
+			resolved := make([]bool, 0, len(notifier.Notifications))
+			for _, n := range notifier.Notifications {
+				resolved = append(resolved, n.Notification.Resolved())
+			}
+			require.Equal(t, tc.expected, resolved)
+
+			// the transition is recorded whether or not it is notified
+			transitions := historian.StateTransitions
+			require.NotEmpty(t, transitions)
+			last := transitions[len(transitions)-1]
+			require.Equal(t, eval.Alerting, last.PreviousState)
+			require.Equal(t, eval.Normal, last.State.State)
+		})
+	}
+}
+
+func TestProcessEvalResults_RepeatsNotifications(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(silences *state.FakeSilenceStore) (*clock.Mock, *state.FakeContactPointNotifier, *state.Manager, *models.AlertRule) {
+		clk := clock.NewMock()
+		notifier := &state.FakeContactPointNotifier{}
+		rule := models.AlertRuleGen(models.WithFor(0))()
+		rule.Labels = nil
+		rule.ContactPointUIDs = []string{"email"}
+		rule.NotificationRepeatInterval = 3 * time.Duration(rule.IntervalSeconds) * time.Second
+		cfg := state.ManagerCfg{
+			Metrics:              testMetrics.GetStateMetrics(),
+			InstanceStore:        &state.FakeInstanceStore{},
+			Images:               &state.NoopImageService{},
+			Clock:                clk,
+			Historian:            &state.FakeHistorian{},
+			ContactPointNotifier: notifier,
+		}
+		if silences != nil {
+			cfg.Silences = silences
+		}
+		return clk, notifier, state.NewManager(cfg), rule
+	}
+
+	evaluate := func(st *state.Manager, clk *clock.Mock, rule *models.AlertRule, s eval.State) {
+		clk.Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		results := eval.Results{
+			eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithState(s), eval.WithLabels(data.Labels{"host": "a"}))(),
+		}
+		st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil)
+	}
+
+	t.Run("should repeat the notification once per interval until the instance is resolved", func(t *testing.T) {
+		clk, notifier, st, rule := setup(nil)
+
+		evaluate(st, clk, rule, eval.Alerting)
+		require.Len(t, notifier.Notifications, 1)
+
+		// the interval has not elapsed yet
+		evaluate(st, clk, rule, eval.Alerting)
+		evaluate(st, clk, rule, eval.Alerting)
+		require.Len(t, notifier.Notifications, 1)
+
+		evaluate(st, clk, rule, eval.Alerting)
+		require.Len(t, notifier.Notifications, 2)
+		require.Equal(t, "a", notifier.Notifications[1].Notification.Labels["host"])
+
+		evaluate(st, clk, rule, eval.Alerting)
+		require.Len(t, notifier.Notifications, 2, "the notification should be repeated at most once per interval")
+
+		for i := 0; i < 5; i++ {
+			evaluate(st, clk, rule, eval.Normal)
+		}
+		require.Len(t, notifier.Notifications, 3)
+		require.True(t, notifier.Notifications[2].Notification.Resolved(), "a resolved instance should only be notified that it is resolved")
+
+		// the repeat interval starts over when the instance fires again
+		evaluate(st, clk, rule, eval.Alerting)
+		require.Len(t, notifier.Notifications, 4)
+		evaluate(st, clk, rule, eval.Alerting)
+		evaluate(st, clk, rule, eval.Alerting)
+		require.Len(t, notifier.Notifications, 4)
+	})
+
+	t.Run("should not repeat the notification if it is zero", func(t *testing.T) {
+		clk, notifier, st, rule := setup(nil)
+		rule.NotificationRepeatInterval = 0
+
+		for i := 0; i < 10; i++ {
+			evaluate(st, clk, rule, eval.Alerting)
+		}
+		require.Len(t, notifier.Notifications, 1)
+	})
+
+	t.Run("should not repeat the notification while the instance is silenced", func(t *testing.T) {
+		silences := &state.FakeSilenceStore{}
+		clk, notifier, st, rule := setup(silences)
+		interval := time.Duration(rule.IntervalSeconds) * time.Second
+
+		evaluate(st, clk, rule, eval.Alerting)
+		require.Len(t, notifier.Notifications, 1)
+
+		silences.Silences = []*models.Silence{{
+			ID:       1,
+			OrgID:    rule.OrgID,
+			Matchers: []models.SilenceMatcher{{Name: "host", Value: "a"}},
+			Start:    clk.Now(),
+			End:      clk.Now().Add(4*interval + interval/2),
+		}}
+		for i := 0; i < 4; i++ {
+			evaluate(st, clk, rule, eval.Alerting)
+		}
+		require.Len(t, notifier.Notifications, 1)
+
+		// the repeat is sent at the first evaluation after the silence ends
+		evaluate(st, clk, rule, eval.Alerting)
+		require.Len(t, notifier.Notifications, 2)
+	})
+}
+
+func TestLimitResults(t *testing.T) {
+	ctx := context.Background()
+	const limit = 5
+
+	results := func(evaluatedAt time.Time, count int) eval.Results {
+		r := make(eval.Results, 0, count)
+		for i := 0; i < count; i++ {
+			r = append(r, eval.ResultGen(
+				eval.WithEvaluatedAt(evaluatedAt),
+				eval.WithState(eval.Alerting),
+				eval.WithLabels(data.Labels{"series": fmt.Sprintf("%02d", i)}),
+			)())
+		}
+		return r
+	}
+
+	t.Run("should keep existing instances and store instances up to the limit", func(t *testing.T) {
+		clk := clock.NewMock()
+		store := &state.FakeInstanceStore{}
+		st := state.NewManager(state.ManagerCfg{
+			Metrics:               testMetrics.GetStateMetrics(),
+			InstanceStore:         store,
+			Images:                &state.NoopImageService{},
+			Clock:                 clk,
+			Historian:             &state.FakeHistorian{},
+			InstancesPerRuleLimit: limit,
+		})
+		rule := models.AlertRuleGen(models.WithFor(0))()
+
+		// the existing instances sort after the new series, and must be kept anyway
+		existing := results(clk.Now(), limit+10)[limit+7:]
+		limited, err := st.LimitResults(ctx, rule, existing)
+		require.NoError(t, err)
+		st.ProcessEvalResults(ctx, clk.Now(), rule, limited, nil)
+		before := st.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, before, 3)
+		startsAt := before[0].StartsAt
+
+		clk.Add(time.Duration(rule.IntervalSeconds) * time.Second)
+		store.RecordedOps = nil
+		limited, err = st.LimitResults(ctx, rule, results(clk.Now(), limit+10))
+		var limitErr *state.TooManySeriesError
+		require.ErrorAs(t, err, &limitErr)
+		require.ErrorIs(t, err, state.ErrTooManySeries)
+		require.EqualValues(t, limit, limitErr.Limit)
+		require.Equal(t, limit+10, limitErr.Series)
+		require.Len(t, limited, limit)
+
+		st.ProcessEvalResults(ctx, clk.Now(), rule, limited, nil)
+		var saved []models.AlertInstance
+		for _, op := range store.RecordedOps {
+			if instance, ok := op.(models.AlertInstance); ok {
+				saved = append(saved, instance)
+			}
+		}
+		require.Len(t, saved, limit)
+
+		after := st.GetStatesForRuleUID(rule.OrgID, rule.UID)
+		require.Len(t, after, limit)
+		series := make([]string, 0, len(after))
+		for _, s := range after {
+			series = append(series, s.Labels["series"])
+			if s.Labels["series"] >= fmt.Sprint(limit+7) {
+				require.Equal(t, eval.Alerting, s.State)
+				require.Equal(t, startsAt, s.StartsAt)
+				require.Len(t, s.Results, 2)
+			}
+		}
+		require.ElementsMatch(t, []string{"00", "01", "12", "13", "14"}, series)
+	})
+
+	t.Run("should bound the limit of the rule by the maximum", func(t *testing.T) {
+		st := state.NewManager(state.ManagerCfg{
+			Metrics:                  testMetrics.GetStateMetrics(),
+			Clock:                    clock.NewMock(),
+			InstancesPerRuleLimit:    limit,
+			MaxInstancesPerRuleLimit: limit + 2,
+		})
+		rule := models.AlertRuleGen()()
+
+		rule.InstanceLimit = limit + 1
+		limited, err := st.LimitResults(ctx, rule, results(time.Now(), limit+10))
+		require.ErrorIs(t, err, state.ErrTooManySeries)
+		require.Len(t, limited, limit+1)
+
+		rule.InstanceLimit = limit + 10
+		limited, err = st.LimitResults(ctx, rule, results(time.Now(), limit+10))
+		require.ErrorIs(t, err, state.ErrTooManySeries)
+		require.Len(t, limited, limit+2)
+	})
+
+	t.Run("should not limit results if there is no limit", func(t *testing.T) {
+		st := state.NewManager(state.ManagerCfg{Metrics: testMetrics.GetStateMetrics(), Clock: clock.NewMock()})
+		limited, err := st.LimitResults(ctx, models.AlertRuleGen()(), results(time.Now(), limit+10))
+		require.NoError(t, err)
+		require.Len(t, limited, limit+10)
+	})
+}
+
+func TestResetAlertInstances(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	store := &state.FakeInstanceStore{}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:          testMetrics.GetStateMetrics(),
+		InstanceStore:    store,
+		Images:           &state.NoopImageService{},
+		Clock:            clk,
+		Historian:        &state.FakeHistorian{},
+		SaveStateHistory: true,
+	})
+
+	rule := models.AlertRuleGen(models.WithFor(0))()
+	interval := time.Duration(rule.IntervalSeconds) * time.Second
+	rule.For = interval
+	results := eval.Results{
+		eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithLabels(data.Labels{"instance": "1"}))(),
+		eval.ResultGen(eval.WithEvaluatedAt(clk.Now()), eval.WithLabels(data.Labels{"instance": "2"}))(),
+	}
+	evaluate := func() map[string]state.StateTransition {
+		clk.Add(interval)
+		for i := range results {
+			results[i].State = eval.Alerting
+			results[i].EvaluatedAt = clk.Now()
+		}
+		byInstance := make(map[string]state.StateTransition)
+		for _, tr := range st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil) {
+			byInstance[tr.Labels["instance"]] = tr
+		}
+		return byInstance
+	}
+
+	evaluate()
+	states := evaluate()
+	require.Equal(t, eval.Alerting, states["1"].State.State)
+	require.Equal(t, eval.Alerting, states["2"].State.State)
+	key, err := states["1"].GetAlertInstanceKey()
+	require.NoError(t, err)
+
+	t.Run("should reset only the instance with the labels hash", func(t *testing.T) {
+		store.History = nil
+		transitions := st.ResetAlertInstances(ctx, rule, &models.ResetAlertInstancesCommand{
+			OrgID:      rule.OrgID,
+			RuleUID:    rule.UID,
+			LabelsHash: key.LabelsHash,
+			ResetBy:    "editor",
+		})
+		require.Len(t, transitions, 1)
+		require.Equal(t, eval.Normal, transitions[0].State.State)
+		require.Equal(t, models.StateReasonManualReset, transitions[0].StateReason)
+		require.Equal(t, eval.Alerting, transitions[0].PreviousState)
+		require.True(t, transitions[0].Resolved)
+
+		require.Len(t, store.History, 1)
+		require.Equal(t, key.LabelsHash, store.History[0].LabelsHash)
+		require.Equal(t, models.InstanceStateNormal, store.History[0].State)
+		require.Equal(t, models.StateReasonManualReset, store.History[0].StateReason)
+		require.Equal(t, "editor", store.History[0].ResetBy)
+
+		require.Nil(t, st.Get(rule.OrgID, rule.UID, transitions[0].CacheID))
+		require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), 1)
+	})
+
+	t.Run("next evaluation should recompute the state from scratch", func(t *testing.T) {
+		states := evaluate()
+		// the reset instance has to be pending for the duration of the rule again
+		require.Equal(t, eval.Pending, states["1"].State.State)
+		require.Equal(t, eval.Normal, states["1"].PreviousState)
+		require.Equal(t, clk.Now(), states["1"].StartsAt)
+		require.Len(t, states["1"].Results, 1)
+		require.Equal(t, eval.Alerting, states["2"].State.State)
+	})
+
+	t.Run("should reset all instances without labels hash", func(t *testing.T) {
+		transitions := st.ResetAlertInstances(ctx, rule, &models.ResetAlertInstancesCommand{OrgID: rule.OrgID, RuleUID: rule.UID})
+		require.Len(t, transitions, 2)
+		require.Empty(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+		require.Empty(t, st.ResetAlertInstances(ctx, rule, &models.ResetAlertInstancesCommand{OrgID: rule.OrgID, RuleUID: rule.UID}))
+	})
+}
+
+func TestProcessEvalResults_PersistsValues(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, 1)
+	rule := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+	clk := clock.NewMock()
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:       testMetrics.GetStateMetrics(),
+		InstanceStore: dbstore,
+		Images:        &state.NoopImageService{},
+		Clock:         clk,
+		Historian:     &state.FakeHistorian{},
+	})
+
+	value := 97.3
+	result := eval.Result{
+		Instance:    data.Labels{"instance": "host-1"},
+		State:       eval.Alerting,
+		EvaluatedAt: clk.Now(),
+		Values: map[string]eval.NumberValueCapture{
+			"A": {Var: "A", Value: &value},
+			"B": {Var: "B", Value: nil},
+		},
+	}
+	st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil)
+
+	instances, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	require.Len(t, instances[0].Values, 2)
+	require.Equal(t, 97.3, instances[0].Values["A"])
+	require.True(t, math.IsNaN(instances[0].Values["B"]))
+
+	// the values are restored with the state
+	restored := state.NewManager(state.ManagerCfg{
+		Metrics:       testMetrics.GetStateMetrics(),
+		InstanceStore: dbstore,
+		Images:        &state.NoopImageService{},
+		Clock:         clk,
+		Historian:     &state.FakeHistorian{},
+	})
+	restored.Warm(ctx, dbstore)
+	states := restored.GetStatesForRuleUID(rule.OrgID, rule.UID)
+	require.Len(t, states, 1)
+	require.Equal(t, 97.3, states[0].Values["A"])
+	require.True(t, math.IsNaN(states[0].Values["B"]))
+}
+
+func TestWarmStateCacheMaxAge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, 1)
+	clk := clock.NewMock()
+	clk.Set(time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC))
+
+	short := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(time.Minute))()
+	long := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(6*time.Hour))()
+	// the rule is evaluated every day at 9:00, and its instance was last evaluated 3 hours ago
+	cron := models.AlertRuleGen(models.WithOrgID(1), models.WithInterval(time.Minute))()
+	cron.Schedule = "0 9 * * *"
+	rules := []*models.AlertRule{short, long, cron}
+	instances := make([]models.AlertInstance, 0, len(rules))
+	for _, rule := range rules {
+		labels := models.InstanceLabels{"instance": "host-1"}
+		_, hash, _ := labels.StringAndHash()
+		instances = append(instances, models.AlertInstance{
+			AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash},
+			Labels:           labels,
+			CurrentState:     models.InstanceStateFiring,
+			LastEvalTime:     clk.Now().Add(-3 * time.Hour),
+		})
+	}
+	require.NoError(t, dbstore.SaveAlertInstances(ctx, instances...))
+
+	// the instance of the short rule is written every 4 hours if its state does not change
+	heartbeat := state.NewManager(state.ManagerCfg{
+		Metrics:              testMetrics.GetStateMetrics(),
+		InstanceStore:        dbstore,
+		Images:               &state.NoopImageService{},
+		Clock:                clk,
+		Historian:            &state.FakeHistorian{},
+		RestoredStateMaxAge:  time.Hour,
+		HeartbeatEvaluations: 240,
+	})
+	heartbeat.Warm(ctx, staticRuleReader(rules))
+	require.Len(t, heartbeat.GetStatesForRuleUID(short.OrgID, short.UID), 1, "the instance is younger than the heartbeat of its rule")
+
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:             testMetrics.GetStateMetrics(),
+		InstanceStore:       dbstore,
+		Images:              &state.NoopImageService{},
+		Clock:               clk,
+		Historian:           &state.FakeHistorian{},
+		RestoredStateMaxAge: time.Hour,
+	})
+	st.Warm(ctx, staticRuleReader(rules))
+
+	require.Empty(t, st.GetStatesForRuleUID(short.OrgID, short.UID), "the instance is older than the max age and 3 intervals of its rule")
+	require.Len(t, st.GetStatesForRuleUID(long.OrgID, long.UID), 1, "the instance is younger than 3 intervals of its rule")
+	require.Len(t, st.GetStatesForRuleUID(cron.OrgID, cron.UID), 1, "the instance is younger than 3 intervals of its cron schedule")
+}
+
+// staticRuleReader returns the same rules for every organization.
+type staticRuleReader []*models.AlertRule
+
+func (r staticRuleReader) ListAlertRules(_ context.Context, _ *models.ListAlertRulesQuery) (models.RulesGroup, error) {
+	return models.RulesGroup(r), nil
+}
+
+// pageRecordingInstanceStore records the number of instances in every page read from the instance store, and calls
+// onPage after every page.
+type pageRecordingInstanceStore struct {
+	state.InstanceStore
+	pages  []int
+	onPage func()
+}
+
+func (s *pageRecordingInstanceStore) ListAlertInstancesPage(ctx context.Context, query *models.ListAlertInstancesPageQuery) ([]*models.AlertInstance, error) {
+	page, err := s.InstanceStore.ListAlertInstancesPage(ctx, query)
+	s.pages = append(s.pages, len(page))
+	if s.onPage != nil {
+		s.onPage()
+	}
+	return page, err
+}
+
+func TestWarmStateCacheInPages(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, 1)
+	clk := clock.NewMock()
+	clk.Set(time.Now())
+
+	const batchSize = 7
+	rules := []*models.AlertRule{
+		tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1),
+		tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1),
+		tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1),
+	}
+	instances := make([]models.AlertInstance, 0)
+	for _, rule := range rules {
+		for i := 0; i < 1000; i++ {
+			labels := models.InstanceLabels{"instance": fmt.Sprintf("host-%d", i)}
+			_, hash, _ := labels.StringAndHash()
+			instances = append(instances, models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash},
+				Labels:           labels,
+				CurrentState:     models.InstanceStateFiring,
+				LastEvalTime:     clk.Now(),
+			})
+		}
+	}
+	require.NoError(t, dbstore.SaveAlertInstances(ctx, instances...))
+
+	newManager := func(store state.InstanceStore) *state.Manager {
+		return state.NewManager(state.ManagerCfg{
+			Metrics:              testMetrics.GetStateMetrics(),
+			InstanceStore:        store,
+			Images:               &state.NoopImageService{},
+			Clock:                clk,
+			Historian:            &state.FakeHistorian{},
+			RestorationBatchSize: batchSize,
+		})
+	}
+
+	t.Run("should restore all instances one page at a time", func(t *testing.T) {
+		store := &pageRecordingInstanceStore{InstanceStore: dbstore}
+		st := newManager(store)
+		st.Warm(ctx, dbstore)
+
+		for _, rule := range rules {
+			require.Len(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID), 1000)
+		}
+		// 3000 instances are 428 pages of 7 instances and a last page of 4 instances
+		require.Len(t, store.pages, 429)
+		for _, page := range store.pages {
+			require.LessOrEqual(t, page, batchSize)
+		}
+		require.Equal(t, 4, store.pages[len(store.pages)-1])
+	})
+
+	t.Run("should stop without restoring state when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		store := &pageRecordingInstanceStore{InstanceStore: dbstore, onPage: cancel}
+		st := newManager(store)
+		st.Warm(ctx, dbstore)
+
+		require.Len(t, store.pages, 1)
+		for _, rule := range rules {
+			require.Empty(t, st.GetStatesForRuleUID(rule.OrgID, rule.UID))
+		}
+	})
+}
+
 func TestDeleteStateByRuleUID(t *testing.T) {
 	interval := time.Minute
 	ctx := context.Background()
@@ -2820,3 +4014,179 @@ func TestResetStateByRuleUID(t *testing.T) {
 		})
 	}
 }
+
+// savedInstances returns the instances that were written to the instance store, and forgets them.
+func savedInstances(store *state.FakeInstanceStore) []models.AlertInstance {
+	var saved []models.AlertInstance
+	for _, op := range store.RecordedOps {
+		if instance, ok := op.(models.AlertInstance); ok {
+			saved = append(saved, instance)
+		}
+	}
+	store.RecordedOps = nil
+	return saved
+}
+
+func TestProcessEvalResults_SavesOnlyChangedInstances(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock()
+	store := &state.FakeInstanceStore{}
+	st := state.NewManager(state.ManagerCfg{
+		Metrics:              testMetrics.GetStateMetrics(),
+		InstanceStore:        store,
+		Images:               &state.NoopImageService{},
+		Clock:                clk,
+		Historian:            &state.FakeHistorian{},
+		HeartbeatEvaluations: 5,
+	})
+	rule := models.AlertRuleGen(models.WithInterval(10*time.Second), func(rule *models.AlertRule) {
+		rule.For = 0
+		rule.Labels = nil
+		rule.Annotations = nil
+	})()
+	evaluate := func(s eval.State, value float64) []models.AlertInstance {
+		clk.Add(10 * time.Second)
+		result := eval.Result{
+			Instance:    data.Labels{"instance": "host-1"},
+			State:       s,
+			EvaluatedAt: clk.Now(),
+			Values:      map[string]eval.NumberValueCapture{"A": {Var: "A", Value: &value}},
+		}
+		st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{result}, nil)
+		return savedInstances(store)
+	}
+
+	t.Run("should save a new instance", func(t *testing.T) {
+		require.Len(t, evaluate(eval.Normal, 1), 1)
+	})
+
+	t.Run("should not save an instance whose state does not change", func(t *testing.T) {
+		require.Empty(t, evaluate(eval.Normal, 1))
+		// a change of the value within the tolerance is not saved either
+		require.Empty(t, evaluate(eval.Normal, 1.001))
+	})
+
+	t.Run("should save an instance whose state changes", func(t *testing.T) {
+		saved := evaluate(eval.Alerting, 1)
+		require.Len(t, saved, 1)
+		require.Equal(t, models.InstanceStateFiring, saved[0].CurrentState)
+	})
+
+	t.Run("should save an instance whose value changes", func(t *testing.T) {
+		saved := evaluate(eval.Alerting, 2)
+		require.Len(t, saved, 1)
+		require.Equal(t, 2.0, saved[0].Values["A"])
+	})
+
+	t.Run("should save an instance whose state does not change every heartbeat", func(t *testing.T) {
+		for cycle := 0; cycle < 3; cycle++ {
+			for i := 0; i < 4; i++ {
+				require.Empty(t, evaluate(eval.Alerting, 2))
+			}
+			saved := evaluate(eval.Alerting, 2)
+			require.Len(t, saved, 1)
+			require.Equal(t, clk.Now(), saved[0].LastEvalTime)
+		}
+	})
+
+	t.Run("should save an instance whose state does not change before it is too old to be restored", func(t *testing.T) {
+		st := state.NewManager(state.ManagerCfg{
+			Metrics:              testMetrics.GetStateMetrics(),
+			InstanceStore:        store,
+			Images:               &state.NoopImageService{},
+			Clock:                clk,
+			Historian:            &state.FakeHistorian{},
+			HeartbeatEvaluations: 5,
+			RestoredStateMaxAge:  time.Minute,
+		})
+		evaluate := func() []models.AlertInstance {
+			clk.Add(10 * time.Second)
+			value := 1.0
+			st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{{
+				Instance:    data.Labels{"instance": "host-1"},
+				State:       eval.Normal,
+				EvaluatedAt: clk.Now(),
+				Values:      map[string]eval.NumberValueCapture{"A": {Var: "A", Value: &value}},
+			}}, nil)
+			return savedInstances(store)
+		}
+		require.Len(t, evaluate(), 1)
+		for cycle := 0; cycle < 3; cycle++ {
+			require.Empty(t, evaluate())
+			require.Empty(t, evaluate())
+			require.Len(t, evaluate(), 1, "the instance is saved every half of the maximum age instead of every heartbeat")
+		}
+	})
+
+	t.Run("should save every instance at every evaluation without heartbeat", func(t *testing.T) {
+		st := state.NewManager(state.ManagerCfg{
+			Metrics:       testMetrics.GetStateMetrics(),
+			InstanceStore: store,
+			Images:        &state.NoopImageService{},
+			Clock:         clk,
+			Historian:     &state.FakeHistorian{},
+		})
+		for i := 0; i < 3; i++ {
+			clk.Add(10 * time.Second)
+			value := 1.0
+			st.ProcessEvalResults(ctx, clk.Now(), rule, eval.Results{{
+				Instance:    data.Labels{"instance": "host-1"},
+				State:       eval.Normal,
+				EvaluatedAt: clk.Now(),
+				Values:      map[string]eval.NumberValueCapture{"A": {Var: "A", Value: &value}},
+			}}, nil)
+			require.Len(t, savedInstances(store), 1)
+		}
+	})
+}
+
+// BenchmarkProcessEvalResults_SavedInstances evaluates a rule with 5000 series whose state does not change, and reports
+// the number of instances written to the instance store by every evaluation.
+func BenchmarkProcessEvalResults_SavedInstances(b *testing.B) {
+	const series = 5000
+	ctx := context.Background()
+	rule := models.AlertRuleGen(models.WithInterval(10*time.Second), func(rule *models.AlertRule) {
+		rule.For = 0
+		rule.Labels = nil
+		rule.Annotations = nil
+	})()
+	run := func(heartbeat int64) func(b *testing.B) {
+		return func(b *testing.B) {
+			clk := clock.NewMock()
+			store := &state.FakeInstanceStore{}
+			st := state.NewManager(state.ManagerCfg{
+				Metrics:              metrics.NewNGAlert(prometheus.NewPedanticRegistry()).GetStateMetrics(),
+				InstanceStore:        store,
+				Images:               &state.NoopImageService{},
+				Clock:                clk,
+				Historian:            &state.FakeHistorian{},
+				HeartbeatEvaluations: heartbeat,
+			})
+			results := make(eval.Results, 0, series)
+			for i := 0; i < series; i++ {
+				results = append(results, eval.Result{Instance: data.Labels{"instance": fmt.Sprintf("host-%d", i)}, State: eval.Normal})
+			}
+			evaluate := func() {
+				clk.Add(10 * time.Second)
+				for i := range results {
+					results[i].EvaluatedAt = clk.Now()
+				}
+				st.ProcessEvalResults(ctx, clk.Now(), rule, results, nil)
+			}
+			// the first evaluation creates the instances
+			evaluate()
+			savedInstances(store)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			saved := 0
+			for n := 0; n < b.N; n++ {
+				evaluate()
+				saved += len(savedInstances(store))
+			}
+			b.ReportMetric(float64(saved)/float64(b.N), "saved/op")
+		}
+	}
+	b.Run("every evaluation", run(0))
+	b.Run("heartbeat every 10 evaluations", run(10))
+}
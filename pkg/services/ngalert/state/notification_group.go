@@ -0,0 +1,244 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/template"
+)
+
+// maxGroupedInstances is the maximum number of instances whose labels are listed in a grouped notification.
+const maxGroupedInstances = 10
+
+// notificationGroupKey identifies the notifications of a rule that are sent together. groupValue is the value of the label
+// that the notifications are grouped by, if any.
+type notificationGroupKey struct {
+	orgID      int64
+	ruleUID    string
+	groupValue string
+}
+
+// notificationGroup is the buffer of the notifications of the instances of a rule that started alerting, by the cache ID
+// of the instances, in the order they started alerting.
+type notificationGroup struct {
+	rule          *ngModels.AlertRule
+	ids           []string
+	notifications map[string]ngModels.AlertingNotification
+	timer         *clock.Timer
+}
+
+// notificationGrouper buffers the notifications of the instances of a rule that start alerting within wait of the first
+// one, and sends them as a single notification. Instances that stop alerting before the group is sent are removed from it.
+type notificationGrouper struct {
+	mtx     sync.Mutex
+	clock   clock.Clock
+	log     log.Logger
+	wait    time.Duration
+	groupBy string
+	groups  map[notificationGroupKey]*notificationGroup
+	send    func(ctx context.Context, logger log.Logger, rule *ngModels.AlertRule, n ngModels.AlertingNotification)
+}
+
+func newNotificationGrouper(clk clock.Clock, logger log.Logger, wait time.Duration, groupBy string,
+	send func(ctx context.Context, logger log.Logger, rule *ngModels.AlertRule, n ngModels.AlertingNotification)) *notificationGrouper {
+	return &notificationGrouper{
+		clock:   clk,
+		log:     logger,
+		wait:    wait,
+		groupBy: groupBy,
+		groups:  map[notificationGroupKey]*notificationGroup{},
+		send:    send,
+	}
+}
+
+func (g *notificationGrouper) key(rule *ngModels.AlertRule, s *State) notificationGroupKey {
+	key := notificationGroupKey{orgID: rule.OrgID, ruleUID: rule.UID}
+	if g.groupBy != "" {
+		key.groupValue = s.Labels[g.groupBy]
+	}
+	return key
+}
+
+// add buffers the notification of the instance. The group of the instance is sent wait after its first notification.
+// An instance is notified at most once per group.
+func (g *notificationGrouper) add(rule *ngModels.AlertRule, s *State, n ngModels.AlertingNotification) {
+	key := g.key(rule, s)
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	group, ok := g.groups[key]
+	if !ok {
+		group = &notificationGroup{notifications: map[string]ngModels.AlertingNotification{}}
+		group.timer = g.clock.AfterFunc(g.wait, func() {
+			// the timer of a group that was flushed or emptied meanwhile is stopped, but can fire concurrently
+			g.flush(key, group)
+		})
+		g.groups[key] = group
+	}
+	// the notification is rendered with the latest version of the rule
+	group.rule = rule
+	if _, ok := group.notifications[s.CacheID]; !ok {
+		group.ids = append(group.ids, s.CacheID)
+	}
+	group.notifications[s.CacheID] = n
+}
+
+// remove removes the notification of the instance from its group, if it has not been sent yet, and returns true if it
+// was removed. A group without notifications is not sent.
+func (g *notificationGrouper) remove(rule *ngModels.AlertRule, s *State) bool {
+	key := g.key(rule, s)
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	group, ok := g.groups[key]
+	if !ok {
+		return false
+	}
+	if _, ok := group.notifications[s.CacheID]; !ok {
+		return false
+	}
+	delete(group.notifications, s.CacheID)
+	for i, id := range group.ids {
+		if id == s.CacheID {
+			group.ids = append(group.ids[:i], group.ids[i+1:]...)
+			break
+		}
+	}
+	if len(group.ids) == 0 {
+		group.timer.Stop()
+		delete(g.groups, key)
+	}
+	return true
+}
+
+// removeRule removes the notifications of all the instances of the rule that have not been sent yet.
+func (g *notificationGrouper) removeRule(key ngModels.AlertRuleKey) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	for k, group := range g.groups {
+		if k.orgID == key.OrgID && k.ruleUID == key.UID {
+			group.timer.Stop()
+			delete(g.groups, k)
+		}
+	}
+}
+
+// flush sends the group if it is still buffered.
+func (g *notificationGrouper) flush(key notificationGroupKey, group *notificationGroup) {
+	g.mtx.Lock()
+	if g.groups[key] != group {
+		g.mtx.Unlock()
+		return
+	}
+	delete(g.groups, key)
+	n := group.notification(g.log)
+	g.mtx.Unlock()
+	g.send(context.Background(), g.log, group.rule, n)
+}
+
+// flushAll sends all the buffered groups without waiting for them.
+func (g *notificationGrouper) flushAll(ctx context.Context) {
+	g.mtx.Lock()
+	groups := make([]*notificationGroup, 0, len(g.groups))
+	notifications := make([]ngModels.AlertingNotification, 0, len(g.groups))
+	for key, group := range g.groups {
+		group.timer.Stop()
+		delete(g.groups, key)
+		groups = append(groups, group)
+		notifications = append(notifications, group.notification(g.log))
+	}
+	g.mtx.Unlock()
+	for i, group := range groups {
+		g.send(ctx, g.log, group.rule, notifications[i])
+	}
+}
+
+// notification returns the notification of the group. A group of a single instance is sent as the notification of the
+// instance. Otherwise, the notification has the labels and annotations that all instances have in common, and lists
+// the labels of the first maxGroupedInstances instances.
+func (group *notificationGroup) notification(logger log.Logger) ngModels.AlertingNotification {
+	first := group.notifications[group.ids[0]]
+	if len(group.ids) == 1 {
+		return first
+	}
+
+	n := ngModels.AlertingNotification{
+		OrgID:       first.OrgID,
+		RuleUID:     group.rule.UID,
+		RuleTitle:   group.rule.Title,
+		Labels:      first.Labels,
+		Annotations: first.Annotations,
+		RunbookURL:  first.RunbookURL,
+		StartsAt:    first.StartsAt,
+		Count:       len(group.ids),
+	}
+	for _, id := range group.ids {
+		instance := group.notifications[id]
+		n.Labels = commonLabels(n.Labels, instance.Labels)
+		n.Annotations = commonLabels(n.Annotations, instance.Annotations)
+		if instance.StartsAt.Before(n.StartsAt) {
+			n.StartsAt = instance.StartsAt
+		}
+		if len(n.Instances) < maxGroupedInstances {
+			n.Instances = append(n.Instances, instance.Labels)
+		}
+	}
+
+	tmplData := template.NotificationData{
+		Name:       group.rule.Title,
+		Labels:     template.Labels(n.Labels),
+		RunbookURL: n.RunbookURL,
+	}
+	n.Title = fmt.Sprintf("%s is alerting for %d instances", group.rule.Title, n.Count)
+	if group.rule.NotificationTitle != "" {
+		n.Title = expandNotification(logger, "title", group.rule.NotificationTitle, tmplData, n.Title)
+	}
+	n.Message = groupedInstancesList(n)
+	if group.rule.NotificationMessage != "" {
+		if message := expandNotification(logger, "message", group.rule.NotificationMessage, tmplData, ""); message != "" {
+			n.Message = message + "\n\n" + n.Message
+		}
+	}
+	return n
+}
+
+// commonLabels returns the labels of a that have the same value in b.
+func commonLabels(a, b map[string]string) map[string]string {
+	result := make(map[string]string, len(a))
+	for k, v := range a {
+		if bv, ok := b[k]; ok && bv == v {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// groupedInstancesList lists the labels of the instances of the grouped notification, one instance per line.
+func groupedInstancesList(n ngModels.AlertingNotification) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d instances are alerting:", n.Count)
+	for _, labels := range n.Instances {
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			if _, ok := n.Labels[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			pairs = append(pairs, k+"="+labels[k])
+		}
+		fmt.Fprintf(&b, "\n- {%s}", strings.Join(pairs, ", "))
+	}
+	if more := n.Count - len(n.Instances); more > 0 {
+		fmt.Fprintf(&b, "\n- and %d more", more)
+	}
+	return b.String()
+}
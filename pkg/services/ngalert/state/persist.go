@@ -2,6 +2,7 @@ package state
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
@@ -11,9 +12,30 @@ import (
 type InstanceStore interface {
 	FetchOrgIds(ctx context.Context) ([]int64, error)
 	ListAlertInstances(ctx context.Context, cmd *models.ListAlertInstancesQuery) ([]*models.AlertInstance, error)
+	// ListAlertInstancesPage returns a page of the instances of an organization, ordered by rule UID and labels hash.
+	ListAlertInstancesPage(ctx context.Context, query *models.ListAlertInstancesPageQuery) ([]*models.AlertInstance, error)
 	SaveAlertInstances(ctx context.Context, cmd ...models.AlertInstance) error
+	// SaveAlertInstancesWithHistory saves the instances and appends the transitions to the state history atomically.
+	SaveAlertInstancesWithHistory(ctx context.Context, instances []models.AlertInstance, history []models.AlertStateHistory) error
 	DeleteAlertInstances(ctx context.Context, keys ...models.AlertInstanceKey) error
+	// DeleteAlertInstancesWithHistory deletes the instances and appends the transitions to the state history atomically.
+	DeleteAlertInstancesWithHistory(ctx context.Context, keys []models.AlertInstanceKey, history []models.AlertStateHistory) error
 	DeleteAlertInstancesByRule(ctx context.Context, key models.AlertRuleKey) error
+	// DeleteOrphanedAlertInstances deletes at most limit instances of alert rules that do not exist anymore.
+	DeleteOrphanedAlertInstances(ctx context.Context, limit int) (int64, error)
+	// DeleteAlertStateHistory deletes at most limit state transitions that happened before the given time.
+	DeleteAlertStateHistory(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// MaintenanceWindowReader reads the maintenance windows of organizations.
+type MaintenanceWindowReader interface {
+	GetMaintenanceWindows(ctx context.Context, orgID int64) ([]*models.MaintenanceWindow, error)
+}
+
+// SilenceStore reads the silences of organizations and deletes the expired ones.
+type SilenceStore interface {
+	GetSilences(ctx context.Context, orgID int64) ([]*models.Silence, error)
+	DeleteExpiredSilences(ctx context.Context, before time.Time) (int64, error)
 }
 
 // RuleReader represents the ability to fetch alert rules.
@@ -29,6 +51,18 @@ type Historian interface {
 	Record(ctx context.Context, rule history_model.RuleMeta, states []StateTransition) <-chan error
 }
 
+// StatePublisher publishes the state transitions of alert instances to the clients that watch them, e.g. over Grafana
+// Live. Publishing is best-effort, so it does not return errors.
+type StatePublisher interface {
+	PublishTransitions(ctx context.Context, rule *models.AlertRule, transitions []StateTransition)
+}
+
+// ContactPointNotifier sends notifications directly to contact points, without routing them through the notification
+// policies.
+type ContactPointNotifier interface {
+	NotifyContactPoint(ctx context.Context, orgID int64, contactPointUID string, n models.AlertingNotification) error
+}
+
 // ImageCapturer captures images.
 //
 //go:generate mockgen -destination=image_mock.go -package=state github.com/grafana/grafana/pkg/services/ngalert/state ImageCapturer
@@ -42,6 +42,11 @@ type State struct {
 	// All subsequent states will be false until the next transition from Firing to Normal.
 	Resolved bool
 
+	// ResolvedAt is the time of the latest transition from Firing to Normal. It is kept while the state is Normal, and
+	// is zero if the state is not Normal or the state has not been Firing before. A Pending state that returns to Normal
+	// is not resolved.
+	ResolvedAt time.Time
+
 	// Image contains an optional image for the state. It tends to be included in notifications
 	// as a visualization to show why the alert fired.
 	Image *models.Image
@@ -50,6 +55,10 @@ type State struct {
 	// then the template is first evaluated to derive the final annotation.
 	Annotations map[string]string
 
+	// ResultLabels identifies the series of the evaluation results that the state belongs to. It is the string representation
+	// of the labels of the series, before they are merged with the labels of the rule.
+	ResultLabels string
+
 	// Labels contain the labels from the query and any custom labels from the alert rule.
 	// If a label is templated then the template is first evaluated to derive the final label.
 	Labels data.Labels
@@ -58,6 +67,20 @@ type State struct {
 	// conditions.
 	Values map[string]float64
 
+	// NormalStreak is the number of consecutive normal evaluations since the state has been Alerting. It is used to keep
+	// the state Alerting until it reaches the number of normal evaluations required to resolve an alert.
+	NormalStreak int64
+
+	// LastNotifiedAt is the time of the evaluation at which the contact points of the rule were last notified that the
+	// state is Alerting. It is used to repeat the notification while the state stays Alerting, and is zero if the state is
+	// not Alerting or the contact points have not been notified yet.
+	LastNotifiedAt time.Time
+
+	// NotifiedStartsAt is the time at which the state started alerting, as sent to the contact points of the rule. It is
+	// kept until the contact points are notified that the state is resolved, because StartsAt is reset when the state
+	// changes.
+	NotifiedStartsAt time.Time
+
 	StartsAt             time.Time
 	EndsAt               time.Time
 	LastSentAt           time.Time
@@ -132,6 +155,7 @@ func (a *State) Resolve(reason string, endsAt time.Time) {
 	a.State = eval.Normal
 	a.StateReason = reason
 	a.Resolved = true
+	a.ResolvedAt = endsAt
 	a.EndsAt = endsAt
 }
 
@@ -150,6 +174,12 @@ type StateTransition struct {
 	*State
 	PreviousState       eval.State
 	PreviousStateReason string
+	// MaintenanceWindowID is the ID of the maintenance window that suppresses the notification of the transition. Zero means
+	// that the transition is not suppressed.
+	MaintenanceWindowID int64
+	// SilenceID is the ID of the silence that suppresses the notification of the transition. Zero means that the transition
+	// is not silenced.
+	SilenceID int64
 }
 
 func (c StateTransition) Formatted() string {
@@ -164,6 +194,48 @@ func (c StateTransition) Changed() bool {
 	return c.PreviousState != c.State.State || c.PreviousStateReason != c.State.StateReason
 }
 
+// TransitionKind tells consumers of state transitions whether an alert started or stopped firing.
+type TransitionKind int
+
+const (
+	// TransitionNone is a transition in which neither the state nor its reason changed.
+	TransitionNone TransitionKind = iota
+	// TransitionChanged is a transition in which the state or its reason changed, but the alert neither started nor
+	// stopped firing, for example from Normal to Pending, or from Pending back to Normal.
+	TransitionChanged
+	// TransitionFired is a transition to Alerting from any other state.
+	TransitionFired
+	// TransitionResolved is a transition from Alerting to Normal.
+	TransitionResolved
+)
+
+func (k TransitionKind) String() string {
+	switch k {
+	case TransitionChanged:
+		return "changed"
+	case TransitionFired:
+		return "fired"
+	case TransitionResolved:
+		return "resolved"
+	default:
+		return "none"
+	}
+}
+
+// Kind returns the kind of the transition.
+func (c StateTransition) Kind() TransitionKind {
+	switch {
+	case c.PreviousState != eval.Alerting && c.State.State == eval.Alerting:
+		return TransitionFired
+	case c.PreviousState == eval.Alerting && c.State.State == eval.Normal:
+		return TransitionResolved
+	case c.Changed():
+		return TransitionChanged
+	default:
+		return TransitionNone
+	}
+}
+
 type Evaluation struct {
 	EvaluationTime  time.Time
 	EvaluationState eval.State
@@ -260,13 +332,9 @@ func resultError(state *State, rule *models.AlertRule, result eval.Result, logge
 }
 
 func resultNoData(state *State, rule *models.AlertRule, result eval.Result, _ log.Logger) {
+	previousState := state.State
 	state.Error = result.Error
 
-	if state.StartsAt.IsZero() {
-		state.StartsAt = result.EvaluatedAt
-	}
-	state.EndsAt = nextEndsTime(rule.IntervalSeconds, result.EvaluatedAt)
-
 	switch rule.NoDataState {
 	case models.Alerting:
 		state.State = eval.Alerting
@@ -275,6 +343,90 @@ func resultNoData(state *State, rule *models.AlertRule, result eval.Result, _ lo
 	case models.OK:
 		state.State = eval.Normal
 	}
+
+	// the start time changes only if the state does
+	if state.StartsAt.IsZero() || state.State != previousState {
+		state.StartsAt = result.EvaluatedAt
+	}
+	state.EndsAt = nextEndsTime(rule.IntervalSeconds, result.EvaluatedAt)
+}
+
+// keepAlerting keeps the state Alerting with the reason Resolving if the result is normal but it is not yet the
+// evalsToResolve-th consecutive normal result, and returns the transition. It returns false if the result must be applied
+// as usual, which is always the case if evalsToResolve is less than 2.
+func keepAlerting(state *State, rule *models.AlertRule, result eval.Result, evalsToResolve int64, logger log.Logger) (StateTransition, bool) {
+	if result.State != eval.Normal || state.State != eval.Alerting {
+		state.NormalStreak = 0
+		return StateTransition{}, false
+	}
+	state.NormalStreak++
+	if state.NormalStreak >= evalsToResolve {
+		state.NormalStreak = 0
+		return StateTransition{}, false
+	}
+
+	logger.Debug("Keeping state until enough consecutive evaluations are normal", "state", state.State, "normal_evaluations", state.NormalStreak, "required", evalsToResolve)
+	oldReason := state.StateReason
+	state.Maintain(rule.IntervalSeconds, result.EvaluatedAt)
+	state.StateReason = models.StateReasonResolving
+	state.Error = nil
+	state.Resolved = false
+	return StateTransition{
+		State:               state,
+		PreviousState:       eval.Alerting,
+		PreviousStateReason: oldReason,
+	}, true
+}
+
+// applyEvalResult moves the state of an alert instance to the next state according to the result of an evaluation and
+// returns the transition. The next state depends only on the current state, the result, and the For duration, NoData and
+// execution error policies of the rule. The start time of the state changes only if the state does.
+// It updates the given state in place, and the returned transition points to it. Callers that need the previous state
+// must copy it first. It does not persist the state, so that callers can decide what to do with the transition.
+func applyEvalResult(state *State, rule *models.AlertRule, result eval.Result, logger log.Logger) StateTransition {
+	oldState := state.State
+	oldReason := state.StateReason
+
+	switch result.State {
+	case eval.Normal:
+		logger.Debug("Setting next state", "handler", "resultNormal")
+		resultNormal(state, rule, result, logger)
+	case eval.Alerting:
+		logger.Debug("Setting next state", "handler", "resultAlerting")
+		resultAlerting(state, rule, result, logger)
+	case eval.Error:
+		logger.Debug("Setting next state", "handler", "resultError")
+		resultError(state, rule, result, logger)
+	case eval.NoData:
+		logger.Debug("Setting next state", "handler", "resultNoData")
+		resultNoData(state, rule, result, logger)
+	case eval.Pending: // we do not emit results with this state
+		logger.Debug("Ignoring set next state as result is pending")
+	}
+
+	// Set reason iff: result and state are different, reason is not Alerting or Normal
+	state.StateReason = ""
+
+	if state.State != result.State &&
+		result.State != eval.Normal &&
+		result.State != eval.Alerting {
+		state.StateReason = result.State.String()
+	}
+
+	// Set Resolved property so the scheduler knows to send a postable alert
+	// to Alertmanager.
+	state.Resolved = oldState == eval.Alerting && state.State == eval.Normal
+	if state.Resolved {
+		state.ResolvedAt = result.EvaluatedAt
+	} else if state.State != eval.Normal {
+		state.ResolvedAt = time.Time{}
+	}
+
+	return StateTransition{
+		State:               state,
+		PreviousState:       oldState,
+		PreviousStateReason: oldReason,
+	}
 }
 
 func (a *State) NeedsSending(resendDelay time.Duration) bool {
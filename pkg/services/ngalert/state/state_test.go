@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"testing"
@@ -13,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/screenshot"
@@ -343,6 +345,272 @@ func TestEnd(t *testing.T) {
 	}
 }
 
+func TestApplyEvalResult(t *testing.T) {
+	mock := clock.NewMock()
+	evaluatedAt := mock.Now().Add(time.Minute)
+	// the current state started 30 seconds before the evaluation, less than the For duration of the rule
+	startsAt := evaluatedAt.Add(-30 * time.Second)
+	rule := func(mutators ...func(r *ngmodels.AlertRule)) *ngmodels.AlertRule {
+		r := &ngmodels.AlertRule{
+			IntervalSeconds: 10,
+			For:             time.Minute,
+			NoDataState:     ngmodels.NoData,
+			ExecErrState:    ngmodels.ErrorErrState,
+		}
+		for _, m := range mutators {
+			m(r)
+		}
+		return r
+	}
+	withFor := func(d time.Duration) func(r *ngmodels.AlertRule) {
+		return func(r *ngmodels.AlertRule) { r.For = d }
+	}
+	withNoDataState := func(s ngmodels.NoDataState) func(r *ngmodels.AlertRule) {
+		return func(r *ngmodels.AlertRule) { r.NoDataState = s }
+	}
+	withExecErrState := func(s ngmodels.ExecutionErrorState) func(r *ngmodels.AlertRule) {
+		return func(r *ngmodels.AlertRule) { r.ExecErrState = s }
+	}
+
+	type expected struct {
+		state    eval.State
+		reason   string
+		changed  bool
+		resolved bool
+	}
+	testCases := []struct {
+		current  eval.State
+		result   eval.State
+		rule     *ngmodels.AlertRule
+		expected expected
+	}{
+		{current: eval.Normal, result: eval.Normal, rule: rule(), expected: expected{state: eval.Normal}},
+		{current: eval.Normal, result: eval.Alerting, rule: rule(), expected: expected{state: eval.Pending, changed: true}},
+		{current: eval.Normal, result: eval.NoData, rule: rule(), expected: expected{state: eval.NoData, changed: true}},
+		{current: eval.Normal, result: eval.Error, rule: rule(), expected: expected{state: eval.Error, changed: true}},
+
+		{current: eval.Pending, result: eval.Normal, rule: rule(), expected: expected{state: eval.Normal, changed: true}},
+		{current: eval.Pending, result: eval.Alerting, rule: rule(), expected: expected{state: eval.Pending}},
+		{current: eval.Pending, result: eval.NoData, rule: rule(), expected: expected{state: eval.NoData, changed: true}},
+		{current: eval.Pending, result: eval.Error, rule: rule(), expected: expected{state: eval.Error, changed: true}},
+
+		{current: eval.Alerting, result: eval.Normal, rule: rule(), expected: expected{state: eval.Normal, changed: true, resolved: true}},
+		{current: eval.Alerting, result: eval.Alerting, rule: rule(), expected: expected{state: eval.Alerting}},
+		{current: eval.Alerting, result: eval.NoData, rule: rule(), expected: expected{state: eval.NoData, changed: true}},
+		{current: eval.Alerting, result: eval.Error, rule: rule(), expected: expected{state: eval.Error, changed: true}},
+
+		{current: eval.NoData, result: eval.Normal, rule: rule(), expected: expected{state: eval.Normal, changed: true}},
+		{current: eval.NoData, result: eval.Alerting, rule: rule(), expected: expected{state: eval.Pending, changed: true}},
+		{current: eval.NoData, result: eval.NoData, rule: rule(), expected: expected{state: eval.NoData}},
+		{current: eval.NoData, result: eval.Error, rule: rule(), expected: expected{state: eval.Error, changed: true}},
+
+		{current: eval.Error, result: eval.Normal, rule: rule(), expected: expected{state: eval.Normal, changed: true}},
+		{current: eval.Error, result: eval.Alerting, rule: rule(), expected: expected{state: eval.Pending, changed: true}},
+		{current: eval.Error, result: eval.NoData, rule: rule(), expected: expected{state: eval.NoData, changed: true}},
+		{current: eval.Error, result: eval.Error, rule: rule(), expected: expected{state: eval.Error}},
+
+		// For duration
+		{current: eval.Normal, result: eval.Alerting, rule: rule(withFor(0)), expected: expected{state: eval.Alerting, changed: true}},
+		{current: eval.Pending, result: eval.Alerting, rule: rule(withFor(30 * time.Second)), expected: expected{state: eval.Alerting, changed: true}},
+
+		// NoData policy
+		{current: eval.Normal, result: eval.NoData, rule: rule(withNoDataState(ngmodels.Alerting)), expected: expected{state: eval.Alerting, reason: "NoData", changed: true}},
+		{current: eval.Alerting, result: eval.NoData, rule: rule(withNoDataState(ngmodels.Alerting)), expected: expected{state: eval.Alerting, reason: "NoData"}},
+		{current: eval.Alerting, result: eval.NoData, rule: rule(withNoDataState(ngmodels.OK)), expected: expected{state: eval.Normal, reason: "NoData", changed: true, resolved: true}},
+		{current: eval.Normal, result: eval.NoData, rule: rule(withNoDataState(ngmodels.OK)), expected: expected{state: eval.Normal, reason: "NoData"}},
+
+		// execution error policy
+		{current: eval.Normal, result: eval.Error, rule: rule(withExecErrState(ngmodels.AlertingErrState)), expected: expected{state: eval.Pending, reason: "Error", changed: true}},
+		{current: eval.Alerting, result: eval.Error, rule: rule(withExecErrState(ngmodels.AlertingErrState)), expected: expected{state: eval.Alerting, reason: "Error"}},
+		{current: eval.Alerting, result: eval.Error, rule: rule(withExecErrState(ngmodels.OkErrState)), expected: expected{state: eval.Normal, reason: "Error", changed: true, resolved: true}},
+		{current: eval.Normal, result: eval.Error, rule: rule(withExecErrState(ngmodels.OkErrState)), expected: expected{state: eval.Normal, reason: "Error"}},
+	}
+
+	for _, tc := range testCases {
+		name := fmt.Sprintf("%s + %s result with For %s, NoData %s, Error %s", tc.current, tc.result, tc.rule.For, tc.rule.NoDataState, tc.rule.ExecErrState)
+		t.Run(name, func(t *testing.T) {
+			current := &State{
+				State:       tc.current,
+				StartsAt:    startsAt,
+				EndsAt:      evaluatedAt,
+				Labels:      map[string]string{},
+				Annotations: map[string]string{},
+			}
+			result := eval.Result{State: tc.result, EvaluatedAt: evaluatedAt}
+			if tc.result == eval.Error {
+				result.Error = errors.New("failed to query data")
+			}
+
+			transition := applyEvalResult(current, tc.rule, result, log.NewNopLogger())
+
+			require.Equal(t, tc.current, transition.PreviousState)
+			require.Equal(t, tc.expected.state, transition.State.State)
+			require.Equal(t, tc.expected.reason, transition.StateReason)
+			require.Equal(t, tc.expected.resolved, transition.Resolved)
+			if tc.expected.changed {
+				require.Equal(t, evaluatedAt, transition.StartsAt, "the start time should change with the state")
+			} else {
+				require.Equal(t, startsAt, transition.StartsAt, "the start time should not change if the state does not")
+			}
+			require.False(t, transition.EndsAt.Before(evaluatedAt))
+		})
+	}
+}
+
+func TestApplyEvalResult_Resolved(t *testing.T) {
+	rule := &ngmodels.AlertRule{IntervalSeconds: 10, For: 30 * time.Second, NoDataState: ngmodels.NoData, ExecErrState: ngmodels.ErrorErrState}
+	start := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	apply := func(s *State, r eval.State, after time.Duration) StateTransition {
+		return applyEvalResult(s, rule, eval.Result{State: r, EvaluatedAt: start.Add(after)}, log.NewNopLogger())
+	}
+
+	t.Run("Pending to Normal is not resolved", func(t *testing.T) {
+		s := &State{State: eval.Normal}
+		require.Equal(t, TransitionChanged, apply(s, eval.Alerting, 0).Kind())
+		require.Equal(t, eval.Pending, s.State)
+
+		tr := apply(s, eval.Normal, 10*time.Second)
+		require.Equal(t, eval.Normal, s.State)
+		require.Equal(t, TransitionChanged, tr.Kind())
+		require.False(t, s.Resolved)
+		require.True(t, s.ResolvedAt.IsZero())
+	})
+
+	t.Run("Alerting to Normal is resolved", func(t *testing.T) {
+		s := &State{State: eval.Pending, StartsAt: start}
+		require.Equal(t, TransitionFired, apply(s, eval.Alerting, 30*time.Second).Kind())
+
+		tr := apply(s, eval.Normal, 40*time.Second)
+		require.Equal(t, TransitionResolved, tr.Kind())
+		require.True(t, s.Resolved)
+		require.Equal(t, start.Add(40*time.Second), s.ResolvedAt)
+
+		// the resolution time is kept while the state is Normal, but the transition is not resolved again
+		tr = apply(s, eval.Normal, 50*time.Second)
+		require.Equal(t, TransitionNone, tr.Kind())
+		require.False(t, s.Resolved)
+		require.Equal(t, start.Add(40*time.Second), s.ResolvedAt)
+
+		// and it is cleared when the state changes
+		apply(s, eval.Alerting, time.Minute)
+		require.Equal(t, eval.Pending, s.State)
+		require.True(t, s.ResolvedAt.IsZero())
+	})
+}
+
+func TestApplyEvalResult_ForDuration(t *testing.T) {
+	type step struct {
+		after    time.Duration
+		result   eval.State
+		expected eval.State
+	}
+	testCases := []struct {
+		name     string
+		interval time.Duration
+		forDur   time.Duration
+		steps    []step
+	}{
+		{
+			name:     "fires immediately if For is zero",
+			interval: 10 * time.Second,
+			steps: []step{
+				{after: 0, result: eval.Alerting, expected: eval.Alerting},
+			},
+		},
+		{
+			name:     "fires exactly when the condition has held for the For duration",
+			interval: 10 * time.Second,
+			forDur:   30 * time.Second,
+			steps: []step{
+				{after: 0, result: eval.Alerting, expected: eval.Pending},
+				{after: 10 * time.Second, result: eval.Alerting, expected: eval.Pending},
+				{after: 20 * time.Second, result: eval.Alerting, expected: eval.Pending},
+				{after: 30 * time.Second, result: eval.Alerting, expected: eval.Alerting},
+			},
+		},
+		{
+			name:     "restarts the For duration if the condition clears in the middle",
+			interval: 10 * time.Second,
+			forDur:   30 * time.Second,
+			steps: []step{
+				{after: 0, result: eval.Alerting, expected: eval.Pending},
+				{after: 10 * time.Second, result: eval.Alerting, expected: eval.Pending},
+				{after: 20 * time.Second, result: eval.Normal, expected: eval.Normal},
+				{after: 30 * time.Second, result: eval.Alerting, expected: eval.Pending},
+				{after: 50 * time.Second, result: eval.Alerting, expected: eval.Pending},
+				{after: 60 * time.Second, result: eval.Alerting, expected: eval.Alerting},
+			},
+		},
+		{
+			name:     "fires at the next evaluation if For is shorter than the interval",
+			interval: time.Minute,
+			forDur:   10 * time.Second,
+			steps: []step{
+				{after: 0, result: eval.Alerting, expected: eval.Pending},
+				{after: time.Minute, result: eval.Alerting, expected: eval.Alerting},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mock := clock.NewMock()
+			start := mock.Now()
+			rule := &ngmodels.AlertRule{
+				IntervalSeconds: int64(tc.interval.Seconds()),
+				For:             tc.forDur,
+				NoDataState:     ngmodels.NoData,
+				ExecErrState:    ngmodels.ErrorErrState,
+			}
+			s := &State{State: eval.Normal, Labels: map[string]string{}, Annotations: map[string]string{}}
+			for _, step := range tc.steps {
+				mock.Set(start.Add(step.after))
+				applyEvalResult(s, rule, eval.Result{State: step.result, EvaluatedAt: mock.Now()}, log.NewNopLogger())
+				require.Equalf(t, step.expected, s.State, "unexpected state %s after %s", s.State, step.after)
+			}
+		})
+	}
+}
+
+func TestKeepAlerting(t *testing.T) {
+	rule := &ngmodels.AlertRule{IntervalSeconds: 10, NoDataState: ngmodels.NoData, ExecErrState: ngmodels.ErrorErrState}
+	evaluate := func(s *State, evalsToResolve int64, results ...eval.State) []eval.State {
+		states := make([]eval.State, 0, len(results))
+		for _, r := range results {
+			result := eval.Result{State: r, EvaluatedAt: time.Now()}
+			if _, ok := keepAlerting(s, rule, result, evalsToResolve, log.NewNopLogger()); !ok {
+				applyEvalResult(s, rule, result, log.NewNopLogger())
+			}
+			states = append(states, s.State)
+		}
+		return states
+	}
+
+	t.Run("should resolve immediately by default", func(t *testing.T) {
+		s := &State{State: eval.Normal}
+		states := evaluate(s, 1, eval.Alerting, eval.Normal, eval.Alerting, eval.Normal)
+		require.Equal(t, []eval.State{eval.Alerting, eval.Normal, eval.Alerting, eval.Normal}, states)
+	})
+
+	t.Run("should keep alerting until the calm streak is reached", func(t *testing.T) {
+		s := &State{State: eval.Normal}
+		states := evaluate(s, 3, eval.Alerting, eval.Normal, eval.Alerting, eval.Normal, eval.Alerting, eval.Normal, eval.Normal)
+		require.Equal(t, []eval.State{eval.Alerting, eval.Alerting, eval.Alerting, eval.Alerting, eval.Alerting, eval.Alerting, eval.Alerting}, states)
+		require.Equal(t, ngmodels.StateReasonResolving, s.StateReason)
+		require.EqualValues(t, 2, s.NormalStreak)
+
+		require.Equal(t, []eval.State{eval.Normal}, evaluate(s, 3, eval.Normal))
+		require.True(t, s.Resolved)
+		require.Zero(t, s.NormalStreak)
+	})
+
+	t.Run("should not delay other transitions", func(t *testing.T) {
+		s := &State{State: eval.Normal}
+		states := evaluate(s, 3, eval.Normal, eval.NoData, eval.Normal, eval.Alerting, eval.NoData)
+		require.Equal(t, []eval.State{eval.Normal, eval.NoData, eval.Normal, eval.Alerting, eval.NoData}, states)
+	})
+}
+
 func TestNeedsSending(t *testing.T) {
 	evaluationTime, _ := time.Parse("2006-01-02", "2021-03-25")
 	testCases := []struct {
@@ -543,7 +811,8 @@ func TestGetLastEvaluationValuesForCondition(t *testing.T) {
 
 func TestResolve(t *testing.T) {
 	s := State{State: eval.Alerting, EndsAt: time.Now().Add(time.Minute)}
-	expected := State{State: eval.Normal, StateReason: "This is a reason", EndsAt: time.Now(), Resolved: true}
+	now := time.Now()
+	expected := State{State: eval.Normal, StateReason: "This is a reason", EndsAt: now, Resolved: true, ResolvedAt: now}
 	s.Resolve("This is a reason", expected.EndsAt)
 	assert.Equal(t, expected, s)
 }
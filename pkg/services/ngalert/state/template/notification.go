@@ -0,0 +1,131 @@
+package template
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// notificationFuncs are the only functions, besides the builtin functions of text/template, that notification templates
+// can use. Unlike the templates of annotations and labels, notification templates cannot query data sources or build
+// links.
+var notificationFuncs = template.FuncMap{
+	"humanize":           humanizeFunc,
+	"humanizePercentage": humanizePercentageFunc,
+	"toLower":            strings.ToLower,
+	"toUpper":            strings.ToUpper,
+	"trimSpace":          strings.TrimSpace,
+}
+
+// NotificationData is the data of the notification templates of an alert rule.
+type NotificationData struct {
+	// Name is the title of the rule.
+	Name string
+	// Labels are the labels of the alert instance.
+	Labels Labels
+	// Values are the values of the expressions of the evaluation that fired the instance, by RefID.
+	Values map[string]Value
+	// Value is the string representation of Values, as in the templates of annotations.
+	Value      string
+	RunbookURL string
+}
+
+// NewNotificationValues returns the values of the expressions, by RefID, as they can be used in notification templates.
+func NewNotificationValues(values map[string]float64) map[string]Value {
+	result := make(map[string]Value, len(values))
+	for refID, v := range values {
+		result[refID] = Value{Value: v}
+	}
+	return result
+}
+
+// ParseNotificationTemplate checks that the notification template can be parsed.
+func ParseNotificationTemplate(tmpl string) error {
+	_, err := newNotificationTemplate(tmpl)
+	return err
+}
+
+// ExpandNotification renders the notification template with the data. Like in the templates of annotations, the labels,
+// values and value are available as $labels, $values and $value, and missing data is rendered as [no value]. The
+// rendered data is never parsed as a template.
+func ExpandNotification(tmpl string, data NotificationData) (string, error) {
+	t, err := newNotificationTemplate(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", ExpandError{Tmpl: tmpl, Err: err}
+	}
+	return strings.ReplaceAll(b.String(), "<no value>", "[no value]"), nil
+}
+
+func newNotificationTemplate(tmpl string) (*template.Template, error) {
+	// add variables for the data to the beginning of the template
+	tmpl = "{{- $labels := .Labels -}}{{- $values := .Values -}}{{- $value := .Value -}}" +
+		"{{- $name := .Name -}}{{- $runbookURL := .RunbookURL -}}" + tmpl
+	t, err := template.New("__notification").Option("missingkey=invalid").Funcs(notificationFuncs).Parse(tmpl)
+	if err != nil {
+		return nil, ExpandError{Tmpl: tmpl, Err: err}
+	}
+	return t, nil
+}
+
+// humanizeFunc formats the number with at most 4 significant digits and a metric prefix, e.g. 1234567 as 1.235M.
+func humanizeFunc(v interface{}) (string, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+	if f == 0 || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Sprintf("%.4g", f), nil
+	}
+	if math.Abs(f) >= 1 {
+		prefix := ""
+		for _, p := range []string{"k", "M", "G", "T", "P", "E", "Z", "Y"} {
+			if math.Abs(f) < 1000 {
+				break
+			}
+			prefix = p
+			f /= 1000
+		}
+		return fmt.Sprintf("%.4g%s", f, prefix), nil
+	}
+	prefix := ""
+	for _, p := range []string{"m", "u", "n", "p", "f", "a", "z", "y"} {
+		if math.Abs(f) >= 1 {
+			break
+		}
+		prefix = p
+		f *= 1000
+	}
+	return fmt.Sprintf("%.4g%s", f, prefix), nil
+}
+
+// humanizePercentageFunc formats the ratio as a percentage with at most 4 significant digits, e.g. 0.1234 as 12.34%.
+func humanizePercentageFunc(v interface{}) (string, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%.4g%%", f*100), nil
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case Value:
+		return n.Value, nil
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(n, 64)
+	default:
+		return 0, fmt.Errorf("cannot convert %T to a number", v)
+	}
+}
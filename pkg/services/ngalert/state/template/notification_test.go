@@ -0,0 +1,115 @@
+package template
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandNotification(t *testing.T) {
+	data := NotificationData{
+		Name:       "High latency",
+		Labels:     Labels{"instance": "server-1", "team": "sre"},
+		Values:     NewNotificationValues(map[string]float64{"B": 95.12345, "C": 1, "D": 1234567, "E": 0.0321, "F": math.NaN()}),
+		Value:      "[ var='B' labels={instance=server-1} value=95.12345 ]",
+		RunbookURL: "https://example.com/runbook",
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		data     NotificationData
+		expected string
+	}{{
+		name:     "labels, name and runbook URL",
+		tmpl:     "{{ $name }} on {{ $labels.instance }}, see {{ $runbookURL }}",
+		expected: "High latency on server-1, see https://example.com/runbook",
+	}, {
+		name:     "fields of the data",
+		tmpl:     "{{ .Name }} on {{ .Labels.instance }}",
+		expected: "High latency on server-1",
+	}, {
+		name:     "missing label",
+		tmpl:     "High latency on {{ $labels.host }}",
+		expected: "High latency on [no value]",
+	}, {
+		name:     "missing value",
+		tmpl:     "Latency is {{ $values.A }}",
+		expected: "Latency is [no value]",
+	}, {
+		name:     "no labels",
+		tmpl:     "High latency on {{ $labels.instance }}",
+		data:     NotificationData{Name: "High latency"},
+		expected: "High latency on [no value]",
+	}, {
+		name:     "value by RefID",
+		tmpl:     "Latency is {{ $values.B }}ms",
+		expected: "Latency is 95.12345ms",
+	}, {
+		name:     "value of the evaluation",
+		tmpl:     "{{ $value }}",
+		expected: "[ var='B' labels={instance=server-1} value=95.12345 ]",
+	}, {
+		name:     "numeric formatting with printf",
+		tmpl:     `Latency is {{ printf "%.2f" $values.B.Value }}ms`,
+		expected: "Latency is 95.12ms",
+	}, {
+		name:     "integer value",
+		tmpl:     "{{ $values.C }}",
+		expected: "1",
+	}, {
+		name:     "humanize",
+		tmpl:     "{{ humanize $values.D }} {{ humanize $values.E }} {{ humanize $values.B.Value }} {{ humanize 0 }}",
+		expected: "1.235M 32.1m 95.12 0",
+	}, {
+		name:     "humanize NaN",
+		tmpl:     "{{ humanize $values.F }}",
+		expected: "NaN",
+	}, {
+		name:     "humanizePercentage",
+		tmpl:     "{{ humanizePercentage $values.E }}",
+		expected: "3.21%",
+	}, {
+		name:     "string functions",
+		tmpl:     `{{ toUpper $labels.team }} {{ toLower "SRE" }} {{ trimSpace "  sre  " }}`,
+		expected: "SRE sre sre",
+	}, {
+		name: "template syntax in label values is not parsed",
+		tmpl: "High latency on {{ $labels.instance }}",
+		data: NotificationData{
+			Labels: Labels{"instance": `{{ .Name }}{{ template "x" }}`},
+		},
+		expected: `High latency on {{ .Name }}{{ template "x" }}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := data
+			if test.data.Name != "" || test.data.Labels != nil {
+				d = test.data
+			}
+			result, err := ExpandNotification(test.tmpl, d)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, result)
+		})
+	}
+
+	t.Run("fails if the template cannot be parsed", func(t *testing.T) {
+		_, err := ExpandNotification("{{ $labels.instance", data)
+		require.Error(t, err)
+		require.Error(t, ParseNotificationTemplate("{{ $labels.instance"))
+	})
+
+	t.Run("functions of other templates cannot be used", func(t *testing.T) {
+		for _, tmpl := range []string{`{{ query "up" }}`, `{{ graphLink "{}" }}`, `{{ externalURL }}`} {
+			require.Error(t, ParseNotificationTemplate(tmpl), tmpl)
+		}
+	})
+
+	t.Run("fails if the template cannot be executed", func(t *testing.T) {
+		_, err := ExpandNotification(`{{ humanize $labels.instance }}`, data)
+		require.Error(t, err)
+	})
+}
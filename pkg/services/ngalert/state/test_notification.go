@@ -0,0 +1,90 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngModels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/template"
+)
+
+const (
+	// TestNotificationLabel is the label that marks the notifications that are sent to test the contact points of a rule.
+	TestNotificationLabel = "test_notification"
+	// TestNotificationTitlePrefix is the prefix of the title of the notifications that are sent to test the contact points
+	// of a rule.
+	TestNotificationTitlePrefix = "[TEST] "
+)
+
+// sampleTestNotificationLabels are the labels of the instance of a test notification, if the caller does not provide any.
+var sampleTestNotificationLabels = map[string]string{"instance": "test-instance"}
+
+// NewTestNotification returns a notification that the rule would send for an instance with the given labels and values,
+// to test its contact points. The instance has sample labels if labels is nil, and every query and expression of the
+// rule has the value 1 if values is nil. The annotations and notification templates of the rule are rendered like for
+// the instances of the rule, and the notification is marked as a test with the TestNotificationLabel label and the
+// TestNotificationTitlePrefix prefix of its title. The state of the instances of the rule is not changed.
+func NewTestNotification(ctx context.Context, logger log.Logger, rule *ngModels.AlertRule, labels map[string]string,
+	values map[string]float64, now time.Time, externalURL *url.URL) ngModels.AlertingNotification {
+	if labels == nil {
+		labels = sampleTestNotificationLabels
+	}
+	if values == nil {
+		values = make(map[string]float64, len(rule.Data))
+		for _, q := range rule.Data {
+			values[q.RefID] = 1
+		}
+	}
+
+	result := eval.Result{
+		Instance:    data.Labels(labels),
+		State:       eval.Alerting,
+		EvaluatedAt: now,
+		Values:      make(map[string]eval.NumberValueCapture, len(values)),
+	}
+	refIDs := make([]string, 0, len(values))
+	for refID := range values {
+		refIDs = append(refIDs, refID)
+	}
+	sort.Strings(refIDs)
+	captures := make([]string, 0, len(refIDs))
+	for _, refID := range refIDs {
+		v := values[refID]
+		result.Values[refID] = eval.NumberValueCapture{Var: refID, Labels: data.Labels(labels), Value: &v}
+		captures = append(captures, fmt.Sprintf("[ var='%s' labels={%s} value=%v ]", refID, data.Labels(labels), v))
+	}
+	result.EvaluationString = strings.Join(captures, ", ")
+
+	templateData := template.NewData(labels, result)
+	ruleLabels, _ := expand(ctx, logger, rule.Title, rule.Labels, templateData, externalURL, now)
+	annotations, _ := expand(ctx, logger, rule.Title, rule.Annotations, templateData, externalURL, now)
+
+	s := &State{
+		Labels:               mergeLabels(ruleLabels, data.Labels(labels)),
+		Annotations:          annotations,
+		Values:               values,
+		LastEvaluationString: result.EvaluationString,
+		StartsAt:             now,
+	}
+	s.Labels[TestNotificationLabel] = "true"
+
+	n := newAlertingNotification(logger, rule, s)
+	title := n.Title
+	if title == "" {
+		// the title replaces the summary annotation in the notification
+		title = n.Annotations["summary"]
+	}
+	if title == "" {
+		title = rule.Title
+	}
+	n.Title = TestNotificationTitlePrefix + title
+	return n
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
@@ -11,9 +12,63 @@ import (
 
 var _ InstanceStore = &FakeInstanceStore{}
 
+// FakeMaintenanceWindowReader returns the windows in Windows that belong to the requested organization, and counts the
+// reads in Reads.
+type FakeMaintenanceWindowReader struct {
+	Windows []*models.MaintenanceWindow
+	Reads   int
+}
+
+func (f *FakeMaintenanceWindowReader) GetMaintenanceWindows(_ context.Context, orgID int64) ([]*models.MaintenanceWindow, error) {
+	f.Reads++
+	var result []*models.MaintenanceWindow
+	for _, w := range f.Windows {
+		if w.OrgID == orgID {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
+
+// FakeSilenceStore returns the silences in Silences that belong to the requested organization, counts the reads in
+// Reads, and deletes the expired silences from Silences.
+type FakeSilenceStore struct {
+	mtx      sync.Mutex
+	Silences []*models.Silence
+	Reads    int
+}
+
+func (f *FakeSilenceStore) GetSilences(_ context.Context, orgID int64) ([]*models.Silence, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.Reads++
+	var result []*models.Silence
+	for _, s := range f.Silences {
+		if s.OrgID == orgID {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (f *FakeSilenceStore) DeleteExpiredSilences(_ context.Context, before time.Time) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	kept := f.Silences[:0]
+	for _, s := range f.Silences {
+		if !s.End.Before(before) {
+			kept = append(kept, s)
+		}
+	}
+	deleted := int64(len(f.Silences) - len(kept))
+	f.Silences = kept
+	return deleted, nil
+}
+
 type FakeInstanceStore struct {
 	mtx         sync.Mutex
 	RecordedOps []interface{}
+	History     []models.AlertStateHistory
 }
 
 type FakeInstanceStoreOp struct {
@@ -28,6 +83,13 @@ func (f *FakeInstanceStore) ListAlertInstances(_ context.Context, q *models.List
 	return nil, nil
 }
 
+func (f *FakeInstanceStore) ListAlertInstancesPage(_ context.Context, q *models.ListAlertInstancesPageQuery) ([]*models.AlertInstance, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, *q)
+	return nil, nil
+}
+
 func (f *FakeInstanceStore) SaveAlertInstances(_ context.Context, q ...models.AlertInstance) error {
 	f.mtx.Lock()
 	defer f.mtx.Unlock()
@@ -37,6 +99,29 @@ func (f *FakeInstanceStore) SaveAlertInstances(_ context.Context, q ...models.Al
 	return nil
 }
 
+func (f *FakeInstanceStore) SaveAlertInstancesWithHistory(_ context.Context, instances []models.AlertInstance, history []models.AlertStateHistory) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, inst := range instances {
+		f.RecordedOps = append(f.RecordedOps, inst)
+	}
+	f.History = append(f.History, history...)
+	return nil
+}
+
+func (f *FakeInstanceStore) DeleteAlertStateHistory(ctx context.Context, before time.Time, limit int) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, FakeInstanceStoreOp{
+		Name: "DeleteAlertStateHistory", Args: []interface{}{
+			ctx,
+			before,
+			limit,
+		},
+	})
+	return 0, nil
+}
+
 func (f *FakeInstanceStore) FetchOrgIds(_ context.Context) ([]int64, error) { return []int64{}, nil }
 
 func (f *FakeInstanceStore) DeleteAlertInstances(ctx context.Context, q ...models.AlertInstanceKey) error {
@@ -51,10 +136,35 @@ func (f *FakeInstanceStore) DeleteAlertInstances(ctx context.Context, q ...model
 	return nil
 }
 
+func (f *FakeInstanceStore) DeleteAlertInstancesWithHistory(ctx context.Context, keys []models.AlertInstanceKey, history []models.AlertStateHistory) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, FakeInstanceStoreOp{
+		Name: "DeleteAlertInstances", Args: []interface{}{
+			ctx,
+			keys,
+		},
+	})
+	f.History = append(f.History, history...)
+	return nil
+}
+
 func (f *FakeInstanceStore) DeleteAlertInstancesByRule(ctx context.Context, key models.AlertRuleKey) error {
 	return nil
 }
 
+func (f *FakeInstanceStore) DeleteOrphanedAlertInstances(ctx context.Context, limit int) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, FakeInstanceStoreOp{
+		Name: "DeleteOrphanedAlertInstances", Args: []interface{}{
+			ctx,
+			limit,
+		},
+	})
+	return 0, nil
+}
+
 type FakeRuleReader struct{}
 
 func (f *FakeRuleReader) ListAlertRules(_ context.Context, q *models.ListAlertRulesQuery) (models.RulesGroup, error) {
@@ -72,6 +182,37 @@ func (f *FakeHistorian) Record(ctx context.Context, rule history_model.RuleMeta,
 	return errCh
 }
 
+// FakeStatePublisher records the published state transitions. The states are copied, because the manager keeps updating
+// them.
+type FakeStatePublisher struct {
+	StateTransitions []StateTransition
+}
+
+func (f *FakeStatePublisher) PublishTransitions(_ context.Context, _ *models.AlertRule, transitions []StateTransition) {
+	for _, t := range transitions {
+		s := *t.State
+		t.State = &s
+		f.StateTransitions = append(f.StateTransitions, t)
+	}
+}
+
+// FakeContactPointNotifier records the notifications sent to contact points. Notifications to the contact points in
+// Errors fail with the error.
+type FakeContactPointNotifier struct {
+	Notifications []FakeContactPointNotification
+	Errors        map[string]error
+}
+
+type FakeContactPointNotification struct {
+	ContactPointUID string
+	Notification    models.AlertingNotification
+}
+
+func (f *FakeContactPointNotifier) NotifyContactPoint(_ context.Context, _ int64, contactPointUID string, n models.AlertingNotification) error {
+	f.Notifications = append(f.Notifications, FakeContactPointNotification{ContactPointUID: contactPointUID, Notification: n})
+	return f.Errors[contactPointUID]
+}
+
 // NotAvailableImageService is a service that returns ErrScreenshotsUnavailable.
 type NotAvailableImageService struct{}
 
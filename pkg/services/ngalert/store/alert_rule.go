@@ -5,12 +5,17 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"xorm.io/xorm"
 
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/guardian"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/template"
 	"github.com/grafana/grafana/pkg/services/search/model"
 	"github.com/grafana/grafana/pkg/services/sqlstore"
 	"github.com/grafana/grafana/pkg/services/sqlstore/searchstore"
@@ -43,9 +48,20 @@ func getAlertRuleByUID(sess *db.Session, alertRuleUID string, orgID int64) (*ngm
 }
 
 // DeleteAlertRulesByUID is a handler for deleting an alert rule.
-func (st DBstore) DeleteAlertRulesByUID(ctx context.Context, orgID int64, ruleUID ...string) error {
+func (st DBstore) DeleteAlertRulesByUID(ctx context.Context, orgID int64, ruleUID ...string) (err error) {
+	defer st.observe("delete_alert_rules", time.Now(), &err)
 	logger := st.Logger.New("org_id", orgID, "rule_uids", ruleUID)
-	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+	defer st.invalidateRuleCache(orgID)
+	return st.withTransactionRetryOnLocked(ctx, func(sess *db.Session) error {
+		// the folders of the rules are kept in the audit entries of the deletion, which outlive the rules
+		var deleted []struct {
+			UID          string `xorm:"uid"`
+			NamespaceUID string `xorm:"namespace_uid"`
+		}
+		if err := sess.Table("alert_rule").Cols("uid", "namespace_uid").Where("org_id = ?", orgID).In("uid", ruleUID).Find(&deleted); err != nil {
+			return err
+		}
+
 		rows, err := sess.Table("alert_rule").Where("org_id = ?", orgID).In("uid", ruleUID).Delete(ngmodels.AlertRule{})
 		if err != nil {
 			return err
@@ -63,6 +79,47 @@ func (st DBstore) DeleteAlertRulesByUID(ctx context.Context, orgID int64, ruleUI
 			return err
 		}
 		logger.Debug("deleted alert instances", "count", rows)
+
+		rows, err = sess.Table("alert_rule_evaluation").Where("rule_org_id = ?", orgID).In("rule_uid", ruleUID).Delete(ngmodels.AlertRule{})
+		if err != nil {
+			return err
+		}
+		logger.Debug("deleted alert rule evaluations", "count", rows)
+
+		rows, err = sess.Table("alert_rule_contact_point").Where("rule_org_id = ?", orgID).In("rule_uid", ruleUID).Delete(ngmodels.AlertRuleContactPoint{})
+		if err != nil {
+			return err
+		}
+		logger.Debug("deleted alert rule contact points", "count", rows)
+
+		rows, err = sess.Table("alert_rule_datasource").Where("rule_org_id = ?", orgID).In("rule_uid", ruleUID).Delete(ngmodels.AlertRuleDatasource{})
+		if err != nil {
+			return err
+		}
+		logger.Debug("deleted alert rule data sources", "count", rows)
+
+		now := st.now()
+		for _, uid := range ruleUID {
+			sess.PublishAfterCommit(&ngmodels.AlertRuleChanged{OrgID: orgID, UID: uid, Action: ngmodels.AlertRuleDeleted, Timestamp: now})
+		}
+
+		if len(deleted) > 0 {
+			actorID := auditActor(ctx)
+			entries := make([]ngmodels.AlertRuleAuditEntry, 0, len(deleted))
+			for _, r := range deleted {
+				entries = append(entries, ngmodels.AlertRuleAuditEntry{
+					OrgID:        orgID,
+					RuleUID:      r.UID,
+					NamespaceUID: r.NamespaceUID,
+					Action:       ngmodels.AlertRuleAuditDelete,
+					ActorID:      actorID,
+					Created:      now,
+				})
+			}
+			if _, err := sess.Table("alert_rule_audit").Insert(&entries); err != nil {
+				return fmt.Errorf("failed to create audit entries: %w", err)
+			}
+		}
 		return nil
 	})
 }
@@ -70,8 +127,9 @@ func (st DBstore) DeleteAlertRulesByUID(ctx context.Context, orgID int64, ruleUI
 // IncreaseVersionForAllRulesInNamespace Increases version for all rules that have specified namespace. Returns all rules that belong to the namespace
 func (st DBstore) IncreaseVersionForAllRulesInNamespace(ctx context.Context, orgID int64, namespaceUID string) ([]ngmodels.AlertRuleKeyWithVersionAndPauseStatus, error) {
 	var keys []ngmodels.AlertRuleKeyWithVersionAndPauseStatus
+	defer st.invalidateRuleCache(orgID)
 	err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
-		now := TimeNow()
+		now := st.now()
 		_, err := sess.Exec("UPDATE alert_rule SET version = version + 1, updated = ? WHERE namespace_uid = ? AND org_id = ?", now, namespaceUID, orgID)
 		if err != nil {
 			return err
@@ -84,19 +142,35 @@ func (st DBstore) IncreaseVersionForAllRulesInNamespace(ctx context.Context, org
 // GetAlertRuleByUID is a handler for retrieving an alert rule from that database by its UID and organisation ID.
 // It returns ngmodels.ErrAlertRuleNotFound if no alert rule is found for the provided ID.
 func (st DBstore) GetAlertRuleByUID(ctx context.Context, query *ngmodels.GetAlertRuleByUIDQuery) (result *ngmodels.AlertRule, err error) {
+	cache := st.ruleCacheFor(ctx, query.OrgID)
+	var generation uint64
+	if cache != nil {
+		generation = cache.generation(query.OrgID)
+		if rules, ok := cache.get(query.OrgID, ruleCacheKeyByUID(query.UID)); ok {
+			return rules[0], nil
+		}
+	}
+	defer st.observe("get_alert_rule", time.Now(), &err)
 	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
 		alertRule, err := getAlertRuleByUID(sess, query.UID, query.OrgID)
 		if err != nil {
 			return err
 		}
+		if err := loadRuleContactPoints(sess, []*ngmodels.AlertRule{alertRule}); err != nil {
+			return err
+		}
 		result = alertRule
 		return nil
 	})
+	if err == nil && cache != nil {
+		cache.set(query.OrgID, generation, ruleCacheKeyByUID(query.UID), []*ngmodels.AlertRule{result})
+	}
 	return result, err
 }
 
 // GetAlertRulesGroupByRuleUID is a handler for retrieving a group of alert rules from that database by UID and organisation ID of one of rules that belong to that group.
 func (st DBstore) GetAlertRulesGroupByRuleUID(ctx context.Context, query *ngmodels.GetAlertRulesGroupByRuleUIDQuery) (result []*ngmodels.AlertRule, err error) {
+	defer st.observe("get_alert_rules_group", time.Now(), &err)
 	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
 		var rules []*ngmodels.AlertRule
 		err := sess.Table("alert_rule").Alias("a").Join(
@@ -106,6 +180,9 @@ func (st DBstore) GetAlertRulesGroupByRuleUID(ctx context.Context, query *ngmode
 		if err != nil {
 			return err
 		}
+		if err := loadRuleContactPoints(sess, rules); err != nil {
+			return err
+		}
 		result = rules
 		return nil
 	})
@@ -113,11 +190,19 @@ func (st DBstore) GetAlertRulesGroupByRuleUID(ctx context.Context, query *ngmode
 }
 
 // InsertAlertRules is a handler for creating/updating alert rules.
-func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRule) (map[string]int64, error) {
+func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRule) (_ map[string]int64, err error) {
+	defer st.observe("insert_alert_rules", time.Now(), &err)
 	ids := make(map[string]int64, len(rules))
-	return ids, st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+	defer func() {
+		for _, r := range rules {
+			st.invalidateRuleCache(r.OrgID)
+		}
+	}()
+	return ids, st.withTransactionRetryOnLocked(ctx, func(sess *db.Session) error {
 		newRules := make([]ngmodels.AlertRule, 0, len(rules))
 		ruleVersions := make([]ngmodels.AlertRuleVersion, 0, len(rules))
+		auditEntries := make([]ngmodels.AlertRuleAuditEntry, 0, len(rules))
+		actorID := auditActor(ctx)
 		for i := range rules {
 			r := rules[i]
 			if r.UID == "" {
@@ -131,27 +216,34 @@ func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRu
 			if err := st.validateAlertRule(r); err != nil {
 				return err
 			}
-			if err := (&r).PreSave(TimeNow); err != nil {
+			if err := (&r).PreSave(st.now); err != nil {
 				return err
 			}
 			newRules = append(newRules, r)
 			ruleVersions = append(ruleVersions, ngmodels.AlertRuleVersion{
-				RuleUID:          r.UID,
-				RuleOrgID:        r.OrgID,
-				RuleNamespaceUID: r.NamespaceUID,
-				RuleGroup:        r.RuleGroup,
-				ParentVersion:    0,
-				Version:          r.Version,
-				Created:          r.Updated,
-				Condition:        r.Condition,
-				Title:            r.Title,
-				Data:             r.Data,
-				IntervalSeconds:  r.IntervalSeconds,
-				NoDataState:      r.NoDataState,
-				ExecErrState:     r.ExecErrState,
-				For:              r.For,
-				Annotations:      r.Annotations,
-				Labels:           r.Labels,
+				RuleUID:                    r.UID,
+				RuleOrgID:                  r.OrgID,
+				RuleNamespaceUID:           r.NamespaceUID,
+				RuleGroup:                  r.RuleGroup,
+				ParentVersion:              0,
+				Version:                    r.Version,
+				Created:                    r.Updated,
+				Condition:                  r.Condition,
+				Title:                      r.Title,
+				Data:                       r.Data,
+				IntervalSeconds:            r.IntervalSeconds,
+				NoDataState:                r.NoDataState,
+				ExecErrState:               r.ExecErrState,
+				For:                        r.For,
+				Annotations:                r.Annotations,
+				Labels:                     r.Labels,
+				Schedule:                   r.Schedule,
+				ScheduleTimezone:           r.ScheduleTimezone,
+				InstanceLimit:              r.InstanceLimit,
+				NotificationTitle:          r.NotificationTitle,
+				NotificationMessage:        r.NotificationMessage,
+				NotificationRepeatInterval: r.NotificationRepeatInterval,
+				SendResolved:               r.SendResolved,
 			})
 		}
 		if len(newRules) > 0 {
@@ -165,6 +257,29 @@ func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRu
 					return fmt.Errorf("failed to create new rules: %w", err)
 				}
 				ids[newRules[i].UID] = newRules[i].ID
+				if len(newRules[i].ContactPointUIDs) > 0 {
+					if err := saveRuleContactPoints(sess, newRules[i]); err != nil {
+						return err
+					}
+				}
+				if err := saveRuleDatasources(sess, newRules[i]); err != nil {
+					return err
+				}
+				sess.PublishAfterCommit(&ngmodels.AlertRuleChanged{
+					OrgID:     newRules[i].OrgID,
+					ID:        newRules[i].ID,
+					UID:       newRules[i].UID,
+					Action:    ngmodels.AlertRuleCreated,
+					Timestamp: newRules[i].Updated,
+				})
+				auditEntries = append(auditEntries, ngmodels.AlertRuleAuditEntry{
+					OrgID:        newRules[i].OrgID,
+					RuleUID:      newRules[i].UID,
+					NamespaceUID: newRules[i].NamespaceUID,
+					Action:       ngmodels.AlertRuleAuditCreate,
+					ActorID:      actorID,
+					Created:      newRules[i].Updated,
+				})
 			}
 		}
 
@@ -173,22 +288,39 @@ func (st DBstore) InsertAlertRules(ctx context.Context, rules []ngmodels.AlertRu
 				return fmt.Errorf("failed to create new rule versions: %w", err)
 			}
 		}
+		if len(auditEntries) > 0 {
+			if _, err := sess.Table("alert_rule_audit").Insert(&auditEntries); err != nil {
+				return fmt.Errorf("failed to create audit entries: %w", err)
+			}
+		}
 		return nil
 	})
 }
 
-// UpdateAlertRules is a handler for updating alert rules.
-func (st DBstore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateRule) error {
-	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+// UpdateAlertRules is a handler for updating alert rules. Each rule is written with a single UPDATE that matches the
+// version of the existing rule, so that concurrent updates of the same version fail with ErrOptimisticLock instead of
+// overwriting each other.
+func (st DBstore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateRule) (err error) {
+	defer st.observe("update_alert_rules", time.Now(), &err)
+	defer func() {
+		for _, r := range rules {
+			st.invalidateRuleCache(r.Existing.OrgID)
+			st.invalidateRuleCache(r.New.OrgID)
+		}
+	}()
+	return st.withTransactionRetryOnLocked(ctx, func(sess *db.Session) error {
 		ruleVersions := make([]ngmodels.AlertRuleVersion, 0, len(rules))
+		auditEntries := make([]ngmodels.AlertRuleAuditEntry, 0, len(rules))
+		actorID := auditActor(ctx)
 		for _, r := range rules {
 			var parentVersion int64
 			r.New.ID = r.Existing.ID
 			r.New.Version = r.Existing.Version // xorm will take care of increasing it (see https://xorm.io/docs/chapter-06/1.lock/)
+			r.New.CreatedBy = r.Existing.CreatedBy
 			if err := st.validateAlertRule(r.New); err != nil {
 				return err
 			}
-			if err := (&r.New).PreSave(TimeNow); err != nil {
+			if err := (&r.New).PreSave(st.now); err != nil {
 				return err
 			}
 			// no way to update multiple rules at once
@@ -201,25 +333,51 @@ func (st DBstore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateR
 				}
 				return fmt.Errorf("%w: alert rule UID %s version %d", ErrOptimisticLock, r.New.UID, r.New.Version)
 			}
+			// the contact points are compared as sets, so that rules read without them are not changed
+			if !equalContactPoints(r.Existing.ContactPointUIDs, r.New.ContactPointUIDs) {
+				if err := saveRuleContactPoints(sess, r.New); err != nil {
+					return err
+				}
+			}
+			if !equalDatasources(r.Existing, &r.New) {
+				if err := saveRuleDatasources(sess, r.New); err != nil {
+					return err
+				}
+			}
+			sess.PublishAfterCommit(&ngmodels.AlertRuleChanged{
+				OrgID:     r.New.OrgID,
+				ID:        r.New.ID,
+				UID:       r.New.UID,
+				Action:    ngmodels.AlertRuleUpdated,
+				Timestamp: r.New.Updated,
+			})
+			auditEntries = append(auditEntries, newUpdateAuditEntry(actorID, r.Existing, &r.New))
 			parentVersion = r.Existing.Version
 			ruleVersions = append(ruleVersions, ngmodels.AlertRuleVersion{
-				RuleOrgID:        r.New.OrgID,
-				RuleUID:          r.New.UID,
-				RuleNamespaceUID: r.New.NamespaceUID,
-				RuleGroup:        r.New.RuleGroup,
-				RuleGroupIndex:   r.New.RuleGroupIndex,
-				ParentVersion:    parentVersion,
-				Version:          r.New.Version + 1,
-				Created:          r.New.Updated,
-				Condition:        r.New.Condition,
-				Title:            r.New.Title,
-				Data:             r.New.Data,
-				IntervalSeconds:  r.New.IntervalSeconds,
-				NoDataState:      r.New.NoDataState,
-				ExecErrState:     r.New.ExecErrState,
-				For:              r.New.For,
-				Annotations:      r.New.Annotations,
-				Labels:           r.New.Labels,
+				RuleOrgID:                  r.New.OrgID,
+				RuleUID:                    r.New.UID,
+				RuleNamespaceUID:           r.New.NamespaceUID,
+				RuleGroup:                  r.New.RuleGroup,
+				RuleGroupIndex:             r.New.RuleGroupIndex,
+				ParentVersion:              parentVersion,
+				Version:                    r.New.Version + 1,
+				Created:                    r.New.Updated,
+				Condition:                  r.New.Condition,
+				Title:                      r.New.Title,
+				Data:                       r.New.Data,
+				IntervalSeconds:            r.New.IntervalSeconds,
+				NoDataState:                r.New.NoDataState,
+				ExecErrState:               r.New.ExecErrState,
+				For:                        r.New.For,
+				Annotations:                r.New.Annotations,
+				Labels:                     r.New.Labels,
+				Schedule:                   r.New.Schedule,
+				ScheduleTimezone:           r.New.ScheduleTimezone,
+				InstanceLimit:              r.New.InstanceLimit,
+				NotificationTitle:          r.New.NotificationTitle,
+				NotificationMessage:        r.New.NotificationMessage,
+				NotificationRepeatInterval: r.New.NotificationRepeatInterval,
+				SendResolved:               r.New.SendResolved,
 			})
 		}
 		if len(ruleVersions) > 0 {
@@ -227,6 +385,11 @@ func (st DBstore) UpdateAlertRules(ctx context.Context, rules []ngmodels.UpdateR
 				return fmt.Errorf("failed to create new rule versions: %w", err)
 			}
 		}
+		if len(auditEntries) > 0 {
+			if _, err := sess.Table("alert_rule_audit").Insert(&auditEntries); err != nil {
+				return fmt.Errorf("failed to create audit entries: %w", err)
+			}
+		}
 		return nil
 	})
 }
@@ -244,37 +407,70 @@ func (st DBstore) CountAlertRulesInFolder(ctx context.Context, query *ngmodels.C
 	return count, err
 }
 
-// ListAlertRules is a handler for retrieving alert rules of specific organisation.
-func (st DBstore) ListAlertRules(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (result ngmodels.RulesGroup, err error) {
+// ListAlertRuleSummaries returns the summaries of the alert rules that match the query, in the same order as
+// ListAlertRules. The queries and expressions of the rules are not read.
+func (st DBstore) ListAlertRuleSummaries(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (_ []*ngmodels.AlertRuleSummary, err error) {
+	defer st.observe("list_alert_rule_summaries", time.Now(), &err)
+	result := make([]*ngmodels.AlertRuleSummary, 0)
 	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
-		q := sess.Table("alert_rule")
+		return filterAlertRules(sess.Table("alert_rule"), query).Cols(alertRuleSummaryColumns...).Find(&result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
 
-		if query.OrgID >= 0 {
-			q = q.Where("org_id = ?", query.OrgID)
-		}
+// alertRuleSummaryColumns are the columns of the alert_rule table that ngmodels.AlertRuleSummary has.
+var alertRuleSummaryColumns = []string{"id", "org_id", "uid", "title", "namespace_uid", "rule_group", "rule_group_idx", "interval_seconds", "is_paused", "updated"}
 
-		if query.DashboardUID != "" {
-			q = q.Where("dashboard_uid = ?", query.DashboardUID)
-			if query.PanelID != 0 {
-				q = q.Where("panel_id = ?", query.PanelID)
-			}
-		}
+// filterAlertRules adds the conditions of the query to the statement, and orders the rules by folder, group and index.
+func filterAlertRules(q *xorm.Session, query *ngmodels.ListAlertRulesQuery) *xorm.Session {
+	if query.OrgID >= 0 {
+		q = q.Where("org_id = ?", query.OrgID)
+	}
 
-		if len(query.NamespaceUIDs) > 0 {
-			args := make([]interface{}, 0, len(query.NamespaceUIDs))
-			in := make([]string, 0, len(query.NamespaceUIDs))
-			for _, namespaceUID := range query.NamespaceUIDs {
-				args = append(args, namespaceUID)
-				in = append(in, "?")
-			}
-			q = q.Where(fmt.Sprintf("namespace_uid IN (%s)", strings.Join(in, ",")), args...)
+	if query.DashboardUID != "" {
+		q = q.Where("dashboard_uid = ?", query.DashboardUID)
+		if query.PanelID != 0 {
+			q = q.Where("panel_id = ?", query.PanelID)
 		}
+	}
 
-		if query.RuleGroup != "" {
-			q = q.Where("rule_group = ?", query.RuleGroup)
+	if len(query.NamespaceUIDs) > 0 {
+		args := make([]interface{}, 0, len(query.NamespaceUIDs))
+		in := make([]string, 0, len(query.NamespaceUIDs))
+		for _, namespaceUID := range query.NamespaceUIDs {
+			args = append(args, namespaceUID)
+			in = append(in, "?")
 		}
+		q = q.Where(fmt.Sprintf("namespace_uid IN (%s)", strings.Join(in, ",")), args...)
+	}
 
-		q = q.Asc("namespace_uid", "rule_group", "rule_group_idx", "id")
+	if query.RuleGroup != "" {
+		q = q.Where("rule_group = ?", query.RuleGroup)
+	}
+
+	if query.TitleSearch != "" {
+		q = q.Where("LOWER(title) LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(strings.ToLower(query.TitleSearch))+"%")
+	}
+
+	return q.Asc("namespace_uid", "rule_group", "rule_group_idx", "id")
+}
+
+// ListAlertRules is a handler for retrieving alert rules of specific organisation.
+func (st DBstore) ListAlertRules(ctx context.Context, query *ngmodels.ListAlertRulesQuery) (result ngmodels.RulesGroup, err error) {
+	cache := st.ruleCacheFor(ctx, query.OrgID)
+	var generation uint64
+	if cache != nil {
+		generation = cache.generation(query.OrgID)
+		if rules, ok := cache.get(query.OrgID, ruleCacheKeyByQuery(query)); ok {
+			return rules, nil
+		}
+	}
+	defer st.observe("list_alert_rules", time.Now(), &err)
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := filterAlertRules(sess.Table("alert_rule"), query)
 
 		alertRules := make([]*ngmodels.AlertRule, 0)
 		rule := new(ngmodels.AlertRule)
@@ -300,6 +496,16 @@ func (st DBstore) ListAlertRules(ctx context.Context, query *ngmodels.ListAlertR
 		result = alertRules
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	// the contact points are read after the rows are closed, as the connection cannot run other queries before
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return loadRuleContactPoints(sess, result)
+	})
+	if err == nil && cache != nil {
+		cache.set(query.OrgID, generation, ruleCacheKeyByQuery(query), result)
+	}
 	return result, err
 }
 
@@ -458,8 +664,20 @@ func (st DBstore) GetAlertRulesKeysForScheduling(ctx context.Context) ([]ngmodel
 	return result, err
 }
 
-// GetAlertRulesForScheduling returns a short version of all alert rules except those that belong to an excluded list of organizations
-func (st DBstore) GetAlertRulesForScheduling(ctx context.Context, query *ngmodels.GetAlertRulesForSchedulingQuery) error {
+// pausedAlertRuleColumns are the columns fetched for paused alert rules. Paused rules are not evaluated, and the scheduler only needs to know
+// that they exist to keep their state and to resume their evaluation when they are unpaused.
+const pausedAlertRuleColumns = "id, org_id, uid, title, namespace_uid, rule_group, rule_group_idx, version, interval_seconds, is_paused, annotations, schedule, schedule_timezone"
+
+// alertRulesForSchedulingPageSize is the maximum number of alert rules that GetAlertRulesForScheduling reads with one statement.
+// This is a variable so that the tests can override it.
+var alertRulesForSchedulingPageSize = 1000
+
+// GetAlertRulesForScheduling returns a short version of all alert rules except those that belong to an excluded list of organizations.
+// Paused rules contain only their identity, version and pause status because they are not evaluated.
+// The rules are read in pages of consecutive IDs, so that a large number of rules is not read with a single long-running statement
+// that holds the database, and the rows of a page are decoded one at a time.
+func (st DBstore) GetAlertRulesForScheduling(ctx context.Context, query *ngmodels.GetAlertRulesForSchedulingQuery) (err error) {
+	defer st.observe("get_alert_rules_for_scheduling", time.Now(), &err)
 	var folders []struct {
 		Uid   string
 		Title string
@@ -467,31 +685,40 @@ func (st DBstore) GetAlertRulesForScheduling(ctx context.Context, query *ngmodel
 	var rules []*ngmodels.AlertRule
 	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
 		foldersSql := "SELECT D.uid, D.title FROM dashboard AS D WHERE is_folder IS TRUE AND EXISTS (SELECT 1 FROM alert_rule AS A WHERE D.uid = A.namespace_uid)"
-		alertRulesSql := "SELECT * FROM alert_rule"
+		idsSql := "SELECT id FROM alert_rule WHERE is_paused = ?"
+		alertRulesSql := "SELECT * FROM alert_rule WHERE is_paused = ?"
+		pausedAlertRulesSql := "SELECT " + pausedAlertRuleColumns + " FROM alert_rule WHERE is_paused = ?"
 		filter, args := st.getFilterByOrgsString()
 		if filter != "" {
 			foldersSql += " AND " + filter
-			alertRulesSql += " WHERE " + filter
+			idsSql += " AND " + filter
+			alertRulesSql += " AND " + filter
+			pausedAlertRulesSql += " AND " + filter
 		}
 
-		rule := new(ngmodels.AlertRule)
-		rows, err := sess.SQL(alertRulesSql, args...).Rows(rule)
-		if err != nil {
-			return fmt.Errorf("failed to fetch alert rules: %w", err)
-		}
-		defer func() {
-			_ = rows.Close()
-		}()
-
-		// Deserialize each rule separately in case any of them contain invalid JSON.
-		for rows.Next() {
-			rule := new(ngmodels.AlertRule)
-			err = rows.Scan(rule)
-			if err != nil {
-				st.Logger.Error("Invalid rule found in DB store, ignoring it", "func", "GetAlertRulesForScheduling", "error", err)
-				continue
+		fetch := func(sql string, isPaused bool) error {
+			filterArgs := append([]interface{}{st.SQLStore.GetDialect().BooleanStr(isPaused)}, args...)
+			// the pages are ranges of the IDs of the rules rather than offsets, so that rules that cannot be decoded do not shift them
+			var ids []int64
+			if err := sess.SQL(idsSql+" ORDER BY id", filterArgs...).Find(&ids); err != nil {
+				return fmt.Errorf("failed to fetch alert rules: %w", err)
+			}
+			for start := 0; start < len(ids); start += alertRulesForSchedulingPageSize {
+				end := start + alertRulesForSchedulingPageSize
+				if end > len(ids) {
+					end = len(ids)
+				}
+				if err := fetchPage(sess, st.Logger, sql+" AND id >= ? AND id <= ?", append(filterArgs, ids[start], ids[end-1]), &rules); err != nil {
+					return err
+				}
 			}
-			rules = append(rules, rule)
+			return nil
+		}
+		if err := fetch(alertRulesSql, false); err != nil {
+			return err
+		}
+		if err := fetch(pausedAlertRulesSql, true); err != nil {
+			return err
 		}
 
 		query.ResultRules = rules
@@ -508,6 +735,28 @@ func (st DBstore) GetAlertRulesForScheduling(ctx context.Context, query *ngmodel
 	})
 }
 
+// fetchPage appends the alert rules that the statement returns to rules, and skips the rules that cannot be decoded.
+func fetchPage(sess *db.Session, logger log.Logger, sql string, args []interface{}, rules *[]*ngmodels.AlertRule) error {
+	rows, err := sess.SQL(sql, args...).Rows(new(ngmodels.AlertRule))
+	if err != nil {
+		return fmt.Errorf("failed to fetch alert rules: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	// Deserialize each rule separately in case any of them contain invalid JSON.
+	for rows.Next() {
+		rule := new(ngmodels.AlertRule)
+		if err := rows.Scan(rule); err != nil {
+			logger.Error("Invalid rule found in DB store, ignoring it", "func", "GetAlertRulesForScheduling", "error", err)
+			continue
+		}
+		*rules = append(*rules, rule)
+	}
+	return nil
+}
+
 // GenerateNewAlertRuleUID generates a unique UID for a rule.
 // This is set as a variable so that the tests can override it.
 // The ruleTitle is only used by the mocked functions.
@@ -542,6 +791,25 @@ func (st DBstore) validateAlertRule(alertRule ngmodels.AlertRule) error {
 		return err
 	}
 
+	if err := alertRule.ValidateSchedule(); err != nil {
+		return err
+	}
+
+	if err := alertRule.ValidateInstanceLimit(st.Cfg.MaxInstancesPerRuleLimit); err != nil {
+		return err
+	}
+
+	if err := alertRule.ValidateNotificationRepeatInterval(); err != nil {
+		return err
+	}
+
+	if err := template.ParseNotificationTemplate(alertRule.NotificationTitle); err != nil {
+		return fmt.Errorf("%w: notification title: %s", ngmodels.ErrAlertRuleFailedValidation, err)
+	}
+	if err := template.ParseNotificationTemplate(alertRule.NotificationMessage); err != nil {
+		return fmt.Errorf("%w: notification message: %s", ngmodels.ErrAlertRuleFailedValidation, err)
+	}
+
 	// enfore max name length in SQLite
 	if len(alertRule.Title) > AlertRuleMaxTitleLength {
 		return fmt.Errorf("%w: name length should not be greater than %d", ngmodels.ErrAlertRuleFailedValidation, AlertRuleMaxTitleLength)
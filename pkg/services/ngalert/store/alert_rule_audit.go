@@ -0,0 +1,91 @@
+package store
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/db"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// auditActor returns the actor ID of the user who makes the request of the context, or zero if the context has no user,
+// e.g. for file provisioning. See ngmodels.ActorID.
+func auditActor(ctx context.Context) int64 {
+	u, err := appcontext.User(ctx)
+	if err != nil {
+		return 0
+	}
+	return ngmodels.ActorID(u)
+}
+
+// newUpdateAuditEntry returns the audit entry of the update of a rule. An update that pauses or unpauses the rule is
+// recorded as such, with the other fields that it changed.
+func newUpdateAuditEntry(actorID int64, existing, updated *ngmodels.AlertRule) ngmodels.AlertRuleAuditEntry {
+	action := ngmodels.AlertRuleAuditUpdate
+	if existing.IsPaused != updated.IsPaused {
+		action = ngmodels.AlertRuleAuditUnpause
+		if updated.IsPaused {
+			action = ngmodels.AlertRuleAuditPause
+		}
+	}
+	return ngmodels.AlertRuleAuditEntry{
+		OrgID:        updated.OrgID,
+		RuleUID:      updated.UID,
+		NamespaceUID: updated.NamespaceUID,
+		Action:       action,
+		ActorID:      actorID,
+		Created:      updated.Updated,
+		Changes:      changedFields(existing, updated),
+	}
+}
+
+// changedFields returns the sorted names of the top-level fields of the rule that differ.
+func changedFields(existing, updated *ngmodels.AlertRule) []string {
+	ignore := append(AlertRuleFieldsToIgnoreInDiff[:], "ContactPointUIDs")
+	fields := make(map[string]struct{})
+	for _, diff := range existing.Diff(updated, ignore...) {
+		field := diff.Path
+		if i := strings.IndexAny(field, ".["); i >= 0 {
+			field = field[:i]
+		}
+		fields[field] = struct{}{}
+	}
+	// the contact points are compared as sets, so that rules read without them are not reported as changed
+	if !equalContactPoints(existing.ContactPointUIDs, updated.ContactPointUIDs) {
+		fields["ContactPointUIDs"] = struct{}{}
+	}
+	result := make([]string, 0, len(fields))
+	for field := range fields {
+		result = append(result, field)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ListAlertRuleAuditEntries returns a page of the audit entries of a rule, from the newest.
+func (st DBstore) ListAlertRuleAuditEntries(ctx context.Context, query *ngmodels.ListAlertRuleAuditQuery) (result []*ngmodels.AlertRuleAuditEntry, err error) {
+	defer st.observe("list_alert_rule_audit_entries", time.Now(), &err)
+	result = make([]*ngmodels.AlertRuleAuditEntry, 0)
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Table("alert_rule_audit").Where("org_id = ? AND rule_uid = ?", query.OrgID, query.RuleUID).Desc("id")
+		if query.Limit > 0 {
+			q = q.Limit(int(query.Limit), int(query.Offset))
+		}
+		return q.Find(&result)
+	})
+	return result, err
+}
+
+// CountAlertRuleAuditEntries returns the number of audit entries of a rule, regardless of the page of the query.
+func (st DBstore) CountAlertRuleAuditEntries(ctx context.Context, query *ngmodels.ListAlertRuleAuditQuery) (int64, error) {
+	var count int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		count, err = sess.Table("alert_rule_audit").Where("org_id = ? AND rule_uid = ?", query.OrgID, query.RuleUID).Count()
+		return err
+	})
+	return count, err
+}
@@ -0,0 +1,147 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/appcontext"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationAlertRuleAudit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := &DBstore{
+		SQLStore: db.InitTestDB(t),
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.NewNopLogger(),
+	}
+	orgID := int64(1)
+	asUser := func(userID int64) context.Context {
+		return appcontext.WithUser(context.Background(), &user.SignedInUser{UserID: userID, OrgID: orgID})
+	}
+	uniqueID := models.WithUniqueID()
+	rule := models.AlertRuleGen(uniqueID, models.WithOrgID(orgID), models.WithIsPaused(false), func(rule *models.AlertRule) {
+		rule.IntervalSeconds = 60
+		rule.For = time.Minute
+	})()
+	get := func(t *testing.T) *models.AlertRule {
+		t.Helper()
+		r, err := store.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+		require.NoError(t, err)
+		return r
+	}
+	update := func(t *testing.T, ctx context.Context, mutate func(r *models.AlertRule)) {
+		t.Helper()
+		existing := get(t)
+		updated := *existing
+		mutate(&updated)
+		require.NoError(t, store.UpdateAlertRules(ctx, []models.UpdateRule{{Existing: existing, New: updated}}))
+	}
+	latest := func(t *testing.T) *models.AlertRuleAuditEntry {
+		t.Helper()
+		entries, err := store.ListAlertRuleAuditEntries(context.Background(), &models.ListAlertRuleAuditQuery{OrgID: orgID, RuleUID: rule.UID, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, orgID, entries[0].OrgID)
+		require.Equal(t, rule.UID, entries[0].RuleUID)
+		require.Equal(t, rule.NamespaceUID, entries[0].NamespaceUID)
+		require.False(t, entries[0].Created.IsZero())
+		return entries[0]
+	}
+
+	t.Run("create", func(t *testing.T) {
+		_, err := store.InsertAlertRules(asUser(1), []models.AlertRule{*rule})
+		require.NoError(t, err)
+		entry := latest(t)
+		require.Equal(t, models.AlertRuleAuditCreate, entry.Action)
+		require.Equal(t, int64(1), entry.ActorID)
+		require.Empty(t, entry.Changes)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		update(t, asUser(2), func(r *models.AlertRule) {
+			r.Title = "updated title"
+			r.Labels = map[string]string{"team": "updated"}
+		})
+		entry := latest(t)
+		require.Equal(t, models.AlertRuleAuditUpdate, entry.Action)
+		require.Equal(t, int64(2), entry.ActorID)
+		require.Equal(t, []string{"Labels", "Title"}, entry.Changes)
+	})
+
+	t.Run("pause", func(t *testing.T) {
+		update(t, asUser(3), func(r *models.AlertRule) { r.IsPaused = true })
+		entry := latest(t)
+		require.Equal(t, models.AlertRuleAuditPause, entry.Action)
+		require.Equal(t, int64(3), entry.ActorID)
+		require.Equal(t, []string{"IsPaused"}, entry.Changes)
+	})
+
+	t.Run("unpause", func(t *testing.T) {
+		update(t, asUser(4), func(r *models.AlertRule) { r.IsPaused = false })
+		entry := latest(t)
+		require.Equal(t, models.AlertRuleAuditUnpause, entry.Action)
+		require.Equal(t, int64(4), entry.ActorID)
+	})
+
+	t.Run("update without a user", func(t *testing.T) {
+		update(t, context.Background(), func(r *models.AlertRule) { r.IntervalSeconds = 120 })
+		entry := latest(t)
+		require.Equal(t, models.AlertRuleAuditUpdate, entry.Action)
+		require.Equal(t, int64(0), entry.ActorID)
+		require.Equal(t, []string{"IntervalSeconds"}, entry.Changes)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.DeleteAlertRulesByUID(asUser(5), orgID, rule.UID))
+		entry := latest(t)
+		require.Equal(t, models.AlertRuleAuditDelete, entry.Action)
+		require.Equal(t, int64(5), entry.ActorID)
+	})
+
+	t.Run("entries are kept after the deletion and paginated from the newest", func(t *testing.T) {
+		query := &models.ListAlertRuleAuditQuery{OrgID: orgID, RuleUID: rule.UID}
+		count, err := store.CountAlertRuleAuditEntries(context.Background(), query)
+		require.NoError(t, err)
+		require.Equal(t, int64(6), count)
+
+		query.Limit = 4
+		query.Offset = 4
+		entries, err := store.ListAlertRuleAuditEntries(context.Background(), query)
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, models.AlertRuleAuditUpdate, entries[0].Action)
+		require.Equal(t, models.AlertRuleAuditCreate, entries[1].Action)
+	})
+
+	t.Run("are not written if the mutation fails", func(t *testing.T) {
+		other := models.AlertRuleGen(uniqueID, models.WithOrgID(orgID), func(rule *models.AlertRule) {
+			rule.IntervalSeconds = 60
+			rule.For = time.Minute
+		})()
+		_, err := store.InsertAlertRules(asUser(1), []models.AlertRule{*other})
+		require.NoError(t, err)
+		existing, err := store.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: other.UID})
+		require.NoError(t, err)
+		stale := *existing
+		stale.Version--
+		updated := *existing
+		updated.Title = "never saved"
+		require.ErrorIs(t, store.UpdateAlertRules(asUser(1), []models.UpdateRule{{Existing: &stale, New: updated}}), ErrOptimisticLock)
+
+		count, err := store.CountAlertRuleAuditEntries(context.Background(), &models.ListAlertRuleAuditQuery{OrgID: orgID, RuleUID: other.UID})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), count)
+	})
+}
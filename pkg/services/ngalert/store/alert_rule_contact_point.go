@@ -0,0 +1,154 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// saveRuleContactPoints replaces the contact points associated with the rule by its ContactPointUIDs. The contact points
+// must exist in the latest Alertmanager configuration of the organization of the rule.
+func saveRuleContactPoints(sess *db.Session, rule ngmodels.AlertRule) error {
+	if len(rule.ContactPointUIDs) > 0 {
+		existing, err := getContactPointUIDs(sess, rule.OrgID)
+		if err != nil {
+			return err
+		}
+		for _, uid := range rule.ContactPointUIDs {
+			if _, ok := existing[uid]; !ok {
+				return fmt.Errorf("%w: contact point %s does not exist", ngmodels.ErrAlertRuleFailedValidation, uid)
+			}
+		}
+	}
+
+	if _, err := sess.Table("alert_rule_contact_point").Where("rule_org_id = ? AND rule_uid = ?", rule.OrgID, rule.UID).Delete(&ngmodels.AlertRuleContactPoint{}); err != nil {
+		return fmt.Errorf("failed to delete contact points of rule %s: %w", rule.UID, err)
+	}
+	seen := make(map[string]struct{}, len(rule.ContactPointUIDs))
+	for _, uid := range rule.ContactPointUIDs {
+		if _, ok := seen[uid]; ok {
+			continue
+		}
+		seen[uid] = struct{}{}
+		cp := ngmodels.AlertRuleContactPoint{RuleOrgID: rule.OrgID, RuleUID: rule.UID, ContactPointUID: uid}
+		if _, err := sess.Insert(&cp); err != nil {
+			return fmt.Errorf("failed to associate rule %s with contact point %s: %w", rule.UID, uid, err)
+		}
+	}
+	return nil
+}
+
+// loadRuleContactPoints sets the ContactPointUIDs of the rules. The UIDs are sorted.
+func loadRuleContactPoints(sess *db.Session, rules []*ngmodels.AlertRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	orgIDs := make(map[int64]struct{})
+	for _, rule := range rules {
+		orgIDs[rule.OrgID] = struct{}{}
+	}
+	args := make([]interface{}, 0, len(orgIDs))
+	in := make([]string, 0, len(orgIDs))
+	for orgID := range orgIDs {
+		args = append(args, orgID)
+		in = append(in, "?")
+	}
+
+	// the query is raw, because iterating over rows with xorm does not reset the conditions of the session afterwards
+	var associations []ngmodels.AlertRuleContactPoint
+	q := fmt.Sprintf("SELECT * FROM alert_rule_contact_point WHERE rule_org_id IN (%s) ORDER BY contact_point_uid", strings.Join(in, ","))
+	if err := sess.SQL(q, args...).Find(&associations); err != nil {
+		return fmt.Errorf("failed to get contact points of rules: %w", err)
+	}
+	byRule := make(map[ngmodels.AlertRuleKey][]string)
+	for _, a := range associations {
+		key := ngmodels.AlertRuleKey{OrgID: a.RuleOrgID, UID: a.RuleUID}
+		byRule[key] = append(byRule[key], a.ContactPointUID)
+	}
+	for _, rule := range rules {
+		rule.ContactPointUIDs = byRule[rule.GetKey()]
+	}
+	return nil
+}
+
+// equalContactPoints returns true if both lists contain the same contact points, regardless of their order.
+func equalContactPoints(a, b []string) bool {
+	set := make(map[string]struct{}, len(a))
+	for _, uid := range a {
+		set[uid] = struct{}{}
+	}
+	other := make(map[string]struct{}, len(b))
+	for _, uid := range b {
+		if _, ok := set[uid]; !ok {
+			return false
+		}
+		other[uid] = struct{}{}
+	}
+	return len(set) == len(other)
+}
+
+// checkContactPointsInUse returns ngmodels.ErrContactPointInUse if the Alertmanager configuration does not contain a
+// contact point that alert rules of the organization are associated with.
+func checkContactPointsInUse(sess *db.Session, orgID int64, config string) error {
+	var associations []ngmodels.AlertRuleContactPoint
+	if err := sess.Table("alert_rule_contact_point").Where("rule_org_id = ?", orgID).Find(&associations); err != nil {
+		return fmt.Errorf("failed to get contact points of rules: %w", err)
+	}
+	if len(associations) == 0 {
+		return nil
+	}
+	existing, err := contactPointUIDsFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	missing := make(map[string][]string)
+	for _, a := range associations {
+		if _, ok := existing[a.ContactPointUID]; !ok {
+			missing[a.ContactPointUID] = append(missing[a.ContactPointUID], a.RuleUID)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	uids := make([]string, 0, len(missing))
+	for uid := range missing {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+	rules := missing[uids[0]]
+	sort.Strings(rules)
+	return fmt.Errorf("%w: contact point %s is used by alert rules %s", ngmodels.ErrContactPointInUse, uids[0], strings.Join(rules, ", "))
+}
+
+// getContactPointUIDs returns the UIDs of the contact points in the latest Alertmanager configuration of the organization.
+func getContactPointUIDs(sess *db.Session, orgID int64) (map[string]struct{}, error) {
+	c := &ngmodels.AlertConfiguration{}
+	ok, err := sess.Table("alert_configuration").Where("org_id = ?", orgID).Get(c)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return map[string]struct{}{}, nil
+	}
+	return contactPointUIDsFromConfig(c.AlertmanagerConfiguration)
+}
+
+func contactPointUIDsFromConfig(config string) (map[string]struct{}, error) {
+	cfg := definitions.PostableUserConfig{}
+	if err := json.Unmarshal([]byte(config), &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse the Alertmanager configuration: %w", err)
+	}
+	uids := make(map[string]struct{})
+	for _, receiver := range cfg.AlertmanagerConfig.Receivers {
+		for _, integration := range receiver.GrafanaManagedReceivers {
+			uids[integration.UID] = struct{}{}
+		}
+	}
+	return uids, nil
+}
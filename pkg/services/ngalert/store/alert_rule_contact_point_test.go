@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const contactPointsConfig = `{
+	"alertmanager_config": {
+		"route": {"receiver": "team"},
+		"receivers": [{
+			"name": "team",
+			"grafana_managed_receiver_configs": [
+				{"uid": "email", "name": "team", "type": "email", "settings": {"addresses": "team@example.com"}},
+				{"uid": "slack", "name": "team", "type": "slack", "settings": {"url": "http://localhost"}}
+			]
+		}]
+	}
+}`
+
+const withoutSlackConfig = `{
+	"alertmanager_config": {
+		"route": {"receiver": "team"},
+		"receivers": [{
+			"name": "team",
+			"grafana_managed_receiver_configs": [
+				{"uid": "email", "name": "team", "type": "email", "settings": {"addresses": "team@example.com"}}
+			]
+		}]
+	}
+}`
+
+func TestIntegrationAlertRuleContactPoints(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.NewNopLogger(),
+	}
+	ctx := context.Background()
+	orgID := int64(1)
+	setupConfigInOrg(t, contactPointsConfig, orgID, store)
+	gen := models.AlertRuleGen(models.WithUniqueID(), func(rule *models.AlertRule) {
+		rule.OrgID = orgID
+		rule.IntervalSeconds = 60
+		rule.For = time.Minute
+	})
+	get := func(t *testing.T, uid string) *models.AlertRule {
+		t.Helper()
+		rule, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: uid})
+		require.NoError(t, err)
+		return rule
+	}
+
+	t.Run("are saved with the rule and returned by the queries", func(t *testing.T) {
+		rule := gen()
+		rule.ContactPointUIDs = []string{"slack", "email"}
+		_, err := store.InsertAlertRules(ctx, []models.AlertRule{*rule})
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"email", "slack"}, get(t, rule.UID).ContactPointUIDs)
+
+		rules, err := store.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		found := false
+		for _, r := range rules {
+			if r.UID == rule.UID {
+				found = true
+				require.Equal(t, []string{"email", "slack"}, r.ContactPointUIDs)
+			}
+		}
+		require.True(t, found)
+
+		group, err := store.GetAlertRulesGroupByRuleUID(ctx, &models.GetAlertRulesGroupByRuleUIDQuery{OrgID: orgID, UID: rule.UID})
+		require.NoError(t, err)
+		require.Len(t, group, 1)
+		require.Equal(t, []string{"email", "slack"}, group[0].ContactPointUIDs)
+	})
+
+	t.Run("are replaced when the rule is updated", func(t *testing.T) {
+		rule := gen()
+		rule.ContactPointUIDs = []string{"email"}
+		_, err := store.InsertAlertRules(ctx, []models.AlertRule{*rule})
+		require.NoError(t, err)
+
+		stored := get(t, rule.UID)
+		updated := *stored
+		updated.ContactPointUIDs = []string{"slack"}
+		require.NoError(t, store.UpdateAlertRules(ctx, []models.UpdateRule{{Existing: stored, New: updated}}))
+		require.Equal(t, []string{"slack"}, get(t, rule.UID).ContactPointUIDs)
+
+		stored = get(t, rule.UID)
+		updated = *stored
+		updated.ContactPointUIDs = nil
+		require.NoError(t, store.UpdateAlertRules(ctx, []models.UpdateRule{{Existing: stored, New: updated}}))
+		require.Empty(t, get(t, rule.UID).ContactPointUIDs)
+	})
+
+	t.Run("must exist in the organization of the rule", func(t *testing.T) {
+		otherOrg := orgID + 1
+		setupConfigInOrg(t, `{"alertmanager_config": {"route": {"receiver": "other"}, "receivers": [{"name": "other", "grafana_managed_receiver_configs": [{"uid": "other", "name": "other", "type": "email", "settings": {"addresses": "other@example.com"}}]}]}}`, otherOrg, store)
+
+		for _, uid := range []string{"missing", "other"} {
+			rule := gen()
+			rule.ContactPointUIDs = []string{uid}
+			_, err := store.InsertAlertRules(ctx, []models.AlertRule{*rule})
+			require.ErrorIs(t, err, models.ErrAlertRuleFailedValidation)
+			require.ErrorContains(t, err, fmt.Sprintf("contact point %s does not exist", uid))
+
+			_, err = store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+			require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+		}
+	})
+
+	t.Run("cannot be removed from the configuration while rules use them", func(t *testing.T) {
+		rule := gen()
+		rule.ContactPointUIDs = []string{"slack"}
+		_, err := store.InsertAlertRules(ctx, []models.AlertRule{*rule})
+		require.NoError(t, err)
+
+		cmd := buildSaveConfigCmd(t, withoutSlackConfig, orgID)
+		err = store.SaveAlertmanagerConfiguration(ctx, &cmd)
+		require.ErrorIs(t, err, models.ErrContactPointInUse)
+		require.ErrorContains(t, err, rule.UID)
+
+		// the other rules that use the contact point are deleted as well
+		rules, err := store.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		uids := make([]string, 0, len(rules))
+		for _, r := range rules {
+			uids = append(uids, r.UID)
+		}
+		require.NoError(t, store.DeleteAlertRulesByUID(ctx, orgID, uids...))
+
+		require.NoError(t, store.SaveAlertmanagerConfiguration(ctx, &cmd))
+	})
+}
@@ -0,0 +1,110 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"xorm.io/xorm"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// saveRuleDatasources replaces the data sources associated with the rule by the data sources that its queries read.
+func saveRuleDatasources(sess *db.Session, rule ngmodels.AlertRule) error {
+	if _, err := sess.Table("alert_rule_datasource").Where("rule_org_id = ? AND rule_uid = ?", rule.OrgID, rule.UID).Delete(&ngmodels.AlertRuleDatasource{}); err != nil {
+		return fmt.Errorf("failed to delete data sources of rule %s: %w", rule.UID, err)
+	}
+	for _, uid := range rule.GetDatasourceUIDs() {
+		d := ngmodels.AlertRuleDatasource{RuleOrgID: rule.OrgID, RuleUID: rule.UID, DatasourceUID: uid}
+		if _, err := sess.Insert(&d); err != nil {
+			return fmt.Errorf("failed to associate rule %s with data source %s: %w", rule.UID, uid, err)
+		}
+	}
+	return nil
+}
+
+// equalDatasources returns true if the queries of both rules read the same data sources.
+func equalDatasources(a, b *ngmodels.AlertRule) bool {
+	uidsA, uidsB := a.GetDatasourceUIDs(), b.GetDatasourceUIDs()
+	if len(uidsA) != len(uidsB) {
+		return false
+	}
+	for i := range uidsA {
+		if uidsA[i] != uidsB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// filterRulesAcrossOrgs adds the conditions of the query, other than the page, to the session.
+func filterRulesAcrossOrgs(sess *xorm.Session, query *ngmodels.ListAlertRulesAcrossOrgsQuery) *xorm.Session {
+	q := sess.Table("alert_rule")
+	if query.DatasourceUID != "" {
+		q = q.Where("EXISTS (SELECT 1 FROM alert_rule_datasource WHERE alert_rule_datasource.rule_org_id = alert_rule.org_id AND alert_rule_datasource.rule_uid = alert_rule.uid AND alert_rule_datasource.datasource_uid = ?)", query.DatasourceUID)
+	}
+	return q
+}
+
+// ListAlertRulesAcrossOrgs returns a page of the alert rules of all organizations, ordered by organization, with the
+// names of their organizations. It must only be used for Grafana admins, as it ignores the organization of the user.
+func (st DBstore) ListAlertRulesAcrossOrgs(ctx context.Context, query *ngmodels.ListAlertRulesAcrossOrgsQuery) (result []*ngmodels.AlertRuleWithOrg, err error) {
+	defer st.observe("list_alert_rules_across_orgs", time.Now(), &err)
+	result = make([]*ngmodels.AlertRuleWithOrg, 0)
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		rules := make([]*ngmodels.AlertRule, 0)
+		q := filterRulesAcrossOrgs(sess.Session, query).Asc("org_id", "id")
+		if query.Limit > 0 {
+			q = q.Limit(int(query.Limit), int(query.Offset))
+		}
+		if err := q.Find(&rules); err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		if err := loadRuleContactPoints(sess, rules); err != nil {
+			return err
+		}
+
+		orgIDs := make([]int64, 0)
+		seen := make(map[int64]struct{})
+		for _, rule := range rules {
+			if _, ok := seen[rule.OrgID]; !ok {
+				seen[rule.OrgID] = struct{}{}
+				orgIDs = append(orgIDs, rule.OrgID)
+			}
+		}
+		var orgs []struct {
+			ID   int64 `xorm:"id"`
+			Name string
+		}
+		if err := sess.Table("org").Cols("id", "name").In("id", orgIDs).Find(&orgs); err != nil {
+			return fmt.Errorf("failed to get organizations of rules: %w", err)
+		}
+		names := make(map[int64]string, len(orgs))
+		for _, o := range orgs {
+			names[o.ID] = o.Name
+		}
+
+		for _, rule := range rules {
+			result = append(result, &ngmodels.AlertRuleWithOrg{AlertRule: rule, OrgName: names[rule.OrgID]})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// CountAlertRulesAcrossOrgs returns the number of alert rules of all organizations that match the query, regardless of
+// its page.
+func (st DBstore) CountAlertRulesAcrossOrgs(ctx context.Context, query *ngmodels.ListAlertRulesAcrossOrgsQuery) (int64, error) {
+	var count int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		count, err = filterRulesAcrossOrgs(sess.Session, query).Count()
+		return err
+	})
+	return count, err
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationAlertRulesAcrossOrgs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.NewNopLogger(),
+	}
+	ctx := context.Background()
+
+	var orgIDs []int64
+	for _, name := range []string{"first", "second"} {
+		o := org.Org{Name: name, Created: time.Now(), Updated: time.Now()}
+		require.NoError(t, sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Insert(&o)
+			return err
+		}))
+		orgIDs = append(orgIDs, o.ID)
+	}
+
+	uniqueID := models.WithUniqueID()
+	// gen returns a rule whose queries read the data sources, and an expression that is never associated
+	gen := func(orgID int64, datasourceUIDs ...string) models.AlertRule {
+		return *models.AlertRuleGen(uniqueID, models.WithOrgID(orgID), func(rule *models.AlertRule) {
+			rule.IntervalSeconds = 60
+			rule.For = time.Minute
+			rule.Data = nil
+			for _, uid := range datasourceUIDs {
+				q := models.GenerateAlertQuery()
+				q.DatasourceUID = uid
+				rule.Data = append(rule.Data, q)
+			}
+			rule.Data = append(rule.Data, models.CreateClassicConditionExpression("C", rule.Data[0].RefID, "last", "gt", 1))
+			rule.Condition = "C"
+		})()
+	}
+	rules := []models.AlertRule{
+		gen(orgIDs[0], "prometheus", "loki"),
+		gen(orgIDs[0], "loki", "loki"),
+		gen(orgIDs[1], "prometheus"),
+		gen(orgIDs[1], "tempo"),
+	}
+	_, err := store.InsertAlertRules(ctx, rules)
+	require.NoError(t, err)
+
+	list := func(t *testing.T, query models.ListAlertRulesAcrossOrgsQuery) ([]string, int64) {
+		t.Helper()
+		result, err := store.ListAlertRulesAcrossOrgs(ctx, &query)
+		require.NoError(t, err)
+		count, err := store.CountAlertRulesAcrossOrgs(ctx, &query)
+		require.NoError(t, err)
+		uids := make([]string, 0, len(result))
+		for _, r := range result {
+			require.Equal(t, map[int64]string{orgIDs[0]: "first", orgIDs[1]: "second"}[r.OrgID], r.OrgName)
+			uids = append(uids, r.UID)
+		}
+		return uids, count
+	}
+
+	t.Run("should list the rules of all organizations", func(t *testing.T) {
+		uids, count := list(t, models.ListAlertRulesAcrossOrgsQuery{})
+		require.EqualValues(t, 4, count)
+		require.ElementsMatch(t, []string{rules[0].UID, rules[1].UID, rules[2].UID, rules[3].UID}, uids)
+	})
+
+	t.Run("should filter the rules by data source", func(t *testing.T) {
+		uids, count := list(t, models.ListAlertRulesAcrossOrgsQuery{DatasourceUID: "loki"})
+		require.EqualValues(t, 2, count)
+		require.ElementsMatch(t, []string{rules[0].UID, rules[1].UID}, uids)
+
+		uids, count = list(t, models.ListAlertRulesAcrossOrgsQuery{DatasourceUID: "tempo"})
+		require.EqualValues(t, 1, count)
+		require.Equal(t, []string{rules[3].UID}, uids)
+
+		uids, count = list(t, models.ListAlertRulesAcrossOrgsQuery{DatasourceUID: "__expr__"})
+		require.Zero(t, count)
+		require.Empty(t, uids)
+	})
+
+	t.Run("should return a page of the rules ordered by organization", func(t *testing.T) {
+		uids, count := list(t, models.ListAlertRulesAcrossOrgsQuery{DatasourceUID: "prometheus", Limit: 1, Offset: 1})
+		require.EqualValues(t, 2, count)
+		require.Equal(t, []string{rules[2].UID}, uids)
+	})
+
+	t.Run("should associate the data sources of updated rules", func(t *testing.T) {
+		existing, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgIDs[1], UID: rules[3].UID})
+		require.NoError(t, err)
+		updated := models.CopyRule(existing)
+		updated.Data[0].DatasourceUID = "loki"
+		require.NoError(t, store.UpdateAlertRules(ctx, []models.UpdateRule{{Existing: existing, New: *updated}}))
+
+		uids, _ := list(t, models.ListAlertRulesAcrossOrgsQuery{DatasourceUID: "loki"})
+		require.ElementsMatch(t, []string{rules[0].UID, rules[1].UID, rules[3].UID}, uids)
+		_, count := list(t, models.ListAlertRulesAcrossOrgsQuery{DatasourceUID: "tempo"})
+		require.Zero(t, count)
+	})
+
+	t.Run("should delete the associations of deleted rules", func(t *testing.T) {
+		require.NoError(t, store.DeleteAlertRulesByUID(ctx, orgIDs[0], rules[1].UID))
+		uids, _ := list(t, models.ListAlertRulesAcrossOrgsQuery{DatasourceUID: "loki"})
+		require.ElementsMatch(t, []string{rules[0].UID, rules[3].UID}, uids)
+
+		var associations []models.AlertRuleDatasource
+		require.NoError(t, sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			return sess.Where("rule_uid = ?", rules[1].UID).Find(&associations)
+		}))
+		require.Empty(t, associations)
+	})
+}
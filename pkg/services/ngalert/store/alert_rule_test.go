@@ -2,8 +2,11 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,7 +15,9 @@ import (
 	"golang.org/x/exp/rand"
 
 	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/util"
 )
@@ -25,7 +30,7 @@ func TestIntegrationUpdateAlertRules(t *testing.T) {
 	store := &DBstore{
 		SQLStore: sqlStore,
 		Cfg: setting.UnifiedAlertingSettings{
-			BaseInterval: time.Duration(rand.Int63n(100)) * time.Second,
+			BaseInterval: time.Duration(rand.Int63n(100)+1) * time.Second,
 		},
 	}
 
@@ -66,6 +71,50 @@ func TestIntegrationUpdateAlertRules(t *testing.T) {
 
 		require.ErrorIs(t, err, ErrOptimisticLock)
 	})
+
+	t.Run("should apply only one of concurrent updates of the same version", func(t *testing.T) {
+		rule := createRule(t, store)
+		titles := []string{util.GenerateShortUID(), util.GenerateShortUID()}
+		errs := make([]error, len(titles))
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i, title := range titles {
+			newRule := models.CopyRule(rule)
+			newRule.Title = title
+			newRule.IntervalSeconds = int64(store.Cfg.BaseInterval.Seconds())
+			wg.Add(1)
+			go func(i int, newRule *models.AlertRule) {
+				defer wg.Done()
+				<-start
+				errs[i] = store.UpdateAlertRules(context.Background(), []models.UpdateRule{{
+					Existing: rule,
+					New:      *newRule,
+				}})
+			}(i, newRule)
+		}
+		close(start)
+		wg.Wait()
+
+		winner := -1
+		for i, err := range errs {
+			if err == nil {
+				require.Equal(t, -1, winner, "both concurrent updates succeeded")
+				winner = i
+				continue
+			}
+			require.ErrorIs(t, err, ErrOptimisticLock)
+		}
+		require.NotEqual(t, -1, winner, "none of the concurrent updates succeeded")
+
+		dbrule := &models.AlertRule{}
+		err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+			_, err := sess.Table(models.AlertRule{}).ID(rule.ID).Get(dbrule)
+			return err
+		})
+		require.NoError(t, err)
+		require.Equal(t, titles[winner], dbrule.Title)
+		require.Equal(t, rule.Version+1, dbrule.Version)
+	})
 }
 
 func withIntervalMatching(baseInterval time.Duration) func(*models.AlertRule) {
@@ -175,6 +224,320 @@ func TestIntegration_CountAlertRules(t *testing.T) {
 	}
 }
 
+func TestIntegration_GetAlertRulesForScheduling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: time.Duration(rand.Int63n(100)+1) * time.Second,
+		},
+		Logger: log.New("test-dbstore"),
+	}
+	active := createRule(t, store)
+	paused := createRule(t, store)
+	err := sqlStore.WithDbSession(context.Background(), func(sess *db.Session) error {
+		_, err := sess.Exec("UPDATE alert_rule SET is_paused = ? WHERE id = ?", true, paused.ID)
+		return err
+	})
+	require.NoError(t, err)
+
+	query := &models.GetAlertRulesForSchedulingQuery{}
+	require.NoError(t, store.GetAlertRulesForScheduling(context.Background(), query))
+	require.Len(t, query.ResultRules, 2)
+
+	result := make(map[string]*models.AlertRule, len(query.ResultRules))
+	for _, rule := range query.ResultRules {
+		result[rule.UID] = rule
+	}
+
+	t.Run("should return active rules with all fields", func(t *testing.T) {
+		rule := result[active.UID]
+		require.NotNil(t, rule)
+		require.False(t, rule.IsPaused)
+		require.Equal(t, active.Data, rule.Data)
+		require.Equal(t, active.Condition, rule.Condition)
+	})
+
+	t.Run("should return paused rules without queries", func(t *testing.T) {
+		rule := result[paused.UID]
+		require.NotNil(t, rule)
+		require.True(t, rule.IsPaused)
+		require.Equal(t, paused.GetKey(), rule.GetKey())
+		require.Equal(t, paused.GetGroupKey(), rule.GetGroupKey())
+		require.Equal(t, paused.Version, rule.Version)
+		require.Equal(t, paused.Title, rule.Title)
+		require.Empty(t, rule.Data)
+	})
+
+	t.Run("should return the rules of all pages", func(t *testing.T) {
+		pageSize := alertRulesForSchedulingPageSize
+		alertRulesForSchedulingPageSize = 2
+		t.Cleanup(func() {
+			alertRulesForSchedulingPageSize = pageSize
+		})
+		expected := map[string]struct{}{active.UID: {}, paused.UID: {}}
+		for i := 0; i < 4; i++ {
+			expected[createRule(t, store).UID] = struct{}{}
+		}
+
+		query := &models.GetAlertRulesForSchedulingQuery{}
+		require.NoError(t, store.GetAlertRulesForScheduling(context.Background(), query))
+		actual := make(map[string]struct{}, len(query.ResultRules))
+		for _, rule := range query.ResultRules {
+			actual[rule.UID] = struct{}{}
+		}
+		require.Len(t, query.ResultRules, len(expected))
+		require.Equal(t, expected, actual)
+	})
+}
+
+// BenchmarkGetAlertRulesForScheduling compares fetching the rules that the scheduler evaluates one by one with fetching them all at once.
+func BenchmarkGetAlertRulesForScheduling(b *testing.B) {
+	ctx := context.Background()
+	for _, count := range []int{1_000, 10_000} {
+		sqlStore := db.InitTestDB(b)
+		store := &DBstore{
+			SQLStore: sqlStore,
+			Cfg: setting.UnifiedAlertingSettings{
+				BaseInterval: 10 * time.Second,
+			},
+			Logger: log.New("test-dbstore"),
+		}
+		rules := models.GenerateAlertRules(count, models.AlertRuleGen(func(rule *models.AlertRule) {
+			rule.ID = 0
+			rule.OrgID = 1
+			rule.IsPaused = false
+		}))
+		err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			for start := 0; start < len(rules); start += 20 {
+				if _, err := sess.Table(models.AlertRule{}).Insert(rules[start : start+20]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(b, err)
+
+		b.Run(fmt.Sprintf("%d rules one by one", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				keys, err := store.GetAlertRulesKeysForScheduling(ctx)
+				require.NoError(b, err)
+				for _, key := range keys {
+					_, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: key.OrgID, UID: key.UID})
+					require.NoError(b, err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("%d rules at once", count), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				query := &models.GetAlertRulesForSchedulingQuery{}
+				require.NoError(b, store.GetAlertRulesForScheduling(ctx, query))
+				require.Len(b, query.ResultRules, count)
+			}
+		})
+	}
+}
+
+func BenchmarkGetAlertRulesForSchedulingIndex(b *testing.B) {
+	ctx := context.Background()
+	const count = 50_000
+	sqlStore := db.InitTestDB(b)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.New("test-dbstore"),
+	}
+	rules := models.GenerateAlertRules(count, models.AlertRuleGen(func(rule *models.AlertRule) {
+		rule.ID = 0
+		rule.OrgID = rand.Int63n(100) + 1
+		// one rule in a hundred is paused
+		rule.IsPaused = rand.Intn(100) == 0
+	}))
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		for start := 0; start < len(rules); start += 20 {
+			if _, err := sess.Table(models.AlertRule{}).Insert(rules[start : start+20]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(b, err)
+
+	index := &migrator.Index{Cols: []string{"is_paused", "id"}, Type: migrator.IndexType}
+	exec := func(sql string) {
+		require.NoError(b, sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec(sql)
+			return err
+		}))
+	}
+	run := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			query := &models.GetAlertRulesForSchedulingQuery{}
+			require.NoError(b, store.GetAlertRulesForScheduling(ctx, query))
+			require.Len(b, query.ResultRules, count)
+		}
+	}
+
+	exec(sqlStore.GetDialect().DropIndexSQL("alert_rule", index))
+	b.Run(fmt.Sprintf("%d rules without index", count), run)
+	exec(sqlStore.GetDialect().CreateIndexSQL("alert_rule", index))
+	b.Run(fmt.Sprintf("%d rules with index", count), run)
+}
+
+func TestIntegrationListAlertRuleSummaries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.New("test-dbstore"),
+	}
+	orgID := rand.Int63()
+	withInterval := func(rule *models.AlertRule) {
+		rule.IntervalSeconds = 60
+		rule.For = time.Minute
+	}
+	uniqueID := models.WithUniqueID()
+	rules := models.GenerateAlertRules(10, models.AlertRuleGen(uniqueID, models.WithOrgID(orgID), models.WithUniqueGroupIndex(), withInterval))
+	rules = append(rules, models.AlertRuleGen(uniqueID, models.WithOrgID(orgID+1), withInterval)())
+	ruleValues := make([]models.AlertRule, 0, len(rules))
+	for _, rule := range rules {
+		ruleValues = append(ruleValues, *rule)
+	}
+	_, err := store.InsertAlertRules(context.Background(), ruleValues)
+	require.NoError(t, err)
+
+	query := &models.ListAlertRulesQuery{OrgID: orgID}
+	expected, err := store.ListAlertRules(context.Background(), query)
+	require.NoError(t, err)
+	summaries, err := store.ListAlertRuleSummaries(context.Background(), query)
+	require.NoError(t, err)
+
+	require.Len(t, summaries, len(expected))
+	for i, rule := range expected {
+		require.Equal(t, &models.AlertRuleSummary{
+			ID:              rule.ID,
+			OrgID:           rule.OrgID,
+			UID:             rule.UID,
+			Title:           rule.Title,
+			NamespaceUID:    rule.NamespaceUID,
+			RuleGroup:       rule.RuleGroup,
+			RuleGroupIndex:  rule.RuleGroupIndex,
+			IntervalSeconds: rule.IntervalSeconds,
+			IsPaused:        rule.IsPaused,
+			Updated:         rule.Updated,
+		}, summaries[i])
+	}
+}
+
+func BenchmarkListAlertRuleSummaries(b *testing.B) {
+	ctx := context.Background()
+	const count = 1_000
+	sqlStore := db.InitTestDB(b)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.New("test-dbstore"),
+	}
+	// every rule has a condition of about 100 KB, like a query with a long list of series
+	largeModel := json.RawMessage(fmt.Sprintf(`{"expr":%q}`, strings.Repeat("up{instance=\"host\"} or ", 4_000)))
+	rules := models.GenerateAlertRules(count, models.AlertRuleGen(func(rule *models.AlertRule) {
+		rule.ID = 0
+		rule.OrgID = 1
+		rule.Data[0].Model = largeModel
+	}))
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		for start := 0; start < len(rules); start += 20 {
+			if _, err := sess.Table(models.AlertRule{}).Insert(rules[start : start+20]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(b, err)
+	query := &models.ListAlertRulesQuery{OrgID: 1}
+
+	b.Run(fmt.Sprintf("%d rules", count), func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			result, err := store.ListAlertRules(ctx, query)
+			require.NoError(b, err)
+			require.Len(b, result, count)
+		}
+	})
+	b.Run(fmt.Sprintf("%d rule summaries", count), func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			result, err := store.ListAlertRuleSummaries(ctx, query)
+			require.NoError(b, err)
+			require.Len(b, result, count)
+		}
+	})
+}
+
+func TestIntegrationListAlertRulesByTitle(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+	}
+	orgID := rand.Int63()
+	titles := []string{"Kafka lag", "kafka 50% lag", "kafka_lag", "kafkaXlag", "disk full!", "disk full"}
+	generate := models.AlertRuleGen(models.WithUniqueID(), func(rule *models.AlertRule) {
+		rule.OrgID = orgID
+		rule.IntervalSeconds = 60
+		rule.For = time.Minute
+	})
+	for _, title := range titles {
+		rule := generate()
+		rule.Title = title
+		_, err := store.InsertAlertRules(context.Background(), []models.AlertRule{*rule})
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		search   string
+		expected []string
+	}{
+		{search: "KAFKA", expected: []string{"Kafka lag", "kafka 50% lag", "kafka_lag", "kafkaXlag"}},
+		{search: "%", expected: []string{"kafka 50% lag"}},
+		{search: "50%", expected: []string{"kafka 50% lag"}},
+		{search: "_", expected: []string{"kafka_lag"}},
+		{search: "kafka_", expected: []string{"kafka_lag"}},
+		{search: "!", expected: []string{"disk full!"}},
+		{search: "[a-z]", expected: nil},
+		{search: "", expected: titles},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("search %q", tc.search), func(t *testing.T) {
+			rules, err := store.ListAlertRules(context.Background(), &models.ListAlertRulesQuery{OrgID: orgID, TitleSearch: tc.search})
+			require.NoError(t, err)
+			var actual []string
+			for _, rule := range rules {
+				actual = append(actual, rule.Title)
+			}
+			require.ElementsMatch(t, tc.expected, actual)
+		})
+	}
+}
+
 func createRule(t *testing.T, store *DBstore) *models.AlertRule {
 	rule := models.AlertRuleGen(withIntervalMatching(store.Cfg.BaseInterval), models.WithUniqueID())()
 	err := store.SQLStore.WithDbSession(context.Background(), func(sess *db.Session) error {
@@ -196,3 +559,65 @@ func createRule(t *testing.T, store *DBstore) *models.AlertRule {
 	require.NoError(t, err)
 	return rule
 }
+
+func TestIntegrationAlertRuleChangedEvents(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.NewNopLogger(),
+	}
+	orgID := rand.Int63()
+	var events []*models.AlertRuleChanged
+	sqlStore.Bus().AddEventListener(func(_ context.Context, e *models.AlertRuleChanged) error {
+		if e.OrgID == orgID {
+			events = append(events, e)
+		}
+		return nil
+	})
+	gen := models.AlertRuleGen(models.WithUniqueID(), func(rule *models.AlertRule) {
+		rule.OrgID = orgID
+		rule.IntervalSeconds = 60
+		rule.For = time.Minute
+	})
+
+	rule := gen()
+	ids, err := store.InsertAlertRules(context.Background(), []models.AlertRule{*rule})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, models.AlertRuleCreated, events[0].Action)
+	require.Equal(t, rule.UID, events[0].UID)
+	require.Equal(t, ids[rule.UID], events[0].ID)
+	require.False(t, events[0].Timestamp.IsZero())
+
+	stored, err := store.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+	require.NoError(t, err)
+	updated := models.CopyRule(stored)
+	updated.Title = util.GenerateShortUID()
+	require.NoError(t, store.UpdateAlertRules(context.Background(), []models.UpdateRule{{Existing: stored, New: *updated}}))
+	require.Len(t, events, 2)
+	require.Equal(t, models.AlertRuleUpdated, events[1].Action)
+	require.Equal(t, rule.UID, events[1].UID)
+
+	require.NoError(t, store.DeleteAlertRulesByUID(context.Background(), orgID, rule.UID))
+	require.Len(t, events, 3)
+	require.Equal(t, models.AlertRuleDeleted, events[2].Action)
+	require.Equal(t, rule.UID, events[2].UID)
+
+	t.Run("nothing is published if the transaction is rolled back", func(t *testing.T) {
+		err := store.InTransaction(context.Background(), func(ctx context.Context) error {
+			if _, err := store.InsertAlertRules(ctx, []models.AlertRule{*gen()}); err != nil {
+				return err
+			}
+			require.Len(t, events, 3)
+			return errors.New("rollback")
+		})
+		require.Error(t, err)
+		require.Len(t, events, 3)
+	})
+}
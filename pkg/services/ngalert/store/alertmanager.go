@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
-	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -66,16 +65,20 @@ func (st DBstore) SaveAlertmanagerConfiguration(ctx context.Context, cmd *models
 type SaveCallback func() error
 
 // SaveAlertmanagerConfigurationWithCallback creates an alertmanager configuration version and then executes a callback.
-// If the callback results in error it rolls back the transaction.
+// If the callback results in error it rolls back the transaction. The configuration is rejected with
+// models.ErrContactPointInUse if it removes a contact point that alert rules are associated with.
 func (st DBstore) SaveAlertmanagerConfigurationWithCallback(ctx context.Context, cmd *models.SaveAlertmanagerConfigurationCmd, callback SaveCallback) error {
 	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if err := checkContactPointsInUse(sess, cmd.OrgID, cmd.AlertmanagerConfiguration); err != nil {
+			return err
+		}
 		config := models.AlertConfiguration{
 			AlertmanagerConfiguration: cmd.AlertmanagerConfiguration,
 			ConfigurationHash:         fmt.Sprintf("%x", md5.Sum([]byte(cmd.AlertmanagerConfiguration))),
 			ConfigurationVersion:      cmd.ConfigurationVersion,
 			Default:                   cmd.Default,
 			OrgID:                     cmd.OrgID,
-			CreatedAt:                 time.Now().Unix(),
+			CreatedAt:                 st.now().Unix(),
 		}
 
 		// TODO: If we are more structured around how we seed configurations in the future, this can be a pure update instead of upsert. This should improve perf and code clarity.
@@ -108,15 +111,19 @@ func (st DBstore) SaveAlertmanagerConfigurationWithCallback(ctx context.Context,
 }
 
 // UpdateAlertmanagerConfiguration replaces an alertmanager configuration with optimistic locking. It assumes that an existing revision of the configuration exists in the store, and will return an error otherwise.
+// The configuration is rejected with models.ErrContactPointInUse if it removes a contact point that alert rules are associated with.
 func (st *DBstore) UpdateAlertmanagerConfiguration(ctx context.Context, cmd *models.SaveAlertmanagerConfigurationCmd) error {
 	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if err := checkContactPointsInUse(sess, cmd.OrgID, cmd.AlertmanagerConfiguration); err != nil {
+			return err
+		}
 		config := models.AlertConfiguration{
 			AlertmanagerConfiguration: cmd.AlertmanagerConfiguration,
 			ConfigurationHash:         fmt.Sprintf("%x", md5.Sum([]byte(cmd.AlertmanagerConfiguration))),
 			ConfigurationVersion:      cmd.ConfigurationVersion,
 			Default:                   cmd.Default,
 			OrgID:                     cmd.OrgID,
-			CreatedAt:                 time.Now().Unix(),
+			CreatedAt:                 st.now().Unix(),
 		}
 		rows, err := sess.Table("alert_configuration").
 			Where("org_id = ? AND configuration_hash = ?", config.OrgID, cmd.FetchedConfigurationHash).
@@ -142,7 +149,7 @@ func (st *DBstore) UpdateAlertmanagerConfiguration(ctx context.Context, cmd *mod
 // MarkConfigurationAsApplied sets the `last_applied` field of the last config with the given hash to the current UNIX timestamp.
 func (st *DBstore) MarkConfigurationAsApplied(ctx context.Context, cmd *models.MarkConfigurationAsAppliedCmd) error {
 	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
-		update := map[string]interface{}{"last_applied": time.Now().UTC().Unix()}
+		update := map[string]interface{}{"last_applied": st.now().UTC().Unix()}
 		rowsAffected, err := sess.Table("alert_configuration_history").
 			Desc("id").
 			Limit(1).
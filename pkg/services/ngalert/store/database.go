@@ -4,19 +4,19 @@ import (
 	"context"
 	"time"
 
+	"github.com/benbjohnson/clock"
+
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
-// TimeNow makes it possible to test usage of time
-var TimeNow = time.Now
-
 // AlertDefinitionMaxTitleLength is the maximum length of the alert definition title
 const AlertDefinitionMaxTitleLength = 190
 
@@ -39,6 +39,27 @@ type DBstore struct {
 	FolderService    folder.Service
 	AccessControl    accesscontrol.AccessControl
 	DashboardService dashboards.DashboardService
+	// RuleCache keeps the alert rules read by GetAlertRuleByUID and ListAlertRules in memory. If it is nil, the rules are
+	// always read from the database.
+	RuleCache *RuleCache
+	// Metrics records the duration and failures of the database operations. If it is nil, nothing is recorded.
+	Metrics *metrics.Store
+	// MaintenanceWindowCache keeps the maintenance windows read by GetMaintenanceWindows in memory. If it is nil, they are
+	// always read from the database.
+	MaintenanceWindowCache *OrgCache[[]*models.MaintenanceWindow]
+	// SilenceCache keeps the silences read by GetSilences in memory. If it is nil, they are always read from the database.
+	SilenceCache *OrgCache[[]*models.Silence]
+	// Clock tells the time of the changes, e.g. the time at which alert rules are updated. If it is nil, the wall clock is
+	// used.
+	Clock clock.Clock
+}
+
+// now returns the current time of the clock of the store.
+func (st DBstore) now() time.Time {
+	if st.Clock == nil {
+		return time.Now()
+	}
+	return st.Clock.Now()
 }
 
 func ProvideDBStore(
@@ -52,5 +73,6 @@ func ProvideDBStore(
 		FolderService:    folderService,
 		AccessControl:    access,
 		DashboardService: dashboards,
+		Clock:            clock.New(),
 	}
 }
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// alertRuleEvaluation is the row of the alert_rule_evaluation table.
+type alertRuleEvaluation struct {
+	ID           int64  `xorm:"pk autoincr 'id'"`
+	RuleOrgID    int64  `xorm:"rule_org_id"`
+	RuleUID      string `xorm:"rule_uid"`
+	EvaluatedAt  int64  `xorm:"evaluated_at"`
+	DurationMs   int64  `xorm:"duration_ms"`
+	State        string `xorm:"state"`
+	ErrorMessage string `xorm:"error_message"`
+}
+
+func (e alertRuleEvaluation) TableName() string {
+	return "alert_rule_evaluation"
+}
+
+// SaveAlertRuleEvaluations inserts or replaces the latest evaluations of alert rules. Rows are written in batches,
+// so that saving the evaluations of many rules takes few statements.
+func (st DBstore) SaveAlertRuleEvaluations(ctx context.Context, evaluations ...models.AlertRuleEvaluation) error {
+	keyNames := []string{"rule_org_id", "rule_uid"}
+	fieldNames := []string{"rule_org_id", "rule_uid", "evaluated_at", "duration_ms", "state", "error_message"}
+	fieldsPerRow := len(fieldNames)
+	maxRows := 20
+
+	for start := 0; start < len(evaluations); start += maxRows {
+		end := start + maxRows
+		if end > len(evaluations) {
+			end = len(evaluations)
+		}
+		batch := evaluations[start:end]
+		upsertSQL, err := st.SQLStore.GetDialect().UpsertMultipleSQL("alert_rule_evaluation", keyNames, fieldNames, len(batch))
+		if err != nil {
+			return err
+		}
+		args := make([]interface{}, 0, len(batch)*fieldsPerRow+1)
+		args = append(args, upsertSQL)
+		for _, e := range batch {
+			args = append(args, e.RuleOrgID, e.RuleUID, e.EvaluatedAt.Unix(), e.Duration.Milliseconds(), e.State, e.Error)
+		}
+		err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec(args...)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save alert rule evaluations: %w", err)
+		}
+	}
+	return nil
+}
+
+// alertRuleEvaluationsChunkSize is the maximum number of rule UIDs that GetAlertRuleEvaluations binds to one statement, so
+// that it stays below the limit of bound parameters of the databases. This is a variable so that the tests can override it.
+var alertRuleEvaluationsChunkSize = 500
+
+// GetAlertRuleEvaluations returns the latest evaluations of the alert rules of an organization, ordered by rule UID. The rules
+// of the query are read in chunks of alertRuleEvaluationsChunkSize. Rules that have not been evaluated yet are omitted.
+func (st DBstore) GetAlertRuleEvaluations(ctx context.Context, query *models.GetAlertRuleEvaluationsQuery) (result []*models.AlertRuleEvaluation, err error) {
+	var rows []alertRuleEvaluation
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		fetch := func(ruleUIDs []string) error {
+			q := sess.Table(alertRuleEvaluation{}).Where("rule_org_id = ?", query.OrgID)
+			if len(ruleUIDs) > 0 {
+				q = q.In("rule_uid", ruleUIDs)
+			}
+			var chunk []alertRuleEvaluation
+			if err := q.Asc("rule_uid").Find(&chunk); err != nil {
+				return err
+			}
+			rows = append(rows, chunk...)
+			return nil
+		}
+		if len(query.RuleUIDs) == 0 {
+			return fetch(nil)
+		}
+		for start := 0; start < len(query.RuleUIDs); start += alertRuleEvaluationsChunkSize {
+			end := start + alertRuleEvaluationsChunkSize
+			if end > len(query.RuleUIDs) {
+				end = len(query.RuleUIDs)
+			}
+			if err := fetch(query.RuleUIDs[start:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// every chunk is sorted, sorting the rows again orders them across the chunks
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].RuleUID < rows[j].RuleUID
+	})
+	result = make([]*models.AlertRuleEvaluation, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, &models.AlertRuleEvaluation{
+			RuleOrgID:   row.RuleOrgID,
+			RuleUID:     row.RuleUID,
+			EvaluatedAt: time.Unix(row.EvaluatedAt, 0),
+			Duration:    time.Duration(row.DurationMs) * time.Millisecond,
+			State:       row.State,
+			Error:       row.ErrorMessage,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestIntegrationAlertRuleEvaluations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{SQLStore: sqlStore, Logger: log.NewNopLogger()}
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	t.Run("should advance stored values on every save", func(t *testing.T) {
+		first := models.AlertRuleEvaluation{RuleOrgID: 1, RuleUID: "rule-1", EvaluatedAt: now, Duration: 150 * time.Millisecond, State: "Normal"}
+		require.NoError(t, store.SaveAlertRuleEvaluations(ctx, first))
+		second := models.AlertRuleEvaluation{RuleOrgID: 1, RuleUID: "rule-1", EvaluatedAt: now.Add(10 * time.Second), Duration: 2 * time.Second, State: "Error", Error: "failed to query data"}
+		require.NoError(t, store.SaveAlertRuleEvaluations(ctx, second))
+
+		result, err := store.GetAlertRuleEvaluations(ctx, &models.GetAlertRuleEvaluationsQuery{OrgID: 1, RuleUIDs: []string{"rule-1"}})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.True(t, second.EvaluatedAt.Equal(result[0].EvaluatedAt))
+		require.Equal(t, second.Duration, result[0].Duration)
+		require.Equal(t, "Error", result[0].State)
+		require.Equal(t, "failed to query data", result[0].Error)
+	})
+
+	t.Run("should save evaluations in batches", func(t *testing.T) {
+		evaluations := make([]models.AlertRuleEvaluation, 0, 45)
+		for i := 0; i < 45; i++ {
+			evaluations = append(evaluations, models.AlertRuleEvaluation{RuleOrgID: 2, RuleUID: fmt.Sprintf("rule-%02d", i), EvaluatedAt: now, State: "Alerting"})
+		}
+		require.NoError(t, store.SaveAlertRuleEvaluations(ctx, evaluations...))
+
+		result, err := store.GetAlertRuleEvaluations(ctx, &models.GetAlertRuleEvaluationsQuery{OrgID: 2})
+		require.NoError(t, err)
+		require.Len(t, result, 45)
+		require.Equal(t, "rule-44", result[44].RuleUID)
+	})
+
+	t.Run("should read the evaluations of many rules in chunks", func(t *testing.T) {
+		chunkSize := alertRuleEvaluationsChunkSize
+		alertRuleEvaluationsChunkSize = 2
+		t.Cleanup(func() {
+			alertRuleEvaluationsChunkSize = chunkSize
+		})
+		// the UIDs are not sorted, so that the chunks are not sorted either
+		ruleUIDs := []string{"rule-07", "rule-03", "rule-40", "missing", "rule-12", "rule-00"}
+		result, err := store.GetAlertRuleEvaluations(ctx, &models.GetAlertRuleEvaluationsQuery{OrgID: 2, RuleUIDs: ruleUIDs})
+		require.NoError(t, err)
+		uids := make([]string, 0, len(result))
+		for _, e := range result {
+			uids = append(uids, e.RuleUID)
+		}
+		require.Equal(t, []string{"rule-00", "rule-03", "rule-07", "rule-12", "rule-40"}, uids)
+	})
+
+	t.Run("should delete evaluations of deleted rules", func(t *testing.T) {
+		require.NoError(t, store.DeleteAlertRulesByUID(ctx, 2, "rule-00", "rule-01"))
+		result, err := store.GetAlertRuleEvaluations(ctx, &models.GetAlertRuleEvaluationsQuery{OrgID: 2})
+		require.NoError(t, err)
+		require.Len(t, result, 43)
+	})
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+// healthCheckTables are the tables that CheckHealth reads.
+var healthCheckTables = []string{"alert_rule", "alert_instance"}
+
+// CheckHealth returns an error if the tables of the alert rules and alert instances cannot be read. It reads at most one
+// row of each table.
+func (st DBstore) CheckHealth(ctx context.Context) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		for _, table := range healthCheckTables {
+			if _, err := sess.Table(table).Exist(); err != nil {
+				return fmt.Errorf("failed to read table %s: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+func TestIntegrationCheckHealth(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{SQLStore: sqlStore}
+	ctx := context.Background()
+
+	require.NoError(t, store.CheckHealth(ctx))
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, store.CheckHealth(cancelled), context.Canceled)
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// Heartbeat records that the scheduler instance with the given ID is alive at the given time.
+func (st DBstore) Heartbeat(ctx context.Context, instanceID string, at time.Time) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		heartbeat := models.SchedulerHeartbeat{InstanceID: instanceID, Updated: at.UTC()}
+		exists, err := sess.Where("instance_id = ?", instanceID).Exist(&models.SchedulerHeartbeat{})
+		if err != nil {
+			return fmt.Errorf("failed to check heartbeat: %w", err)
+		}
+		if exists {
+			if _, err := sess.Where("instance_id = ?", instanceID).Cols("updated").Update(&heartbeat); err != nil {
+				return fmt.Errorf("failed to update heartbeat: %w", err)
+			}
+			return nil
+		}
+		if _, err := sess.Insert(&heartbeat); err != nil {
+			return fmt.Errorf("failed to insert heartbeat: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetLiveInstances returns the IDs of the scheduler instances that sent a heartbeat at or after since, sorted in ascending order.
+func (st DBstore) GetLiveInstances(ctx context.Context, since time.Time) ([]string, error) {
+	var result []string
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		return sess.Table(&models.SchedulerHeartbeat{}).Where("updated >= ?", since.UTC()).Asc("instance_id").Cols("instance_id").Find(&result)
+	})
+	return result, err
+}
+
+// DeleteHeartbeat removes the heartbeat of the scheduler instance with the given ID, so that other instances can take over its rules immediately.
+func (st DBstore) DeleteHeartbeat(ctx context.Context, instanceID string) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Where("instance_id = ?", instanceID).Delete(&models.SchedulerHeartbeat{})
+		return err
+	})
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+)
+
+func TestIntegrationHeartbeat(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{SQLStore: sqlStore}
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	require.NoError(t, store.Heartbeat(ctx, "instance-b", now))
+	require.NoError(t, store.Heartbeat(ctx, "instance-a", now.Add(-time.Minute)))
+
+	t.Run("should return instances that sent a heartbeat since the given time", func(t *testing.T) {
+		live, err := store.GetLiveInstances(ctx, now.Add(-30*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, []string{"instance-b"}, live)
+	})
+
+	t.Run("should update existing heartbeat", func(t *testing.T) {
+		require.NoError(t, store.Heartbeat(ctx, "instance-a", now))
+		require.NoError(t, store.Heartbeat(ctx, "instance-a", now))
+		live, err := store.GetLiveInstances(ctx, now.Add(-30*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, []string{"instance-a", "instance-b"}, live)
+	})
+
+	t.Run("should delete heartbeat", func(t *testing.T) {
+		require.NoError(t, store.DeleteHeartbeat(ctx, "instance-b"))
+		live, err := store.GetLiveInstances(ctx, now.Add(-30*time.Second))
+		require.NoError(t, err)
+		require.Equal(t, []string{"instance-a"}, live)
+	})
+}
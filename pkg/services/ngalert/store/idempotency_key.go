@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// IdempotencyKeyMaxLength is the maximum length of an idempotency key
+const IdempotencyKeyMaxLength = 190
+
+// GetIdempotencyKey returns the idempotency key of the organization, or models.ErrIdempotencyKeyNotFound. The key is returned
+// even if it is expired.
+func (st DBstore) GetIdempotencyKey(ctx context.Context, orgID int64, key string) (*models.IdempotencyKey, error) {
+	var result models.IdempotencyKey
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("org_id = ? AND idempotency_key = ?", orgID, key).Get(&result)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrIdempotencyKeyNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SaveIdempotencyKey saves the idempotency key and replaces the key of the organization with the same value, if any. If a
+// concurrent transaction saved the same key first, models.ErrIdempotencyKeyConflict is returned.
+func (st DBstore) SaveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	return st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Where("org_id = ? AND idempotency_key = ?", key.OrgID, key.Key).Delete(&models.IdempotencyKey{}); err != nil {
+			return fmt.Errorf("failed to delete idempotency key: %w", err)
+		}
+		if _, err := sess.Insert(key); err != nil {
+			if st.SQLStore.GetDialect().IsUniqueConstraintViolation(err) {
+				return models.ErrIdempotencyKeyConflict
+			}
+			return fmt.Errorf("failed to insert idempotency key: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteIdempotencyKeys deletes the idempotency keys of all organizations that were created before the given time, and
+// returns the number of deleted keys.
+func (st DBstore) DeleteIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		deleted, err = sess.Where("created < ?", before.UTC()).Delete(&models.IdempotencyKey{})
+		return err
+	})
+	return deleted, err
+}
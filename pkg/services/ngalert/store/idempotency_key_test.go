@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestIntegrationIdempotencyKeys(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{SQLStore: sqlStore}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	old := &models.IdempotencyKey{OrgID: 1, Key: "old", RequestHash: "hash", RuleUID: "rule-1", Created: now.Add(-models.IdempotencyKeyTTL - time.Hour)}
+	recent := &models.IdempotencyKey{OrgID: 1, Key: "recent", RequestHash: "hash", RuleUID: "rule-2", Created: now}
+	otherOrg := &models.IdempotencyKey{OrgID: 2, Key: "recent", RequestHash: "other", RuleUID: "rule-3", Created: now}
+	for _, k := range []*models.IdempotencyKey{old, recent, otherOrg} {
+		require.NoError(t, store.SaveIdempotencyKey(ctx, k))
+	}
+
+	t.Run("should return the key of the organization", func(t *testing.T) {
+		k, err := store.GetIdempotencyKey(ctx, 2, "recent")
+		require.NoError(t, err)
+		require.Equal(t, "rule-3", k.RuleUID)
+		require.Equal(t, "other", k.RequestHash)
+		require.True(t, now.Equal(k.Created))
+
+		_, err = store.GetIdempotencyKey(ctx, 3, "recent")
+		require.ErrorIs(t, err, models.ErrIdempotencyKeyNotFound)
+	})
+
+	t.Run("should replace the key with the same value", func(t *testing.T) {
+		require.NoError(t, store.SaveIdempotencyKey(ctx, &models.IdempotencyKey{OrgID: 1, Key: "old", RequestHash: "new-hash", RuleUID: "rule-4", Created: now.Add(-models.IdempotencyKeyTTL - time.Hour)}))
+		k, err := store.GetIdempotencyKey(ctx, 1, "old")
+		require.NoError(t, err)
+		require.Equal(t, "rule-4", k.RuleUID)
+		require.Equal(t, "new-hash", k.RequestHash)
+	})
+
+	t.Run("should delete the keys created before the given time", func(t *testing.T) {
+		deleted, err := store.DeleteIdempotencyKeys(ctx, now.Add(-models.IdempotencyKeyTTL))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+
+		_, err = store.GetIdempotencyKey(ctx, 1, "old")
+		require.ErrorIs(t, err, models.ErrIdempotencyKeyNotFound)
+		_, err = store.GetIdempotencyKey(ctx, 1, "recent")
+		require.NoError(t, err)
+	})
+}
@@ -40,7 +40,7 @@ type ImageAdminStore interface {
 func (st DBstore) GetImage(ctx context.Context, token string) (*models.Image, error) {
 	var image models.Image
 	if err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
-		exists, err := sess.Where("token = ? AND expires_at > ?", token, TimeNow().UTC()).Get(&image)
+		exists, err := sess.Where("token = ? AND expires_at > ?", token, st.now().UTC()).Get(&image)
 		if err != nil {
 			return fmt.Errorf("failed to get image: %w", err)
 		} else if !exists {
@@ -57,7 +57,7 @@ func (st DBstore) GetImage(ctx context.Context, token string) (*models.Image, er
 func (st DBstore) GetImages(ctx context.Context, tokens []string) ([]models.Image, []string, error) {
 	var images []models.Image
 	if err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
-		return sess.In("token", tokens).Where("expires_at > ?", TimeNow().UTC()).Find(&images)
+		return sess.In("token", tokens).Where("expires_at > ?", st.now().UTC()).Find(&images)
 	}); err != nil {
 		return nil, nil, err
 	}
@@ -79,7 +79,7 @@ func (st DBstore) SaveImage(ctx context.Context, img *models.Image) error {
 				return fmt.Errorf("failed to create token: %w", err)
 			}
 			img.Token = token.String()
-			img.CreatedAt = TimeNow().UTC()
+			img.CreatedAt = st.now().UTC()
 			img.ExpiresAt = img.CreatedAt.Add(imageExpirationDuration)
 			if _, err := sess.Insert(img); err != nil {
 				return fmt.Errorf("failed to insert image: %w", err)
@@ -105,7 +105,7 @@ func (st DBstore) SaveImage(ctx context.Context, img *models.Image) error {
 func (st DBstore) DeleteExpiredImages(ctx context.Context) (int64, error) {
 	var n int64
 	if err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
-		rows, err := sess.Where("expires_at < ?", TimeNow().UTC()).Delete(&models.Image{})
+		rows, err := sess.Where("expires_at < ?", st.now().UTC()).Delete(&models.Image{})
 		if err != nil {
 			return fmt.Errorf("failed to delete expired images: %w", err)
 		}
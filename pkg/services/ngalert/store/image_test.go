@@ -5,12 +5,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
-	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests"
 )
 
@@ -19,13 +19,12 @@ func TestIntegrationSaveAndGetImage(t *testing.T) {
 		t.Skip("skipping integration test")
 	}
 
-	// our database schema uses second precision for timestamps
-	store.TimeNow = func() time.Time {
-		return time.Now().Truncate(time.Second)
-	}
-
 	ctx := context.Background()
 	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	// our database schema uses second precision for timestamps
+	clk := clock.NewMock()
+	clk.Set(time.Now().Truncate(time.Second))
+	dbstore.Clock = clk
 
 	// create an image with a path on disk
 	image1 := models.Image{Path: "example.png"}
@@ -33,7 +32,7 @@ func TestIntegrationSaveAndGetImage(t *testing.T) {
 	require.NotEqual(t, "", image1.Token)
 
 	// image should not have expired
-	assert.False(t, image1.HasExpired())
+	assert.False(t, image1.HasExpired(clk.Now()))
 	assert.Equal(t, image1.ExpiresAt, image1.CreatedAt.Add(24*time.Hour))
 
 	// should return the image with a path on disk
@@ -52,7 +51,7 @@ func TestIntegrationSaveAndGetImage(t *testing.T) {
 	require.NotEqual(t, "", image2.Token)
 
 	// image should not have expired
-	assert.False(t, image2.HasExpired())
+	assert.False(t, image2.HasExpired(clk.Now()))
 	assert.Equal(t, image2.ExpiresAt, image2.CreatedAt.Add(24*time.Hour))
 
 	// should return the image with a URL
@@ -61,7 +60,7 @@ func TestIntegrationSaveAndGetImage(t *testing.T) {
 	assert.Equal(t, image2, *result2)
 
 	// expired image should not be returned
-	image1.ExpiresAt = time.Now().Add(-time.Second)
+	image1.ExpiresAt = clk.Now().Add(-time.Second)
 	require.NoError(t, dbstore.SaveImage(ctx, &image1))
 	result1, err = dbstore.GetImage(ctx, image1.Token)
 	assert.EqualError(t, err, "image not found")
@@ -73,13 +72,12 @@ func TestIntegrationGetImages(t *testing.T) {
 		t.Skip("skipping integration test")
 	}
 
-	// our database schema uses second precision for timestamps
-	store.TimeNow = func() time.Time {
-		return time.Now().Truncate(time.Second)
-	}
-
 	ctx := context.Background()
 	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	// our database schema uses second precision for timestamps
+	clk := clock.NewMock()
+	clk.Set(time.Now().Truncate(time.Second))
+	dbstore.Clock = clk
 
 	// create an image with a path on disk
 	image1 := models.Image{Path: "example.png"}
@@ -142,7 +140,7 @@ func TestIntegrationGetImages(t *testing.T) {
 	assert.Len(t, images, 0)
 
 	// expired image should not be returned
-	image1.ExpiresAt = time.Now().Add(-time.Second)
+	image1.ExpiresAt = clk.Now().Add(-time.Second)
 	require.NoError(t, dbstore.SaveImage(ctx, &image1))
 	images, mismatched, err = dbstore.GetImages(ctx, []string{image1.Token, image2.Token})
 	assert.EqualError(t, err, "image not found")
@@ -155,13 +153,12 @@ func TestIntegrationDeleteExpiredImages(t *testing.T) {
 		t.Skip("skipping integration test")
 	}
 
-	// our database schema uses second precision for timestamps
-	store.TimeNow = func() time.Time {
-		return time.Now().Truncate(time.Second)
-	}
-
 	ctx := context.Background()
 	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	// our database schema uses second precision for timestamps
+	clk := clock.NewMock()
+	clk.Set(time.Now().Truncate(time.Second))
+	dbstore.Clock = clk
 
 	// create two images
 	image1 := models.Image{Path: "example.png"}
@@ -180,7 +177,7 @@ func TestIntegrationDeleteExpiredImages(t *testing.T) {
 		assert.True(t, ok)
 
 		// should delete expired image
-		image1.ExpiresAt = time.Now().Add(-time.Second)
+		image1.ExpiresAt = clk.Now().Add(-time.Second)
 		require.NoError(t, dbstore.SaveImage(ctx, &image1))
 		n, err := dbstore.DeleteExpiredImages(ctx)
 		require.NoError(t, err)
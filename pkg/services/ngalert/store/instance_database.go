@@ -5,33 +5,33 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
 )
 
 // ListAlertInstances is a handler for retrieving alert instances within specific organisation
 // based on various filters.
 func (st DBstore) ListAlertInstances(ctx context.Context, cmd *models.ListAlertInstancesQuery) (result []*models.AlertInstance, err error) {
+	defer st.observe("list_alert_instances", time.Now(), &err)
 	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
 		alertInstances := make([]*models.AlertInstance, 0)
 
 		s := strings.Builder{}
-		params := make([]interface{}, 0)
-
-		addToQuery := func(stmt string, p ...interface{}) {
-			s.WriteString(stmt)
-			params = append(params, p...)
-		}
-
-		addToQuery("SELECT * FROM alert_instance WHERE rule_org_id = ?", cmd.RuleOrgID)
-
-		if cmd.RuleUID != "" {
-			addToQuery(` AND rule_uid = ?`, cmd.RuleUID)
+		s.WriteString("SELECT * FROM alert_instance")
+		where, params, err := st.alertInstancesFilter(cmd)
+		if err != nil {
+			return err
 		}
-		if st.FeatureToggles.IsEnabled(featuremgmt.FlagAlertingNoNormalState) {
-			s.WriteString(fmt.Sprintf(" AND NOT (current_state = '%s' AND current_reason = '')", models.InstanceStateNormal))
+		s.WriteString(where)
+		if cmd.Limit > 0 {
+			s.WriteString(" ORDER BY rule_uid, ")
+			s.WriteString(instanceSeverityOrder())
+			s.WriteString(", labels, labels_hash")
+			s.WriteString(st.SQLStore.GetDialect().LimitOffset(cmd.Limit, cmd.Offset))
 		}
 		if err := sess.SQL(s.String(), params...).Find(&alertInstances); err != nil {
 			return err
@@ -43,97 +43,304 @@ func (st DBstore) ListAlertInstances(ctx context.Context, cmd *models.ListAlertI
 	return result, err
 }
 
-// SaveAlertInstances saves all the provided alert instances to the store.
-func (st DBstore) SaveAlertInstances(ctx context.Context, cmd ...models.AlertInstance) error {
-	if !st.FeatureToggles.IsEnabled(featuremgmt.FlagAlertingBigTransactions) {
-		// This mimics the replace code-path by calling SaveAlertInstance in a loop, with a transaction per call.
-		for _, c := range cmd {
-			err := st.SaveAlertInstance(ctx, c)
-			if err != nil {
+// ListAlertInstancesPage returns at most query.Limit alert instances of the organization that come after query.After in
+// the order of rule UID and labels hash. The pages are read with the primary key, so that reading a page does not depend
+// on the number of instances before it.
+func (st DBstore) ListAlertInstancesPage(ctx context.Context, query *models.ListAlertInstancesPageQuery) ([]*models.AlertInstance, error) {
+	result := make([]*models.AlertInstance, 0, query.Limit)
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		s := strings.Builder{}
+		params := []interface{}{query.RuleOrgID}
+		s.WriteString("SELECT * FROM alert_instance WHERE rule_org_id = ?")
+		if query.After != nil {
+			s.WriteString(" AND (rule_uid > ? OR (rule_uid = ? AND labels_hash > ?))")
+			params = append(params, query.After.RuleUID, query.After.RuleUID, query.After.LabelsHash)
+		}
+		s.WriteString(" ORDER BY rule_uid, labels_hash")
+		s.WriteString(st.SQLStore.GetDialect().Limit(query.Limit))
+		return sess.SQL(s.String(), params...).Find(&result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// instanceSeverityOrder returns an SQL expression that ranks the state of an instance by severity, so that instances can be
+// ordered from the most severe state.
+func instanceSeverityOrder() string {
+	s := strings.Builder{}
+	s.WriteString("CASE current_state")
+	for i, state := range models.InstanceStatesBySeverity {
+		s.WriteString(fmt.Sprintf(" WHEN '%s' THEN %d", state, i))
+	}
+	s.WriteString(fmt.Sprintf(" ELSE %d END", len(models.InstanceStatesBySeverity)))
+	return s.String()
+}
+
+// CountAlertInstances returns the number of alert instances that match the filters of the query. Limit and Offset are ignored.
+func (st DBstore) CountAlertInstances(ctx context.Context, cmd *models.ListAlertInstancesQuery) (int64, error) {
+	var count int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		where, params, err := st.alertInstancesFilter(cmd)
+		if err != nil {
+			return err
+		}
+		_, err = sess.SQL("SELECT COUNT(*) FROM alert_instance"+where, params...).Get(&count)
+		return err
+	})
+	return count, err
+}
+
+// alertInstanceStateSummariesChunkSize is the maximum number of rule UIDs that GetAlertInstanceStateSummaries binds to one
+// statement, so that it stays below the limit of bound parameters of the databases. This is a variable so that the tests
+// can override it.
+var alertInstanceStateSummariesChunkSize = 500
+
+// GetAlertInstanceStateSummaries returns the number of alert instances per state of the alert rules of an organization,
+// ordered by rule UID. The rules of the query are read in chunks of alertInstanceStateSummariesChunkSize. Rules that have
+// no instances are omitted.
+func (st DBstore) GetAlertInstanceStateSummaries(ctx context.Context, query *models.GetAlertInstanceStateSummariesQuery) ([]*models.AlertInstanceStateSummary, error) {
+	type stateCount struct {
+		RuleUID      string `xorm:"rule_uid"`
+		CurrentState string `xorm:"current_state"`
+		Instances    int64  `xorm:"instances"`
+	}
+	var rows []stateCount
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		fetch := func(ruleUIDs []string) error {
+			q := strings.Builder{}
+			params := []interface{}{query.OrgID}
+			q.WriteString("SELECT rule_uid, current_state, COUNT(*) AS instances FROM alert_instance WHERE rule_org_id = ?")
+			if len(ruleUIDs) > 0 {
+				q.WriteString(" AND rule_uid IN (?" + strings.Repeat(",?", len(ruleUIDs)-1) + ")")
+				for _, uid := range ruleUIDs {
+					params = append(params, uid)
+				}
+			}
+			q.WriteString(" GROUP BY rule_uid, current_state")
+			var chunk []stateCount
+			if err := sess.SQL(q.String(), params...).Find(&chunk); err != nil {
+				return err
+			}
+			rows = append(rows, chunk...)
+			return nil
+		}
+		if len(query.RuleUIDs) == 0 {
+			return fetch(nil)
+		}
+		for start := 0; start < len(query.RuleUIDs); start += alertInstanceStateSummariesChunkSize {
+			end := start + alertInstanceStateSummariesChunkSize
+			if end > len(query.RuleUIDs) {
+				end = len(query.RuleUIDs)
+			}
+			if err := fetch(query.RuleUIDs[start:end]); err != nil {
 				return err
 			}
 		}
 		return nil
-	} else {
-		//  Batches write into statements with `maxRows` instances per statements.
-		//  This makes sure we don't create  statements that are too long for some
-		//  databases to process. For example, SQLite has a limit of 999 variables
-		//  per write.
-		keyNames := []string{"rule_org_id", "rule_uid", "labels_hash"}
-		fieldNames := []string{
-			"rule_org_id", "rule_uid", "labels", "labels_hash", "current_state",
-			"current_reason", "current_state_since", "current_state_end", "last_eval_time",
+	})
+	// the rows of a rule are in a single chunk, sorting them groups them by rule across the chunks
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].RuleUID < rows[j].RuleUID
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*models.AlertInstanceStateSummary, 0)
+	for _, row := range rows {
+		if len(result) == 0 || result[len(result)-1].RuleUID != row.RuleUID {
+			result = append(result, &models.AlertInstanceStateSummary{
+				RuleUID: row.RuleUID,
+				Counts:  make(map[models.InstanceStateType]int64),
+			})
 		}
-		fieldsPerRow := len(fieldNames)
-		maxRows := 20
-		maxArgs := maxRows * fieldsPerRow
+		result[len(result)-1].Counts[models.InstanceStateType(row.CurrentState)] = row.Instances
+	}
+	return result, nil
+}
 
-		bigUpsertSQL, err := st.SQLStore.GetDialect().UpsertMultipleSQL(
-			"alert_instance", keyNames, fieldNames, maxRows)
+// alertInstancesFilter returns the WHERE clause and its parameters for the filters of the query.
+// Labels are matched as case-sensitive substrings of the stored labels, which are JSON arrays of [name, value] tuples, so
+// that the labels do not need to be decoded. A tuple cannot match across two labels because quotes in names and values are escaped.
+func (st DBstore) alertInstancesFilter(cmd *models.ListAlertInstancesQuery) (string, []interface{}, error) {
+	s := strings.Builder{}
+	params := make([]interface{}, 0)
+
+	addToQuery := func(stmt string, p ...interface{}) {
+		s.WriteString(stmt)
+		params = append(params, p...)
+	}
+
+	addToQuery(" WHERE rule_org_id = ?", cmd.RuleOrgID)
+
+	if cmd.RuleUID != "" {
+		addToQuery(` AND rule_uid = ?`, cmd.RuleUID)
+	}
+	if len(cmd.States) > 0 {
+		s.WriteString(" AND current_state IN (?" + strings.Repeat(",?", len(cmd.States)-1) + ")")
+		for _, state := range cmd.States {
+			params = append(params, state)
+		}
+	}
+	names := make([]string, 0, len(cmd.Labels))
+	for name := range cmd.Labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tuple, err := models.LabelTupleString(name, cmd.Labels[name])
 		if err != nil {
-			return err
+			return "", nil, err
 		}
+		addToQuery(" AND "+containsSQL(st.SQLStore.GetDialect(), "labels"), tuple)
+	}
+	if st.FeatureToggles.IsEnabled(featuremgmt.FlagAlertingNoNormalState) {
+		s.WriteString(fmt.Sprintf(" AND NOT (current_state = '%s' AND current_reason = '')", models.InstanceStateNormal))
+	}
+	return s.String(), params, nil
+}
 
-		// Args contains the SQL statement, and the values to fill into the SQL statement.
-		args := make([]interface{}, 0, maxArgs)
-		args = append(args, bigUpsertSQL)
-		values := func(a []interface{}) int {
-			return len(a) - 1
+// likeEscaper escapes the wildcards of LIKE patterns with the escape character '!'.
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// containsSQL returns a condition that the column contains the string of the parameter. Unlike LIKE, which ignores the
+// case of letters on SQLite and with the default collation of MySQL, the condition is case-sensitive on every database.
+func containsSQL(dialect migrator.Dialect, column string) string {
+	switch dialect.DriverName() {
+	case migrator.MySQL:
+		return "INSTR(CAST(" + column + " AS BINARY), ?) > 0"
+	case migrator.Postgres:
+		return "STRPOS(" + column + ", ?) > 0"
+	default:
+		return "INSTR(" + column + ", ?) > 0"
+	}
+}
+
+// GetAlertInstance returns the alert instance of a rule with the given labels hash.
+// Returns models.ErrAlertInstanceNotFound if the instance does not exist.
+func (st DBstore) GetAlertInstance(ctx context.Context, key models.AlertInstanceKey) (*models.AlertInstance, error) {
+	var result *models.AlertInstance
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		instance := models.AlertInstance{}
+		has, err := sess.SQL("SELECT * FROM alert_instance WHERE rule_org_id = ? AND rule_uid = ? AND labels_hash = ?", key.RuleOrgID, key.RuleUID, key.LabelsHash).Get(&instance)
+		if err != nil {
+			return err
 		}
+		if !has {
+			return models.ErrAlertInstanceNotFound
+		}
+		result = &instance
+		return nil
+	})
+	return result, err
+}
 
-		// Generate batches of `maxRows` and write the statements when full.
-		for _, alertInstance := range cmd {
-			labelTupleJSON, err := alertInstance.Labels.StringKey()
+// defaultInstanceSaveBatchSize is used if the batch size is not configured.
+const defaultInstanceSaveBatchSize = 100
+
+// maxStatementParams returns the maximum number of parameters that the database binds to a single statement.
+func maxStatementParams(dialect migrator.Dialect) int {
+	switch dialect.DriverName() {
+	case migrator.SQLite:
+		return 999
+	case migrator.MySQL, migrator.Postgres:
+		return 65535
+	default:
+		// the limit of MSSQL, which is the lowest of the other databases
+		return 2100
+	}
+}
+
+// statementBatchSize returns the configured batch size, reduced so that a batch of rows with paramsPerRow parameters
+// stays under the limit of parameters of the database.
+func (st DBstore) statementBatchSize(paramsPerRow int) int {
+	batchSize := st.Cfg.InstanceSaveBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultInstanceSaveBatchSize
+	}
+	if max := maxStatementParams(st.SQLStore.GetDialect()) / paramsPerRow; batchSize > max {
+		batchSize = max
+	}
+	return batchSize
+}
+
+// SaveAlertInstances saves all the provided alert instances to the store. Instances are upserted in batches of at most
+// Cfg.InstanceSaveBatchSize instances with a single multi-row statement per batch, and smaller batches if the statement
+// would exceed the limit of parameters of the database. Every batch is written in its own transaction, so if writing a
+// batch fails, none of its instances are saved but the batches written before are kept.
+func (st DBstore) SaveAlertInstances(ctx context.Context, cmd ...models.AlertInstance) (err error) {
+	defer st.observe("save_alert_instances", time.Now(), &err)
+	keyNames := []string{"rule_org_id", "rule_uid", "labels_hash"}
+	fieldNames := []string{
+		"rule_org_id", "rule_uid", "labels", "labels_hash", "current_state",
+		"current_reason", "current_state_since", "current_state_end", "last_eval_time", "resolved_at", "state_values",
+	}
+	batchSize := st.statementBatchSize(len(fieldNames))
+
+	// args contains the SQL statement, and the values to fill into the SQL statement.
+	args := make([]interface{}, 0, batchSize*len(fieldNames)+1)
+	var upsertSQL string
+	for start := 0; start < len(cmd); start += batchSize {
+		end := start + batchSize
+		if end > len(cmd) {
+			end = len(cmd)
+		}
+		batch := cmd[start:end]
+		// the statement is the same for all batches but the last one
+		if upsertSQL == "" || len(batch) < batchSize {
+			var err error
+			upsertSQL, err = st.SQLStore.GetDialect().UpsertMultipleSQL("alert_instance", keyNames, fieldNames, len(batch))
 			if err != nil {
 				return err
 			}
+		}
 
+		args = append(args[:0], upsertSQL)
+		for _, alertInstance := range batch {
 			if err := models.ValidateAlertInstance(alertInstance); err != nil {
 				return err
 			}
-
-			args = append(args,
-				alertInstance.RuleOrgID, alertInstance.RuleUID, labelTupleJSON, alertInstance.LabelsHash,
-				alertInstance.CurrentState, alertInstance.CurrentReason, alertInstance.CurrentStateSince.Unix(),
-				alertInstance.CurrentStateEnd.Unix(), alertInstance.LastEvalTime.Unix())
-
-			// If we've reached the maximum batch size, write to the database.
-			if values(args) >= maxArgs {
-				err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
-					_, err := sess.Exec(args...)
-					return err
-				})
-				if err != nil {
-					return fmt.Errorf("failed to save alert instances: %w", err)
-				}
-
-				// Reset args so we can re-use the allocated interface pointers.
-				args = args[:1]
-			}
-		}
-
-		// Write the final batch of up to maxRows in size.
-		if values(args) != 0 && values(args)%fieldsPerRow == 0 {
-			upsertSQL, err := st.SQLStore.GetDialect().UpsertMultipleSQL(
-				"alert_instance", keyNames, fieldNames, values(args)/fieldsPerRow)
+			labelTupleJSON, err := alertInstance.Labels.StringKey()
 			if err != nil {
 				return err
 			}
-
-			args[0] = upsertSQL
-			err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
-				_, err := sess.Exec(args...)
-				return err
-			})
+			values, err := stateValuesToDB(alertInstance.Values)
 			if err != nil {
-				return fmt.Errorf("failed to save alert instances: %w", err)
+				return err
 			}
-		} else if values(args) != 0 {
-			return fmt.Errorf("failed to upsert alert instances. Last statements had %v fields, which is not a multiple of the number of fields, %v", len(args), fieldsPerRow)
+			args = append(args,
+				alertInstance.RuleOrgID, alertInstance.RuleUID, labelTupleJSON, alertInstance.LabelsHash,
+				alertInstance.CurrentState, alertInstance.CurrentReason, alertInstance.CurrentStateSince.Unix(),
+				alertInstance.CurrentStateEnd.Unix(), alertInstance.LastEvalTime.Unix(), resolvedAtToDB(alertInstance.ResolvedAt), values)
 		}
 
+		err := st.withTransactionRetryOnLocked(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec(args...)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save alert instances: %w", err)
+		}
+	}
+	return nil
+}
+
+// stateValuesToDB returns the values encoded for the state_values column, or nil if there are none.
+func stateValuesToDB(values models.InstanceValues) (interface{}, error) {
+	b, err := values.ToDB()
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// resolvedAtToDB returns the time for the resolved_at column, or nil if the instance is not resolved.
+func resolvedAtToDB(resolvedAt time.Time) interface{} {
+	if resolvedAt.IsZero() {
 		return nil
 	}
+	return resolvedAt.Unix()
 }
 
 // SaveAlertInstance is a handler for saving a new alert instance.
@@ -147,12 +354,16 @@ func (st DBstore) SaveAlertInstance(ctx context.Context, alertInstance models.Al
 		if err != nil {
 			return err
 		}
-		params := append(make([]interface{}, 0), alertInstance.RuleOrgID, alertInstance.RuleUID, labelTupleJSON, alertInstance.LabelsHash, alertInstance.CurrentState, alertInstance.CurrentReason, alertInstance.CurrentStateSince.Unix(), alertInstance.CurrentStateEnd.Unix(), alertInstance.LastEvalTime.Unix())
+		values, err := stateValuesToDB(alertInstance.Values)
+		if err != nil {
+			return err
+		}
+		params := append(make([]interface{}, 0), alertInstance.RuleOrgID, alertInstance.RuleUID, labelTupleJSON, alertInstance.LabelsHash, alertInstance.CurrentState, alertInstance.CurrentReason, alertInstance.CurrentStateSince.Unix(), alertInstance.CurrentStateEnd.Unix(), alertInstance.LastEvalTime.Unix(), resolvedAtToDB(alertInstance.ResolvedAt), values)
 
 		upsertSQL := st.SQLStore.GetDialect().UpsertSQL(
 			"alert_instance",
 			[]string{"rule_org_id", "rule_uid", "labels_hash"},
-			[]string{"rule_org_id", "rule_uid", "labels", "labels_hash", "current_state", "current_reason", "current_state_since", "current_state_end", "last_eval_time"})
+			[]string{"rule_org_id", "rule_uid", "labels", "labels_hash", "current_state", "current_reason", "current_state_since", "current_state_end", "last_eval_time", "resolved_at", "state_values"})
 		_, err = sess.SQL(upsertSQL, params...).Query()
 		if err != nil {
 			return err
@@ -162,6 +373,25 @@ func (st DBstore) SaveAlertInstance(ctx context.Context, alertInstance models.Al
 	})
 }
 
+// DeleteOrphanedAlertInstances deletes at most limit alert instances whose alert rule does not exist anymore,
+// and returns the number of deleted instances.
+func (st DBstore) DeleteOrphanedAlertInstances(ctx context.Context, limit int) (int64, error) {
+	keys := make([]models.AlertInstanceKey, 0)
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := "SELECT ai.rule_org_id, ai.rule_uid, ai.labels_hash FROM alert_instance AS ai " +
+			"LEFT JOIN alert_rule AS ar ON ai.rule_org_id = ar.org_id AND ai.rule_uid = ar.uid " +
+			"WHERE ar.uid IS NULL" + st.SQLStore.GetDialect().Limit(int64(limit))
+		return sess.SQL(q).Find(&keys)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := st.DeleteAlertInstances(ctx, keys...); err != nil {
+		return 0, err
+	}
+	return int64(len(keys)), nil
+}
+
 func (st DBstore) FetchOrgIds(ctx context.Context) ([]int64, error) {
 	orgIds := []int64{}
 
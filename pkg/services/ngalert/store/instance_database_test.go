@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/tests"
@@ -19,7 +21,6 @@ func BenchmarkAlertInstanceOperations(b *testing.B) {
 	b.StopTimer()
 	ctx := context.Background()
 	_, dbstore := tests.SetupTestEnv(b, baseIntervalSeconds)
-	dbstore.FeatureToggles = featuremgmt.WithFeatures(featuremgmt.FlagAlertingBigTransactions)
 
 	const mainOrgID int64 = 1
 
@@ -47,9 +48,22 @@ func BenchmarkAlertInstanceOperations(b *testing.B) {
 	}
 
 	b.StartTimer()
-	for i := 0; i < b.N; i++ {
-		_ = dbstore.SaveAlertInstances(ctx, instances...)
-		_ = dbstore.DeleteAlertInstances(ctx, keys...)
+	b.Run("per row", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, instance := range instances {
+				_ = dbstore.SaveAlertInstance(ctx, instance)
+			}
+			_ = dbstore.DeleteAlertInstances(ctx, keys...)
+		}
+	})
+	for _, batchSize := range []int{20, 100} {
+		b.Run(fmt.Sprintf("batches of %d", batchSize), func(b *testing.B) {
+			dbstore.Cfg.InstanceSaveBatchSize = batchSize
+			for i := 0; i < b.N; i++ {
+				_ = dbstore.SaveAlertInstances(ctx, instances...)
+				_ = dbstore.DeleteAlertInstances(ctx, keys...)
+			}
+		})
 	}
 }
 
@@ -87,8 +101,9 @@ func TestIntegrationAlertInstanceBulkWrite(t *testing.T) {
 		}
 	}
 
-	for _, bigStmts := range []bool{false, true} {
-		dbstore.FeatureToggles = featuremgmt.WithFeatures([]interface{}{featuremgmt.FlagAlertingBigTransactions, bigStmts})
+	// batches of 1000 instances exceed the limit of parameters of SQLite and MSSQL and are split
+	for _, batchSize := range []int{1, 20, 100, 1000} {
+		dbstore.Cfg.InstanceSaveBatchSize = batchSize
 		t.Log("Saving")
 		err := dbstore.SaveAlertInstances(ctx, instances...)
 		require.NoError(t, err)
@@ -357,4 +372,404 @@ func TestIntegrationAlertInstanceOperations(t *testing.T) {
 		require.Equal(t, instance2.Labels, alerts[0].Labels)
 		require.Equal(t, instance2.CurrentState, alerts[0].CurrentState)
 	})
+	t.Run("get and upsert instance in place", func(t *testing.T) {
+		labels := models.InstanceLabels{"severity": "critical", "instance": "host-1"}
+		_, hash, err := labels.StringAndHash()
+		require.NoError(t, err)
+		key := models.AlertInstanceKey{RuleOrgID: alertRule3.OrgID, RuleUID: alertRule3.UID, LabelsHash: hash}
+
+		_, err = dbstore.GetAlertInstance(ctx, key)
+		require.ErrorIs(t, err, models.ErrAlertInstanceNotFound)
+
+		since := time.Unix(1679641200, 0)
+		require.NoError(t, dbstore.SaveAlertInstance(ctx, models.AlertInstance{
+			AlertInstanceKey:  key,
+			Labels:            labels,
+			CurrentState:      models.InstanceStatePending,
+			CurrentStateSince: since,
+			LastEvalTime:      since,
+		}))
+		require.NoError(t, dbstore.SaveAlertInstance(ctx, models.AlertInstance{
+			AlertInstanceKey:  key,
+			Labels:            models.InstanceLabels{"instance": "host-1", "severity": "critical"},
+			CurrentState:      models.InstanceStateFiring,
+			CurrentStateSince: since.Add(time.Minute),
+			LastEvalTime:      since.Add(time.Minute),
+		}))
+
+		instance, err := dbstore.GetAlertInstance(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, labels, instance.Labels)
+		require.Equal(t, models.InstanceStateFiring, instance.CurrentState)
+		require.Equal(t, since.Add(time.Minute).Unix(), instance.CurrentStateSince.Unix())
+		require.Equal(t, since.Add(time.Minute).Unix(), instance.LastEvalTime.Unix())
+
+		alerts, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: alertRule3.OrgID, RuleUID: alertRule3.UID})
+		require.NoError(t, err)
+		containsHash(t, alerts, hash)
+		count := 0
+		for _, a := range alerts {
+			if a.LabelsHash == hash {
+				count++
+			}
+		}
+		require.Equal(t, 1, count)
+	})
+}
+
+func TestIntegrationListAlertInstancesFilters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	rule1 := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+	rule2 := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 2)
+
+	// every other instance of the first rule is firing
+	instances := make([]models.AlertInstance, 0, 12)
+	for _, rule := range []*models.AlertRule{rule1, rule2} {
+		for i := 0; i < 6; i++ {
+			labels := models.InstanceLabels{"test": fmt.Sprint(i)}
+			_, hash, _ := labels.StringAndHash()
+			state := models.InstanceStateNormal
+			if i%2 == 0 || rule == rule2 {
+				state = models.InstanceStateFiring
+			}
+			instances = append(instances, models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash},
+				Labels:           labels,
+				CurrentState:     state,
+			})
+		}
+	}
+	require.NoError(t, dbstore.SaveAlertInstances(ctx, instances...))
+
+	t.Run("should return only instances of the organization", func(t *testing.T) {
+		result, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule2.OrgID, RuleUID: rule1.UID})
+		require.NoError(t, err)
+		require.Empty(t, result)
+
+		count, err := dbstore.CountAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule2.OrgID, RuleUID: rule1.UID})
+		require.NoError(t, err)
+		require.Zero(t, count)
+
+		result, err = dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule2.OrgID})
+		require.NoError(t, err)
+		require.Len(t, result, 6)
+		for _, instance := range result {
+			require.Equal(t, rule2.OrgID, instance.RuleOrgID)
+			require.Equal(t, rule2.UID, instance.RuleUID)
+		}
+	})
+
+	t.Run("should filter by state before the page is applied", func(t *testing.T) {
+		query := &models.ListAlertInstancesQuery{
+			RuleOrgID: rule1.OrgID,
+			RuleUID:   rule1.UID,
+			States:    []models.InstanceStateType{models.InstanceStateFiring},
+			Limit:     2,
+		}
+		count, err := dbstore.CountAlertInstances(ctx, query)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, count)
+
+		// if the state was filtered after the page is read, pages would be incomplete
+		seen := map[string]struct{}{}
+		for offset, expected := range map[int64]int{0: 2, 2: 1, 4: 0} {
+			query.Offset = offset
+			result, err := dbstore.ListAlertInstances(ctx, query)
+			require.NoError(t, err)
+			require.Len(t, result, expected, "offset %d", offset)
+			for _, instance := range result {
+				require.Equal(t, models.InstanceStateFiring, instance.CurrentState)
+				seen[instance.LabelsHash] = struct{}{}
+			}
+		}
+		require.Len(t, seen, 3)
+	})
+
+	t.Run("should order a page by severity and labels", func(t *testing.T) {
+		result, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{
+			RuleOrgID: rule1.OrgID,
+			RuleUID:   rule1.UID,
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		order := make([]string, 0, len(result))
+		for _, instance := range result {
+			order = append(order, fmt.Sprintf("%s %s", instance.CurrentState, instance.Labels["test"]))
+		}
+		require.Equal(t, []string{"Alerting 0", "Alerting 2", "Alerting 4", "Normal 1", "Normal 3", "Normal 5"}, order)
+	})
+
+	t.Run("should return instances in any of the states", func(t *testing.T) {
+		result, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{
+			RuleOrgID: rule1.OrgID,
+			RuleUID:   rule1.UID,
+			States:    []models.InstanceStateType{models.InstanceStateFiring, models.InstanceStateNormal},
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 6)
+	})
+}
+
+func TestIntegrationListAlertInstancesLabelMatchers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	rule := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+
+	labelSets := []models.InstanceLabels{
+		{"cluster": "eu-west-1", "instance": "a"},
+		{"cluster": "eu-west-1", "instance": "b"},
+		{"cluster": "us-east-1", "instance": "a"},
+		{"cluster": "eu%west_1", "instance": "c"},
+		{"instance": "eu-west-1"},
+		{"cluster": "EU-WEST-1", "instance": "d"},
+	}
+	instances := make([]models.AlertInstance, 0, len(labelSets))
+	for _, labels := range labelSets {
+		_, hash, _ := labels.StringAndHash()
+		instances = append(instances, models.AlertInstance{
+			AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash},
+			Labels:           labels,
+			CurrentState:     models.InstanceStateFiring,
+		})
+	}
+	require.NoError(t, dbstore.SaveAlertInstances(ctx, instances...))
+
+	testCases := []struct {
+		name     string
+		matchers map[string]string
+		expected []models.InstanceLabels
+	}{
+		{
+			name:     "single matcher",
+			matchers: map[string]string{"cluster": "eu-west-1"},
+			expected: []models.InstanceLabels{labelSets[0], labelSets[1]},
+		},
+		{
+			name:     "matchers are ANDed",
+			matchers: map[string]string{"cluster": "eu-west-1", "instance": "a"},
+			expected: []models.InstanceLabels{labelSets[0]},
+		},
+		{
+			name:     "label that does not exist",
+			matchers: map[string]string{"region": "eu-west-1"},
+			expected: nil,
+		},
+		{
+			name:     "one of the labels does not exist",
+			matchers: map[string]string{"cluster": "eu-west-1", "region": "eu"},
+			expected: nil,
+		},
+		{
+			name:     "wildcards are matched literally",
+			matchers: map[string]string{"cluster": "eu%west_1"},
+			expected: []models.InstanceLabels{labelSets[3]},
+		},
+		{
+			name:     "value is matched case-sensitively",
+			matchers: map[string]string{"cluster": "EU-WEST-1"},
+			expected: []models.InstanceLabels{labelSets[5]},
+		},
+		{
+			name:     "value is not matched partially",
+			matchers: map[string]string{"cluster": "eu-west"},
+			expected: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID, Labels: tc.matchers}
+			result, err := dbstore.ListAlertInstances(ctx, query)
+			require.NoError(t, err)
+			labels := make([]models.InstanceLabels, 0, len(result))
+			for _, instance := range result {
+				labels = append(labels, instance.Labels)
+			}
+			require.ElementsMatch(t, tc.expected, labels)
+
+			count, err := dbstore.CountAlertInstances(ctx, query)
+			require.NoError(t, err)
+			require.EqualValues(t, len(tc.expected), count)
+		})
+	}
+}
+
+func TestIntegrationSaveAlertInstancesFailedBatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	dbstore.Cfg.InstanceSaveBatchSize = 2
+
+	rule := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+	instances := make([]models.AlertInstance, 0, 5)
+	for i := 0; i < 5; i++ {
+		labels := models.InstanceLabels{"test": fmt.Sprint(i)}
+		_, hash, _ := labels.StringAndHash()
+		instances = append(instances, models.AlertInstance{
+			AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash},
+			Labels:           labels,
+			CurrentState:     models.InstanceStateFiring,
+		})
+	}
+	// the second batch contains an invalid instance
+	instances[3].CurrentState = "invalid"
+
+	require.Error(t, dbstore.SaveAlertInstances(ctx, instances...))
+
+	saved, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+	require.NoError(t, err)
+	hashes := make([]string, 0, len(saved))
+	for _, instance := range saved {
+		hashes = append(hashes, instance.LabelsHash)
+	}
+	require.ElementsMatch(t, []string{instances[0].LabelsHash, instances[1].LabelsHash}, hashes)
+}
+
+func TestIntegrationDeleteOrphanedAlertInstances(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	existing := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+	deleted := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 2)
+
+	instances := make([]models.AlertInstance, 0, 10)
+	for _, rule := range []*models.AlertRule{existing, deleted} {
+		for i := 0; i < 5; i++ {
+			labels := models.InstanceLabels{"test": fmt.Sprint(i)}
+			_, hash, _ := labels.StringAndHash()
+			instances = append(instances, models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash},
+				Labels:           labels,
+				CurrentState:     models.InstanceStateFiring,
+			})
+		}
+	}
+	require.NoError(t, dbstore.SaveAlertInstances(ctx, instances...))
+	// delete the rule directly to leave its instances behind
+	err := dbstore.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Exec("DELETE FROM alert_rule WHERE org_id = ? AND uid = ?", deleted.OrgID, deleted.UID)
+		return err
+	})
+	require.NoError(t, err)
+
+	count := func(rule *models.AlertRule) int64 {
+		c, err := dbstore.CountAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		return c
+	}
+
+	n, err := dbstore.DeleteOrphanedAlertInstances(ctx, 3)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, n)
+	require.EqualValues(t, 2, count(deleted))
+	require.EqualValues(t, 5, count(existing))
+
+	n, err = dbstore.DeleteOrphanedAlertInstances(ctx, 3)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, n)
+	require.Zero(t, count(deleted))
+	require.EqualValues(t, 5, count(existing))
+
+	n, err = dbstore.DeleteOrphanedAlertInstances(ctx, 3)
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestIntegrationGetAlertInstanceStateSummaries(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+
+	mixed := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+	normal := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+	empty := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+	otherOrg := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 2)
+
+	instances := make([]models.AlertInstance, 0)
+	add := func(rule *models.AlertRule, states ...models.InstanceStateType) {
+		for i, state := range states {
+			labels := models.InstanceLabels{"test": fmt.Sprint(i)}
+			_, hash, _ := labels.StringAndHash()
+			instances = append(instances, models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash},
+				Labels:           labels,
+				CurrentState:     state,
+			})
+		}
+	}
+	add(mixed, models.InstanceStateNormal, models.InstanceStateFiring, models.InstanceStateFiring, models.InstanceStateError, models.InstanceStatePending)
+	add(normal, models.InstanceStateNormal, models.InstanceStateNormal)
+	add(otherOrg, models.InstanceStateFiring)
+	require.NoError(t, dbstore.SaveAlertInstances(ctx, instances...))
+
+	summaries, err := dbstore.GetAlertInstanceStateSummaries(ctx, &models.GetAlertInstanceStateSummariesQuery{OrgID: 1})
+	require.NoError(t, err)
+	byUID := make(map[string]*models.AlertInstanceStateSummary, len(summaries))
+	for _, s := range summaries {
+		byUID[s.RuleUID] = s
+	}
+	require.Len(t, byUID, 2)
+	require.Equal(t, map[models.InstanceStateType]int64{
+		models.InstanceStateNormal:  1,
+		models.InstanceStateFiring:  2,
+		models.InstanceStateError:   1,
+		models.InstanceStatePending: 1,
+	}, byUID[mixed.UID].Counts)
+	require.Equal(t, models.InstanceStateError, byUID[mixed.UID].WorstState())
+	require.Equal(t, map[models.InstanceStateType]int64{models.InstanceStateNormal: 2}, byUID[normal.UID].Counts)
+	require.NotContains(t, byUID, empty.UID)
+	require.Equal(t, models.InstanceStateNormal, byUID[empty.UID].WorstState())
+
+	summaries, err = dbstore.GetAlertInstanceStateSummaries(ctx, &models.GetAlertInstanceStateSummariesQuery{OrgID: 1, RuleUIDs: []string{normal.UID, empty.UID}})
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+	require.Equal(t, normal.UID, summaries[0].RuleUID)
+}
+
+func TestIntegrationAlertInstanceResolvedAt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	rule := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+
+	resolvedAt := time.Unix(1_000_000, 0)
+	instances := make([]models.AlertInstance, 0, 2)
+	for i, at := range []time.Time{{}, resolvedAt} {
+		labels := models.InstanceLabels{"test": fmt.Sprint(i)}
+		_, hash, _ := labels.StringAndHash()
+		instances = append(instances, models.AlertInstance{
+			AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash},
+			Labels:           labels,
+			CurrentState:     models.InstanceStateNormal,
+			ResolvedAt:       at,
+		})
+	}
+	require.NoError(t, dbstore.SaveAlertInstances(ctx, instances...))
+
+	saved, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+	require.NoError(t, err)
+	require.Len(t, saved, 2)
+	for _, instance := range saved {
+		if instance.LabelsHash == instances[0].LabelsHash {
+			require.True(t, instance.ResolvedAt.IsZero())
+		} else {
+			require.True(t, resolvedAt.Equal(instance.ResolvedAt))
+		}
+	}
 }
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestIntegrationGetAlertInstanceStateSummariesInChunks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	chunkSize := alertInstanceStateSummariesChunkSize
+	alertInstanceStateSummariesChunkSize = 2
+	t.Cleanup(func() {
+		alertInstanceStateSummariesChunkSize = chunkSize
+	})
+	store := &DBstore{SQLStore: db.InitTestDB(t), Logger: log.NewNopLogger()}
+	ctx := context.Background()
+
+	ruleUIDs := make([]string, 0, 5)
+	instances := make([]models.AlertInstance, 0)
+	for i := 0; i < 5; i++ {
+		// the UIDs are not sorted, so that the chunks are not sorted either
+		uid := fmt.Sprintf("rule-%d", (i*3)%5)
+		ruleUIDs = append(ruleUIDs, uid)
+		for j := 0; j <= i; j++ {
+			labels := models.InstanceLabels{"test": fmt.Sprint(j)}
+			_, hash, _ := labels.StringAndHash()
+			instances = append(instances, models.AlertInstance{
+				AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: 1, RuleUID: uid, LabelsHash: hash},
+				Labels:           labels,
+				CurrentState:     models.InstanceStateFiring,
+			})
+		}
+	}
+	require.NoError(t, store.SaveAlertInstances(ctx, instances...))
+
+	summaries, err := store.GetAlertInstanceStateSummaries(ctx, &models.GetAlertInstanceStateSummariesQuery{OrgID: 1, RuleUIDs: append(ruleUIDs, "missing")})
+	require.NoError(t, err)
+	require.Len(t, summaries, 5)
+	for i, s := range summaries {
+		require.Equal(t, fmt.Sprintf("rule-%d", i), s.RuleUID)
+	}
+	for i, uid := range ruleUIDs {
+		require.Equal(t, int64(i+1), summaries[(i*3)%5].Counts[models.InstanceStateFiring], uid)
+	}
+}
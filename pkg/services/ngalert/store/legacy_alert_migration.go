@@ -0,0 +1,288 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrations/ualert"
+)
+
+// legacyAlert is a legacy dashboard alert with the UIDs of its dashboard and of the folder of the dashboard.
+type legacyAlert struct {
+	ID           int64 `xorm:"id"`
+	OrgID        int64 `xorm:"org_id"`
+	DashboardID  int64 `xorm:"dashboard_id"`
+	PanelID      int64 `xorm:"panel_id"`
+	Name         string
+	Message      string
+	Frequency    int64
+	For          time.Duration `xorm:"for"`
+	State        string
+	Settings     json.RawMessage
+	DashboardUID string `xorm:"dashboard_uid"`
+	FolderUID    string `xorm:"folder_uid"`
+}
+
+var legacyAlertsSQL = `
+SELECT a.id,
+	a.org_id,
+	a.dashboard_id,
+	a.panel_id,
+	a.name,
+	a.message,
+	a.frequency,
+	a.%s,
+	a.state,
+	a.settings,
+	d.uid AS dashboard_uid,
+	f.uid AS folder_uid
+FROM alert a
+	INNER JOIN dashboard d ON d.id = a.dashboard_id AND d.org_id = a.org_id
+	LEFT JOIN dashboard f ON f.id = d.folder_id AND f.org_id = d.org_id AND f.is_folder = ?
+ORDER BY a.org_id, d.uid, a.id
+`
+
+// MigrateLegacyAlerts creates an alert rule for every legacy dashboard alert that has not been migrated yet. The queries
+// and the classic conditions of the legacy alert are converted like the migration to unified alerting does, and the rule
+// is associated with the contact points of the notification channels of the alert, which the migration created with the
+// same UIDs. The rule is created in the folder of the dashboard, or in the General Alerting folder for the dashboards
+// of the General folder. The rules of a dashboard are created in one transaction. The legacy alerts that cannot be
+// migrated are reported with the reason, and the migration continues with the others. Migrating again only migrates
+// the legacy alerts that have not been migrated yet.
+func (st DBstore) MigrateLegacyAlerts(ctx context.Context) (*ngmodels.LegacyAlertMigrationReport, error) {
+	var alerts []legacyAlert
+	dsUIDs := map[[2]int64]string{}
+	channelUIDs := map[[2]int64]string{}
+	defaultChannels := map[int64][]string{}
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := fmt.Sprintf(legacyAlertsSQL, st.SQLStore.GetDialect().Quote("for"))
+		if err := sess.SQL(q, st.SQLStore.GetDialect().BooleanStr(true)).Find(&alerts); err != nil {
+			return fmt.Errorf("failed to load the legacy alerts: %w", err)
+		}
+
+		var dataSources []struct {
+			OrgID int64  `xorm:"org_id"`
+			ID    int64  `xorm:"id"`
+			UID   string `xorm:"uid"`
+		}
+		if err := sess.SQL("SELECT org_id, id, uid FROM data_source").Find(&dataSources); err != nil {
+			return fmt.Errorf("failed to load the data sources: %w", err)
+		}
+		for _, ds := range dataSources {
+			dsUIDs[[2]int64{ds.OrgID, ds.ID}] = ds.UID
+		}
+
+		var channels []struct {
+			OrgID     int64  `xorm:"org_id"`
+			ID        int64  `xorm:"id"`
+			UID       string `xorm:"uid"`
+			IsDefault bool   `xorm:"is_default"`
+		}
+		if err := sess.SQL("SELECT org_id, id, uid, is_default FROM alert_notification").Find(&channels); err != nil {
+			return fmt.Errorf("failed to load the notification channels: %w", err)
+		}
+		for _, c := range channels {
+			channelUIDs[[2]int64{c.OrgID, c.ID}] = c.UID
+			if c.IsDefault {
+				defaultChannels[c.OrgID] = append(defaultChannels[c.OrgID], c.UID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := st.ListAlertRules(ctx, &ngmodels.ListAlertRulesQuery{OrgID: -1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the alert rules: %w", err)
+	}
+	// migrated maps the organization and the ID of the legacy alerts to the UID of the rule that was migrated from it
+	migrated := make(map[[2]int64]string, len(existing))
+	// titles are the titles of the rules by organization and folder, which must be unique
+	titles := make(map[[2]string]struct{}, len(existing))
+	for _, rule := range existing {
+		titles[ruleTitleKey(rule.OrgID, rule.NamespaceUID, rule.Title)] = struct{}{}
+		if id, ok := rule.MigratedAlertID(); ok {
+			migrated[[2]int64{rule.OrgID, id}] = rule.UID
+		}
+	}
+
+	report := &ngmodels.LegacyAlertMigrationReport{}
+	contactPoints := map[int64]map[string]struct{}{}
+	generalFolders := map[int64]string{}
+	for start := 0; start < len(alerts); {
+		// the alerts are sorted by organization and dashboard
+		end := start + 1
+		for end < len(alerts) && alerts[end].OrgID == alerts[start].OrgID && alerts[end].DashboardUID == alerts[start].DashboardUID {
+			end++
+		}
+		dashboardAlerts := alerts[start:end]
+		start = end
+
+		orgID := dashboardAlerts[0].OrgID
+		if _, ok := contactPoints[orgID]; !ok {
+			err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+				uids, err := getContactPointUIDs(sess, orgID)
+				if err != nil {
+					return err
+				}
+				contactPoints[orgID] = uids
+
+				var folderUID string
+				if _, err := sess.SQL("SELECT uid FROM dashboard WHERE org_id = ? AND is_folder = ? AND title = ?",
+					orgID, st.SQLStore.GetDialect().BooleanStr(true), ualert.GENERAL_FOLDER).Get(&folderUID); err != nil {
+					return err
+				}
+				generalFolders[orgID] = folderUID
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to load the contact points and folders of organization %d: %w", orgID, err)
+			}
+		}
+
+		var rules []ngmodels.AlertRule
+		var results []ngmodels.LegacyAlertMigrationResult
+		for _, a := range dashboardAlerts {
+			result := ngmodels.LegacyAlertMigrationResult{
+				AlertID:      a.ID,
+				OrgID:        a.OrgID,
+				DashboardUID: a.DashboardUID,
+				PanelID:      a.PanelID,
+				Name:         a.Name,
+			}
+			if uid, ok := migrated[[2]int64{a.OrgID, a.ID}]; ok {
+				result.Status = ngmodels.LegacyAlertSkipped
+				result.RuleUID = uid
+				report.Results = append(report.Results, result)
+				continue
+			}
+			rule, err := st.convertLegacyAlert(a, dsUIDs, channelUIDs, defaultChannels[a.OrgID], contactPoints[a.OrgID], generalFolders[a.OrgID])
+			if err != nil {
+				result.Status = ngmodels.LegacyAlertFailed
+				result.Reason = err.Error()
+				report.Results = append(report.Results, result)
+				continue
+			}
+			key := ruleTitleKey(rule.OrgID, rule.NamespaceUID, rule.Title)
+			if _, ok := titles[key]; ok {
+				// like the migration to unified alerting, break the conflict with the UID of the rule
+				rule.Title = truncateRuleTitle(rule.Title, rule.UID)
+				rule.RuleGroup = rule.Title
+				key = ruleTitleKey(rule.OrgID, rule.NamespaceUID, rule.Title)
+			}
+			titles[key] = struct{}{}
+			result.RuleUID = rule.UID
+			rules = append(rules, *rule)
+			results = append(results, result)
+		}
+		if len(rules) == 0 {
+			continue
+		}
+
+		_, err := st.InsertAlertRules(ctx, rules)
+		for _, result := range results {
+			if err != nil {
+				result.Status = ngmodels.LegacyAlertFailed
+				result.RuleUID = ""
+				result.Reason = fmt.Sprintf("failed to create the alert rules of the dashboard: %s", err)
+			} else {
+				result.Status = ngmodels.LegacyAlertMigrated
+				migrated[[2]int64{result.OrgID, result.AlertID}] = result.RuleUID
+			}
+			report.Results = append(report.Results, result)
+		}
+	}
+	return report, nil
+}
+
+// convertLegacyAlert returns the alert rule that is equivalent to the legacy alert, or an error that explains why the
+// legacy alert cannot be migrated.
+func (st DBstore) convertLegacyAlert(a legacyAlert, dsUIDs, channelUIDs map[[2]int64]string, defaultChannels []string,
+	contactPoints map[string]struct{}, generalFolderUID string) (*ngmodels.AlertRule, error) {
+	converted, err := ualert.ConvertLegacyAlert(ualert.LegacyAlert{
+		ID:           a.ID,
+		OrgID:        a.OrgID,
+		DashboardID:  a.DashboardID,
+		DashboardUID: a.DashboardUID,
+		PanelID:      a.PanelID,
+		Name:         a.Name,
+		Message:      a.Message,
+		Frequency:    a.Frequency,
+		For:          a.For,
+		State:        a.State,
+		Settings:     a.Settings,
+	}, dsUIDs)
+	if err != nil {
+		return nil, err
+	}
+	rule := converted.Rule
+
+	rule.NamespaceUID = a.FolderUID
+	if rule.NamespaceUID == "" {
+		if generalFolderUID == "" {
+			return nil, fmt.Errorf("the dashboard is in the General folder, and the %s folder does not exist", ualert.GENERAL_FOLDER)
+		}
+		rule.NamespaceUID = generalFolderUID
+	}
+
+	uids := converted.ChannelUIDs
+	for _, id := range converted.ChannelIDs {
+		uid, ok := channelUIDs[[2]int64{a.OrgID, id}]
+		if !ok {
+			return nil, fmt.Errorf("notification channel with ID %d does not exist", id)
+		}
+		uids = append(uids, uid)
+	}
+	if len(uids) == 0 {
+		// the legacy alerts without channels notify the default channels
+		uids = defaultChannels
+	}
+	for _, uid := range uids {
+		if _, ok := contactPoints[uid]; !ok {
+			return nil, fmt.Errorf("notification channel %s has no contact point", uid)
+		}
+	}
+	rule.ContactPointUIDs = uids
+
+	if err := st.validateAlertRule(rule); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func ruleTitleKey(orgID int64, namespaceUID, title string) [2]string {
+	return [2]string{fmt.Sprintf("%d/%s", orgID, namespaceUID), title}
+}
+
+// truncateRuleTitle appends the UID to the title, and truncates the title so that the result is not longer than the
+// maximum length of the titles.
+func truncateRuleTitle(title, uid string) string {
+	if max := AlertDefinitionMaxTitleLength - 1 - len(uid); len(title) > max {
+		title = title[:max]
+	}
+	return title + "_" + uid
+}
+
+// GetLegacyAlertState returns the state of the legacy dashboard alert, or nil if the legacy alert does not exist.
+func (st DBstore) GetLegacyAlertState(ctx context.Context, orgID, alertID int64) (*ngmodels.LegacyAlertState, error) {
+	var result *ngmodels.LegacyAlertState
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		s := struct {
+			State        string
+			NewStateDate time.Time `xorm:"new_state_date"`
+		}{}
+		ok, err := sess.SQL("SELECT state, new_state_date FROM alert WHERE org_id = ? AND id = ?", orgID, alertID).Get(&s)
+		if err != nil || !ok {
+			return err
+		}
+		result = &ngmodels.LegacyAlertState{State: s.State, NewStateDate: s.NewStateDate}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,204 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr/classic"
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	legacymodels "github.com/grafana/grafana/pkg/services/alerting/models"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestIntegrationMigrateLegacyAlerts(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.NewNopLogger(),
+	}
+	ctx := context.Background()
+	orgID := int64(1)
+	setupConfigInOrg(t, contactPointsConfig, orgID, store)
+
+	now := time.Now()
+	legacyAlert := func(dashboardID, panelID int64, name, settings string) *legacymodels.Alert {
+		s, err := simplejson.NewJson([]byte(settings))
+		require.NoError(t, err)
+		return &legacymodels.Alert{
+			OrgID:        orgID,
+			DashboardID:  dashboardID,
+			PanelID:      panelID,
+			Name:         name,
+			Message:      name + " message",
+			Frequency:    60,
+			For:          5 * time.Minute,
+			State:        legacymodels.AlertStateOK,
+			Settings:     s,
+			NewStateDate: now,
+			Created:      now,
+			Updated:      now,
+		}
+	}
+	err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		_, err := sess.Insert(
+			&dashboards.Dashboard{ID: 1, OrgID: orgID, UID: "folder", Title: "Folder", IsFolder: true, Created: now, Updated: now},
+			&dashboards.Dashboard{ID: 2, OrgID: orgID, UID: "dashboard", Title: "Dashboard", FolderID: 1, Created: now, Updated: now},
+			&dashboards.Dashboard{ID: 3, OrgID: orgID, UID: "general", Title: "General", Created: now, Updated: now},
+			&datasources.DataSource{ID: 1, OrgID: orgID, UID: "prometheus", Name: "Prometheus", Created: now, Updated: now},
+			&legacymodels.AlertNotification{ID: 1, OrgID: orgID, UID: "slack", Name: "slack", Type: "slack", Settings: simplejson.New(), Created: now, Updated: now},
+			&legacymodels.AlertNotification{ID: 2, OrgID: orgID, UID: "email", Name: "email", Type: "email", Settings: simplejson.New(), Created: now, Updated: now},
+			legacyAlert(2, 1, "High CPU", `{
+				"noDataState": "alerting",
+				"executionErrorState": "alerting",
+				"conditions": [{
+					"evaluator": {"type": "gt", "params": [80]},
+					"operator": {"type": "and"},
+					"query": {"datasourceId": 1, "model": {"refId": "A", "expr": "cpu"}, "params": ["A", "5m", "now"]},
+					"reducer": {"type": "avg"}
+				}],
+				"notifications": [{"uid": "slack"}]
+			}`),
+			legacyAlert(2, 2, "Low disk", `{
+				"conditions": [{
+					"evaluator": {"type": "lt", "params": [10]},
+					"operator": {"type": "and"},
+					"query": {"datasourceId": 1, "model": {"refId": "A", "expr": "disk"}, "params": ["A", "10m", "now"]},
+					"reducer": {"type": "last"}
+				}],
+				"notifications": [{"id": 2}]
+			}`),
+			legacyAlert(2, 3, "Unknown channel", `{
+				"conditions": [{
+					"evaluator": {"type": "gt", "params": [1]},
+					"operator": {"type": "and"},
+					"query": {"datasourceId": 1, "model": {"refId": "A", "expr": "up"}, "params": ["A", "5m", "now"]},
+					"reducer": {"type": "avg"}
+				}],
+				"notifications": [{"uid": "missing"}]
+			}`),
+			legacyAlert(3, 1, "General dashboard", `{
+				"conditions": [{
+					"evaluator": {"type": "gt", "params": [1]},
+					"operator": {"type": "and"},
+					"query": {"datasourceId": 1, "model": {"refId": "A", "expr": "up"}, "params": ["A", "5m", "now"]},
+					"reducer": {"type": "avg"}
+				}]
+			}`),
+		)
+		return err
+	})
+	require.NoError(t, err)
+
+	report, err := store.MigrateLegacyAlerts(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Count(models.LegacyAlertMigrated))
+	require.Equal(t, 2, report.Count(models.LegacyAlertFailed))
+	results := make(map[string]models.LegacyAlertMigrationResult, len(report.Results))
+	for _, r := range report.Results {
+		results[r.Name] = r
+	}
+	require.Equal(t, models.LegacyAlertFailed, results["Unknown channel"].Status)
+	require.Contains(t, results["Unknown channel"].Reason, "notification channel missing has no contact point")
+	require.Equal(t, models.LegacyAlertFailed, results["General dashboard"].Status)
+	require.Contains(t, results["General dashboard"].Reason, "General folder")
+
+	get := func(t *testing.T, name string) *models.AlertRule {
+		t.Helper()
+		require.Equal(t, models.LegacyAlertMigrated, results[name].Status)
+		rule, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: results[name].RuleUID})
+		require.NoError(t, err)
+		return rule
+	}
+	// evaluate returns whether the classic condition of the rule fires for the values of its query A.
+	evaluate := func(t *testing.T, rule *models.AlertRule, values ...float64) bool {
+		t.Helper()
+		var model map[string]interface{}
+		for _, q := range rule.Data {
+			if q.RefID == rule.Condition {
+				require.Equal(t, "__expr__", q.DatasourceUID)
+				require.NoError(t, json.Unmarshal(q.Model, &model))
+			}
+		}
+		require.Equal(t, "classic_conditions", model["type"])
+		cmd, err := classic.UnmarshalConditionsCmd(model, rule.Condition)
+		require.NoError(t, err)
+
+		series := mathexp.NewSeries("A", nil, len(values))
+		for i := range values {
+			series.SetPoint(i, now.Add(time.Duration(i)*time.Minute), util.Pointer(values[i]))
+		}
+		res, err := cmd.Execute(ctx, now, mathexp.Vars{"A": mathexp.Results{Values: []mathexp.Value{series}}})
+		require.NoError(t, err)
+		require.Len(t, res.Values, 1)
+		return *res.Values[0].(mathexp.Number).GetFloat64Value() == 1
+	}
+
+	t.Run("converts the conditions and preserves the settings of the legacy alerts", func(t *testing.T) {
+		cpu := get(t, "High CPU")
+		require.Equal(t, "High CPU", cpu.Title)
+		require.Equal(t, "folder", cpu.NamespaceUID)
+		require.Equal(t, int64(60), cpu.IntervalSeconds)
+		require.Equal(t, 5*time.Minute, cpu.For)
+		require.Equal(t, models.Alerting, cpu.NoDataState)
+		require.Equal(t, []string{"slack"}, cpu.ContactPointUIDs)
+		require.Equal(t, "dashboard", *cpu.DashboardUID)
+		require.Equal(t, int64(1), *cpu.PanelID)
+		require.Equal(t, "High CPU message", cpu.Annotations["message"])
+		require.Equal(t, "prometheus", cpu.Data[0].DatasourceUID)
+		require.Equal(t, models.Duration(5*time.Minute), cpu.Data[0].RelativeTimeRange.From)
+		require.True(t, evaluate(t, cpu, 70, 90, 100))
+		require.False(t, evaluate(t, cpu, 70, 80, 90))
+
+		disk := get(t, "Low disk")
+		require.Equal(t, []string{"email"}, disk.ContactPointUIDs)
+		require.Equal(t, models.Duration(10*time.Minute), disk.Data[0].RelativeTimeRange.From)
+		require.True(t, evaluate(t, disk, 50, 20, 5))
+		require.False(t, evaluate(t, disk, 5, 20, 50))
+	})
+
+	t.Run("does not duplicate the rules when it runs again", func(t *testing.T) {
+		again, err := store.MigrateLegacyAlerts(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 0, again.Count(models.LegacyAlertMigrated))
+		require.Equal(t, 2, again.Count(models.LegacyAlertSkipped))
+		require.Equal(t, 2, again.Count(models.LegacyAlertFailed))
+		for _, r := range again.Results {
+			if r.Status == models.LegacyAlertSkipped {
+				require.Equal(t, results[r.Name].RuleUID, r.RuleUID)
+			}
+		}
+
+		rules, err := store.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID})
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+	})
+
+	t.Run("returns the state of the legacy alerts", func(t *testing.T) {
+		id, ok := get(t, "High CPU").MigratedAlertID()
+		require.True(t, ok)
+		state, err := store.GetLegacyAlertState(ctx, orgID, id)
+		require.NoError(t, err)
+		require.Equal(t, string(legacymodels.AlertStateOK), state.State)
+
+		state, err = store.GetLegacyAlertState(ctx, orgID, 1000)
+		require.NoError(t, err)
+		require.Nil(t, state)
+	})
+}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// GetMaintenanceWindows returns the maintenance windows of the organization ordered by ID. They are read from
+// MaintenanceWindowCache if it is set.
+func (st DBstore) GetMaintenanceWindows(ctx context.Context, orgID int64) ([]*models.MaintenanceWindow, error) {
+	read := func() ([]*models.MaintenanceWindow, error) {
+		var result []*models.MaintenanceWindow
+		err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+			return sess.Where("org_id = ?", orgID).Asc("id").Find(&result)
+		})
+		return result, err
+	}
+	if st.MaintenanceWindowCache == nil {
+		return read()
+	}
+	return st.MaintenanceWindowCache.Get(ctx, orgID, read)
+}
+
+// GetMaintenanceWindow returns the maintenance window of the organization with the given ID, or models.ErrMaintenanceWindowNotFound.
+func (st DBstore) GetMaintenanceWindow(ctx context.Context, orgID, id int64) (*models.MaintenanceWindow, error) {
+	var result models.MaintenanceWindow
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("org_id = ? AND id = ?", orgID, id).Get(&result)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrMaintenanceWindowNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// InsertMaintenanceWindow saves a new maintenance window and sets its ID.
+func (st DBstore) InsertMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(window); err != nil {
+			return fmt.Errorf("failed to insert maintenance window: %w", err)
+		}
+		st.invalidateMaintenanceWindowCache(window.OrgID)
+		return nil
+	})
+}
+
+// UpdateMaintenanceWindow replaces the maintenance window with the ID and organization of the given one. It does not change
+// the creator and the creation time. Returns models.ErrMaintenanceWindowNotFound if the window does not exist.
+func (st DBstore) UpdateMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		updated, err := sess.Where("org_id = ? AND id = ?", window.OrgID, window.ID).
+			Cols("title", "starts_at", "ends_at", "recurrence", "updated").
+			Update(window)
+		if err != nil {
+			return fmt.Errorf("failed to update maintenance window: %w", err)
+		}
+		if updated == 0 {
+			return models.ErrMaintenanceWindowNotFound
+		}
+		st.invalidateMaintenanceWindowCache(window.OrgID)
+		return nil
+	})
+}
+
+// DeleteMaintenanceWindow deletes the maintenance window of the organization with the given ID. Returns
+// models.ErrMaintenanceWindowNotFound if the window does not exist.
+func (st DBstore) DeleteMaintenanceWindow(ctx context.Context, orgID, id int64) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		deleted, err := sess.Where("org_id = ? AND id = ?", orgID, id).Delete(&models.MaintenanceWindow{})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return models.ErrMaintenanceWindowNotFound
+		}
+		st.invalidateMaintenanceWindowCache(orgID)
+		return nil
+	})
+}
+
+// invalidateMaintenanceWindowCache removes the cached maintenance windows of the organization after they are changed.
+func (st DBstore) invalidateMaintenanceWindowCache(orgID int64) {
+	if st.MaintenanceWindowCache != nil {
+		st.MaintenanceWindowCache.InvalidateOrg(orgID)
+	}
+}
+
+// NewMaintenanceWindowCache creates a cache that keeps the maintenance windows of each organization for ttl.
+func NewMaintenanceWindowCache(ttl time.Duration) *OrgCache[[]*models.MaintenanceWindow] {
+	return NewOrgCache(ttl, func(windows []*models.MaintenanceWindow) []*models.MaintenanceWindow {
+		result := make([]*models.MaintenanceWindow, 0, len(windows))
+		for _, w := range windows {
+			window := *w
+			result = append(result, &window)
+		}
+		return result
+	})
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestIntegrationMaintenanceWindows(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{SQLStore: sqlStore}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	window := &models.MaintenanceWindow{
+		OrgID:      1,
+		Title:      "weekly maintenance",
+		Start:      now,
+		End:        now.Add(2 * time.Hour),
+		Recurrence: models.MaintenanceWindowWeekly,
+		CreatedBy:  42,
+		Created:    now,
+		Updated:    now,
+	}
+	require.NoError(t, store.InsertMaintenanceWindow(ctx, window))
+	require.NotZero(t, window.ID)
+	other := &models.MaintenanceWindow{OrgID: 2, Title: "other org", Start: now, End: now.Add(time.Hour), Created: now, Updated: now}
+	require.NoError(t, store.InsertMaintenanceWindow(ctx, other))
+
+	t.Run("should return the windows of the organization", func(t *testing.T) {
+		windows, err := store.GetMaintenanceWindows(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, windows, 1)
+		require.Equal(t, window.ID, windows[0].ID)
+		require.Equal(t, models.MaintenanceWindowWeekly, windows[0].Recurrence)
+		require.Equal(t, int64(42), windows[0].CreatedBy)
+		require.True(t, now.Equal(windows[0].Start))
+		require.True(t, now.Add(2*time.Hour).Equal(windows[0].End))
+	})
+
+	t.Run("should return not found if the window belongs to another organization", func(t *testing.T) {
+		_, err := store.GetMaintenanceWindow(ctx, 1, other.ID)
+		require.ErrorIs(t, err, models.ErrMaintenanceWindowNotFound)
+		require.ErrorIs(t, store.DeleteMaintenanceWindow(ctx, 1, other.ID), models.ErrMaintenanceWindowNotFound)
+	})
+
+	t.Run("should update the window but keep its creator", func(t *testing.T) {
+		update := *window
+		update.Title = "daily maintenance"
+		update.Recurrence = models.MaintenanceWindowDaily
+		update.CreatedBy = 7
+		update.Updated = now.Add(time.Minute)
+		require.NoError(t, store.UpdateMaintenanceWindow(ctx, &update))
+
+		stored, err := store.GetMaintenanceWindow(ctx, 1, window.ID)
+		require.NoError(t, err)
+		require.Equal(t, "daily maintenance", stored.Title)
+		require.Equal(t, models.MaintenanceWindowDaily, stored.Recurrence)
+		require.Equal(t, int64(42), stored.CreatedBy)
+
+		update.ID = other.ID
+		require.ErrorIs(t, store.UpdateMaintenanceWindow(ctx, &update), models.ErrMaintenanceWindowNotFound)
+	})
+
+	t.Run("should delete the window", func(t *testing.T) {
+		require.NoError(t, store.DeleteMaintenanceWindow(ctx, 1, window.ID))
+		_, err := store.GetMaintenanceWindow(ctx, 1, window.ID)
+		require.ErrorIs(t, err, models.ErrMaintenanceWindowNotFound)
+	})
+}
+
+func TestIntegrationMaintenanceWindowCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := &DBstore{SQLStore: db.InitTestDB(t), MaintenanceWindowCache: NewMaintenanceWindowCache(time.Hour)}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	windows, err := store.GetMaintenanceWindows(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, windows)
+
+	window := &models.MaintenanceWindow{OrgID: 1, Title: "maintenance", Start: now, End: now.Add(time.Hour), Created: now, Updated: now}
+	require.NoError(t, store.InsertMaintenanceWindow(ctx, window))
+	windows, err = store.GetMaintenanceWindows(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, windows, 1, "inserting a window should invalidate the cache")
+
+	window.Title = "changed"
+	require.NoError(t, store.UpdateMaintenanceWindow(ctx, window))
+	windows, err = store.GetMaintenanceWindows(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "changed", windows[0].Title, "updating a window should invalidate the cache")
+
+	require.NoError(t, store.DeleteMaintenanceWindow(ctx, 1, window.ID))
+	windows, err = store.GetMaintenanceWindows(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, windows, "deleting a window should invalidate the cache")
+}
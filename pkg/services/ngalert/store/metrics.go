@@ -0,0 +1,15 @@
+package store
+
+import "time"
+
+// observe records the duration of the store operation that started at start, and counts it as failed if *err is not
+// nil. The store functions defer it with their named error result, so that it sees the error they return.
+func (st DBstore) observe(operation string, start time.Time, err *error) {
+	if st.Metrics == nil {
+		return
+	}
+	st.Metrics.OperationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		st.Metrics.OperationFailures.WithLabelValues(operation).Inc()
+	}
+}
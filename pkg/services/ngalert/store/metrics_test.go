@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationStoreMetrics(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	reg := prometheus.NewPedanticRegistry()
+	storeMetrics := metrics.NewStoreMetrics(reg)
+	// the metrics can be created again with the same registerer
+	require.Equal(t, storeMetrics, metrics.NewStoreMetrics(reg))
+
+	store := &DBstore{
+		SQLStore: db.InitTestDB(t),
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger:  log.NewNopLogger(),
+		Metrics: storeMetrics,
+	}
+	rule := models.AlertRuleGen(models.WithOrgID(1), func(rule *models.AlertRule) {
+		rule.UID = ""
+		rule.IntervalSeconds = 60
+		rule.For = time.Minute
+	})()
+	_, err := store.InsertAlertRules(context.Background(), []models.AlertRule{*rule})
+	require.NoError(t, err)
+	_, err = store.GetAlertRuleByUID(context.Background(), &models.GetAlertRuleByUIDQuery{OrgID: 1, UID: "does-not-exist"})
+	require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	observed := map[string]map[string]float64{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			require.Len(t, m.GetLabel(), 1)
+			require.Equal(t, "operation", m.GetLabel()[0].GetName())
+			if observed[family.GetName()] == nil {
+				observed[family.GetName()] = map[string]float64{}
+			}
+			value := m.GetCounter().GetValue()
+			if m.GetHistogram() != nil {
+				value = float64(m.GetHistogram().GetSampleCount())
+			}
+			observed[family.GetName()][m.GetLabel()[0].GetValue()] = value
+		}
+	}
+	require.Equal(t, map[string]map[string]float64{
+		"grafana_alerting_ngalert_store_operation_duration_seconds": {
+			"insert_alert_rules": 1,
+			"get_alert_rule":     1,
+		},
+		"grafana_alerting_ngalert_store_operation_failures_total": {
+			"get_alert_rule": 1,
+		},
+	}, observed)
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// OrgCache keeps one value per organization that is read from the database in memory for a short time, for example the
+// silences of an organization that every evaluation of its rules reads. The writes of an organization through the store
+// remove its value, so this instance sees them immediately. Other instances that share the database see them once the
+// value expires.
+type OrgCache[T any] struct {
+	ttl   time.Duration
+	clock clock.Clock
+	// copy returns a copy of a value, so that the callers can change the values they read without changing the cached ones.
+	copy func(T) T
+
+	mtx     sync.Mutex
+	entries map[int64]orgCacheEntry[T]
+	// generations counts the invalidations of each organization, and generation the invalidations of all organizations,
+	// so that reads that started before a write are not cached after it.
+	generations map[int64]uint64
+	generation  uint64
+}
+
+type orgCacheEntry[T any] struct {
+	value   T
+	expires time.Time
+}
+
+// NewOrgCache creates a cache that keeps the value of each organization for ttl. copy must return a copy of a value that
+// shares nothing that the callers may change with it.
+func NewOrgCache[T any](ttl time.Duration, copy func(T) T) *OrgCache[T] {
+	return &OrgCache[T]{
+		ttl:         ttl,
+		clock:       clock.New(),
+		copy:        copy,
+		entries:     make(map[int64]orgCacheEntry[T]),
+		generations: make(map[int64]uint64),
+	}
+}
+
+// Get returns a copy of the cached value of the organization. If there is none or it is expired, the value is read with
+// read and cached. Reads in a transaction are never cached, because they can see changes that are not committed yet.
+func (c *OrgCache[T]) Get(ctx context.Context, orgID int64, read func() (T, error)) (T, error) {
+	if ctx.Value(sqlstore.ContextSessionKey{}) != nil {
+		return read()
+	}
+	c.mtx.Lock()
+	entry, ok := c.entries[orgID]
+	orgGeneration, generation := c.generations[orgID], c.generation
+	c.mtx.Unlock()
+	if ok && c.clock.Now().Before(entry.expires) {
+		return c.copy(entry.value), nil
+	}
+
+	value, err := read()
+	if err != nil {
+		return value, err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.generations[orgID] == orgGeneration && c.generation == generation {
+		c.entries[orgID] = orgCacheEntry[T]{value: c.copy(value), expires: c.clock.Now().Add(c.ttl)}
+	}
+	return value, nil
+}
+
+// InvalidateOrg removes the cached value of the organization.
+func (c *OrgCache[T]) InvalidateOrg(orgID int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	delete(c.entries, orgID)
+	c.generations[orgID]++
+}
+
+// InvalidateAll removes the cached values of all organizations.
+func (c *OrgCache[T]) InvalidateAll() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.generation++
+	c.entries = make(map[int64]orgCacheEntry[T])
+}
@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+func TestOrgCache(t *testing.T) {
+	ctx := context.Background()
+	newCache := func() (*OrgCache[[]int], *clock.Mock) {
+		mockClock := clock.NewMock()
+		cache := NewOrgCache(time.Minute, func(v []int) []int {
+			return append([]int(nil), v...)
+		})
+		cache.clock = mockClock
+		return cache, mockClock
+	}
+	// read returns a function that reads the value and counts the reads
+	reads := 0
+	read := func(v ...int) func() ([]int, error) {
+		return func() ([]int, error) {
+			reads++
+			return v, nil
+		}
+	}
+
+	t.Run("should read the value of each organization once until it expires", func(t *testing.T) {
+		cache, mockClock := newCache()
+		reads = 0
+		v, err := cache.Get(ctx, 1, read(1))
+		require.NoError(t, err)
+		require.Equal(t, []int{1}, v)
+		v, err = cache.Get(ctx, 1, read(2))
+		require.NoError(t, err)
+		require.Equal(t, []int{1}, v)
+		v, err = cache.Get(ctx, 2, read(3))
+		require.NoError(t, err)
+		require.Equal(t, []int{3}, v)
+		require.Equal(t, 2, reads)
+
+		mockClock.Add(time.Minute)
+		v, err = cache.Get(ctx, 1, read(4))
+		require.NoError(t, err)
+		require.Equal(t, []int{4}, v)
+	})
+
+	t.Run("should return copies of the cached value", func(t *testing.T) {
+		cache, _ := newCache()
+		v, err := cache.Get(ctx, 1, read(1))
+		require.NoError(t, err)
+		v[0] = 2
+		v, err = cache.Get(ctx, 1, read(3))
+		require.NoError(t, err)
+		require.Equal(t, []int{1}, v)
+	})
+
+	t.Run("should not cache errors", func(t *testing.T) {
+		cache, _ := newCache()
+		_, err := cache.Get(ctx, 1, func() ([]int, error) {
+			return nil, errors.New("failed")
+		})
+		require.Error(t, err)
+		v, err := cache.Get(ctx, 1, read(1))
+		require.NoError(t, err)
+		require.Equal(t, []int{1}, v)
+	})
+
+	t.Run("should read the value again after it is invalidated", func(t *testing.T) {
+		cache, _ := newCache()
+		_, _ = cache.Get(ctx, 1, read(1))
+		_, _ = cache.Get(ctx, 2, read(1))
+		cache.InvalidateOrg(1)
+		v, _ := cache.Get(ctx, 1, read(2))
+		require.Equal(t, []int{2}, v)
+		v, _ = cache.Get(ctx, 2, read(2))
+		require.Equal(t, []int{1}, v)
+
+		cache.InvalidateAll()
+		v, _ = cache.Get(ctx, 2, read(3))
+		require.Equal(t, []int{3}, v)
+	})
+
+	t.Run("should not cache a read that started before an invalidation", func(t *testing.T) {
+		cache, _ := newCache()
+		v, _ := cache.Get(ctx, 1, func() ([]int, error) {
+			cache.InvalidateOrg(1)
+			return []int{1}, nil
+		})
+		require.Equal(t, []int{1}, v)
+		v, _ = cache.Get(ctx, 1, read(2))
+		require.Equal(t, []int{2}, v)
+
+		cache, _ = newCache()
+		_, _ = cache.Get(ctx, 1, func() ([]int, error) {
+			cache.InvalidateAll()
+			return []int{3}, nil
+		})
+		v, _ = cache.Get(ctx, 1, read(4))
+		require.Equal(t, []int{4}, v)
+	})
+
+	t.Run("should not cache reads in a transaction", func(t *testing.T) {
+		cache, _ := newCache()
+		txCtx := context.WithValue(ctx, sqlstore.ContextSessionKey{}, struct{}{})
+		_, _ = cache.Get(txCtx, 1, read(1))
+		v, _ := cache.Get(ctx, 1, read(2))
+		require.Equal(t, []int{2}, v)
+	})
+}
@@ -0,0 +1,360 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+const (
+	// prometheusExportConditionAnnotation is the annotation with the queries and the condition of the rules whose
+	// condition cannot be expressed in PromQL.
+	prometheusExportConditionAnnotation = "__grafana_condition__"
+	// prometheusExportWarningAnnotation is the annotation that explains why the condition of the rule cannot be expressed
+	// in PromQL.
+	prometheusExportWarningAnnotation = "__grafana_export_warning__"
+	// unexportableExpr is the expression of the rules whose condition cannot be expressed in PromQL. It never returns a
+	// result, so that the exported rule never fires.
+	unexportableExpr = "vector(1) == 0"
+)
+
+// mathComparisonRegexp matches the math expressions that compare a query with a number, like $A > 80.
+var mathComparisonRegexp = regexp.MustCompile(`^\$(?:\{([^}]+)\}|([A-Za-z_][A-Za-z0-9_]*))\s*(>=|<=|==|!=|>|<)\s*(\S+)$`)
+
+// ExportPrometheusRules returns the alert rules as Prometheus rule groups, keyed by the title of their folder. The
+// condition of a rule is converted to PromQL if it compares a single query of a Prometheus data source with a threshold,
+// either with a threshold expression or a math expression, optionally after reducing the query to its last value.
+// Other rules are exported with an expression that never fires, and with their queries, their condition and the reason
+// in annotations. The export is deterministic: the rule groups are sorted by name and the rules by their index in the
+// group.
+func (st DBstore) ExportPrometheusRules(ctx context.Context, query *ngmodels.ExportPrometheusRulesQuery) (definitions.PrometheusRulesExport, error) {
+	q := ngmodels.ListAlertRulesQuery{
+		OrgID:     query.OrgID,
+		RuleGroup: query.RuleGroup,
+	}
+	if query.NamespaceUID != "" {
+		q.NamespaceUIDs = []string{query.NamespaceUID}
+	}
+	rules, err := st.ListAlertRules(ctx, &q)
+	if err != nil {
+		return nil, err
+	}
+
+	folderTitles := map[string]string{}
+	dsTypes := map[string]string{}
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var folders []struct {
+			UID   string `xorm:"uid"`
+			Title string
+		}
+		if err := sess.SQL("SELECT uid, title FROM dashboard WHERE org_id = ? AND is_folder = ?",
+			query.OrgID, st.SQLStore.GetDialect().BooleanStr(true)).Find(&folders); err != nil {
+			return fmt.Errorf("failed to load the folders: %w", err)
+		}
+		for _, f := range folders {
+			folderTitles[f.UID] = f.Title
+		}
+
+		var dataSources []struct {
+			UID  string `xorm:"uid"`
+			Type string
+		}
+		if err := sess.SQL("SELECT uid, type FROM data_source WHERE org_id = ?", query.OrgID).Find(&dataSources); err != nil {
+			return fmt.Errorf("failed to load the data sources: %w", err)
+		}
+		for _, ds := range dataSources {
+			dsTypes[ds.UID] = ds.Type
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ConvertToPrometheusRules(rules, folderTitles, dsTypes)
+}
+
+// ConvertToPrometheusRules groups the rules by folder and rule group, and converts them to Prometheus rules. The folders
+// are given by title, and the types of the data sources by UID.
+func ConvertToPrometheusRules(rules []*ngmodels.AlertRule, folderTitles, dsTypes map[string]string) (definitions.PrometheusRulesExport, error) {
+	sorted := make([]*ngmodels.AlertRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.NamespaceUID != b.NamespaceUID {
+			return a.NamespaceUID < b.NamespaceUID
+		}
+		if a.RuleGroup != b.RuleGroup {
+			return a.RuleGroup < b.RuleGroup
+		}
+		if a.RuleGroupIndex != b.RuleGroupIndex {
+			return a.RuleGroupIndex < b.RuleGroupIndex
+		}
+		return a.ID < b.ID
+	})
+
+	result := definitions.PrometheusRulesExport{}
+	var group *definitions.PrometheusRuleGroup
+	for i, rule := range sorted {
+		title, ok := folderTitles[rule.NamespaceUID]
+		if !ok {
+			return nil, fmt.Errorf("cannot find title for folder with uid '%s'", rule.NamespaceUID)
+		}
+		if i == 0 || rule.NamespaceUID != sorted[i-1].NamespaceUID || rule.RuleGroup != sorted[i-1].RuleGroup {
+			result[title] = append(result[title], definitions.PrometheusRuleGroup{
+				Name:     rule.RuleGroup,
+				Interval: model.Duration(time.Duration(rule.IntervalSeconds) * time.Second),
+			})
+			group = &result[title][len(result[title])-1]
+		}
+		node, err := convertToPrometheusRule(rule, dsTypes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export rule %s: %w", rule.UID, err)
+		}
+		group.Rules = append(group.Rules, node)
+	}
+	return result, nil
+}
+
+// convertToPrometheusRule converts the rule to a Prometheus alerting rule. If the condition of the rule cannot be
+// expressed in PromQL, the rule gets an expression that never fires, and its queries, its condition and the reason in
+// annotations.
+func convertToPrometheusRule(rule *ngmodels.AlertRule, dsTypes map[string]string) (definitions.ApiRuleNode, error) {
+	node := definitions.ApiRuleNode{
+		Alert: rule.Title,
+	}
+	if rule.For > 0 {
+		d := model.Duration(rule.For)
+		node.For = &d
+	}
+	if len(rule.Labels) > 0 {
+		node.Labels = make(map[string]string, len(rule.Labels))
+		for k, v := range rule.Labels {
+			node.Labels[k] = v
+		}
+	}
+	if len(rule.Annotations) > 0 {
+		node.Annotations = make(map[string]string, len(rule.Annotations)+2)
+		for k, v := range rule.Annotations {
+			node.Annotations[k] = v
+		}
+	}
+
+	promQL, reason := prometheusExpr(rule, dsTypes)
+	if reason == nil {
+		node.Expr = promQL
+		return node, nil
+	}
+	// the queries are not HTML-escaped, so that the comparisons of the expressions remain readable
+	var condition bytes.Buffer
+	enc := json.NewEncoder(&condition)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(PrometheusRuleCondition{
+		Condition: rule.Condition,
+		Data:      rule.Data,
+	})
+	if err != nil {
+		return definitions.ApiRuleNode{}, err
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string, 2)
+	}
+	node.Expr = unexportableExpr
+	node.Annotations[prometheusExportConditionAnnotation] = strings.TrimSpace(condition.String())
+	node.Annotations[prometheusExportWarningAnnotation] = fmt.Sprintf("the condition cannot be expressed in PromQL: %s", reason)
+	return node, nil
+}
+
+// PrometheusRuleCondition is the condition of an alert rule that cannot be expressed in PromQL, as it is exported in the
+// annotations of the Prometheus rule.
+type PrometheusRuleCondition struct {
+	Condition string                `json:"condition"`
+	Data      []ngmodels.AlertQuery `json:"data"`
+}
+
+// ParsePrometheusRuleCondition returns the condition that was exported in the annotations of the Prometheus rule, or nil
+// if the rule has none, and the annotations of the rule without the ones that the export adds.
+func ParsePrometheusRuleCondition(node definitions.ApiRuleNode) (*PrometheusRuleCondition, map[string]string, error) {
+	encoded, ok := node.Annotations[prometheusExportConditionAnnotation]
+	if !ok {
+		return nil, node.Annotations, nil
+	}
+	var condition PrometheusRuleCondition
+	if err := json.Unmarshal([]byte(encoded), &condition); err != nil {
+		return nil, nil, fmt.Errorf("%w: annotation %s of alert %q is not a valid condition: %s", ngmodels.ErrAlertRuleFailedValidation, prometheusExportConditionAnnotation, node.Alert, err)
+	}
+	annotations := make(map[string]string, len(node.Annotations))
+	for k, v := range node.Annotations {
+		if k != prometheusExportConditionAnnotation && k != prometheusExportWarningAnnotation {
+			annotations[k] = v
+		}
+	}
+	return &condition, annotations, nil
+}
+
+// prometheusExpr returns the PromQL expression that is equivalent to the condition of the rule, or the reason why there
+// is none.
+func prometheusExpr(rule *ngmodels.AlertRule, dsTypes map[string]string) (string, error) {
+	queries := make(map[string]ngmodels.AlertQuery, len(rule.Data))
+	for _, q := range rule.Data {
+		queries[q.RefID] = q
+	}
+	cond, ok := queries[rule.Condition]
+	if !ok || !expr.IsDataSource(cond.DatasourceUID) {
+		return "", errors.New("the condition is not an expression")
+	}
+	m, err := expressionModel(cond)
+	if err != nil {
+		return "", err
+	}
+	used := map[string]struct{}{cond.RefID: {}}
+
+	var result string
+	// unwrap tells whether the result is the operand alone, which must not be in parentheses
+	unwrap := false
+	switch m.Type {
+	case "threshold":
+		if len(m.Conditions) != 1 {
+			return "", errors.New("the threshold expression must have exactly one condition")
+		}
+		operand, err := prometheusOperand(queries, m.Expression, dsTypes, used)
+		if err != nil {
+			return "", err
+		}
+		params := m.Conditions[0].Evaluator.Params
+		switch t := m.Conditions[0].Evaluator.Type; {
+		case t == expr.ThresholdIsAbove && len(params) >= 1 && params[0] == 0 && !expr.IsDataSource(queries[m.Expression].DatasourceUID):
+			// an instant query that fires above 0 is how Prometheus rules are imported, so that the imported rules are
+			// exported with their expression unchanged
+			result = operand
+			unwrap = true
+		case (t == expr.ThresholdIsAbove || t == expr.ThresholdIsBelow) && len(params) >= 1:
+			op := ">"
+			if t == expr.ThresholdIsBelow {
+				op = "<"
+			}
+			result = fmt.Sprintf("%s %s %s", operand, op, formatNumber(params[0]))
+		case t == expr.ThresholdIsWithinRange && len(params) >= 2:
+			result = fmt.Sprintf("%s > %s < %s", operand, formatNumber(params[0]), formatNumber(params[1]))
+		case t == expr.ThresholdIsOutsideRange && len(params) >= 2:
+			result = fmt.Sprintf("%s < %s or %s > %s", operand, formatNumber(params[0]), operand, formatNumber(params[1]))
+		default:
+			return "", fmt.Errorf("the threshold function %s is not supported", t)
+		}
+	case "math":
+		matches := mathComparisonRegexp.FindStringSubmatch(strings.TrimSpace(m.Expression))
+		if matches == nil {
+			return "", fmt.Errorf("the math expression %q does not compare a query with a number", m.Expression)
+		}
+		ref := matches[1] + matches[2]
+		threshold, err := strconv.ParseFloat(matches[4], 64)
+		if err != nil {
+			return "", fmt.Errorf("the math expression %q does not compare a query with a number", m.Expression)
+		}
+		operand, err := prometheusOperand(queries, ref, dsTypes, used)
+		if err != nil {
+			return "", err
+		}
+		result = fmt.Sprintf("%s %s %s", operand, matches[3], formatNumber(threshold))
+	default:
+		return "", fmt.Errorf("expressions of type %s are not supported", m.Type)
+	}
+	if len(used) != len(rule.Data) {
+		return "", errors.New("the rule has queries or expressions that the condition does not use")
+	}
+
+	// format the expression like Prometheus, so that the export does not change when it is parsed and formatted again
+	parsed, err := parser.ParseExpr(result)
+	if err != nil {
+		return "", fmt.Errorf("the expression is not valid PromQL: %w", err)
+	}
+	if p, ok := parsed.(*parser.ParenExpr); ok && unwrap {
+		return p.Expr.String(), nil
+	}
+	return parsed.String(), nil
+}
+
+// prometheusOperand returns the PromQL query of the query with the given RefID, which is a query of a Prometheus data
+// source or an expression that reduces it to its last value. The query must be an instant query if it is not reduced.
+func prometheusOperand(queries map[string]ngmodels.AlertQuery, refID string, dsTypes map[string]string, used map[string]struct{}) (string, error) {
+	q, ok := queries[refID]
+	if !ok {
+		return "", fmt.Errorf("query %s does not exist", refID)
+	}
+	used[refID] = struct{}{}
+	reduced := false
+	if expr.IsDataSource(q.DatasourceUID) {
+		m, err := expressionModel(q)
+		if err != nil {
+			return "", err
+		}
+		if m.Type != "reduce" || m.Reducer != "last" || m.Settings.Mode != "" {
+			return "", fmt.Errorf("expression %s is not a strict reduction to the last value", refID)
+		}
+		if q, ok = queries[m.Expression]; !ok || expr.IsDataSource(q.DatasourceUID) {
+			return "", fmt.Errorf("expression %s does not reduce a query", refID)
+		}
+		used[q.RefID] = struct{}{}
+		reduced = true
+	}
+
+	if t := dsTypes[q.DatasourceUID]; t != datasources.DS_PROMETHEUS {
+		return "", fmt.Errorf("query %s is not a query of a Prometheus data source", q.RefID)
+	}
+	var m struct {
+		Expr    string `json:"expr"`
+		Instant bool   `json:"instant"`
+		Range   bool   `json:"range"`
+	}
+	if err := json.Unmarshal(q.Model, &m); err != nil {
+		return "", fmt.Errorf("failed to parse query %s: %w", q.RefID, err)
+	}
+	if !reduced && (!m.Instant || m.Range) {
+		return "", fmt.Errorf("query %s is a range query that is not reduced", q.RefID)
+	}
+	parsed, err := parser.ParseExpr(m.Expr)
+	if err != nil {
+		return "", fmt.Errorf("query %s is not valid PromQL: %w", q.RefID, err)
+	}
+	if _, ok := parsed.(*parser.BinaryExpr); ok {
+		return "(" + parsed.String() + ")", nil
+	}
+	return parsed.String(), nil
+}
+
+type exportedExpressionModel struct {
+	Type       string                        `json:"type"`
+	Expression string                        `json:"expression"`
+	Reducer    string                        `json:"reducer"`
+	Conditions []expr.ThresholdConditionJSON `json:"conditions"`
+	Settings   struct {
+		Mode string `json:"mode"`
+	} `json:"settings"`
+}
+
+func expressionModel(q ngmodels.AlertQuery) (exportedExpressionModel, error) {
+	var m exportedExpressionModel
+	if err := json.Unmarshal(q.Model, &m); err != nil {
+		return m, fmt.Errorf("failed to parse expression %s: %w", q.RefID, err)
+	}
+	return m, nil
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,253 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/rulefmt"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestConvertToPrometheusRules(t *testing.T) {
+	folderTitles := map[string]string{"folder-1": "Infrastructure", "folder-2": "Databases"}
+	dsTypes := map[string]string{"prom": "prometheus", "loki": "loki"}
+
+	query := func(refID, dsUID, model string) models.AlertQuery {
+		return models.AlertQuery{
+			RefID:             refID,
+			DatasourceUID:     dsUID,
+			RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(10 * time.Minute)},
+			Model:             json.RawMessage(model),
+		}
+	}
+	expression := func(refID, model string) models.AlertQuery {
+		return query(refID, "__expr__", model)
+	}
+	instant := func(refID, expr string) models.AlertQuery {
+		return query(refID, "prom", fmt.Sprintf(`{"refId":%q,"expr":%q,"instant":true,"range":false}`, refID, expr))
+	}
+	threshold := func(refID, ref, typ string, params ...float64) models.AlertQuery {
+		p, err := json.Marshal(params)
+		require.NoError(t, err)
+		return expression(refID, fmt.Sprintf(`{"refId":%q,"type":"threshold","expression":%q,"conditions":[{"evaluator":{"type":%q,"params":%s}}]}`, refID, ref, typ, p))
+	}
+	rule := func(uid, folder, group string, idx int, condition string, data ...models.AlertQuery) *models.AlertRule {
+		return &models.AlertRule{
+			UID:             uid,
+			OrgID:           1,
+			Title:           "Rule " + uid,
+			NamespaceUID:    folder,
+			RuleGroup:       group,
+			RuleGroupIndex:  idx,
+			IntervalSeconds: 60,
+			Condition:       condition,
+			Data:            data,
+		}
+	}
+
+	t.Run("converts the conditions that compare a Prometheus query with a threshold", func(t *testing.T) {
+		testCases := []struct {
+			desc      string
+			condition string
+			data      []models.AlertQuery
+			expected  string
+		}{
+			{
+				desc:      "threshold above",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", `sum by (job) (rate(http_requests_total[5m]))`), threshold("B", "A", "gt", 80)},
+				expected:  `sum by(job) (rate(http_requests_total[5m])) > 80`,
+			},
+			{
+				desc:      "threshold below a binary expression",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", `node_filesystem_avail_bytes / node_filesystem_size_bytes`), threshold("B", "A", "lt", 0.1)},
+				expected:  `(node_filesystem_avail_bytes / node_filesystem_size_bytes) < 0.1`,
+			},
+			{
+				desc:      "instant query above 0 like an imported Prometheus rule",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", `node_filesystem_avail_bytes / node_filesystem_size_bytes < 0.1`), threshold("B", "A", "gt", 0)},
+				expected:  `node_filesystem_avail_bytes / node_filesystem_size_bytes < 0.1`,
+			},
+			{
+				desc:      "threshold within range",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", `up`), threshold("B", "A", "within_range", 1, 5)},
+				expected:  `up > 1 < 5`,
+			},
+			{
+				desc:      "threshold outside range",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", `up`), threshold("B", "A", "outside_range", -1, 5)},
+				expected:  `up < -1 or up > 5`,
+			},
+			{
+				desc:      "math comparison",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", `up`), expression("B", `{"type":"math","expression":"${A} != 1"}`)},
+				expected:  `up != 1`,
+			},
+			{
+				desc:      "range query reduced to its last value",
+				condition: "C",
+				data: []models.AlertQuery{
+					query("A", "prom", `{"expr":"up","range":true}`),
+					expression("B", `{"type":"reduce","expression":"A","reducer":"last"}`),
+					threshold("C", "B", "lt", 1),
+				},
+				expected: `up < 1`,
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.desc, func(t *testing.T) {
+				r := rule("uid", "folder-1", "group", 1, tc.condition, tc.data...)
+				node, err := convertToPrometheusRule(r, dsTypes)
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, node.Expr)
+				require.Nil(t, node.Annotations)
+			})
+		}
+	})
+
+	t.Run("exports the conditions that cannot be converted in annotations", func(t *testing.T) {
+		testCases := []struct {
+			desc      string
+			condition string
+			data      []models.AlertQuery
+			reason    string
+		}{
+			{
+				desc:      "query of another data source",
+				condition: "B",
+				data:      []models.AlertQuery{query("A", "loki", `{"expr":"count_over_time({job=\"app\"}[5m])","instant":true}`), threshold("B", "A", "gt", 1)},
+				reason:    "query A is not a query of a Prometheus data source",
+			},
+			{
+				desc:      "query with Grafana variables",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", `rate(up[$__rate_interval])`), threshold("B", "A", "gt", 1)},
+				reason:    "query A is not valid PromQL",
+			},
+			{
+				desc:      "range query that is not reduced",
+				condition: "B",
+				data:      []models.AlertQuery{query("A", "prom", `{"expr":"up","range":true}`), threshold("B", "A", "gt", 1)},
+				reason:    "query A is a range query that is not reduced",
+			},
+			{
+				desc:      "reduction to the mean",
+				condition: "C",
+				data: []models.AlertQuery{
+					query("A", "prom", `{"expr":"up","range":true}`),
+					expression("B", `{"type":"reduce","expression":"A","reducer":"mean"}`),
+					threshold("C", "B", "lt", 1),
+				},
+				reason: "expression B is not a strict reduction to the last value",
+			},
+			{
+				desc:      "math expression of two queries",
+				condition: "C",
+				data:      []models.AlertQuery{instant("A", "up"), instant("B", "up"), expression("C", `{"type":"math","expression":"$A + $B > 1"}`)},
+				reason:    "does not compare a query with a number",
+			},
+			{
+				desc:      "classic condition",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", "up"), expression("B", `{"type":"classic_conditions","conditions":[]}`)},
+				reason:    "expressions of type classic_conditions are not supported",
+			},
+			{
+				desc:      "unused query",
+				condition: "B",
+				data:      []models.AlertQuery{instant("A", "up"), threshold("B", "A", "gt", 1), instant("C", "up")},
+				reason:    "the rule has queries or expressions that the condition does not use",
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.desc, func(t *testing.T) {
+				r := rule("uid", "folder-1", "group", 1, tc.condition, tc.data...)
+				r.Annotations = map[string]string{"summary": "The rule fires"}
+				node, err := convertToPrometheusRule(r, dsTypes)
+				require.NoError(t, err)
+				require.Equal(t, unexportableExpr, node.Expr)
+				require.Equal(t, "The rule fires", node.Annotations["summary"])
+				require.Contains(t, node.Annotations[prometheusExportWarningAnnotation], tc.reason)
+
+				// the condition is restored from the annotations when the rule is imported again
+				condition, annotations, err := ParsePrometheusRuleCondition(node)
+				require.NoError(t, err)
+				require.Equal(t, tc.condition, condition.Condition)
+				require.Equal(t, tc.data, condition.Data)
+				require.Equal(t, map[string]string{"summary": "The rule fires"}, annotations)
+			})
+		}
+	})
+
+	t.Run("groups the rules by folder and rule group deterministically", func(t *testing.T) {
+		exportable := rule("exportable", "folder-1", "api", 1, "B", instant("A", `sum(rate(http_requests_total{code=~"5.."}[5m]))`), threshold("B", "A", "gt", 10))
+		exportable.For = 5 * time.Minute
+		exportable.Labels = map[string]string{"severity": "critical", "team": "api"}
+		exportable.Annotations = map[string]string{"summary": "Too many errors"}
+		fallback := rule("fallback", "folder-1", "api", 2, "B", instant("A", `rate(up[$__rate_interval])`), threshold("B", "A", "lt", 1))
+		other := rule("other", "folder-1", "alpha", 1, "B", instant("A", "up"), threshold("B", "A", "lt", 1))
+		database := rule("database", "folder-2", "api", 1, "B", instant("A", "pg_up"), threshold("B", "A", "lt", 1))
+		database.IntervalSeconds = 120
+
+		result, err := ConvertToPrometheusRules([]*models.AlertRule{fallback, database, exportable, other}, folderTitles, dsTypes)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		require.Len(t, result["Infrastructure"], 2)
+		require.Equal(t, "alpha", result["Infrastructure"][0].Name)
+		api := result["Infrastructure"][1]
+		require.Equal(t, "api", api.Name)
+		require.Equal(t, model.Duration(time.Minute), api.Interval)
+		require.Len(t, api.Rules, 2)
+		forDuration := model.Duration(5 * time.Minute)
+		require.Equal(t, definitions.ApiRuleNode{
+			Alert:       "Rule exportable",
+			Expr:        `sum(rate(http_requests_total{code=~"5.."}[5m])) > 10`,
+			For:         &forDuration,
+			Labels:      map[string]string{"severity": "critical", "team": "api"},
+			Annotations: map[string]string{"summary": "Too many errors"},
+		}, api.Rules[0])
+		require.Equal(t, "Rule fallback", api.Rules[1].Alert)
+		require.Equal(t, unexportableExpr, api.Rules[1].Expr)
+		require.Equal(t, []definitions.PrometheusRuleGroup{{
+			Name:     "api",
+			Interval: model.Duration(2 * time.Minute),
+			Rules:    []definitions.ApiRuleNode{{Alert: "Rule database", Expr: "pg_up < 1"}},
+		}}, result["Databases"])
+
+		out, err := yaml.Marshal(result)
+		require.NoError(t, err)
+		again, err := ConvertToPrometheusRules([]*models.AlertRule{other, exportable, database, fallback}, folderTitles, dsTypes)
+		require.NoError(t, err)
+		outAgain, err := yaml.Marshal(again)
+		require.NoError(t, err)
+		require.Equal(t, string(out), string(outAgain))
+
+		// every folder is a valid Prometheus rule file, which is exported again unchanged
+		var parsed definitions.PrometheusRulesExport
+		require.NoError(t, yaml.Unmarshal(out, &parsed))
+		require.Equal(t, result, parsed)
+		for _, groups := range parsed {
+			file, err := yaml.Marshal(map[string]interface{}{"groups": groups})
+			require.NoError(t, err)
+			_, errs := rulefmt.Parse(file)
+			require.Empty(t, errs)
+		}
+	})
+
+	t.Run("fails if the folder of a rule does not exist", func(t *testing.T) {
+		_, err := ConvertToPrometheusRules([]*models.AlertRule{rule("uid", "missing", "group", 1, "B", instant("A", "up"), threshold("B", "A", "gt", 1))}, folderTitles, dsTypes)
+		require.ErrorContains(t, err, "cannot find title for folder with uid 'missing'")
+	})
+}
@@ -0,0 +1,67 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+)
+
+const (
+	// lockedRetryMaxDuration bounds the total time for which a transaction is retried while the SQLite database is locked.
+	lockedRetryMaxDuration = 500 * time.Millisecond
+	// lockedRetryMinDelay is the delay before the first retry. It doubles after every retry.
+	lockedRetryMinDelay = 5 * time.Millisecond
+)
+
+// withTransactionRetryOnLocked calls the callback with a session within a transaction, like
+// db.DB.WithTransactionalDbSession, and runs the whole transaction again while the SQLite database is locked. See
+// retryOnLocked.
+func (st DBstore) withTransactionRetryOnLocked(ctx context.Context, callback sqlstore.DBTransactionFunc) error {
+	return st.retryOnLocked(ctx, func() error {
+		return st.SQLStore.WithTransactionalDbSession(ctx, callback)
+	})
+}
+
+// retryOnLocked calls the function that runs a transaction. If the transaction fails because the SQLite database is
+// locked by another writer, it is run again with exponential backoff for at most lockedRetryMaxDuration. Other
+// databases wait for their locks themselves, so the transaction is not retried there. It is not retried either if it
+// joins a transaction of the caller, because only the caller can roll that back and run it again. The outermost
+// transaction, such as the one of InTransaction, is retried instead.
+func (st DBstore) retryOnLocked(ctx context.Context, transaction func() error) error {
+	if st.SQLStore.GetDialect().DriverName() != migrator.SQLite || ctx.Value(sqlstore.ContextSessionKey{}) != nil {
+		return transaction()
+	}
+	deadline := time.Now().Add(lockedRetryMaxDuration)
+	delay := lockedRetryMinDelay
+	for retry := 1; ; retry++ {
+		err := transaction()
+		if err == nil || !isSQLiteLocked(err) {
+			return err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return err
+		}
+		if delay > remaining {
+			delay = remaining
+		}
+		st.Logger.Debug("Database is locked, retrying the transaction", "error", err, "retry", retry, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isSQLiteLocked returns true if the error is caused by a table or the database being locked by another connection.
+func isSQLiteLocked(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && (sqliteErr.Code == sqlite3.ErrLocked || sqliteErr.Code == sqlite3.ErrBusy)
+}
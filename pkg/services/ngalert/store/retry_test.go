@@ -0,0 +1,148 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore/migrator"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestIntegrationRetryOnLocked(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	if sqlStore.GetDialect().DriverName() != migrator.SQLite {
+		t.Skip("the transactions are only retried on SQLite")
+	}
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg:      setting.UnifiedAlertingSettings{BaseInterval: 10 * time.Second},
+		Logger:   log.NewNopLogger(),
+	}
+	ctx := context.Background()
+	gen := models.AlertRuleGen(models.WithUniqueID(), models.WithOrgID(1), models.WithInterval(store.Cfg.BaseInterval))
+
+	// lock keeps a write transaction open for longer than the transactions of the SQL store are retried by themselves,
+	// so that the other connections to the shared SQLite test database fail with "database table is locked".
+	lock := func(t *testing.T) <-chan error {
+		t.Helper()
+		locked := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			done <- sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+				if _, err := sess.Exec("UPDATE alert_rule SET version = version"); err != nil {
+					return err
+				}
+				close(locked)
+				time.Sleep(300 * time.Millisecond)
+				return nil
+			})
+		}()
+		<-locked
+		return done
+	}
+
+	t.Run("writes fail while the database is locked without retries", func(t *testing.T) {
+		done := lock(t)
+		err := sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec("UPDATE alert_rule SET version = version")
+			return err
+		})
+		require.True(t, isSQLiteLocked(err), "expected a locked error, got %v", err)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("parallel writes are retried until the database is unlocked", func(t *testing.T) {
+		ids, err := store.InsertAlertRules(ctx, []models.AlertRule{*gen(), *gen()})
+		require.NoError(t, err)
+		var existing []*models.AlertRule
+		for uid := range ids {
+			rule, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: 1, UID: uid})
+			require.NoError(t, err)
+			existing = append(existing, rule)
+		}
+		updated := models.CopyRule(existing[0])
+		updated.Title = "updated"
+		instance := models.AlertInstance{
+			AlertInstanceKey: models.AlertInstanceKey{RuleOrgID: 1, RuleUID: existing[0].UID, LabelsHash: "hash"},
+			Labels:           models.InstanceLabels{"test": "retry"},
+			CurrentState:     models.InstanceStateFiring,
+		}
+
+		done := lock(t)
+		writes := map[string]func() error{
+			"insert": func() error {
+				_, err := store.InsertAlertRules(ctx, []models.AlertRule{*gen()})
+				return err
+			},
+			"update": func() error {
+				return store.UpdateAlertRules(ctx, []models.UpdateRule{{Existing: existing[0], New: *updated}})
+			},
+			"delete": func() error {
+				return store.DeleteAlertRulesByUID(ctx, 1, existing[1].UID)
+			},
+			"save instances": func() error {
+				return store.SaveAlertInstances(ctx, instance)
+			},
+		}
+		var wg sync.WaitGroup
+		errs := make(map[string]error, len(writes))
+		var mtx sync.Mutex
+		for name, write := range writes {
+			name, write := name, write
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := write()
+				mtx.Lock()
+				errs[name] = err
+				mtx.Unlock()
+			}()
+		}
+		wg.Wait()
+		require.NoError(t, <-done)
+		for name, err := range errs {
+			require.NoErrorf(t, err, "write %q", name)
+		}
+
+		rule, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: 1, UID: existing[0].UID})
+		require.NoError(t, err)
+		require.Equal(t, "updated", rule.Title)
+		_, err = store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: 1, UID: existing[1].UID})
+		require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+		_, err = store.GetAlertInstance(ctx, instance.AlertInstanceKey)
+		require.NoError(t, err)
+	})
+
+	t.Run("writes fail if the database stays locked", func(t *testing.T) {
+		unlock := make(chan struct{})
+		locked := make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			done <- sqlStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+				if _, err := sess.Exec("UPDATE alert_rule SET version = version"); err != nil {
+					return err
+				}
+				close(locked)
+				<-unlock
+				return nil
+			})
+		}()
+		<-locked
+		start := time.Now()
+		_, err := store.InsertAlertRules(ctx, []models.AlertRule{*gen()})
+		close(unlock)
+		require.True(t, isSQLiteLocked(err), "expected a locked error, got %v", err)
+		require.GreaterOrEqual(t, time.Since(start), lockedRetryMaxDuration)
+		require.NoError(t, <-done)
+	})
+}
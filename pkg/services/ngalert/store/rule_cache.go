@@ -0,0 +1,200 @@
+package store
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/sqlstore"
+)
+
+// RuleCache keeps the alert rules that are read from the database in memory for a short time, so that the API does not
+// query the database again for each request that reads the same rules. The rules are kept separately for each
+// organization, and every organization can keep at most a fixed number of reads, so that the reads of one organization
+// never remove the reads of another. Writes of the rules of an organization remove all its reads.
+type RuleCache struct {
+	ttl              time.Duration
+	maxEntriesPerOrg int
+	clock            clock.Clock
+
+	mtx  sync.Mutex
+	orgs map[int64]*orgRuleCache
+	// generations counts the invalidations of each organization, so that reads that started before a write are not
+	// cached after it.
+	generations map[int64]uint64
+}
+
+// orgRuleCache is the reads of the rules of one organization, from the most to the least recently used.
+type orgRuleCache struct {
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type ruleCacheEntry struct {
+	key     string
+	rules   []*ngmodels.AlertRule
+	expires time.Time
+}
+
+// NewRuleCache creates a cache that keeps each read of rules for ttl and at most maxEntriesPerOrg reads for each
+// organization.
+func NewRuleCache(ttl time.Duration, maxEntriesPerOrg int) *RuleCache {
+	return &RuleCache{
+		ttl:              ttl,
+		maxEntriesPerOrg: maxEntriesPerOrg,
+		clock:            clock.New(),
+		orgs:             make(map[int64]*orgRuleCache),
+		generations:      make(map[int64]uint64),
+	}
+}
+
+// get returns copies of the rules that were cached under the key, and false if there are none or they are expired.
+func (c *RuleCache) get(orgID int64, key string) ([]*ngmodels.AlertRule, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	org, ok := c.orgs[orgID]
+	if !ok {
+		return nil, false
+	}
+	el, ok := org.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*ruleCacheEntry)
+	if !c.clock.Now().Before(entry.expires) {
+		org.remove(el)
+		if org.order.Len() == 0 {
+			delete(c.orgs, orgID)
+		}
+		return nil, false
+	}
+	org.order.MoveToFront(el)
+	return copyRules(entry.rules), true
+}
+
+// generation returns the number of invalidations of the organization. It must be taken before the rules are read from
+// the database and passed to set.
+func (c *RuleCache) generation(orgID int64) uint64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.generations[orgID]
+}
+
+// set caches copies of the rules under the key, unless the rules of the organization were invalidated since generation
+// was taken. If the organization already has the maximum number of reads, the least recently used one is removed.
+func (c *RuleCache) set(orgID int64, generation uint64, key string, rules []*ngmodels.AlertRule) {
+	entry := &ruleCacheEntry{key: key, rules: copyRules(rules)}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.generations[orgID] != generation {
+		return
+	}
+	entry.expires = c.clock.Now().Add(c.ttl)
+	org, ok := c.orgs[orgID]
+	if !ok {
+		org = &orgRuleCache{entries: make(map[string]*list.Element), order: list.New()}
+		c.orgs[orgID] = org
+	}
+	if el, ok := org.entries[key]; ok {
+		org.remove(el)
+	}
+	for org.order.Len() >= c.maxEntriesPerOrg {
+		org.remove(org.order.Back())
+	}
+	org.entries[key] = org.order.PushFront(entry)
+}
+
+// InvalidateOrg removes all cached reads of the rules of the organization.
+func (c *RuleCache) InvalidateOrg(orgID int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	delete(c.orgs, orgID)
+	c.generations[orgID]++
+}
+
+func (o *orgRuleCache) remove(el *list.Element) {
+	delete(o.entries, el.Value.(*ruleCacheEntry).key)
+	o.order.Remove(el)
+}
+
+// ruleCacheFor returns the cache to read the rules of the organization from, or nil if they must be read from the
+// database. Reads in a transaction are never cached, because they can see changes that are not committed yet.
+func (st DBstore) ruleCacheFor(ctx context.Context, orgID int64) *RuleCache {
+	if st.RuleCache == nil || orgID < 0 {
+		return nil
+	}
+	if ctx.Value(sqlstore.ContextSessionKey{}) != nil {
+		return nil
+	}
+	return st.RuleCache
+}
+
+// invalidateRuleCache removes the cached reads of the rules of the organization after they are changed.
+func (st DBstore) invalidateRuleCache(orgID int64) {
+	if st.RuleCache != nil {
+		st.RuleCache.InvalidateOrg(orgID)
+	}
+}
+
+func ruleCacheKeyByUID(uid string) string {
+	return "uid:" + uid
+}
+
+func ruleCacheKeyByQuery(query *ngmodels.ListAlertRulesQuery) string {
+	namespaces := make([]string, len(query.NamespaceUIDs))
+	copy(namespaces, query.NamespaceUIDs)
+	sort.Strings(namespaces)
+	return fmt.Sprintf("list:%q:%q:%q:%d:%q", strings.Join(namespaces, ","), query.RuleGroup, query.DashboardUID, query.PanelID, query.TitleSearch)
+}
+
+func copyRules(rules []*ngmodels.AlertRule) []*ngmodels.AlertRule {
+	result := make([]*ngmodels.AlertRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, copyRule(rule))
+	}
+	return result
+}
+
+// copyRule returns a deep copy of the rule, so that the callers can change the rules they read without changing the
+// cached ones.
+func copyRule(rule *ngmodels.AlertRule) *ngmodels.AlertRule {
+	result := *rule
+	if rule.DashboardUID != nil {
+		dashboardUID := *rule.DashboardUID
+		result.DashboardUID = &dashboardUID
+	}
+	if rule.PanelID != nil {
+		panelID := *rule.PanelID
+		result.PanelID = &panelID
+	}
+	if rule.Data != nil {
+		result.Data = make([]ngmodels.AlertQuery, 0, len(rule.Data))
+		for _, q := range rule.Data {
+			q.Model = append([]byte(nil), q.Model...)
+			result.Data = append(result.Data, q)
+		}
+	}
+	if rule.Annotations != nil {
+		result.Annotations = make(map[string]string, len(rule.Annotations))
+		for k, v := range rule.Annotations {
+			result.Annotations[k] = v
+		}
+	}
+	if rule.Labels != nil {
+		result.Labels = make(map[string]string, len(rule.Labels))
+		for k, v := range rule.Labels {
+			result.Labels[k] = v
+		}
+	}
+	if rule.ContactPointUIDs != nil {
+		result.ContactPointUIDs = append([]string(nil), rule.ContactPointUIDs...)
+	}
+	return &result
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/rand"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestIntegrationRuleCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	mockClock := clock.NewMock()
+	cache := NewRuleCache(time.Minute, 3)
+	cache.clock = mockClock
+	store := &DBstore{
+		SQLStore: sqlStore,
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger:    log.NewNopLogger(),
+		RuleCache: cache,
+	}
+	ctx := context.Background()
+
+	// insertRule creates a rule in the organization, and setTitle changes its title in the database without the store
+	// so that it can be seen whether the rule is read from the cache.
+	insertRule := func(t *testing.T, orgID int64) *models.AlertRule {
+		t.Helper()
+		rule := models.AlertRuleGen(models.WithOrgID(orgID), func(rule *models.AlertRule) {
+			rule.UID = ""
+			rule.IntervalSeconds = 60
+			rule.For = time.Minute
+		})()
+		ids, err := store.InsertAlertRules(ctx, []models.AlertRule{*rule})
+		require.NoError(t, err)
+		for uid := range ids {
+			rule, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: uid})
+			require.NoError(t, err)
+			return rule
+		}
+		t.Fatal("the rule was not inserted")
+		return nil
+	}
+	setTitle := func(t *testing.T, rule *models.AlertRule, title string) {
+		t.Helper()
+		err := sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+			_, err := sess.Exec("UPDATE alert_rule SET title = ? WHERE uid = ? AND org_id = ?", title, rule.UID, rule.OrgID)
+			return err
+		})
+		require.NoError(t, err)
+	}
+	getTitle := func(t *testing.T, rule *models.AlertRule) string {
+		t.Helper()
+		result, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: rule.OrgID, UID: rule.UID})
+		require.NoError(t, err)
+		return result.Title
+	}
+
+	t.Run("should read the rules from the cache after the first read", func(t *testing.T) {
+		orgID := rand.Int63()
+		rule := insertRule(t, orgID)
+		query := &models.ListAlertRulesQuery{OrgID: orgID}
+		rules, err := store.ListAlertRules(ctx, query)
+		require.NoError(t, err)
+		require.Len(t, rules, 1)
+
+		setTitle(t, rule, "changed in the database")
+
+		require.Equal(t, rule.Title, getTitle(t, rule))
+		rules, err = store.ListAlertRules(ctx, query)
+		require.NoError(t, err)
+		require.Equal(t, rule.Title, rules[0].Title)
+
+		mockClock.Add(time.Minute)
+		require.Equal(t, "changed in the database", getTitle(t, rule))
+	})
+
+	t.Run("should not let the callers change the cached rules", func(t *testing.T) {
+		orgID := rand.Int63()
+		rule := insertRule(t, orgID)
+		result, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+		require.NoError(t, err)
+		result.Title = "changed by the caller"
+		result.Data[0].RefID = "changed by the caller"
+
+		result, err = store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+		require.NoError(t, err)
+		require.Equal(t, rule, result)
+	})
+
+	t.Run("should read the rules again after they are updated", func(t *testing.T) {
+		orgID := rand.Int63()
+		rule := insertRule(t, orgID)
+		query := &models.ListAlertRulesQuery{OrgID: orgID}
+		_, err := store.ListAlertRules(ctx, query)
+		require.NoError(t, err)
+
+		newRule := models.CopyRule(rule)
+		newRule.Title = util.GenerateShortUID()
+		err = store.UpdateAlertRules(ctx, []models.UpdateRule{{Existing: rule, New: *newRule}})
+		require.NoError(t, err)
+
+		require.Equal(t, newRule.Title, getTitle(t, rule))
+		rules, err := store.ListAlertRules(ctx, query)
+		require.NoError(t, err)
+		require.Equal(t, newRule.Title, rules[0].Title)
+	})
+
+	t.Run("should read the rules again after they are deleted", func(t *testing.T) {
+		orgID := rand.Int63()
+		rule := insertRule(t, orgID)
+		query := &models.ListAlertRulesQuery{OrgID: orgID}
+		_, err := store.ListAlertRules(ctx, query)
+		require.NoError(t, err)
+
+		require.NoError(t, store.DeleteAlertRulesByUID(ctx, orgID, rule.UID))
+
+		rules, err := store.ListAlertRules(ctx, query)
+		require.NoError(t, err)
+		require.Empty(t, rules)
+		_, err = store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+		require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+	})
+
+	t.Run("should keep the rules of the organizations apart", func(t *testing.T) {
+		orgID := rand.Int63()
+		otherOrgID := rand.Int63()
+		insertRule(t, orgID)
+		otherRule := insertRule(t, otherOrgID)
+		setTitle(t, otherRule, "changed in the database")
+
+		// writes in one organization do not clear the cached rules of the other
+		insertRule(t, orgID)
+		require.Equal(t, otherRule.Title, getTitle(t, otherRule))
+
+		// neither do the reads of one organization that are more than it can keep
+		for i := 0; i < 5; i++ {
+			_, err := store.ListAlertRules(ctx, &models.ListAlertRulesQuery{OrgID: orgID, RuleGroup: util.GenerateShortUID()})
+			require.NoError(t, err)
+		}
+		require.Equal(t, otherRule.Title, getTitle(t, otherRule))
+	})
+
+	t.Run("should not cache the reads in a transaction", func(t *testing.T) {
+		orgID := rand.Int63()
+		rule := insertRule(t, orgID)
+		setTitle(t, rule, "changed in the database")
+		err := sqlStore.InTransaction(ctx, func(ctx context.Context) error {
+			result, err := store.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{OrgID: orgID, UID: rule.UID})
+			require.NoError(t, err)
+			require.Equal(t, "changed in the database", result.Title)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+}
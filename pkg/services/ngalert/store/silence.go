@@ -0,0 +1,124 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// GetSilences returns the silences of the organization ordered by ID. They are read from SilenceCache if it is set.
+func (st DBstore) GetSilences(ctx context.Context, orgID int64) ([]*models.Silence, error) {
+	read := func() ([]*models.Silence, error) {
+		var result []*models.Silence
+		err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+			return sess.Where("org_id = ?", orgID).Asc("id").Find(&result)
+		})
+		return result, err
+	}
+	if st.SilenceCache == nil {
+		return read()
+	}
+	return st.SilenceCache.Get(ctx, orgID, read)
+}
+
+// GetSilence returns the silence of the organization with the given ID, or models.ErrSilenceNotFound.
+func (st DBstore) GetSilence(ctx context.Context, orgID, id int64) (*models.Silence, error) {
+	var result models.Silence
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		has, err := sess.Where("org_id = ? AND id = ?", orgID, id).Get(&result)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return models.ErrSilenceNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// InsertSilence saves a new silence and sets its ID.
+func (st DBstore) InsertSilence(ctx context.Context, silence *models.Silence) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		if _, err := sess.Insert(silence); err != nil {
+			return fmt.Errorf("failed to insert silence: %w", err)
+		}
+		st.invalidateSilenceCache(silence.OrgID)
+		return nil
+	})
+}
+
+// UpdateSilence replaces the silence with the ID and organization of the given one. It does not change the creator and the
+// creation time. Returns models.ErrSilenceNotFound if the silence does not exist.
+func (st DBstore) UpdateSilence(ctx context.Context, silence *models.Silence) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		updated, err := sess.Where("org_id = ? AND id = ?", silence.OrgID, silence.ID).
+			Cols("matchers", "starts_at", "ends_at", "comment", "updated").
+			Update(silence)
+		if err != nil {
+			return fmt.Errorf("failed to update silence: %w", err)
+		}
+		if updated == 0 {
+			return models.ErrSilenceNotFound
+		}
+		st.invalidateSilenceCache(silence.OrgID)
+		return nil
+	})
+}
+
+// DeleteSilence deletes the silence of the organization with the given ID. Returns models.ErrSilenceNotFound if the silence
+// does not exist.
+func (st DBstore) DeleteSilence(ctx context.Context, orgID, id int64) error {
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		deleted, err := sess.Where("org_id = ? AND id = ?", orgID, id).Delete(&models.Silence{})
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return models.ErrSilenceNotFound
+		}
+		st.invalidateSilenceCache(orgID)
+		return nil
+	})
+}
+
+// DeleteExpiredSilences deletes the silences of all organizations that ended before the given time, and returns how many
+// were deleted.
+func (st DBstore) DeleteExpiredSilences(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var err error
+		deleted, err = sess.Where("ends_at < ?", before).Delete(&models.Silence{})
+		return err
+	})
+	if deleted > 0 && st.SilenceCache != nil {
+		st.SilenceCache.InvalidateAll()
+	}
+	return deleted, err
+}
+
+// invalidateSilenceCache removes the cached silences of the organization after they are changed.
+func (st DBstore) invalidateSilenceCache(orgID int64) {
+	if st.SilenceCache != nil {
+		st.SilenceCache.InvalidateOrg(orgID)
+	}
+}
+
+// NewSilenceCache creates a cache that keeps the silences of each organization for ttl.
+func NewSilenceCache(ttl time.Duration) *OrgCache[[]*models.Silence] {
+	return NewOrgCache(ttl, func(silences []*models.Silence) []*models.Silence {
+		result := make([]*models.Silence, 0, len(silences))
+		for _, s := range silences {
+			silence := *s
+			silence.Matchers = append([]models.SilenceMatcher(nil), s.Matchers...)
+			result = append(result, &silence)
+		}
+		return result
+	})
+}
@@ -0,0 +1,143 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestIntegrationSilences(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	sqlStore := db.InitTestDB(t)
+	store := &DBstore{SQLStore: sqlStore}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	silence := &models.Silence{
+		OrgID:     1,
+		Matchers:  []models.SilenceMatcher{{Name: "cluster", Value: "staging", Type: models.SilenceMatchEqual}},
+		Start:     now,
+		End:       now.Add(4 * time.Hour),
+		Comment:   "staging upgrade",
+		CreatedBy: 42,
+		Created:   now,
+		Updated:   now,
+	}
+	require.NoError(t, store.InsertSilence(ctx, silence))
+	require.NotZero(t, silence.ID)
+	other := &models.Silence{
+		OrgID:    2,
+		Matchers: []models.SilenceMatcher{{Name: "cluster", Value: "staging"}},
+		Start:    now,
+		End:      now.Add(time.Hour),
+		Created:  now,
+		Updated:  now,
+	}
+	require.NoError(t, store.InsertSilence(ctx, other))
+
+	t.Run("should return the silences of the organization", func(t *testing.T) {
+		silences, err := store.GetSilences(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, silences, 1)
+		require.Equal(t, silence.ID, silences[0].ID)
+		require.Equal(t, silence.Matchers, silences[0].Matchers)
+		require.Equal(t, "staging upgrade", silences[0].Comment)
+		require.Equal(t, int64(42), silences[0].CreatedBy)
+		require.True(t, now.Equal(silences[0].Start))
+		require.True(t, now.Add(4*time.Hour).Equal(silences[0].End))
+	})
+
+	t.Run("should return not found if the silence belongs to another organization", func(t *testing.T) {
+		_, err := store.GetSilence(ctx, 1, other.ID)
+		require.ErrorIs(t, err, models.ErrSilenceNotFound)
+		require.ErrorIs(t, store.DeleteSilence(ctx, 1, other.ID), models.ErrSilenceNotFound)
+	})
+
+	t.Run("should update the silence but keep its creator", func(t *testing.T) {
+		update := *silence
+		update.Matchers = []models.SilenceMatcher{{Name: "cluster", Value: "dev"}}
+		update.Comment = "dev upgrade"
+		update.CreatedBy = 7
+		update.Updated = now.Add(time.Minute)
+		require.NoError(t, store.UpdateSilence(ctx, &update))
+
+		stored, err := store.GetSilence(ctx, 1, silence.ID)
+		require.NoError(t, err)
+		require.Equal(t, update.Matchers, stored.Matchers)
+		require.Equal(t, "dev upgrade", stored.Comment)
+		require.Equal(t, int64(42), stored.CreatedBy)
+
+		update.ID = other.ID
+		require.ErrorIs(t, store.UpdateSilence(ctx, &update), models.ErrSilenceNotFound)
+	})
+
+	t.Run("should delete expired silences of all organizations", func(t *testing.T) {
+		deleted, err := store.DeleteExpiredSilences(ctx, now.Add(2*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, int64(1), deleted)
+		_, err = store.GetSilence(ctx, 2, other.ID)
+		require.ErrorIs(t, err, models.ErrSilenceNotFound)
+		_, err = store.GetSilence(ctx, 1, silence.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("should delete the silence", func(t *testing.T) {
+		require.NoError(t, store.DeleteSilence(ctx, 1, silence.ID))
+		_, err := store.GetSilence(ctx, 1, silence.ID)
+		require.ErrorIs(t, err, models.ErrSilenceNotFound)
+	})
+}
+
+func TestIntegrationSilenceCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	store := &DBstore{SQLStore: db.InitTestDB(t), SilenceCache: NewSilenceCache(time.Hour)}
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	silences, err := store.GetSilences(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, silences)
+
+	silence := &models.Silence{
+		OrgID:    1,
+		Matchers: []models.SilenceMatcher{{Name: "cluster", Value: "staging"}},
+		Start:    now,
+		End:      now.Add(time.Hour),
+		Created:  now,
+		Updated:  now,
+	}
+	require.NoError(t, store.InsertSilence(ctx, silence))
+	silences, err = store.GetSilences(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, silences, 1, "inserting a silence should invalidate the cache")
+
+	silence.Comment = "changed"
+	require.NoError(t, store.UpdateSilence(ctx, silence))
+	silences, err = store.GetSilences(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, "changed", silences[0].Comment, "updating a silence should invalidate the cache")
+
+	deleted, err := store.DeleteExpiredSilences(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	silences, err = store.GetSilences(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, silences, "deleting expired silences should invalidate the cache")
+
+	require.NoError(t, store.InsertSilence(ctx, silence))
+	_, err = store.GetSilences(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, store.DeleteSilence(ctx, 1, silence.ID))
+	silences, err = store.GetSilences(ctx, 1)
+	require.NoError(t, err)
+	require.Empty(t, silences, "deleting a silence should invalidate the cache")
+}
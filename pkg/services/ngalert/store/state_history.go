@@ -0,0 +1,174 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// alertStateHistory is the row of the alert_state_history table.
+type alertStateHistory struct {
+	ID             int64  `xorm:"pk autoincr 'id'"`
+	RuleOrgID      int64  `xorm:"rule_org_id"`
+	RuleUID        string `xorm:"rule_uid"`
+	LabelsHash     string `xorm:"labels_hash"`
+	Labels         string `xorm:"labels"`
+	PreviousState  string `xorm:"previous_state"`
+	State          string `xorm:"state"`
+	StateReason    string `xorm:"state_reason"`
+	StateValues    string `xorm:"state_values"`
+	TransitionedAt int64  `xorm:"transitioned_at"`
+	ResetBy        string `xorm:"reset_by"`
+	// MaintenanceWindowID is the ID of the maintenance window that suppressed the notification of the transition.
+	MaintenanceWindowID int64 `xorm:"maintenance_window_id"`
+	// SilenceID is the ID of the silence that suppressed the notification of the transition.
+	SilenceID int64 `xorm:"silence_id"`
+}
+
+func (h alertStateHistory) TableName() string {
+	return "alert_state_history"
+}
+
+// SaveAlertInstancesWithHistory saves the alert instances and appends the state transitions to the state history
+// in a single transaction, so that the current state of instances and their history cannot diverge.
+func (st DBstore) SaveAlertInstancesWithHistory(ctx context.Context, instances []models.AlertInstance, history []models.AlertStateHistory) (err error) {
+	defer st.observe("save_alert_instances_with_history", time.Now(), &err)
+	rows, err := toStateHistoryRows(history)
+	if err != nil {
+		return err
+	}
+	return st.SQLStore.InTransaction(ctx, func(ctx context.Context) error {
+		if err := st.SaveAlertInstances(ctx, instances...); err != nil {
+			return err
+		}
+		return st.insertStateHistory(ctx, rows)
+	})
+}
+
+// DeleteAlertInstancesWithHistory deletes the alert instances with the provided keys and appends the state transitions to
+// the state history in a single transaction.
+func (st DBstore) DeleteAlertInstancesWithHistory(ctx context.Context, keys []models.AlertInstanceKey, history []models.AlertStateHistory) error {
+	rows, err := toStateHistoryRows(history)
+	if err != nil {
+		return err
+	}
+	return st.SQLStore.InTransaction(ctx, func(ctx context.Context) error {
+		if err := st.DeleteAlertInstances(ctx, keys...); err != nil {
+			return err
+		}
+		return st.insertStateHistory(ctx, rows)
+	})
+}
+
+func toStateHistoryRows(history []models.AlertStateHistory) ([]alertStateHistory, error) {
+	rows := make([]alertStateHistory, 0, len(history))
+	for _, h := range history {
+		values, err := h.Values.ToDB()
+		if err != nil {
+			return nil, err
+		}
+		labels, err := h.Labels.StringKey()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, alertStateHistory{
+			RuleOrgID:      h.RuleOrgID,
+			RuleUID:        h.RuleUID,
+			LabelsHash:     h.LabelsHash,
+			Labels:         labels,
+			PreviousState:  string(h.PreviousState),
+			State:          string(h.State),
+			StateReason:    h.StateReason,
+			StateValues:    string(values),
+			TransitionedAt: h.TransitionedAt.Unix(),
+			ResetBy:        h.ResetBy,
+
+			MaintenanceWindowID: h.MaintenanceWindowID,
+			SilenceID:           h.SilenceID,
+		})
+	}
+	return rows, nil
+}
+
+func (st DBstore) insertStateHistory(ctx context.Context, rows []alertStateHistory) error {
+	// every row takes a parameter per column but the ID
+	batchSize := st.statementBatchSize(reflect.TypeOf(alertStateHistory{}).NumField() - 1)
+	return st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		for start := 0; start < len(rows); start += batchSize {
+			end := start + batchSize
+			if end > len(rows) {
+				end = len(rows)
+			}
+			batch := rows[start:end]
+			if _, err := sess.Insert(&batch); err != nil {
+				return fmt.Errorf("failed to save alert state history: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetAlertStateHistory returns the state transitions of the instances of an alert rule ordered from the oldest to the newest.
+func (st DBstore) GetAlertStateHistory(ctx context.Context, query *models.GetAlertStateHistoryQuery) (result []*models.AlertStateHistory, err error) {
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		q := sess.Table(alertStateHistory{}).Where("rule_org_id = ? AND rule_uid = ?", query.OrgID, query.RuleUID)
+		if !query.From.IsZero() {
+			q = q.And("transitioned_at >= ?", query.From.Unix())
+		}
+		if !query.To.IsZero() {
+			q = q.And("transitioned_at < ?", query.To.Unix())
+		}
+		var rows []alertStateHistory
+		if err := q.Asc("transitioned_at", "id").Find(&rows); err != nil {
+			return err
+		}
+		result = make([]*models.AlertStateHistory, 0, len(rows))
+		for _, row := range rows {
+			var values models.InstanceValues
+			if err := values.FromDB([]byte(row.StateValues)); err != nil {
+				return err
+			}
+			var labels models.InstanceLabels
+			if err := labels.FromDB([]byte(row.Labels)); err != nil {
+				return fmt.Errorf("failed to decode the labels of a state transition: %w", err)
+			}
+			result = append(result, &models.AlertStateHistory{
+				RuleOrgID:      row.RuleOrgID,
+				RuleUID:        row.RuleUID,
+				LabelsHash:     row.LabelsHash,
+				Labels:         labels,
+				PreviousState:  models.InstanceStateType(row.PreviousState),
+				State:          models.InstanceStateType(row.State),
+				StateReason:    row.StateReason,
+				Values:         values,
+				TransitionedAt: time.Unix(row.TransitionedAt, 0),
+				ResetBy:        row.ResetBy,
+
+				MaintenanceWindowID: row.MaintenanceWindowID,
+				SilenceID:           row.SilenceID,
+			})
+		}
+		return nil
+	})
+	return result, err
+}
+
+// DeleteAlertStateHistory deletes at most limit state transitions that happened before the given time, starting from the oldest.
+// It returns the number of deleted transitions.
+func (st DBstore) DeleteAlertStateHistory(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var deleted int64
+	err := st.SQLStore.WithTransactionalDbSession(ctx, func(sess *db.Session) error {
+		var ids []int64
+		err := sess.Table(alertStateHistory{}).Cols("id").Where("transitioned_at < ?", before.Unix()).Asc("transitioned_at").Limit(limit).Find(&ids)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		deleted, err = sess.In("id", ids).Delete(&alertStateHistory{})
+		return err
+	})
+	return deleted, err
+}
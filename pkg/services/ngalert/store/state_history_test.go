@@ -0,0 +1,134 @@
+package store_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests"
+)
+
+func TestIntegrationAlertStateHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	_, dbstore := tests.SetupTestEnv(t, baseIntervalSeconds)
+	dbstore.Cfg.InstanceSaveBatchSize = 2
+
+	rule := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+	other := tests.CreateTestAlertRule(t, ctx, dbstore, 60, 1)
+
+	labels := models.InstanceLabels{"test": "a"}
+	_, hash, _ := labels.StringAndHash()
+	key := models.AlertInstanceKey{RuleOrgID: rule.OrgID, RuleUID: rule.UID, LabelsHash: hash}
+	start := time.Unix(1_000_000, 0)
+	transition := func(k models.AlertInstanceKey, offset time.Duration, prev, next models.InstanceStateType) models.AlertStateHistory {
+		return models.AlertStateHistory{
+			RuleOrgID:      k.RuleOrgID,
+			RuleUID:        k.RuleUID,
+			LabelsHash:     k.LabelsHash,
+			Labels:         labels,
+			PreviousState:  prev,
+			State:          next,
+			Values:         map[string]float64{"A": 1, "B": math.NaN()},
+			TransitionedAt: start.Add(offset),
+		}
+	}
+
+	// transitions are saved out of order and in several batches
+	history := []models.AlertStateHistory{
+		transition(key, 2*time.Minute, models.InstanceStateFiring, models.InstanceStateNormal),
+		transition(key, 0, models.InstanceStateNormal, models.InstanceStatePending),
+		transition(key, time.Minute, models.InstanceStatePending, models.InstanceStateFiring),
+		transition(models.AlertInstanceKey{RuleOrgID: other.OrgID, RuleUID: other.UID, LabelsHash: hash}, 0, models.InstanceStateNormal, models.InstanceStateFiring),
+	}
+	instance := models.AlertInstance{AlertInstanceKey: key, Labels: labels, CurrentState: models.InstanceStateNormal, LastEvalTime: start.Add(2 * time.Minute)}
+	require.NoError(t, dbstore.SaveAlertInstancesWithHistory(ctx, []models.AlertInstance{instance}, history))
+
+	t.Run("should save the instances in the same transaction", func(t *testing.T) {
+		saved, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		require.Len(t, saved, 1)
+
+		invalid := instance
+		invalid.CurrentState = "invalid"
+		extra := transition(key, 3*time.Minute, models.InstanceStateNormal, models.InstanceStateFiring)
+		require.Error(t, dbstore.SaveAlertInstancesWithHistory(ctx, []models.AlertInstance{invalid}, []models.AlertStateHistory{extra}))
+
+		result, err := dbstore.GetAlertStateHistory(ctx, &models.GetAlertStateHistoryQuery{OrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+	})
+
+	t.Run("should return transitions of the rule ordered by time", func(t *testing.T) {
+		result, err := dbstore.GetAlertStateHistory(ctx, &models.GetAlertStateHistoryQuery{OrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+		states := make([]models.InstanceStateType, 0, len(result))
+		for _, h := range result {
+			require.Equal(t, rule.UID, h.RuleUID)
+			require.Equal(t, labels, h.Labels)
+			require.Equal(t, 1.0, h.Values["A"])
+			require.True(t, math.IsNaN(h.Values["B"]))
+			states = append(states, h.State)
+		}
+		require.Equal(t, []models.InstanceStateType{models.InstanceStatePending, models.InstanceStateFiring, models.InstanceStateNormal}, states)
+		require.Equal(t, models.InstanceStatePending, result[1].PreviousState)
+		require.True(t, start.Add(time.Minute).Equal(result[1].TransitionedAt))
+	})
+
+	t.Run("should filter by time range", func(t *testing.T) {
+		result, err := dbstore.GetAlertStateHistory(ctx, &models.GetAlertStateHistoryQuery{
+			OrgID:   rule.OrgID,
+			RuleUID: rule.UID,
+			From:    start.Add(time.Minute),
+			To:      start.Add(2 * time.Minute),
+		})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, models.InstanceStateFiring, result[0].State)
+	})
+
+	t.Run("should delete instances and record who reset them", func(t *testing.T) {
+		reset := transition(key, 3*time.Minute, models.InstanceStateNormal, models.InstanceStateNormal)
+		reset.StateReason = models.StateReasonManualReset
+		reset.ResetBy = "editor"
+		require.NoError(t, dbstore.DeleteAlertInstancesWithHistory(ctx, []models.AlertInstanceKey{key}, []models.AlertStateHistory{reset}))
+
+		saved, err := dbstore.ListAlertInstances(ctx, &models.ListAlertInstancesQuery{RuleOrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		require.Empty(t, saved)
+
+		result, err := dbstore.GetAlertStateHistory(ctx, &models.GetAlertStateHistoryQuery{OrgID: rule.OrgID, RuleUID: rule.UID, From: start.Add(3 * time.Minute)})
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, models.StateReasonManualReset, result[0].StateReason)
+		require.Equal(t, "editor", result[0].ResetBy)
+	})
+
+	t.Run("should delete old transitions in batches", func(t *testing.T) {
+		before := start.Add(90 * time.Second)
+		n, err := dbstore.DeleteAlertStateHistory(ctx, before, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, n)
+
+		n, err = dbstore.DeleteAlertStateHistory(ctx, before, 2)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, n)
+
+		n, err = dbstore.DeleteAlertStateHistory(ctx, before, 2)
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		result, err := dbstore.GetAlertStateHistory(ctx, &models.GetAlertStateHistoryQuery{OrgID: rule.OrgID, RuleUID: rule.UID})
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		require.Equal(t, models.InstanceStateNormal, result[0].State)
+		require.Equal(t, models.StateReasonManualReset, result[1].StateReason)
+	})
+}
@@ -2,6 +2,10 @@ package store
 
 import "context"
 
+// InTransaction runs the function within a transaction that the stores join through the context. The transaction is
+// run again while the SQLite database is locked, because the writes of the function cannot retry on their own.
 func (st *DBstore) InTransaction(ctx context.Context, f func(ctx context.Context) error) error {
-	return st.SQLStore.InTransaction(ctx, f)
+	return st.retryOnLocked(ctx, func() error {
+		return st.SQLStore.InTransaction(ctx, f)
+	})
 }
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// UsageStats are the counts of the alerting objects that are reported in the usage statistics.
+type UsageStats struct {
+	Rules       int64
+	PausedRules int64
+	// RulesPerOrg is the number of rules of every organization that has rules.
+	RulesPerOrg map[int64]int64
+	// Instances is the number of alert instances in every state.
+	Instances map[models.InstanceStateType]int64
+	// ContactPointAssociations is the number of associations between rules and contact points.
+	ContactPointAssociations int64
+}
+
+// GetUsageStats counts the rules, instances and contact point associations. Only counts are read from the database,
+// never the rows.
+func (st DBstore) GetUsageStats(ctx context.Context) (result *UsageStats, err error) {
+	defer st.observe("get_usage_stats", time.Now(), &err)
+	result = &UsageStats{
+		RulesPerOrg: make(map[int64]int64),
+		Instances:   make(map[models.InstanceStateType]int64),
+	}
+	err = st.SQLStore.WithDbSession(ctx, func(sess *db.Session) error {
+		var rules []struct {
+			OrgID    int64 `xorm:"org_id"`
+			IsPaused bool
+			Count    int64
+		}
+		if err := sess.SQL("SELECT org_id, is_paused, COUNT(*) AS count FROM alert_rule GROUP BY org_id, is_paused").Find(&rules); err != nil {
+			return err
+		}
+		for _, r := range rules {
+			result.Rules += r.Count
+			result.RulesPerOrg[r.OrgID] += r.Count
+			if r.IsPaused {
+				result.PausedRules += r.Count
+			}
+		}
+
+		var instances []struct {
+			CurrentState models.InstanceStateType
+			Count        int64
+		}
+		if err := sess.SQL("SELECT current_state, COUNT(*) AS count FROM alert_instance GROUP BY current_state").Find(&instances); err != nil {
+			return err
+		}
+		for _, i := range instances {
+			result.Instances[i.CurrentState] = i.Count
+		}
+
+		associations, err := sess.Table("alert_rule_contact_point").Count()
+		if err != nil {
+			return err
+		}
+		result.ContactPointAssociations = associations
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -5,17 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/folder"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/util"
 )
 
-// FakeRuleStore mocks the RuleStore of the scheduler.
+// RuleStore is an in-memory store of alert rules and alert instances for unit tests. It has the semantics of the database
+// store that matter to its callers: the data is scoped by organization, missing rules are reported with typed errors, and
+// the deletions return the number of deleted rows.
 type RuleStore struct {
 	t   *testing.T
 	mtx sync.Mutex
@@ -24,6 +29,18 @@ type RuleStore struct {
 	Hook        func(cmd interface{}) error // use Hook if you need to intercept some query and return an error
 	RecordedOps []interface{}
 	Folders     map[int64][]*folder.Folder
+	// OrgID -> latest evaluations of rules
+	Evaluations map[int64][]*models.AlertRuleEvaluation
+	// OrgID -> alert instances
+	Instances map[int64][]*models.AlertInstance
+	// State transitions of alert instances, from the oldest
+	History []models.AlertStateHistory
+	// OrgID -> name of the organization
+	OrgNames map[int64]string
+	// OrgID -> audit entries of rules, from the oldest
+	AuditEntries map[int64][]*models.AlertRuleAuditEntry
+	// Folder UID -> permission of the user on the folder. If set, folders without a permission are not visible.
+	FolderPermissions map[string]dashboards.PermissionType
 }
 
 type GenericRecordedQuery struct {
@@ -38,7 +55,9 @@ func NewRuleStore(t *testing.T) *RuleStore {
 		Hook: func(interface{}) error {
 			return nil
 		},
-		Folders: map[int64][]*folder.Folder{},
+		Folders:     map[int64][]*folder.Folder{},
+		Evaluations: map[int64][]*models.AlertRuleEvaluation{},
+		Instances:   map[int64][]*models.AlertInstance{},
 	}
 }
 
@@ -94,6 +113,8 @@ func (f *RuleStore) GetRecordedCommands(predicate func(cmd interface{}) (interfa
 }
 
 func (f *RuleStore) DeleteAlertRulesByUID(_ context.Context, orgID int64, UIDs ...string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
 	f.RecordedOps = append(f.RecordedOps, GenericRecordedQuery{
 		Name:   "DeleteAlertRulesByUID",
 		Params: []interface{}{orgID, UIDs},
@@ -117,6 +138,30 @@ func (f *RuleStore) DeleteAlertRulesByUID(_ context.Context, orgID int64, UIDs .
 	}
 
 	f.Rules[orgID] = result
+
+	// like the database store, delete the instances and evaluations of the rules with them
+	deleted := func(ruleUID string) bool {
+		for _, UID := range UIDs {
+			if ruleUID == UID {
+				return true
+			}
+		}
+		return false
+	}
+	instances := make([]*models.AlertInstance, 0, len(f.Instances[orgID]))
+	for _, instance := range f.Instances[orgID] {
+		if !deleted(instance.RuleUID) {
+			instances = append(instances, instance)
+		}
+	}
+	f.Instances[orgID] = instances
+	evaluations := make([]*models.AlertRuleEvaluation, 0, len(f.Evaluations[orgID]))
+	for _, evaluation := range f.Evaluations[orgID] {
+		if !deleted(evaluation.RuleUID) {
+			evaluations = append(evaluations, evaluation)
+		}
+	}
+	f.Evaluations[orgID] = evaluations
 	return nil
 }
 
@@ -129,7 +174,7 @@ func (f *RuleStore) GetAlertRuleByUID(_ context.Context, q *models.GetAlertRuleB
 	}
 	rules, ok := f.Rules[q.OrgID]
 	if !ok {
-		return nil, nil
+		return nil, models.ErrAlertRuleNotFound
 	}
 
 	for _, rule := range rules {
@@ -137,7 +182,7 @@ func (f *RuleStore) GetAlertRuleByUID(_ context.Context, q *models.GetAlertRuleB
 			return rule, nil
 		}
 	}
-	return nil, nil
+	return nil, models.ErrAlertRuleNotFound
 }
 
 func (f *RuleStore) GetAlertRulesGroupByRuleUID(_ context.Context, q *models.GetAlertRulesGroupByRuleUIDQuery) ([]*models.AlertRule, error) {
@@ -222,12 +267,189 @@ func (f *RuleStore) ListAlertRules(_ context.Context, q *models.ListAlertRulesQu
 		if q.RuleGroup != "" && r.RuleGroup != q.RuleGroup {
 			continue
 		}
+		if q.TitleSearch != "" && !strings.Contains(strings.ToLower(r.Title), strings.ToLower(q.TitleSearch)) {
+			continue
+		}
 		ruleList = append(ruleList, r)
 	}
 
 	return ruleList, nil
 }
 
+func (f *RuleStore) GetAlertRuleEvaluations(_ context.Context, q *models.GetAlertRuleEvaluationsQuery) ([]*models.AlertRuleEvaluation, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	result := make([]*models.AlertRuleEvaluation, 0)
+	for _, e := range f.Evaluations[q.OrgID] {
+		if len(q.RuleUIDs) > 0 {
+			var ok bool
+			for _, uid := range q.RuleUIDs {
+				if uid == e.RuleUID {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+func (f *RuleStore) ListAlertInstances(_ context.Context, q *models.ListAlertInstancesQuery) ([]*models.AlertInstance, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, *q)
+	result := f.filterAlertInstances(q)
+	if q.Limit > 0 {
+		sortAlertInstances(result)
+		if q.Offset >= int64(len(result)) {
+			return []*models.AlertInstance{}, nil
+		}
+		end := q.Offset + q.Limit
+		if end > int64(len(result)) {
+			end = int64(len(result))
+		}
+		result = result[q.Offset:end]
+	}
+	return result, nil
+}
+
+func (f *RuleStore) CountAlertInstances(_ context.Context, q *models.ListAlertInstancesQuery) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return int64(len(f.filterAlertInstances(q))), nil
+}
+
+func (f *RuleStore) ListAlertRuleAuditEntries(_ context.Context, q *models.ListAlertRuleAuditQuery) ([]*models.AlertRuleAuditEntry, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, *q)
+	result := f.filterAlertRuleAuditEntries(q)
+	if q.Limit > 0 {
+		if q.Offset >= int64(len(result)) {
+			return []*models.AlertRuleAuditEntry{}, nil
+		}
+		end := q.Offset + q.Limit
+		if end > int64(len(result)) {
+			end = int64(len(result))
+		}
+		result = result[q.Offset:end]
+	}
+	return result, nil
+}
+
+func (f *RuleStore) CountAlertRuleAuditEntries(_ context.Context, q *models.ListAlertRuleAuditQuery) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return int64(len(f.filterAlertRuleAuditEntries(q))), nil
+}
+
+// filterAlertRuleAuditEntries returns the audit entries of the rule of the query, from the newest.
+func (f *RuleStore) filterAlertRuleAuditEntries(q *models.ListAlertRuleAuditQuery) []*models.AlertRuleAuditEntry {
+	entries := f.AuditEntries[q.OrgID]
+	result := make([]*models.AlertRuleAuditEntry, 0)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].RuleUID == q.RuleUID {
+			result = append(result, entries[i])
+		}
+	}
+	return result
+}
+
+func (f *RuleStore) GetAlertInstanceStateSummaries(_ context.Context, q *models.GetAlertInstanceStateSummariesQuery) ([]*models.AlertInstanceStateSummary, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	summaries := make(map[string]*models.AlertInstanceStateSummary)
+	result := make([]*models.AlertInstanceStateSummary, 0)
+	for _, instance := range f.Instances[q.OrgID] {
+		if len(q.RuleUIDs) > 0 {
+			var ok bool
+			for _, uid := range q.RuleUIDs {
+				if uid == instance.RuleUID {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+		}
+		summary, ok := summaries[instance.RuleUID]
+		if !ok {
+			summary = &models.AlertInstanceStateSummary{RuleUID: instance.RuleUID, Counts: make(map[models.InstanceStateType]int64)}
+			summaries[instance.RuleUID] = summary
+			result = append(result, summary)
+		}
+		summary.Counts[instance.CurrentState]++
+	}
+	return result, nil
+}
+
+func (f *RuleStore) filterAlertInstances(q *models.ListAlertInstancesQuery) []*models.AlertInstance {
+	result := make([]*models.AlertInstance, 0)
+	for _, instance := range f.Instances[q.RuleOrgID] {
+		if q.RuleUID != "" && instance.RuleUID != q.RuleUID {
+			continue
+		}
+		if len(q.States) > 0 {
+			var ok bool
+			for _, state := range q.States {
+				if instance.CurrentState == state {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+		}
+		if !matchesLabels(instance.Labels, q.Labels) {
+			continue
+		}
+		result = append(result, instance)
+	}
+	return result
+}
+
+// sortAlertInstances orders the instances like the database store does when a page is requested.
+func sortAlertInstances(instances []*models.AlertInstance) {
+	severity := func(state models.InstanceStateType) int {
+		for i, s := range models.InstanceStatesBySeverity {
+			if s == state {
+				return i
+			}
+		}
+		return len(models.InstanceStatesBySeverity)
+	}
+	sort.SliceStable(instances, func(i, j int) bool {
+		a, b := instances[i], instances[j]
+		if a.RuleUID != b.RuleUID {
+			return a.RuleUID < b.RuleUID
+		}
+		if sa, sb := severity(a.CurrentState), severity(b.CurrentState); sa != sb {
+			return sa < sb
+		}
+		la, _ := a.Labels.StringKey()
+		lb, _ := b.Labels.StringKey()
+		if la != lb {
+			return la < lb
+		}
+		return a.LabelsHash < b.LabelsHash
+	})
+}
+
+func matchesLabels(labels models.InstanceLabels, matchers map[string]string) bool {
+	for name, value := range matchers {
+		if v, ok := labels[name]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
 func (f *RuleStore) GetUserVisibleNamespaces(_ context.Context, orgID int64, _ *user.SignedInUser) (map[string]*folder.Folder, error) {
 	f.mtx.Lock()
 	defer f.mtx.Unlock()
@@ -240,21 +462,35 @@ func (f *RuleStore) GetUserVisibleNamespaces(_ context.Context, orgID int64, _ *
 	}
 
 	for _, folder := range f.Folders[orgID] {
+		if !f.canAccessFolder(folder.UID, dashboards.PERMISSION_VIEW) {
+			continue
+		}
 		namespacesMap[folder.UID] = folder
 	}
 	return namespacesMap, nil
 }
 
-func (f *RuleStore) GetNamespaceByTitle(_ context.Context, title string, orgID int64, _ *user.SignedInUser, _ bool) (*folder.Folder, error) {
+func (f *RuleStore) GetNamespaceByTitle(_ context.Context, title string, orgID int64, _ *user.SignedInUser, withCanSave bool) (*folder.Folder, error) {
 	folders := f.Folders[orgID]
 	for _, folder := range folders {
-		if folder.Title == title {
+		if folder.Title == title && f.canAccessFolder(folder.UID, dashboards.PERMISSION_VIEW) {
+			if withCanSave && !f.canAccessFolder(folder.UID, dashboards.PERMISSION_EDIT) {
+				return nil, models.ErrCannotEditNamespace
+			}
 			return folder, nil
 		}
 	}
 	return nil, fmt.Errorf("not found")
 }
 
+// canAccessFolder returns true if FolderPermissions is not set or grants at least the given permission on the folder.
+func (f *RuleStore) canAccessFolder(uid string, permission dashboards.PermissionType) bool {
+	if f.FolderPermissions == nil {
+		return true
+	}
+	return f.FolderPermissions[uid] >= permission
+}
+
 func (f *RuleStore) GetNamespaceByUID(_ context.Context, uid string, orgID int64, _ *user.SignedInUser) (*folder.Folder, error) {
 	f.RecordedOps = append(f.RecordedOps, GenericRecordedQuery{
 		Name:   "GetNamespaceByUID",
@@ -277,6 +513,17 @@ func (f *RuleStore) UpdateAlertRules(_ context.Context, q []models.UpdateRule) e
 	if err := f.Hook(q); err != nil {
 		return err
 	}
+	for _, update := range q {
+		rules := f.Rules[update.New.OrgID]
+		for idx, r := range rules {
+			if r.UID == update.New.UID {
+				updated := update.New
+				updated.Version = update.Existing.Version + 1
+				rules[idx] = &updated
+				break
+			}
+		}
+	}
 	return nil
 }
 
@@ -288,6 +535,40 @@ func (f *RuleStore) InsertAlertRules(_ context.Context, q []models.AlertRule) (m
 	if err := f.Hook(q); err != nil {
 		return ids, err
 	}
+	var lastID int64
+	for _, rules := range f.Rules {
+		for _, r := range rules {
+			if r.ID > lastID {
+				lastID = r.ID
+			}
+		}
+	}
+	inserted := make([]*models.AlertRule, 0, len(q))
+	for i := range q {
+		r := q[i]
+		if r.UID == "" {
+			r.UID = util.GenerateShortUID()
+		}
+		for _, existing := range append(f.Rules[r.OrgID], inserted...) {
+			if existing.OrgID != r.OrgID {
+				continue
+			}
+			if existing.UID == r.UID || (existing.NamespaceUID == r.NamespaceUID && existing.Title == r.Title) {
+				return ids, models.ErrAlertRuleUniqueConstraintViolation
+			}
+		}
+		if err := r.PreSave(time.Now); err != nil {
+			return ids, err
+		}
+		lastID++
+		r.ID = lastID
+		r.Version = 1
+		inserted = append(inserted, &r)
+	}
+	for _, r := range inserted {
+		f.Rules[r.OrgID] = append(f.Rules[r.OrgID], r)
+		ids[r.UID] = r.ID
+	}
 	return ids, nil
 }
 
@@ -344,6 +625,287 @@ func (f *RuleStore) IncreaseVersionForAllRulesInNamespace(_ context.Context, org
 	return result, nil
 }
 
-func (f *RuleStore) Count(ctx context.Context, orgID int64) (int64, error) {
-	return 0, nil
+// Count returns the number of the alert rules of the organization, or of all organizations if orgID is zero.
+func (f *RuleStore) Count(_ context.Context, orgID int64) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	if orgID != 0 {
+		return int64(len(f.Rules[orgID])), nil
+	}
+	var count int64
+	for _, rules := range f.Rules {
+		count += int64(len(rules))
+	}
+	return count, nil
+}
+
+// CheckHealth returns the error of Hook, so that tests can simulate a database that cannot be reached.
+func (f *RuleStore) CheckHealth(_ context.Context) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.Hook(GenericRecordedQuery{Name: "CheckHealth"})
+}
+
+// allRules returns the alert rules of all organizations, ordered by organization and ID like the database store does.
+func (f *RuleStore) allRules() []*models.AlertRule {
+	result := make([]*models.AlertRule, 0)
+	for _, rules := range f.Rules {
+		result = append(result, rules...)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].OrgID != result[j].OrgID {
+			return result[i].OrgID < result[j].OrgID
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func (f *RuleStore) GetAlertRulesKeysForScheduling(_ context.Context) ([]models.AlertRuleKeyWithVersion, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, GenericRecordedQuery{Name: "GetAlertRulesKeysForScheduling"})
+	result := make([]models.AlertRuleKeyWithVersion, 0)
+	for _, rule := range f.allRules() {
+		result = append(result, models.AlertRuleKeyWithVersion{
+			Version:      rule.Version,
+			AlertRuleKey: rule.GetKey(),
+		})
+	}
+	return result, nil
+}
+
+func (f *RuleStore) GetAlertRulesForScheduling(_ context.Context, q *models.GetAlertRulesForSchedulingQuery) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, *q)
+	if err := f.Hook(*q); err != nil {
+		return err
+	}
+	q.ResultRules = f.allRules()
+	if q.PopulateFolders {
+		q.ResultFoldersTitles = make(map[string]string)
+		for _, rule := range q.ResultRules {
+			for _, folder := range f.Folders[rule.OrgID] {
+				if folder.UID == rule.NamespaceUID {
+					q.ResultFoldersTitles[folder.UID] = folder.Title
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// filterRulesAcrossOrgs returns the alert rules of all organizations that match the query, regardless of its page.
+func (f *RuleStore) filterRulesAcrossOrgs(q *models.ListAlertRulesAcrossOrgsQuery) []*models.AlertRule {
+	result := make([]*models.AlertRule, 0)
+	for _, rule := range f.allRules() {
+		if q.DatasourceUID != "" {
+			var ok bool
+			for _, uid := range rule.GetDatasourceUIDs() {
+				if uid == q.DatasourceUID {
+					ok = true
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+		}
+		result = append(result, rule)
+	}
+	return result
+}
+
+func (f *RuleStore) ListAlertRulesAcrossOrgs(_ context.Context, q *models.ListAlertRulesAcrossOrgsQuery) ([]*models.AlertRuleWithOrg, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, *q)
+	rules := f.filterRulesAcrossOrgs(q)
+	if q.Limit > 0 {
+		if q.Offset >= int64(len(rules)) {
+			return []*models.AlertRuleWithOrg{}, nil
+		}
+		end := q.Offset + q.Limit
+		if end > int64(len(rules)) {
+			end = int64(len(rules))
+		}
+		rules = rules[q.Offset:end]
+	}
+	result := make([]*models.AlertRuleWithOrg, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, &models.AlertRuleWithOrg{AlertRule: rule, OrgName: f.OrgNames[rule.OrgID]})
+	}
+	return result, nil
+}
+
+func (f *RuleStore) CountAlertRulesAcrossOrgs(_ context.Context, q *models.ListAlertRulesAcrossOrgsQuery) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return int64(len(f.filterRulesAcrossOrgs(q))), nil
+}
+
+// FetchOrgIds returns the organizations that have alert instances.
+func (f *RuleStore) FetchOrgIds(_ context.Context) ([]int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	result := make([]int64, 0, len(f.Instances))
+	for orgID, instances := range f.Instances {
+		if len(instances) > 0 {
+			result = append(result, orgID)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, nil
+}
+
+func (f *RuleStore) ListAlertInstancesPage(_ context.Context, q *models.ListAlertInstancesPageQuery) ([]*models.AlertInstance, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.RecordedOps = append(f.RecordedOps, *q)
+	instances := make([]*models.AlertInstance, len(f.Instances[q.RuleOrgID]))
+	copy(instances, f.Instances[q.RuleOrgID])
+	sort.Slice(instances, func(i, j int) bool {
+		return compareInstanceKeys(instances[i].AlertInstanceKey, instances[j].AlertInstanceKey) < 0
+	})
+	result := make([]*models.AlertInstance, 0, q.Limit)
+	for _, instance := range instances {
+		if int64(len(result)) >= q.Limit {
+			break
+		}
+		if q.After != nil && compareInstanceKeys(instance.AlertInstanceKey, *q.After) <= 0 {
+			continue
+		}
+		result = append(result, instance)
+	}
+	return result, nil
+}
+
+// compareInstanceKeys orders the keys of the instances of an organization by rule UID and labels hash.
+func compareInstanceKeys(a, b models.AlertInstanceKey) int {
+	if a.RuleUID != b.RuleUID {
+		return strings.Compare(a.RuleUID, b.RuleUID)
+	}
+	return strings.Compare(a.LabelsHash, b.LabelsHash)
+}
+
+// SaveAlertInstances inserts the instances, or replaces those that already exist.
+func (f *RuleStore) SaveAlertInstances(_ context.Context, q ...models.AlertInstance) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.saveAlertInstances(q)
+	return nil
+}
+
+func (f *RuleStore) SaveAlertInstancesWithHistory(_ context.Context, instances []models.AlertInstance, history []models.AlertStateHistory) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.saveAlertInstances(instances)
+	f.History = append(f.History, history...)
+	return nil
+}
+
+func (f *RuleStore) saveAlertInstances(instances []models.AlertInstance) {
+mainloop:
+	for i := range instances {
+		instance := instances[i]
+		f.RecordedOps = append(f.RecordedOps, instance)
+		existing := f.Instances[instance.RuleOrgID]
+		for idx, e := range existing {
+			if e.AlertInstanceKey == instance.AlertInstanceKey {
+				existing[idx] = &instance
+				continue mainloop
+			}
+		}
+		f.Instances[instance.RuleOrgID] = append(existing, &instance)
+	}
+}
+
+func (f *RuleStore) DeleteAlertInstances(_ context.Context, keys ...models.AlertInstanceKey) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.deleteAlertInstances(keys)
+	return nil
+}
+
+func (f *RuleStore) DeleteAlertInstancesWithHistory(_ context.Context, keys []models.AlertInstanceKey, history []models.AlertStateHistory) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.deleteAlertInstances(keys)
+	f.History = append(f.History, history...)
+	return nil
+}
+
+// deleteAlertInstances deletes the instances with the keys, and returns the number of deleted instances.
+func (f *RuleStore) deleteAlertInstances(keys []models.AlertInstanceKey) int64 {
+	f.RecordedOps = append(f.RecordedOps, GenericRecordedQuery{
+		Name:   "DeleteAlertInstances",
+		Params: []interface{}{keys},
+	})
+	var deleted int64
+	for _, key := range keys {
+		instances := f.Instances[key.RuleOrgID]
+		for idx, instance := range instances {
+			if instance.AlertInstanceKey == key {
+				f.Instances[key.RuleOrgID] = append(instances[:idx:idx], instances[idx+1:]...)
+				deleted++
+				break
+			}
+		}
+	}
+	return deleted
+}
+
+func (f *RuleStore) DeleteAlertInstancesByRule(_ context.Context, key models.AlertRuleKey) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	keys := make([]models.AlertInstanceKey, 0)
+	for _, instance := range f.Instances[key.OrgID] {
+		if instance.RuleUID == key.UID {
+			keys = append(keys, instance.AlertInstanceKey)
+		}
+	}
+	f.deleteAlertInstances(keys)
+	return nil
+}
+
+// DeleteOrphanedAlertInstances deletes at most limit instances of alert rules that do not exist, and returns the number
+// of deleted instances.
+func (f *RuleStore) DeleteOrphanedAlertInstances(_ context.Context, limit int) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	keys := make([]models.AlertInstanceKey, 0)
+	for orgID, instances := range f.Instances {
+	instanceLoop:
+		for _, instance := range instances {
+			if len(keys) >= limit {
+				break
+			}
+			for _, rule := range f.Rules[orgID] {
+				if rule.UID == instance.RuleUID {
+					continue instanceLoop
+				}
+			}
+			keys = append(keys, instance.AlertInstanceKey)
+		}
+	}
+	return f.deleteAlertInstances(keys), nil
+}
+
+// DeleteAlertStateHistory deletes at most limit state transitions that happened before the given time, and returns the
+// number of deleted transitions.
+func (f *RuleStore) DeleteAlertStateHistory(_ context.Context, before time.Time, limit int) (int64, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	history := make([]models.AlertStateHistory, 0, len(f.History))
+	var deleted int64
+	for _, h := range f.History {
+		if h.TransitionedAt.Before(before) && deleted < int64(limit) {
+			deleted++
+			continue
+		}
+		history = append(history, h)
+	}
+	f.History = history
+	return deleted, nil
 }
@@ -0,0 +1,63 @@
+package ngalert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// rulesPerOrgBuckets are the upper bounds of the buckets in which the organizations are counted by their number of
+// rules. Organizations with more rules than the last bound are counted in the "gt" bucket.
+var rulesPerOrgBuckets = []int64{10, 100, 1000}
+
+// usageStatsInstanceStates are the states of alert instances that are reported, even if no instance is in them.
+var usageStatsInstanceStates = []models.InstanceStateType{
+	models.InstanceStateNormal,
+	models.InstanceStateFiring,
+	models.InstanceStatePending,
+	models.InstanceStateNoData,
+	models.InstanceStateError,
+}
+
+func (ng *AlertNG) getUsageStats(ctx context.Context) (map[string]interface{}, error) {
+	stats, err := ng.store.GetUsageStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]interface{}{
+		"stats.unified_alerting.rules.count":                      stats.Rules,
+		"stats.unified_alerting.rules.paused.count":               stats.PausedRules,
+		"stats.unified_alerting.contact_point_associations.count": stats.ContactPointAssociations,
+	}
+
+	orgsByRules := make(map[string]int64, len(rulesPerOrgBuckets)+1)
+	for _, bound := range rulesPerOrgBuckets {
+		orgsByRules[fmt.Sprintf("le_%d", bound)] = 0
+	}
+	orgsByRules[fmt.Sprintf("gt_%d", rulesPerOrgBuckets[len(rulesPerOrgBuckets)-1])] = 0
+	for _, count := range stats.RulesPerOrg {
+		orgsByRules[rulesPerOrgBucket(count)]++
+	}
+	for bucket, count := range orgsByRules {
+		m[fmt.Sprintf("stats.unified_alerting.orgs_by_rules.%s.count", bucket)] = count
+	}
+
+	for _, state := range usageStatsInstanceStates {
+		m[fmt.Sprintf("stats.unified_alerting.instances.%s.count", strings.ToLower(string(state)))] = stats.Instances[state]
+	}
+
+	return m, nil
+}
+
+// rulesPerOrgBucket returns the name of the bucket of an organization with count rules.
+func rulesPerOrgBucket(count int64) string {
+	for _, bound := range rulesPerOrgBuckets {
+		if count <= bound {
+			return fmt.Sprintf("le_%d", bound)
+		}
+	}
+	return fmt.Sprintf("gt_%d", rulesPerOrgBuckets[len(rulesPerOrgBuckets)-1])
+}
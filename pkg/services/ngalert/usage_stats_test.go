@@ -0,0 +1,123 @@
+package ngalert
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/usagestats"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+func TestIntegrationUsageStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test")
+	}
+	ctx := context.Background()
+	dbstore := &store.DBstore{
+		SQLStore: db.InitTestDB(t),
+		Cfg: setting.UnifiedAlertingSettings{
+			BaseInterval: 10 * time.Second,
+		},
+		Logger: log.NewNopLogger(),
+	}
+	require.NoError(t, dbstore.SaveAlertmanagerConfiguration(ctx, &models.SaveAlertmanagerConfigurationCmd{
+		AlertmanagerConfiguration: `{
+			"alertmanager_config": {
+				"route": {"receiver": "team"},
+				"receivers": [{
+					"name": "team",
+					"grafana_managed_receiver_configs": [
+						{"uid": "email", "name": "team", "type": "email", "settings": {"addresses": "team@example.com"}},
+						{"uid": "slack", "name": "team", "type": "slack", "settings": {"url": "http://localhost"}}
+					]
+				}]
+			}
+		}`,
+		ConfigurationVersion: "v1",
+		OrgID:                1,
+	}))
+
+	uniqueID := models.WithUniqueID()
+	gen := func(orgID int64, isPaused bool, contactPoints ...string) models.AlertRule {
+		return *models.AlertRuleGen(uniqueID, models.WithOrgID(orgID), models.WithIsPaused(isPaused), func(rule *models.AlertRule) {
+			rule.IntervalSeconds = 60
+			rule.For = time.Minute
+			rule.ContactPointUIDs = contactPoints
+		})()
+	}
+	rules := []models.AlertRule{
+		gen(1, false, "email", "slack"),
+		gen(1, true, "email"),
+		gen(1, false),
+	}
+	for i := 0; i < 11; i++ {
+		rules = append(rules, gen(2, i%2 == 0))
+	}
+	_, err := dbstore.InsertAlertRules(ctx, rules)
+	require.NoError(t, err)
+
+	var instances []models.AlertInstance
+	for _, state := range []models.InstanceStateType{models.InstanceStateFiring, models.InstanceStateFiring, models.InstanceStateNormal} {
+		instances = append(instances, *models.AlertInstanceGen(func(instance *models.AlertInstance) {
+			instance.RuleOrgID = 1
+			instance.RuleUID = rules[0].UID
+			instance.CurrentState = state
+		}))
+	}
+	require.NoError(t, dbstore.SaveAlertInstances(ctx, instances...))
+
+	usageStats := &usagestats.UsageStatsMock{T: t}
+	ng := &AlertNG{store: dbstore}
+	usageStats.RegisterMetricsFunc(ng.getUsageStats)
+
+	report, err := usageStats.GetUsageReport(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"stats.unified_alerting.rules.count":                      int64(14),
+		"stats.unified_alerting.rules.paused.count":               int64(7),
+		"stats.unified_alerting.contact_point_associations.count": int64(3),
+		"stats.unified_alerting.orgs_by_rules.le_10.count":        int64(1),
+		"stats.unified_alerting.orgs_by_rules.le_100.count":       int64(1),
+		"stats.unified_alerting.orgs_by_rules.le_1000.count":      int64(0),
+		"stats.unified_alerting.orgs_by_rules.gt_1000.count":      int64(0),
+		"stats.unified_alerting.instances.normal.count":           int64(1),
+		"stats.unified_alerting.instances.alerting.count":         int64(2),
+		"stats.unified_alerting.instances.pending.count":          int64(0),
+		"stats.unified_alerting.instances.nodata.count":           int64(0),
+		"stats.unified_alerting.instances.error.count":            int64(0),
+	}, report.Metrics)
+}
+
+func TestRulesPerOrgBucket(t *testing.T) {
+	for count, bucket := range map[int64]string{
+		1:    "le_10",
+		10:   "le_10",
+		11:   "le_100",
+		1000: "le_1000",
+		1001: "gt_1000",
+	} {
+		require.Equal(t, bucket, rulesPerOrgBucket(count), "count %d", count)
+	}
+}
+
+func TestUsageStatsNotRegisteredWhenDisabled(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.UnifiedAlerting.Enabled = util.Pointer(false)
+	usageStats := &usagestats.UsageStatsMock{T: t}
+
+	ng, err := ProvideService(cfg, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, usageStats)
+	require.NoError(t, err)
+	require.True(t, ng.IsDisabled())
+
+	report, err := usageStats.GetUsageReport(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, report.Metrics)
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/grafana/grafana/pkg/util"
@@ -27,6 +28,35 @@ type Webhook struct {
 	Validation func(body []byte, statusCode int) error
 }
 
+// WebhookResponseError is returned when the webhook responds with a status code other than 2xx.
+type WebhookResponseError struct {
+	StatusCode int
+	Status     string
+	// RetryAfter is the delay that the webhook asked for with the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (e WebhookResponseError) Error() string {
+	return fmt.Sprintf("webhook response status %v", e.Status)
+}
+
+// parseRetryAfter parses the value of the Retry-After header, which is either a number of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
 // WebhookClient exists to mock the client in tests.
 type WebhookClient interface {
 	Do(req *http.Request) (*http.Response, error)
@@ -107,5 +137,9 @@ func (ns *NotificationService) sendWebRequestSync(ctx context.Context, webhook *
 	}
 
 	ns.log.Debug("Webhook failed", "url", webhook.Url, "statuscode", resp.Status, "body", string(body))
-	return fmt.Errorf("webhook response status %v", resp.Status)
+	return WebhookResponseError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
 }
@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWebhookClient struct {
+	resp *http.Response
+}
+
+func (c *fakeWebhookClient) Do(_ *http.Request) (*http.Response, error) {
+	return c.resp, nil
+}
+
+func TestSendWebRequestSync_ResponseError(t *testing.T) {
+	original := netClient
+	t.Cleanup(func() { netClient = original })
+
+	header := http.Header{}
+	header.Set("Retry-After", "30")
+	netClient = &fakeWebhookClient{resp: &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Status:     "429 Too Many Requests",
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("slow down")),
+	}}
+
+	ns, _ := createSut(t, newBus(t))
+	err := ns.sendWebRequestSync(context.Background(), &Webhook{Url: "http://localhost/hook"})
+
+	var respErr WebhookResponseError
+	require.True(t, errors.As(err, &respErr))
+	require.Equal(t, http.StatusTooManyRequests, respErr.StatusCode)
+	require.Equal(t, 30*time.Second, respErr.RetryAfter)
+	require.EqualError(t, err, "webhook response status 429 Too Many Requests")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2023, 3, 20, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "", expected: 0},
+		{value: "120", expected: 2 * time.Minute},
+		{value: "-1", expected: 0},
+		{value: "Mon, 20 Mar 2023 10:01:00 GMT", expected: time.Minute},
+		{value: "Mon, 20 Mar 2023 09:59:00 GMT", expected: 0},
+		{value: "soon", expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			require.Equal(t, tc.expected, parseRetryAfter(tc.value, now))
+		})
+	}
+}
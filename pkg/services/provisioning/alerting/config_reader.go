@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/hashicorp/go-multierror"
 	"gopkg.in/yaml.v3"
 
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -23,14 +24,17 @@ func newRulesConfigReader(logger log.Logger) rulesConfigReader {
 	}
 }
 
+// readConfig reads the alerting files of the directory. The files that cannot be read are skipped, and reported by the
+// returned error together with the files that could be read. If the directory cannot be listed, no file is returned
+// and the error wraps the error of the listing.
 func (cr *rulesConfigReader) readConfig(ctx context.Context, path string) ([]*AlertingFile, error) {
 	var alertFiles []*AlertingFile
+	var errs *multierror.Error
 	cr.log.Debug("looking for alerting provisioning files", "path", path)
 
 	files, err := os.ReadDir(path)
 	if err != nil {
-		cr.log.Error("can't read alerting provisioning files from directory", "path", path, "error", err)
-		return alertFiles, nil
+		return nil, &listFilesError{path: path, err: err}
 	}
 
 	for _, file := range files {
@@ -41,18 +45,36 @@ func (cr *rulesConfigReader) readConfig(ctx context.Context, path string) ([]*Al
 		}
 		alertFileV1, err := cr.parseConfig(path, file)
 		if err != nil {
-			return nil, fmt.Errorf("failure to parse file %s: %w", file.Name(), err)
+			cr.log.Error("failed to parse alerting provisioning file", "path", path, "file.Name", file.Name(), "error", err)
+			errs = multierror.Append(errs, fmt.Errorf("failure to parse file %s: %w", file.Name(), err))
+			continue
 		}
 		if alertFileV1 != nil {
 			alertFileV1.Filename = file.Name()
 			alertFile, err := alertFileV1.MapToModel()
 			if err != nil {
-				return nil, fmt.Errorf("failure to map file %s: %w", alertFileV1.Filename, err)
+				cr.log.Error("failed to map alerting provisioning file", "path", path, "file.Name", file.Name(), "error", err)
+				errs = multierror.Append(errs, fmt.Errorf("failure to map file %s: %w", alertFileV1.Filename, err))
+				continue
 			}
 			alertFiles = append(alertFiles, &alertFile)
 		}
 	}
-	return alertFiles, nil
+	return alertFiles, errs.ErrorOrNil()
+}
+
+// listFilesError is returned by readConfig if the directory of the alerting files cannot be listed.
+type listFilesError struct {
+	path string
+	err  error
+}
+
+func (e *listFilesError) Error() string {
+	return fmt.Sprintf("can't read alerting provisioning files from directory %s: %s", e.path, e.err)
+}
+
+func (e *listFilesError) Unwrap() error {
+	return e.err
 }
 
 func (cr *rulesConfigReader) isYAML(file string) bool {
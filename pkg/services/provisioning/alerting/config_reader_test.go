@@ -2,6 +2,7 @@ package alerting
 
 import (
 	"context"
+	"io/fs"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -12,7 +13,6 @@ import (
 const (
 	testFileBrokenYAML                  = "./testdata/common/broken-yaml"
 	testFileEmptyFile                   = "./testdata/common/empty-file"
-	testFileEmptyFolder                 = "./testdata/common/empty-folder"
 	testFileSupportedFiletypes          = "./testdata/common/supported-filetypes"
 	testFileCorrectProperties           = "./testdata/alert_rules/correct-properties"
 	testFileCorrectPropertiesWithOrg    = "./testdata/alert_rules/correct-properties-with-org"
@@ -61,9 +61,14 @@ func TestConfigReader(t *testing.T) {
 		require.NoError(t, err)
 	})
 	t.Run("an empty folder should not make the config reader error", func(t *testing.T) {
-		_, err := configReader.readConfig(ctx, testFileEmptyFolder)
+		_, err := configReader.readConfig(ctx, t.TempDir())
 		require.NoError(t, err)
 	})
+	t.Run("a folder that cannot be listed should error", func(t *testing.T) {
+		ruleFiles, err := configReader.readConfig(ctx, "./testdata/does-not-exist")
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		require.Empty(t, ruleFiles)
+	})
 	t.Run("the config reader should be able to read multiple files in the folder", func(t *testing.T) {
 		ruleFiles, err := configReader.readConfig(ctx, testFileMultipleFiles)
 		require.NoError(t, err)
@@ -2,7 +2,11 @@ package alerting
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+
+	"github.com/hashicorp/go-multierror"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
@@ -18,14 +22,26 @@ type ProvisionerConfig struct {
 	NotificiationPolicyService provisioning.NotificationPolicyService
 	MuteTimingService          provisioning.MuteTimingService
 	TemplateService            provisioning.TemplateService
+	// Prune determines whether the alert rules that were provisioned from files that no longer define them are deleted.
+	Prune bool
 }
 
 func Provision(ctx context.Context, cfg ProvisionerConfig) error {
 	logger := log.New("provisioning.alerting")
 	cfgReader := newRulesConfigReader(logger)
-	files, err := cfgReader.readConfig(ctx, cfg.Path)
-	if err != nil {
-		return err
+	// the files that cannot be read are reported after the other files are provisioned
+	files, readErr := cfgReader.readConfig(ctx, cfg.Path)
+	// nothing is provisioned or pruned if the directory cannot be listed, as all the files would look removed
+	var listErr *listFilesError
+	if errors.As(readErr, &listErr) {
+		if errors.Is(listErr, fs.ErrNotExist) {
+			if cfg.Prune {
+				logger.Warn("not pruning provisioned alert rules because the alerting provisioning directory does not exist", "path", cfg.Path)
+			}
+			return nil
+		}
+		logger.Error("can't read alerting provisioning files from directory", "path", cfg.Path, "error", listErr.err)
+		return readErr
 	}
 	logger.Info("starting to provision alerting")
 	logger.Debug("read all alerting files", "file_count", len(files))
@@ -34,12 +50,17 @@ func Provision(ctx context.Context, cfg ProvisionerConfig) error {
 		cfg.DashboardService,
 		cfg.DashboardProvService,
 		cfg.RuleService)
-	err = ruleProvisioner.Provision(ctx, files)
-	if err != nil {
-		return fmt.Errorf("alert rules: %w", err)
+	ruleErr := ruleProvisioner.Provision(ctx, files)
+	if cfg.Prune {
+		// the rules of a file that cannot be read must not be deleted
+		if readErr != nil {
+			logger.Warn("not pruning provisioned alert rules because some alerting files cannot be read")
+		} else if err := ruleProvisioner.Prune(ctx, files); err != nil {
+			return fmt.Errorf("alert rules: %w", err)
+		}
 	}
 	cpProvisioner := NewContactPointProvisoner(logger, cfg.ContactPointService)
-	err = cpProvisioner.Provision(ctx, files)
+	err := cpProvisioner.Provision(ctx, files)
 	if err != nil {
 		return fmt.Errorf("contact points: %w", err)
 	}
@@ -74,6 +95,16 @@ func Provision(ctx context.Context, cfg ProvisionerConfig) error {
 	if err != nil {
 		return fmt.Errorf("text templates: %w", err)
 	}
+	if readErr != nil || ruleErr != nil {
+		var errs *multierror.Error
+		if readErr != nil {
+			errs = multierror.Append(errs, readErr)
+		}
+		if ruleErr != nil {
+			errs = multierror.Append(errs, fmt.Errorf("alert rules: %w", ruleErr))
+		}
+		return errs
+	}
 	logger.Info("finished to provision alerting")
 	return nil
 }
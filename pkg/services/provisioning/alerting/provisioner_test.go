@@ -0,0 +1,187 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/db"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestProvision(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (ProvisionerConfig, *provisioning.AlertRuleService) {
+		t.Helper()
+		sqlStore := db.InitTestDB(t)
+		st := store.DBstore{
+			SQLStore: sqlStore,
+			Cfg:      setting.UnifiedAlertingSettings{BaseInterval: 10 * time.Second},
+			Logger:   log.NewNopLogger(),
+		}
+		quotas := &provisioning.MockQuotaChecker{}
+		quotas.EXPECT().LimitOK()
+		dashboardService := dashboards.NewFakeDashboardService(t)
+		dashboardService.On("GetDashboard", mock.Anything, mock.Anything).Return(&dashboards.Dashboard{UID: "folder-uid", IsFolder: true}, nil).Maybe()
+		ruleService := provisioning.NewAlertRuleService(st, st, dashboardService, quotas, sqlStore, 60, 10, log.NewNopLogger())
+		return ProvisionerConfig{
+			Path:             t.TempDir(),
+			DashboardService: dashboardService,
+			RuleService:      *ruleService,
+			Prune:            true,
+		}, ruleService
+	}
+	writeFile := func(t *testing.T, cfg ProvisionerConfig, name string, content string) {
+		t.Helper()
+		require.NoError(t, os.WriteFile(filepath.Join(cfg.Path, name), []byte(content), 0600))
+	}
+	removeFile := func(t *testing.T, cfg ProvisionerConfig, name string) {
+		t.Helper()
+		require.NoError(t, os.Remove(filepath.Join(cfg.Path, name)))
+	}
+	ruleFile := func(group string, rules ...string) string {
+		var b strings.Builder
+		fmt.Fprintf(&b, "apiVersion: 1\ngroups:\n  - name: %s\n    folder: my_folder\n    interval: 1m\n    rules:\n", group)
+		for _, rule := range rules {
+			uid, title, _ := strings.Cut(rule, ":")
+			fmt.Fprintf(&b, `      - uid: %s
+        title: %s
+        condition: A
+        for: 5m
+        data:
+          - refId: A
+            relativeTimeRange:
+              from: 600
+              to: 0
+            datasourceUid: "__expr__"
+            model:
+              type: math
+              expression: "1 > 0"
+`, uid, title)
+		}
+		return b.String()
+	}
+	provisioned := func(t *testing.T, ruleService *provisioning.AlertRuleService) map[string]string {
+		t.Helper()
+		rules, err := ruleService.GetProvisionedAlertRules(ctx, models.ProvenanceFile)
+		require.NoError(t, err)
+		result := make(map[string]string, len(rules))
+		for _, rule := range rules {
+			result[rule.UID] = rule.Title
+		}
+		return result
+	}
+
+	t.Run("reconciles the alert rules with the files", func(t *testing.T) {
+		cfg, ruleService := setup(t)
+		// a rule that was not provisioned from a file is never pruned
+		apiRule, err := ruleService.CreateAlertRule(ctx, models.AlertRule{
+			OrgID:           1,
+			Title:           "api rule",
+			NamespaceUID:    "folder-uid",
+			RuleGroup:       "api",
+			IntervalSeconds: 60,
+			Condition:       "A",
+			Data: []models.AlertQuery{{
+				RefID:         "A",
+				DatasourceUID: "__expr__",
+				Model:         []byte(`{"type":"math","expression":"1 > 0"}`),
+			}},
+			NoDataState:  models.OK,
+			ExecErrState: models.AlertingErrState,
+		}, models.ProvenanceAPI, 0)
+		require.NoError(t, err)
+
+		writeFile(t, cfg, "node.yaml", ruleFile("node", "node-down:Node down", "node-cpu:Node CPU"))
+		writeFile(t, cfg, "api.yaml", ruleFile("http", "api-errors:API errors"))
+		require.NoError(t, Provision(ctx, cfg))
+		require.Equal(t, map[string]string{"node-down": "Node down", "node-cpu": "Node CPU", "api-errors": "API errors"}, provisioned(t, ruleService))
+		rule, provenance, err := ruleService.GetAlertRule(ctx, 1, "node-down")
+		require.NoError(t, err)
+		require.Equal(t, models.ProvenanceFile, provenance)
+		require.Equal(t, "folder-uid", rule.NamespaceUID)
+		require.Equal(t, "node", rule.RuleGroup)
+		require.Equal(t, 5*time.Minute, rule.For)
+
+		// modify a file, remove a rule from it and remove another file
+		writeFile(t, cfg, "node.yaml", ruleFile("node", "node-down:Node is down"))
+		removeFile(t, cfg, "api.yaml")
+		require.NoError(t, Provision(ctx, cfg))
+		require.Equal(t, map[string]string{"node-down": "Node is down"}, provisioned(t, ruleService))
+		_, _, err = ruleService.GetAlertRule(ctx, 1, "api-errors")
+		require.ErrorIs(t, err, models.ErrAlertRuleNotFound)
+		_, provenance, err = ruleService.GetAlertRule(ctx, 1, apiRule.UID)
+		require.NoError(t, err)
+		require.Equal(t, models.ProvenanceAPI, provenance)
+
+		// add a file again
+		writeFile(t, cfg, "api.yaml", ruleFile("http", "api-errors:API errors", "api-latency:API latency"))
+		require.NoError(t, Provision(ctx, cfg))
+		require.Equal(t, map[string]string{"node-down": "Node is down", "api-errors": "API errors", "api-latency": "API latency"}, provisioned(t, ruleService))
+	})
+
+	t.Run("reports the malformed files and provisions the other files", func(t *testing.T) {
+		cfg, ruleService := setup(t)
+		writeFile(t, cfg, "node.yaml", ruleFile("node", "node-down:Node down", "node-cpu:Node CPU"))
+		require.NoError(t, Provision(ctx, cfg))
+
+		writeFile(t, cfg, "api.yaml", ruleFile("http", "api-errors:API errors"))
+		writeFile(t, cfg, "broken.yaml", "groups:\n  - name: [broken\n")
+		writeFile(t, cfg, "node.yaml", ruleFile("node", "node-down:Node down"))
+		err := Provision(ctx, cfg)
+		require.ErrorContains(t, err, "broken.yaml")
+		require.NotContains(t, err.Error(), "api.yaml")
+		// the rules are not pruned while a file cannot be read, as it could define them
+		require.Equal(t, map[string]string{"node-down": "Node down", "node-cpu": "Node CPU", "api-errors": "API errors"}, provisioned(t, ruleService))
+
+		removeFile(t, cfg, "broken.yaml")
+		require.NoError(t, Provision(ctx, cfg))
+		require.Equal(t, map[string]string{"node-down": "Node down", "api-errors": "API errors"}, provisioned(t, ruleService))
+	})
+
+	t.Run("reports the files that fail validation and provisions the other files", func(t *testing.T) {
+		cfg, ruleService := setup(t)
+		writeFile(t, cfg, "invalid.yaml", ruleFile("invalid", "invalid-rule:"+strings.Repeat("a", store.AlertRuleMaxTitleLength+1)))
+		writeFile(t, cfg, "node.yaml", ruleFile("node", "node-down:Node down"))
+		err := Provision(ctx, cfg)
+		require.ErrorContains(t, err, "invalid.yaml")
+		require.Equal(t, map[string]string{"node-down": "Node down"}, provisioned(t, ruleService))
+	})
+
+	t.Run("keeps the rules if the directory cannot be listed", func(t *testing.T) {
+		cfg, ruleService := setup(t)
+		writeFile(t, cfg, "node.yaml", ruleFile("node", "node-down:Node down"))
+		require.NoError(t, Provision(ctx, cfg))
+		require.NoError(t, os.RemoveAll(cfg.Path))
+		require.NoError(t, Provision(ctx, cfg))
+		require.Equal(t, map[string]string{"node-down": "Node down"}, provisioned(t, ruleService))
+
+		// a file instead of the directory cannot be listed either, and is reported
+		require.NoError(t, os.WriteFile(cfg.Path, nil, 0600))
+		require.Error(t, Provision(ctx, cfg))
+		require.Equal(t, map[string]string{"node-down": "Node down"}, provisioned(t, ruleService))
+	})
+
+	t.Run("keeps the rules of removed files if pruning is disabled", func(t *testing.T) {
+		cfg, ruleService := setup(t)
+		cfg.Prune = false
+		writeFile(t, cfg, "node.yaml", ruleFile("node", "node-down:Node down"))
+		require.NoError(t, Provision(ctx, cfg))
+		removeFile(t, cfg, "node.yaml")
+		require.NoError(t, Provision(ctx, cfg))
+		require.Equal(t, map[string]string{"node-down": "Node down"}, provisioned(t, ruleService))
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/hashicorp/go-multierror"
+
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	alert_models "github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -14,6 +16,7 @@ import (
 
 type AlertRuleProvisioner interface {
 	Provision(ctx context.Context, files []*AlertingFile) error
+	Prune(ctx context.Context, files []*AlertingFile) error
 }
 
 func NewAlertRuleProvisioner(
@@ -36,38 +39,78 @@ type defaultAlertRuleProvisioner struct {
 	ruleService          provisioning.AlertRuleService
 }
 
+// Provision creates or updates the alert rules of the files, and deletes the rules that the files list for deletion.
+// A file that cannot be provisioned does not stop the provisioning of the other files, and is reported by the returned
+// error.
 func (prov *defaultAlertRuleProvisioner) Provision(ctx context.Context,
 	files []*AlertingFile) error {
+	var errs *multierror.Error
 	for _, file := range files {
-		for _, group := range file.Groups {
-			folderUID, err := prov.getOrCreateFolderUID(ctx, group.FolderTitle, group.OrgID)
+		if err := prov.provisionFile(ctx, file); err != nil {
+			prov.logger.Error("failed to provision alert rules of file", "file", file.Filename, "error", err)
+			errs = multierror.Append(errs, fmt.Errorf("file %s: %w", file.Filename, err))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+func (prov *defaultAlertRuleProvisioner) provisionFile(ctx context.Context, file *AlertingFile) error {
+	for _, group := range file.Groups {
+		folderUID, err := prov.getOrCreateFolderUID(ctx, group.FolderTitle, group.OrgID)
+		if err != nil {
+			return err
+		}
+		prov.logger.Debug("provisioning alert rule group",
+			"org", group.OrgID,
+			"folder", group.FolderTitle,
+			"folderUID", folderUID,
+			"name", group.Title)
+		for _, rule := range group.Rules {
+			rule.NamespaceUID = folderUID
+			rule.RuleGroup = group.Title
+			err = prov.provisionRule(ctx, group.OrgID, rule)
 			if err != nil {
 				return err
 			}
-			prov.logger.Debug("provisioning alert rule group",
-				"org", group.OrgID,
-				"folder", group.FolderTitle,
-				"folderUID", folderUID,
-				"name", group.Title)
+		}
+		err = prov.ruleService.UpdateRuleGroup(ctx, group.OrgID, folderUID, group.Title, group.Interval)
+		if err != nil {
+			return err
+		}
+	}
+	for _, deleteRule := range file.DeleteRules {
+		err := prov.ruleService.DeleteAlertRule(ctx, deleteRule.OrgID,
+			deleteRule.UID, alert_models.ProvenanceFile)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune deletes the alert rules that were provisioned from files and that none of the files defines anymore, because
+// they were removed from their file or their file was removed.
+func (prov *defaultAlertRuleProvisioner) Prune(ctx context.Context, files []*AlertingFile) error {
+	provisioned := make(map[alert_models.AlertRuleKey]struct{})
+	for _, file := range files {
+		for _, group := range file.Groups {
 			for _, rule := range group.Rules {
-				rule.NamespaceUID = folderUID
-				rule.RuleGroup = group.Title
-				err = prov.provisionRule(ctx, group.OrgID, rule)
-				if err != nil {
-					return err
-				}
-			}
-			err = prov.ruleService.UpdateRuleGroup(ctx, group.OrgID, folderUID, group.Title, group.Interval)
-			if err != nil {
-				return err
+				provisioned[alert_models.AlertRuleKey{OrgID: group.OrgID, UID: rule.UID}] = struct{}{}
 			}
 		}
-		for _, deleteRule := range file.DeleteRules {
-			err := prov.ruleService.DeleteAlertRule(ctx, deleteRule.OrgID,
-				deleteRule.UID, alert_models.ProvenanceFile)
-			if err != nil {
-				return err
-			}
+	}
+	rules, err := prov.ruleService.GetProvisionedAlertRules(ctx, alert_models.ProvenanceFile)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if _, ok := provisioned[rule.GetKey()]; ok {
+			continue
+		}
+		prov.logger.Info("deleting alert rule that is not provisioned anymore", "uid", rule.UID, "org", rule.OrgID)
+		err := prov.ruleService.DeleteAlertRule(ctx, rule.OrgID, rule.UID, alert_models.ProvenanceFile)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -180,6 +181,9 @@ func (ps *ProvisioningServiceImpl) Run(ctx context.Context) error {
 	if ps.dashboardProvisioner.HasDashboardSources() {
 		ps.searchService.TriggerReIndex()
 	}
+	if interval := ps.Cfg.UnifiedAlerting.ProvisioningPollInterval; interval > 0 && ps.Cfg.UnifiedAlerting.IsEnabled() {
+		go ps.pollAlerting(ctx, interval)
+	}
 
 	for {
 		// Wait for unlock. This is tied to new dashboardProvisioner to be instantiated before we start polling.
@@ -290,10 +294,28 @@ func (ps *ProvisioningServiceImpl) ProvisionAlerting(ctx context.Context) error
 		NotificiationPolicyService: *notificationPolicyService,
 		MuteTimingService:          *mutetimingsService,
 		TemplateService:            *templateService,
+		Prune:                      ps.Cfg.UnifiedAlerting.ProvisioningPrune,
 	}
 	return ps.provisionAlerting(ctx, cfg)
 }
 
+// pollAlerting provisions the alerting files again at every interval until the context is canceled. The changes of the
+// files are applied, and the errors are only logged so that a broken file does not stop the polling.
+func (ps *ProvisioningServiceImpl) pollAlerting(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := ps.ProvisionAlerting(ctx); err != nil {
+				ps.log.Error("Failed to provision alerting", "error", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (ps *ProvisioningServiceImpl) GetDashboardProvisionerResolvedPath(name string) string {
 	return ps.dashboardProvisioner.GetProvisionerResolvedPath(name)
 }
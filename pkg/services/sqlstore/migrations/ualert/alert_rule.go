@@ -100,7 +100,7 @@ func addMigrationInfo(da *dashAlert) (map[string]string, map[string]string) {
 	annotations := make(map[string]string, 3)
 	annotations[ngmodels.DashboardUIDAnnotation] = da.DashboardUID
 	annotations[ngmodels.PanelIDAnnotation] = fmt.Sprintf("%v", da.PanelId)
-	annotations["__alertId__"] = fmt.Sprintf("%v", da.Id)
+	annotations[ngmodels.MigratedAlertIDAnnotation] = fmt.Sprintf("%v", da.Id)
 
 	return lbls, annotations
 }
@@ -0,0 +1,132 @@
+package ualert
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// LegacyAlert is an alert of a dashboard panel, as it is stored in the alert table by the legacy alerting.
+type LegacyAlert struct {
+	ID           int64
+	OrgID        int64
+	DashboardID  int64
+	DashboardUID string
+	PanelID      int64
+	Name         string
+	Message      string
+	Frequency    int64
+	For          time.Duration
+	State        string
+	Settings     json.RawMessage
+}
+
+// ConvertedLegacyAlert is the alert rule that is equivalent to a legacy alert.
+type ConvertedLegacyAlert struct {
+	// Rule has no namespace, the caller must set the folder of the rule.
+	Rule ngmodels.AlertRule
+	// ChannelUIDs and ChannelIDs reference the notification channels of the legacy alert. Legacy alerts reference
+	// their channels by UID, or by ID in older dashboards.
+	ChannelUIDs []string
+	ChannelIDs  []int64
+}
+
+// ConvertLegacyAlert converts the queries and the classic conditions of the legacy alert into an alert rule with the
+// same queries and a classic condition expression, like the migration to unified alerting does. dsUIDs maps the
+// organization ID and the data source ID to the data source UID. It returns an error if the alert cannot be converted.
+func ConvertLegacyAlert(a LegacyAlert, dsUIDs map[[2]int64]string) (*ConvertedLegacyAlert, error) {
+	da := dashAlert{
+		Id:           a.ID,
+		OrgId:        a.OrgID,
+		DashboardId:  a.DashboardID,
+		PanelId:      a.PanelID,
+		Name:         a.Name,
+		Message:      a.Message,
+		Frequency:    a.Frequency,
+		For:          a.For,
+		State:        a.State,
+		Settings:     a.Settings,
+		DashboardUID: a.DashboardUID,
+	}
+	if err := json.Unmarshal(da.Settings, &da.ParsedSettings); err != nil {
+		return nil, fmt.Errorf("failed to parse the settings: %w", err)
+	}
+	if da.ParsedSettings == nil || len(da.ParsedSettings.Conditions) == 0 {
+		return nil, fmt.Errorf("the alert has no conditions")
+	}
+
+	cond, err := transConditions(*da.ParsedSettings, da.OrgId, dsUIDLookup(dsUIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the conditions: %w", err)
+	}
+	queries, err := migrateAlertRuleQueries(cond.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the queries: %w", err)
+	}
+	data := make([]ngmodels.AlertQuery, 0, len(queries))
+	for _, q := range queries {
+		if q.DatasourceUID == "" {
+			return nil, fmt.Errorf("the data source of query %s does not exist", q.RefID)
+		}
+		data = append(data, ngmodels.AlertQuery{
+			RefID:     q.RefID,
+			QueryType: q.QueryType,
+			RelativeTimeRange: ngmodels.RelativeTimeRange{
+				From: ngmodels.Duration(q.RelativeTimeRange.From),
+				To:   ngmodels.Duration(q.RelativeTimeRange.To),
+			},
+			DatasourceUID: q.DatasourceUID,
+			Model:         q.Model,
+		})
+	}
+
+	noData, err := transNoData(da.ParsedSettings.NoDataState)
+	if err != nil {
+		return nil, err
+	}
+	execErr, err := transExecErr(da.ParsedSettings.ExecutionErrorState)
+	if err != nil {
+		return nil, err
+	}
+
+	lbls, annotations := addMigrationInfo(&da)
+	annotations["message"] = da.Message
+	uid := util.GenerateShortUID()
+	name := normalizeRuleName(da.Name, uid)
+	dashboardUID := da.DashboardUID
+	panelID := da.PanelId
+
+	converted := &ConvertedLegacyAlert{
+		Rule: ngmodels.AlertRule{
+			OrgID:           da.OrgId,
+			Title:           name,
+			UID:             uid,
+			Condition:       cond.Condition,
+			Data:            data,
+			IntervalSeconds: ruleAdjustInterval(da.Frequency),
+			DashboardUID:    &dashboardUID,
+			PanelID:         &panelID,
+			RuleGroup:       name,
+			NoDataState:     ngmodels.NoDataState(noData),
+			ExecErrState:    ngmodels.ExecutionErrorState(execErr),
+			For:             da.For,
+			Annotations:     annotations,
+			Labels:          lbls,
+			IsPaused:        da.State == "paused",
+			// the legacy alerts notify their channels when they are resolved, unless the channel disables it
+			SendResolved: true,
+		},
+	}
+	for _, c := range extractChannelIDs(da) {
+		switch v := c.(type) {
+		case string:
+			converted.ChannelUIDs = append(converted.ChannelUIDs, v)
+		case int64:
+			converted.ChannelIDs = append(converted.ChannelIDs, v)
+		}
+	}
+	return converted, nil
+}
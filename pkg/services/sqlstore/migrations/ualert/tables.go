@@ -1,6 +1,7 @@
 package ualert
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"xorm.io/xorm"
@@ -24,6 +25,17 @@ func AddTablesMigrations(mg *migrator.Migrator) {
 	mg.AddMigration("add last_applied column to alert_configuration_history", migrator.NewAddColumnMigration(migrator.Table{Name: "alert_configuration_history"}, &migrator.Column{
 		Name: "last_applied", Type: migrator.DB_Int, Nullable: false, Default: "0",
 	}))
+
+	addSchedulerHeartbeatMigrations(mg)
+	addAlertRuleEvaluationMigrations(mg)
+	addAlertStateHistoryMigrations(mg)
+	addMaintenanceWindowMigrations(mg)
+	addSilenceMigrations(mg)
+	addIdempotencyKeyMigrations(mg)
+	addAlertRuleContactPointMigrations(mg)
+	addAlertRuleSchedulingIndexMigrations(mg)
+	addAlertRuleAuditMigrations(mg)
+	addAlertRuleDatasourceMigrations(mg)
 }
 
 // historicalTableMigrations contains those migrations that existed prior to creating the improved messaging around migration immutability.
@@ -188,6 +200,16 @@ func alertInstanceMigration(mg *migrator.Migrator) {
 		migrator.NewAddColumnMigration(alertInstance, &migrator.Column{
 			Name: "current_reason", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: true,
 		}))
+
+	mg.AddMigration("add state_values column to alert_instance",
+		migrator.NewAddColumnMigration(alertInstance, &migrator.Column{
+			Name: "state_values", Type: migrator.DB_Text, Nullable: true,
+		}))
+
+	mg.AddMigration("add resolved_at column to alert_instance",
+		migrator.NewAddColumnMigration(alertInstance, &migrator.Column{
+			Name: "resolved_at", Type: migrator.DB_BigInt, Nullable: true,
+		}))
 }
 
 func addAlertRuleMigrations(mg *migrator.Migrator, defaultIntervalSeconds int64) {
@@ -295,6 +317,38 @@ func addAlertRuleMigrations(mg *migrator.Migrator, defaultIntervalSeconds int64)
 	mg.AddMigration("fix is_paused column for alert_rule table", migrator.NewRawSQLMigration("").
 		Postgres(`ALTER TABLE alert_rule ALTER COLUMN is_paused SET DEFAULT false;
 UPDATE alert_rule SET is_paused = false;`))
+
+	mg.AddMigration("add schedule column to alert_rule table", migrator.NewAddColumnMigration(alertRule, &migrator.Column{
+		Name: "schedule", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: true,
+	}))
+
+	mg.AddMigration("add schedule_timezone column to alert_rule table", migrator.NewAddColumnMigration(alertRule, &migrator.Column{
+		Name: "schedule_timezone", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: true,
+	}))
+
+	mg.AddMigration("add instance_limit column to alert_rule table", migrator.NewAddColumnMigration(alertRule, &migrator.Column{
+		Name: "instance_limit", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("add created_by column to alert_rule table", migrator.NewAddColumnMigration(alertRule, &migrator.Column{
+		Name: "created_by", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("add notification_title column to alert_rule table", migrator.NewAddColumnMigration(alertRule, &migrator.Column{
+		Name: "notification_title", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("add notification_message column to alert_rule table", migrator.NewAddColumnMigration(alertRule, &migrator.Column{
+		Name: "notification_message", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("add notification_repeat_interval column to alert_rule table", migrator.NewAddColumnMigration(alertRule, &migrator.Column{
+		Name: "notification_repeat_interval", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("add send_resolved column to alert_rule table", migrator.NewAddColumnMigration(alertRule, &migrator.Column{
+		Name: "send_resolved", Type: migrator.DB_Bool, Nullable: false, Default: "1",
+	}))
 }
 
 func addAlertRuleVersionMigrations(mg *migrator.Migrator) {
@@ -364,6 +418,34 @@ func addAlertRuleVersionMigrations(mg *migrator.Migrator) {
 	mg.AddMigration("fix is_paused column for alert_rule_version table", migrator.NewRawSQLMigration("").
 		Postgres(`ALTER TABLE alert_rule_version ALTER COLUMN is_paused SET DEFAULT false;
 UPDATE alert_rule_version SET is_paused = false;`))
+
+	mg.AddMigration("add schedule column to alert_rule_version table", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{
+		Name: "schedule", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: true,
+	}))
+
+	mg.AddMigration("add schedule_timezone column to alert_rule_version table", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{
+		Name: "schedule_timezone", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: true,
+	}))
+
+	mg.AddMigration("add instance_limit column to alert_rule_version table", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{
+		Name: "instance_limit", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("add notification_title column to alert_rule_version table", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{
+		Name: "notification_title", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("add notification_message column to alert_rule_version table", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{
+		Name: "notification_message", Type: migrator.DB_Text, Nullable: true,
+	}))
+
+	mg.AddMigration("add notification_repeat_interval column to alert_rule_version table", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{
+		Name: "notification_repeat_interval", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+	}))
+
+	mg.AddMigration("add send_resolved column to alert_rule_version table", migrator.NewAddColumnMigration(alertRuleVersion, &migrator.Column{
+		Name: "send_resolved", Type: migrator.DB_Bool, Nullable: false, Default: "1",
+	}))
 }
 
 func addAlertmanagerConfigMigrations(mg *migrator.Migrator) {
@@ -490,6 +572,175 @@ func addAlertImageMigrations(mg *migrator.Migrator) {
 		Mysql("ALTER TABLE alert_image MODIFY url VARCHAR(2048) NOT NULL;"))
 }
 
+func addSchedulerHeartbeatMigrations(mg *migrator.Migrator) {
+	heartbeatTable := migrator.Table{
+		Name: "alert_scheduler_heartbeat",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "instance_id", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"instance_id"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create alert_scheduler_heartbeat table", migrator.NewAddTableMigration(heartbeatTable))
+	mg.AddMigration("add unique index on instance_id to alert_scheduler_heartbeat table", migrator.NewAddIndexMigration(heartbeatTable, heartbeatTable.Indices[0]))
+}
+
+func addAlertRuleEvaluationMigrations(mg *migrator.Migrator) {
+	evaluationTable := migrator.Table{
+		Name: "alert_rule_evaluation",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "rule_org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "evaluated_at", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "duration_ms", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "state", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "error_message", Type: migrator.DB_Text, Nullable: true},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"rule_org_id", "rule_uid"}, Type: migrator.UniqueIndex},
+		},
+	}
+
+	mg.AddMigration("create alert_rule_evaluation table", migrator.NewAddTableMigration(evaluationTable))
+	mg.AddMigration("add unique index on rule_org_id, rule_uid to alert_rule_evaluation table", migrator.NewAddIndexMigration(evaluationTable, evaluationTable.Indices[0]))
+}
+
+func addAlertStateHistoryMigrations(mg *migrator.Migrator) {
+	historyTable := migrator.Table{
+		Name: "alert_state_history",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "rule_org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "labels_hash", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "labels", Type: migrator.DB_Text, Nullable: false},
+			{Name: "previous_state", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "state", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "state_reason", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: true},
+			{Name: "state_values", Type: migrator.DB_Text, Nullable: true},
+			{Name: "transitioned_at", Type: migrator.DB_BigInt, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"rule_org_id", "rule_uid", "transitioned_at"}, Type: migrator.IndexType},
+			{Cols: []string{"transitioned_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_state_history table", migrator.NewAddTableMigration(historyTable))
+	mg.AddMigration("add index on rule_org_id, rule_uid, transitioned_at to alert_state_history table", migrator.NewAddIndexMigration(historyTable, historyTable.Indices[0]))
+	mg.AddMigration("add index on transitioned_at to alert_state_history table", migrator.NewAddIndexMigration(historyTable, historyTable.Indices[1]))
+
+	mg.AddMigration("add reset_by column to alert_state_history",
+		migrator.NewAddColumnMigration(historyTable, &migrator.Column{
+			Name: "reset_by", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: true,
+		}))
+
+	mg.AddMigration("add maintenance_window_id column to alert_state_history",
+		migrator.NewAddColumnMigration(historyTable, &migrator.Column{
+			Name: "maintenance_window_id", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+		}))
+
+	mg.AddMigration("add silence_id column to alert_state_history",
+		migrator.NewAddColumnMigration(historyTable, &migrator.Column{
+			Name: "silence_id", Type: migrator.DB_BigInt, Nullable: false, Default: "0",
+		}))
+}
+
+func addMaintenanceWindowMigrations(mg *migrator.Migrator) {
+	windowTable := migrator.Table{
+		Name: "alert_maintenance_window",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "title", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "starts_at", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "ends_at", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "recurrence", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "created_by", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_maintenance_window table", migrator.NewAddTableMigration(windowTable))
+	mg.AddMigration("add index on org_id to alert_maintenance_window table", migrator.NewAddIndexMigration(windowTable, windowTable.Indices[0]))
+}
+
+func addSilenceMigrations(mg *migrator.Migrator) {
+	silenceTable := migrator.Table{
+		Name: "alert_silence",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "matchers", Type: migrator.DB_Text, Nullable: false},
+			{Name: "starts_at", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "ends_at", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "comment", Type: migrator.DB_Text, Nullable: false},
+			{Name: "created_by", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "updated", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id"}, Type: migrator.IndexType},
+			{Cols: []string{"ends_at"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_silence table", migrator.NewAddTableMigration(silenceTable))
+	mg.AddMigration("add index on org_id to alert_silence table", migrator.NewAddIndexMigration(silenceTable, silenceTable.Indices[0]))
+	mg.AddMigration("add index on ends_at to alert_silence table", migrator.NewAddIndexMigration(silenceTable, silenceTable.Indices[1]))
+}
+
+func addIdempotencyKeyMigrations(mg *migrator.Migrator) {
+	keyTable := migrator.Table{
+		Name: "alert_rule_idempotency_key",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "idempotency_key", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "request_hash", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "idempotency_key"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"created"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_rule_idempotency_key table", migrator.NewAddTableMigration(keyTable))
+	mg.AddMigration("add unique index on org_id, idempotency_key to alert_rule_idempotency_key table", migrator.NewAddIndexMigration(keyTable, keyTable.Indices[0]))
+	mg.AddMigration("add index on created to alert_rule_idempotency_key table", migrator.NewAddIndexMigration(keyTable, keyTable.Indices[1]))
+}
+
+func addAlertRuleContactPointMigrations(mg *migrator.Migrator) {
+	contactPointTable := migrator.Table{
+		Name: "alert_rule_contact_point",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "rule_org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "contact_point_uid", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"rule_org_id", "rule_uid", "contact_point_uid"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"rule_org_id", "contact_point_uid"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_rule_contact_point table", migrator.NewAddTableMigration(contactPointTable))
+	mg.AddMigration("add unique index on rule_org_id, rule_uid, contact_point_uid to alert_rule_contact_point table", migrator.NewAddIndexMigration(contactPointTable, contactPointTable.Indices[0]))
+	mg.AddMigration("add index on rule_org_id, contact_point_uid to alert_rule_contact_point table", migrator.NewAddIndexMigration(contactPointTable, contactPointTable.Indices[1]))
+}
+
 func extractAlertmanagerConfigurationHistoryMigration(mg *migrator.Migrator) {
 	if !mg.Cfg.UnifiedAlerting.IsEnabled() {
 		return
@@ -567,3 +818,112 @@ func (c extractAlertmanagerConfigurationHistory) Exec(sess *xorm.Session, migrat
 	}
 	return nil
 }
+
+// addAlertRuleSchedulingIndexMigrations adds the index that the scheduler reads the IDs of the paused and not paused
+// alert rules with, in order, without reading the rows of the table.
+func addAlertRuleSchedulingIndexMigrations(mg *migrator.Migrator) {
+	mg.AddMigration("add index in alert_rule on is_paused and id columns", migrator.NewAddIndexMigration(migrator.Table{Name: "alert_rule"}, &migrator.Index{
+		Cols: []string{"is_paused", "id"}, Type: migrator.IndexType,
+	}))
+}
+
+func addAlertRuleAuditMigrations(mg *migrator.Migrator) {
+	auditTable := migrator.Table{
+		Name: "alert_rule_audit",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "namespace_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "action", Type: migrator.DB_NVarchar, Length: 20, Nullable: false},
+			{Name: "actor_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "created", Type: migrator.DB_DateTime, Nullable: false},
+			{Name: "changes", Type: migrator.DB_Text, Nullable: true},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"org_id", "rule_uid"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_rule_audit table", migrator.NewAddTableMigration(auditTable))
+	mg.AddMigration("add index on org_id, rule_uid to alert_rule_audit table", migrator.NewAddIndexMigration(auditTable, auditTable.Indices[0]))
+}
+
+func addAlertRuleDatasourceMigrations(mg *migrator.Migrator) {
+	datasourceTable := migrator.Table{
+		Name: "alert_rule_datasource",
+		Columns: []*migrator.Column{
+			{Name: "id", Type: migrator.DB_BigInt, IsPrimaryKey: true, IsAutoIncrement: true},
+			{Name: "rule_org_id", Type: migrator.DB_BigInt, Nullable: false},
+			{Name: "rule_uid", Type: migrator.DB_NVarchar, Length: UIDMaxLength, Nullable: false},
+			{Name: "datasource_uid", Type: migrator.DB_NVarchar, Length: DefaultFieldMaxLength, Nullable: false},
+		},
+		Indices: []*migrator.Index{
+			{Cols: []string{"rule_org_id", "rule_uid", "datasource_uid"}, Type: migrator.UniqueIndex},
+			{Cols: []string{"datasource_uid"}, Type: migrator.IndexType},
+		},
+	}
+
+	mg.AddMigration("create alert_rule_datasource table", migrator.NewAddTableMigration(datasourceTable))
+	mg.AddMigration("add unique index on rule_org_id, rule_uid, datasource_uid to alert_rule_datasource table", migrator.NewAddIndexMigration(datasourceTable, datasourceTable.Indices[0]))
+	mg.AddMigration("add index on datasource_uid to alert_rule_datasource table", migrator.NewAddIndexMigration(datasourceTable, datasourceTable.Indices[1]))
+	mg.AddMigration("fill alert_rule_datasource table from the queries of existing alert rules", &fillAlertRuleDatasources{})
+}
+
+// fillAlertRuleDatasources associates the alert rules that exist before the alert_rule_datasource table with the data
+// sources that their queries read. Later rules are associated when they are saved.
+type fillAlertRuleDatasources struct {
+	migrator.MigrationBase
+}
+
+// fillAlertRuleDatasourcesModel is the model of an association at the time that the fillAlertRuleDatasources migration
+// was run. This is not to be used outside of the migration.
+type fillAlertRuleDatasourcesModel struct {
+	RuleOrgID     int64  `xorm:"rule_org_id"`
+	RuleUID       string `xorm:"rule_uid"`
+	DatasourceUID string `xorm:"datasource_uid"`
+}
+
+func (c fillAlertRuleDatasources) SQL(migrator.Dialect) string {
+	return codeMigration
+}
+
+func (c fillAlertRuleDatasources) Exec(sess *xorm.Session, mg *migrator.Migrator) error {
+	var rules []struct {
+		OrgID int64  `xorm:"org_id"`
+		UID   string `xorm:"uid"`
+		Data  string `xorm:"data"`
+	}
+	if err := sess.SQL("SELECT org_id, uid, data FROM alert_rule").Find(&rules); err != nil {
+		return fmt.Errorf("failed to get alert rules: %w", err)
+	}
+
+	associations := make([]fillAlertRuleDatasourcesModel, 0, len(rules))
+	for _, rule := range rules {
+		var queries []struct {
+			DatasourceUID string `json:"datasourceUid"`
+		}
+		if err := json.Unmarshal([]byte(rule.Data), &queries); err != nil {
+			mg.Logger.Warn("failed to parse the queries of alert rule, its data sources are associated when it is saved", "org_id", rule.OrgID, "rule_uid", rule.UID, "error", err)
+			continue
+		}
+		seen := make(map[string]struct{}, len(queries))
+		for _, q := range queries {
+			if q.DatasourceUID == expressionDatasourceUID || q.DatasourceUID == "-100" {
+				continue
+			}
+			if _, ok := seen[q.DatasourceUID]; ok {
+				continue
+			}
+			seen[q.DatasourceUID] = struct{}{}
+			associations = append(associations, fillAlertRuleDatasourcesModel{RuleOrgID: rule.OrgID, RuleUID: rule.UID, DatasourceUID: q.DatasourceUID})
+		}
+	}
+	if len(associations) == 0 {
+		return nil
+	}
+	if _, err := sess.Table("alert_rule_datasource").InsertMulti(associations); err != nil {
+		return fmt.Errorf("failed to associate alert rules with data sources: %w", err)
+	}
+	return nil
+}
@@ -85,6 +85,8 @@ type AdminStats struct {
 	Playlists           int64 `json:"playlists"`
 	Stars               int64 `json:"stars"`
 	Alerts              int64 `json:"alerts"`
+	PausedAlerts        int64 `json:"pausedAlerts"`
+	AlertInstances      int64 `json:"alertInstances"`
 	Users               int64 `json:"users"`
 	Admins              int64 `json:"admins"`
 	Editors             int64 `json:"editors"`
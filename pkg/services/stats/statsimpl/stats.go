@@ -179,8 +179,13 @@ func (ss *sqlStatsService) GetAdminStats(ctx context.Context, query *stats.GetAd
 		monthlyActiveEndDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 
 		alertsQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s", dialect.Quote("alert"))
+		pausedAlertsQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE state = 'paused'", dialect.Quote("alert"))
+		// the legacy alerting has no instances
+		alertInstancesQuery := "SELECT 0"
 		if ss.IsUnifiedAlertingEnabled() {
 			alertsQuery = fmt.Sprintf("SELECT COUNT(*) FROM %s", dialect.Quote("alert_rule"))
+			pausedAlertsQuery = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE is_paused = %s", dialect.Quote("alert_rule"), dialect.BooleanStr(true))
+			alertInstancesQuery = fmt.Sprintf("SELECT COUNT(*) FROM %s", dialect.Quote("alert_instance"))
 		}
 
 		var rawSQL = `SELECT
@@ -213,6 +218,8 @@ func (ss *sqlStatsService) GetAdminStats(ctx context.Context, query *stats.GetAd
 			FROM ` + dialect.Quote("star") + `
 		) AS stars,
 		(` + alertsQuery + ` ) AS alerts,
+		(` + pausedAlertsQuery + ` ) AS paused_alerts,
+		(` + alertInstancesQuery + ` ) AS alert_instances,
 		(
 			SELECT COUNT(*)
 			FROM ` + dialect.Quote("user") + ` WHERE ` + notServiceAccount(dialect) + `
@@ -128,4 +128,17 @@ func TestIntegration_GetAdminStats(t *testing.T) {
 	query := stats.GetAdminStatsQuery{}
 	err := statsService.GetAdminStats(context.Background(), &query)
 	require.NoError(t, err)
+	assert.Equal(t, int64(0), query.Result.PausedAlerts)
+	assert.Equal(t, int64(0), query.Result.AlertInstances)
+
+	t.Run("with legacy alerting", func(t *testing.T) {
+		disabled := false
+		statsService := ProvideService(&setting.Cfg{UnifiedAlerting: setting.UnifiedAlertingSettings{Enabled: &disabled}}, db)
+
+		query := stats.GetAdminStatsQuery{}
+		err := statsService.GetAdminStats(context.Background(), &query)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), query.Result.PausedAlerts)
+		assert.Equal(t, int64(0), query.Result.AlertInstances)
+	})
 }
@@ -147,6 +147,9 @@ type Cfg struct {
 	Target []string
 	Raw    *ini.File
 	Logger log.Logger
+	// loadArgs are the arguments that Load read the configuration with. It is nil if the configuration was not loaded from
+	// files.
+	loadArgs *CommandLineArgs
 
 	// HTTP Server Settings
 	CertFile         string
@@ -619,7 +622,13 @@ func RedactedURL(value string) (string, error) {
 }
 
 func applyEnvVariableOverrides(file *ini.File) error {
-	appliedEnvOverrides = make([]string, 0)
+	appliedEnvOverrides = overrideWithEnvVariables(file)
+	return nil
+}
+
+// overrideWithEnvVariables sets the keys of the file that environment variables override, and returns the overrides.
+func overrideWithEnvVariables(file *ini.File) []string {
+	applied := make([]string, 0)
 	for _, section := range file.Sections() {
 		for _, key := range section.Keys() {
 			envKey := EnvKey(section.Name(), key.Name())
@@ -627,12 +636,11 @@ func applyEnvVariableOverrides(file *ini.File) error {
 
 			if len(envValue) > 0 {
 				key.SetValue(envValue)
-				appliedEnvOverrides = append(appliedEnvOverrides, fmt.Sprintf("%s=%s", envKey, RedactedValue(envKey, envValue)))
+				applied = append(applied, fmt.Sprintf("%s=%s", envKey, RedactedValue(envKey, envValue)))
 			}
 		}
 	}
-
-	return nil
+	return applied
 }
 
 func (cfg *Cfg) readGrafanaEnvironmentMetrics() error {
@@ -715,21 +723,33 @@ func EnvKey(sectionName string, keyName string) string {
 }
 
 func applyCommandLineDefaultProperties(props map[string]string, file *ini.File) {
-	appliedCommandLineProperties = make([]string, 0)
+	appliedCommandLineProperties = setCommandLineDefaultProperties(props, file)
+}
+
+// setCommandLineDefaultProperties sets the keys of the file that default properties on the command line set, and returns
+// the properties.
+func setCommandLineDefaultProperties(props map[string]string, file *ini.File) []string {
+	applied := make([]string, 0)
 	for _, section := range file.Sections() {
 		for _, key := range section.Keys() {
 			keyString := fmt.Sprintf("default.%s.%s", section.Name(), key.Name())
 			value, exists := props[keyString]
 			if exists {
 				key.SetValue(value)
-				appliedCommandLineProperties = append(appliedCommandLineProperties,
-					fmt.Sprintf("%s=%s", keyString, RedactedValue(keyString, value)))
+				applied = append(applied, fmt.Sprintf("%s=%s", keyString, RedactedValue(keyString, value)))
 			}
 		}
 	}
+	return applied
 }
 
 func applyCommandLineProperties(props map[string]string, file *ini.File) {
+	appliedCommandLineProperties = append(appliedCommandLineProperties, setCommandLineProperties(props, file)...)
+}
+
+// setCommandLineProperties sets the keys of the file that properties on the command line set, and returns the properties.
+func setCommandLineProperties(props map[string]string, file *ini.File) []string {
+	var applied []string
 	for _, section := range file.Sections() {
 		sectionName := section.Name() + "."
 		if section.Name() == ini.DefaultSection {
@@ -739,11 +759,12 @@ func applyCommandLineProperties(props map[string]string, file *ini.File) {
 			keyString := sectionName + key.Name()
 			value, exists := props[keyString]
 			if exists {
-				appliedCommandLineProperties = append(appliedCommandLineProperties, fmt.Sprintf("%s=%s", keyString, value))
+				applied = append(applied, fmt.Sprintf("%s=%s", keyString, value))
 				key.SetValue(value)
 			}
 		}
 	}
+	return applied
 }
 
 func (cfg Cfg) getCommandLineProperties(args []string) map[string]string {
@@ -774,17 +795,30 @@ func makeAbsolute(path string, root string) string {
 }
 
 func (cfg *Cfg) loadSpecifiedConfigFile(configFile string, masterFile *ini.File) error {
+	loaded, err := cfg.mergeSpecifiedConfigFile(configFile, masterFile)
+	if err != nil {
+		return err
+	}
+	if loaded != "" {
+		configFiles = append(configFiles, loaded)
+	}
+	return nil
+}
+
+// mergeSpecifiedConfigFile sets the keys of masterFile that the config file, or the custom config file if none is
+// specified, sets. It returns the path of the file, or an empty path if there is no custom config file.
+func (cfg *Cfg) mergeSpecifiedConfigFile(configFile string, masterFile *ini.File) (string, error) {
 	if configFile == "" {
 		configFile = filepath.Join(cfg.HomePath, CustomInitPath)
 		// return without error if custom file does not exist
 		if !pathExists(configFile) {
-			return nil
+			return "", nil
 		}
 	}
 
 	userConfig, err := ini.Load(configFile)
 	if err != nil {
-		return fmt.Errorf("failed to parse %q: %w", configFile, err)
+		return "", fmt.Errorf("failed to parse %q: %w", configFile, err)
 	}
 
 	userConfig.BlockMode = false
@@ -807,8 +841,7 @@ func (cfg *Cfg) loadSpecifiedConfigFile(configFile string, masterFile *ini.File)
 		}
 	}
 
-	configFiles = append(configFiles, configFile)
-	return nil
+	return configFile, nil
 }
 
 func (cfg *Cfg) loadConfiguration(args CommandLineArgs) (*ini.File, error) {
@@ -877,6 +910,29 @@ func (cfg *Cfg) loadConfiguration(args CommandLineArgs) (*ini.File, error) {
 	return parsedFile, err
 }
 
+// reloadConfiguration reads the configuration again from the files, the environment variables and the command line
+// arguments, the same way as loadConfiguration, but returns an error instead of exiting if a file cannot be read.
+func (cfg *Cfg) reloadConfiguration(args CommandLineArgs) (*ini.File, error) {
+	parsedFile, err := ini.Load(path.Join(HomePath, "conf/defaults.ini"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse defaults.ini: %w", err)
+	}
+	parsedFile.BlockMode = false
+
+	// the files and overrides are listed by LogConfigSources only once, so the ones of the reload are not recorded
+	commandLineProps := cfg.getCommandLineProperties(args.Args)
+	setCommandLineDefaultProperties(commandLineProps, parsedFile)
+	if _, err := cfg.mergeSpecifiedConfigFile(args.Config, parsedFile); err != nil {
+		return nil, err
+	}
+	overrideWithEnvVariables(parsedFile)
+	setCommandLineProperties(commandLineProps, parsedFile)
+	if err := expandConfig(parsedFile); err != nil {
+		return nil, err
+	}
+	return parsedFile, nil
+}
+
 func pathExists(path string) bool {
 	_, err := os.Stat(path)
 	if err == nil {
@@ -964,6 +1020,7 @@ func (cfg *Cfg) Load(args CommandLineArgs) error {
 	}
 
 	cfg.Raw = iniFile
+	cfg.loadArgs = &args
 
 	// Temporarily keep global, to make refactor in steps
 	Raw = cfg.Raw
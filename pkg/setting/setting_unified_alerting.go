@@ -3,6 +3,8 @@ package setting
 import (
 	"errors"
 	"fmt"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -48,6 +50,30 @@ const (
 	schedulereDefaultExecuteAlerts          = true
 	schedulerDefaultMaxAttempts             = 3
 	schedulerDefaultLegacyMinInterval       = 1
+	schedulerDefaultDrainTimeout            = 10 * time.Second
+	schedulerDefaultHeartbeatTTL            = 30 * time.Second
+	schedulerDefaultBackoffThreshold        = 5
+	schedulerDefaultBackoffMax              = time.Hour
+	schedulerDefaultEvaluationSaveInterval  = time.Minute
+	stateDefaultInstanceSaveBatchSize       = 100
+	stateDefaultMissingSeriesEvalsToResolve = 2
+	stateDefaultInstanceCleanupInterval     = time.Hour
+	stateDefaultInstanceCleanupBatchSize    = 1000
+	stateDefaultInstanceHistoryRetention    = 30 * 24 * time.Hour
+	stateDefaultNormalEvalsToResolve        = 1
+	stateDefaultResolvedGracePeriod         = 5 * time.Minute
+	stateDefaultInstancesPerRuleLimit       = 10000
+	stateDefaultMaxInstancesPerRuleLimit    = 100000
+	stateDefaultRestoredStateMaxAge         = 24 * time.Hour
+	stateDefaultRestorationBatchSize        = 1000
+	stateDefaultInstanceHeartbeatEvals      = 10
+	stateDefaultNotificationGroupWait       = 30 * time.Second
+	ruleCacheDefaultTTL                     = 5 * time.Second
+	ruleCacheDefaultMaxEntriesPerOrg        = 500
+	notifierDefaultMaxAttempts              = 3
+	notifierDefaultRetryInitialBackoff      = time.Second
+	notifierDefaultRetryMaxBackoff          = time.Minute
+	shadowModeDefaultTolerance              = 2 * time.Minute
 	screenshotsDefaultCapture               = false
 	screenshotsDefaultCaptureTimeout        = 10 * time.Second
 	screenshotsMaxCaptureTimeout            = 30 * time.Second
@@ -63,21 +89,60 @@ const (
 )
 
 type UnifiedAlertingSettings struct {
-	AdminConfigPollInterval        time.Duration
-	AlertmanagerConfigPollInterval time.Duration
-	HAListenAddr                   string
-	HAAdvertiseAddr                string
-	HAPeers                        []string
-	HAPeerTimeout                  time.Duration
-	HAGossipInterval               time.Duration
-	HAPushPullInterval             time.Duration
-	MaxAttempts                    int64
-	MinInterval                    time.Duration
-	EvaluationTimeout              time.Duration
-	ExecuteAlerts                  bool
-	DefaultConfiguration           string
-	Enabled                        *bool // determines whether unified alerting is enabled. If it is nil then user did not define it and therefore its value will be determined during migration. Services should not use it directly.
-	DisabledOrgs                   map[int64]struct{}
+	AdminConfigPollInterval         time.Duration
+	AlertmanagerConfigPollInterval  time.Duration
+	HAListenAddr                    string
+	HAAdvertiseAddr                 string
+	HAPeers                         []string
+	HAPeerTimeout                   time.Duration
+	HAGossipInterval                time.Duration
+	HAPushPullInterval              time.Duration
+	HAEvaluationCoordination        bool
+	HAEvaluationSharding            bool
+	HAInstanceID                    string
+	HAHeartbeatTTL                  time.Duration
+	MaxAttempts                     int64
+	MinInterval                     time.Duration
+	EvaluationTimeout               time.Duration
+	AllowedDatasources              []string // the UIDs and types of the data sources that alert rules may query. Empty allows all data sources.
+	DrainTimeout                    time.Duration
+	EvaluationBackoffThreshold      int64
+	EvaluationBackoffMax            time.Duration
+	EvaluationSaveInterval          time.Duration
+	EvaluationsPerMinuteLimit       int64           // the maximum number of evaluations of the rules of an organization per minute. Zero disables the limit.
+	MaxConcurrentEvaluations        int             // the maximum number of evaluations of rules that run at the same time. Zero disables the limit.
+	HealthStaleTicks                int64           // the number of base intervals without a tick after which the health check reports the scheduler as degraded.
+	EvaluationsPerMinuteOrgLimits   map[int64]int64 // overrides EvaluationsPerMinuteLimit for organizations. Zero disables the limit of the organization.
+	InstanceSaveBatchSize           int
+	MissingSeriesEvalsToResolve     int64
+	InstanceCleanupInterval         time.Duration
+	InstanceCleanupBatchSize        int
+	InstanceHistoryRetention        time.Duration
+	NormalEvalsToResolve            int64
+	ResolvedGracePeriod             time.Duration
+	InstancesPerRuleLimit           int64
+	MaxInstancesPerRuleLimit        int64
+	RestoredStateMaxAge             time.Duration
+	StateRestorationBatchSize       int
+	InstanceHeartbeatEvaluations    int64
+	RuleCacheTTL                    time.Duration // how long the alert rules read for the API are kept in memory. Zero disables the cache.
+	RuleCacheMaxEntriesPerOrg       int           // the maximum number of reads of alert rules kept in memory for each organization.
+	NotificationGroupWait           time.Duration
+	NotificationGroupBy             string
+	NotificationMaxAttempts         int
+	NotificationRetryInitialBackoff time.Duration
+	NotificationRetryMaxBackoff     time.Duration
+	ExecuteAlerts                   bool
+	ExternalAlertmanagers           []string // the URLs of the external Alertmanagers that the alerts of all organizations are forwarded to.
+	ExternalAlertmanagersOnly       bool     // determines whether the alerts are only forwarded to the external Alertmanagers, without notifying the contact points.
+	MigrateLegacyAlerts             bool     // determines whether the legacy dashboard alerts that have not been migrated yet are migrated to alert rules on startup.
+	ShadowMode                      bool     // determines whether the rules migrated from legacy alerts are only compared with the legacy alerts, without notifying.
+	ShadowModeTolerance             time.Duration
+	ProvisioningPollInterval        time.Duration // determines how often the alerting provisioning files are provisioned again. They are only provisioned on startup if it is 0.
+	ProvisioningPrune               bool          // determines whether the alert rules that were provisioned from files that no longer define them are deleted.
+	DefaultConfiguration            string
+	Enabled                         *bool // determines whether unified alerting is enabled. If it is nil then user did not define it and therefore its value will be determined during migration. Services should not use it directly.
+	DisabledOrgs                    map[int64]struct{}
 	// BaseInterval interval of time the scheduler updates the rules and evaluates rules.
 	// Only for internal use and not user configuration.
 	BaseInterval time.Duration
@@ -113,6 +178,8 @@ type UnifiedAlertingStateHistorySettings struct {
 	MultiPrimary          string
 	MultiSecondaries      []string
 	ExternalLabels        map[string]string
+	// AnnotatePending controls whether transitions to Pending are written as annotations.
+	AnnotatePending bool
 }
 
 // IsEnabled returns true if UnifiedAlertingSettings.Enabled is either nil or true.
@@ -131,7 +198,8 @@ func (u *UnifiedAlertingReservedLabelSettings) IsReservedLabelDisabled(label str
 // It returns a non-nil bool and a nil error when unified alerting is enabled either
 // because it has been enabled in the settings or by default. It returns nil and
 // a non-nil error both unified alerting and legacy alerting are enabled at the same time.
-func (cfg *Cfg) readUnifiedAlertingEnabledSetting(section *ini.Section) (*bool, error) {
+// It also returns whether legacy alerting is enabled, given whether it is enabled in the legacy settings.
+func (cfg *Cfg) readUnifiedAlertingEnabledSetting(section *ini.Section, legacyAlertingEnabled *bool) (*bool, *bool, error) {
 	// At present an invalid value is considered the same as no value. This means that a
 	// spelling mistake in the string "false" could enable unified alerting rather
 	// than disable it. This issue can be found here
@@ -142,55 +210,62 @@ func (cfg *Cfg) readUnifiedAlertingEnabledSetting(section *ini.Section) (*bool,
 			cfg.Logger.Warn("ngalert feature flag is deprecated: use unified alerting enabled setting instead")
 			// feature flag overrides the legacy alerting setting
 			legacyAlerting := false
-			AlertingEnabled = &legacyAlerting
+			legacyAlertingEnabled = &legacyAlerting
 			unifiedAlerting := true
-			return &unifiedAlerting, nil
+			return &unifiedAlerting, legacyAlertingEnabled, nil
 		}
 
 		// if legacy alerting has not been configured then enable unified alerting
-		if AlertingEnabled == nil {
+		if legacyAlertingEnabled == nil {
 			unifiedAlerting := true
-			return &unifiedAlerting, nil
+			return &unifiedAlerting, legacyAlertingEnabled, nil
 		}
 
 		// enable unified alerting and disable legacy alerting
 		legacyAlerting := false
-		AlertingEnabled = &legacyAlerting
+		legacyAlertingEnabled = &legacyAlerting
 		unifiedAlerting := true
-		return &unifiedAlerting, nil
+		return &unifiedAlerting, legacyAlertingEnabled, nil
 	}
 
 	unifiedAlerting, err := section.Key("enabled").Bool()
 	if err != nil {
 		// the value for unified alerting is invalid so disable all alerting
 		legacyAlerting := false
-		AlertingEnabled = &legacyAlerting
-		return nil, fmt.Errorf("invalid value %s, should be either true or false", section.Key("enabled"))
+		legacyAlertingEnabled = &legacyAlerting
+		return nil, legacyAlertingEnabled, fmt.Errorf("invalid value %s, should be either true or false", section.Key("enabled"))
 	}
 
 	// If both legacy and unified alerting are enabled then return an error
-	if AlertingEnabled != nil && *AlertingEnabled && unifiedAlerting {
-		return nil, errors.New("legacy and unified alerting cannot both be enabled at the same time, please disable one of them and restart Grafana")
+	if legacyAlertingEnabled != nil && *legacyAlertingEnabled && unifiedAlerting {
+		return nil, legacyAlertingEnabled, errors.New("legacy and unified alerting cannot both be enabled at the same time, please disable one of them and restart Grafana")
 	}
 
-	if AlertingEnabled == nil {
+	if legacyAlertingEnabled == nil {
 		legacyAlerting := !unifiedAlerting
-		AlertingEnabled = &legacyAlerting
+		legacyAlertingEnabled = &legacyAlerting
 	}
 
-	return &unifiedAlerting, nil
+	return &unifiedAlerting, legacyAlertingEnabled, nil
 }
 
 // ReadUnifiedAlertingSettings reads both the `unified_alerting` and `alerting` sections of the configuration while preferring configuration the `alerting` section.
 // It first reads the `unified_alerting` section, then looks for non-defaults on the `alerting` section and prefers those.
+// It also updates whether legacy alerting is enabled.
 func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
-	var err error
-	uaCfg := UnifiedAlertingSettings{}
-	ua := iniFile.Section("unified_alerting")
-	uaCfg.Enabled, err = cfg.readUnifiedAlertingEnabledSetting(ua)
+	enabled, legacyAlertingEnabled, err := cfg.readUnifiedAlertingEnabledSetting(iniFile.Section("unified_alerting"), AlertingEnabled)
+	AlertingEnabled = legacyAlertingEnabled
 	if err != nil {
 		return fmt.Errorf("failed to read unified alerting enabled setting: %w", err)
 	}
+	return cfg.readUnifiedAlertingSettings(iniFile, enabled)
+}
+
+// readUnifiedAlertingSettings reads the settings of unified alerting other than whether it is enabled, which is given.
+func (cfg *Cfg) readUnifiedAlertingSettings(iniFile *ini.File, enabled *bool) error {
+	var err error
+	uaCfg := UnifiedAlertingSettings{Enabled: enabled}
+	ua := iniFile.Section("unified_alerting")
 
 	uaCfg.DisabledOrgs = make(map[int64]struct{})
 	orgsStr := valueAsString(ua, "disabled_orgs", "")
@@ -233,6 +308,17 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		}
 	}
 
+	uaCfg.HAEvaluationCoordination = ua.Key("ha_evaluation_coordination").MustBool(false)
+	uaCfg.HAEvaluationSharding = ua.Key("ha_evaluation_sharding").MustBool(false)
+	uaCfg.HAInstanceID = ua.Key("ha_instance_id").MustString("")
+	if uaCfg.HAInstanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = util.GenerateShortUID()
+		}
+		uaCfg.HAInstanceID = hostname
+	}
+
 	// TODO load from ini file
 	uaCfg.DefaultConfiguration = alertmanagerDefaultConfiguration
 
@@ -247,6 +333,30 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		uaExecuteAlerts = legacyExecuteAlerts
 	}
 	uaCfg.ExecuteAlerts = uaExecuteAlerts
+	uaCfg.ExternalAlertmanagers = make([]string, 0)
+	for _, am := range strings.Split(ua.Key("external_alertmanagers").MustString(""), ",") {
+		if am = strings.TrimSpace(am); am != "" {
+			uaCfg.ExternalAlertmanagers = append(uaCfg.ExternalAlertmanagers, am)
+		}
+	}
+	uaCfg.ExternalAlertmanagersOnly = ua.Key("external_alertmanagers_only").MustBool(false)
+	uaCfg.MigrateLegacyAlerts = ua.Key("migrate_legacy_alerts").MustBool(false)
+	uaCfg.ShadowMode = ua.Key("shadow_mode").MustBool(false)
+	uaCfg.ShadowModeTolerance, err = gtime.ParseDuration(valueAsString(ua, "shadow_mode_tolerance", shadowModeDefaultTolerance.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'shadow_mode_tolerance' is not a valid duration: %w", err)
+	}
+	if uaCfg.ShadowModeTolerance < 0 {
+		return errors.New("value of setting 'shadow_mode_tolerance' cannot be negative")
+	}
+	uaCfg.ProvisioningPollInterval, err = gtime.ParseDuration(valueAsString(ua, "provisioning_poll_interval", "0s"))
+	if err != nil {
+		return fmt.Errorf("value of setting 'provisioning_poll_interval' is not a valid duration: %w", err)
+	}
+	if uaCfg.ProvisioningPollInterval < 0 {
+		return errors.New("value of setting 'provisioning_poll_interval' cannot be negative")
+	}
+	uaCfg.ProvisioningPrune = ua.Key("provisioning_prune").MustBool(false)
 
 	// if the unified alerting options equal the defaults, apply the respective legacy one
 	uaEvaluationTimeout, err := gtime.ParseDuration(valueAsString(ua, "evaluation_timeout", evaluatorDefaultEvaluationTimeout.String()))
@@ -269,6 +379,163 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	}
 	uaCfg.MaxAttempts = uaMaxAttempts
 
+	uaDrainTimeout, err := gtime.ParseDuration(valueAsString(ua, "drain_timeout", schedulerDefaultDrainTimeout.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'drain_timeout' is not a valid duration: %w", err)
+	}
+	if uaDrainTimeout < 0 {
+		return errors.New("value of setting 'drain_timeout' cannot be negative")
+	}
+	uaCfg.DrainTimeout = uaDrainTimeout
+
+	uaCfg.EvaluationBackoffThreshold = ua.Key("evaluation_backoff_threshold").MustInt64(schedulerDefaultBackoffThreshold)
+	if uaCfg.EvaluationBackoffThreshold < 0 {
+		return errors.New("value of setting 'evaluation_backoff_threshold' cannot be negative")
+	}
+	uaCfg.EvaluationBackoffMax, err = gtime.ParseDuration(valueAsString(ua, "evaluation_backoff_max", schedulerDefaultBackoffMax.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'evaluation_backoff_max' is not a valid duration: %w", err)
+	}
+	if uaCfg.EvaluationBackoffMax < 0 {
+		return errors.New("value of setting 'evaluation_backoff_max' cannot be negative")
+	}
+	uaCfg.EvaluationSaveInterval, err = gtime.ParseDuration(valueAsString(ua, "last_evaluation_save_interval", schedulerDefaultEvaluationSaveInterval.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'last_evaluation_save_interval' is not a valid duration: %w", err)
+	}
+	if uaCfg.EvaluationSaveInterval < 0 {
+		return errors.New("value of setting 'last_evaluation_save_interval' cannot be negative")
+	}
+	uaCfg.EvaluationsPerMinuteLimit = ua.Key("evaluations_per_minute_limit").MustInt64(0)
+	if uaCfg.EvaluationsPerMinuteLimit < 0 {
+		return errors.New("value of setting 'evaluations_per_minute_limit' cannot be negative")
+	}
+	uaCfg.MaxConcurrentEvaluations = ua.Key("max_concurrent_evaluations").MustInt(0)
+	if uaCfg.MaxConcurrentEvaluations < 0 {
+		return errors.New("value of setting 'max_concurrent_evaluations' cannot be negative")
+	}
+	uaCfg.HealthStaleTicks = ua.Key("health_stale_ticks").MustInt64(3)
+	if uaCfg.HealthStaleTicks < 1 {
+		return errors.New("value of setting 'health_stale_ticks' must be at least 1")
+	}
+	uaCfg.EvaluationsPerMinuteOrgLimits = make(map[int64]int64)
+	for _, pair := range util.SplitString(valueAsString(ua, "evaluations_per_minute_org_limits", "")) {
+		org, limit, ok := strings.Cut(pair, ":")
+		if !ok {
+			return fmt.Errorf("value of setting 'evaluations_per_minute_org_limits' must be a list of org_id:limit, got %q", pair)
+		}
+		orgID, err := strconv.ParseInt(org, 10, 64)
+		if err != nil {
+			return fmt.Errorf("value of setting 'evaluations_per_minute_org_limits' has an invalid organization ID %q: %w", org, err)
+		}
+		orgLimit, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil || orgLimit < 0 {
+			return fmt.Errorf("value of setting 'evaluations_per_minute_org_limits' has an invalid limit %q for organization %d", limit, orgID)
+		}
+		uaCfg.EvaluationsPerMinuteOrgLimits[orgID] = orgLimit
+	}
+	uaCfg.AllowedDatasources = util.SplitString(valueAsString(ua, "allowed_datasources", ""))
+	uaCfg.InstanceSaveBatchSize = ua.Key("instance_save_batch_size").MustInt(stateDefaultInstanceSaveBatchSize)
+	if uaCfg.InstanceSaveBatchSize < 1 {
+		return errors.New("value of setting 'instance_save_batch_size' must be greater than 0")
+	}
+	uaCfg.MissingSeriesEvalsToResolve = ua.Key("missing_series_evals_to_resolve").MustInt64(stateDefaultMissingSeriesEvalsToResolve)
+	if uaCfg.MissingSeriesEvalsToResolve < 1 {
+		return errors.New("value of setting 'missing_series_evals_to_resolve' must be greater than 0")
+	}
+	uaCfg.InstanceCleanupInterval, err = gtime.ParseDuration(valueAsString(ua, "instance_cleanup_interval", stateDefaultInstanceCleanupInterval.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'instance_cleanup_interval' is not a valid duration: %w", err)
+	}
+	if uaCfg.InstanceCleanupInterval < 0 {
+		return errors.New("value of setting 'instance_cleanup_interval' cannot be negative")
+	}
+	uaCfg.InstanceCleanupBatchSize = ua.Key("instance_cleanup_batch_size").MustInt(stateDefaultInstanceCleanupBatchSize)
+	if uaCfg.InstanceCleanupBatchSize < 1 {
+		return errors.New("value of setting 'instance_cleanup_batch_size' must be greater than 0")
+	}
+	uaCfg.InstanceHistoryRetention, err = gtime.ParseDuration(valueAsString(ua, "instance_history_retention", stateDefaultInstanceHistoryRetention.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'instance_history_retention' is not a valid duration: %w", err)
+	}
+	if uaCfg.InstanceHistoryRetention < 0 {
+		return errors.New("value of setting 'instance_history_retention' cannot be negative")
+	}
+	uaCfg.NormalEvalsToResolve = ua.Key("normal_evals_to_resolve").MustInt64(stateDefaultNormalEvalsToResolve)
+	if uaCfg.NormalEvalsToResolve < 1 {
+		return errors.New("value of setting 'normal_evals_to_resolve' must be greater than 0")
+	}
+	uaCfg.ResolvedGracePeriod, err = gtime.ParseDuration(valueAsString(ua, "resolved_grace_period", stateDefaultResolvedGracePeriod.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'resolved_grace_period' is not a valid duration: %w", err)
+	}
+	if uaCfg.ResolvedGracePeriod < 0 {
+		return errors.New("value of setting 'resolved_grace_period' cannot be negative")
+	}
+	uaCfg.InstancesPerRuleLimit = ua.Key("instances_per_rule_limit").MustInt64(stateDefaultInstancesPerRuleLimit)
+	if uaCfg.InstancesPerRuleLimit < 0 {
+		return errors.New("value of setting 'instances_per_rule_limit' cannot be negative")
+	}
+	uaCfg.MaxInstancesPerRuleLimit = ua.Key("max_instances_per_rule_limit").MustInt64(stateDefaultMaxInstancesPerRuleLimit)
+	if uaCfg.MaxInstancesPerRuleLimit < 0 {
+		return errors.New("value of setting 'max_instances_per_rule_limit' cannot be negative")
+	}
+	if uaCfg.MaxInstancesPerRuleLimit > 0 && uaCfg.InstancesPerRuleLimit > uaCfg.MaxInstancesPerRuleLimit {
+		return errors.New("value of setting 'instances_per_rule_limit' cannot be greater than the value of setting 'max_instances_per_rule_limit'")
+	}
+	uaCfg.RestoredStateMaxAge, err = gtime.ParseDuration(valueAsString(ua, "restored_state_max_age", stateDefaultRestoredStateMaxAge.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'restored_state_max_age' is not a valid duration: %w", err)
+	}
+	if uaCfg.RestoredStateMaxAge < 0 {
+		return errors.New("value of setting 'restored_state_max_age' cannot be negative")
+	}
+	uaCfg.StateRestorationBatchSize = ua.Key("state_restoration_batch_size").MustInt(stateDefaultRestorationBatchSize)
+	if uaCfg.StateRestorationBatchSize < 1 {
+		return errors.New("value of setting 'state_restoration_batch_size' must be greater than 0")
+	}
+	uaCfg.InstanceHeartbeatEvaluations = ua.Key("instance_heartbeat_evaluations").MustInt64(stateDefaultInstanceHeartbeatEvals)
+	if uaCfg.InstanceHeartbeatEvaluations < 0 {
+		return errors.New("value of setting 'instance_heartbeat_evaluations' cannot be negative")
+	}
+	uaCfg.RuleCacheTTL, err = gtime.ParseDuration(valueAsString(ua, "rule_cache_ttl", ruleCacheDefaultTTL.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'rule_cache_ttl' is not a valid duration: %w", err)
+	}
+	if uaCfg.RuleCacheTTL < 0 {
+		return errors.New("value of setting 'rule_cache_ttl' cannot be negative")
+	}
+	uaCfg.RuleCacheMaxEntriesPerOrg = ua.Key("rule_cache_max_entries_per_org").MustInt(ruleCacheDefaultMaxEntriesPerOrg)
+	if uaCfg.RuleCacheMaxEntriesPerOrg < 1 {
+		return errors.New("value of setting 'rule_cache_max_entries_per_org' must be greater than 0")
+	}
+	uaCfg.NotificationGroupWait, err = gtime.ParseDuration(valueAsString(ua, "notification_group_wait", stateDefaultNotificationGroupWait.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'notification_group_wait' is not a valid duration: %w", err)
+	}
+	if uaCfg.NotificationGroupWait < 0 {
+		return errors.New("value of setting 'notification_group_wait' cannot be negative")
+	}
+	uaCfg.NotificationGroupBy = valueAsString(ua, "notification_group_by", "")
+	uaCfg.NotificationMaxAttempts = ua.Key("notification_max_attempts").MustInt(notifierDefaultMaxAttempts)
+	if uaCfg.NotificationMaxAttempts < 1 {
+		return errors.New("value of setting 'notification_max_attempts' must be at least 1")
+	}
+	uaCfg.NotificationRetryInitialBackoff, err = gtime.ParseDuration(valueAsString(ua, "notification_retry_initial_backoff", notifierDefaultRetryInitialBackoff.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'notification_retry_initial_backoff' is not a valid duration: %w", err)
+	}
+	if uaCfg.NotificationRetryInitialBackoff <= 0 {
+		return errors.New("value of setting 'notification_retry_initial_backoff' must be positive")
+	}
+	uaCfg.NotificationRetryMaxBackoff, err = gtime.ParseDuration(valueAsString(ua, "notification_retry_max_backoff", notifierDefaultRetryMaxBackoff.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'notification_retry_max_backoff' is not a valid duration: %w", err)
+	}
+	if uaCfg.NotificationRetryMaxBackoff < uaCfg.NotificationRetryInitialBackoff {
+		return errors.New("value of setting 'notification_retry_max_backoff' cannot be less than the value of setting 'notification_retry_initial_backoff'")
+	}
+
 	uaCfg.BaseInterval = SchedulerBaseInterval
 
 	uaMinInterval, err := gtime.ParseDuration(valueAsString(ua, "min_interval", uaCfg.BaseInterval.String()))
@@ -292,10 +559,25 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	}
 	uaCfg.MinInterval = uaMinInterval
 
+	uaHeartbeatTTL, err := gtime.ParseDuration(valueAsString(ua, "ha_heartbeat_ttl", schedulerDefaultHeartbeatTTL.String()))
+	if err != nil {
+		return fmt.Errorf("value of setting 'ha_heartbeat_ttl' is not a valid duration: %w", err)
+	}
+	if uaHeartbeatTTL <= uaCfg.BaseInterval {
+		return fmt.Errorf("value of setting 'ha_heartbeat_ttl' should be greater than the base interval (%v)", uaCfg.BaseInterval)
+	}
+	uaCfg.HAHeartbeatTTL = uaHeartbeatTTL
+
 	uaCfg.DefaultRuleEvaluationInterval = DefaultRuleEvaluationInterval
 	if uaMinInterval > uaCfg.DefaultRuleEvaluationInterval {
 		uaCfg.DefaultRuleEvaluationInterval = uaMinInterval
 	}
+	// the instances that do not change are written every instance_heartbeat_evaluations evaluations, and must not get too
+	// old to be restored in between
+	if uaCfg.RestoredStateMaxAge > 0 && uaCfg.InstanceHeartbeatEvaluations > 1 &&
+		uaCfg.InstanceHeartbeatEvaluations > int64(uaCfg.RestoredStateMaxAge/uaCfg.DefaultRuleEvaluationInterval) {
+		return fmt.Errorf("value of setting 'instance_heartbeat_evaluations' times the default evaluation interval of the rules (%v) cannot exceed the value of setting 'restored_state_max_age'", uaCfg.DefaultRuleEvaluationInterval)
+	}
 
 	screenshots := iniFile.Section("unified_alerting.screenshots")
 	uaCfgScreenshots := uaCfg.Screenshots
@@ -335,6 +617,7 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		MultiPrimary:          stateHistory.Key("primary").MustString(""),
 		MultiSecondaries:      splitTrim(stateHistory.Key("secondaries").MustString(""), ","),
 		ExternalLabels:        stateHistoryLabels.KeysHash(),
+		AnnotatePending:       stateHistory.Key("annotate_pending").MustBool(false),
 	}
 	uaCfg.StateHistory = uaCfgStateHistory
 
@@ -342,6 +625,78 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 	return nil
 }
 
+// ReloadUnifiedAlertingSettings reads the configuration again from the files, environment variables and command line
+// arguments that Load read it from, and returns the settings of unified alerting that it defines now. It also returns
+// the keys of the alerting sections whose values are not the ones that Grafana was started with, as "section.key".
+// cfg is not changed.
+func (cfg *Cfg) ReloadUnifiedAlertingSettings() (UnifiedAlertingSettings, []string, error) {
+	if cfg.loadArgs == nil {
+		return UnifiedAlertingSettings{}, nil, errors.New("the configuration was not loaded from files")
+	}
+	iniFile, err := cfg.reloadConfiguration(*cfg.loadArgs)
+	if err != nil {
+		return UnifiedAlertingSettings{}, nil, err
+	}
+
+	// whether legacy alerting is enabled only changes on restart, so it is not updated
+	reloaded := &Cfg{Logger: cfg.Logger, IsFeatureToggleEnabled: cfg.IsFeatureToggleEnabled}
+	enabled, _, err := reloaded.readUnifiedAlertingEnabledSetting(iniFile.Section("unified_alerting"), AlertingEnabled)
+	if err != nil {
+		return UnifiedAlertingSettings{}, nil, fmt.Errorf("failed to read unified alerting enabled setting: %w", err)
+	}
+	if err := reloaded.readUnifiedAlertingSettings(iniFile, enabled); err != nil {
+		return UnifiedAlertingSettings{}, nil, err
+	}
+	return reloaded.UnifiedAlerting, changedAlertingKeys(cfg.Raw, iniFile), nil
+}
+
+// changedAlertingKeys returns the keys of the sections of unified and legacy alerting whose values differ between the
+// configurations, as "section.key" in ascending order.
+func changedAlertingKeys(before, after *ini.File) []string {
+	var changed []string
+	for _, name := range alertingSectionNames(before, after) {
+		beforeValues, afterValues := sectionValues(before, name), sectionValues(after, name)
+		for key, value := range afterValues {
+			if beforeValue, ok := beforeValues[key]; !ok || beforeValue != value {
+				changed = append(changed, name+"."+key)
+			}
+		}
+		for key := range beforeValues {
+			if _, ok := afterValues[key]; !ok {
+				changed = append(changed, name+"."+key)
+			}
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// alertingSectionNames returns the names of the sections of unified and legacy alerting that are in either file.
+func alertingSectionNames(files ...*ini.File) []string {
+	names := make(map[string]struct{})
+	for _, f := range files {
+		for _, name := range f.SectionStrings() {
+			if name == "alerting" || name == "unified_alerting" || strings.HasPrefix(name, "unified_alerting.") {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	return result
+}
+
+// sectionValues returns the values of the keys of the section, or nil if the file has no such section.
+func sectionValues(f *ini.File, name string) map[string]string {
+	section, err := f.GetSection(name)
+	if err != nil {
+		return nil
+	}
+	return section.KeysHash()
+}
+
 func GetAlertmanagerDefaultConfiguration() string {
 	return alertmanagerDefaultConfiguration
 }
@@ -2,6 +2,8 @@ package setting
 
 import (
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 	"time"
@@ -25,6 +27,7 @@ func TestCfg_ReadUnifiedAlertingSettings(t *testing.T) {
 		require.Len(t, cfg.UnifiedAlerting.HAPeers, 0)
 		require.Equal(t, 200*time.Millisecond, cfg.UnifiedAlerting.HAGossipInterval)
 		require.Equal(t, time.Minute, cfg.UnifiedAlerting.HAPushPullInterval)
+		require.Equal(t, int64(3), cfg.UnifiedAlerting.HealthStaleTicks)
 	}
 
 	// With peers set, it correctly parses them.
@@ -39,6 +42,59 @@ func TestCfg_ReadUnifiedAlertingSettings(t *testing.T) {
 		require.Len(t, cfg.UnifiedAlerting.HAPeers, 3)
 		require.ElementsMatch(t, []string{"hostname1:9090", "hostname2:9090", "hostname3:9090"}, cfg.UnifiedAlerting.HAPeers)
 	}
+
+	// With evaluation limits set, it correctly parses the limits of organizations.
+	{
+		require.Zero(t, cfg.UnifiedAlerting.EvaluationsPerMinuteLimit)
+		require.Empty(t, cfg.UnifiedAlerting.EvaluationsPerMinuteOrgLimits)
+		s := cfg.Raw.Section("unified_alerting")
+		s.Key("evaluations_per_minute_limit").SetValue("600")
+		s.Key("evaluations_per_minute_org_limits").SetValue("1:6000, 2:0")
+
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		require.Equal(t, int64(600), cfg.UnifiedAlerting.EvaluationsPerMinuteLimit)
+		require.Equal(t, map[int64]int64{1: 6000, 2: 0}, cfg.UnifiedAlerting.EvaluationsPerMinuteOrgLimits)
+
+		s.Key("evaluations_per_minute_org_limits").SetValue("1=6000")
+		require.Error(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		s.Key("evaluations_per_minute_org_limits").SetValue("1:-1")
+		require.Error(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		s.Key("evaluations_per_minute_org_limits").SetValue("")
+	}
+
+	// With allowed data sources set, it correctly parses them.
+	{
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		require.Empty(t, cfg.UnifiedAlerting.AllowedDatasources)
+		s := cfg.Raw.Section("unified_alerting")
+		s.Key("allowed_datasources").SetValue("prometheus, P8E80F9AEF21F6940")
+
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		require.Equal(t, []string{"prometheus", "P8E80F9AEF21F6940"}, cfg.UnifiedAlerting.AllowedDatasources)
+	}
+
+	// With the health stale ticks set, it rejects values below 1.
+	{
+		s := cfg.Raw.Section("unified_alerting")
+		s.Key("health_stale_ticks").SetValue("0")
+		require.ErrorContains(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw), "health_stale_ticks")
+		s.Key("health_stale_ticks").SetValue("5")
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		require.Equal(t, int64(5), cfg.UnifiedAlerting.HealthStaleTicks)
+	}
+
+	// With the instance heartbeat set, it rejects heartbeats that are longer than the maximum age of the restored state.
+	{
+		s := cfg.Raw.Section("unified_alerting")
+		s.Key("restored_state_max_age").SetValue("10m")
+		s.Key("instance_heartbeat_evaluations").SetValue("11")
+		require.ErrorContains(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw), "instance_heartbeat_evaluations")
+		s.Key("instance_heartbeat_evaluations").SetValue("10")
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+		s.Key("restored_state_max_age").SetValue("0")
+		s.Key("instance_heartbeat_evaluations").SetValue("1000")
+		require.NoError(t, cfg.ReadUnifiedAlertingSettings(cfg.Raw))
+	}
 }
 
 func TestUnifiedAlertingSettings(t *testing.T) {
@@ -266,3 +322,64 @@ func TestMinInterval(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadUnifiedAlertingSettings(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "custom.ini")
+	writeConfig := func(content string) {
+		require.NoError(t, os.WriteFile(configFile, []byte(content), 0600))
+	}
+	writeConfig(`
+[unified_alerting]
+max_concurrent_evaluations = 4
+ha_peers = localhost:9094
+`)
+	cfg := NewCfg()
+	require.NoError(t, cfg.Load(CommandLineArgs{HomePath: "../../", Config: configFile}))
+	require.Equal(t, 4, cfg.UnifiedAlerting.MaxConcurrentEvaluations)
+	legacyAlertingEnabled := AlertingEnabled
+	loadedFiles, loadedEnvOverrides := configFiles, appliedEnvOverrides
+
+	t.Run("should return the settings that the files define now", func(t *testing.T) {
+		writeConfig(`
+[unified_alerting]
+max_concurrent_evaluations = 2
+ha_peers = localhost:9095
+
+[alerting]
+max_attempts = 5
+`)
+		settings, changed, err := cfg.ReloadUnifiedAlertingSettings()
+		require.NoError(t, err)
+		require.Equal(t, 2, settings.MaxConcurrentEvaluations)
+		require.Equal(t, []string{"localhost:9095"}, settings.HAPeers)
+		require.EqualValues(t, 5, settings.MaxAttempts)
+		require.Equal(t, []string{"alerting.max_attempts", "unified_alerting.ha_peers", "unified_alerting.max_concurrent_evaluations"}, changed)
+
+		require.Equal(t, 4, cfg.UnifiedAlerting.MaxConcurrentEvaluations)
+	})
+
+	t.Run("should not change the global configuration", func(t *testing.T) {
+		t.Setenv("GF_UNIFIED_ALERTING_MAX_ATTEMPTS", "2")
+		settings, _, err := cfg.ReloadUnifiedAlertingSettings()
+		require.NoError(t, err)
+		require.EqualValues(t, 2, settings.MaxAttempts)
+
+		require.Same(t, legacyAlertingEnabled, AlertingEnabled)
+		require.Equal(t, loadedFiles, configFiles)
+		require.Equal(t, loadedEnvOverrides, appliedEnvOverrides)
+	})
+
+	t.Run("should fail if the settings are invalid", func(t *testing.T) {
+		writeConfig(`
+[unified_alerting]
+max_concurrent_evaluations = -1
+`)
+		_, _, err := cfg.ReloadUnifiedAlertingSettings()
+		require.ErrorContains(t, err, "max_concurrent_evaluations")
+	})
+
+	t.Run("should fail if the configuration was not loaded from files", func(t *testing.T) {
+		_, _, err := NewCfg().ReloadUnifiedAlertingSettings()
+		require.Error(t, err)
+	})
+}
@@ -889,7 +889,7 @@ func TestIntegrationAlertRuleCRUD(t *testing.T) {
 						Data:  []apimodels.AlertQuery{},
 					},
 				},
-				expectedMessage: "invalid rule specification at index [0]: invalid alert rule: no queries or expressions are found",
+				expectedMessage: "rules[0].grafana_alert.data: invalid alert rule: no queries or expressions are found",
 			},
 			{
 				desc:      "alert rule with empty title",
@@ -919,7 +919,7 @@ func TestIntegrationAlertRuleCRUD(t *testing.T) {
 						},
 					},
 				},
-				expectedMessage: "invalid rule specification at index [0]: alert rule title cannot be empty",
+				expectedMessage: "rules[0].grafana_alert.title: alert rule title cannot be empty",
 			},
 			{
 				desc:      "alert rule with too long name",
@@ -949,7 +949,7 @@ func TestIntegrationAlertRuleCRUD(t *testing.T) {
 						},
 					},
 				},
-				expectedMessage: "invalid rule specification at index [0]: alert rule title is too long. Max length is 190",
+				expectedMessage: "rules[0].grafana_alert.title: alert rule title is too long. Max length is 190",
 			},
 			{
 				desc:      "alert rule with too long rulegroup",
@@ -979,7 +979,7 @@ func TestIntegrationAlertRuleCRUD(t *testing.T) {
 						},
 					},
 				},
-				expectedMessage: "rule group name is too long. Max length is 190",
+				expectedMessage: "name: rule group name is too long. Max length is 190",
 			},
 			{
 				desc:      "alert rule with invalid interval",
@@ -1010,7 +1010,7 @@ func TestIntegrationAlertRuleCRUD(t *testing.T) {
 						},
 					},
 				},
-				expectedMessage: "rule evaluation interval (1 second) should be positive number that is multiple of the base interval of 10 seconds",
+				expectedMessage: "interval: rule evaluation interval (1 second) should be positive number that is multiple of the base interval of 10 seconds",
 			},
 			{
 				desc:      "alert rule with unknown datasource",
@@ -1040,7 +1040,7 @@ func TestIntegrationAlertRuleCRUD(t *testing.T) {
 						},
 					},
 				},
-				expectedMessage: "invalid rule specification at index [0]: failed to validate condition of alert rule AlwaysFiring: failed to build query 'A': data source not found",
+				expectedMessage: "rules[0].grafana_alert.condition: failed to validate condition of alert rule AlwaysFiring: failed to build query 'A': data source not found",
 			},
 			{
 				desc:      "alert rule with invalid condition",
@@ -1070,7 +1070,7 @@ func TestIntegrationAlertRuleCRUD(t *testing.T) {
 						},
 					},
 				},
-				expectedMessage: "invalid rule specification at index [0]: failed to validate condition of alert rule AlwaysFiring: condition B does not exist, must be one of [A]",
+				expectedMessage: "rules[0].grafana_alert.condition: failed to validate condition of alert rule AlwaysFiring: condition B does not exist, must be one of [A]",
 			},
 		}
 
@@ -1432,7 +1432,7 @@ func TestIntegrationAlertRuleCRUD(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, status)
 		var res map[string]interface{}
 		require.NoError(t, json.Unmarshal([]byte(body), &res))
-		require.Equal(t, fmt.Sprintf("rule [1] has UID %s that is already assigned to another rule at index 0", ruleUID), res["message"])
+		require.Equal(t, fmt.Sprintf("rules[1].grafana_alert.uid: rule [1] has UID %s that is already assigned to another rule at index 0", ruleUID), res["message"])
 
 		// let's make sure that rule definitions are not affected by the failed POST request.
 		u := fmt.Sprintf("http://grafana:password@%s/api/ruler/grafana/api/v1/rules/default", grafanaListedAddr)
@@ -1949,9 +1949,9 @@ func TestIntegrationQuota(t *testing.T) {
 			apiClient.UpdateAlertRuleOrgQuota(t, 1, limit)
 		})
 
-		// try to create an alert rule
+		// try to create an alert rule in another group, so that the existing rule is not deleted
 		rules := apimodels.PostableRuleGroupConfig{
-			Name:     "arulegroup",
+			Name:     "anotherrulegroup",
 			Interval: interval,
 			Rules: []apimodels.PostableExtendedRuleNode{
 				{
@@ -1980,7 +1980,10 @@ func TestIntegrationQuota(t *testing.T) {
 		assert.Equal(t, http.StatusForbidden, status)
 		var res map[string]interface{}
 		require.NoError(t, json.Unmarshal([]byte(body), &res))
-		require.Equal(t, "quota has been exceeded", res["message"])
+		require.Contains(t, res["message"], "quota has been exceeded")
+		require.Equal(t, "One more alert rule", res["rule"])
+		require.EqualValues(t, used, res["limit"])
+		require.EqualValues(t, used, res["usage"])
 	})
 
 	t.Run("when quota limit exceed updating existing rule should succeed", func(t *testing.T) {
@@ -211,7 +211,7 @@ func TestIntegrationPrometheusRules(t *testing.T) {
 		assert.Equal(t, 400, resp.StatusCode)
 		var res map[string]interface{}
 		require.NoError(t, json.Unmarshal(b, &res))
-		require.Equal(t, "invalid rule specification at index [0]: both annotations __dashboardUid__ and __panelId__ must be specified", res["message"])
+		require.Equal(t, "rules[0].annotations: both annotations __dashboardUid__ and __panelId__ must be specified", res["message"])
 	}
 
 	// Now, let's see how this looks like.
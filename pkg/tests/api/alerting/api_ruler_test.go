@@ -1,11 +1,13 @@
 package alerting
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +20,8 @@ import (
 	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgimpl"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/tests/testinfra"
 	"github.com/grafana/grafana/pkg/util"
@@ -317,6 +321,123 @@ func createRule(t *testing.T, client apiClient, folder string) {
 	require.JSONEq(t, `{"message":"rule group updated successfully"}`, body)
 }
 
+func TestIntegrationAlertRuleEndpointsRoleAccess(t *testing.T) {
+	testinfra.SQLiteIntegrationTest(t)
+
+	dir, path := testinfra.CreateGrafDir(t, testinfra.GrafanaOpts{
+		DisableLegacyAlerting: true,
+		EnableUnifiedAlerting: true,
+		DisableAnonymous:      true,
+		AppModeProduction:     true,
+	})
+	grafanaListedAddr, store := testinfra.StartGrafana(t, dir, path)
+
+	orgService, err := orgimpl.ProvideService(store, store.Cfg, quotatest.New(false, nil))
+	require.NoError(t, err)
+
+	editorID := createUser(t, store, user.CreateUserCommand{
+		DefaultOrgRole: string(org.RoleEditor),
+		Password:       "editor",
+		Login:          "editor",
+	})
+	createUser(t, store, user.CreateUserCommand{
+		DefaultOrgRole: string(org.RoleViewer),
+		Password:       "viewer",
+		Login:          "viewer",
+	})
+	newOrg, err := orgService.CreateWithMember(context.Background(), &org.CreateOrgCommand{Name: "another org", UserID: editorID})
+	require.NoError(t, err)
+	createUser(t, store, user.CreateUserCommand{
+		DefaultOrgRole: string(org.RoleEditor),
+		Password:       "editor-42",
+		Login:          "editor-42",
+		OrgID:          newOrg.ID,
+	})
+
+	editorClient := newAlertingApiClient(grafanaListedAddr, "editor", "editor")
+	editorClient.CreateFolder(t, "folder1", "folder1")
+	createRule(t, editorClient, "folder1")
+	ruleUID := editorClient.GetRulesGroup(t, "folder1", "arulegroup").Rules[0].GrafanaManagedAlert.UID
+
+	do := func(t *testing.T, login, password, method, path string) int {
+		t.Helper()
+		u := fmt.Sprintf("http://%s:%s@%s%s", login, password, grafanaListedAddr, path)
+		var body io.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader("{}")
+		}
+		req, err := http.NewRequest(method, u, body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		return resp.StatusCode
+	}
+
+	ruleURL := "/api/ruler/grafana/api/v1/rule/" + ruleUID
+
+	t.Run("viewer can read rules but not change them", func(t *testing.T) {
+		read := []string{
+			"/api/ruler/grafana/api/v1/rules",
+			"/api/ruler/grafana/api/v1/rules/folder1",
+			"/api/ruler/grafana/api/v1/rules/folder1/arulegroup",
+			ruleURL + "/instances",
+			ruleURL + "/eval",
+		}
+		for _, path := range read {
+			status := do(t, "viewer", "viewer", http.MethodGet, path)
+			require.Containsf(t, []int{http.StatusOK, http.StatusAccepted}, status, "GET %s", path)
+		}
+
+		write := []struct {
+			method string
+			path   string
+		}{
+			{http.MethodPost, "/api/ruler/grafana/api/v1/rules/folder1"},
+			{http.MethodDelete, "/api/ruler/grafana/api/v1/rules/folder1"},
+			{http.MethodDelete, "/api/ruler/grafana/api/v1/rules/folder1/arulegroup"},
+			{http.MethodPost, ruleURL + "/eval"},
+			{http.MethodPost, ruleURL + "/reset"},
+			{http.MethodPost, ruleURL + "/pause"},
+			{http.MethodPost, ruleURL + "/unpause"},
+		}
+		for _, w := range write {
+			require.Equalf(t, http.StatusForbidden, do(t, "viewer", "viewer", w.method, w.path), "%s %s", w.method, w.path)
+		}
+		require.False(t, editorClient.GetRulesGroup(t, "folder1", "arulegroup").Rules[0].GrafanaManagedAlert.IsPaused)
+	})
+
+	t.Run("editor can read and change rules", func(t *testing.T) {
+		require.Equal(t, http.StatusOK, do(t, "editor", "editor", http.MethodGet, ruleURL+"/instances"))
+		require.Equal(t, http.StatusOK, do(t, "editor", "editor", http.MethodGet, ruleURL+"/eval"))
+		require.Equal(t, http.StatusOK, do(t, "editor", "editor", http.MethodPost, ruleURL+"/pause"))
+		require.True(t, editorClient.GetRulesGroup(t, "folder1", "arulegroup").Rules[0].GrafanaManagedAlert.IsPaused)
+		require.Equal(t, http.StatusOK, do(t, "editor", "editor", http.MethodPost, ruleURL+"/unpause"))
+		require.False(t, editorClient.GetRulesGroup(t, "folder1", "arulegroup").Rules[0].GrafanaManagedAlert.IsPaused)
+	})
+
+	t.Run("user of another organization cannot see the rule", func(t *testing.T) {
+		for _, r := range []struct {
+			method string
+			path   string
+		}{
+			{http.MethodGet, "/api/ruler/grafana/api/v1/rules/folder1"},
+			{http.MethodGet, "/api/ruler/grafana/api/v1/rules/folder1/arulegroup"},
+			{http.MethodGet, ruleURL + "/instances"},
+			{http.MethodGet, ruleURL + "/eval"},
+			{http.MethodPost, ruleURL + "/eval"},
+			{http.MethodPost, ruleURL + "/reset"},
+			{http.MethodPost, ruleURL + "/pause"},
+			{http.MethodPost, ruleURL + "/unpause"},
+		} {
+			require.Equalf(t, http.StatusNotFound, do(t, "editor-42", "editor-42", r.method, r.path), "%s %s", r.method, r.path)
+		}
+
+		require.False(t, editorClient.GetRulesGroup(t, "folder1", "arulegroup").Rules[0].GrafanaManagedAlert.IsPaused)
+	})
+}
+
 func TestIntegrationAlertRuleConflictingTitle(t *testing.T) {
 	testinfra.SQLiteIntegrationTest(t)
 